@@ -6,9 +6,79 @@ package v1alpha1
 
 import (
 	"github.com/seatgeek/k8s-reconciler-generic/apiobjects"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// MaxSyncHistory is the number of certificate writes kept in status.syncHistory
+const MaxSyncHistory = 10
+
+// SuspendMode controls how a suspended FastlyCertificateSync is treated.
+type SuspendMode string
+
+const (
+	// SuspendModeFull skips reconciliation of a suspended resource entirely
+	SuspendModeFull SuspendMode = "Full"
+	// SuspendModeObserveOnly keeps observing a suspended resource and updating its status, without making changes
+	SuspendModeObserveOnly SuspendMode = "ObserveOnly"
+)
+
+// PrivateKeyManagement controls who uploads the private key of a FastlyCertificateSync to Fastly.
+type PrivateKeyManagement string
+
+const (
+	// PrivateKeyManagementOperator has the operator read the private key from the Certificate's secret and upload it
+	PrivateKeyManagementOperator PrivateKeyManagement = "Operator"
+	// PrivateKeyManagementExternal has the private key uploaded to Fastly by someone else, the operator never reads it
+	PrivateKeyManagementExternal PrivateKeyManagement = "External"
+)
+
+// CertificateMatchStrategy controls how the certificate in Fastly that a FastlyCertificateSync syncs to is found.
+type CertificateMatchStrategy string
+
+const (
+	// CertificateMatchStrategyName matches the certificate by the name the operator gives it
+	CertificateMatchStrategyName CertificateMatchStrategy = "Name"
+	// CertificateMatchStrategySerialNumber matches the certificate holding the serial number of the local
+	// certificate, or else the certificate the operator last synced, which is updated once the local one is renewed
+	CertificateMatchStrategySerialNumber CertificateMatchStrategy = "SerialNumber"
+	// CertificateMatchStrategyFastlyID matches the certificate the operator last synced by its ID, and by name only
+	// until a certificate was synced
+	CertificateMatchStrategyFastlyID CertificateMatchStrategy = "FastlyID"
+)
+
+// DeletionPolicy controls what happens to the certificate in Fastly once its FastlyCertificateSync is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain leaves the certificate and its TLS activations in Fastly
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyDelete deletes the TLS activations of the certificate, and then the certificate itself
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+)
+
+// ActivationDeletionPolicy controls what happens to the TLS activations still referencing a Fastly certificate that
+// the operator is about to delete.
+type ActivationDeletionPolicy string
+
+const (
+	// ActivationDeletionPolicyCascade deletes the TLS activations, and then the certificate
+	ActivationDeletionPolicyCascade ActivationDeletionPolicy = "Cascade"
+	// ActivationDeletionPolicyRefuse leaves the certificate and its TLS activations in place, reporting it in use
+	ActivationDeletionPolicyRefuse ActivationDeletionPolicy = "Refuse"
+)
+
+// TLSActivationOrder controls the order in which the certificate is activated on its TLS configurations.
+type TLSActivationOrder string
+
+const (
+	// TLSActivationOrderParallel activates the certificate on every TLS configuration at once
+	TLSActivationOrderParallel TLSActivationOrder = "Parallel"
+	// TLSActivationOrderOrdered activates the certificate on one TLS configuration after the other, in the order they
+	// are listed, stopping at the first whose activations fail
+	TLSActivationOrderOrdered TLSActivationOrder = "Ordered"
+)
+
 // FastlyCertificateSyncSpec defines the desired state of FastlyCertificateSync.
 type FastlyCertificateSyncSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -17,11 +87,443 @@ type FastlyCertificateSyncSpec struct {
 	// Reconciliation of individual resources may be suspended by setting this flag.
 	Suspend bool `json:"suspend,omitempty" yaml:"suspend,omitempty"`
 
+	// How a suspended resource is treated. Full skips reconciliation entirely, while ObserveOnly keeps observing
+	// Fastly and reporting drift in status, without ever making changes. Defaults to Full.
+	// +kubebuilder:validation:Enum=Full;ObserveOnly
+	// +optional
+	SuspendMode SuspendMode `json:"suspendMode,omitempty" yaml:"suspendMode,omitempty"`
+
 	// The name of the Certificate resource to sync
 	CertificateName string `json:"certificateName,omitempty" yaml:"certificateName,omitempty"`
 
+	// A reference to the Certificate resource to sync, which may live in another namespace.
+	// Cross-namespace references must be permitted by a ReferenceGrant in the Certificate's namespace, or by the
+	// operator's allowlist. Mutually exclusive with certificateName.
+	// +optional
+	CertificateRef *CertificateRef `json:"certificateRef,omitempty" yaml:"certificateRef,omitempty"`
+
 	// The list of TLS configuration IDs to sync
 	TLSConfigurationIds []string `json:"tlsConfigurationIds,omitempty" yaml:"tlsConfigurationIds,omitempty"`
+
+	// Selects TLS configurations to sync by their attributes, in addition to tlsConfigurationIds. Matching
+	// configurations are discovered whenever Fastly is observed, so configurations added later are activated too.
+	// Not supported with accounts.
+	// +optional
+	TLSConfigurationSelector *TLSConfigurationSelector `json:"tlsConfigurationSelector,omitempty" yaml:"tlsConfigurationSelector,omitempty"`
+
+	// The order in which TLS activations are created, and moved to a rotated certificate. Parallel activates on every
+	// TLS configuration at once, while Ordered activates on one TLS configuration after the other, in the order of
+	// tlsConfigurationIds (or of the account's), followed by those selected, and holds back the later configurations
+	// as soon as activations on one fail. List lower-risk configurations first to catch problems before they reach
+	// high-traffic ones. Defaults to Parallel.
+	// +kubebuilder:validation:Enum=Parallel;Ordered
+	// +optional
+	TLSActivationOrder TLSActivationOrder `json:"tlsActivationOrder,omitempty" yaml:"tlsActivationOrder,omitempty"`
+
+	// The domains to activate the certificate on, each of which must be covered by one of the certificate's DNS
+	// names. When unset, the certificate is activated on all of its domains.
+	// +optional
+	Domains []string `json:"domains,omitempty" yaml:"domains,omitempty"`
+
+	// The ID of the Fastly service serving the certificate's domains. When set, the ServiceDomainMissing condition
+	// reports DNS names of the certificate that are not domains of the service's active version. Not supported with
+	// accounts.
+	// +optional
+	ServiceID string `json:"serviceId,omitempty" yaml:"serviceId,omitempty"`
+
+	// The Fastly accounts to sync the certificate and its TLS activations to, each with its own TLS configuration
+	// IDs. When set, the certificate is synced to every account instead of the one the operator uses for this
+	// namespace. Mutually exclusive with tlsConfigurationIds.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Accounts []FastlyAccount `json:"accounts,omitempty" yaml:"accounts,omitempty"`
+
+	// Takes over a matching Fastly certificate that was not created by the operator. Without it, such certificates
+	// are never overwritten and the CertificateReady condition reports NeedsAdoption instead.
+	// +optional
+	AdoptExisting bool `json:"adoptExisting,omitempty" yaml:"adoptExisting,omitempty"`
+
+	// How the certificate in Fastly is matched. Name matches the name the operator gives the certificate, which
+	// resources reusing Certificate names may share. SerialNumber matches the serial number of the local certificate,
+	// and FastlyID the ID of the certificate last synced, neither of which depends on names. Defaults to Name.
+	// +kubebuilder:validation:Enum=Name;SerialNumber;FastlyID
+	// +optional
+	MatchStrategy CertificateMatchStrategy `json:"matchStrategy,omitempty" yaml:"matchStrategy,omitempty"`
+
+	// The name given to the certificate and private key created in Fastly, in place of the name of the Certificate
+	// and its secret, e.g. {labels.team}-{name}. Literal characters are limited to letters, digits, '.', '_' and '-',
+	// and {namespace}, {name} and {labels.<key>} are replaced by the namespace and name of the source object and the
+	// labels of this resource. The operator's name prefix, cluster name and namespace are still prepended as
+	// configured.
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9._-]|\{(namespace|name|labels\.[A-Za-z0-9./_-]+)\})+$`
+	// +optional
+	NameTemplate string `json:"nameTemplate,omitempty" yaml:"nameTemplate,omitempty"`
+
+	// When set, the operator creates and owns the Certificate resource from this template instead of requiring a
+	// pre-existing one. The Certificate is named after this FastlyCertificateSync.
+	// +optional
+	CertificateTemplate *CertificateTemplate `json:"certificateTemplate,omitempty" yaml:"certificateTemplate,omitempty"`
+
+	// Overrides the keys read from the Certificate's secret, for issuers that don't use the standard kubernetes.io/tls keys
+	// +optional
+	SecretKeys *SecretKeys `json:"secretKeys,omitempty" yaml:"secretKeys,omitempty"`
+
+	// Who uploads the private key to Fastly. With External, the operator never reads the private key from the
+	// Certificate's secret, it only verifies that a matching key already exists in Fastly and manages the certificate
+	// and TLS activations. Defaults to Operator.
+	// +kubebuilder:validation:Enum=Operator;External
+	// +optional
+	PrivateKeyManagement PrivateKeyManagement `json:"privateKeyManagement,omitempty" yaml:"privateKeyManagement,omitempty"`
+
+	// The SHA1 of the public key of an externally managed private key, as reported by Fastly. When unset, it is
+	// derived from the public key of the certificate. Only used with privateKeyManagement External.
+	// +kubebuilder:validation:Pattern=`^[0-9a-f]{40}$`
+	// +optional
+	PrivateKeyPublicKeySHA1 string `json:"privateKeyPublicKeySHA1,omitempty" yaml:"privateKeyPublicKeySHA1,omitempty"`
+
+	// How soon the operator checks Fastly for drift again once the certificate is in sync, e.g. 15m for critical
+	// certificates or 4h for others. Overrides the operator's drift check interval.
+	// +optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty" yaml:"resyncInterval,omitempty"`
+
+	// What happens to the certificate in Fastly once this resource is deleted. Retain leaves it in place, while Delete
+	// deletes its TLS activations and the certificate, provided the operator created or adopted it and no other
+	// resource syncs to it. Private keys are left to the sweep of unused keys. Defaults to Retain.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty" yaml:"deletionPolicy,omitempty"`
+
+	// What happens to the TLS activations still referencing a Fastly certificate the operator is about to delete, on
+	// deletion of this resource, once the grace period of a previous certificate is over, or on a rollback. Cascade
+	// deletes them along with the certificate, while Refuse leaves both in place and reports the CertificateInUse
+	// condition until they are gone. Defaults to Cascade.
+	// +kubebuilder:validation:Enum=Cascade;Refuse
+	// +optional
+	ActivationDeletionPolicy ActivationDeletionPolicy `json:"activationDeletionPolicy,omitempty" yaml:"activationDeletionPolicy,omitempty"`
+
+	// When set, a renewed certificate is uploaded as a new Fastly certificate rather than replacing the one in place.
+	// The previous certificate stays in Fastly along with its private key, renamed with a -previous suffix, for this
+	// long after the rotation, so that its TLS activations can be switched back to it right away. Not supported with
+	// accounts.
+	// +optional
+	PreviousCertificateGracePeriod *metav1.Duration `json:"previousCertificateGracePeriod,omitempty" yaml:"previousCertificateGracePeriod,omitempty"`
+
+	// The serial number of the certificate in status.previousCertificate to serve again in place of the current one,
+	// such as after a bad certificate was pushed. The rollback is made once, and holds until the source Certificate
+	// is renewed. Not supported with accounts.
+	// +optional
+	RollbackToSerial string `json:"rollbackToSerial,omitempty" yaml:"rollbackToSerial,omitempty"`
+
+	// The partition of the operator deployment that reconciles this resource, for clusters running several
+	// deployments each started with -partition. The deployment currently reconciling the resource hands it over by
+	// setting the platform.seatgeek.io/partition annotation to this value. When unset, the annotation alone decides.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Partition string `json:"partition,omitempty" yaml:"partition,omitempty"`
+}
+
+// TLSConfigurationSelector selects Fastly TLS configurations by their attributes. Configurations must match every
+// field that is set.
+type TLSConfigurationSelector struct {
+	// A regular expression that the name of the configuration must match
+	// +optional
+	NamePattern string `json:"namePattern,omitempty" yaml:"namePattern,omitempty"`
+
+	// Whether the configuration must, or must not, be for Platform TLS (bulk) certificates
+	// +optional
+	Bulk *bool `json:"bulk,omitempty" yaml:"bulk,omitempty"`
+}
+
+// FastlyAccount is a Fastly account that the certificate is synced to.
+type FastlyAccount struct {
+	// The name of the account, used to report its status
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name" yaml:"name"`
+
+	// A reference to a secret in the namespace of the FastlyCertificateSync holding the API token of the account.
+	// When unset, the Fastly token the operator uses for this namespace is used.
+	// +optional
+	TokenSecretRef *FastlyTokenSecretRef `json:"tokenSecretRef,omitempty" yaml:"tokenSecretRef,omitempty"`
+
+	// The list of TLS configuration IDs to sync in this account
+	TLSConfigurationIds []string `json:"tlsConfigurationIds,omitempty" yaml:"tlsConfigurationIds,omitempty"`
+}
+
+// FastlyTokenSecretRef references the entry of a secret holding a Fastly API token.
+type FastlyTokenSecretRef struct {
+	// The name of the secret
+	Name string `json:"name" yaml:"name"`
+
+	// The key holding the API token, defaults to the key the operator reads its own token secrets from
+	// +optional
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+// SecretKeys names the entries of the Certificate's secret that hold the TLS material.
+type SecretKeys struct {
+	// The key holding the PEM encoded certificate, defaults to tls.crt
+	// +optional
+	Certificate string `json:"certificate,omitempty" yaml:"certificate,omitempty"`
+
+	// The key holding the PEM encoded private key, defaults to tls.key
+	// +optional
+	PrivateKey string `json:"privateKey,omitempty" yaml:"privateKey,omitempty"`
+
+	// The key holding the PEM encoded CA certificate, defaults to ca.crt
+	// +optional
+	CA string `json:"ca,omitempty" yaml:"ca,omitempty"`
+
+	// The key holding a PKCS#12 keystore, e.g. keystore.p12. When set, the certificate and private key are extracted
+	// from the keystore instead of the certificate and privateKey keys.
+	// +optional
+	PKCS12 string `json:"pkcs12,omitempty" yaml:"pkcs12,omitempty"`
+
+	// The key holding the password of the PKCS#12 keystore, the keystore is assumed to have an empty password when unset
+	// +optional
+	PKCS12Password string `json:"pkcs12Password,omitempty" yaml:"pkcs12Password,omitempty"`
+}
+
+// CertificateRef references a cert-manager Certificate, optionally in another namespace.
+type CertificateRef struct {
+	// The name of the Certificate
+	Name string `json:"name" yaml:"name"`
+
+	// The namespace of the Certificate, defaults to the namespace of the FastlyCertificateSync
+	// +optional
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// CertificateTemplate describes the cert-manager Certificate to create on behalf of a FastlyCertificateSync.
+type CertificateTemplate struct {
+	// The issuer used to sign the certificate
+	IssuerRef CertificateIssuerRef `json:"issuerRef" yaml:"issuerRef"`
+
+	// The DNS names to include in the certificate
+	// +kubebuilder:validation:MinItems=1
+	DNSNames []string `json:"dnsNames" yaml:"dnsNames"`
+
+	// The private key algorithm of the certificate, defaults to cert-manager's own default
+	// +kubebuilder:validation:Enum=RSA;ECDSA;Ed25519
+	// +optional
+	PrivateKeyAlgorithm string `json:"privateKeyAlgorithm,omitempty" yaml:"privateKeyAlgorithm,omitempty"`
+
+	// The private key size in bits (RSA) or curve size (ECDSA), defaults to cert-manager's own default
+	// +optional
+	PrivateKeySize int `json:"privateKeySize,omitempty" yaml:"privateKeySize,omitempty"`
+}
+
+// CertificateIssuerRef references the cert-manager issuer of a templated certificate.
+type CertificateIssuerRef struct {
+	// The name of the issuer
+	Name string `json:"name" yaml:"name"`
+
+	// The kind of the issuer, e.g. Issuer or ClusterIssuer
+	// +optional
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+
+	// The group of the issuer, defaults to cert-manager.io
+	// +optional
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+}
+
+// TLSActivationState is the outcome of an attempt to activate the certificate for a domain.
+type TLSActivationState string
+
+const (
+	// TLSActivationStateCreated indicates that the TLS activation was created in Fastly
+	TLSActivationStateCreated TLSActivationState = "Created"
+	// TLSActivationStateFailed indicates that Fastly rejected the TLS activation
+	TLSActivationStateFailed TLSActivationState = "Failed"
+)
+
+// TLSActivationResult reports the outcome of activating the certificate for a single domain and TLS configuration.
+type TLSActivationResult struct {
+	// The domain the certificate was activated for
+	Domain string `json:"domain" yaml:"domain"`
+
+	// The ID of the Fastly TLS configuration the certificate was activated on
+	ConfigurationID string `json:"configurationId" yaml:"configurationId"`
+
+	// Whether the activation was created or failed
+	State TLSActivationState `json:"state" yaml:"state"`
+
+	// The error returned by Fastly when the activation failed
+	// +optional
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// When the activation was last attempted
+	LastAttemptTime metav1.Time `json:"lastAttemptTime" yaml:"lastAttemptTime"`
+
+	// The name of the account the activation was attempted in, when syncing to multiple accounts
+	// +optional
+	Account string `json:"account,omitempty" yaml:"account,omitempty"`
+
+	// The ID of the activation Fastly created
+	// +optional
+	ActivationID string `json:"activationId,omitempty" yaml:"activationId,omitempty"`
+}
+
+// FastlyError describes the last failed attempt to sync the certificate to Fastly, with the detail returned by
+// Fastly so that rejected requests can be diagnosed without access to the operator's logs.
+type FastlyError struct {
+	// The Fastly API operation that failed, e.g. CreateCustomTLSCertificate
+	// +optional
+	Operation string `json:"operation,omitempty" yaml:"operation,omitempty"`
+
+	// The HTTP status code returned by Fastly
+	// +optional
+	HTTPStatus int `json:"httpStatus,omitempty" yaml:"httpStatus,omitempty"`
+
+	// The title of the first problem returned by Fastly for a rejected request, e.g. Bad Request
+	// +optional
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+
+	// The error detail returned by Fastly
+	// +optional
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+
+	// The full error message
+	Message string `json:"message" yaml:"message"`
+
+	// When the error occurred
+	Time metav1.Time `json:"time" yaml:"time"`
+}
+
+// FastlyAccountStatus reports the sync state of the certificate in one of the accounts in spec.accounts.
+type FastlyAccountStatus struct {
+	// The name of the account
+	Name string `json:"name" yaml:"name"`
+
+	// Whether the certificate and its TLS activations are fully in sync in this account
+	Ready bool `json:"ready" yaml:"ready"`
+
+	// The ID of the certificate in this account
+	// +optional
+	CertificateID string `json:"certificateId,omitempty" yaml:"certificateId,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+}
+
+// TLSConfigurationStatus reports the TLS activations of the certificate on one of the TLS configurations it is
+// activated on.
+type TLSConfigurationStatus struct {
+	// The ID of the Fastly TLS configuration
+	ID string `json:"id" yaml:"id"`
+
+	// The number of domains the certificate is activated for on this configuration
+	ActivatedDomains int `json:"activatedDomains" yaml:"activatedDomains"`
+
+	// The domains the certificate is still to be activated for on this configuration
+	// +optional
+	MissingDomains []string `json:"missingDomains,omitempty" yaml:"missingDomains,omitempty"`
+}
+
+// FastlyCertificateStatus describes the certificate in Fastly matching the resource, as last observed, so that it can
+// be compared to the local certificate without access to Fastly.
+type FastlyCertificateStatus struct {
+	// The serial number of the certificate in Fastly
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty" yaml:"serialNumber,omitempty"`
+
+	// When the certificate in Fastly expires
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty" yaml:"notAfter,omitempty"`
+
+	// The issuer of the certificate in Fastly
+	// +optional
+	Issuer string `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+
+	// The number of subject alternative names of the certificate in Fastly
+	SANCount int `json:"sanCount" yaml:"sanCount"`
+}
+
+// DebugStatus is a snapshot of the state of Fastly the operator last observed for the resource, reported with
+// -status-debug so that it can be diagnosed without raising the log verbosity.
+type DebugStatus struct {
+	// Whether the private key was found in Fastly
+	PrivateKeyUploaded bool `json:"privateKeyUploaded" yaml:"privateKeyUploaded"`
+
+	// The ID of the private key found in Fastly
+	// +optional
+	PrivateKeyID string `json:"privateKeyId,omitempty" yaml:"privateKeyId,omitempty"`
+
+	// How the certificate in Fastly compares to the local one: Synced, Stale or Missing
+	// +optional
+	CertificateStatus string `json:"certificateStatus,omitempty" yaml:"certificateStatus,omitempty"`
+
+	// The ID of the matching certificate in Fastly
+	// +optional
+	FastlyCertificateID string `json:"fastlyCertificateId,omitempty" yaml:"fastlyCertificateId,omitempty"`
+
+	// The serial number of the local certificate
+	// +optional
+	LocalSerialNumber string `json:"localSerialNumber,omitempty" yaml:"localSerialNumber,omitempty"`
+
+	// Whether the snapshot comes from a quick drift check, which doesn't observe TLS activations
+	// +optional
+	QuickDriftCheck bool `json:"quickDriftCheck,omitempty" yaml:"quickDriftCheck,omitempty"`
+
+	// The TLS activations still to be created
+	// +optional
+	MissingTLSActivations []DebugTLSActivation `json:"missingTLSActivations,omitempty" yaml:"missingTLSActivations,omitempty"`
+
+	// The IDs of the TLS activations still to be deleted
+	// +optional
+	ExtraTLSActivationIDs []string `json:"extraTLSActivationIds,omitempty" yaml:"extraTLSActivationIds,omitempty"`
+
+	// The write operations to Fastly the observed state requires
+	// +optional
+	PendingChanges []string `json:"pendingChanges,omitempty" yaml:"pendingChanges,omitempty"`
+}
+
+// DebugTLSActivation is a TLS activation still to be created.
+type DebugTLSActivation struct {
+	// The domain to activate the certificate for
+	Domain string `json:"domain" yaml:"domain"`
+
+	// The ID of the Fastly TLS configuration to activate the certificate on
+	ConfigurationID string `json:"configurationId" yaml:"configurationId"`
+}
+
+// SyncHistoryEntry records a write of the certificate to Fastly.
+type SyncHistoryEntry struct {
+	// The serial number of the certificate written
+	SerialNumber string `json:"serialNumber" yaml:"serialNumber"`
+
+	// When the certificate was written
+	Time metav1.Time `json:"time" yaml:"time"`
+}
+
+// PreviousCertificateStatus tracks the certificate kept in Fastly after a rotation with
+// spec.previousCertificateGracePeriod.
+type PreviousCertificateStatus struct {
+	// The ID of the previous certificate in Fastly
+	CertificateID string `json:"certificateId" yaml:"certificateId"`
+
+	// The serial number of the previous certificate
+	SerialNumber string `json:"serialNumber,omitempty" yaml:"serialNumber,omitempty"`
+
+	// When the previous certificate is deleted from Fastly
+	RetainedUntil metav1.Time `json:"retainedUntil" yaml:"retainedUntil"`
+}
+
+// RollbackStatus tracks the rollback made for spec.rollbackToSerial.
+type RollbackStatus struct {
+	// The serial number of the certificate rolled back to
+	SerialNumber string `json:"serialNumber" yaml:"serialNumber"`
+
+	// The serial number of the source Certificate at the time of the rollback, which is not synced to Fastly until
+	// the Certificate is renewed
+	FromSerialNumber string `json:"fromSerialNumber,omitempty" yaml:"fromSerialNumber,omitempty"`
+
+	// The ID of the Fastly certificate rolled back to
+	CertificateID string `json:"certificateId" yaml:"certificateId"`
+
+	// When the rollback was made
+	Time metav1.Time `json:"time" yaml:"time"`
 }
 
 // FastlyCertificateSyncStatus defines the observed state of FastlyCertificateSync.
@@ -32,11 +534,91 @@ type FastlyCertificateSyncStatus struct {
 
 	Ready      bool               `json:"ready" yaml:"ready"`
 	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+
+	// ConsecutiveFailures counts the Fastly sync attempts that failed in a row, it is reset by the next successful sync
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty" yaml:"consecutiveFailures,omitempty"`
+	// NextRetryTime is when the operator will next retry a failed Fastly sync
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty" yaml:"nextRetryTime,omitempty"`
+
+	// TLSActivationResults reports the outcome of each activation attempted since the TLS activations were last
+	// fully in sync, so that a single failing domain doesn't obscure the others
+	TLSActivationResults []TLSActivationResult `json:"tlsActivationResults,omitempty" yaml:"tlsActivationResults,omitempty"`
+
+	// PrivateKeyPublicKeySHA1 is the SHA1 of the public key that private keys in Fastly were last matched against
+	PrivateKeyPublicKeySHA1 string `json:"privateKeyPublicKeySHA1,omitempty" yaml:"privateKeyPublicKeySHA1,omitempty"`
+
+	// PrivateKeyPublicKeySHA256 is the SHA256 of the DER encoded public key, reported alongside the SHA1 for
+	// compliance. Fastly only reports the SHA1, so private keys are never matched by it.
+	PrivateKeyPublicKeySHA256 string `json:"privateKeyPublicKeySHA256,omitempty" yaml:"privateKeyPublicKeySHA256,omitempty"`
+
+	// CertificateID is the ID of the Fastly certificate the operator created or adopted for this resource. Other
+	// matching certificates are only updated with spec.adoptExisting.
+	CertificateID string `json:"certificateId,omitempty" yaml:"certificateId,omitempty"`
+
+	// FastlyCertificate is the certificate in Fastly matching this resource, as last observed. It isn't reported for
+	// resources syncing to several accounts.
+	FastlyCertificate *FastlyCertificateStatus `json:"fastlyCertificate,omitempty" yaml:"fastlyCertificate,omitempty"`
+
+	// PreviousCertificate is the certificate replaced by the last rotation, kept in Fastly for
+	// spec.previousCertificateGracePeriod
+	PreviousCertificate *PreviousCertificateStatus `json:"previousCertificate,omitempty" yaml:"previousCertificate,omitempty"`
+
+	// Rollback is the rollback made for spec.rollbackToSerial, it is cleared once spec.rollbackToSerial is unset
+	Rollback *RollbackStatus `json:"rollback,omitempty" yaml:"rollback,omitempty"`
+
+	// StaleSince is when the certificate in Fastly was first observed to be stale or missing, it is cleared once the
+	// certificate is in sync
+	StaleSince *metav1.Time `json:"staleSince,omitempty" yaml:"staleSince,omitempty"`
+
+	// LastWrittenSerial is the serial number of the certificate this operator last wrote to Fastly, and LastWriteTime
+	// when it did. A different serial in Fastly means another writer, such as an operator in another cluster, has
+	// overwritten the certificate since.
+	LastWrittenSerial string       `json:"lastWrittenSerial,omitempty" yaml:"lastWrittenSerial,omitempty"`
+	LastWriteTime     *metav1.Time `json:"lastWriteTime,omitempty" yaml:"lastWriteTime,omitempty"`
+
+	// Accounts reports the sync state of each account in spec.accounts
+	Accounts []FastlyAccountStatus `json:"accounts,omitempty" yaml:"accounts,omitempty"`
+
+	// SelectedTLSConfigurationIds are the TLS configurations last discovered with spec.tlsConfigurationSelector
+	SelectedTLSConfigurationIds []string `json:"selectedTLSConfigurationIds,omitempty" yaml:"selectedTLSConfigurationIds,omitempty"`
+
+	// LastError describes the last failed Fastly sync, it is cleared by the next successful sync
+	LastError *FastlyError `json:"lastError,omitempty" yaml:"lastError,omitempty"`
+
+	// TLSConfigurations reports the TLS activations of the certificate on each TLS configuration it is activated on
+	TLSConfigurations []TLSConfigurationStatus `json:"tlsConfigurations,omitempty" yaml:"tlsConfigurations,omitempty"`
+
+	// SyncHistory records the last writes of the certificate to Fastly, oldest first, up to MaxSyncHistory
+	SyncHistory []SyncHistoryEntry `json:"syncHistory,omitempty" yaml:"syncHistory,omitempty"`
+
+	// Debug is the state of Fastly last observed for the resource, only reported with -status-debug
+	Debug *DebugStatus `json:"debug,omitempty" yaml:"debug,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil when it isn't set
+func (in *FastlyCertificateSyncStatus) GetCondition(conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(in.Conditions, conditionType)
+}
+
+// SetCondition adds the condition or updates the one of the same type, keeping its last transition time unless the
+// status changed. It reports whether the conditions changed.
+func (in *FastlyCertificateSyncStatus) SetCondition(condition metav1.Condition) bool {
+	return meta.SetStatusCondition(&in.Conditions, condition)
+}
+
+// AppendSyncHistory records a write of the certificate, dropping the oldest entries beyond MaxSyncHistory
+func (in *FastlyCertificateSyncStatus) AppendSyncHistory(entry SyncHistoryEntry) {
+	in.SyncHistory = append(in.SyncHistory, entry)
+	if excess := len(in.SyncHistory) - MaxSyncHistory; excess > 0 {
+		in.SyncHistory = append([]SyncHistoryEntry(nil), in.SyncHistory[excess:]...)
+	}
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=fcs,categories={fastly,all}
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Certificate",type="string",JSONPath=".spec.certificateName"
 // +kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspend"
 
 // FastlyCertificateSync is the Schema for the fastlycertificatesyncs API.
@@ -57,8 +639,37 @@ type FastlyCertificateSyncList struct {
 	Items           []FastlyCertificateSync `json:"items" yaml:"items"`
 }
 
+// IsSuspended reports whether reconciliation should be skipped entirely. Resources suspended in ObserveOnly mode
+// are still reconciled, and are expected to be checked with IsObserveOnly before making any changes. Deleted resources
+// are never skipped, so that their finalizer is removed, leaving their certificate in Fastly.
 func (in *FastlyCertificateSync) IsSuspended() bool {
-	return in.Spec.Suspend
+	return in.Spec.Suspend && in.Spec.SuspendMode != SuspendModeObserveOnly && in.DeletionTimestamp.IsZero()
+}
+
+// IsObserveOnly reports whether the resource is suspended, but should still be observed
+func (in *FastlyCertificateSync) IsObserveOnly() bool {
+	return in.Spec.Suspend && in.Spec.SuspendMode == SuspendModeObserveOnly
+}
+
+// IsDeletionPolicyDelete reports whether the certificate in Fastly is deleted along with the resource
+func (in *FastlyCertificateSync) IsDeletionPolicyDelete() bool {
+	return in.Spec.DeletionPolicy == DeletionPolicyDelete
+}
+
+// RefusesInUseDeletion reports whether Fastly certificates still referenced by TLS activations are left in place
+// rather than deleted along with their activations
+func (in *FastlyCertificateSync) RefusesInUseDeletion() bool {
+	return in.Spec.ActivationDeletionPolicy == ActivationDeletionPolicyRefuse
+}
+
+// ActivatesInOrder reports whether the certificate is activated on one TLS configuration after the other
+func (in *FastlyCertificateSync) ActivatesInOrder() bool {
+	return in.Spec.TLSActivationOrder == TLSActivationOrderOrdered
+}
+
+// IsPrivateKeyExternal reports whether the private key is uploaded to Fastly by someone other than the operator
+func (in *FastlyCertificateSync) IsPrivateKeyExternal() bool {
+	return in.Spec.PrivateKeyManagement == PrivateKeyManagementExternal
 }
 
 func init() {
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 SeatGeek.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/seatgeek/k8s-reconciler-generic/apiobjects"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FastlyConfigStoreSyncSpec defines the desired state of FastlyConfigStoreSync.
+type FastlyConfigStoreSyncSpec struct {
+	// Reconciliation of individual resources may be suspended by setting this flag.
+	Suspend bool `json:"suspend,omitempty" yaml:"suspend,omitempty"`
+
+	// The name of the ConfigMap to mirror, in the namespace of the FastlyConfigStoreSync. Every key in its data is
+	// mirrored as an item of the config store, binaryData is ignored.
+	ConfigMapName string `json:"configMapName" yaml:"configMapName"`
+
+	// The name of the Fastly config store to mirror the ConfigMap into, it is created when missing. Items that were
+	// synced from the ConfigMap are deleted from the store once they are removed from the ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	StoreName string `json:"storeName" yaml:"storeName"`
+
+	// Take over the items already in the config store that were not synced from the ConfigMap, deleting those that
+	// are not in the ConfigMap. Without it, such items are left in place.
+	// +optional
+	AdoptExistingItems bool `json:"adoptExistingItems,omitempty" yaml:"adoptExistingItems,omitempty"`
+}
+
+// FastlyConfigStoreSyncStatus defines the observed state of FastlyConfigStoreSync.
+type FastlyConfigStoreSyncStatus struct {
+	apiobjects.SubjectStatus `json:",inline" yaml:",inline"`
+
+	Ready      bool               `json:"ready" yaml:"ready"`
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+
+	// StoreID is the ID of the Fastly config store the ConfigMap is mirrored into
+	StoreID string `json:"storeId,omitempty" yaml:"storeId,omitempty"`
+	// ItemCount is the number of items last observed in the Fastly config store
+	ItemCount int `json:"itemCount,omitempty" yaml:"itemCount,omitempty"`
+	// ManagedKeys are the keys of the items synced from the ConfigMap into the config store, which are deleted from
+	// the store once they are removed from the ConfigMap
+	ManagedKeys []string `json:"managedKeys,omitempty" yaml:"managedKeys,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil when it isn't set
+func (in *FastlyConfigStoreSyncStatus) GetCondition(conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(in.Conditions, conditionType)
+}
+
+// SetCondition adds the condition or updates the one of the same type, keeping its last transition time unless the
+// status changed. It reports whether the conditions changed.
+func (in *FastlyConfigStoreSyncStatus) SetCondition(condition metav1.Condition) bool {
+	return meta.SetStatusCondition(&in.Conditions, condition)
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Store",type="string",JSONPath=".spec.storeName"
+// +kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspend"
+
+// FastlyConfigStoreSync is the Schema for the fastlyconfigstoresyncs API.
+type FastlyConfigStoreSync struct {
+	metav1.TypeMeta   `json:",inline" yaml:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec   FastlyConfigStoreSyncSpec   `json:"spec,omitempty" yaml:"spec,omitempty"`
+	Status FastlyConfigStoreSyncStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FastlyConfigStoreSyncList contains a list of FastlyConfigStoreSync.
+type FastlyConfigStoreSyncList struct {
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Items           []FastlyConfigStoreSync `json:"items" yaml:"items"`
+}
+
+// IsSuspended reports whether reconciliation should be skipped
+func (in *FastlyConfigStoreSync) IsSuspended() bool {
+	return in.Spec.Suspend
+}
+
+func init() {
+	SchemeBuilder.Register(&FastlyConfigStoreSync{}, &FastlyConfigStoreSyncList{})
+}
@@ -13,6 +13,168 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateIssuerRef) DeepCopyInto(out *CertificateIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateIssuerRef.
+func (in *CertificateIssuerRef) DeepCopy() *CertificateIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRef) DeepCopyInto(out *CertificateRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRef.
+func (in *CertificateRef) DeepCopy() *CertificateRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateTemplate) DeepCopyInto(out *CertificateTemplate) {
+	*out = *in
+	out.IssuerRef = in.IssuerRef
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateTemplate.
+func (in *CertificateTemplate) DeepCopy() *CertificateTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DebugStatus) DeepCopyInto(out *DebugStatus) {
+	*out = *in
+	if in.MissingTLSActivations != nil {
+		in, out := &in.MissingTLSActivations, &out.MissingTLSActivations
+		*out = make([]DebugTLSActivation, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExtraTLSActivationIDs != nil {
+		in, out := &in.ExtraTLSActivationIDs, &out.ExtraTLSActivationIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingChanges != nil {
+		in, out := &in.PendingChanges, &out.PendingChanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DebugStatus.
+func (in *DebugStatus) DeepCopy() *DebugStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DebugStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DebugTLSActivation) DeepCopyInto(out *DebugTLSActivation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DebugTLSActivation.
+func (in *DebugTLSActivation) DeepCopy() *DebugTLSActivation {
+	if in == nil {
+		return nil
+	}
+	out := new(DebugTLSActivation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyAccount) DeepCopyInto(out *FastlyAccount) {
+	*out = *in
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(FastlyTokenSecretRef)
+		**out = **in
+	}
+	if in.TLSConfigurationIds != nil {
+		in, out := &in.TLSConfigurationIds, &out.TLSConfigurationIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyAccount.
+func (in *FastlyAccount) DeepCopy() *FastlyAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyAccountStatus) DeepCopyInto(out *FastlyAccountStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyAccountStatus.
+func (in *FastlyAccountStatus) DeepCopy() *FastlyAccountStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyAccountStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyCertificateStatus) DeepCopyInto(out *FastlyCertificateStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyCertificateStatus.
+func (in *FastlyCertificateStatus) DeepCopy() *FastlyCertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyCertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FastlyCertificateSync) DeepCopyInto(out *FastlyCertificateSync) {
 	*out = *in
@@ -75,11 +237,53 @@ func (in *FastlyCertificateSyncList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FastlyCertificateSyncSpec) DeepCopyInto(out *FastlyCertificateSyncSpec) {
 	*out = *in
+	if in.CertificateRef != nil {
+		in, out := &in.CertificateRef, &out.CertificateRef
+		*out = new(CertificateRef)
+		**out = **in
+	}
 	if in.TLSConfigurationIds != nil {
 		in, out := &in.TLSConfigurationIds, &out.TLSConfigurationIds
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TLSConfigurationSelector != nil {
+		in, out := &in.TLSConfigurationSelector, &out.TLSConfigurationSelector
+		*out = new(TLSConfigurationSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Accounts != nil {
+		in, out := &in.Accounts, &out.Accounts
+		*out = make([]FastlyAccount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CertificateTemplate != nil {
+		in, out := &in.CertificateTemplate, &out.CertificateTemplate
+		*out = new(CertificateTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeys != nil {
+		in, out := &in.SecretKeys, &out.SecretKeys
+		*out = new(SecretKeys)
+		**out = **in
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PreviousCertificateGracePeriod != nil {
+		in, out := &in.PreviousCertificateGracePeriod, &out.PreviousCertificateGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyCertificateSyncSpec.
@@ -103,6 +307,209 @@ func (in *FastlyCertificateSyncStatus) DeepCopyInto(out *FastlyCertificateSyncSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.TLSActivationResults != nil {
+		in, out := &in.TLSActivationResults, &out.TLSActivationResults
+		*out = make([]TLSActivationResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FastlyCertificate != nil {
+		in, out := &in.FastlyCertificate, &out.FastlyCertificate
+		*out = new(FastlyCertificateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreviousCertificate != nil {
+		in, out := &in.PreviousCertificate, &out.PreviousCertificate
+		*out = new(PreviousCertificateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(RollbackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StaleSince != nil {
+		in, out := &in.StaleSince, &out.StaleSince
+		*out = (*in).DeepCopy()
+	}
+	if in.LastWriteTime != nil {
+		in, out := &in.LastWriteTime, &out.LastWriteTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Accounts != nil {
+		in, out := &in.Accounts, &out.Accounts
+		*out = make([]FastlyAccountStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SelectedTLSConfigurationIds != nil {
+		in, out := &in.SelectedTLSConfigurationIds, &out.SelectedTLSConfigurationIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastError != nil {
+		in, out := &in.LastError, &out.LastError
+		*out = new(FastlyError)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLSConfigurations != nil {
+		in, out := &in.TLSConfigurations, &out.TLSConfigurations
+		*out = make([]TLSConfigurationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SyncHistory != nil {
+		in, out := &in.SyncHistory, &out.SyncHistory
+		*out = make([]SyncHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Debug != nil {
+		in, out := &in.Debug, &out.Debug
+		*out = new(DebugStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyConfigStoreSync) DeepCopyInto(out *FastlyConfigStoreSync) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyConfigStoreSync.
+func (in *FastlyConfigStoreSync) DeepCopy() *FastlyConfigStoreSync {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyConfigStoreSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FastlyConfigStoreSync) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyConfigStoreSyncList) DeepCopyInto(out *FastlyConfigStoreSyncList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FastlyConfigStoreSync, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyConfigStoreSyncList.
+func (in *FastlyConfigStoreSyncList) DeepCopy() *FastlyConfigStoreSyncList {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyConfigStoreSyncList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FastlyConfigStoreSyncList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyConfigStoreSyncSpec) DeepCopyInto(out *FastlyConfigStoreSyncSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyConfigStoreSyncSpec.
+func (in *FastlyConfigStoreSyncSpec) DeepCopy() *FastlyConfigStoreSyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyConfigStoreSyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyConfigStoreSyncStatus) DeepCopyInto(out *FastlyConfigStoreSyncStatus) {
+	*out = *in
+	in.SubjectStatus.DeepCopyInto(&out.SubjectStatus)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ManagedKeys != nil {
+		in, out := &in.ManagedKeys, &out.ManagedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyConfigStoreSyncStatus.
+func (in *FastlyConfigStoreSyncStatus) DeepCopy() *FastlyConfigStoreSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyConfigStoreSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyError) DeepCopyInto(out *FastlyError) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyError.
+func (in *FastlyError) DeepCopy() *FastlyError {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FastlyTokenSecretRef) DeepCopyInto(out *FastlyTokenSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyTokenSecretRef.
+func (in *FastlyTokenSecretRef) DeepCopy() *FastlyTokenSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(FastlyTokenSecretRef)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FastlyCertificateSyncStatus.
@@ -114,3 +521,122 @@ func (in *FastlyCertificateSyncStatus) DeepCopy() *FastlyCertificateSyncStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviousCertificateStatus) DeepCopyInto(out *PreviousCertificateStatus) {
+	*out = *in
+	in.RetainedUntil.DeepCopyInto(&out.RetainedUntil)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviousCertificateStatus.
+func (in *PreviousCertificateStatus) DeepCopy() *PreviousCertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviousCertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollbackStatus) DeepCopyInto(out *RollbackStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollbackStatus.
+func (in *RollbackStatus) DeepCopy() *RollbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RollbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeys) DeepCopyInto(out *SecretKeys) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeys.
+func (in *SecretKeys) DeepCopy() *SecretKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncHistoryEntry) DeepCopyInto(out *SyncHistoryEntry) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncHistoryEntry.
+func (in *SyncHistoryEntry) DeepCopy() *SyncHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSActivationResult) DeepCopyInto(out *TLSActivationResult) {
+	*out = *in
+	in.LastAttemptTime.DeepCopyInto(&out.LastAttemptTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSActivationResult.
+func (in *TLSActivationResult) DeepCopy() *TLSActivationResult {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSActivationResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfigurationSelector) DeepCopyInto(out *TLSConfigurationSelector) {
+	*out = *in
+	if in.Bulk != nil {
+		in, out := &in.Bulk, &out.Bulk
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfigurationSelector.
+func (in *TLSConfigurationSelector) DeepCopy() *TLSConfigurationSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfigurationSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfigurationStatus) DeepCopyInto(out *TLSConfigurationStatus) {
+	*out = *in
+	if in.MissingDomains != nil {
+		in, out := &in.MissingDomains, &out.MissingDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfigurationStatus.
+func (in *TLSConfigurationStatus) DeepCopy() *TLSConfigurationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfigurationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
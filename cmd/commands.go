@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+
+	"github.com/fastly-tls-operator/internal/reconciler/fastlycertificatesync"
+	"github.com/fastly-tls-operator/internal/version"
+)
+
+// fastlyCommands are the subcommands that run against the account of FASTLY_API_KEY instead of starting the manager
+var fastlyCommands = map[string]func(ctx context.Context, fastlyClient *fastly.Client, args []string) error{
+	"export":    runExport,
+	"import":    runImport,
+	"terraform": runTerraform,
+}
+
+// runFastlyCommand runs one of fastlyCommands and returns the exit code
+func runFastlyCommand(command string, args []string) int {
+	ctx := context.Background()
+
+	fastlyClient, err := fastly.NewClient(os.Getenv("FASTLY_API_KEY"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create Fastly client: %v\n", err)
+		return 1
+	}
+
+	if err := fastlyCommands[command](ctx, fastlyClient, args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", command, err)
+		return 1
+	}
+	return 0
+}
+
+// runVersion prints the build of the operator and returns the exit code
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the version as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	info := version.Get()
+	if !*asJSON {
+		fmt.Println(info)
+		return 0
+	}
+	encoded, err := json.Marshal(info)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "version: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(encoded))
+	return 0
+}
+
+func runExport(ctx context.Context, fastlyClient *fastly.Client, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := fs.String("output", "-", "File to write the backup to, - for stdout")
+	namePrefix := fs.String("fastly-object-name-prefix", "",
+		"Prefix of the Fastly certificates and private keys owned by the operator")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	backup, err := fastlycertificatesync.ExportBackup(ctx, fastlyClient, "default", *namePrefix)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup: %w", err)
+	}
+	encoded = append(encoded, '\n')
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(encoded)
+		return err
+	}
+	return os.WriteFile(*output, encoded, 0o600)
+}
+
+func runImport(ctx context.Context, fastlyClient *fastly.Client, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	input := fs.String("input", "-", "File to read the backup from, - for stdin")
+	dryRun := fs.Bool("dry-run", false, "Report the activations that would be created without creating them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var encoded []byte
+	var err error
+	if *input == "-" {
+		encoded, err = io.ReadAll(os.Stdin)
+	} else {
+		encoded, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	backup := &fastlycertificatesync.Backup{}
+	if err := json.Unmarshal(encoded, backup); err != nil {
+		return fmt.Errorf("failed to decode backup: %w", err)
+	}
+
+	result, restoreErr := fastlycertificatesync.RestoreBackup(ctx, fastlyClient, backup, *dryRun)
+	if result != nil {
+		report, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode restore result: %w", err)
+		}
+		fmt.Println(string(report))
+	}
+	return restoreErr
+}
+
+func runTerraform(ctx context.Context, fastlyClient *fastly.Client, args []string) error {
+	fs := flag.NewFlagSet("terraform", flag.ContinueOnError)
+	input := fs.String("input", "", "Backup to generate the imports from, instead of reading the Fastly account")
+	namePrefix := fs.String("fastly-object-name-prefix", "",
+		"Prefix of the Fastly certificates and private keys owned by the operator")
+	format := fs.String("format", "blocks",
+		"Either blocks, for Terraform import blocks, or commands, for terraform import commands")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "blocks" && *format != "commands" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	backup := &fastlycertificatesync.Backup{}
+	if *input != "" {
+		encoded, err := os.ReadFile(*input)
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		if err := json.Unmarshal(encoded, backup); err != nil {
+			return fmt.Errorf("failed to decode backup: %w", err)
+		}
+	} else {
+		var err error
+		backup, err = fastlycertificatesync.ExportBackup(ctx, fastlyClient, "default", *namePrefix)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, terraformImport := range fastlycertificatesync.TerraformImports(backup) {
+		if *format == "commands" {
+			fmt.Print(terraformImport.Command())
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(terraformImport.Block())
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+)
+
+// fastlyTracingTransport logs each request made to the Fastly API along with its outcome. Request and response bodies
+// and headers are never logged, as they carry private keys and the API token.
+type fastlyTracingTransport struct {
+	next http.RoundTripper
+	log  logr.Logger
+}
+
+func (t *fastlyTracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	keysAndValues := []any{"method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(start)}
+	if err != nil {
+		t.log.Error(err, "Fastly request failed", keysAndValues...)
+		return resp, err
+	}
+	t.log.Info("Fastly request", append(keysAndValues,
+		"status", resp.StatusCode,
+		"rateLimitRemaining", resp.Header.Get("Fastly-RateLimit-Remaining"))...)
+	return resp, nil
+}
+
+// newFastlyClientFactory returns the function creating Fastly clients, whose requests are traced to log when trace is
+// set
+func newFastlyClientFactory(trace bool, log logr.Logger) func(token string) (*fastly.Client, error) {
+	return func(token string) (*fastly.Client, error) {
+		client, err := fastly.NewClient(token)
+		if err != nil || !trace {
+			return client, err
+		}
+
+		next := client.HTTPClient.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		httpClient := *client.HTTPClient
+		httpClient.Transport = &fastlyTracingTransport{next: next, log: log}
+		client.HTTPClient = &httpClient
+		return client, nil
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// klogComponent is the name of the logger klog's output is routed to, which -log-config components may refer to
+const klogComponent = "klog"
+
+// logConfig configures the three logging backends in the process consistently: zap, the logr loggers built on it,
+// and klog, used by client-go. It is given as JSON with -log-config, e.g.
+// {"level":"info","format":"json","components":{"klog":"error","fastly-private-key-sweeper":"debug"}}
+type logConfig struct {
+	// Level of every logger: error, info, debug, or a logr verbosity such as 5
+	Level string `json:"level,omitempty"`
+
+	// Format of the log lines: json or console
+	Format string `json:"format,omitempty"`
+
+	// Components overrides the level of the named loggers, along with their children
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// parseLogConfig parses the value of -log-config, rejecting unknown fields so that typos don't go unnoticed
+func parseLogConfig(value string) (*logConfig, error) {
+	config := &logConfig{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("invalid -log-config: %w", err)
+	}
+	return config, nil
+}
+
+// newLoggerFromConfig builds the logger of the process from the value of -log-config
+func newLoggerFromConfig(value string) (logr.Logger, error) {
+	config, err := parseLogConfig(value)
+	if err != nil {
+		return logr.Logger{}, err
+	}
+	return config.newLogger()
+}
+
+// parseLogLevel parses a level of -log-config into a zap level, logr verbosities being negative zap levels
+func parseLogLevel(value string) (zapcore.Level, error) {
+	switch value {
+	case "", "info":
+		return zapcore.InfoLevel, nil
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	}
+
+	verbosity, err := strconv.Atoi(value)
+	if err != nil || verbosity < 0 || verbosity > 127 {
+		return 0, fmt.Errorf("invalid log level %q, expected error, info, debug or a verbosity between 0 and 127", value)
+	}
+	return zapcore.Level(-verbosity), nil
+}
+
+// newLogger builds the logger of the process from the config, and routes klog's output through it
+func (c *logConfig) newLogger() (logr.Logger, error) {
+	level, err := parseLogLevel(c.Level)
+	if err != nil {
+		return logr.Logger{}, err
+	}
+
+	// zap itself lets through the most verbose of the levels, the rest is filtered by component
+	minLevel := level
+	components := map[string]zapcore.Level{}
+	for name, value := range c.Components {
+		componentLevel, err := parseLogLevel(value)
+		if err != nil {
+			return logr.Logger{}, fmt.Errorf("component %s: %w", name, err)
+		}
+		components[name] = componentLevel
+		minLevel = min(minLevel, componentLevel)
+	}
+
+	opts := []zap.Opts{
+		zap.Level(minLevel),
+		zap.RawZapOpts(uberzap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &componentLevelCore{Core: core, level: level, components: components}
+		})),
+	}
+	switch c.Format {
+	case "", "json":
+		opts = append(opts, zap.JSONEncoder())
+	case "console":
+		opts = append(opts, zap.ConsoleEncoder())
+	default:
+		return logr.Logger{}, fmt.Errorf("invalid log format %q, expected json or console", c.Format)
+	}
+	logger := zap.New(opts...)
+
+	// klog only hands its lines up to its own verbosity over to the logger
+	klogLevel, ok := components[klogComponent]
+	if !ok {
+		klogLevel = level
+	}
+	klogFlags := &flag.FlagSet{}
+	klog.InitFlags(klogFlags)
+	if err := klogFlags.Set("v", strconv.Itoa(max(0, -int(klogLevel)))); err != nil {
+		return logr.Logger{}, err
+	}
+	klog.SetLogger(logger.WithName(klogComponent))
+
+	return logger, nil
+}
+
+// componentLevelCore drops the entries below the level of the most specific component their logger falls under
+type componentLevelCore struct {
+	zapcore.Core
+	level      zapcore.Level
+	components map[string]zapcore.Level
+}
+
+func (c *componentLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &componentLevelCore{Core: c.Core.With(fields), level: c.level, components: c.components}
+}
+
+func (c *componentLevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < c.levelFor(entry.LoggerName) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// levelFor returns the level of the named logger, looking its parents up as well, e.g. controller-runtime for
+// controller-runtime.metrics
+func (c *componentLevelCore) levelFor(name string) zapcore.Level {
+	for name != "" {
+		if level, ok := c.components[name]; ok {
+			return level
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return c.level
+}
@@ -1,16 +1,17 @@
 package main
 
 import (
-	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/fastly-tls-operator/api/v1alpha1"
-	"github.com/fastly/go-fastly/v11/fastly"
 	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/transport"
@@ -23,12 +24,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/fastly-tls-operator/internal/events"
 	"github.com/fastly-tls-operator/internal/reconciler/fastlycertificatesync"
+	"github.com/fastly-tls-operator/internal/reconciler/fastlyconfigstoresync"
+	"github.com/fastly-tls-operator/internal/version"
 	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
 )
 
@@ -41,36 +46,237 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 	utilruntime.Must(cmv1.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1beta1.AddToScheme(scheme))
 }
 
 type cliFlags struct {
 	metricsAddr                                  string
+	metricsSecure                                bool
 	enableLeaderElection                         bool
 	probeAddr                                    string
 	leaderElectionID                             string
 	syncPeriod                                   time.Duration
 	webhookPort                                  int
 	webhookCertDir                               string
+	tlsMinVersion                                string
+	tlsCipherSuites                              string
+	httpDebug                                    bool
+	startupSelfCheck                             bool
+	partition                                    string
 	hackFastlyCertificateSyncLocalReconciliation bool
+	localReconciliationAllowMissingCA            bool
+	fastlyNamespaceTokenSecrets                  string
+	fastlyTokenSecretNamespace                   string
+	fastlyTokenSecretKey                         string
+	fastlyObjectNamePrefix                       string
+	fastlyClusterName                            string
+	fastlyNamespacedObjectNames                  bool
+	privateKeyUploadCacheTTL                     time.Duration
+	fastlyInventoryCacheTTL                      time.Duration
+	mutationBudgetWindow                         time.Duration
+	globalMutationBudget                         int
+	subjectMutationBudget                        int
+	tlsActivationParallelism                     int
+	privateKeyDeletionParallelism                int
+	defaultTLSConfigurationFallback              bool
+	domainVerificationCheck                      bool
+	driftCheckInterval                           time.Duration
+	writerConflictHoldOff                        time.Duration
+	staleThreshold                               time.Duration
+	quickDriftCheck                              bool
+	allowedCertificateNamespaces                 string
+	certificateSyncAnnotation                    string
+	certificateSyncAnnotationValue               string
+	ignoreCertificateSyncAnnotation              bool
+	labelSources                                 bool
+	statusDebug                                  bool
+	accountAuditInterval                         time.Duration
+	customCertificateLimit                       int
+	privateKeyLimit                              int
+	quotaWarningPercent                          int
+	privateKeySweepInterval                      time.Duration
+	backupInterval                               time.Duration
+	backupConfigMap                              string
+	mutationPauseConfigMap                       string
+	notificationTemplate                         string
+	notificationInterval                         time.Duration
+	notificationExpiryThreshold                  time.Duration
+	eventRateLimitBurst                          int
+	eventRateLimitWindow                         time.Duration
+	fleetMetricsInterval                         time.Duration
+	standbyObserverInterval                      time.Duration
+	workqueueMaxItemAge                          time.Duration
+	workqueueMaxIdle                             time.Duration
+	retryBaseDelay                               time.Duration
+	retryMaxDelay                                time.Duration
+	logConfig                                    string
 }
 
 // BindFlags will parse the given flagset
 func (c *cliFlags) BindFlags(fs *flag.FlagSet) {
 	fs.StringVar(&(c.metricsAddr), "metrics-bind-address", c.metricsAddr, "The address the metric endpoint binds to.")
+	fs.BoolVar(&(c.metricsSecure), "metrics-secure", c.metricsSecure,
+		"Serve the metrics endpoint over HTTPS, with a self-signed certificate, rather than HTTP.")
 	fs.BoolVar(&(c.enableLeaderElection), "leader-election", c.enableLeaderElection,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	fs.StringVar(&(c.partition), "partition", c.partition,
+		"Partition of the FastlyCertificateSyncs this deployment reconciles, those whose platform.seatgeek.io/partition "+
+			"annotation, set from spec.partition, matches. Empty for those without the annotation. Deployments of "+
+			"different partitions need different -leader-election-id values.")
 	fs.StringVar(&(c.leaderElectionID), "leader-election-id", c.leaderElectionID,
 		"The name of the resource that leader election will use for holding the leader lock.")
 	fs.DurationVar(&(c.syncPeriod), "sync-period", c.syncPeriod, "Maximum delay between reconciles of any object.")
 	fs.IntVar(&(c.webhookPort), "webhook-port", c.webhookPort, "Webhook bind port")
 	fs.StringVar(&(c.webhookCertDir), "webhook-cert-dir", c.webhookCertDir,
 		"Certs used to terminate TLS for webhook server")
+	fs.StringVar(&(c.tlsMinVersion), "tls-min-version", c.tlsMinVersion,
+		"Minimum TLS version accepted by the webhook server, and the metrics server with -metrics-secure: 1.2 or 1.3")
+	fs.StringVar(&(c.tlsCipherSuites), "tls-cipher-suites", c.tlsCipherSuites,
+		"Comma separated IANA names of the TLS 1.2 cipher suites accepted by the webhook server, and the metrics "+
+			"server with -metrics-secure, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Empty for Go's defaults.")
+	fs.BoolVar(&(c.httpDebug), "http-debug", c.httpDebug,
+		"Debug HTTP requests: wrap the Kubernetes API transport with client-go's debugging round trippers, logging "+
+			"per klog verbosity, and log each request made to the Fastly API to the fastly-http logger")
+	fs.BoolVar(&(c.startupSelfCheck), "startup-self-check", c.startupSelfCheck,
+		"Check on startup that CRDs are installed, RBAC allows reading Secrets and Certificates, Fastly tokens are "+
+			"accepted and referenced TLS configurations exist, failing readiness until every check passes")
 	fs.BoolVar(&(c.hackFastlyCertificateSyncLocalReconciliation), "hack-fastly-certificate-sync-local-reconciliation",
 		c.hackFastlyCertificateSyncLocalReconciliation, "Enable local reconciliation for Fastly certificate sync")
+	fs.BoolVar(&(c.localReconciliationAllowMissingCA), "hack-fastly-certificate-sync-local-allow-missing-ca",
+		c.localReconciliationAllowMissingCA, "In local reconciliation mode, upload certificates without their CA when the secret holds none, rather than failing")
+	fs.StringVar(&(c.fastlyNamespaceTokenSecrets), "fastly-namespace-token-secrets", c.fastlyNamespaceTokenSecrets,
+		"Comma separated namespace=secret pairs routing subjects in a namespace to the Fastly token "+
+			"stored in the named secret")
+	fs.StringVar(&(c.fastlyTokenSecretNamespace), "fastly-token-secret-namespace", c.fastlyTokenSecretNamespace,
+		"Namespace containing the secrets referenced by -fastly-namespace-token-secrets")
+	fs.StringVar(&(c.fastlyTokenSecretKey), "fastly-token-secret-key", c.fastlyTokenSecretKey,
+		"Key within the secrets referenced by -fastly-namespace-token-secrets that holds the Fastly API token")
+	fs.StringVar(&(c.fastlyObjectNamePrefix), "fastly-object-name-prefix", c.fastlyObjectNamePrefix,
+		"Prefix for Fastly certificates and private keys created by the operator. "+
+			"Fastly objects without this prefix are only modified when explicitly adopted.")
+	fs.StringVar(&(c.fastlyClusterName), "fastly-cluster-name", c.fastlyClusterName,
+		"Name of this cluster, embedded along with the namespace into the names of Fastly certificates and private "+
+			"keys created by the operator as <prefix><cluster>--<namespace>--<name>. Fastly objects named for another "+
+			"cluster are never modified.")
+	fs.BoolVar(&(c.fastlyNamespacedObjectNames), "fastly-namespaced-object-names", c.fastlyNamespacedObjectNames,
+		"Embed the namespace into the names of Fastly certificates and private keys created by the operator as "+
+			"<prefix><namespace>--<name>, so that Certificates of the same name in different namespaces never share "+
+			"a Fastly certificate. Set to false to keep the previous <prefix><name> names.")
+	fs.DurationVar(&(c.privateKeyUploadCacheTTL), "private-key-upload-cache-ttl", c.privateKeyUploadCacheTTL,
+		"How long a freshly uploaded private key is assumed to exist in Fastly before it is listed. "+
+			"Set to 0 to disable.")
+	fs.DurationVar(&(c.fastlyInventoryCacheTTL), "fastly-inventory-cache-ttl", c.fastlyInventoryCacheTTL,
+		"How long the listing of a Fastly account's certificates and private keys is shared across reconciles. "+
+			"The listing is fetched once on leader election. Set to 0 to list the account on every reconcile.")
+	fs.DurationVar(&(c.mutationBudgetWindow), "fastly-mutation-budget-window", c.mutationBudgetWindow,
+		"Sliding window over which Fastly write operations are counted against the mutation budgets.")
+	fs.IntVar(&(c.globalMutationBudget), "fastly-mutation-budget-global", c.globalMutationBudget,
+		"Maximum Fastly write operations across all resources within the budget window. Set to 0 for no limit.")
+	fs.IntVar(&(c.subjectMutationBudget), "fastly-mutation-budget-per-subject", c.subjectMutationBudget,
+		"Maximum Fastly write operations per FastlyCertificateSync within the budget window. Set to 0 for no limit.")
+	fs.StringVar(&(c.mutationPauseConfigMap), "fastly-mutation-pause-configmap", c.mutationPauseConfigMap,
+		"Name of the ConfigMap in the operator's namespace whose \"paused\" key set to \"true\" halts every Fastly write "+
+			"operation, while resources keep being observed. Set to an empty string to disable.")
+	fs.IntVar(&(c.tlsActivationParallelism), "fastly-tls-activation-parallelism", c.tlsActivationParallelism,
+		"Maximum Fastly TLS activations created or deleted concurrently for a single FastlyCertificateSync.")
+	fs.IntVar(&(c.privateKeyDeletionParallelism), "fastly-private-key-deletion-parallelism",
+		c.privateKeyDeletionParallelism,
+		"Maximum unused Fastly private keys deleted concurrently in a single Fastly account.")
+	fs.BoolVar(&(c.defaultTLSConfigurationFallback), "fastly-default-tls-configuration-fallback",
+		c.defaultTLSConfigurationFallback,
+		"Activate certificates of resources without TLS configuration IDs on the account's default TLS configuration.")
+	fs.BoolVar(&(c.domainVerificationCheck), "fastly-domain-verification-check", c.domainVerificationCheck,
+		"Report whether the DNS names of each certificate are verified domains in Fastly's Domain Management API, "+
+			"in the DomainVerified condition.")
+	fs.DurationVar(&(c.driftCheckInterval), "fastly-drift-check-interval", c.driftCheckInterval,
+		"How soon a FastlyCertificateSync that is in sync is reconciled again, to notice changes made to Fastly "+
+			"out of band. Set to 0 to rely on the sync period.")
+	fs.BoolVar(&(c.quickDriftCheck), "fastly-quick-drift-check", c.quickDriftCheck,
+		"Look up the synced certificate by ID on drift checks, instead of observing all of Fastly. "+
+			"Disable to fully observe Fastly on every drift check.")
+	fs.DurationVar(&(c.staleThreshold), "fastly-stale-threshold", c.staleThreshold,
+		"How long a certificate may remain stale or missing in Fastly before the StaleTooLong condition and metric "+
+			"report it as stuck. Set to 0 to disable.")
+	fs.DurationVar(&(c.writerConflictHoldOff), "fastly-writer-conflict-hold-off", c.writerConflictHoldOff,
+		"How long certificate updates are held back after another writer, such as an operator in another cluster, "+
+			"overwrote the certificate last written to Fastly. Set to 0 to always update the certificate.")
+	fs.StringVar(&(c.allowedCertificateNamespaces), "allowed-certificate-namespaces", c.allowedCertificateNamespaces,
+		"Comma separated namespaces whose Certificates may be referenced from any namespace without a ReferenceGrant")
+	fs.BoolVar(&(c.labelSources), "label-sources", c.labelSources,
+		"Label the Certificate and Secret synced by each FastlyCertificateSync with "+
+			"platform.seatgeek.io/fastly-certificate-sync set to its name")
+	fs.BoolVar(&(c.statusDebug), "status-debug", c.statusDebug,
+		"Report the state of Fastly last observed for each FastlyCertificateSync in status.debug: whether the private "+
+			"key exists, how the certificate compares, and the TLS activations still to be created or deleted")
+	fs.StringVar(&(c.certificateSyncAnnotation), "certificate-sync-annotation", c.certificateSyncAnnotation,
+		"Annotation marking the Certificates whose changes trigger a sync of the FastlyCertificateSyncs referencing them")
+	fs.StringVar(&(c.certificateSyncAnnotationValue), "certificate-sync-annotation-value", c.certificateSyncAnnotationValue,
+		"Value of -certificate-sync-annotation marking a Certificate for syncing")
+	fs.BoolVar(&(c.ignoreCertificateSyncAnnotation), "ignore-certificate-sync-annotation", c.ignoreCertificateSyncAnnotation,
+		"Watch every Certificate referenced by a FastlyCertificateSync, rather than only those carrying "+
+			"-certificate-sync-annotation")
+	fs.DurationVar(&(c.accountAuditInterval), "fastly-account-audit-interval", c.accountAuditInterval,
+		"How often the objects in each Fastly account are counted and exported as metrics. Set to 0 to disable.")
+	fs.IntVar(&(c.customCertificateLimit), "fastly-custom-certificate-limit", c.customCertificateLimit,
+		"Custom TLS certificates each Fastly account may hold, as counted by the account audit. New certificates "+
+			"are refused once an account reaches it. Set to 0 for no limit.")
+	fs.IntVar(&(c.privateKeyLimit), "fastly-private-key-limit", c.privateKeyLimit,
+		"TLS private keys each Fastly account may hold, as counted by the account audit. New private keys are "+
+			"refused once an account reaches it. Set to 0 for no limit.")
+	fs.IntVar(&(c.quotaWarningPercent), "fastly-quota-warning-percent", c.quotaWarningPercent,
+		"Percentage of -fastly-custom-certificate-limit or -fastly-private-key-limit at which the QuotaNearLimit "+
+			"condition is raised")
+	fs.DurationVar(&(c.privateKeySweepInterval), "fastly-private-key-sweep-interval", c.privateKeySweepInterval,
+		"How often the private keys no certificate uses are deleted from each Fastly account. Set to 0 to disable.")
+	fs.DurationVar(&(c.backupInterval), "fastly-backup-interval", c.backupInterval,
+		"How often the operator-owned Fastly TLS state is backed up to -fastly-backup-configmap. Set to 0 to disable.")
+	fs.StringVar(&(c.backupConfigMap), "fastly-backup-configmap", c.backupConfigMap,
+		"Name of the ConfigMap in the operator's namespace that Fastly TLS state backups are written to")
+	fs.StringVar(&(c.notificationTemplate), "notification-template", c.notificationTemplate,
+		"Go text/template rendering notifications posted to NOTIFICATION_WEBHOOK_URL, "+
+			"with .Kind, .Namespace, .Name and .Message available.")
+	fs.DurationVar(&(c.notificationInterval), "notification-interval", c.notificationInterval,
+		"Minimum time between notifications of the same kind for a single FastlyCertificateSync.")
+	fs.DurationVar(&(c.notificationExpiryThreshold), "notification-expiry-threshold", c.notificationExpiryThreshold,
+		"Notify when the certificate served by Fastly expires within this duration. Set to 0 to disable.")
+	fs.IntVar(&(c.eventRateLimitBurst), "event-rate-limit-burst", c.eventRateLimitBurst,
+		"Maximum events of the same type and reason recorded for a single resource within -event-rate-limit-window. "+
+			"Set to 0 for no limit.")
+	fs.DurationVar(&(c.eventRateLimitWindow), "event-rate-limit-window", c.eventRateLimitWindow,
+		"Window over which events are counted against -event-rate-limit-burst.")
+	fs.DurationVar(&(c.fleetMetricsInterval), "fleet-metrics-interval", c.fleetMetricsInterval,
+		"Minimum time between recounts of the FastlyCertificateSyncs by state, exported as the "+
+			"fastly_certificate_syncs gauge. Set to 0 to disable.")
+	fs.DurationVar(&(c.standbyObserverInterval), "standby-observer-interval", c.standbyObserverInterval,
+		"How often replicas that aren't the leader observe every FastlyCertificateSync read-only, keeping the Fastly "+
+			"inventory cache warm for failover. Set to 0 to disable.")
+	fs.DurationVar(&(c.workqueueMaxItemAge), "workqueue-max-item-age", c.workqueueMaxItemAge,
+		"Fail the liveness probe once a FastlyCertificateSync waited this long in the reconcile queue. "+
+			"Set to 0 to disable.")
+	fs.DurationVar(&(c.workqueueMaxIdle), "workqueue-max-idle", c.workqueueMaxIdle,
+		"Fail the liveness probe once no reconcile completed for this long while FastlyCertificateSyncs are queued. "+
+			"Set to 0 to disable.")
+	fs.DurationVar(&(c.retryBaseDelay), "retry-base-delay", c.retryBaseDelay,
+		"First delay before retrying a FastlyCertificateSync whose reconcile failed, doubling with each failure in a "+
+			"row. Set to 0 for the controller-runtime default of 5ms.")
+	fs.DurationVar(&(c.retryMaxDelay), "retry-max-delay", c.retryMaxDelay,
+		"Maximum delay before retrying a FastlyCertificateSync whose reconcile keeps failing. "+
+			"Set to 0 for the controller-runtime default of 1000s.")
+	fs.StringVar(&(c.logConfig), "log-config", c.logConfig,
+		"Logging configuration as JSON, e.g. {\"level\":\"info\",\"format\":\"json\",\"components\":{\"klog\":\"error\"}}. "+
+			"Configures zap, logr and klog consistently, in place of the -zap-* and -klog-* flags.")
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "version" {
+		os.Exit(runVersion(os.Args[2:]))
+	}
+	// export, import and terraform work with the Fastly TLS state instead of running the manager
+	if len(os.Args) > 1 && fastlyCommands[os.Args[1]] != nil {
+		os.Exit(runFastlyCommand(os.Args[1], os.Args[2:]))
+	}
+
 	opts := cliFlags{
 		metricsAddr:          ":8080",
 		probeAddr:            ":8081",
@@ -79,7 +285,36 @@ func main() {
 		syncPeriod:           4 * time.Hour,
 		webhookPort:          9443,
 		webhookCertDir:       "/var/run/webhook-serving-certs",
+		tlsMinVersion:        "1.2",
+		startupSelfCheck:     true,
 		hackFastlyCertificateSyncLocalReconciliation: false,
+		localReconciliationAllowMissingCA:            false,
+		fastlyTokenSecretNamespace:                   os.Getenv("POD_NAMESPACE"),
+		fastlyTokenSecretKey:                         "api-key",
+		privateKeyUploadCacheTTL:                     5 * time.Minute,
+		fastlyInventoryCacheTTL:                      time.Minute,
+		mutationBudgetWindow:                         time.Hour,
+		tlsActivationParallelism:                     4,
+		privateKeyDeletionParallelism:                4,
+		fastlyNamespacedObjectNames:                  true,
+		driftCheckInterval:                           15 * time.Minute,
+		quickDriftCheck:                              true,
+		certificateSyncAnnotation:                    fastlycertificatesync.EnableFastlySyncAnnotation,
+		certificateSyncAnnotationValue:               "true",
+		writerConflictHoldOff:                        time.Hour,
+		staleThreshold:                               2 * time.Hour,
+		accountAuditInterval:                         5 * time.Minute,
+		quotaWarningPercent:                          90,
+		privateKeySweepInterval:                      10 * time.Minute,
+		backupConfigMap:                              "fastly-tls-operator-backup",
+		mutationPauseConfigMap:                       "fastly-tls-operator-mutation-pause",
+		notificationTemplate:                         fastlycertificatesync.DefaultNotificationTemplate,
+		notificationInterval:                         time.Hour,
+		notificationExpiryThreshold:                  7 * 24 * time.Hour,
+		eventRateLimitBurst:                          5,
+		eventRateLimitWindow:                         10 * time.Minute,
+		fleetMetricsInterval:                         30 * time.Second,
+		workqueueMaxIdle:                             15 * time.Minute,
 	}
 
 	opts.BindFlags(flag.CommandLine)
@@ -89,9 +324,20 @@ func main() {
 
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zapOpts)))
+	if opts.logConfig == "" {
+		ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zapOpts)))
+	} else {
+		logger, err := newLoggerFromConfig(opts.logConfig)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		ctrl.SetLogger(logger)
+	}
 
-	setupLog.Info("initializing", "cluster", "fastly-tls-operator")
+	build := version.Get()
+	setupLog.Info("initializing", "cluster", "fastly-tls-operator",
+		"version", build.Version, "commit", build.Commit, "go_fastly_version", build.GoFastlyVersion)
 
 	config, err := kconf.GetConfig()
 	if err != nil {
@@ -99,25 +345,92 @@ func main() {
 		os.Exit(1)
 	}
 
+	tlsOpts, err := parseTLSOptions(opts.tlsMinVersion, opts.tlsCipherSuites)
+	if err != nil {
+		setupLog.Error(err, "unable to parse TLS options")
+		os.Exit(1)
+	}
+
 	webhookOpts := webhook.Options{
 		Port:     opts.webhookPort,
 		CertName: "tls.crt",
 		KeyName:  "tls.key",
 		CertDir:  opts.webhookCertDir,
-		TLSOpts:  []func(*tls.Config){},
+		TLSOpts:  tlsOpts,
 	}
 
-	config.WrapTransport = transport.DebugWrappers
+	if opts.httpDebug {
+		config.WrapTransport = transport.DebugWrappers
+	}
+	newFastlyClient := newFastlyClientFactory(opts.httpDebug, ctrl.Log.WithName("fastly-http"))
+
+	tokenSecrets, err := fastlycertificatesync.ParseNamespaceTokenSecrets(opts.fastlyNamespaceTokenSecrets)
+	if err != nil {
+		setupLog.Error(err, "unable to parse namespace token secrets")
+		os.Exit(1)
+	}
+	if len(tokenSecrets) > 0 && opts.fastlyTokenSecretNamespace == "" {
+		setupLog.Error(nil, "-fastly-token-secret-namespace is required when -fastly-namespace-token-secrets is set")
+		os.Exit(1)
+	}
+
+	if strings.Contains(opts.fastlyClusterName, "--") {
+		setupLog.Error(nil, "-fastly-cluster-name must not contain --, which separates it from the namespace in Fastly object names")
+		os.Exit(1)
+	}
+
+	allowedCertificateNamespaces := fastlycertificatesync.ParseAllowedCertificateNamespaces(
+		opts.allowedCertificateNamespaces,
+	)
 
 	// populate the runtime config struct for the controller
 	controllerRuntimeConfig := fastlycertificatesync.RuntimeConfig{
 		HackFastlyCertificateSyncLocalReconciliation: opts.hackFastlyCertificateSyncLocalReconciliation,
+		LocalReconciliationAllowMissingCA:            opts.localReconciliationAllowMissingCA,
+		FastlyTokenSecretsByNamespace:                tokenSecrets,
+		FastlyTokenSecretNamespace:                   opts.fastlyTokenSecretNamespace,
+		FastlyTokenSecretKey:                         opts.fastlyTokenSecretKey,
+		FastlyObjectNamePrefix:                       opts.fastlyObjectNamePrefix,
+		FastlyClusterName:                            opts.fastlyClusterName,
+		FastlyNamespacedObjectNames:                  opts.fastlyNamespacedObjectNames,
+		PrivateKeyUploadCacheTTL:                     opts.privateKeyUploadCacheTTL,
+		FastlyInventoryCacheTTL:                      opts.fastlyInventoryCacheTTL,
+		MutationBudgetWindow:                         opts.mutationBudgetWindow,
+		GlobalMutationBudget:                         opts.globalMutationBudget,
+		SubjectMutationBudget:                        opts.subjectMutationBudget,
+		TLSActivationParallelism:                     opts.tlsActivationParallelism,
+		PrivateKeyDeletionParallelism:                opts.privateKeyDeletionParallelism,
+		DefaultTLSConfigurationFallback:              opts.defaultTLSConfigurationFallback,
+		DomainVerificationCheck:                      opts.domainVerificationCheck,
+		DriftCheckInterval:                           opts.driftCheckInterval,
+		QuickDriftCheck:                              opts.quickDriftCheck,
+		WriterConflictHoldOff:                        opts.writerConflictHoldOff,
+		StaleThreshold:                               opts.staleThreshold,
+		FastlyCustomCertificateLimit:                 opts.customCertificateLimit,
+		FastlyPrivateKeyLimit:                        opts.privateKeyLimit,
+		FastlyQuotaWarningPercent:                    opts.quotaWarningPercent,
+		AllowedCertificateNamespaces:                 allowedCertificateNamespaces,
+		CertificateSyncAnnotation:                    opts.certificateSyncAnnotation,
+		CertificateSyncAnnotationValue:               opts.certificateSyncAnnotationValue,
+		IgnoreCertificateSyncAnnotation:              opts.ignoreCertificateSyncAnnotation,
+		LabelSources:                                 opts.labelSources,
+		StatusDebug:                                  opts.statusDebug,
+		NotificationInterval:                         opts.notificationInterval,
+		NotificationExpiryThreshold:                  opts.notificationExpiryThreshold,
+		RetryBaseDelay:                               opts.retryBaseDelay,
+		RetryMaxDelay:                                opts.retryMaxDelay,
+	}
+	// the mutation pause lives in the operator's namespace, which is unknown when running outside of the cluster
+	if namespace := os.Getenv("POD_NAMESPACE"); opts.mutationPauseConfigMap != "" && namespace != "" {
+		controllerRuntimeConfig.MutationPauseConfigMap = types.NamespacedName{Name: opts.mutationPauseConfigMap, Namespace: namespace}
 	}
 
 	mgr, err := ctrl.NewManager(config, ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
-			BindAddress: opts.metricsAddr,
+			BindAddress:   opts.metricsAddr,
+			SecureServing: opts.metricsSecure,
+			TLSOpts:       tlsOpts,
 		},
 		WebhookServer:          webhook.NewServer(webhookOpts),
 		HealthProbeBindAddress: opts.probeAddr,
@@ -141,36 +454,193 @@ func main() {
 		Scheme: mgr.GetScheme(),
 	}
 
+	fastlyClient, err := newFastlyClient(os.Getenv("FASTLY_API_KEY"))
+	if err != nil {
+		setupLog.Error(err, "unable to create Fastly client")
+		os.Exit(1)
+	}
+
+	logic := &fastlycertificatesync.Logic{
+		ResourceManager: fastlycertificatesync.ResourceManager,
+		Config:          controllerRuntimeConfig,
+		FastlyClient:    fastlyClient,
+		NewFastlyClient: func(token string) (fastlycertificatesync.FastlyClientInterface, error) {
+			return newFastlyClient(token)
+		},
+	}
+
+	// notifications are only sent when a webhook is configured
+	if url := os.Getenv("NOTIFICATION_WEBHOOK_URL"); url != "" {
+		notifier, err := fastlycertificatesync.NewWebhookNotifier(url, opts.notificationTemplate)
+		if err != nil {
+			setupLog.Error(err, "unable to create notifier")
+			os.Exit(1)
+		}
+		logic.Notifier = notifier
+	}
+
+	// a wedged operator fails its liveness probe
+	var queueHealth *fastlycertificatesync.QueueHealth
+	if opts.workqueueMaxItemAge > 0 || opts.workqueueMaxIdle > 0 {
+		queueHealth = &fastlycertificatesync.QueueHealth{
+			MaxItemAge: opts.workqueueMaxItemAge,
+			MaxIdle:    opts.workqueueMaxIdle,
+		}
+		logic.QueueHealth = queueHealth
+	}
+
+	// a flapping resource may not flood the cluster with events
+	recorder := events.NewRateLimitedRecorder(mgr.GetEventRecorderFor("fastly-tls-operator"),
+		opts.eventRateLimitWindow, opts.eventRateLimitBurst)
+
 	// setup FastlyCertificateSync controller
-	if err = (&genrec.Reconciler[*v1alpha1.FastlyCertificateSync, *fastlycertificatesync.Config]{
-		Logic: &fastlycertificatesync.Logic{
-			ResourceManager: fastlycertificatesync.ResourceManager,
-			Config:          controllerRuntimeConfig,
-			FastlyClient: func() *fastly.Client {
-				client, err := fastly.NewClient(os.Getenv("FASTLY_API_KEY"))
-				if err != nil {
-					setupLog.Error(err, "unable to create Fastly client")
-					os.Exit(1)
-				}
-				return client
-			}(),
+	certificateSyncReconciler := &genrec.Reconciler[*v1alpha1.FastlyCertificateSync, *fastlycertificatesync.Config]{
+		Logic:            logic,
+		Recorder:         recorder,
+		Client:           sc,
+		KeyNamespace:     "platform.seatgeek.io",
+		CurrentPartition: opts.partition,
+	}
+	// the Certificate watch skips subjects the reconciler would ignore as belonging to another partition
+	logic.Config.PartitionAnnotation = certificateSyncReconciler.LabelKey("partition")
+	logic.Config.Partition = certificateSyncReconciler.CurrentPartition
+	if err = certificateSyncReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FastlyCertificateSync")
+		os.Exit(1)
+	}
+
+	// setup FastlyConfigStoreSync controller
+	if err = (&genrec.Reconciler[*v1alpha1.FastlyConfigStoreSync, *fastlyconfigstoresync.Config]{
+		Logic: &fastlyconfigstoresync.Logic{
+			ResourceManager: fastlyconfigstoresync.ResourceManager,
+			Config: fastlyconfigstoresync.RuntimeConfig{
+				DriftCheckInterval:            opts.driftCheckInterval,
+				FastlyTokenSecretsByNamespace: tokenSecrets,
+				FastlyTokenSecretNamespace:    opts.fastlyTokenSecretNamespace,
+				FastlyTokenSecretKey:          opts.fastlyTokenSecretKey,
+			},
+			FastlyClient: fastlyClient,
+			NewFastlyClient: func(token string) (fastlyconfigstoresync.FastlyClientInterface, error) {
+				return newFastlyClient(token)
+			},
+			// Writes to config stores count against the same budget as certificate changes
+			MutationBudget: logic,
 		},
-		Recorder:     mgr.GetEventRecorderFor("fastly-tls-operator"),
+		Recorder:     recorder,
 		Client:       sc,
 		KeyNamespace: "platform.seatgeek.io",
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "FastlyCertificateSync")
+		setupLog.Error(err, "unable to create controller", "controller", "FastlyConfigStoreSync")
 		os.Exit(1)
 	}
 
+	// setup warm-up of the Fastly inventory cache
+	if opts.fastlyInventoryCacheTTL > 0 {
+		if err = mgr.Add(&fastlycertificatesync.InventoryWarmup{
+			Logic:  logic,
+			Client: mgr.GetClient(),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up Fastly inventory warm-up")
+			os.Exit(1)
+		}
+	}
+
+	// setup read-only observations on standby replicas
+	if opts.standbyObserverInterval > 0 {
+		if err = mgr.Add(&fastlycertificatesync.StandbyObserver{
+			Logic:      logic,
+			Reconciler: certificateSyncReconciler,
+			Interval:   opts.standbyObserverInterval,
+			Elected:    mgr.Elected(),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up standby observer")
+			os.Exit(1)
+		}
+	}
+
+	// setup the gauges summarizing every FastlyCertificateSync
+	if opts.fleetMetricsInterval > 0 {
+		if err = mgr.Add(&fastlycertificatesync.FleetMetrics{
+			Cache:    mgr.GetCache(),
+			Interval: opts.fleetMetricsInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up fleet metrics")
+			os.Exit(1)
+		}
+	}
+
+	// setup periodic audit of Fastly account totals
+	if opts.accountAuditInterval > 0 {
+		if err = mgr.Add(&fastlycertificatesync.AccountAudit{
+			Logic:    logic,
+			Client:   mgr.GetClient(),
+			Interval: opts.accountAuditInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up Fastly account audit")
+			os.Exit(1)
+		}
+	}
+
+	// setup periodic deletion of unused private keys
+	if opts.privateKeySweepInterval > 0 {
+		if err = mgr.Add(&fastlycertificatesync.PrivateKeySweeper{
+			Logic:    logic,
+			Client:   mgr.GetClient(),
+			Interval: opts.privateKeySweepInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up Fastly private key sweeper")
+			os.Exit(1)
+		}
+	}
+
+	// setup periodic backup of the operator-owned Fastly TLS state
+	if opts.backupInterval > 0 {
+		if err = mgr.Add(&fastlycertificatesync.PeriodicBackup{
+			Logic:     logic,
+			Client:    mgr.GetClient(),
+			Interval:  opts.backupInterval,
+			ConfigMap: types.NamespacedName{Name: opts.backupConfigMap, Namespace: os.Getenv("POD_NAMESPACE")},
+		}); err != nil {
+			setupLog.Error(err, "unable to set up Fastly backup")
+			os.Exit(1)
+		}
+	}
+
+	// setup the startup self-check, reported by the readiness probe
+	var selfCheck *fastlycertificatesync.SelfCheck
+	if opts.startupSelfCheck {
+		selfCheck = &fastlycertificatesync.SelfCheck{
+			Logic:      logic,
+			Reader:     mgr.GetAPIReader(),
+			Client:     mgr.GetClient(),
+			RESTMapper: mgr.GetRESTMapper(),
+		}
+		if err = mgr.Add(selfCheck); err != nil {
+			setupLog.Error(err, "unable to set up startup self-check")
+			os.Exit(1)
+		}
+	}
+
 	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
+	if queueHealth != nil {
+		if err = mgr.AddHealthzCheck("workqueue", queueHealth.Check); err != nil {
+			setupLog.Error(err, "unable to set up workqueue health check")
+			os.Exit(1)
+		}
+	}
 	if err = mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if selfCheck != nil {
+		if err = mgr.AddReadyzCheck("self-check", selfCheck.Check); err != nil {
+			setupLog.Error(err, "unable to set up self-check ready check")
+			os.Exit(1)
+		}
+	}
 
 	ctx := ctrl.SetupSignalHandler()
 	setupLog.Info("starting manager")
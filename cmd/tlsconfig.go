@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// tlsVersions are the values -tls-min-version accepts. Older versions fall short of any security baseline.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSOptions turns -tls-min-version and -tls-cipher-suites into the options applied to the TLS config of the
+// webhook and metrics servers
+func parseTLSOptions(minVersion, cipherSuites string) ([]func(*tls.Config), error) {
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid -tls-min-version %q, must be 1.2 or 1.3", minVersion)
+	}
+
+	suites, err := parseCipherSuites(cipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	// Go doesn't allow the TLS 1.3 cipher suites to be configured, so a list would silently have no effect
+	if len(suites) > 0 && version == tls.VersionTLS13 {
+		return nil, errors.New("-tls-cipher-suites only applies to TLS 1.2, and can't be set along with -tls-min-version=1.3")
+	}
+
+	return []func(*tls.Config){
+		func(c *tls.Config) {
+			c.MinVersion = version
+			if len(suites) > 0 {
+				c.CipherSuites = suites
+			}
+		},
+	}, nil
+}
+
+// parseCipherSuites parses the comma separated IANA names of -tls-cipher-suites, such as
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Suites Go considers insecure are refused.
+func parseCipherSuites(value string) ([]uint16, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	// TLS 1.3 suites are listed by Go as well, but can't be configured
+	known := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		if slices.Contains(suite.SupportedVersions, tls.VersionTLS12) {
+			known[suite.Name] = suite.ID
+		}
+	}
+	insecure := map[string]bool{}
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = true
+	}
+
+	var suites []uint16
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if insecure[name] {
+			return nil, fmt.Errorf("insecure cipher suite %s in -tls-cipher-suites", name)
+		}
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q in -tls-cipher-suites", name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}
@@ -0,0 +1,142 @@
+// Package events provides helpers around the Kubernetes event recorder.
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Events are recorded in the namespace of the object they are about
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// RateLimitedRecorder wraps an EventRecorder, letting at most Burst events of the same type and reason through for
+// each object within Window. Events beyond that are dropped and counted, and the count is appended to the message
+// of the next event that is let through, e.g. "... (12 similar events suppressed in the last 10m0s)", so that a
+// flapping object can't flood the cluster with events.
+type RateLimitedRecorder struct {
+	Recorder record.EventRecorder
+	Window   time.Duration
+	Burst    int
+
+	now       func() time.Time
+	mu        sync.Mutex
+	buckets   map[eventKey]*eventBucket
+	lastPrune time.Time
+}
+
+// eventKey identifies the events of a single object that are limited together
+type eventKey struct {
+	object    string
+	eventtype string
+	reason    string
+}
+
+// eventBucket counts the events of a key within the current window
+type eventBucket struct {
+	windowStart time.Time
+	sent        int
+	suppressed  int
+}
+
+// NewRateLimitedRecorder returns recorder limited to burst events per object, type and reason within window
+func NewRateLimitedRecorder(recorder record.EventRecorder, window time.Duration, burst int) *RateLimitedRecorder {
+	return &RateLimitedRecorder{
+		Recorder: recorder,
+		Window:   window,
+		Burst:    burst,
+	}
+}
+
+// Event records the event unless the limit of the object has been reached
+func (r *RateLimitedRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if message, ok := r.allow(object, eventtype, reason, message); ok {
+		r.Recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf is just like Event, but with Sprintf for the message field
+func (r *RateLimitedRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf is just like Eventf, but with annotations attached
+func (r *RateLimitedRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if message, ok := r.allow(object, eventtype, reason, fmt.Sprintf(messageFmt, args...)); ok {
+		r.Recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+// allow reports whether an event may be recorded, returning its message with any suppressed events accounted for
+func (r *RateLimitedRecorder) allow(object runtime.Object, eventtype, reason, message string) (string, bool) {
+	if r.Burst <= 0 || r.Window <= 0 {
+		return message, true
+	}
+
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		// Object references and the like can't be told apart, so they aren't limited
+		return message, true
+	}
+	key := eventKey{
+		object:    fmt.Sprintf("%T/%s/%s", object, accessor.GetNamespace(), accessor.GetName()),
+		eventtype: eventtype,
+		reason:    reason,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock()
+	r.prune(now)
+
+	if r.buckets == nil {
+		r.buckets = map[eventKey]*eventBucket{}
+	}
+	bucket, ok := r.buckets[key]
+	if !ok || now.Sub(bucket.windowStart) >= r.Window {
+		suppressed := 0
+		if ok {
+			suppressed = bucket.suppressed
+		}
+		bucket = &eventBucket{windowStart: now}
+		r.buckets[key] = bucket
+
+		if suppressed > 0 {
+			message = fmt.Sprintf("%s (%d similar events suppressed in the last %s)", message, suppressed, r.Window)
+		}
+	}
+
+	if bucket.sent >= r.Burst {
+		bucket.suppressed++
+		return "", false
+	}
+	bucket.sent++
+	return message, true
+}
+
+// prune forgets objects without events for a full window, at most once per window. Objects that still had
+// suppressed events are kept until their count has been reported.
+func (r *RateLimitedRecorder) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < r.Window {
+		return
+	}
+	r.lastPrune = now
+
+	for key, bucket := range r.buckets {
+		if bucket.suppressed == 0 && now.Sub(bucket.windowStart) >= r.Window {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+func (r *RateLimitedRecorder) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
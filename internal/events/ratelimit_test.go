@@ -0,0 +1,97 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// drain returns the events recorded so far
+func drain(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRateLimitedRecorder(t *testing.T) {
+	fake := record.NewFakeRecorder(100)
+	now := time.Now()
+	recorder := NewRateLimitedRecorder(fake, 10*time.Minute, 2)
+	recorder.now = func() time.Time { return now }
+
+	podA := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test"}}
+	podB := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "test"}}
+
+	// Only the burst gets through, per object, type and reason
+	for range 5 {
+		recorder.Event(podA, corev1.EventTypeWarning, "SyncFailed", "failed")
+	}
+	recorder.Eventf(podA, corev1.EventTypeNormal, "Synced", "synced %s", "a")
+	recorder.Event(podB, corev1.EventTypeWarning, "SyncFailed", "failed")
+	assert.Equal(t, []string{
+		"Warning SyncFailed failed",
+		"Warning SyncFailed failed",
+		"Normal Synced synced a",
+		"Warning SyncFailed failed",
+	}, drain(fake))
+
+	// The next window reports what was suppressed
+	now = now.Add(10 * time.Minute)
+	recorder.Event(podA, corev1.EventTypeWarning, "SyncFailed", "failed")
+	recorder.Event(podA, corev1.EventTypeWarning, "SyncFailed", "failed")
+	assert.Equal(t, []string{
+		"Warning SyncFailed failed (3 similar events suppressed in the last 10m0s)",
+		"Warning SyncFailed failed",
+	}, drain(fake))
+}
+
+func TestRateLimitedRecorder_Prune(t *testing.T) {
+	now := time.Now()
+	recorder := NewRateLimitedRecorder(record.NewFakeRecorder(100), time.Minute, 1)
+	recorder.now = func() time.Time { return now }
+
+	podA := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test"}}
+	podB := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "test"}}
+
+	recorder.Event(podA, corev1.EventTypeWarning, "SyncFailed", "failed")
+	recorder.Event(podB, corev1.EventTypeWarning, "SyncFailed", "failed")
+	recorder.Event(podB, corev1.EventTypeWarning, "SyncFailed", "failed")
+	assert.Len(t, recorder.buckets, 2)
+
+	// podA is forgotten, podB is kept until its suppressed event has been reported
+	now = now.Add(time.Minute)
+	recorder.Event(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "test"}}, corev1.EventTypeNormal, "Synced", "synced")
+	assert.Len(t, recorder.buckets, 2)
+	assert.NotContains(t, recorder.buckets, eventKey{object: "*v1.Pod/test/a", eventtype: corev1.EventTypeWarning, reason: "SyncFailed"})
+}
+
+func TestRateLimitedRecorder_Disabled(t *testing.T) {
+	fake := record.NewFakeRecorder(100)
+	recorder := NewRateLimitedRecorder(fake, 10*time.Minute, 0)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test"}}
+	for range 3 {
+		recorder.Event(pod, corev1.EventTypeWarning, "SyncFailed", "failed")
+	}
+	assert.Len(t, drain(fake), 3)
+}
+
+func TestRateLimitedRecorder_AnnotatedEventf(t *testing.T) {
+	fake := record.NewFakeRecorder(100)
+	recorder := NewRateLimitedRecorder(fake, 10*time.Minute, 1)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test"}}
+	recorder.AnnotatedEventf(pod, map[string]string{"key": "value"}, corev1.EventTypeWarning, "SyncFailed", "failed %d%%", 100)
+	recorder.AnnotatedEventf(pod, map[string]string{"key": "value"}, corev1.EventTypeWarning, "SyncFailed", "failed %d%%", 100)
+	assert.Equal(t, []string{"Warning SyncFailed failed 100% map[key:value]"}, drain(fake))
+}
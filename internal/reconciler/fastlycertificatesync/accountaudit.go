@@ -0,0 +1,194 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	accountCustomCertificates = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fastly_account_custom_certificates",
+		Help: "Custom TLS certificates in the Fastly account, as of the last account audit",
+	}, []string{"account"})
+
+	accountPrivateKeys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fastly_account_private_keys",
+		Help: "TLS private keys in the Fastly account, as of the last account audit",
+	}, []string{"account"})
+
+	accountUnusedPrivateKeys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fastly_account_unused_private_keys",
+		Help: "TLS private keys in the Fastly account that no certificate uses, as of the last account audit",
+	}, []string{"account"})
+
+	accountTLSActivations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fastly_account_tls_activations",
+		Help: "TLS activations in the Fastly account, as of the last account audit",
+	}, []string{"account"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(accountCustomCertificates, accountPrivateKeys, accountUnusedPrivateKeys, accountTLSActivations)
+}
+
+// AccountAudit periodically counts the objects in every Fastly account the operator talks to, so that growth can be
+// tracked against Fastly's account limits, and creations refused once a configured limit is reached
+type AccountAudit struct {
+	Logic    *Logic
+	Client   client.Reader
+	Interval time.Duration
+}
+
+// accountTotals are the object counts of a single Fastly account
+type accountTotals struct {
+	customCertificates int
+	privateKeys        int
+	unusedPrivateKeys  int
+	tlsActivations     int
+}
+
+// NeedLeaderElection only audits from the leader, there is no need to list every account from each replica
+func (a *AccountAudit) NeedLeaderElection() bool {
+	return true
+}
+
+// Start audits the accounts every interval until the context is cancelled
+func (a *AccountAudit) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("fastly-account-audit")
+
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.auditAccounts(ctx, log)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *AccountAudit) auditAccounts(ctx context.Context, log logr.Logger) {
+	clients, err := a.Logic.accountClients(ctx, a.Client)
+	if err != nil {
+		log.Error(err, "failed to resolve Fastly accounts")
+	}
+
+	for _, account := range slices.Sorted(maps.Keys(clients)) {
+		totals, err := countAccountTotals(ctx, clients[account])
+		if err != nil {
+			log.Error(err, "failed to audit Fastly account", "account", account)
+
+			// Drop the account's series rather than report stale totals
+			accountCustomCertificates.DeleteLabelValues(account)
+			accountPrivateKeys.DeleteLabelValues(account)
+			accountUnusedPrivateKeys.DeleteLabelValues(account)
+			accountTLSActivations.DeleteLabelValues(account)
+			deleteAccountQuotaMetrics(account)
+			a.Logic.accountQuotas.Forget(account)
+			continue
+		}
+
+		accountCustomCertificates.WithLabelValues(account).Set(float64(totals.customCertificates))
+		accountPrivateKeys.WithLabelValues(account).Set(float64(totals.privateKeys))
+		accountUnusedPrivateKeys.WithLabelValues(account).Set(float64(totals.unusedPrivateKeys))
+		accountTLSActivations.WithLabelValues(account).Set(float64(totals.tlsActivations))
+		setAccountQuotaMetrics(a.Logic.Config, account, totals)
+		a.Logic.accountQuotas.Set(account, totals)
+	}
+}
+
+// accountClients returns a client for the default account and for each account a namespace is mapped to, labeled
+// the same way as fastlyAccount. Accounts whose token can't be resolved are skipped and reported in the error.
+func (l *Logic) accountClients(ctx context.Context, reader client.Reader) (map[string]FastlyClientInterface, error) {
+	clients := map[string]FastlyClientInterface{}
+	if l.FastlyClient != nil {
+		clients["default"] = l.FastlyClient
+	}
+
+	var errs []error
+	for _, secretName := range l.Config.FastlyTokenSecretsByNamespace {
+		account := "secret/" + secretName
+		if _, ok := clients[account]; ok {
+			continue
+		}
+
+		key := types.NamespacedName{Name: secretName, Namespace: l.Config.FastlyTokenSecretNamespace}
+		fastlyClient, err := l.fastlyClientForSecret(ctx, reader, key, l.Config.FastlyTokenSecretKey)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create Fastly client for %s: %w", account, err))
+			continue
+		}
+		clients[account] = fastlyClient
+	}
+
+	return clients, joinErrors(errs)
+}
+
+func countAccountTotals(ctx context.Context, fastlyClient FastlyClientInterface) (accountTotals, error) {
+	var totals accountTotals
+	var err error
+
+	totals.customCertificates, err = countFastlyPages(func(page int) (int, error) {
+		certs, err := fastlyClient.ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+		return len(certs), err
+	})
+	if err != nil {
+		return totals, fmt.Errorf("failed to list Fastly certificates: %w", err)
+	}
+
+	totals.privateKeys, err = countFastlyPages(func(page int) (int, error) {
+		keys, err := fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+		return len(keys), err
+	})
+	if err != nil {
+		return totals, fmt.Errorf("failed to list Fastly private keys: %w", err)
+	}
+
+	totals.unusedPrivateKeys, err = countFastlyPages(func(page int) (int, error) {
+		keys, err := fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{FilterInUse: "false", PageNumber: page, PageSize: defaultFastlyPageSize})
+		return len(keys), err
+	})
+	if err != nil {
+		return totals, fmt.Errorf("failed to list unused Fastly private keys: %w", err)
+	}
+
+	totals.tlsActivations, err = countFastlyPages(func(page int) (int, error) {
+		activations, err := fastlyClient.ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+		return len(activations), err
+	})
+	if err != nil {
+		return totals, fmt.Errorf("failed to list Fastly TLS activations: %w", err)
+	}
+
+	return totals, nil
+}
+
+// countFastlyPages sums the items of every page returned by list, stopping at the first page that isn't full
+func countFastlyPages(list func(page int) (int, error)) (int, error) {
+	total := 0
+	for page := 1; ; page++ {
+		n, err := list(page)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+
+		if n < defaultFastlyPageSize {
+			return total, nil
+		}
+	}
+}
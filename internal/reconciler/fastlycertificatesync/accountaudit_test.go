@@ -0,0 +1,103 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCountFastlyPages(t *testing.T) {
+	pages := []int{defaultFastlyPageSize, defaultFastlyPageSize, 3}
+	total, err := countFastlyPages(func(page int) (int, error) {
+		return pages[page-1], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2*defaultFastlyPageSize+3, total)
+
+	_, err = countFastlyPages(func(page int) (int, error) {
+		return 0, errors.New("unavailable")
+	})
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestAccountAudit_auditAccounts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a-token", Namespace: "operator"},
+		Data:       map[string][]byte{"api-key": []byte("team-a")},
+	}).Build()
+
+	defaultClient := &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			if input.PageNumber == 1 {
+				return make([]*fastly.CustomTLSCertificate, defaultFastlyPageSize), nil
+			}
+			return make([]*fastly.CustomTLSCertificate, 5), nil
+		},
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			if input.FilterInUse == "false" {
+				return make([]*fastly.PrivateKey, 2), nil
+			}
+			return make([]*fastly.PrivateKey, 7), nil
+		},
+		ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+			return make([]*fastly.TLSActivation, 11), nil
+		},
+	}
+	teamClient := &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			return nil, errors.New("unauthorized")
+		},
+	}
+
+	audit := &AccountAudit{
+		Logic: &Logic{
+			FastlyClient: defaultClient,
+			NewFastlyClient: func(token string) (FastlyClientInterface, error) {
+				assert.Equal(t, "team-a", token)
+				return teamClient, nil
+			},
+			Config: RuntimeConfig{
+				FastlyTokenSecretsByNamespace: map[string]string{"team-a": "team-a-token", "team-b": "team-a-token"},
+				FastlyTokenSecretNamespace:    "operator",
+				FastlyTokenSecretKey:          "api-key",
+			},
+		},
+		Client: fakeClient,
+	}
+	t.Cleanup(func() {
+		for _, gauge := range []*prometheus.GaugeVec{accountCustomCertificates, accountPrivateKeys, accountUnusedPrivateKeys, accountTLSActivations} {
+			gauge.Reset()
+		}
+	})
+
+	// A stale series of an account that can no longer be audited is dropped
+	accountCustomCertificates.WithLabelValues("secret/team-a-token").Set(1)
+
+	audit.auditAccounts(context.Background(), logr.Discard())
+
+	assert.Equal(t, float64(defaultFastlyPageSize+5), gaugeValue(t, accountCustomCertificates, "default"))
+	assert.Equal(t, float64(7), gaugeValue(t, accountPrivateKeys, "default"))
+	assert.Equal(t, float64(2), gaugeValue(t, accountUnusedPrivateKeys, "default"))
+	assert.Equal(t, float64(11), gaugeValue(t, accountTLSActivations, "default"))
+	assert.False(t, accountCustomCertificates.DeleteLabelValues("secret/team-a-token"))
+}
+
+func gaugeValue(t *testing.T, gauge *prometheus.GaugeVec, label string) float64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	require.NoError(t, gauge.WithLabelValues(label).Write(metric))
+	return metric.GetGauge().GetValue()
+}
@@ -0,0 +1,163 @@
+package fastlycertificatesync
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FastlyClientFactory creates a Fastly client authenticated with the given API token
+type FastlyClientFactory func(token string) (FastlyClientInterface, error)
+
+// ParseNamespaceTokenSecrets parses a comma separated list of `namespace=secret` pairs into a map of
+// namespace -> token secret name. An empty string yields an empty map.
+func ParseNamespaceTokenSecrets(s string) (map[string]string, error) {
+	res := map[string]string{}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		namespace, secretName, ok := strings.Cut(pair, "=")
+		namespace, secretName = strings.TrimSpace(namespace), strings.TrimSpace(secretName)
+		if !ok || namespace == "" || secretName == "" {
+			return nil, fmt.Errorf("invalid namespace token secret mapping %q, expected namespace=secret", pair)
+		}
+		if _, exists := res[namespace]; exists {
+			return nil, fmt.Errorf("namespace %q is mapped to more than one token secret", namespace)
+		}
+		res[namespace] = secretName
+	}
+
+	return res, nil
+}
+
+// resolveFastlyClient selects the Fastly client used for the rest of this reconciliation.
+// Subjects in a namespace mapped to a dedicated token secret talk to Fastly with that token, everything else
+// uses the operator's default client.
+func (l *Logic) resolveFastlyClient(ctx *Context) error {
+	l.namespaceFastlyClient = nil
+	l.namespaceFastlyAccount = ""
+
+	secretName, ok := ctx.Config.FastlyTokenSecretsByNamespace[ctx.Subject.Namespace]
+	if !ok {
+		return nil
+	}
+
+	client, err := l.fastlyClientForSecret(ctx, ctx.Client.Client, types.NamespacedName{Name: secretName, Namespace: ctx.Config.FastlyTokenSecretNamespace}, ctx.Config.FastlyTokenSecretKey)
+	if err != nil {
+		return fmt.Errorf("failed to create Fastly client for namespace %s: %w", ctx.Subject.Namespace, err)
+	}
+
+	ctx.Log.V(5).Info("using namespace specific Fastly token", "token_secret", secretName)
+	l.namespaceFastlyClient = client
+	l.namespaceFastlyAccount = secretName
+
+	return nil
+}
+
+// resolveFastlyAccountClient selects the Fastly client used for one of the accounts in spec.accounts. Accounts
+// without a token secret of their own use the client the subject's namespace is routed to.
+func (l *Logic) resolveFastlyAccountClient(ctx *Context, account v1alpha1.FastlyAccount) error {
+	if account.TokenSecretRef == nil {
+		return l.resolveFastlyClient(ctx)
+	}
+
+	key := cmp.Or(account.TokenSecretRef.Key, ctx.Config.FastlyTokenSecretKey)
+	secret := types.NamespacedName{Name: account.TokenSecretRef.Name, Namespace: ctx.Subject.Namespace}
+	client, err := l.fastlyClientForSecret(ctx, ctx.Client.Client, secret, key)
+	if err != nil {
+		return fmt.Errorf("failed to create Fastly client for account %s: %w", account.Name, err)
+	}
+
+	ctx.Log.V(5).Info("using account specific Fastly token", "account", account.Name, "token_secret", secret.String())
+	l.namespaceFastlyClient = client
+	l.namespaceFastlyAccount = secret.String()
+
+	return nil
+}
+
+// cachedFastlyClient is a client created from the token of a secret, as of the secret's resource version
+type cachedFastlyClient struct {
+	key             string
+	resourceVersion string
+	client          FastlyClientInterface
+}
+
+// fastlyClientForSecret returns a client authenticated with the API token held by the secret under the given key.
+// Clients are cached by secret rather than by token, so that tokens aren't kept around once they are rotated: a
+// change to the secret replaces its client, and a deleted secret drops it.
+func (l *Logic) fastlyClientForSecret(ctx context.Context, reader client.Reader, name types.NamespacedName, key string) (FastlyClientInterface, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, name, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.evictFastlyClient(name)
+		}
+		return nil, fmt.Errorf("failed to get Fastly token secret of name %s and namespace %s: %w", name.Name, name.Namespace, err)
+	}
+
+	l.fastlyClientsMu.Lock()
+	defer l.fastlyClientsMu.Unlock()
+
+	if cached, ok := l.fastlyClientsBySecret[name]; ok && cached.key == key && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+	delete(l.fastlyClientsBySecret, name)
+
+	token, ok := secret.Data[key]
+	if !ok || len(token) == 0 {
+		return nil, fmt.Errorf("secret %s/%s does not contain %s", secret.Namespace, secret.Name, key)
+	}
+
+	if l.NewFastlyClient == nil {
+		return nil, fmt.Errorf("no Fastly client factory configured")
+	}
+
+	client, err := l.NewFastlyClient(string(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if l.fastlyClientsBySecret == nil {
+		l.fastlyClientsBySecret = map[types.NamespacedName]cachedFastlyClient{}
+	}
+	l.fastlyClientsBySecret[name] = cachedFastlyClient{key: key, resourceVersion: secret.ResourceVersion, client: client}
+
+	return client, nil
+}
+
+// evictFastlyClient drops the cached client of the secret
+func (l *Logic) evictFastlyClient(name types.NamespacedName) {
+	l.fastlyClientsMu.Lock()
+	defer l.fastlyClientsMu.Unlock()
+	delete(l.fastlyClientsBySecret, name)
+}
+
+// fastlyClient returns the Fastly client resolved for the current subject
+func (l *Logic) fastlyClient() FastlyClientInterface {
+	client := l.FastlyClient
+	if l.namespaceFastlyClient != nil {
+		client = l.namespaceFastlyClient
+	}
+	if l.fastlyCalls != nil {
+		return &countingFastlyClient{next: client, calls: l.fastlyCalls}
+	}
+	return client
+}
+
+// fastlyAccount identifies the Fastly account that the resolved client talks to
+func (l *Logic) fastlyAccount() string {
+	if l.namespaceFastlyClient != nil {
+		return "secret/" + l.namespaceFastlyAccount
+	}
+	return "default"
+}
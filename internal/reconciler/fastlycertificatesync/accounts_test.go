@@ -0,0 +1,276 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseNamespaceTokenSecrets(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      map[string]string
+		expectedError string
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			expected: map[string]string{},
+		},
+		{
+			name:     "single pair",
+			input:    "team-a=fastly-team-a",
+			expected: map[string]string{"team-a": "fastly-team-a"},
+		},
+		{
+			name:     "multiple pairs with whitespace and trailing comma",
+			input:    " team-a = fastly-team-a, team-b=fastly-team-b,",
+			expected: map[string]string{"team-a": "fastly-team-a", "team-b": "fastly-team-b"},
+		},
+		{
+			name:          "missing separator",
+			input:         "team-a",
+			expectedError: "expected namespace=secret",
+		},
+		{
+			name:          "missing secret name",
+			input:         "team-a=",
+			expectedError: "expected namespace=secret",
+		},
+		{
+			name:          "duplicate namespace",
+			input:         "team-a=one,team-a=two",
+			expectedError: "mapped to more than one token secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseNamespaceTokenSecrets(tt.input)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestLogic_resolveFastlyClient(t *testing.T) {
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fastly-team-token",
+			Namespace: "operator-namespace",
+		},
+		Data: map[string][]byte{
+			"api-key": []byte("team-token"),
+		},
+	}
+
+	tests := []struct {
+		name             string
+		mapping          map[string]string
+		setupObjects     []client.Object
+		factoryError     error
+		expectNamespaced bool
+		expectedTokens   []string
+		expectedError    string
+	}{
+		{
+			name:             "namespace not mapped uses default client",
+			mapping:          map[string]string{"other-namespace": "fastly-team-token"},
+			expectNamespaced: false,
+		},
+		{
+			name:             "namespace mapped uses token from secret",
+			mapping:          map[string]string{"test-namespace": "fastly-team-token"},
+			setupObjects:     []client.Object{tokenSecret},
+			expectNamespaced: true,
+			expectedTokens:   []string{"team-token"},
+		},
+		{
+			name:          "mapped secret missing",
+			mapping:       map[string]string{"test-namespace": "fastly-team-token"},
+			expectedError: "failed to get Fastly token secret",
+		},
+		{
+			name:    "mapped secret missing key",
+			mapping: map[string]string{"test-namespace": "fastly-team-token"},
+			setupObjects: []client.Object{&corev1.Secret{
+				ObjectMeta: tokenSecret.ObjectMeta,
+				Data:       map[string][]byte{"other": []byte("value")},
+			}},
+			expectedError: "does not contain api-key",
+		},
+		{
+			name:          "client factory error",
+			mapping:       map[string]string{"test-namespace": "fastly-team-token"},
+			setupObjects:  []client.Object{tokenSecret},
+			factoryError:  errors.New("bad token"),
+			expectedError: "bad token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = corev1.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.setupObjects...).Build()
+
+			defaultClient := &MockFastlyClient{}
+			namespacedClient := &MockFastlyClient{}
+			var factoryTokens []string
+
+			logic := &Logic{
+				FastlyClient: defaultClient,
+				NewFastlyClient: func(token string) (FastlyClientInterface, error) {
+					factoryTokens = append(factoryTokens, token)
+					if tt.factoryError != nil {
+						return nil, tt.factoryError
+					}
+					return namespacedClient, nil
+				},
+			}
+
+			ctx := createTestContext()
+			ctx.Config.FastlyTokenSecretsByNamespace = tt.mapping
+			ctx.Config.FastlyTokenSecretNamespace = "operator-namespace"
+			ctx.Config.FastlyTokenSecretKey = "api-key"
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			err := logic.resolveFastlyClient(ctx)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Same(t, defaultClient, logic.fastlyClient())
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.expectNamespaced {
+				assert.Same(t, namespacedClient, logic.fastlyClient())
+			} else {
+				assert.Same(t, defaultClient, logic.fastlyClient())
+			}
+
+			// resolving again must reuse the cached client for the same token
+			require.NoError(t, logic.resolveFastlyClient(ctx))
+			assert.Equal(t, tt.expectedTokens, factoryTokens)
+		})
+	}
+}
+
+func TestLogic_resolveFastlyAccountClient(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "fastly-staging", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"api-key": []byte("staging-token"), "token": []byte("other-token")},
+		},
+	).Build()
+
+	defaultClient := &MockFastlyClient{}
+	var factoryTokens []string
+	logic := &Logic{
+		FastlyClient: defaultClient,
+		NewFastlyClient: func(token string) (FastlyClientInterface, error) {
+			factoryTokens = append(factoryTokens, token)
+			return &MockFastlyClient{}, nil
+		},
+	}
+
+	ctx := createTestContext()
+	ctx.Config.FastlyTokenSecretKey = "api-key"
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	// Accounts without a token secret use the client of the subject's namespace
+	require.NoError(t, logic.resolveFastlyAccountClient(ctx, v1alpha1.FastlyAccount{Name: "production"}))
+	assert.Same(t, defaultClient, logic.fastlyClient())
+	assert.Equal(t, "default", logic.fastlyAccount())
+
+	// The token is read from the subject's namespace, with the operator's token key by default
+	require.NoError(t, logic.resolveFastlyAccountClient(ctx, v1alpha1.FastlyAccount{
+		Name:           "staging",
+		TokenSecretRef: &v1alpha1.FastlyTokenSecretRef{Name: "fastly-staging"},
+	}))
+	assert.NotSame(t, defaultClient, logic.fastlyClient())
+	assert.Equal(t, "secret/test-namespace/fastly-staging", logic.fastlyAccount())
+
+	require.NoError(t, logic.resolveFastlyAccountClient(ctx, v1alpha1.FastlyAccount{
+		Name:           "staging",
+		TokenSecretRef: &v1alpha1.FastlyTokenSecretRef{Name: "fastly-staging", Key: "token"},
+	}))
+	assert.Equal(t, []string{"staging-token", "other-token"}, factoryTokens)
+
+	err := logic.resolveFastlyAccountClient(ctx, v1alpha1.FastlyAccount{
+		Name:           "missing",
+		TokenSecretRef: &v1alpha1.FastlyTokenSecretRef{Name: "fastly-missing"},
+	})
+	assert.ErrorContains(t, err, "failed to create Fastly client for account missing")
+}
+
+func TestLogic_fastlyClientForSecret_Cache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	name := types.NamespacedName{Name: "fastly-team-token", Namespace: "operator-namespace"}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+		Data:       map[string][]byte{"api-key": []byte("team-token")},
+	}).Build()
+
+	var factoryTokens []string
+	logic := &Logic{
+		NewFastlyClient: func(token string) (FastlyClientInterface, error) {
+			factoryTokens = append(factoryTokens, token)
+			return &MockFastlyClient{}, nil
+		},
+	}
+	ctx := context.Background()
+
+	first, err := logic.fastlyClientForSecret(ctx, fakeClient, name, "api-key")
+	require.NoError(t, err)
+	again, err := logic.fastlyClientForSecret(ctx, fakeClient, name, "api-key")
+	require.NoError(t, err)
+	assert.Same(t, first, again, "reused while the secret is unchanged")
+
+	// Rotating the token replaces the client created from the previous one
+	secret := &corev1.Secret{}
+	require.NoError(t, fakeClient.Get(ctx, name, secret))
+	secret.Data["api-key"] = []byte("rotated-token")
+	require.NoError(t, fakeClient.Update(ctx, secret))
+
+	rotated, err := logic.fastlyClientForSecret(ctx, fakeClient, name, "api-key")
+	require.NoError(t, err)
+	assert.NotSame(t, first, rotated)
+	assert.Equal(t, []string{"team-token", "rotated-token"}, factoryTokens)
+	assert.Len(t, logic.fastlyClientsBySecret, 1, "the client of the previous token is evicted")
+
+	// Deleting the secret drops its client
+	require.NoError(t, fakeClient.Delete(ctx, secret))
+	_, err = logic.fastlyClientForSecret(ctx, fakeClient, name, "api-key")
+	assert.ErrorContains(t, err, "failed to get Fastly token secret")
+	assert.Empty(t, logic.fastlyClientsBySecret)
+}
@@ -0,0 +1,80 @@
+package fastlycertificatesync
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// tlsConfigurationRank orders TLS configurations as tlsConfigurationIDs lists them, those it doesn't list last
+func (l *Logic) tlsConfigurationRank(ctx *Context) func(id string) int {
+	ids := l.tlsConfigurationIDs(ctx)
+	return func(id string) int {
+		if i := slices.Index(ids, id); i >= 0 {
+			return i
+		}
+		return len(ids)
+	}
+}
+
+// missingTLSActivationsByConfiguration groups the missing TLS activations by TLS configuration, in the order the
+// configurations are activated in
+func (l *Logic) missingTLSActivationsByConfiguration(ctx *Context) [][]TLSActivationData {
+	rank := l.tlsConfigurationRank(ctx)
+	missing := slices.Clone(l.ObservedState.MissingTLSActivationData)
+	slices.SortStableFunc(missing, func(a, b TLSActivationData) int {
+		return cmp.Or(cmp.Compare(rank(a.Configuration.ID), rank(b.Configuration.ID)), cmp.Compare(a.Configuration.ID, b.Configuration.ID))
+	})
+
+	var res [][]TLSActivationData
+	for _, data := range missing {
+		if n := len(res); n > 0 && res[n-1][0].Configuration.ID == data.Configuration.ID {
+			res[n-1] = append(res[n-1], data)
+			continue
+		}
+		res = append(res, []TLSActivationData{data})
+	}
+	return res
+}
+
+// createMissingFastlyTLSActivationsInOrder creates the missing TLS activations one TLS configuration after the other,
+// so that activations failing on a configuration listed first never reach those listed after it. Activations deferred
+// by the mutation budget hold back the later configurations too.
+func (l *Logic) createMissingFastlyTLSActivationsInOrder(ctx *Context) error {
+	batches := l.missingTLSActivationsByConfiguration(ctx)
+	for i, batch := range batches {
+		configurationID := batch[0].Configuration.ID
+		created, err := l.createFastlyTLSActivations(ctx, batch)
+		held := len(batches) - i - 1
+		if err != nil {
+			if held == 0 {
+				return err
+			}
+			return fmt.Errorf("%w, holding back the TLS activations on %d later TLS configuration(s)", err, held)
+		}
+		if !created {
+			ctx.Log.Info("holding back the TLS activations on later TLS configurations until those deferred are created",
+				"configuration_id", configurationID, "held_back_configurations", held)
+			return nil
+		}
+		ctx.Log.Info("created TLS activations on TLS configuration", "configuration_id", configurationID, "activations", len(batch))
+	}
+	return nil
+}
+
+// orderPreviousTLSActivations orders the TLS activations to move to a rotated certificate by TLS configuration, for
+// subjects activating in order. Activations are moved one at a time and the first failure stops the rest, so the
+// configurations listed first are moved, and fail, first.
+func (l *Logic) orderPreviousTLSActivations(ctx *Context) []previousTLSActivation {
+	activations := l.ObservedState.PreviousTLSActivations
+	if !ctx.Subject.ActivatesInOrder() {
+		return activations
+	}
+
+	rank := l.tlsConfigurationRank(ctx)
+	activations = slices.Clone(activations)
+	slices.SortStableFunc(activations, func(a, b previousTLSActivation) int {
+		return cmp.Or(cmp.Compare(rank(a.Data.Configuration.ID), rank(b.Data.Configuration.ID)), cmp.Compare(a.Data.Configuration.ID, b.Data.Configuration.ID))
+	})
+	return activations
+}
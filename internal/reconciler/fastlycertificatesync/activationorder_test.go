@@ -0,0 +1,101 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogic_createMissingFastlyTLSActivations_Ordered(t *testing.T) {
+	missing := func(configurationID, domain string) TLSActivationData {
+		return TLSActivationData{
+			Certificate:   &fastly.CustomTLSCertificate{ID: "cert1"},
+			Configuration: &fastly.TLSConfiguration{ID: configurationID},
+			Domain:        &fastly.TLSDomain{ID: domain},
+		}
+	}
+	newLogic := func(mockClient *MockFastlyClient) *Logic {
+		return &Logic{
+			Config:       RuntimeConfig{TLSActivationParallelism: 4},
+			FastlyClient: mockClient,
+			ObservedState: ObservedState{
+				MissingTLSActivationData: []TLSActivationData{
+					missing("high-traffic", "example.com"),
+					missing("canary", "example.com"),
+					missing("high-traffic", "www.example.com"),
+					missing("canary", "www.example.com"),
+				},
+			},
+		}
+	}
+	newContext := func() *Context {
+		ctx := createTestContext()
+		ctx.Subject.Spec.TLSConfigurationIds = []string{"canary", "high-traffic"}
+		ctx.Subject.Spec.TLSActivationOrder = v1alpha1.TLSActivationOrderOrdered
+		return ctx
+	}
+	configurationIDs := func(calls []*fastly.CreateTLSActivationInput) []string {
+		var res []string
+		for _, call := range calls {
+			res = append(res, call.Configuration.ID)
+		}
+		return res
+	}
+
+	t.Run("in the order listed", func(t *testing.T) {
+		mockClient := &MockFastlyClient{}
+		require.NoError(t, newLogic(mockClient).createMissingFastlyTLSActivations(newContext()))
+		assert.Equal(t, []string{"canary", "canary", "high-traffic", "high-traffic"}, configurationIDs(mockClient.CreateTLSActivationCalls))
+	})
+
+	t.Run("failure holds back later configurations", func(t *testing.T) {
+		mockClient := &MockFastlyClient{
+			CreateTLSActivationFunc: func(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error) {
+				if input.Domain.ID == "www.example.com" {
+					return nil, errors.New("rejected")
+				}
+				return &fastly.TLSActivation{}, nil
+			},
+		}
+
+		err := newLogic(mockClient).createMissingFastlyTLSActivations(newContext())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "holding back the TLS activations on 1 later TLS configuration(s)")
+		assert.Equal(t, []string{"canary", "canary"}, configurationIDs(mockClient.CreateTLSActivationCalls))
+	})
+
+	t.Run("parallel by default", func(t *testing.T) {
+		mockClient := &MockFastlyClient{
+			CreateTLSActivationFunc: func(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error) {
+				return nil, errors.New("rejected")
+			},
+		}
+		ctx := newContext()
+		ctx.Subject.Spec.TLSActivationOrder = ""
+
+		require.Error(t, newLogic(mockClient).createMissingFastlyTLSActivations(ctx))
+		assert.Len(t, mockClient.CreateTLSActivationCalls, 4, "every configuration is attempted")
+	})
+}
+
+func TestLogic_orderPreviousTLSActivations(t *testing.T) {
+	previous := func(id, configurationID string) previousTLSActivation {
+		return previousTLSActivation{ID: id, Data: TLSActivationData{Configuration: &fastly.TLSConfiguration{ID: configurationID}}}
+	}
+	logic := &Logic{ObservedState: ObservedState{
+		PreviousTLSActivations: []previousTLSActivation{previous("act1", "high-traffic"), previous("act2", "canary")},
+	}}
+	ctx := createTestContext()
+	ctx.Subject.Spec.TLSConfigurationIds = []string{"canary", "high-traffic"}
+
+	assert.Equal(t, logic.ObservedState.PreviousTLSActivations, logic.orderPreviousTLSActivations(ctx))
+
+	ctx.Subject.Spec.TLSActivationOrder = v1alpha1.TLSActivationOrderOrdered
+	assert.Equal(t, []previousTLSActivation{previous("act2", "canary"), previous("act1", "high-traffic")},
+		logic.orderPreviousTLSActivations(ctx))
+}
@@ -0,0 +1,110 @@
+package fastlycertificatesync
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// createdTLSActivationTTL is how long a created TLS activation is assumed to exist in Fastly, even when it is not
+// listed yet
+const createdTLSActivationTTL = 10 * time.Minute
+
+// tlsActivationProgress remembers the TLS activations created for each subject and Fastly account, for the subject's
+// current generation and Fastly certificate.
+//
+// When creating a batch of activations fails halfway through, the retry observes Fastly from scratch. Fastly's
+// activation listing is eventually consistent, so without this the retry may try to create activations again that
+// were just created, instead of resuming where it left off. Only activations Fastly confirmed creating are recorded,
+// and the progress of subjects that stop reconciling, e.g. because they were deleted, is pruned once it expires.
+type tlsActivationProgress struct {
+	mu        sync.Mutex
+	bySubject map[tlsActivationProgressKey]*subjectTLSActivationProgress
+	now       func() time.Time
+}
+
+type tlsActivationProgressKey struct {
+	subject types.NamespacedName
+	account string
+}
+
+type subjectTLSActivationProgress struct {
+	generation    int64
+	certificateID string
+	created       map[string]time.Time
+}
+
+func (p *tlsActivationProgress) key(domainID, configurationID string) string {
+	return domainID + "/" + configurationID
+}
+
+func (p *tlsActivationProgress) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// Add records that the activation of the certificate for a domain and configuration was created. Progress recorded
+// for an older generation or another certificate is discarded.
+func (p *tlsActivationProgress) Add(subject types.NamespacedName, account string, generation int64, certificateID, domainID, configurationID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock()
+	p.prune(now, createdTLSActivationTTL)
+
+	if p.bySubject == nil {
+		p.bySubject = map[tlsActivationProgressKey]*subjectTLSActivationProgress{}
+	}
+	progress := p.bySubject[tlsActivationProgressKey{subject, account}]
+	if progress == nil || progress.generation != generation || progress.certificateID != certificateID {
+		progress = &subjectTLSActivationProgress{
+			generation:    generation,
+			certificateID: certificateID,
+			created:       map[string]time.Time{},
+		}
+		p.bySubject[tlsActivationProgressKey{subject, account}] = progress
+	}
+	progress.created[p.key(domainID, configurationID)] = now
+}
+
+// Contains reports whether the activation was created within the ttl, for the same generation and certificate
+func (p *tlsActivationProgress) Contains(subject types.NamespacedName, account string, generation int64, certificateID, domainID, configurationID string, ttl time.Duration) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(p.clock(), ttl)
+
+	progress := p.bySubject[tlsActivationProgressKey{subject, account}]
+	if progress == nil || progress.generation != generation || progress.certificateID != certificateID {
+		return false
+	}
+
+	_, ok := progress.created[p.key(domainID, configurationID)]
+	return ok
+}
+
+// Forget discards the progress recorded for the subject in the account, once Fastly lists every activation
+func (p *tlsActivationProgress) Forget(subject types.NamespacedName, account string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.bySubject, tlsActivationProgressKey{subject, account})
+}
+
+// prune drops the activations created longer than the ttl ago, along with subjects left without any, the caller must
+// hold the lock
+func (p *tlsActivationProgress) prune(now time.Time, ttl time.Duration) {
+	for key, progress := range p.bySubject {
+		for activation, createdAt := range progress.created {
+			if now.Sub(createdAt) >= ttl {
+				delete(progress.created, activation)
+			}
+		}
+		if len(progress.created) == 0 {
+			delete(p.bySubject, key)
+		}
+	}
+}
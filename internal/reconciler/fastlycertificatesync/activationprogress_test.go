@@ -0,0 +1,102 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTLSActivationProgress(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	progress := &tlsActivationProgress{now: func() time.Time { return now }}
+	subject := types.NamespacedName{Namespace: "ns", Name: "a"}
+
+	assert.False(t, progress.Contains(subject, "default", 1, "cert1", "example.com", "config1", time.Minute), "empty progress contains nothing")
+
+	progress.Add(subject, "default", 1, "cert1", "example.com", "config1")
+	assert.True(t, progress.Contains(subject, "default", 1, "cert1", "example.com", "config1", time.Minute))
+	assert.False(t, progress.Contains(subject, "default", 1, "cert1", "example.com", "config2", time.Minute), "other configurations are not created")
+	assert.False(t, progress.Contains(subject, "default", 2, "cert1", "example.com", "config1", time.Minute), "progress is scoped to the generation")
+	assert.False(t, progress.Contains(subject, "default", 1, "cert2", "example.com", "config1", time.Minute), "progress is scoped to the certificate")
+	assert.False(t, progress.Contains(types.NamespacedName{Namespace: "ns", Name: "b"}, "default", 1, "cert1", "example.com", "config1", time.Minute), "progress is scoped to the subject")
+
+	now = now.Add(time.Minute)
+	assert.False(t, progress.Contains(subject, "default", 1, "cert1", "example.com", "config1", time.Minute), "progress expires after the ttl")
+
+	progress.Add(subject, "default", 1, "cert1", "example.com", "config1")
+	progress.Add(subject, "default", 2, "cert1", "example.com", "config2")
+	assert.False(t, progress.Contains(subject, "default", 1, "cert1", "example.com", "config1", time.Minute), "a newer generation discards older progress")
+	assert.True(t, progress.Contains(subject, "default", 2, "cert1", "example.com", "config2", time.Minute))
+
+	progress.Forget(subject, "default")
+	assert.False(t, progress.Contains(subject, "default", 2, "cert1", "example.com", "config2", time.Minute))
+
+	// Subjects that stop reconciling, e.g. because they were deleted, don't linger
+	progress.Add(types.NamespacedName{Namespace: "ns", Name: "deleted"}, "default", 1, "cert1", "example.com", "config1")
+	now = now.Add(createdTLSActivationTTL)
+	progress.Add(subject, "default", 1, "cert1", "example.com", "config1")
+	assert.Len(t, progress.bySubject, 1, "expired progress of other subjects is pruned")
+}
+
+func TestLogic_getFastlyTLSActivationState_ResumesProgress(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&cmv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+	}).Build()
+
+	fastlyCertificate := &fastly.CustomTLSCertificate{
+		ID:      "cert1",
+		Name:    "test-certificate",
+		Domains: []*fastly.TLSDomain{{ID: "a.example.com"}, {ID: "b.example.com"}},
+	}
+	listed := []*fastly.TLSActivation{}
+	logic := &Logic{
+		FastlyClient: &MockFastlyClient{
+			ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+				return []*fastly.CustomTLSCertificate{fastlyCertificate}, nil
+			},
+			ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+				return listed, nil
+			},
+		},
+	}
+
+	ctx := createTestContext()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	ctx.Subject.Spec.TLSConfigurationIds = []string{"config1"}
+
+	// The first activation was created by a batch that failed halfway, but isn't listed by Fastly yet
+	logic.tlsActivationProgress.Add(ctx.NamespacedName, "default", ctx.Subject.Generation, "cert1", "a.example.com", "config1")
+
+	missing, extra, err := logic.getFastlyTLSActivationState(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, extra)
+	require.Len(t, missing, 1, "only the activation that wasn't created should be retried")
+	assert.Equal(t, "b.example.com", missing[0].Domain.ID)
+
+	// Once Fastly lists every activation, the progress is no longer needed
+	logic.ObservedState = ObservedState{}
+	listed = []*fastly.TLSActivation{
+		{ID: "activation1", Domain: &fastly.TLSDomain{ID: "a.example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}},
+		{ID: "activation2", Domain: &fastly.TLSDomain{ID: "b.example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}},
+	}
+	missing, _, err = logic.getFastlyTLSActivationState(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	assert.Empty(t, logic.tlsActivationProgress.bySubject)
+}
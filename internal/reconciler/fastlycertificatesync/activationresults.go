@@ -0,0 +1,133 @@
+package fastlycertificatesync
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recordTLSActivationResult keeps the outcome of a TLS activation attempt, to be reported in status along with the ID
+// of the activation Fastly created
+func (l *Logic) recordTLSActivationResult(activationData TLSActivationData, activation *fastly.TLSActivation, err error) {
+	result := v1alpha1.TLSActivationResult{
+		Domain:          activationData.Domain.ID,
+		ConfigurationID: activationData.Configuration.ID,
+		State:           v1alpha1.TLSActivationStateCreated,
+		LastAttemptTime: kmetav1.Now(),
+		Account:         l.accountName(),
+	}
+	if err != nil {
+		result.State = v1alpha1.TLSActivationStateFailed
+		result.Message = err.Error()
+	} else if activation != nil {
+		result.ActivationID = activation.ID
+	}
+
+	l.ObservedState.TLSActivationResults = append(l.ObservedState.TLSActivationResults, result)
+}
+
+// isTLSActivationCreated reports whether the activation of the certificate for the domain and configuration was
+// created recently, though Fastly doesn't list it yet. Activations created by this process are remembered in
+// tlsActivationProgress. Those created before a restart, or by another replica, are looked up by the ID recorded in
+// status.tlsActivationResults.
+func (l *Logic) isTLSActivationCreated(ctx *Context, cert *fastly.CustomTLSCertificate, domainID, configID string) bool {
+	if l.tlsActivationProgress.Contains(ctx.NamespacedName, l.fastlyAccount(), ctx.Subject.Generation, cert.ID, domainID, configID, createdTLSActivationTTL) {
+		return true
+	}
+
+	idx := slices.IndexFunc(ctx.Subject.Status.TLSActivationResults, func(r v1alpha1.TLSActivationResult) bool {
+		return r.Account == l.accountName() && r.Domain == domainID && r.ConfigurationID == configID
+	})
+	if idx < 0 {
+		return false
+	}
+	result := ctx.Subject.Status.TLSActivationResults[idx]
+	if result.State != v1alpha1.TLSActivationStateCreated || result.ActivationID == "" ||
+		time.Since(result.LastAttemptTime.Time) >= createdTLSActivationTTL {
+		return false
+	}
+
+	activation, err := l.fastlyClient().GetTLSActivation(ctx, &fastly.GetTLSActivationInput{ID: result.ActivationID})
+	if err != nil {
+		ctx.Log.V(1).Info("failed to look up recently created TLS activation, creating it again", "activation_id", result.ActivationID, "error", err.Error())
+		return false
+	}
+	return activation != nil && activation.Certificate != nil && activation.Certificate.ID == cert.ID
+}
+
+// mergeTLSActivationResults updates the previously reported results with newer ones for the same account, domain and
+// configuration, ordered by account, domain and configuration
+func mergeTLSActivationResults(previous, latest []v1alpha1.TLSActivationResult) []v1alpha1.TLSActivationResult {
+	if len(latest) == 0 {
+		return previous
+	}
+
+	res := slices.Clone(latest)
+	for _, prev := range previous {
+		if !slices.ContainsFunc(latest, func(r v1alpha1.TLSActivationResult) bool {
+			return r.Account == prev.Account && r.Domain == prev.Domain && r.ConfigurationID == prev.ConfigurationID
+		}) {
+			res = append(res, prev)
+		}
+	}
+
+	slices.SortFunc(res, func(a, b v1alpha1.TLSActivationResult) int {
+		return cmp.Or(cmp.Compare(a.Account, b.Account), cmp.Compare(a.Domain, b.Domain), cmp.Compare(a.ConfigurationID, b.ConfigurationID))
+	})
+	return res
+}
+
+// countFailedTLSActivations counts the reported activations that failed, in the given account when set
+func countFailedTLSActivations(results []v1alpha1.TLSActivationResult, account string) int {
+	n := 0
+	for _, result := range results {
+		if account != "" && result.Account != account {
+			continue
+		}
+		if result.State == v1alpha1.TLSActivationStateFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// tlsConfigurationStatuses reports the TLS activations of the certificate on each TLS configuration it is activated
+// on. Subjects syncing to several accounts activate on different configurations in each, which aren't reported.
+func (l *Logic) tlsConfigurationStatuses(ctx *Context) []v1alpha1.TLSConfigurationStatus {
+	cert := l.ObservedState.FastlyCertificate
+	if cert == nil || len(ctx.Subject.Spec.Accounts) > 0 {
+		return nil
+	}
+
+	missing := map[string][]string{}
+	for _, data := range l.ObservedState.MissingTLSActivationData {
+		missing[data.Configuration.ID] = append(missing[data.Configuration.ID], data.Domain.ID)
+	}
+
+	domains := len(activatedTLSDomains(ctx, cert))
+	var res []v1alpha1.TLSConfigurationStatus
+	for _, id := range l.tlsConfigurationIDs(ctx) {
+		res = append(res, v1alpha1.TLSConfigurationStatus{
+			ID:               id,
+			ActivatedDomains: domains - len(missing[id]),
+			MissingDomains:   missing[id],
+		})
+	}
+	return res
+}
+
+// patchApplyStatus persists status changes made while applying unmanaged changes, along with the outcome of any TLS
+// activations attempted. The status has already been written by the time unmanaged changes are applied, so it is
+// patched separately.
+func (l *Logic) patchApplyStatus(ctx *Context, mutate func(status *v1alpha1.FastlyCertificateSyncStatus)) error {
+	patch := client.MergeFrom(ctx.Subject.DeepCopy())
+	mutate(&ctx.Subject.Status)
+	ctx.Subject.Status.TLSActivationResults = mergeTLSActivationResults(ctx.Subject.Status.TLSActivationResults, l.ObservedState.TLSActivationResults)
+
+	return ctx.Client.Client.Status().Patch(ctx, ctx.Subject, patch)
+}
@@ -0,0 +1,223 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/apiobjects"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMergeTLSActivationResults(t *testing.T) {
+	result := func(domain, configID string, state v1alpha1.TLSActivationState) v1alpha1.TLSActivationResult {
+		return v1alpha1.TLSActivationResult{Domain: domain, ConfigurationID: configID, State: state}
+	}
+
+	previous := []v1alpha1.TLSActivationResult{
+		result("a.example.com", "config1", v1alpha1.TLSActivationStateCreated),
+		result("b.example.com", "config1", v1alpha1.TLSActivationStateFailed),
+	}
+
+	assert.Equal(t, previous, mergeTLSActivationResults(previous, nil))
+	assert.Equal(t, []v1alpha1.TLSActivationResult{
+		result("a.example.com", "config1", v1alpha1.TLSActivationStateCreated),
+		result("b.example.com", "config1", v1alpha1.TLSActivationStateCreated),
+		result("b.example.com", "config2", v1alpha1.TLSActivationStateFailed),
+	}, mergeTLSActivationResults(previous, []v1alpha1.TLSActivationResult{
+		result("b.example.com", "config2", v1alpha1.TLSActivationStateFailed),
+		result("b.example.com", "config1", v1alpha1.TLSActivationStateCreated),
+	}))
+}
+
+func TestLogic_ApplyUnmanaged_ReportsTLSActivationResults(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(ctx.Subject).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	mockClient := &MockFastlyClient{
+		CreateTLSActivationFunc: func(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error) {
+			if input.Domain.ID == "bad.example.com" {
+				return nil, errors.New("domain is not verified")
+			}
+			return &fastly.TLSActivation{ID: "activation"}, nil
+		},
+	}
+	certificate := &fastly.CustomTLSCertificate{ID: "cert1"}
+	logic := &Logic{
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded: true,
+			CertificateStatus:  CertificateStatusSynced,
+			MissingTLSActivationData: []TLSActivationData{
+				{Certificate: certificate, Configuration: &fastly.TLSConfiguration{ID: "config1"}, Domain: &fastly.TLSDomain{ID: "good.example.com"}},
+				{Certificate: certificate, Configuration: &fastly.TLSConfiguration{ID: "config1"}, Domain: &fastly.TLSDomain{ID: "bad.example.com"}},
+			},
+		},
+		SubjectReadyForReconciliation: true,
+	}
+
+	require.NoError(t, logic.ApplyUnmanaged(ctx))
+
+	stored := &v1alpha1.FastlyCertificateSync{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(ctx.Subject), stored))
+	results := stored.Status.TLSActivationResults
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "bad.example.com", results[0].Domain)
+	assert.Equal(t, "config1", results[0].ConfigurationID)
+	assert.Equal(t, v1alpha1.TLSActivationStateFailed, results[0].State)
+	assert.Equal(t, "domain is not verified", results[0].Message)
+	assert.Empty(t, results[0].ActivationID)
+
+	assert.Equal(t, "good.example.com", results[1].Domain)
+	assert.Equal(t, v1alpha1.TLSActivationStateCreated, results[1].State)
+	assert.Empty(t, results[1].Message)
+	assert.Equal(t, "activation", results[1].ActivationID)
+
+	// Only the created activation is remembered, so that a retry resumes with the failed one
+	assert.True(t, logic.tlsActivationProgress.Contains(ctx.NamespacedName, "default", ctx.Subject.Generation, "cert1", "good.example.com", "config1", createdTLSActivationTTL))
+	assert.False(t, logic.tlsActivationProgress.Contains(ctx.NamespacedName, "default", ctx.Subject.Generation, "cert1", "bad.example.com", "config1", createdTLSActivationTTL))
+}
+
+func TestLogic_FillStatus_TLSActivationResults(t *testing.T) {
+	failed := v1alpha1.TLSActivationResult{
+		Domain:          "bad.example.com",
+		ConfigurationID: "config1",
+		State:           v1alpha1.TLSActivationStateFailed,
+		Message:         "domain is not verified",
+	}
+
+	ctx := createTestContext()
+	ctx.Subject.Status.TLSActivationResults = []v1alpha1.TLSActivationResult{failed}
+	logic := &Logic{
+		SubjectReadyForReconciliation: true,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded:       true,
+			CertificateStatus:        CertificateStatusSynced,
+			MissingTLSActivationData: []TLSActivationData{{}},
+		},
+	}
+
+	require.NoError(t, logic.FillStatus(ctx, genrec.Resources{}, apiobjects.SubjectStatus{}))
+	assert.Equal(t, []v1alpha1.TLSActivationResult{failed}, ctx.Subject.Status.TLSActivationResults)
+
+	var condition *metav1.Condition
+	for i := range ctx.Subject.Status.Conditions {
+		if ctx.Subject.Status.Conditions[i].Type == "TLSActivationReady" {
+			condition = &ctx.Subject.Status.Conditions[i]
+		}
+	}
+	require.NotNil(t, condition)
+	assert.Equal(t, "TLSActivationsFailed", condition.Reason)
+	assert.Equal(t, "Missing 1 TLS activations that need to be created, 1 failed on the last attempt, see status.tlsActivationResults", condition.Message)
+
+	// Once every activation exists, the results are no longer reported
+	logic.ObservedState.MissingTLSActivationData = nil
+	require.NoError(t, logic.FillStatus(ctx, genrec.Resources{}, apiobjects.SubjectStatus{}))
+	assert.Nil(t, ctx.Subject.Status.TLSActivationResults)
+}
+
+func TestLogic_TLSConfigurationStatuses(t *testing.T) {
+	cert := &fastly.CustomTLSCertificate{
+		ID:      "cert1",
+		Domains: []*fastly.TLSDomain{{ID: "example.com"}, {ID: "www.example.com"}},
+	}
+	logic := &Logic{
+		ObservedState: ObservedState{
+			FastlyCertificate: cert,
+			MissingTLSActivationData: []TLSActivationData{
+				{Certificate: cert, Configuration: &fastly.TLSConfiguration{ID: "config2"}, Domain: &fastly.TLSDomain{ID: "www.example.com"}},
+			},
+		},
+	}
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.TLSConfigurationIds = []string{"config1", "config2"}
+
+	assert.Equal(t, []v1alpha1.TLSConfigurationStatus{
+		{ID: "config1", ActivatedDomains: 2},
+		{ID: "config2", ActivatedDomains: 1, MissingDomains: []string{"www.example.com"}},
+	}, logic.tlsConfigurationStatuses(ctx))
+
+	// Resources syncing to several accounts don't report their configurations
+	ctx.Subject.Spec.Accounts = []v1alpha1.FastlyAccount{{Name: "production"}}
+	assert.Nil(t, logic.tlsConfigurationStatuses(ctx))
+
+	// Nothing is activated without a certificate
+	ctx.Subject.Spec.Accounts = nil
+	logic.ObservedState.FastlyCertificate = nil
+	assert.Nil(t, logic.tlsConfigurationStatuses(ctx))
+}
+
+func TestLogic_isTLSActivationCreated(t *testing.T) {
+	cert := &fastly.CustomTLSCertificate{ID: "cert1"}
+	var lookups []string
+	mockClient := &MockFastlyClient{
+		GetTLSActivationFunc: func(ctx context.Context, input *fastly.GetTLSActivationInput) (*fastly.TLSActivation, error) {
+			lookups = append(lookups, input.ID)
+			switch input.ID {
+			case "activation1":
+				return &fastly.TLSActivation{ID: input.ID, Certificate: &fastly.CustomTLSCertificate{ID: "cert1"}}, nil
+			case "activation2":
+				return &fastly.TLSActivation{ID: input.ID, Certificate: &fastly.CustomTLSCertificate{ID: "previous-cert"}}, nil
+			}
+			return nil, errors.New("not found")
+		},
+	}
+	logic := &Logic{FastlyClient: mockClient}
+
+	result := func(domain, activationID string, state v1alpha1.TLSActivationState, attempted time.Duration) v1alpha1.TLSActivationResult {
+		return v1alpha1.TLSActivationResult{
+			Domain:          domain,
+			ConfigurationID: "config1",
+			State:           state,
+			ActivationID:    activationID,
+			LastAttemptTime: metav1.NewTime(time.Now().Add(-attempted)),
+		}
+	}
+	ctx := createTestContext()
+	ctx.Subject.Status.TLSActivationResults = []v1alpha1.TLSActivationResult{
+		result("a.example.com", "activation1", v1alpha1.TLSActivationStateCreated, time.Minute),
+		result("b.example.com", "activation2", v1alpha1.TLSActivationStateCreated, time.Minute),
+		result("c.example.com", "activation3", v1alpha1.TLSActivationStateCreated, time.Minute),
+		result("d.example.com", "activation4", v1alpha1.TLSActivationStateCreated, time.Hour),
+		result("e.example.com", "", v1alpha1.TLSActivationStateFailed, time.Minute),
+	}
+
+	// Activations created before a restart are verified by the ID recorded in status
+	assert.True(t, logic.isTLSActivationCreated(ctx, cert, "a.example.com", "config1"))
+	assert.False(t, logic.isTLSActivationCreated(ctx, cert, "b.example.com", "config1"), "activation of another certificate")
+	assert.False(t, logic.isTLSActivationCreated(ctx, cert, "c.example.com", "config1"), "activation Fastly doesn't know")
+	assert.False(t, logic.isTLSActivationCreated(ctx, cert, "d.example.com", "config1"), "activation created too long ago")
+	assert.False(t, logic.isTLSActivationCreated(ctx, cert, "e.example.com", "config1"), "failed activation")
+	assert.False(t, logic.isTLSActivationCreated(ctx, cert, "f.example.com", "config1"), "activation never attempted")
+	assert.Equal(t, []string{"activation1", "activation2", "activation3"}, lookups)
+
+	// Activations created by this process are not looked up
+	lookups = nil
+	logic.tlsActivationProgress.Add(ctx.NamespacedName, "default", ctx.Subject.Generation, "cert1", "f.example.com", "config1")
+	assert.True(t, logic.isTLSActivationCreated(ctx, cert, "f.example.com", "config1"))
+	assert.Empty(t, lookups)
+}
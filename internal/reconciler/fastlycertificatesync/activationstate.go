@@ -0,0 +1,95 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+)
+
+// fastlyTLSActivationSnapshot holds the TLS activations listed for a certificate while observing the subject, so that
+// the steps of a reconcile share a single listing rather than each paging through the activations again
+type fastlyTLSActivationSnapshot struct {
+	certificateID string
+	activations   []*fastly.TLSActivation
+}
+
+// tlsActivationDiff is what it takes for the TLS activations of a certificate to match the desired ones
+type tlsActivationDiff struct {
+	// missing are the desired activations that don't exist
+	missing []TLSActivationData
+	// extraIDs are the IDs of the activations that aren't desired
+	extraIDs []string
+	// pendingListing is set when desired activations were created but are not listed yet
+	pendingListing bool
+}
+
+// getFastlyTLSActivations returns the TLS activations of the certificate in the current account. They are listed once
+// per observation, later calls for the same certificate are answered from the snapshot in the observed state.
+func (l *Logic) getFastlyTLSActivations(ctx *Context, cert *fastly.CustomTLSCertificate) ([]*fastly.TLSActivation, error) {
+	if snapshot := l.ObservedState.fastlyTLSActivations; snapshot != nil && snapshot.certificateID == cert.ID {
+		return snapshot.activations, nil
+	}
+
+	activations, err := listFastlyPages(func(page int) ([]*fastly.TLSActivation, error) {
+		return l.fastlyClient().ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{
+			FilterTLSCertificateID: cert.ID,
+			PageNumber:             page,
+			PageSize:               defaultFastlyPageSize,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly TLS activations: %w", err)
+	}
+
+	ctx.Log.Info(fmt.Sprintf("Found %d TLS activations", len(activations)), "domains", cert.Domains)
+	l.ObservedState.fastlyTLSActivations = &fastlyTLSActivationSnapshot{certificateID: cert.ID, activations: activations}
+	return activations, nil
+}
+
+// indexTLSActivations maps domain ID -> configuration ID -> activation, the activation listed last winning should a
+// domain be activated on a configuration more than once
+func indexTLSActivations(activations []*fastly.TLSActivation) map[string]map[string]*fastly.TLSActivation {
+	res := make(map[string]map[string]*fastly.TLSActivation)
+	for _, activation := range activations {
+		if res[activation.Domain.ID] == nil {
+			res[activation.Domain.ID] = make(map[string]*fastly.TLSActivation)
+		}
+		res[activation.Domain.ID][activation.Configuration.ID] = activation
+	}
+	return res
+}
+
+// diffTLSActivations compares the listed activations of the certificate with one for every domain on every
+// configuration. Desired activations that aren't listed are missing, unless isCreated reports them as created already,
+// and listed activations that aren't desired are extra, in the order they were listed.
+func diffTLSActivations(cert *fastly.CustomTLSCertificate, domains []*fastly.TLSDomain, configIDs []string, activations []*fastly.TLSActivation, isCreated func(domainID, configID string) bool) tlsActivationDiff {
+	res := tlsActivationDiff{missing: []TLSActivationData{}, extraIDs: []string{}}
+	listed := indexTLSActivations(activations)
+
+	kept := map[string]bool{}
+	for _, domain := range domains {
+		for _, configID := range configIDs {
+			if activation, exists := listed[domain.ID][configID]; exists {
+				kept[activation.ID] = true
+				continue
+			}
+			if isCreated(domain.ID, configID) {
+				res.pendingListing = true
+				continue
+			}
+			res.missing = append(res.missing, TLSActivationData{
+				Certificate:   cert,
+				Configuration: &fastly.TLSConfiguration{ID: configID},
+				Domain:        domain,
+			})
+		}
+	}
+
+	for _, activation := range activations {
+		// Activations shadowed in the index by another one for the same domain and configuration are left alone
+		if listed[activation.Domain.ID][activation.Configuration.ID] == activation && !kept[activation.ID] {
+			res.extraIDs = append(res.extraIDs, activation.ID)
+		}
+	}
+	return res
+}
@@ -0,0 +1,138 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTLSActivation(id, domainID, configID string) *fastly.TLSActivation {
+	return &fastly.TLSActivation{ID: id, Domain: &fastly.TLSDomain{ID: domainID}, Configuration: &fastly.TLSConfiguration{ID: configID}}
+}
+
+func TestLogic_getFastlyTLSActivations_Snapshot(t *testing.T) {
+	requests := 0
+	mockClient := &MockFastlyClient{
+		ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+			requests++
+			return []*fastly.TLSActivation{testTLSActivation("act-"+input.FilterTLSCertificateID, "example.com", "config1")}, nil
+		},
+	}
+	logic := &Logic{FastlyClient: mockClient}
+	ctx := createTestContext()
+
+	activations, err := logic.getFastlyTLSActivations(ctx, &fastly.CustomTLSCertificate{ID: "cert1"})
+	require.NoError(t, err)
+	require.Len(t, activations, 1)
+
+	_, err = logic.getFastlyDomainAndConfigurationToActivationMap(ctx, &fastly.CustomTLSCertificate{ID: "cert1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests, "the steps of an observation share a single listing")
+
+	activations, err = logic.getFastlyTLSActivations(ctx, &fastly.CustomTLSCertificate{ID: "cert2"})
+	require.NoError(t, err)
+	assert.Equal(t, "act-cert2", activations[0].ID)
+	assert.Equal(t, 2, requests, "activations of another certificate are listed")
+
+	logic.ObservedState = ObservedState{}
+	_, err = logic.getFastlyTLSActivations(ctx, &fastly.CustomTLSCertificate{ID: "cert2"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests, "every observation lists the activations again")
+}
+
+func TestDiffTLSActivations(t *testing.T) {
+	cert := &fastly.CustomTLSCertificate{ID: "cert1"}
+	domains := []*fastly.TLSDomain{{ID: "a.example.com"}, {ID: "b.example.com"}}
+	notCreated := func(domainID, configID string) bool { return false }
+
+	tests := []struct {
+		name           string
+		configIDs      []string
+		activations    []*fastly.TLSActivation
+		isCreated      func(domainID, configID string) bool
+		expectMissing  []string
+		expectExtraIDs []string
+		expectPending  bool
+	}{
+		{
+			name:          "nothing activated",
+			configIDs:     []string{"config1", "config2"},
+			isCreated:     notCreated,
+			expectMissing: []string{"a.example.com/config1", "a.example.com/config2", "b.example.com/config1", "b.example.com/config2"},
+		},
+		{
+			name:      "in sync",
+			configIDs: []string{"config1"},
+			activations: []*fastly.TLSActivation{
+				testTLSActivation("act1", "a.example.com", "config1"),
+				testTLSActivation("act2", "b.example.com", "config1"),
+			},
+			isCreated: notCreated,
+		},
+		{
+			name:      "extra configurations and domains",
+			configIDs: []string{"config1"},
+			activations: []*fastly.TLSActivation{
+				testTLSActivation("act1", "a.example.com", "config2"),
+				testTLSActivation("act2", "a.example.com", "config1"),
+				testTLSActivation("act3", "c.example.com", "config1"),
+			},
+			isCreated:      notCreated,
+			expectMissing:  []string{"b.example.com/config1"},
+			expectExtraIDs: []string{"act1", "act3"},
+		},
+		{
+			name:      "created but not listed",
+			configIDs: []string{"config1"},
+			activations: []*fastly.TLSActivation{
+				testTLSActivation("act1", "a.example.com", "config1"),
+			},
+			isCreated:     func(domainID, configID string) bool { return domainID == "b.example.com" },
+			expectPending: true,
+		},
+		{
+			name:      "duplicate activation",
+			configIDs: []string{"config1"},
+			activations: []*fastly.TLSActivation{
+				testTLSActivation("act1", "a.example.com", "config1"),
+				testTLSActivation("act2", "a.example.com", "config1"),
+				testTLSActivation("act3", "b.example.com", "config1"),
+			},
+			isCreated: notCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffTLSActivations(cert, domains, tt.configIDs, tt.activations, tt.isCreated)
+
+			var missing []string
+			for _, data := range diff.missing {
+				assert.Same(t, cert, data.Certificate)
+				missing = append(missing, data.Domain.ID+"/"+data.Configuration.ID)
+			}
+			assert.Equal(t, tt.expectMissing, missing)
+			if tt.expectExtraIDs == nil {
+				assert.Empty(t, diff.extraIDs)
+			} else {
+				assert.Equal(t, tt.expectExtraIDs, diff.extraIDs)
+			}
+			assert.Equal(t, tt.expectPending, diff.pendingListing)
+		})
+	}
+}
+
+func TestIndexTLSActivations(t *testing.T) {
+	index := indexTLSActivations([]*fastly.TLSActivation{
+		testTLSActivation("act1", "a.example.com", "config1"),
+		testTLSActivation("act2", "a.example.com", "config2"),
+		testTLSActivation("act3", "a.example.com", "config1"),
+	})
+
+	require.Len(t, index, 1)
+	assert.Equal(t, "act3", index["a.example.com"]["config1"].ID, "the activation listed last wins")
+	assert.Equal(t, "act2", index["a.example.com"]["config2"].ID)
+}
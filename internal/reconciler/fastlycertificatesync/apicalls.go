@@ -0,0 +1,156 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+)
+
+// fastlyCallCounter counts the Fastly API calls made within a single reconcile, by operation, so that the load each
+// subject puts on the account's rate limit is known
+type fastlyCallCounter struct {
+	mu          sync.Mutex
+	byOperation map[string]int
+}
+
+func (c *fastlyCallCounter) count(operation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.byOperation == nil {
+		c.byOperation = map[string]int{}
+	}
+	c.byOperation[operation]++
+}
+
+// counts returns the calls made by operation, and their total
+func (c *fastlyCallCounter) counts() (map[string]int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+	for _, n := range c.byOperation {
+		total += n
+	}
+	return maps.Clone(c.byOperation), total
+}
+
+// countingFastlyClient counts every call made through the client it wraps. Listings count once per page, as each
+// page is a request of its own.
+type countingFastlyClient struct {
+	next  FastlyClientInterface
+	calls *fastlyCallCounter
+}
+
+func (c *countingFastlyClient) ListPrivateKeys(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+	c.calls.count("ListPrivateKeys")
+	return c.next.ListPrivateKeys(ctx, input)
+}
+
+func (c *countingFastlyClient) CreatePrivateKey(ctx context.Context, input *fastly.CreatePrivateKeyInput) (*fastly.PrivateKey, error) {
+	c.calls.count("CreatePrivateKey")
+	return c.next.CreatePrivateKey(ctx, input)
+}
+
+func (c *countingFastlyClient) DeletePrivateKey(ctx context.Context, input *fastly.DeletePrivateKeyInput) error {
+	c.calls.count("DeletePrivateKey")
+	return c.next.DeletePrivateKey(ctx, input)
+}
+
+func (c *countingFastlyClient) ListCustomTLSCertificates(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+	c.calls.count("ListCustomTLSCertificates")
+	return c.next.ListCustomTLSCertificates(ctx, input)
+}
+
+func (c *countingFastlyClient) CreateCustomTLSCertificate(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+	c.calls.count("CreateCustomTLSCertificate")
+	return c.next.CreateCustomTLSCertificate(ctx, input)
+}
+
+func (c *countingFastlyClient) UpdateCustomTLSCertificate(ctx context.Context, input *fastly.UpdateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+	c.calls.count("UpdateCustomTLSCertificate")
+	return c.next.UpdateCustomTLSCertificate(ctx, input)
+}
+
+func (c *countingFastlyClient) GetCustomTLSCertificate(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+	c.calls.count("GetCustomTLSCertificate")
+	return c.next.GetCustomTLSCertificate(ctx, input)
+}
+
+func (c *countingFastlyClient) DeleteCustomTLSCertificate(ctx context.Context, input *fastly.DeleteCustomTLSCertificateInput) error {
+	c.calls.count("DeleteCustomTLSCertificate")
+	return c.next.DeleteCustomTLSCertificate(ctx, input)
+}
+
+func (c *countingFastlyClient) ListTLSActivations(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+	c.calls.count("ListTLSActivations")
+	return c.next.ListTLSActivations(ctx, input)
+}
+
+func (c *countingFastlyClient) CreateTLSActivation(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error) {
+	c.calls.count("CreateTLSActivation")
+	return c.next.CreateTLSActivation(ctx, input)
+}
+
+func (c *countingFastlyClient) GetTLSActivation(ctx context.Context, input *fastly.GetTLSActivationInput) (*fastly.TLSActivation, error) {
+	c.calls.count("GetTLSActivation")
+	return c.next.GetTLSActivation(ctx, input)
+}
+
+func (c *countingFastlyClient) UpdateTLSActivation(ctx context.Context, input *fastly.UpdateTLSActivationInput) (*fastly.TLSActivation, error) {
+	c.calls.count("UpdateTLSActivation")
+	return c.next.UpdateTLSActivation(ctx, input)
+}
+
+func (c *countingFastlyClient) DeleteTLSActivation(ctx context.Context, input *fastly.DeleteTLSActivationInput) error {
+	c.calls.count("DeleteTLSActivation")
+	return c.next.DeleteTLSActivation(ctx, input)
+}
+
+func (c *countingFastlyClient) ListCustomTLSConfigurations(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+	c.calls.count("ListCustomTLSConfigurations")
+	return c.next.ListCustomTLSConfigurations(ctx, input)
+}
+
+func (c *countingFastlyClient) GetService(ctx context.Context, input *fastly.GetServiceInput) (*fastly.Service, error) {
+	c.calls.count("GetService")
+	return c.next.GetService(ctx, input)
+}
+
+func (c *countingFastlyClient) ListDomains(ctx context.Context, input *fastly.ListDomainsInput) ([]*fastly.Domain, error) {
+	c.calls.count("ListDomains")
+	return c.next.ListDomains(ctx, input)
+}
+
+func (c *countingFastlyClient) Get(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+	c.calls.count("Get")
+	return c.next.Get(ctx, p, ro)
+}
+
+func (c *countingFastlyClient) Patch(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+	c.calls.count("Patch")
+	return c.next.Patch(ctx, p, ro)
+}
+
+// startCountingFastlyCalls starts counting the Fastly API calls made through fastlyClient afresh for the reconcile
+func (l *Logic) startCountingFastlyCalls() {
+	l.fastlyCalls = &fastlyCallCounter{}
+}
+
+// reportFastlyCalls logs the Fastly API calls made within the reconcile, and counts them against the subject
+func (l *Logic) reportFastlyCalls(c *Context) {
+	if l.fastlyCalls == nil {
+		return
+	}
+	byOperation, total := l.fastlyCalls.counts()
+	l.fastlyCalls = nil
+
+	c.Log.Info("reconcile complete", "fastly_api_calls", total, "fastly_api_calls_by_operation", byOperation)
+	reconcileFastlyAPICalls.WithLabelValues(c.Namespace).Observe(float64(total))
+	for operation, n := range byOperation {
+		fastlyAPICallsTotal.WithLabelValues(c.Namespace, c.Name, operation).Add(float64(n))
+	}
+}
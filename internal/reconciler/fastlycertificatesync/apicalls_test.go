@@ -0,0 +1,48 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestLogic_fastlyClient_CountsCalls(t *testing.T) {
+	mockClient := &MockFastlyClient{}
+	logic := &Logic{FastlyClient: mockClient}
+	assert.Same(t, mockClient, logic.fastlyClient(), "calls are only counted within a reconcile")
+
+	logic.startCountingFastlyCalls()
+	ctx := context.Background()
+	_, err := logic.fastlyClient().ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{})
+	require.NoError(t, err)
+	_, err = logic.fastlyClient().ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: 2})
+	require.NoError(t, err)
+	require.NoError(t, logic.fastlyClient().DeleteTLSActivation(ctx, &fastly.DeleteTLSActivationInput{ID: "act1"}))
+
+	assert.Equal(t, []string{"act1"}, mockClient.DeleteTLSActivationCalls, "calls reach the wrapped client")
+	byOperation, total := logic.fastlyCalls.counts()
+	assert.Equal(t, map[string]int{"ListPrivateKeys": 2, "DeleteTLSActivation": 1}, byOperation)
+	assert.Equal(t, 3, total)
+}
+
+func TestLogic_ReconcileComplete_ReportsFastlyCalls(t *testing.T) {
+	ctx := createTestContext()
+	ctx.NamespacedName = types.NamespacedName{Namespace: "api-calls-namespace", Name: "api-calls-cert-sync"}
+	t.Cleanup(func() { deleteSubjectMetrics(ctx) })
+
+	logic := &Logic{FastlyClient: &MockFastlyClient{}}
+	logic.startCountingFastlyCalls()
+	for range 3 {
+		_, err := logic.fastlyClient().ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{})
+		require.NoError(t, err)
+	}
+
+	logic.ReconcileComplete(ctx, genrec.Okay, nil)
+	assert.Equal(t, 3.0, counterValue(t, fastlyAPICallsTotal.WithLabelValues("api-calls-namespace", "api-calls-cert-sync", "ListCustomTLSCertificates")))
+	assert.Nil(t, logic.fastlyCalls, "counting stops with the reconcile")
+}
@@ -0,0 +1,165 @@
+package fastlycertificatesync
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	corev1 "k8s.io/api/core/v1"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Bounds of the exponential backoff applied to subjects whose Fastly sync keeps failing
+const (
+	syncFailureBackoffBase = 5 * time.Second
+	syncFailureBackoffMax  = 10 * time.Minute
+)
+
+// syncFailureBackoff returns how long to wait before retrying after the given number of consecutive failures,
+// doubling with every failure up to syncFailureBackoffMax
+func syncFailureBackoff(failures int) time.Duration {
+	backoff := syncFailureBackoffBase
+	for i := 1; i < failures && backoff < syncFailureBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, syncFailureBackoffMax)
+}
+
+// recordSyncFailure counts a failed Fastly sync in the subject's status and requeues it with exponential backoff.
+// The error is logged rather than returned, as returning it would have the subject retried without our backoff.
+func (l *Logic) recordSyncFailure(ctx *Context, syncErr error) error {
+	failures := ctx.Subject.Status.ConsecutiveFailures + 1
+	backoff := syncFailureBackoff(failures)
+
+	ctx.Log.Error(syncErr, "failed to sync Fastly, backing off", "consecutive_failures", failures, "retry_after", backoff)
+	ctx.SetRequeue(backoff)
+	countSyncFailure(ctx)
+	recordFastlyErrorEvent(ctx, "FastlySyncFailed", syncErr)
+	l.notify(ctx, NotificationSyncFailed, fmt.Sprintf("%v (%d consecutive failures)", syncErr, failures))
+
+	now := time.Now()
+	nextRetryTime := kmetav1.NewTime(now.Add(backoff))
+	return l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.ConsecutiveFailures = failures
+		status.NextRetryTime = &nextRetryTime
+		status.LastError = newFastlyError(syncErr, now)
+	})
+}
+
+// recordObserveFailure keeps the Fastly error that failed the observation of the subject in status.lastError, so that
+// failures to read Fastly are surfaced like failures to write to it. The error is returned for the controller to retry.
+func (l *Logic) recordObserveFailure(ctx *Context, observeErr error) error {
+	recordFastlyErrorEvent(ctx, "FastlyObserveFailed", observeErr)
+	if err := l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.LastError = newFastlyError(observeErr, time.Now())
+	}); err != nil {
+		return errors.Join(observeErr, fmt.Errorf("failed to record Fastly error in status: %w", err))
+	}
+	return observeErr
+}
+
+// resetSyncFailures clears the failure tracking from the subject's status after a successful Fastly sync
+func (l *Logic) resetSyncFailures(ctx *Context) error {
+	status := ctx.Subject.Status
+	if status.ConsecutiveFailures == 0 && status.NextRetryTime == nil && status.LastError == nil &&
+		len(l.ObservedState.TLSActivationResults) == 0 {
+		return nil
+	}
+	return l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.ConsecutiveFailures = 0
+		status.NextRetryTime = nil
+		status.LastError = nil
+	})
+}
+
+// fastlyOperationError names the Fastly API operation an error came from, for status.lastError
+type fastlyOperationError struct {
+	operation string
+	err       error
+}
+
+func (e *fastlyOperationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fastlyOperationError) Unwrap() error {
+	return e.err
+}
+
+// withFastlyOperation tags err with the Fastly API operation that failed
+func withFastlyOperation(operation string, err error) error {
+	return &fastlyOperationError{operation: operation, err: err}
+}
+
+// newFastlyError describes a failed sync for status.lastError, picking the failed operation and the HTTP status and
+// detail returned by Fastly out of the error chain
+func newFastlyError(syncErr error, now time.Time) *v1alpha1.FastlyError {
+	lastError := &v1alpha1.FastlyError{
+		Message: syncErr.Error(),
+		Time:    kmetav1.NewTime(now),
+	}
+
+	var operationErr *fastlyOperationError
+	if errors.As(syncErr, &operationErr) {
+		lastError.Operation = operationErr.operation
+	}
+
+	var httpErr *fastly.HTTPError
+	if errors.As(syncErr, &httpErr) {
+		lastError.HTTPStatus = httpErr.StatusCode
+		if problem := fastlyProblem(httpErr); problem != nil {
+			lastError.Title = problem.Title
+		}
+
+		var details []string
+		for _, e := range httpErr.Errors {
+			switch {
+			case e == nil:
+			case e.Detail != "":
+				details = append(details, e.Detail)
+			case e.Title != "":
+				details = append(details, e.Title)
+			}
+		}
+		lastError.Detail = strings.Join(details, "; ")
+	}
+
+	return lastError
+}
+
+// fastlyProblem returns the first problem Fastly reported for a request it rejected with a 4xx status, which tells
+// what was wrong with the request, e.g. an incomplete certificate chain. Server errors carry nothing worth surfacing.
+func fastlyProblem(httpErr *fastly.HTTPError) *fastly.ErrorObject {
+	if httpErr.StatusCode < 400 || httpErr.StatusCode >= 500 {
+		return nil
+	}
+	for _, e := range httpErr.Errors {
+		if e != nil && (e.Title != "" || e.Detail != "") {
+			return e
+		}
+	}
+	return nil
+}
+
+// recordFastlyErrorEvent records a Warning event on the subject for a failed Fastly call. The problem reported by
+// Fastly for a rejected request leads the message, wrapping would otherwise bury it at the end.
+func recordFastlyErrorEvent(ctx *Context, reason string, err error) {
+	if ctx.EventRecorder == nil {
+		return
+	}
+
+	var httpErr *fastly.HTTPError
+	if errors.As(err, &httpErr) {
+		if problem := fastlyProblem(httpErr); problem != nil {
+			details := slices.DeleteFunc([]string{problem.Title, problem.Detail}, func(s string) bool { return s == "" })
+			ctx.Eventf(ctx.Subject, corev1.EventTypeWarning, reason, "Fastly rejected the request with HTTP %d: %s (%v)",
+				httpErr.StatusCode, strings.Join(details, ": "), err)
+			return
+		}
+	}
+	ctx.Eventf(ctx.Subject, corev1.EventTypeWarning, reason, "%v", err)
+}
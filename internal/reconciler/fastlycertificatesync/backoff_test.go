@@ -0,0 +1,255 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncFailureBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 1, expected: 5 * time.Second},
+		{failures: 2, expected: 10 * time.Second},
+		{failures: 4, expected: 40 * time.Second},
+		{failures: 7, expected: 320 * time.Second},
+		{failures: 8, expected: 10 * time.Minute},
+		{failures: 1000, expected: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, syncFailureBackoff(tt.failures), "failures=%d", tt.failures)
+	}
+}
+
+func TestLogic_ApplyUnmanaged_SyncFailureBackoff(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(ctx.Subject).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	mockClient := &MockFastlyClient{
+		DeleteTLSActivationFunc: func(ctx context.Context, input *fastly.DeleteTLSActivationInput) error {
+			return &fastly.HTTPError{
+				StatusCode: http.StatusServiceUnavailable,
+				Errors:     []*fastly.ErrorObject{{Title: "Service Unavailable", Detail: "fastly unavailable"}},
+			}
+		},
+	}
+	newLogic := func() *Logic {
+		return &Logic{
+			FastlyClient: mockClient,
+			ObservedState: ObservedState{
+				PrivateKeyUploaded:    true,
+				CertificateStatus:     CertificateStatusSynced,
+				ExtraTLSActivationIDs: []string{"activation1"},
+			},
+			SubjectReadyForReconciliation: true,
+		}
+	}
+
+	stored := func() *v1alpha1.FastlyCertificateSync {
+		res := &v1alpha1.FastlyCertificateSync{}
+		require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(ctx.Subject), res))
+		return res
+	}
+
+	// Every failure doubles the backoff, rather than retrying immediately
+	for i, expectedBackoff := range []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second} {
+		ctx.RequeueAfter = nil
+		require.NoError(t, newLogic().ApplyUnmanaged(ctx))
+
+		require.NotNil(t, ctx.RequeueAfter)
+		assert.Equal(t, expectedBackoff, *ctx.RequeueAfter)
+
+		status := stored().Status
+		assert.Equal(t, i+1, status.ConsecutiveFailures)
+		require.NotNil(t, status.NextRetryTime)
+		assert.WithinDuration(t, time.Now().Add(expectedBackoff), status.NextRetryTime.Time, 5*time.Second)
+
+		require.NotNil(t, status.LastError)
+		assert.Equal(t, "DeleteTLSActivation", status.LastError.Operation)
+		assert.Equal(t, http.StatusServiceUnavailable, status.LastError.HTTPStatus)
+		assert.Empty(t, status.LastError.Title, "only the problems of rejected requests are surfaced")
+		assert.Equal(t, "fastly unavailable", status.LastError.Detail)
+		assert.Contains(t, status.LastError.Message, "failed to delete TLS activation activation1")
+	}
+
+	// A successful sync resets the failure tracking
+	mockClient.DeleteTLSActivationFunc = nil
+	ctx.RequeueAfter = nil
+	require.NoError(t, newLogic().ApplyUnmanaged(ctx))
+
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Equal(t, time.Duration(0), *ctx.RequeueAfter)
+	status := stored().Status
+	assert.Equal(t, 0, status.ConsecutiveFailures)
+	assert.Nil(t, status.NextRetryTime)
+	assert.Nil(t, status.LastError)
+}
+
+func TestLogic_RecordObserveFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(ctx.Subject).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	observeErr := fmt.Errorf("failed to list Fastly private keys: %w", &fastly.HTTPError{
+		StatusCode: http.StatusTooManyRequests,
+		Errors:     []*fastly.ErrorObject{{Title: "Too Many Requests", Detail: "rate limit exceeded"}},
+	})
+
+	// The error is still returned, so that the controller retries the observation
+	err := (&Logic{}).recordObserveFailure(ctx, observeErr)
+	assert.Equal(t, observeErr, err)
+
+	stored := &v1alpha1.FastlyCertificateSync{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(ctx.Subject), stored))
+	require.NotNil(t, stored.Status.LastError)
+	assert.Equal(t, http.StatusTooManyRequests, stored.Status.LastError.HTTPStatus)
+	assert.Equal(t, "Too Many Requests", stored.Status.LastError.Title)
+	assert.Equal(t, "rate limit exceeded", stored.Status.LastError.Detail)
+	assert.Contains(t, stored.Status.LastError.Message, "failed to list Fastly private keys")
+	assert.Zero(t, stored.Status.ConsecutiveFailures)
+}
+
+func TestLogic_ResetSyncFailures_NoopWithoutFailures(t *testing.T) {
+	ctx := createTestContext()
+
+	// No client is configured, so any attempt to patch the status would panic
+	assert.NoError(t, (&Logic{}).resetSyncFailures(ctx))
+	assert.Equal(t, v1alpha1.FastlyCertificateSyncStatus{}, ctx.Subject.Status)
+}
+
+func TestNewFastlyError(t *testing.T) {
+	now := time.Now()
+
+	t.Run("fastly_http_error", func(t *testing.T) {
+		err := withFastlyOperation("CreateCustomTLSCertificate", fmt.Errorf("failed to create Fastly certificate: %w", &fastly.HTTPError{
+			StatusCode: http.StatusBadRequest,
+			Errors: []*fastly.ErrorObject{
+				{Title: "Bad Request", Detail: "certificate is expired"},
+				{Title: "Invalid domain"},
+			},
+		}))
+
+		lastError := newFastlyError(err, now)
+		assert.Equal(t, "CreateCustomTLSCertificate", lastError.Operation)
+		assert.Equal(t, http.StatusBadRequest, lastError.HTTPStatus)
+		assert.Equal(t, "Bad Request", lastError.Title)
+		assert.Equal(t, "certificate is expired; Invalid domain", lastError.Detail)
+		assert.Equal(t, err.Error(), lastError.Message)
+		assert.True(t, lastError.Time.Time.Equal(now))
+	})
+
+	t.Run("joined_errors", func(t *testing.T) {
+		err := errors.Join(
+			fmt.Errorf("account production: %w", withFastlyOperation("CreateTLSActivation", &fastly.HTTPError{StatusCode: http.StatusConflict})),
+			errors.New("account staging: unavailable"),
+		)
+
+		lastError := newFastlyError(err, now)
+		assert.Equal(t, "CreateTLSActivation", lastError.Operation)
+		assert.Equal(t, http.StatusConflict, lastError.HTTPStatus)
+		assert.Empty(t, lastError.Detail)
+	})
+
+	t.Run("other_error", func(t *testing.T) {
+		lastError := newFastlyError(errors.New("private key is managed externally"), now)
+		assert.Equal(t, &v1alpha1.FastlyError{Message: "private key is managed externally", Time: lastError.Time}, lastError)
+	})
+}
+
+func TestRecordFastlyErrorEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name: "rejected request",
+			err: fmt.Errorf("failed to create Fastly certificate: %w", &fastly.HTTPError{
+				StatusCode: http.StatusBadRequest,
+				Errors: []*fastly.ErrorObject{
+					nil,
+					{Title: "Bad Request", Detail: "certificate chain incomplete"},
+					{Title: "Invalid domain"},
+				},
+			}),
+			expected: "Warning FastlySyncFailed Fastly rejected the request with HTTP 400: Bad Request: certificate chain incomplete (failed to create Fastly certificate: ",
+		},
+		{
+			name: "problem without detail",
+			err: &fastly.HTTPError{
+				StatusCode: http.StatusConflict,
+				Errors:     []*fastly.ErrorObject{{Title: "Conflict"}},
+			},
+			expected: "Warning FastlySyncFailed Fastly rejected the request with HTTP 409: Conflict (",
+		},
+		{
+			name: "server error",
+			err: fmt.Errorf("failed to create Fastly certificate: %w", &fastly.HTTPError{
+				StatusCode: http.StatusServiceUnavailable,
+				Errors:     []*fastly.ErrorObject{{Title: "Service Unavailable"}},
+			}),
+			expected: "Warning FastlySyncFailed failed to create Fastly certificate: ",
+		},
+		{
+			name:     "other error",
+			err:      errors.New("private key is managed externally"),
+			expected: "Warning FastlySyncFailed private key is managed externally",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			ctx := createTestContext()
+			ctx.EventRecorder = recorder
+
+			recordFastlyErrorEvent(ctx, "FastlySyncFailed", tt.err)
+			assert.True(t, strings.HasPrefix(<-recorder.Events, tt.expected))
+		})
+	}
+
+	// Contexts without a recorder, such as in the standby observer, record nothing
+	assert.NotPanics(t, func() {
+		recordFastlyErrorEvent(createTestContext(), "FastlySyncFailed", errors.New("failed"))
+	})
+}
@@ -0,0 +1,404 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BackupVersion is the version of the backup format written by ExportBackup
+const BackupVersion = 1
+
+// Backup is a snapshot of the operator-owned TLS state of a Fastly account. It holds no key material: certificates
+// and private keys are uploaded again from their Secrets, the backup records what should exist and how it was
+// activated so that the topology can be restored into a fresh account.
+type Backup struct {
+	Version           int                      `json:"version"`
+	CreatedAt         time.Time                `json:"createdAt"`
+	Account           string                   `json:"account,omitempty"`
+	NamePrefix        string                   `json:"namePrefix,omitempty"`
+	TLSConfigurations []BackupTLSConfiguration `json:"tlsConfigurations"`
+	PrivateKeys       []BackupPrivateKey       `json:"privateKeys"`
+	Certificates      []BackupCertificate      `json:"certificates"`
+}
+
+// BackupTLSConfiguration is a TLS configuration of the account. IDs differ between accounts, so configurations are
+// matched by name on restore.
+type BackupTLSConfiguration struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Default bool   `json:"default,omitempty"`
+	Bulk    bool   `json:"bulk,omitempty"`
+}
+
+// BackupPrivateKey is an operator-owned private key, identified by the fingerprint of its public key
+type BackupPrivateKey struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	PublicKeySHA1 string `json:"publicKeySHA1"`
+}
+
+// BackupCertificate is an operator-owned certificate and the TLS activations using it
+type BackupCertificate struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	SerialNumber string             `json:"serialNumber,omitempty"`
+	NotAfter     *time.Time         `json:"notAfter,omitempty"`
+	Domains      []string           `json:"domains,omitempty"`
+	Activations  []BackupActivation `json:"activations,omitempty"`
+}
+
+// BackupActivation is a TLS activation of a certificate for a domain on a TLS configuration
+type BackupActivation struct {
+	ID                string `json:"id,omitempty"`
+	Domain            string `json:"domain"`
+	ConfigurationID   string `json:"configurationId"`
+	ConfigurationName string `json:"configurationName,omitempty"`
+}
+
+// RestoreResult reports what RestoreBackup did, or would do on a dry run, and what it could not restore
+type RestoreResult struct {
+	// ConfigurationIDs maps the TLS configuration IDs of the backup to those of the target account
+	ConfigurationIDs map[string]string `json:"configurationIds,omitempty"`
+	// CreatedActivations lists the activations created, as certificate/domain@configuration
+	CreatedActivations []string `json:"createdActivations,omitempty"`
+	// MissingCertificates lists certificates not yet uploaded to the target account
+	MissingCertificates []string `json:"missingCertificates,omitempty"`
+	// MissingPrivateKeys lists private keys not yet uploaded to the target account
+	MissingPrivateKeys []string `json:"missingPrivateKeys,omitempty"`
+	// MissingConfigurations lists TLS configurations without a counterpart of the same name in the target account
+	MissingConfigurations []string `json:"missingConfigurations,omitempty"`
+}
+
+// ExportBackup snapshots the certificates and private keys within namePrefix, their TLS activations and the TLS
+// configurations of the account
+func ExportBackup(ctx context.Context, fastlyClient FastlyClientInterface, account, namePrefix string) (*Backup, error) {
+	backup := &Backup{
+		Version:    BackupVersion,
+		CreatedAt:  time.Now().UTC(),
+		Account:    account,
+		NamePrefix: namePrefix,
+	}
+
+	configurations, err := listFastlyPages(func(page int) ([]*fastly.CustomTLSConfiguration, error) {
+		return fastlyClient.ListCustomTLSConfigurations(ctx, &fastly.ListCustomTLSConfigurationsInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly TLS configurations: %w", err)
+	}
+	configurationNames := map[string]string{}
+	for _, configuration := range configurations {
+		configurationNames[configuration.ID] = configuration.Name
+		backup.TLSConfigurations = append(backup.TLSConfigurations, BackupTLSConfiguration{
+			ID:      configuration.ID,
+			Name:    configuration.Name,
+			Default: configuration.Default,
+			Bulk:    configuration.Bulk,
+		})
+	}
+
+	keys, err := listFastlyPages(func(page int) ([]*fastly.PrivateKey, error) {
+		return fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly private keys: %w", err)
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key.Name, namePrefix) {
+			continue
+		}
+		backup.PrivateKeys = append(backup.PrivateKeys, BackupPrivateKey{ID: key.ID, Name: key.Name, PublicKeySHA1: key.PublicKeySHA1})
+	}
+
+	certs, err := listFastlyPages(func(page int) ([]*fastly.CustomTLSCertificate, error) {
+		return fastlyClient.ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly certificates: %w", err)
+	}
+	for _, cert := range certs {
+		if !strings.HasPrefix(cert.Name, namePrefix) {
+			continue
+		}
+
+		backupCert := BackupCertificate{
+			ID:           cert.ID,
+			Name:         cert.Name,
+			SerialNumber: cert.SerialNumber,
+			NotAfter:     cert.NotAfter,
+		}
+		for _, domain := range cert.Domains {
+			backupCert.Domains = append(backupCert.Domains, domain.ID)
+		}
+
+		activations, err := listFastlyPages(func(page int) ([]*fastly.TLSActivation, error) {
+			return fastlyClient.ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{
+				FilterTLSCertificateID: cert.ID,
+				PageNumber:             page,
+				PageSize:               defaultFastlyPageSize,
+			})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Fastly TLS activations of certificate %s: %w", cert.Name, err)
+		}
+		for _, activation := range activations {
+			if activation.Domain == nil || activation.Configuration == nil {
+				continue
+			}
+			backupCert.Activations = append(backupCert.Activations, BackupActivation{
+				ID:                activation.ID,
+				Domain:            activation.Domain.ID,
+				ConfigurationID:   activation.Configuration.ID,
+				ConfigurationName: configurationNames[activation.Configuration.ID],
+			})
+		}
+
+		backup.Certificates = append(backup.Certificates, backupCert)
+	}
+
+	return backup, nil
+}
+
+// RestoreBackup recreates the TLS activations of the backup in the target account. Certificates and private keys
+// are expected to have been uploaded again by the operator beforehand, and are matched by name and public key
+// fingerprint respectively. With dryRun set, nothing is created and the result lists what would be.
+func RestoreBackup(ctx context.Context, fastlyClient FastlyClientInterface, backup *Backup, dryRun bool) (*RestoreResult, error) {
+	if backup.Version != BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", backup.Version)
+	}
+
+	result := &RestoreResult{ConfigurationIDs: map[string]string{}}
+
+	configurations, err := listFastlyPages(func(page int) ([]*fastly.CustomTLSConfiguration, error) {
+		return fastlyClient.ListCustomTLSConfigurations(ctx, &fastly.ListCustomTLSConfigurationsInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly TLS configurations: %w", err)
+	}
+	configurationIDsByName := map[string]string{}
+	for _, configuration := range configurations {
+		configurationIDsByName[configuration.Name] = configuration.ID
+	}
+	for _, configuration := range backup.TLSConfigurations {
+		if id, ok := configurationIDsByName[configuration.Name]; ok {
+			result.ConfigurationIDs[configuration.ID] = id
+		}
+	}
+
+	keys, err := listFastlyPages(func(page int) ([]*fastly.PrivateKey, error) {
+		return fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly private keys: %w", err)
+	}
+	keyFingerprints := map[string]bool{}
+	for _, key := range keys {
+		keyFingerprints[key.PublicKeySHA1] = true
+	}
+	for _, key := range backup.PrivateKeys {
+		if !keyFingerprints[key.PublicKeySHA1] {
+			result.MissingPrivateKeys = append(result.MissingPrivateKeys, key.Name)
+		}
+	}
+
+	certs, err := listFastlyPages(func(page int) ([]*fastly.CustomTLSCertificate, error) {
+		return fastlyClient.ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly certificates: %w", err)
+	}
+	certsByName := map[string]*fastly.CustomTLSCertificate{}
+	for _, cert := range certs {
+		certsByName[cert.Name] = cert
+	}
+
+	missingConfigurations := map[string]bool{}
+	var errs []error
+	for _, backupCert := range backup.Certificates {
+		cert, ok := certsByName[backupCert.Name]
+		if !ok {
+			result.MissingCertificates = append(result.MissingCertificates, backupCert.Name)
+			continue
+		}
+
+		activations, err := listFastlyPages(func(page int) ([]*fastly.TLSActivation, error) {
+			return fastlyClient.ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{
+				FilterTLSCertificateID: cert.ID,
+				PageNumber:             page,
+				PageSize:               defaultFastlyPageSize,
+			})
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list Fastly TLS activations of certificate %s: %w", cert.Name, err))
+			continue
+		}
+		existing := map[string]bool{}
+		for _, activation := range activations {
+			if activation.Domain != nil && activation.Configuration != nil {
+				existing[activation.Domain.ID+"@"+activation.Configuration.ID] = true
+			}
+		}
+
+		for _, backupActivation := range backupCert.Activations {
+			configurationID, ok := result.ConfigurationIDs[backupActivation.ConfigurationID]
+			if !ok {
+				missingConfigurations[backupActivationConfigurationLabel(backupActivation)] = true
+				continue
+			}
+			if existing[backupActivation.Domain+"@"+configurationID] {
+				continue
+			}
+
+			if !dryRun {
+				_, err := fastlyClient.CreateTLSActivation(ctx, &fastly.CreateTLSActivationInput{
+					Certificate:   &fastly.CustomTLSCertificate{ID: cert.ID},
+					Configuration: &fastly.TLSConfiguration{ID: configurationID},
+					Domain:        &fastly.TLSDomain{ID: backupActivation.Domain},
+				})
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to activate certificate %s for %s: %w", cert.Name, backupActivation.Domain, err))
+					continue
+				}
+			}
+			result.CreatedActivations = append(result.CreatedActivations, cert.Name+"/"+backupActivation.Domain+"@"+configurationID)
+		}
+	}
+	result.MissingConfigurations = slices.Sorted(maps.Keys(missingConfigurations))
+
+	return result, joinErrors(errs)
+}
+
+// backupActivationConfigurationLabel names the configuration of an activation, falling back to its ID for backups of
+// configurations that were deleted before the export
+func backupActivationConfigurationLabel(activation BackupActivation) string {
+	if activation.ConfigurationName != "" {
+		return activation.ConfigurationName
+	}
+	return activation.ConfigurationID
+}
+
+// listFastlyPages collects the items of every page returned by list, stopping at the first page that isn't full
+func listFastlyPages[T any](list func(page int) ([]*T, error)) ([]*T, error) {
+	var all []*T
+	for page := 1; ; page++ {
+		items, err := list(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		if len(items) < defaultFastlyPageSize {
+			return all, nil
+		}
+	}
+}
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
+
+// PeriodicBackup periodically exports the operator-owned state of every Fastly account into a ConfigMap, one key per
+// account, so that it can be restored into a fresh account with the import command
+type PeriodicBackup struct {
+	Logic     *Logic
+	Client    client.Client
+	Interval  time.Duration
+	ConfigMap types.NamespacedName
+}
+
+// NeedLeaderElection only backs up from the leader, so that replicas don't race to write the ConfigMap
+func (b *PeriodicBackup) NeedLeaderElection() bool {
+	return true
+}
+
+// Start backs up the accounts every interval until the context is cancelled
+func (b *PeriodicBackup) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("fastly-backup")
+
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.backupAccounts(ctx); err != nil {
+			log.Error(err, "failed to back up Fastly accounts", "configMap", b.ConfigMap)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *PeriodicBackup) backupAccounts(ctx context.Context) error {
+	clients, err := b.Logic.accountClients(ctx, b.Client)
+	errs := []error{err}
+
+	data := map[string]string{}
+	for _, account := range slices.Sorted(maps.Keys(clients)) {
+		backup, err := ExportBackup(ctx, clients[account], account, ownedFastlyNamePrefix(b.Logic.Config))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to export Fastly account %s: %w", account, err))
+			continue
+		}
+
+		encoded, err := json.MarshalIndent(backup, "", "  ")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to encode backup of Fastly account %s: %w", account, err))
+			continue
+		}
+		data[backupConfigMapKey(account)] = string(encoded)
+	}
+
+	if err := b.writeConfigMap(ctx, data); err != nil {
+		errs = append(errs, err)
+	}
+
+	return joinErrors(errs)
+}
+
+// writeConfigMap stores the backups, keeping the previous backup of accounts that failed to export this time
+func (b *PeriodicBackup) writeConfigMap(ctx context.Context, data map[string]string) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := b.Client.Get(ctx, b.ConfigMap, configMap)
+	if apierrors.IsNotFound(err) {
+		configMap.Name = b.ConfigMap.Name
+		configMap.Namespace = b.ConfigMap.Namespace
+		configMap.Data = data
+		if err := b.Client.Create(ctx, configMap); err != nil {
+			return fmt.Errorf("failed to create backup ConfigMap %s: %w", b.ConfigMap, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get backup ConfigMap %s: %w", b.ConfigMap, err)
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	maps.Copy(configMap.Data, data)
+	if err := b.Client.Update(ctx, configMap); err != nil {
+		return fmt.Errorf("failed to update backup ConfigMap %s: %w", b.ConfigMap, err)
+	}
+	return nil
+}
+
+// backupConfigMapKey turns an account label into a valid ConfigMap key, e.g. secret/team-a becomes secret.team-a.json
+func backupConfigMapKey(account string) string {
+	return strings.ReplaceAll(account, "/", ".") + ".json"
+}
@@ -0,0 +1,197 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExportBackup(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		ListCustomTLSConfigurationsFunc: func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+			return []*fastly.CustomTLSConfiguration{
+				{ID: "config1", Name: "production", Default: true},
+				{ID: "config2", Name: "bulk", Bulk: true},
+			}, nil
+		},
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			return []*fastly.PrivateKey{
+				{ID: "key1", Name: "k8s-test-secret", PublicKeySHA1: "sha1-a"},
+				{ID: "key2", Name: "terraform-key", PublicKeySHA1: "sha1-b"},
+			}, nil
+		},
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			return []*fastly.CustomTLSCertificate{
+				{ID: "cert1", Name: "k8s-test-certificate", SerialNumber: "1234", Domains: []*fastly.TLSDomain{{ID: "example.com"}}},
+				{ID: "cert2", Name: "terraform-certificate"},
+			}, nil
+		},
+		ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+			assert.Equal(t, "cert1", input.FilterTLSCertificateID)
+			return []*fastly.TLSActivation{{
+				ID:            "act1",
+				Domain:        &fastly.TLSDomain{ID: "example.com"},
+				Configuration: &fastly.TLSConfiguration{ID: "config1"},
+			}}, nil
+		},
+	}
+
+	backup, err := ExportBackup(context.Background(), mockClient, "default", "k8s-")
+	require.NoError(t, err)
+
+	assert.Equal(t, BackupVersion, backup.Version)
+	assert.Equal(t, "default", backup.Account)
+	assert.Equal(t, []BackupTLSConfiguration{
+		{ID: "config1", Name: "production", Default: true},
+		{ID: "config2", Name: "bulk", Bulk: true},
+	}, backup.TLSConfigurations)
+	assert.Equal(t, []BackupPrivateKey{{ID: "key1", Name: "k8s-test-secret", PublicKeySHA1: "sha1-a"}}, backup.PrivateKeys)
+	assert.Equal(t, []BackupCertificate{{
+		ID:           "cert1",
+		Name:         "k8s-test-certificate",
+		SerialNumber: "1234",
+		Domains:      []string{"example.com"},
+		Activations:  []BackupActivation{{ID: "act1", Domain: "example.com", ConfigurationID: "config1", ConfigurationName: "production"}},
+	}}, backup.Certificates)
+
+	mockClient.ListPrivateKeysFunc = func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+		return nil, errors.New("unauthorized")
+	}
+	_, err = ExportBackup(context.Background(), mockClient, "default", "k8s-")
+	assert.ErrorContains(t, err, "failed to list Fastly private keys: unauthorized")
+}
+
+func TestRestoreBackup(t *testing.T) {
+	backup := &Backup{
+		Version: BackupVersion,
+		TLSConfigurations: []BackupTLSConfiguration{
+			{ID: "old-config1", Name: "production"},
+			{ID: "old-config2", Name: "staging"},
+		},
+		PrivateKeys: []BackupPrivateKey{
+			{Name: "k8s-test-secret", PublicKeySHA1: "sha1-a"},
+			{Name: "k8s-other-secret", PublicKeySHA1: "sha1-b"},
+		},
+		Certificates: []BackupCertificate{
+			{
+				Name: "k8s-test-certificate",
+				Activations: []BackupActivation{
+					{Domain: "example.com", ConfigurationID: "old-config1", ConfigurationName: "production"},
+					{Domain: "www.example.com", ConfigurationID: "old-config1", ConfigurationName: "production"},
+					{Domain: "example.com", ConfigurationID: "old-config2", ConfigurationName: "staging"},
+				},
+			},
+			{Name: "k8s-other-certificate"},
+		},
+	}
+
+	newMockClient := func() *MockFastlyClient {
+		return &MockFastlyClient{
+			ListCustomTLSConfigurationsFunc: func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+				return []*fastly.CustomTLSConfiguration{{ID: "new-config1", Name: "production"}}, nil
+			},
+			ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+				return []*fastly.PrivateKey{{ID: "key1", PublicKeySHA1: "sha1-a"}}, nil
+			},
+			ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+				return []*fastly.CustomTLSCertificate{{ID: "new-cert1", Name: "k8s-test-certificate"}}, nil
+			},
+			ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+				return []*fastly.TLSActivation{{
+					Domain:        &fastly.TLSDomain{ID: "example.com"},
+					Configuration: &fastly.TLSConfiguration{ID: "new-config1"},
+				}}, nil
+			},
+		}
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		mockClient := newMockClient()
+
+		result, err := RestoreBackup(context.Background(), mockClient, backup, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{"old-config1": "new-config1"}, result.ConfigurationIDs)
+		assert.Equal(t, []string{"k8s-test-certificate/www.example.com@new-config1"}, result.CreatedActivations)
+		assert.Equal(t, []string{"k8s-other-certificate"}, result.MissingCertificates)
+		assert.Equal(t, []string{"k8s-other-secret"}, result.MissingPrivateKeys)
+		assert.Equal(t, []string{"staging"}, result.MissingConfigurations)
+
+		require.Len(t, mockClient.CreateTLSActivationCalls, 1)
+		assert.Equal(t, "new-cert1", mockClient.CreateTLSActivationCalls[0].Certificate.ID)
+		assert.Equal(t, "new-config1", mockClient.CreateTLSActivationCalls[0].Configuration.ID)
+		assert.Equal(t, "www.example.com", mockClient.CreateTLSActivationCalls[0].Domain.ID)
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		mockClient := newMockClient()
+
+		result, err := RestoreBackup(context.Background(), mockClient, backup, true)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"k8s-test-certificate/www.example.com@new-config1"}, result.CreatedActivations)
+		assert.Empty(t, mockClient.CreateTLSActivationCalls)
+	})
+
+	t.Run("activation_error", func(t *testing.T) {
+		mockClient := newMockClient()
+		mockClient.CreateTLSActivationFunc = func(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error) {
+			return nil, errors.New("rate limited")
+		}
+
+		result, err := RestoreBackup(context.Background(), mockClient, backup, false)
+		assert.ErrorContains(t, err, "failed to activate certificate k8s-test-certificate for www.example.com: rate limited")
+		assert.Empty(t, result.CreatedActivations)
+	})
+
+	t.Run("unsupported_version", func(t *testing.T) {
+		_, err := RestoreBackup(context.Background(), newMockClient(), &Backup{Version: 2}, false)
+		assert.EqualError(t, err, "unsupported backup version 2")
+	})
+}
+
+func TestPeriodicBackup_backupAccounts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "fastly-backup", Namespace: "operator"},
+		Data:       map[string]string{"secret.team-a-token.json": "{}"},
+	}).Build()
+
+	periodicBackup := &PeriodicBackup{
+		Logic: &Logic{
+			FastlyClient: &MockFastlyClient{
+				ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+					return []*fastly.CustomTLSCertificate{{ID: "cert1", Name: "test-certificate"}}, nil
+				},
+			},
+		},
+		Client:    fakeClient,
+		ConfigMap: types.NamespacedName{Name: "fastly-backup", Namespace: "operator"},
+	}
+	require.NoError(t, periodicBackup.backupAccounts(context.Background()))
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, fakeClient.Get(context.Background(), periodicBackup.ConfigMap, configMap))
+	assert.Equal(t, "{}", configMap.Data["secret.team-a-token.json"])
+
+	backup := &Backup{}
+	require.NoError(t, json.Unmarshal([]byte(configMap.Data["default.json"]), backup))
+	assert.Equal(t, "default", backup.Account)
+	assert.Equal(t, []BackupCertificate{{ID: "cert1", Name: "test-certificate"}}, backup.Certificates)
+}
+
+func TestBackupConfigMapKey(t *testing.T) {
+	assert.Equal(t, "default.json", backupConfigMapKey("default"))
+	assert.Equal(t, "secret.team-a-token.json", backupConfigMapKey("secret/team-a-token"))
+}
@@ -0,0 +1,162 @@
+package fastlycertificatesync
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// mutationBudget keeps a sliding window of Fastly write operations, both globally and per subject, so that a runaway
+// reconcile loop cannot hammer the Fastly account.
+type mutationBudget struct {
+	mu        sync.Mutex
+	global    []time.Time
+	bySubject map[types.NamespacedName][]time.Time
+	now       func() time.Time
+}
+
+func (b *mutationBudget) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// prune drops the mutations that happened before the window, relying on the slice being in chronological order
+func prune(mutations []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(mutations) && !mutations[i].After(since) {
+		i++
+	}
+	return mutations[i:]
+}
+
+// Record registers a single write operation made on behalf of the subject
+func (b *mutationBudget) Record(subject types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.record(subject)
+}
+
+func (b *mutationBudget) record(subject types.NamespacedName) {
+	now := b.clock()
+	if b.bySubject == nil {
+		b.bySubject = map[types.NamespacedName][]time.Time{}
+	}
+	b.global = append(b.global, now)
+	b.bySubject[subject] = append(b.bySubject[subject], now)
+}
+
+// Check reports whether the subject may perform further write operations within the window. When it may not, the
+// returned duration indicates how long until the oldest counted mutation leaves the window. A limit of zero is
+// treated as unlimited.
+func (b *mutationBudget) Check(subject types.NamespacedName, globalLimit, subjectLimit int, window time.Duration) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.check(subject, globalLimit, subjectLimit, window)
+}
+
+// Reserve records a single write operation made on behalf of the subject, if the budget allows for it. Checking and
+// recording at once allows concurrent writers to share the budget without overrunning it.
+func (b *mutationBudget) Reserve(subject types.NamespacedName, globalLimit, subjectLimit int, window time.Duration) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	allowed, retryAfter := b.check(subject, globalLimit, subjectLimit, window)
+	if allowed {
+		b.record(subject)
+	}
+	return allowed, retryAfter
+}
+
+func (b *mutationBudget) check(subject types.NamespacedName, globalLimit, subjectLimit int, window time.Duration) (bool, time.Duration) {
+	now := b.clock()
+	since := now.Add(-window)
+
+	b.global = prune(b.global, since)
+	for nn, mutations := range b.bySubject {
+		if mutations = prune(mutations, since); len(mutations) == 0 {
+			delete(b.bySubject, nn)
+		} else {
+			b.bySubject[nn] = mutations
+		}
+	}
+
+	var retryAfter time.Duration
+	exceeded := false
+	if globalLimit > 0 && len(b.global) >= globalLimit {
+		exceeded = true
+		retryAfter = b.global[len(b.global)-globalLimit].Add(window).Sub(now)
+	}
+	if mutations := b.bySubject[subject]; subjectLimit > 0 && len(mutations) >= subjectLimit {
+		exceeded = true
+		retryAfter = max(retryAfter, mutations[len(mutations)-subjectLimit].Add(window).Sub(now))
+	}
+
+	return !exceeded, retryAfter
+}
+
+// isMutationBudgetEnabled reports whether any mutation budget is configured
+func (l *Logic) isMutationBudgetEnabled() bool {
+	return l.Config.MutationBudgetWindow > 0 && (l.Config.GlobalMutationBudget > 0 || l.Config.SubjectMutationBudget > 0)
+}
+
+// recordFastlyMutation counts a write operation against the mutation budget
+func (l *Logic) recordFastlyMutation(ctx *Context) {
+	if !l.isMutationBudgetEnabled() {
+		return
+	}
+	l.mutationBudget.Record(ctx.NamespacedName)
+}
+
+// checkMutationBudget reports whether the subject may perform further write operations, and if not, how long until it may
+func (l *Logic) checkMutationBudget(ctx *Context) (bool, time.Duration) {
+	if !l.isMutationBudgetEnabled() {
+		return true, 0
+	}
+	return l.mutationBudget.Check(ctx.NamespacedName, l.Config.GlobalMutationBudget, l.Config.SubjectMutationBudget, l.Config.MutationBudgetWindow)
+}
+
+// reserveFastlyMutation counts a write operation against the mutation budget, if the budget allows for it. When it
+// does not, the returned duration indicates how long until it may.
+func (l *Logic) reserveFastlyMutation(ctx *Context) (bool, time.Duration) {
+	return l.ReserveFastlyMutation(ctx.NamespacedName)
+}
+
+// ReserveFastlyMutation is reserveFastlyMutation for other controllers writing to Fastly, so that they share the
+// mutation budget of FastlyCertificateSyncs. Nothing is reserved while Fastly write operations are paused.
+func (l *Logic) ReserveFastlyMutation(subject types.NamespacedName) (bool, time.Duration) {
+	if l.areFastlyMutationsPaused() {
+		return false, mutationPauseRetryInterval
+	}
+	if !l.isMutationBudgetEnabled() {
+		return true, 0
+	}
+	return l.mutationBudget.Reserve(subject, l.Config.GlobalMutationBudget, l.Config.SubjectMutationBudget, l.Config.MutationBudgetWindow)
+}
+
+// reserveFastlyWrite reserves the mutation budget for a single write operation. When the budget doesn't allow for it,
+// the subject is requeued once it does, and false is returned.
+func (l *Logic) reserveFastlyWrite(ctx *Context) bool {
+	allowed, retryAfter := l.reserveFastlyMutation(ctx)
+	if !allowed {
+		ctx.Log.Info("Fastly mutation budget exceeded, deferring changes", "retry_after", retryAfter)
+		ctx.SetRequeue(retryAfter)
+		l.ObservedState.MutationsDeferred = true
+	}
+	return allowed
+}
+
+// requeueAfterFastlyChanges requeues the subject right away, to observe the changes just made to Fastly. Subjects with
+// changes deferred by the mutation budget are left requeued for when it allows for them instead, as the lowest requeue
+// wins.
+func (l *Logic) requeueAfterFastlyChanges(ctx *Context) {
+	if l.ObservedState.MutationsDeferred {
+		return
+	}
+	ctx.Log.Info("Requeueing...")
+	ctx.SetRequeue(0)
+}
@@ -0,0 +1,216 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestMutationBudget_Check(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	budget := &mutationBudget{now: func() time.Time { return now }}
+
+	subjectA := types.NamespacedName{Namespace: "ns", Name: "a"}
+	subjectB := types.NamespacedName{Namespace: "ns", Name: "b"}
+
+	allowed, _ := budget.Check(subjectA, 3, 2, time.Hour)
+	assert.True(t, allowed, "empty budget should allow mutations")
+
+	budget.Record(subjectA)
+	now = now.Add(10 * time.Minute)
+	budget.Record(subjectA)
+
+	allowed, retryAfter := budget.Check(subjectA, 3, 2, time.Hour)
+	assert.False(t, allowed, "subject budget should be exhausted")
+	assert.Equal(t, 50*time.Minute, retryAfter, "retry once the oldest mutation leaves the window")
+
+	allowed, _ = budget.Check(subjectB, 3, 2, time.Hour)
+	assert.True(t, allowed, "other subjects keep their own budget")
+
+	now = now.Add(10 * time.Minute)
+	budget.Record(subjectB)
+
+	allowed, retryAfter = budget.Check(subjectB, 3, 2, time.Hour)
+	assert.False(t, allowed, "global budget should be exhausted")
+	assert.Equal(t, 40*time.Minute, retryAfter)
+
+	allowed, _ = budget.Check(subjectB, 0, 2, time.Hour)
+	assert.True(t, allowed, "a zero limit is unlimited")
+
+	now = now.Add(41 * time.Minute)
+	allowed, _ = budget.Check(subjectA, 3, 2, time.Hour)
+	assert.True(t, allowed, "mutations leaving the window free up budget")
+	assert.Len(t, budget.global, 2)
+}
+
+func TestMutationBudget_Reserve(t *testing.T) {
+	budget := &mutationBudget{}
+	subject := types.NamespacedName{Namespace: "ns", Name: "a"}
+
+	allowed, _ := budget.Reserve(subject, 0, 2, time.Hour)
+	assert.True(t, allowed)
+	allowed, _ = budget.Reserve(subject, 0, 2, time.Hour)
+	assert.True(t, allowed)
+
+	allowed, retryAfter := budget.Reserve(subject, 0, 2, time.Hour)
+	assert.False(t, allowed, "reservations beyond the budget are refused")
+	assert.Positive(t, retryAfter)
+	assert.Len(t, budget.bySubject[subject], 2, "refused reservations are not recorded")
+}
+
+func TestLogic_createMissingFastlyTLSActivations_MutationBudget(t *testing.T) {
+	mockClient := &MockFastlyClient{}
+	activation := TLSActivationData{
+		Certificate:   &fastly.CustomTLSCertificate{ID: "cert1"},
+		Configuration: &fastly.TLSConfiguration{ID: "config1"},
+		Domain:        &fastly.TLSDomain{ID: "example.com"},
+	}
+	logic := &Logic{
+		Config: RuntimeConfig{
+			MutationBudgetWindow:     time.Hour,
+			SubjectMutationBudget:    2,
+			TLSActivationParallelism: 3,
+		},
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			MissingTLSActivationData: []TLSActivationData{activation, activation, activation, activation},
+		},
+	}
+
+	ctx := createTestContext()
+	require.NoError(t, logic.createMissingFastlyTLSActivations(ctx))
+
+	assert.Len(t, mockClient.CreateTLSActivationCalls, 2, "activations beyond the budget are deferred")
+	assert.Len(t, logic.ObservedState.TLSActivationResults, 2, "deferred activations have no result")
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Positive(t, *ctx.RequeueAfter)
+}
+
+func TestLogic_applyFastlyChanges_KeepsDeferredRequeue(t *testing.T) {
+	mockClient := &MockFastlyClient{}
+	activation := TLSActivationData{
+		Certificate:   &fastly.CustomTLSCertificate{ID: "cert1"},
+		Configuration: &fastly.TLSConfiguration{ID: "config1"},
+		Domain:        &fastly.TLSDomain{ID: "example.com"},
+	}
+	logic := &Logic{
+		Config: RuntimeConfig{
+			MutationBudgetWindow:     time.Hour,
+			SubjectMutationBudget:    2,
+			TLSActivationParallelism: 3,
+		},
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded:       true,
+			CertificateStatus:        CertificateStatusSynced,
+			MissingTLSActivationData: []TLSActivationData{activation, activation, activation, activation},
+		},
+	}
+
+	ctx := createTestContext()
+	require.NoError(t, logic.applyFastlyChanges(ctx))
+
+	assert.Len(t, mockClient.CreateTLSActivationCalls, 2)
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Positive(t, *ctx.RequeueAfter, "not requeued right away while activations are deferred by the budget")
+
+	// Without anything deferred, the changes are observed right away
+	logic.ObservedState = ObservedState{
+		PrivateKeyUploaded:       true,
+		CertificateStatus:        CertificateStatusSynced,
+		MissingTLSActivationData: []TLSActivationData{activation},
+	}
+	logic.Config.SubjectMutationBudget = 0
+	ctx = createTestContext()
+	require.NoError(t, logic.applyFastlyChanges(ctx))
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Zero(t, *ctx.RequeueAfter)
+}
+
+func TestLogic_applyFastlyChanges_ReservesEachWrite(t *testing.T) {
+	created := false
+	mockClient := &MockFastlyClient{
+		CreateCustomTLSCertificateFunc: func(_ context.Context, _ *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			created = true
+			return &fastly.CustomTLSCertificate{}, nil
+		},
+	}
+	logic := &Logic{
+		Config: RuntimeConfig{
+			MutationBudgetWindow:  time.Hour,
+			SubjectMutationBudget: 1,
+		},
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded: true,
+			CertificateStatus:  CertificateStatusMissing,
+		},
+	}
+
+	ctx := createTestContext()
+	// The budget allowed for a write when Fastly was observed, but another one was made since
+	logic.recordFastlyMutation(ctx)
+
+	require.NoError(t, logic.applyFastlyChanges(ctx))
+
+	assert.False(t, created, "writes beyond the budget are deferred")
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Positive(t, *ctx.RequeueAfter)
+}
+
+func TestLogic_recordFastlyMutation_Disabled(t *testing.T) {
+	logic := &Logic{}
+	ctx := createTestContext()
+
+	logic.recordFastlyMutation(ctx)
+
+	assert.Empty(t, logic.mutationBudget.global, "mutations are not tracked without a configured budget")
+	allowed, _ := logic.checkMutationBudget(ctx)
+	assert.True(t, allowed)
+}
+
+func TestLogic_ApplyUnmanaged_MutationBudgetExceeded(t *testing.T) {
+	mockClient := &MockFastlyClient{}
+	logic := &Logic{
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded:       true,
+			CertificateStatus:        CertificateStatusSynced,
+			ExtraTLSActivationIDs:    []string{"activation1"},
+			MutationBudgetExceeded:   true,
+			MutationBudgetRetryAfter: 5 * time.Minute,
+		},
+		SubjectReadyForReconciliation: true,
+	}
+
+	ctx := createTestContext()
+	require.NoError(t, logic.ApplyUnmanaged(ctx))
+
+	assert.Empty(t, mockClient.DeleteTLSActivationCalls, "no mutations should be made once the budget is exceeded")
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Equal(t, 5*time.Minute, *ctx.RequeueAfter)
+}
+
+func TestLogic_observeMutationBudgetExceededCondition(t *testing.T) {
+	ctx := &Context{Log: logr.Discard()}
+
+	logic := &Logic{ObservedState: ObservedState{MutationBudgetExceeded: true, MutationBudgetRetryAfter: 90 * time.Second}}
+	cnd, err := logic.observeMutationBudgetExceededCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionTrue, cnd.Status)
+	assert.Equal(t, "MutationBudgetExceeded", cnd.Reason)
+	assert.Equal(t, "Fastly write budget is exhausted, deferring changes for 1m30s", cnd.Message)
+
+	logic = &Logic{}
+	cnd, err = logic.observeMutationBudgetExceededCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionFalse, cnd.Status)
+	assert.Equal(t, "WithinMutationBudget", cnd.Reason)
+}
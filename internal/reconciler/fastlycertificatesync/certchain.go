@@ -0,0 +1,103 @@
+package fastlycertificatesync
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// orderCertificateChain returns the certificate chain with its leaf certificate first, followed by the CA certificates
+// in the order they appear in. Some issuance flows place cross-signed or several leaf certificates in the chain, only
+// the leaf matching the private key is kept, so that it is the one compared by serial number and synced, rather than
+// whichever came first. The other leaf certificates are dropped instead of being uploaded as intermediates.
+//
+// Chains holding a single leaf certificate, first, are returned as they are, as are chains that fail to parse, for
+// validation to report. matchesKey is only called when there are several leaf certificates to choose from, and may
+// return nil when the private key is unknown, in which case the first leaf certificate is kept.
+func orderCertificateChain(certPEM []byte, matchesKey func() (func(crypto.PublicKey) bool, error)) ([]byte, error) {
+	blocks, err := decodePEMBlob(certPEM, maxCertificateBlobSize)
+	if err != nil {
+		return certPEM, nil
+	}
+
+	certs := make([]*x509.Certificate, 0, len(blocks))
+	var leaves []int
+	for i, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			return certPEM, nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certPEM, nil
+		}
+		certs = append(certs, cert)
+		if !cert.IsCA {
+			leaves = append(leaves, i)
+		}
+	}
+
+	if len(leaves) == 0 || (len(leaves) == 1 && leaves[0] == 0) {
+		return certPEM, nil
+	}
+
+	leaf := leaves[0]
+	if len(leaves) > 1 {
+		matches, err := matchesKey()
+		if err != nil {
+			return nil, err
+		}
+		if matches != nil {
+			leaf = -1
+			for _, i := range leaves {
+				if matches(certs[i].PublicKey) {
+					leaf = i
+					break
+				}
+			}
+			if leaf < 0 {
+				return nil, fmt.Errorf("none of the %d leaf certificates matches the private key", len(leaves))
+			}
+		}
+	}
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[leaf].Raw})
+	for _, cert := range certs {
+		if cert.IsCA {
+			chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+	}
+	return chain, nil
+}
+
+// secretKeyMatcher returns a function telling whether a public key belongs to the private key of the secret.
+// Externally managed private keys are never read, they are matched by the SHA1 from the spec, or not at all when it is
+// unset.
+func secretKeyMatcher(ctx *Context, secret *corev1.Secret) (func(crypto.PublicKey) bool, error) {
+	if ctx.Subject != nil && ctx.Subject.IsPrivateKeyExternal() {
+		publicKeySHA1 := ctx.Subject.Spec.PrivateKeyPublicKeySHA1
+		if publicKeySHA1 == "" {
+			return nil, nil
+		}
+		return func(pubKey crypto.PublicKey) bool {
+			sha1Hash, err := getPublicKeySHA1(pubKey)
+			return err == nil && sha1Hash == publicKeySHA1
+		}, nil
+	}
+
+	keyPEM, err := openSecretKeyPEM(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	defer keyPEM.wipe()
+
+	keyPublic, err := getPublicKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return func(pubKey crypto.PublicKey) bool {
+		return publicKeysEqual(pubKey, keyPublic)
+	}, nil
+}
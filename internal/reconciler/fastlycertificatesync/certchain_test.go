@@ -0,0 +1,183 @@
+package fastlycertificatesync
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testCertificateChain is a CA along with two leaf certificates it issued for different private keys, e.g. a leaf
+// and its cross-signed counterpart as issued for a previous key
+type testCertificateChain struct {
+	caPEM         []byte
+	leafPEM       []byte
+	leafKeyPEM    []byte
+	otherLeafPEM  []byte
+	leafKeySHA1   string
+	otherLeafSHA1 string
+}
+
+func createTestCertificateChain(t testing.TB) testCertificateChain {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(100),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{SerialNumber: big.NewInt(serial), NotAfter: time.Now().Add(time.Hour)}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), key
+	}
+
+	leafPEM, leafKey := issue(1)
+	otherLeafPEM, otherKey := issue(2)
+	keyDER, err := x509.MarshalECPrivateKey(leafKey)
+	require.NoError(t, err)
+	leafKeySHA1, err := getPublicKeySHA1(&leafKey.PublicKey)
+	require.NoError(t, err)
+	otherLeafSHA1, err := getPublicKeySHA1(&otherKey.PublicKey)
+	require.NoError(t, err)
+
+	return testCertificateChain{
+		caPEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		leafPEM:       leafPEM,
+		leafKeyPEM:    pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		otherLeafPEM:  otherLeafPEM,
+		leafKeySHA1:   leafKeySHA1,
+		otherLeafSHA1: otherLeafSHA1,
+	}
+}
+
+func concatPEM(blocks ...[]byte) []byte {
+	var res []byte
+	for _, block := range blocks {
+		res = append(res, block...)
+	}
+	return res
+}
+
+func TestGetSecretCertPEM_SeveralLeafCertificates(t *testing.T) {
+	chain := createTestCertificateChain(t)
+
+	tests := []struct {
+		name          string
+		management    v1alpha1.PrivateKeyManagement
+		specSHA1      string
+		tlsCrt        []byte
+		tlsKey        []byte
+		expected      []byte
+		errorContains string
+	}{
+		{
+			name:     "single_leaf_is_left_alone",
+			tlsCrt:   concatPEM(chain.leafPEM, []byte("\n"), chain.caPEM),
+			expected: concatPEM(chain.leafPEM, []byte("\n"), chain.caPEM),
+		},
+		{
+			name:     "single_leaf_after_intermediate_is_placed_first",
+			tlsCrt:   concatPEM(chain.caPEM, chain.leafPEM),
+			expected: concatPEM(chain.leafPEM, chain.caPEM),
+		},
+		{
+			name:     "leaf_matching_the_private_key_is_kept",
+			tlsCrt:   concatPEM(chain.otherLeafPEM, chain.caPEM, chain.leafPEM),
+			tlsKey:   chain.leafKeyPEM,
+			expected: concatPEM(chain.leafPEM, chain.caPEM),
+		},
+		{
+			name:          "no_leaf_matches_the_private_key",
+			tlsCrt:        concatPEM(chain.otherLeafPEM, chain.otherLeafPEM, chain.caPEM),
+			tlsKey:        chain.leafKeyPEM,
+			errorContains: "none of the 2 leaf certificates matches the private key",
+		},
+		{
+			name:       "external_private_key_matched_by_spec_sha1",
+			management: v1alpha1.PrivateKeyManagementExternal,
+			specSHA1:   chain.leafKeySHA1,
+			tlsCrt:     concatPEM(chain.otherLeafPEM, chain.leafPEM, chain.caPEM),
+			expected:   concatPEM(chain.leafPEM, chain.caPEM),
+		},
+		{
+			name:       "external_private_key_without_sha1_keeps_first_leaf",
+			management: v1alpha1.PrivateKeyManagementExternal,
+			tlsCrt:     concatPEM(chain.otherLeafPEM, chain.leafPEM, chain.caPEM),
+			expected:   concatPEM(chain.otherLeafPEM, chain.caPEM),
+		},
+		{
+			name:     "malformed_chain_is_left_for_validation",
+			tlsCrt:   concatPEM([]byte("garbage\n"), chain.otherLeafPEM, chain.leafPEM),
+			expected: concatPEM([]byte("garbage\n"), chain.otherLeafPEM, chain.leafPEM),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Subject.Spec.PrivateKeyManagement = tt.management
+			ctx.Subject.Spec.PrivateKeyPublicKeySHA1 = tt.specSHA1
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data:       map[string][]byte{"tls.crt": tt.tlsCrt},
+			}
+			if tt.tlsKey != nil {
+				secret.Data["tls.key"] = tt.tlsKey
+			}
+
+			certPEM, err := getSecretCertPEM(ctx, secret)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, string(tt.expected), string(certPEM))
+		})
+	}
+}
+
+func TestGetSerialNumberFromCertificatePEM_SeveralLeafCertificates(t *testing.T) {
+	chain := createTestCertificateChain(t)
+	ctx := createTestContext()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Data: map[string][]byte{
+			"tls.crt": concatPEM(chain.otherLeafPEM, chain.caPEM, chain.leafPEM),
+			"tls.key": chain.leafKeyPEM,
+		},
+	}
+
+	certPEM, err := getSecretCertPEM(ctx, secret)
+	require.NoError(t, err)
+	serialNumber, err := getSerialNumberFromCertificatePEM(certPEM)
+	require.NoError(t, err)
+	assert.Equal(t, "1", serialNumber, "the serial number compared is that of the leaf matching the private key")
+
+	fingerprints, err := getExpectedPublicKeyFingerprints(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, chain.leafKeySHA1, fingerprints.SHA1)
+	assert.NotEqual(t, chain.otherLeafSHA1, fingerprints.SHA1)
+}
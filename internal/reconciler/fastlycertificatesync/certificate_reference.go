@@ -0,0 +1,105 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// certificateReference returns the namespaced name of the Certificate that the subject syncs
+func certificateReference(subject *v1alpha1.FastlyCertificateSync) types.NamespacedName {
+	if ref := subject.Spec.CertificateRef; ref != nil {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = subject.Namespace
+		}
+		return types.NamespacedName{Name: ref.Name, Namespace: namespace}
+	}
+
+	return types.NamespacedName{Name: subject.Spec.CertificateName, Namespace: subject.Namespace}
+}
+
+// validateCertificateReference ensures that the subject references its Certificate in exactly one way
+func validateCertificateReference(svc *v1alpha1.FastlyCertificateSync) error {
+	if svc.Spec.CertificateRef == nil {
+		return nil
+	}
+
+	if svc.Spec.CertificateName != "" {
+		return fmt.Errorf("spec.certificateName and spec.certificateRef are mutually exclusive")
+	}
+	if svc.Spec.CertificateTemplate != nil {
+		return fmt.Errorf("spec.certificateTemplate and spec.certificateRef are mutually exclusive")
+	}
+
+	return nil
+}
+
+// ParseAllowedCertificateNamespaces parses a comma separated list of namespaces whose Certificates may be referenced
+// from any namespace
+func ParseAllowedCertificateNamespaces(s string) []string {
+	var res []string
+	for _, namespace := range strings.Split(s, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			res = append(res, namespace)
+		}
+	}
+	return res
+}
+
+// +kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=referencegrants,verbs=get;list;watch
+
+// isCertificateReferencePermitted reports whether the subject may sync the referenced Certificate.
+// References within the subject's own namespace are always permitted. Cross-namespace references must be allowed by
+// the operator's allowlist, or by a ReferenceGrant in the Certificate's namespace.
+func isCertificateReferencePermitted(ctx *Context, ref types.NamespacedName) (bool, error) {
+	if ref.Namespace == ctx.Subject.Namespace {
+		return true, nil
+	}
+
+	if slices.Contains(ctx.Config.AllowedCertificateNamespaces, ref.Namespace) {
+		return true, nil
+	}
+
+	grants := &gatewayv1beta1.ReferenceGrantList{}
+	if err := ctx.Client.Client.List(ctx, grants, client.InNamespace(ref.Namespace)); err != nil {
+		// the Gateway API CRDs are not installed, so nothing can be granted
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to list ReferenceGrants in namespace %s: %w", ref.Namespace, err)
+	}
+
+	for _, grant := range grants.Items {
+		if isCertificateReferenceGranted(ctx, &grant, ref) {
+			ctx.Log.V(5).Info("cross-namespace Certificate reference permitted by ReferenceGrant", "reference_grant", grant.Name, "certificate_namespace", ref.Namespace)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isCertificateReferenceGranted reports whether the ReferenceGrant allows the subject to reference the Certificate
+func isCertificateReferenceGranted(ctx *Context, grant *gatewayv1beta1.ReferenceGrant, ref types.NamespacedName) bool {
+	fromGranted := slices.ContainsFunc(grant.Spec.From, func(from gatewayv1beta1.ReferenceGrantFrom) bool {
+		return string(from.Group) == v1alpha1.GroupVersion.Group &&
+			string(from.Kind) == "FastlyCertificateSync" &&
+			string(from.Namespace) == ctx.Subject.Namespace
+	})
+
+	toGranted := slices.ContainsFunc(grant.Spec.To, func(to gatewayv1beta1.ReferenceGrantTo) bool {
+		return string(to.Group) == cmv1.SchemeGroupVersion.Group &&
+			string(to.Kind) == cmv1.CertificateKind &&
+			(to.Name == nil || string(*to.Name) == ref.Name)
+	})
+
+	return fromGranted && toGranted
+}
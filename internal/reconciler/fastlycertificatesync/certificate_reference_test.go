@@ -0,0 +1,156 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func TestCertificateReference(t *testing.T) {
+	subject := &v1alpha1.FastlyCertificateSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "app"},
+		Spec:       v1alpha1.FastlyCertificateSyncSpec{CertificateName: "local-certificate"},
+	}
+	assert.Equal(t, types.NamespacedName{Name: "local-certificate", Namespace: "app"}, certificateReference(subject))
+
+	subject.Spec = v1alpha1.FastlyCertificateSyncSpec{CertificateRef: &v1alpha1.CertificateRef{Name: "shared-certificate"}}
+	assert.Equal(t, types.NamespacedName{Name: "shared-certificate", Namespace: "app"}, certificateReference(subject))
+
+	subject.Spec.CertificateRef.Namespace = "tls-central"
+	assert.Equal(t, types.NamespacedName{Name: "shared-certificate", Namespace: "tls-central"}, certificateReference(subject))
+}
+
+func TestValidateCertificateReference(t *testing.T) {
+	ref := &v1alpha1.CertificateRef{Name: "shared-certificate", Namespace: "tls-central"}
+
+	tests := []struct {
+		name        string
+		spec        v1alpha1.FastlyCertificateSyncSpec
+		expectError bool
+	}{
+		{name: "certificate_name_only", spec: v1alpha1.FastlyCertificateSyncSpec{CertificateName: "local-certificate"}, expectError: false},
+		{name: "certificate_ref_only", spec: v1alpha1.FastlyCertificateSyncSpec{CertificateRef: ref}, expectError: false},
+		{name: "certificate_ref_and_name", spec: v1alpha1.FastlyCertificateSyncSpec{CertificateName: "local-certificate", CertificateRef: ref}, expectError: true},
+		{
+			name: "certificate_ref_and_template",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateRef:      ref,
+				CertificateTemplate: &v1alpha1.CertificateTemplate{DNSNames: []string{"example.com"}},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCertificateReference(&v1alpha1.FastlyCertificateSync{Spec: tt.spec})
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseAllowedCertificateNamespaces(t *testing.T) {
+	assert.Nil(t, ParseAllowedCertificateNamespaces(""))
+	assert.Equal(t, []string{"tls-central", "tls-other"}, ParseAllowedCertificateNamespaces(" tls-central, ,tls-other "))
+}
+
+func TestIsCertificateReferencePermitted(t *testing.T) {
+	grant := func(fromNamespace string, name *gatewayv1beta1.ObjectName) *gatewayv1beta1.ReferenceGrant {
+		return &gatewayv1beta1.ReferenceGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "allow-app", Namespace: "tls-central"},
+			Spec: gatewayv1beta1.ReferenceGrantSpec{
+				From: []gatewayv1beta1.ReferenceGrantFrom{
+					{Group: "platform.seatgeek.io", Kind: "FastlyCertificateSync", Namespace: gatewayv1beta1.Namespace(fromNamespace)},
+				},
+				To: []gatewayv1beta1.ReferenceGrantTo{
+					{Group: "cert-manager.io", Kind: "Certificate", Name: name},
+				},
+			},
+		}
+	}
+	sharedName := gatewayv1beta1.ObjectName("shared-certificate")
+	otherName := gatewayv1beta1.ObjectName("other-certificate")
+
+	tests := []struct {
+		name              string
+		ref               types.NamespacedName
+		allowedNamespaces []string
+		setupObjects      []client.Object
+		expectedPermitted bool
+	}{
+		{
+			name:              "same_namespace",
+			ref:               types.NamespacedName{Name: "local-certificate", Namespace: "test-namespace"},
+			expectedPermitted: true,
+		},
+		{
+			name:              "cross_namespace_without_grant",
+			ref:               types.NamespacedName{Name: "shared-certificate", Namespace: "tls-central"},
+			expectedPermitted: false,
+		},
+		{
+			name:              "cross_namespace_allowlisted",
+			ref:               types.NamespacedName{Name: "shared-certificate", Namespace: "tls-central"},
+			allowedNamespaces: []string{"tls-central"},
+			expectedPermitted: true,
+		},
+		{
+			name:              "cross_namespace_granted_for_all_certificates",
+			ref:               types.NamespacedName{Name: "shared-certificate", Namespace: "tls-central"},
+			setupObjects:      []client.Object{grant("test-namespace", nil)},
+			expectedPermitted: true,
+		},
+		{
+			name:              "cross_namespace_granted_for_named_certificate",
+			ref:               types.NamespacedName{Name: "shared-certificate", Namespace: "tls-central"},
+			setupObjects:      []client.Object{grant("test-namespace", &sharedName)},
+			expectedPermitted: true,
+		},
+		{
+			name:              "cross_namespace_granted_for_other_certificate",
+			ref:               types.NamespacedName{Name: "shared-certificate", Namespace: "tls-central"},
+			setupObjects:      []client.Object{grant("test-namespace", &otherName)},
+			expectedPermitted: false,
+		},
+		{
+			name:              "cross_namespace_granted_to_other_namespace",
+			ref:               types.NamespacedName{Name: "shared-certificate", Namespace: "tls-central"},
+			setupObjects:      []client.Object{grant("other-namespace", nil)},
+			expectedPermitted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = gatewayv1beta1.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.setupObjects...).Build()
+
+			ctx := createTestContext()
+			ctx.Config.AllowedCertificateNamespaces = tt.allowedNamespaces
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			permitted, err := isCertificateReferencePermitted(ctx, tt.ref)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPermitted, permitted)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnableFastlySyncAnnotation marks a Certificate as a source for FastlyCertificateSync resources, unless another
+// annotation is configured with RuntimeConfig.CertificateSyncAnnotation
+const EnableFastlySyncAnnotation = "platform.seatgeek.io/enable-fastly-sync"
+
+// hasCertificateTemplate reports whether the subject creates and owns its own Certificate
+func hasCertificateTemplate(ctx *Context) bool {
+	return ctx.Subject.Spec.CertificateTemplate != nil
+}
+
+// validateCertificateTemplate ensures a templated Certificate does not conflict with an explicit certificate name
+func validateCertificateTemplate(svc *v1alpha1.FastlyCertificateSync) error {
+	if svc.Spec.CertificateTemplate == nil {
+		return nil
+	}
+
+	if svc.Spec.CertificateName != "" && svc.Spec.CertificateName != svc.Name {
+		return fmt.Errorf("spec.certificateName must be empty or %q when spec.certificateTemplate is set", svc.Name)
+	}
+
+	return nil
+}
+
+// generateCertificate renders the cert-manager Certificate described by the subject's certificate template
+func generateCertificate(om kmetav1.ObjectMeta, ctx *Context) (*cmv1.Certificate, error) {
+	// Leave an existing Certificate untouched while suspended
+	if ctx.Subject.IsObserveOnly() {
+		return nil, genrec.ErrDoNothing
+	}
+
+	template := ctx.Subject.Spec.CertificateTemplate
+
+	if om.Annotations == nil {
+		om.Annotations = map[string]string{}
+	}
+	key, value := ctx.Config.certificateSyncAnnotation()
+	om.Annotations[key] = value
+
+	certificate := &cmv1.Certificate{
+		ObjectMeta: om,
+		Spec: cmv1.CertificateSpec{
+			SecretName: om.Name + "-tls",
+			DNSNames:   template.DNSNames,
+			IssuerRef: cmmetav1.ObjectReference{
+				Name:  template.IssuerRef.Name,
+				Kind:  template.IssuerRef.Kind,
+				Group: template.IssuerRef.Group,
+			},
+		},
+	}
+
+	if template.PrivateKeyAlgorithm != "" || template.PrivateKeySize != 0 {
+		certificate.Spec.PrivateKey = &cmv1.CertificatePrivateKey{
+			Algorithm: cmv1.PrivateKeyAlgorithm(template.PrivateKeyAlgorithm),
+			Size:      template.PrivateKeySize,
+		}
+	}
+
+	return certificate, nil
+}
@@ -0,0 +1,99 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestValidateCertificateTemplate(t *testing.T) {
+	template := &v1alpha1.CertificateTemplate{
+		IssuerRef: v1alpha1.CertificateIssuerRef{Name: "letsencrypt"},
+		DNSNames:  []string{"example.com"},
+	}
+
+	tests := []struct {
+		name            string
+		certificateName string
+		template        *v1alpha1.CertificateTemplate
+		expectError     bool
+	}{
+		{name: "no_template", certificateName: "other-certificate", template: nil, expectError: false},
+		{name: "template_without_certificate_name", certificateName: "", template: template, expectError: false},
+		{name: "template_with_matching_certificate_name", certificateName: "test-cert-sync", template: template, expectError: false},
+		{name: "template_with_conflicting_certificate_name", certificateName: "other-certificate", template: template, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &v1alpha1.FastlyCertificateSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace"},
+				Spec: v1alpha1.FastlyCertificateSyncSpec{
+					CertificateName:     tt.certificateName,
+					CertificateTemplate: tt.template,
+				},
+			}
+
+			err := validateCertificateTemplate(svc)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLogic_FillDefaults_CertificateTemplate(t *testing.T) {
+	ctx := createTestContext()
+	ctx.NamespacedName = types.NamespacedName{Name: "test-cert-sync", Namespace: "test-namespace"}
+	ctx.Subject.Spec.CertificateName = ""
+	ctx.Subject.Spec.CertificateTemplate = &v1alpha1.CertificateTemplate{
+		IssuerRef: v1alpha1.CertificateIssuerRef{Name: "letsencrypt"},
+		DNSNames:  []string{"example.com"},
+	}
+
+	require.NoError(t, (&Logic{}).FillDefaults(ctx))
+	assert.Equal(t, "test-cert-sync", ctx.Subject.Spec.CertificateName)
+}
+
+func TestGenerateCertificate(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Spec.CertificateTemplate = &v1alpha1.CertificateTemplate{
+		IssuerRef:           v1alpha1.CertificateIssuerRef{Name: "letsencrypt", Kind: "ClusterIssuer"},
+		DNSNames:            []string{"example.com", "www.example.com"},
+		PrivateKeyAlgorithm: "ECDSA",
+		PrivateKeySize:      256,
+	}
+
+	certificate, err := generateCertificate(metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace"}, ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "test-cert-sync", certificate.Name)
+	assert.Equal(t, "true", certificate.Annotations[EnableFastlySyncAnnotation])
+	assert.Equal(t, "test-cert-sync-tls", certificate.Spec.SecretName)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, certificate.Spec.DNSNames)
+	assert.Equal(t, "letsencrypt", certificate.Spec.IssuerRef.Name)
+	assert.Equal(t, "ClusterIssuer", certificate.Spec.IssuerRef.Kind)
+	require.NotNil(t, certificate.Spec.PrivateKey)
+	assert.Equal(t, cmv1.ECDSAKeyAlgorithm, certificate.Spec.PrivateKey.Algorithm)
+	assert.Equal(t, 256, certificate.Spec.PrivateKey.Size)
+
+	ctx.Subject.Spec.CertificateTemplate.PrivateKeyAlgorithm = ""
+	ctx.Subject.Spec.CertificateTemplate.PrivateKeySize = 0
+	certificate, err = generateCertificate(metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace"}, ctx)
+	require.NoError(t, err)
+	assert.Nil(t, certificate.Spec.PrivateKey, "cert-manager defaults apply when no key settings are templated")
+
+	// Templated Certificates carry the configured sync annotation
+	ctx.Config.CertificateSyncAnnotation = "example.com/fastly"
+	ctx.Config.CertificateSyncAnnotationValue = "enabled"
+	certificate, err = generateCertificate(metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace"}, ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"example.com/fastly": "enabled"}, certificate.Annotations)
+}
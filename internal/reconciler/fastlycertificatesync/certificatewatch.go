@@ -0,0 +1,101 @@
+package fastlycertificatesync
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The decisions of the Certificate watch, see certificateWatchMappingsTotal
+const (
+	certificateWatchMatched            = "matched"
+	certificateWatchSkippedUnannotated = "skipped_unannotated"
+	certificateWatchSkippedIneligible  = "skipped_ineligible"
+	certificateWatchNoTarget           = "no_target"
+	certificateWatchListError          = "list_error"
+)
+
+// mapCertificateToSubjects returns the FastlyCertificateSyncs to reconcile after a change to the Certificate. Each
+// decision is logged at debug level and counted, to tell why a renewal did or didn't trigger a sync.
+func (l *Logic) mapCertificateToSubjects(ctx context.Context, reader client.Reader, object client.Object) []reconcile.Request {
+	log := ctrl.Log.WithName("certificate-watch").V(1).WithValues(
+		"certificate_name", object.GetName(), "certificate_namespace", object.GetNamespace())
+	res := []reconcile.Request{}
+
+	// discard certificate if it is not annotated for fastly-certificate-sync
+	if !isCertificateSyncEnabled(l.Config, object) {
+		log.Info("certificate is not annotated for fastly-certificate-sync, skipping reconciliation")
+		countCertificateWatchMapping(object, certificateWatchSkippedUnannotated)
+		return res
+	}
+
+	all := v1alpha1.FastlyCertificateSyncList{}
+
+	if err := reader.List(ctx, &all, &client.ListOptions{Namespace: kmetav1.NamespaceAll}); err != nil {
+		ctrl.Log.Error(err, "could not list FastlyCertificateSync resources to reconcile while watching Certificates")
+		countCertificateWatchMapping(object, certificateWatchListError)
+		return res
+	}
+
+	// attempt to match a fastlyCertificateSync
+	var ineligible []string
+	for _, fastlyCertificateSync := range all.Items {
+		// reconcile fastlyCertificateSync resources that are referenced by the watched certificate
+		ref := certificateReference(&fastlyCertificateSync)
+		if (object.GetName() != ref.Name) || (object.GetNamespace() != ref.Namespace) {
+			continue
+		}
+
+		nn := types.NamespacedName{Name: fastlyCertificateSync.GetName(), Namespace: fastlyCertificateSync.GetNamespace()}
+
+		// reconciling suspended subjects, or those of another operator instance, would be a no-op
+		if !l.isEnqueueable(&fastlyCertificateSync) {
+			ineligible = append(ineligible, nn.String())
+			continue
+		}
+
+		res = append(res, reconcile.Request{NamespacedName: nn})
+	}
+
+	switch {
+	case len(res) > 0:
+		subjects := make([]string, len(res))
+		for i, req := range res {
+			subjects[i] = req.String()
+		}
+		log.Info("certificate changed, reconciling the FastlyCertificateSyncs referencing it",
+			"subjects", subjects, "skipped_subjects", ineligible)
+		countCertificateWatchMapping(object, certificateWatchMatched)
+	case len(ineligible) > 0:
+		log.Info("certificate changed, but the FastlyCertificateSyncs referencing it are suspended or in another partition",
+			"skipped_subjects", ineligible)
+		countCertificateWatchMapping(object, certificateWatchSkippedIneligible)
+	default:
+		log.Info("certificate changed, but no FastlyCertificateSync references it")
+		countCertificateWatchMapping(object, certificateWatchNoTarget)
+	}
+
+	return res
+}
+
+// isCertificateSyncEnabled reports whether changes to the Certificate are watched, which takes the sync annotation
+// unless the operator is configured to ignore it
+func isCertificateSyncEnabled(config RuntimeConfig, object client.Object) bool {
+	if config.IgnoreCertificateSyncAnnotation {
+		return true
+	}
+	key, value := config.certificateSyncAnnotation()
+	return object.GetAnnotations()[key] == value
+}
+
+// certificateSyncAnnotation returns the annotation key and value marking Certificates as a source for
+// FastlyCertificateSyncs
+func (c RuntimeConfig) certificateSyncAnnotation() (key, value string) {
+	return cmp.Or(c.CertificateSyncAnnotation, EnableFastlySyncAnnotation), cmp.Or(c.CertificateSyncAnnotationValue, "true")
+}
@@ -0,0 +1,76 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestLogic_mapCertificateToSubjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	subject := func(name, certificateName string, suspend bool) client.Object {
+		return &v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "watch-namespace"},
+			Spec:       v1alpha1.FastlyCertificateSyncSpec{CertificateName: certificateName, Suspend: suspend},
+		}
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		subject("active", "shared-certificate", false),
+		subject("suspended", "shared-certificate", true),
+		subject("only-suspended", "paused-certificate", true),
+	).Build()
+
+	certificate := func(name string, annotated bool) client.Object {
+		object := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "watch-namespace"}}
+		if annotated {
+			object.Annotations = map[string]string{EnableFastlySyncAnnotation: "true"}
+		}
+		return object
+	}
+	t.Cleanup(func() {
+		certificateWatchMappingsTotal.DeletePartialMatch(prometheus.Labels{"namespace": "watch-namespace"})
+	})
+
+	logic := &Logic{}
+	ctx := context.Background()
+
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "active", Namespace: "watch-namespace"}}},
+		logic.mapCertificateToSubjects(ctx, reader, certificate("shared-certificate", true)))
+	assert.Empty(t, logic.mapCertificateToSubjects(ctx, reader, certificate("shared-certificate", false)))
+	assert.Empty(t, logic.mapCertificateToSubjects(ctx, reader, certificate("paused-certificate", true)))
+	assert.Empty(t, logic.mapCertificateToSubjects(ctx, reader, certificate("unreferenced-certificate", true)))
+
+	for result, expected := range map[string]float64{
+		certificateWatchMatched:            1,
+		certificateWatchSkippedUnannotated: 1,
+		certificateWatchSkippedIneligible:  1,
+		certificateWatchNoTarget:           1,
+		certificateWatchListError:          0,
+	} {
+		assert.Equal(t, expected, counterValue(t, certificateWatchMappingsTotal.WithLabelValues("watch-namespace", result)), result)
+	}
+
+	// The annotation gating the watch is configurable
+	logic.Config = RuntimeConfig{CertificateSyncAnnotation: "example.com/fastly", CertificateSyncAnnotationValue: "enabled"}
+	assert.Empty(t, logic.mapCertificateToSubjects(ctx, reader, certificate("shared-certificate", true)))
+	relabeled := certificate("shared-certificate", false)
+	relabeled.SetAnnotations(map[string]string{"example.com/fastly": "enabled"})
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "active", Namespace: "watch-namespace"}}},
+		logic.mapCertificateToSubjects(ctx, reader, relabeled))
+
+	// Unannotated Certificates are watched as well when the annotation is ignored
+	logic.Config.IgnoreCertificateSyncAnnotation = true
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "active", Namespace: "watch-namespace"}}},
+		logic.mapCertificateToSubjects(ctx, reader, certificate("shared-certificate", false)))
+}
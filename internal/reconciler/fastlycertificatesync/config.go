@@ -1,9 +1,124 @@
 package fastlycertificatesync
 
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
 // RuntimeConfig contains the runtime configuration for the FastlyCertificateSync controller
 type RuntimeConfig struct {
 	// Configuration fields can be added here as needed
 	HackFastlyCertificateSyncLocalReconciliation bool
+	// LocalReconciliationAllowMissingCA uploads the certificate without its CA in local reconciliation mode when the
+	// secret holds none, rather than failing, for local issuers that don't publish their CA
+	LocalReconciliationAllowMissingCA bool
+
+	// PartitionAnnotation is the annotation assigning subjects to a partition, and Partition the partition reconciled
+	// by this instance of the operator. Subjects of other partitions are not enqueued when their Certificate changes.
+	PartitionAnnotation string
+	Partition           string
+
+	// FastlyTokenSecretsByNamespace maps a subject namespace to the name of a secret holding the Fastly API token
+	// for that namespace. Namespaces without an entry use the operator's default Fastly client.
+	FastlyTokenSecretsByNamespace map[string]string
+	// FastlyTokenSecretNamespace is the namespace the per-namespace token secrets live in
+	FastlyTokenSecretNamespace string
+	// FastlyTokenSecretKey is the key within each per-namespace token secret holding the API token
+	FastlyTokenSecretKey string
+
+	// FastlyObjectNamePrefix is prepended to the names of certificates and private keys created in Fastly.
+	// When set, Fastly objects outside of this prefix are never modified unless explicitly adopted.
+	FastlyObjectNamePrefix string
+	// FastlyClusterName, when set, is embedded along with the namespace into the names of certificates and private
+	// keys created in Fastly, see fastlyObjectName. Objects named for another cluster are never modified.
+	FastlyClusterName string
+	// FastlyNamespacedObjectNames embeds the namespace into the names of certificates and private keys created in
+	// Fastly without a cluster name as well, so that Certificates of the same name in different namespaces don't
+	// collide. Objects named before it was enabled are renamed by the subject tracking them.
+	FastlyNamespacedObjectNames bool
+
+	// PrivateKeyUploadCacheTTL is how long an uploaded private key is assumed to exist in Fastly, even when it is not
+	// listed yet. Zero disables the cache.
+	PrivateKeyUploadCacheTTL time.Duration
+	// FastlyInventoryCacheTTL is how long the listing of an account's certificates and private keys is reused across
+	// reconciles. Zero lists the account on every reconcile.
+	FastlyInventoryCacheTTL time.Duration
+
+	// MutationBudgetWindow is the sliding window over which Fastly write operations are counted
+	MutationBudgetWindow time.Duration
+	// GlobalMutationBudget caps the Fastly write operations across all subjects within the window, zero is unlimited
+	GlobalMutationBudget int
+	// SubjectMutationBudget caps the Fastly write operations of a single subject within the window, zero is unlimited
+	SubjectMutationBudget int
+	// MutationPauseConfigMap is the ConfigMap whose paused key set to "true" halts every Fastly write operation, while
+	// subjects keep being observed. Empty disables the pause.
+	MutationPauseConfigMap types.NamespacedName
+
+	// FastlyCustomCertificateLimit and FastlyPrivateKeyLimit are the custom certificates and private keys each Fastly
+	// account may hold, as audited by AccountAudit. Creations are refused once an account reaches a limit, zero is
+	// unlimited.
+	FastlyCustomCertificateLimit int
+	FastlyPrivateKeyLimit        int
+	// FastlyQuotaWarningPercent is the share of a limit at which the QuotaNearLimit condition is raised
+	FastlyQuotaWarningPercent int
+
+	// DriftCheckInterval is how soon a subject that is in sync is reconciled again, to notice changes made to Fastly
+	// out of band. Zero leaves this to the resync period.
+	DriftCheckInterval time.Duration
+	// QuickDriftCheck looks up the synced certificate by ID on drift checks, instead of observing all of Fastly
+	QuickDriftCheck bool
+
+	// StaleThreshold is how long the certificate may remain stale or missing in Fastly before the subject is reported
+	// as stuck, in the StaleTooLong condition and metric. Zero disables the report.
+	StaleThreshold time.Duration
+
+	// WriterConflictHoldOff is how long updates are held back after another writer overwrote the certificate this
+	// operator last wrote to Fastly, so that two operators syncing the same certificate don't keep overwriting each
+	// other. Zero disables the hold off.
+	WriterConflictHoldOff time.Duration
+
+	// DefaultTLSConfigurationFallback activates certificates of subjects without TLS configuration IDs on the
+	// account's default TLS configuration, rather than on none at all
+	DefaultTLSConfigurationFallback bool
+
+	// DomainVerificationCheck looks up whether the DNS names of each certificate are verified in Fastly's Domain
+	// Management API, for accounts that only activate certificates on verified domains
+	DomainVerificationCheck bool
+
+	// TLSActivationParallelism caps the TLS activations created or deleted concurrently for a single subject
+	TLSActivationParallelism int
+	// PrivateKeyDeletionParallelism caps the unused private keys deleted concurrently in a single account
+	PrivateKeyDeletionParallelism int
+
+	// NotificationInterval is the minimum time between notifications of the same kind for a single subject
+	NotificationInterval time.Duration
+	// NotificationExpiryThreshold is how long before the certificate served by Fastly expires to notify about it,
+	// zero disables expiry notifications
+	NotificationExpiryThreshold time.Duration
+
+	// RetryBaseDelay and RetryMaxDelay bound the per-subject exponential backoff between retries of reconciles that
+	// returned an error, zero keeps the controller-runtime default
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	// AllowedCertificateNamespaces lists namespaces whose Certificates may be referenced from any namespace, without
+	// requiring a ReferenceGrant
+	AllowedCertificateNamespaces []string
+
+	// CertificateSyncAnnotation and CertificateSyncAnnotationValue mark the Certificates whose changes are watched,
+	// EnableFastlySyncAnnotation set to "true" when empty
+	CertificateSyncAnnotation      string
+	CertificateSyncAnnotationValue string
+	// LabelSources labels the source Certificate and Secret of each subject with FastlyCertificateSyncLabel
+	LabelSources bool
+
+	// IgnoreCertificateSyncAnnotation watches every Certificate referenced by a FastlyCertificateSync, rather than only
+	// those carrying the sync annotation
+	IgnoreCertificateSyncAnnotation bool
+
+	// StatusDebug reports the state of Fastly last observed for each subject in status.debug
+	StatusDebug bool
 }
 
 // Config wraps the runtime configuration
@@ -0,0 +1,52 @@
+package fastlycertificatesync
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+)
+
+// fillDebugStatus reports the state of Fastly last observed for the subject with -status-debug. The last snapshot is
+// carried over while the subject isn't ready for reconciliation, as Fastly then isn't observed.
+func (l *Logic) fillDebugStatus(ctx *Context) {
+	if !l.Config.StatusDebug {
+		ctx.Subject.Status.Debug = nil
+		return
+	}
+	if l.SubjectReadyForReconciliation {
+		ctx.Subject.Status.Debug = l.ObservedState.debugStatus()
+	}
+}
+
+// debugStatus snapshots the observed state, in a stable order so that the status isn't rewritten for nothing
+func (o *ObservedState) debugStatus() *v1alpha1.DebugStatus {
+	res := &v1alpha1.DebugStatus{
+		PrivateKeyUploaded:    o.PrivateKeyUploaded,
+		PrivateKeyID:          o.PrivateKeyID,
+		CertificateStatus:     string(o.CertificateStatus),
+		LocalSerialNumber:     o.LocalSerialNumber,
+		QuickDriftCheck:       o.QuickDriftChecked,
+		ExtraTLSActivationIDs: slices.Sorted(slices.Values(o.ExtraTLSActivationIDs)),
+		PendingChanges:        o.pendingMutations(),
+	}
+	if o.FastlyCertificate != nil {
+		res.FastlyCertificateID = o.FastlyCertificate.ID
+	}
+
+	for _, data := range o.MissingTLSActivationData {
+		activation := v1alpha1.DebugTLSActivation{}
+		if data.Domain != nil {
+			activation.Domain = data.Domain.ID
+		}
+		if data.Configuration != nil {
+			activation.ConfigurationID = data.Configuration.ID
+		}
+		res.MissingTLSActivations = append(res.MissingTLSActivations, activation)
+	}
+	slices.SortFunc(res.MissingTLSActivations, func(a, b v1alpha1.DebugTLSActivation) int {
+		return cmp.Or(cmp.Compare(a.Domain, b.Domain), cmp.Compare(a.ConfigurationID, b.ConfigurationID))
+	})
+
+	return res
+}
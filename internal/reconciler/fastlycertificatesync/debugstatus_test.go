@@ -0,0 +1,68 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogic_FillStatus_Debug(t *testing.T) {
+	observedState := ObservedState{
+		PrivateKeyUploaded: true,
+		PrivateKeyID:       "key1",
+		CertificateStatus:  CertificateStatusSynced,
+		LocalSerialNumber:  "01",
+		FastlyCertificate:  &fastly.CustomTLSCertificate{ID: "cert1"},
+		MissingTLSActivationData: []TLSActivationData{
+			{Domain: &fastly.TLSDomain{ID: "www.example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}},
+			{Domain: &fastly.TLSDomain{ID: "example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}},
+		},
+		ExtraTLSActivationIDs: []string{"act2", "act1"},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ctx := createTestContext()
+		ctx.Subject.Status.Debug = &v1alpha1.DebugStatus{PrivateKeyUploaded: true}
+		logic := &Logic{ObservedState: observedState, SubjectReadyForReconciliation: true}
+
+		require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+		assert.Nil(t, ctx.Subject.Status.Debug, "dropped once -status-debug is unset")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		ctx := createTestContext()
+		logic := &Logic{
+			Config:                        RuntimeConfig{StatusDebug: true},
+			ObservedState:                 observedState,
+			SubjectReadyForReconciliation: true,
+		}
+
+		require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+		assert.Equal(t, &v1alpha1.DebugStatus{
+			PrivateKeyUploaded:  true,
+			PrivateKeyID:        "key1",
+			CertificateStatus:   "Synced",
+			FastlyCertificateID: "cert1",
+			LocalSerialNumber:   "01",
+			MissingTLSActivations: []v1alpha1.DebugTLSActivation{
+				{Domain: "example.com", ConfigurationID: "config1"},
+				{Domain: "www.example.com", ConfigurationID: "config1"},
+			},
+			ExtraTLSActivationIDs: []string{"act1", "act2"},
+			PendingChanges:        []string{"create 2 TLS activation(s)", "delete 2 TLS activation(s)"},
+		}, ctx.Subject.Status.Debug)
+	})
+
+	t.Run("not ready for reconciliation", func(t *testing.T) {
+		ctx := createTestContext()
+		last := &v1alpha1.DebugStatus{PrivateKeyUploaded: true, CertificateStatus: "Synced"}
+		ctx.Subject.Status.Debug = last
+		logic := &Logic{Config: RuntimeConfig{StatusDebug: true}}
+
+		require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+		assert.Equal(t, last, ctx.Subject.Status.Debug, "the last snapshot is carried over")
+	})
+}
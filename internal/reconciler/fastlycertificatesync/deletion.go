@@ -0,0 +1,268 @@
+package fastlycertificatesync
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Finalizer keeps a deleted FastlyCertificateSync around until its certificate is deleted from Fastly, for
+// spec.deletionPolicy Delete
+const Finalizer = "platform.seatgeek.io/fastly-certificate-sync"
+
+// DeletionProtectedAnnotation set to "true" on a FastlyCertificateSync keeps it from being finalized, so that it stays
+// Terminating with its certificate untouched in Fastly until the annotation is removed
+const DeletionProtectedAnnotation = "platform.seatgeek.io/deletion-protected"
+
+const (
+	deletionProtectedConditionType = "DeletionProtected"
+	certificateInUseConditionType  = "CertificateInUse"
+)
+
+var errFastlyCertificateInUse = errors.New("certificate is in use")
+
+// isDeletionProtected reports whether the subject carries the DeletionProtectedAnnotation
+func isDeletionProtected(subject *v1alpha1.FastlyCertificateSync) bool {
+	return subject.GetAnnotations()[DeletionProtectedAnnotation] == "true"
+}
+
+// holdProtectedDeletion refuses to finalize a deletion protected subject, recording an event as to why. The subject
+// keeps being reconciled as usual, reporting the blocked deletion with the DeletionProtected condition, and is
+// finalized once an update removes the annotation.
+func (l *Logic) holdProtectedDeletion(ctx *Context) genrec.FinalizationAction {
+	ctx.Log.Info("deletion is blocked", "annotation", DeletionProtectedAnnotation)
+	ctx.Eventf(ctx.Subject, corev1.EventTypeWarning, "DeletionProtected",
+		"Deletion is blocked until the %s annotation is removed", DeletionProtectedAnnotation)
+	return genrec.FinalizationImpossible
+}
+
+// observeDeletionProtectedCondition reports a deletion blocked by the DeletionProtectedAnnotation
+func (l *Logic) observeDeletionProtectedCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || !ctx.IsFinalizing() || !isDeletionProtected(ctx.Subject) {
+		return nil, nil
+	}
+	return &kmetav1.Condition{
+		Type:    deletionProtectedConditionType,
+		Status:  kmetav1.ConditionTrue,
+		Reason:  "DeletionBlocked",
+		Message: fmt.Sprintf("Deletion is blocked until the %s annotation is removed", DeletionProtectedAnnotation),
+	}, nil
+}
+
+// deleteFastlyCertificates deletes the certificate the subject synced to from every account it synced to, once the
+// subject is deleted with spec.deletionPolicy Delete. Certificates are only ever found by the ID tracked in status, so
+// nothing the operator didn't sync for this subject is deleted.
+func (l *Logic) deleteFastlyCertificates(ctx *Context) error {
+	if !ctx.Subject.IsDeletionPolicyDelete() {
+		return nil
+	}
+	if ctx.Subject.Spec.Suspend {
+		ctx.Log.Info("FastlyCertificateSync is suspended, leaving its Fastly certificate in place")
+		return nil
+	}
+
+	if len(ctx.Subject.Spec.Accounts) == 0 {
+		// Subjects deleted before their first sync left nothing behind, and previous certificates kept after a
+		// rotation go along with the current one
+		var ids []string
+		if previous := ctx.Subject.Status.PreviousCertificate; previous != nil {
+			ids = append(ids, previous.CertificateID)
+		}
+		if id := ctx.Subject.Status.CertificateID; id != "" {
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := l.resolveFastlyClient(ctx); err != nil {
+			return err
+		}
+		defer l.useFastlyAccount(nil)
+
+		for _, id := range ids {
+			if err := l.deleteFastlyCertificate(ctx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	defer l.useFastlyAccount(nil)
+	log := ctx.Log
+	defer func() { ctx.Log = log }()
+
+	var errs []error
+	for _, status := range ctx.Subject.Status.Accounts {
+		if status.CertificateID == "" {
+			continue
+		}
+		i := slices.IndexFunc(ctx.Subject.Spec.Accounts, func(account v1alpha1.FastlyAccount) bool { return account.Name == status.Name })
+		if i < 0 {
+			continue
+		}
+		account := ctx.Subject.Spec.Accounts[i]
+
+		ctx.Log = log.WithValues(logKeyAccount, account.Name)
+		if err := l.resolveFastlyAccountClient(ctx, account); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		l.currentAccount = &account
+
+		if err := l.deleteFastlyCertificate(ctx, status.CertificateID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete Fastly certificate in account %s: %w", account.Name, err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// deleteFastlyCertificate deletes a certificate from the current account, after its TLS activations which Fastly
+// requires to be gone first. Certificates outside of the owned prefix, or that another FastlyCertificateSync syncs to,
+// are left in place, as are those still activated when the subject refuses to delete in use certificates.
+func (l *Logic) deleteFastlyCertificate(ctx *Context, id string) error {
+	cert, err := l.fastlyClient().GetCustomTLSCertificate(ctx, &fastly.GetCustomTLSCertificateInput{ID: id})
+	if err != nil {
+		var httpErr *fastly.HTTPError
+		if errors.As(err, &httpErr) && httpErr.IsNotFound() {
+			ctx.Log.Info("Fastly certificate is already deleted", "certificate_id", id)
+			return nil
+		}
+		return fmt.Errorf("failed to get Fastly certificate %s: %w", id, err)
+	}
+
+	if hasOwnedFastlyNames(ctx) && !isFastlyObjectOwned(ctx, cert.Name) {
+		ctx.Log.Info("Fastly certificate is not owned by the operator, leaving it in place", "certificate_id", id, "name", cert.Name)
+		return nil
+	}
+
+	shared, err := l.isFastlyCertificateShared(ctx, id)
+	if err != nil {
+		return err
+	}
+	if shared {
+		ctx.Log.Info("Fastly certificate is synced by another FastlyCertificateSync, leaving it in place", "certificate_id", id)
+		return nil
+	}
+
+	activations, err := l.getFastlyDomainAndConfigurationToActivationMap(ctx, cert)
+	if err != nil {
+		return err
+	}
+	if len(activations) > 0 && ctx.Subject.RefusesInUseDeletion() {
+		return l.refuseInUseDeletion(ctx, cert, activations)
+	}
+	for _, byConfiguration := range activations {
+		for _, activation := range byConfiguration {
+			if err := l.reserveFastlyDeletion(ctx); err != nil {
+				return err
+			}
+			if err := l.fastlyClient().DeleteTLSActivation(ctx, &fastly.DeleteTLSActivationInput{ID: activation.ID}); err != nil {
+				return fmt.Errorf("failed to delete TLS activation %s: %w", activation.ID, err)
+			}
+		}
+	}
+
+	if err := l.reserveFastlyDeletion(ctx); err != nil {
+		return err
+	}
+	ctx.Log.Info("deleting Fastly certificate", "certificate_id", id, "name", cert.Name)
+	err = l.fastlyClient().DeleteCustomTLSCertificate(ctx, &fastly.DeleteCustomTLSCertificateInput{ID: id})
+	l.forgetFastlyInventory()
+	if err != nil {
+		return fmt.Errorf("failed to delete Fastly certificate %s: %w", id, err)
+	}
+	l.syncedSubjects.Forget(ctx.NamespacedName)
+	return l.clearCertificateInUse(ctx)
+}
+
+// refuseInUseDeletion leaves a certificate still referenced by TLS activations in place, reporting it with the
+// CertificateInUse condition and an event. Like the DeletionProtected condition, the condition is patched in, as the
+// status isn't filled while the subject is being deleted, and is already written by the time changes are applied.
+func (l *Logic) refuseInUseDeletion(ctx *Context, cert *fastly.CustomTLSCertificate, activations map[string]map[string]*fastly.TLSActivation) error {
+	message := fmt.Sprintf("Fastly certificate %s was not deleted, as it is still activated on %s", cert.ID,
+		strings.Join(slices.Sorted(maps.Keys(activations)), ", "))
+
+	patch := client.MergeFrom(ctx.Subject.DeepCopy())
+	apimeta.SetStatusCondition(&ctx.Subject.Status.Conditions, kmetav1.Condition{
+		Type:               certificateInUseConditionType,
+		Status:             kmetav1.ConditionTrue,
+		Reason:             "TLSActivationsRemaining",
+		Message:            message,
+		ObservedGeneration: ctx.Subject.Generation,
+	})
+	if err := ctx.Client.Client.Status().Patch(ctx, ctx.Subject, patch); err != nil {
+		return fmt.Errorf("failed to report the Fastly certificate in use: %w", err)
+	}
+
+	ctx.Eventf(ctx.Subject, corev1.EventTypeWarning, "CertificateInUse", "%s", message)
+	return fmt.Errorf("refusing to delete Fastly certificate %s: %w", cert.ID, errFastlyCertificateInUse)
+}
+
+// clearCertificateInUse drops the CertificateInUse condition once the certificate it reported is gone
+func (l *Logic) clearCertificateInUse(ctx *Context) error {
+	if apimeta.FindStatusCondition(ctx.Subject.Status.Conditions, certificateInUseConditionType) == nil {
+		return nil
+	}
+
+	patch := client.MergeFrom(ctx.Subject.DeepCopy())
+	apimeta.RemoveStatusCondition(&ctx.Subject.Status.Conditions, certificateInUseConditionType)
+	if err := ctx.Client.Client.Status().Patch(ctx, ctx.Subject, patch); err != nil {
+		return fmt.Errorf("failed to clear the CertificateInUse condition: %w", err)
+	}
+	return nil
+}
+
+// observeCertificateInUseCondition carries over the CertificateInUse condition reported when a certificate was left in
+// place, until it is deleted. It is omitted for subjects that don't refuse to delete certificates in use.
+func (l *Logic) observeCertificateInUseCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || !ctx.Subject.RefusesInUseDeletion() {
+		return nil, nil
+	}
+	return apimeta.FindStatusCondition(ctx.Subject.Status.Conditions, certificateInUseConditionType), nil
+}
+
+// reserveFastlyDeletion counts a deletion against the mutation budget, failing the finalization until the budget
+// allows for it, and Fastly write operations aren't paused
+func (l *Logic) reserveFastlyDeletion(ctx *Context) error {
+	if l.areFastlyMutationsPaused() {
+		return errors.New("write operations to Fastly are paused, retrying deletion once the pause is lifted")
+	}
+	if allowed, retryAfter := l.reserveFastlyMutation(ctx); !allowed {
+		return fmt.Errorf("mutation budget exceeded, retrying deletion in %s", retryAfter)
+	}
+	return nil
+}
+
+// isFastlyCertificateShared reports whether any other FastlyCertificateSync tracks the certificate of the given ID in
+// its status, such as one matching the certificate by serial number
+func (l *Logic) isFastlyCertificateShared(ctx *Context, id string) (bool, error) {
+	all := v1alpha1.FastlyCertificateSyncList{}
+	if err := ctx.Client.Client.List(ctx, &all, &client.ListOptions{Namespace: kmetav1.NamespaceAll}); err != nil {
+		return false, fmt.Errorf("failed to list FastlyCertificateSyncs: %w", err)
+	}
+
+	for _, other := range all.Items {
+		if other.Namespace == ctx.Subject.Namespace && other.Name == ctx.Subject.Name {
+			continue
+		}
+		if other.Status.CertificateID == id {
+			return true, nil
+		}
+		for _, account := range other.Status.Accounts {
+			if account.CertificateID == id {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,356 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func createDeletionTestContext(objects ...client.Object) *Context {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.DeletionPolicy = v1alpha1.DeletionPolicyDelete
+	ctx.Subject.Status.CertificateID = "cert1"
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(objects, ctx.Subject)...).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	return ctx
+}
+
+func TestLogic_deleteFastlyCertificates(t *testing.T) {
+	newMockClient := func(name string) *MockFastlyClient {
+		return &MockFastlyClient{
+			GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+				return &fastly.CustomTLSCertificate{ID: input.ID, Name: name}, nil
+			},
+			ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+				assert.Equal(t, "cert1", input.FilterTLSCertificateID)
+				return []*fastly.TLSActivation{
+					{ID: "act1", Domain: &fastly.TLSDomain{ID: "example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}},
+					{ID: "act2", Domain: &fastly.TLSDomain{ID: "www.example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}},
+				}, nil
+			},
+		}
+	}
+
+	t.Run("deletes activations before the certificate", func(t *testing.T) {
+		mockClient := newMockClient("k8s-test-certificate")
+		mockClient.DeleteCustomTLSCertificateFunc = func(ctx context.Context, input *fastly.DeleteCustomTLSCertificateInput) error {
+			assert.Len(t, mockClient.DeleteTLSActivationCalls, 2, "activations must be gone before the certificate")
+			return nil
+		}
+		ctx := createDeletionTestContext()
+		ctx.Config.FastlyObjectNamePrefix = "k8s-"
+
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.ElementsMatch(t, []string{"act1", "act2"}, mockClient.DeleteTLSActivationCalls)
+		assert.Equal(t, []string{"cert1"}, mockClient.DeleteCustomTLSCertificateCalls)
+	})
+
+	t.Run("refused while in use", func(t *testing.T) {
+		mockClient := newMockClient("k8s-test-certificate")
+		ctx := createDeletionTestContext()
+		ctx.Config.FastlyObjectNamePrefix = "k8s-"
+		ctx.Subject.Spec.ActivationDeletionPolicy = v1alpha1.ActivationDeletionPolicyRefuse
+		recorder := record.NewFakeRecorder(1)
+		ctx.EventRecorder = recorder
+
+		err := (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx)
+		assert.ErrorIs(t, err, errFastlyCertificateInUse)
+		assert.Empty(t, mockClient.DeleteTLSActivationCalls)
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+		assert.Equal(t, "Warning CertificateInUse Fastly certificate cert1 was not deleted, as it is still activated on example.com, www.example.com",
+			<-recorder.Events)
+
+		subject := &v1alpha1.FastlyCertificateSync{}
+		require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+		condition := apimeta.FindStatusCondition(subject.Status.Conditions, certificateInUseConditionType)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+
+		mockClient.ListTLSActivationsFunc = nil
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.Equal(t, []string{"cert1"}, mockClient.DeleteCustomTLSCertificateCalls, "deleted once no longer activated")
+		require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+		assert.Nil(t, apimeta.FindStatusCondition(subject.Status.Conditions, certificateInUseConditionType))
+	})
+
+	t.Run("retained by default", func(t *testing.T) {
+		mockClient := newMockClient("test-certificate")
+		ctx := createDeletionTestContext()
+		ctx.Subject.Spec.DeletionPolicy = ""
+
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+	})
+
+	t.Run("suspended", func(t *testing.T) {
+		mockClient := newMockClient("test-certificate")
+		ctx := createDeletionTestContext()
+		ctx.Subject.Spec.Suspend = true
+		ctx.Subject.Spec.SuspendMode = v1alpha1.SuspendModeObserveOnly
+
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+	})
+
+	t.Run("never synced", func(t *testing.T) {
+		mockClient := newMockClient("test-certificate")
+		ctx := createDeletionTestContext()
+		ctx.Subject.Status.CertificateID = ""
+
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+	})
+
+	t.Run("not owned", func(t *testing.T) {
+		mockClient := newMockClient("terraform-managed")
+		ctx := createDeletionTestContext()
+		ctx.Config.FastlyObjectNamePrefix = "k8s-"
+
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.Empty(t, mockClient.DeleteTLSActivationCalls)
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+	})
+
+	t.Run("synced by another resource", func(t *testing.T) {
+		mockClient := newMockClient("test-certificate")
+		ctx := createDeletionTestContext(&v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other-namespace"},
+			Status:     v1alpha1.FastlyCertificateSyncStatus{CertificateID: "cert1"},
+		})
+
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.Empty(t, mockClient.DeleteTLSActivationCalls)
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+	})
+
+	t.Run("already deleted", func(t *testing.T) {
+		mockClient := newMockClient("test-certificate")
+		mockClient.GetCustomTLSCertificateFunc = func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return nil, &fastly.HTTPError{StatusCode: http.StatusNotFound}
+		}
+		ctx := createDeletionTestContext()
+
+		require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+	})
+
+	t.Run("failed deletion", func(t *testing.T) {
+		mockClient := newMockClient("test-certificate")
+		mockClient.DeleteCustomTLSCertificateFunc = func(ctx context.Context, input *fastly.DeleteCustomTLSCertificateInput) error {
+			return assert.AnError
+		}
+		ctx := createDeletionTestContext()
+
+		_, err := (&Logic{FastlyClient: mockClient}).Finalize(ctx)
+		assert.ErrorIs(t, err, assert.AnError, "failed deletions are retried")
+	})
+}
+
+func TestLogic_deleteFastlyCertificates_Accounts(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return &fastly.CustomTLSCertificate{ID: input.ID, Name: "test-certificate"}, nil
+		},
+	}
+	ctx := createDeletionTestContext()
+	ctx.Subject.Spec.Accounts = []v1alpha1.FastlyAccount{{Name: "staging"}, {Name: "production"}}
+	ctx.Subject.Status.CertificateID = ""
+	ctx.Subject.Status.Accounts = []v1alpha1.FastlyAccountStatus{
+		{Name: "staging", CertificateID: "staging-cert"},
+		{Name: "production", CertificateID: "production-cert"},
+		{Name: "removed", CertificateID: "removed-cert"},
+	}
+
+	logic := &Logic{FastlyClient: mockClient}
+	require.NoError(t, logic.deleteFastlyCertificates(ctx))
+	assert.Equal(t, []string{"staging-cert", "production-cert"}, mockClient.DeleteCustomTLSCertificateCalls,
+		"only accounts still in spec.accounts can be talked to")
+	assert.Nil(t, logic.currentAccount)
+}
+
+func TestLogic_Finalize_DeletionProtected(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return &fastly.CustomTLSCertificate{ID: input.ID, Name: "test-certificate"}, nil
+		},
+	}
+	ctx := createDeletionTestContext()
+	ctx.Subject.Annotations = map[string]string{DeletionProtectedAnnotation: "true"}
+	recorder := record.NewFakeRecorder(1)
+	ctx.EventRecorder = recorder
+	logic := &Logic{FastlyClient: mockClient}
+
+	action, err := logic.Finalize(ctx)
+	require.NoError(t, err, "a blocked deletion isn't a failure")
+	assert.Equal(t, genrec.FinalizationImpossible, action, "protected subjects stay Terminating")
+	assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+	assert.Equal(t, "Warning DeletionProtected Deletion is blocked until the "+DeletionProtectedAnnotation+" annotation is removed",
+		<-recorder.Events)
+
+	ctx.Finalization = action
+	condition, err := logic.observeDeletionProtectedCondition(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "DeletionBlocked", condition.Reason)
+
+	ctx.Subject.Annotations[DeletionProtectedAnnotation] = "false"
+	action, err = logic.Finalize(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, genrec.FinalizationCompleted, action)
+	assert.Equal(t, []string{"cert1"}, mockClient.DeleteCustomTLSCertificateCalls, "deleted once the annotation is removed")
+}
+
+func TestReconciler_Finalize(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, cmv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	newReconciler := func(mockClient *MockFastlyClient, objects ...client.Object) (*genrec.Reconciler[*v1alpha1.FastlyCertificateSync, *Config], client.Client) {
+		fakeClient := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+			Build()
+		logic := &Logic{
+			ResourceManager: ResourceManager,
+			Config:          RuntimeConfig{FastlyObjectNamePrefix: "k8s-"},
+			FastlyClient:    mockClient,
+		}
+		return &genrec.Reconciler[*v1alpha1.FastlyCertificateSync, *Config]{
+			Logic:  logic,
+			Client: k8sutil.SchemedClient{Client: fakeClient, Scheme: scheme},
+		}, fakeClient
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-cert-sync", Namespace: "test-namespace"}}
+
+	t.Run("adds the finalizer to live subjects", func(t *testing.T) {
+		reconciler, fakeClient := newReconciler(&MockFastlyClient{}, &v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace"},
+			Spec:       v1alpha1.FastlyCertificateSyncSpec{CertificateName: "test-certificate"},
+		})
+
+		_, err := reconciler.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+
+		subject := &v1alpha1.FastlyCertificateSync{}
+		require.NoError(t, fakeClient.Get(context.Background(), request.NamespacedName, subject))
+		assert.Equal(t, []string{Finalizer}, subject.Finalizers)
+	})
+
+	t.Run("deletes the Fastly certificate of deleted subjects", func(t *testing.T) {
+		mockClient := &MockFastlyClient{
+			GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+				return &fastly.CustomTLSCertificate{ID: input.ID, Name: "k8s-test-certificate"}, nil
+			},
+		}
+		reconciler, fakeClient := newReconciler(mockClient, &v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "test-cert-sync",
+				Namespace:         "test-namespace",
+				Finalizers:        []string{Finalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				DeletionPolicy:  v1alpha1.DeletionPolicyDelete,
+			},
+			Status: v1alpha1.FastlyCertificateSyncStatus{CertificateID: "cert1"},
+		})
+
+		_, err := reconciler.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cert1"}, mockClient.DeleteCustomTLSCertificateCalls)
+
+		err = fakeClient.Get(context.Background(), request.NamespacedName, &v1alpha1.FastlyCertificateSync{})
+		assert.True(t, apierrors.IsNotFound(err), "the subject is gone once finalized, got %v", err)
+	})
+
+	t.Run("finalizes suspended subjects without deleting", func(t *testing.T) {
+		mockClient := &MockFastlyClient{}
+		reconciler, fakeClient := newReconciler(mockClient, &v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "test-cert-sync",
+				Namespace:         "test-namespace",
+				Finalizers:        []string{Finalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				DeletionPolicy:  v1alpha1.DeletionPolicyDelete,
+				Suspend:         true,
+			},
+			Status: v1alpha1.FastlyCertificateSyncStatus{CertificateID: "cert1"},
+		})
+
+		_, err := reconciler.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+
+		err = fakeClient.Get(context.Background(), request.NamespacedName, &v1alpha1.FastlyCertificateSync{})
+		assert.True(t, apierrors.IsNotFound(err), "the subject is gone once finalized, got %v", err)
+	})
+
+	t.Run("keeps protected subjects without failing", func(t *testing.T) {
+		mockClient := &MockFastlyClient{}
+		reconciler, fakeClient := newReconciler(mockClient, &v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "test-cert-sync",
+				Namespace:         "test-namespace",
+				Annotations:       map[string]string{DeletionProtectedAnnotation: "true"},
+				Finalizers:        []string{Finalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				DeletionPolicy:  v1alpha1.DeletionPolicyDelete,
+			},
+			Status: v1alpha1.FastlyCertificateSyncStatus{CertificateID: "cert1"},
+		})
+		recorder := record.NewFakeRecorder(1)
+		reconciler.Recorder = recorder
+
+		_, err := reconciler.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+		assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+		assert.Equal(t, "Warning DeletionProtected Deletion is blocked until the "+DeletionProtectedAnnotation+" annotation is removed",
+			<-recorder.Events)
+
+		subject := &v1alpha1.FastlyCertificateSync{}
+		require.NoError(t, fakeClient.Get(context.Background(), request.NamespacedName, subject))
+		assert.Equal(t, []string{Finalizer}, subject.Finalizers)
+		condition := apimeta.FindStatusCondition(subject.Status.Conditions, deletionProtectedConditionType)
+		require.NotNil(t, condition, "the condition is kept by the reconcile that follows the blocked finalization")
+		assert.Equal(t, "DeletionBlocked", condition.Reason)
+	})
+}
@@ -0,0 +1,76 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"strings"
+
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// observeDomainCoverage checks that every domain in spec.domains is covered by a DNS name of the certificate, so that
+// a domain the certificate can't serve is reported up front rather than as TLS activations that never succeed
+func (l *Logic) observeDomainCoverage(ctx *Context) {
+	if len(ctx.Subject.Spec.Domains) == 0 {
+		return
+	}
+
+	dnsNames, err := getSubjectCertificateDNSNames(ctx)
+	if err != nil {
+		ctx.Log.Error(err, "failed to check domain coverage")
+		l.ObservedState.DomainCoverageError = err.Error()
+		return
+	}
+
+	l.ObservedState.DomainCoverageChecked = true
+	l.ObservedState.UncoveredDomains = uncoveredDomains(ctx.Subject.Spec.Domains, dnsNames)
+}
+
+// uncoveredDomains returns the domains that none of the certificate's DNS names cover, wildcards included
+func uncoveredDomains(domains, dnsNames []string) []string {
+	names := make([]string, len(dnsNames))
+	for i, name := range dnsNames {
+		names[i] = strings.ToLower(name)
+	}
+
+	var uncovered []string
+	for _, domain := range domains {
+		if !isDomainServed(strings.ToLower(domain), names) {
+			uncovered = append(uncovered, domain)
+		}
+	}
+	return uncovered
+}
+
+// observeDomainsCoveredCondition generates the condition for domains in spec.domains that the certificate doesn't
+// cover. It is omitted for subjects without spec.domains.
+func (l *Logic) observeDomainsCoveredCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || len(ctx.Subject.Spec.Domains) == 0 {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type: "DomainsCovered",
+	}
+
+	switch {
+	case l.ObservedState.DomainCoverageError != "":
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "CertificateUnreadable"
+		condition.Message = l.ObservedState.DomainCoverageError
+	case !l.ObservedState.DomainCoverageChecked:
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "DomainCoverageNotObserved"
+		condition.Message = "Domain coverage has not been checked yet"
+	case len(l.ObservedState.UncoveredDomains) > 0:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "DomainsNotCovered"
+		condition.Message = fmt.Sprintf("Domains %s are not covered by the DNS names of the certificate",
+			strings.Join(l.ObservedState.UncoveredDomains, ", "))
+	default:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "AllDomainsCovered"
+		condition.Message = "All domains are covered by the DNS names of the certificate"
+	}
+
+	return condition, nil
+}
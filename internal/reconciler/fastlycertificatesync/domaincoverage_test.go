@@ -0,0 +1,156 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUncoveredDomains(t *testing.T) {
+	tests := []struct {
+		name     string
+		domains  []string
+		dnsNames []string
+		expected []string
+	}{
+		{
+			name:     "exact names",
+			domains:  []string{"example.com", "WWW.example.com"},
+			dnsNames: []string{"www.example.com", "Example.com"},
+		},
+		{
+			name:     "wildcard covers a single label",
+			domains:  []string{"www.example.com", "api.example.com", "a.b.example.com", "example.com"},
+			dnsNames: []string{"*.example.com"},
+			expected: []string{"a.b.example.com", "example.com"},
+		},
+		{
+			name:     "requested wildcard",
+			domains:  []string{"*.example.com", "*.example.org"},
+			dnsNames: []string{"*.example.com", "www.example.org"},
+			expected: []string{"*.example.org"},
+		},
+		{
+			name:     "other names",
+			domains:  []string{"example.org"},
+			dnsNames: []string{"example.com"},
+			expected: []string{"example.org"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, uncoveredDomains(tt.domains, tt.dnsNames))
+		})
+	}
+}
+
+func TestLogic_observeDomainsCoveredCondition(t *testing.T) {
+	ctx := createTestContext()
+
+	// Subjects activating all of their domains have nothing to cover
+	condition, err := (&Logic{}).observeDomainsCoveredCondition(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, condition)
+
+	ctx.Subject.Spec.Domains = []string{"www.example.com", "example.org"}
+	tests := []struct {
+		name           string
+		observedState  ObservedState
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "not checked",
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "DomainCoverageNotObserved",
+		},
+		{
+			name:           "certificate unreadable",
+			observedState:  ObservedState{DomainCoverageError: "failed to decode PEM block"},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "CertificateUnreadable",
+		},
+		{
+			name:           "uncovered",
+			observedState:  ObservedState{DomainCoverageChecked: true, UncoveredDomains: []string{"example.org"}},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "DomainsNotCovered",
+		},
+		{
+			name:           "covered",
+			observedState:  ObservedState{DomainCoverageChecked: true},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AllDomainsCovered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, err := (&Logic{ObservedState: tt.observedState}).observeDomainsCoveredCondition(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, condition)
+			assert.Equal(t, "DomainsCovered", condition.Type)
+			assert.Equal(t, tt.expectedStatus, condition.Status)
+			assert.Equal(t, tt.expectedReason, condition.Reason)
+		})
+	}
+}
+
+func TestActivatedTLSDomains(t *testing.T) {
+	cert := &fastly.CustomTLSCertificate{
+		ID:      "cert1",
+		Domains: []*fastly.TLSDomain{{ID: "example.com"}, {ID: "www.example.com"}, {ID: "api.example.com"}},
+	}
+
+	ctx := createTestContext()
+	assert.Equal(t, cert.Domains, activatedTLSDomains(ctx, cert))
+
+	ctx.Subject.Spec.Domains = []string{"WWW.example.com", "example.com"}
+	assert.Equal(t, []*fastly.TLSDomain{{ID: "example.com"}, {ID: "www.example.com"}}, activatedTLSDomains(ctx, cert))
+}
+
+func TestActivatedTLSDomains_Wildcard(t *testing.T) {
+	cert := &fastly.CustomTLSCertificate{
+		ID:      "cert1",
+		Domains: []*fastly.TLSDomain{{ID: "example.com"}, {ID: "*.example.com"}, {ID: "www.example.com"}},
+	}
+
+	tests := []struct {
+		name     string
+		domains  []string
+		expected []*fastly.TLSDomain
+	}{
+		{
+			name:     "subdomains map onto the wildcard",
+			domains:  []string{"api.example.com", "cdn.example.com"},
+			expected: []*fastly.TLSDomain{{ID: "*.example.com"}},
+		},
+		{
+			name:     "explicit names are preferred over the wildcard",
+			domains:  []string{"www.example.com"},
+			expected: []*fastly.TLSDomain{{ID: "www.example.com"}},
+		},
+		{
+			name:     "wildcards only cover a single label",
+			domains:  []string{"a.b.example.com", "example.com"},
+			expected: []*fastly.TLSDomain{{ID: "example.com"}},
+		},
+		{
+			name:     "requested wildcard",
+			domains:  []string{"*.example.com", "api.example.com"},
+			expected: []*fastly.TLSDomain{{ID: "*.example.com"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Subject.Spec.Domains = tt.domains
+			assert.Equal(t, tt.expected, activatedTLSDomains(ctx, cert))
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package fastlycertificatesync
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+)
+
+// domainVerificationCacheTTL is how long the verification status of a domain is reused before it is looked up again
+const domainVerificationCacheTTL = 10 * time.Minute
+
+// fastlyManagedDomain is a domain of Fastly's Domain Management API. go-fastly's domains/v1 package doesn't decode
+// whether the domain is verified, so it is requested directly.
+type fastlyManagedDomain struct {
+	FQDN     string `json:"fqdn"`
+	Verified bool   `json:"verified"`
+}
+
+// domainVerificationCache remembers whether the domains of each Fastly account are verified.
+//
+// Many FastlyCertificateSyncs share DNS names, e.g. a wildcard and its apex, and looking each of them up on every
+// reconcile would spend the account's API rate limit on a status that rarely changes.
+type domainVerificationCache struct {
+	mu      sync.Mutex
+	entries map[string]domainVerification
+	now     func() time.Time
+}
+
+type domainVerification struct {
+	verified  bool
+	checkedAt time.Time
+}
+
+func (c *domainVerificationCache) key(account, name string) string {
+	return account + "/" + strings.ToLower(name)
+}
+
+func (c *domainVerificationCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Get returns whether the domain of the given account is verified, if it was looked up within the ttl
+func (c *domainVerificationCache) Get(account, name string, ttl time.Duration) (verified, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[c.key(account, name)]
+	if !ok || c.clock().Sub(entry.checkedAt) >= ttl {
+		return false, false
+	}
+	return entry.verified, true
+}
+
+// Add records whether the domain of the given account is verified
+func (c *domainVerificationCache) Add(account, name string, verified bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]domainVerification{}
+	}
+	c.entries[c.key(account, name)] = domainVerification{verified: verified, checkedAt: c.clock()}
+}
+
+// observeDomainVerification checks that the DNS names of the certificate are verified in Fastly, as some accounts
+// only activate certificates on verified domains. It only matters while TLS activations are missing, so it is skipped
+// otherwise. Failing to check is reported in status rather than failing the sync, as the TLS activations report
+// whether they could be created.
+func (l *Logic) observeDomainVerification(ctx *Context) {
+	if !ctx.Config.DomainVerificationCheck {
+		return
+	}
+	if len(l.ObservedState.MissingTLSActivationData) == 0 {
+		l.ObservedState.DomainVerificationSkipped = true
+		return
+	}
+
+	dnsNames, err := getSubjectCertificateDNSNames(ctx)
+	if err != nil {
+		l.ObservedState.DomainVerificationError = err.Error()
+		return
+	}
+
+	var unverified []string
+	for _, name := range dnsNames {
+		verified, err := l.getFastlyDomainVerified(ctx, name)
+		if err != nil {
+			ctx.Log.Error(err, "failed to check domain verification", "domain", name)
+			l.ObservedState.DomainVerificationError = err.Error()
+			return
+		}
+		if !verified {
+			unverified = append(unverified, name)
+		}
+	}
+	slices.Sort(unverified)
+
+	l.ObservedState.DomainVerificationChecked = true
+	l.ObservedState.UnverifiedDomains = unverified
+}
+
+// getFastlyDomainVerified reports whether the DNS name is a verified domain of the account, from the cache when it
+// was looked up recently. Names that aren't domains of the account at all are not verified either.
+func (l *Logic) getFastlyDomainVerified(ctx *Context, name string) (bool, error) {
+	if verified, ok := l.domainVerifications.Get(l.fastlyAccount(), name, domainVerificationCacheTTL); ok {
+		return verified, nil
+	}
+
+	verified, err := l.lookupFastlyDomainVerified(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	l.domainVerifications.Add(l.fastlyAccount(), name, verified)
+	return verified, nil
+}
+
+// lookupFastlyDomainVerified looks the DNS name up in the Domain Management API. The fqdn filter also matches
+// partially, so every page of matches is searched for the exact name.
+func (l *Logic) lookupFastlyDomainVerified(ctx *Context, name string) (bool, error) {
+	cursor := ""
+	for {
+		ro := fastly.CreateRequestOptions()
+		ro.Params["fqdn"] = name
+		ro.Params["limit"] = strconv.Itoa(defaultFastlyPageSize)
+		if cursor != "" {
+			ro.Params["cursor"] = cursor
+		}
+
+		page, err := l.getFastlyManagedDomains(ctx, name, ro)
+		if err != nil {
+			return false, err
+		}
+
+		for _, domain := range page.Data {
+			if strings.EqualFold(domain.FQDN, name) {
+				return domain.Verified, nil
+			}
+		}
+
+		if page.Meta.NextCursor == "" || page.Meta.NextCursor == cursor {
+			return false, nil
+		}
+		cursor = page.Meta.NextCursor
+	}
+}
+
+// fastlyManagedDomainsPage is a page of the Domain Management API's domain listing
+type fastlyManagedDomainsPage struct {
+	Data []fastlyManagedDomain `json:"data"`
+	Meta struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"meta"`
+}
+
+func (l *Logic) getFastlyManagedDomains(ctx *Context, name string, ro fastly.RequestOptions) (*fastlyManagedDomainsPage, error) {
+	resp, err := l.fastlyClient().Get(ctx, "/domains/v1", ro)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up Fastly domain %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	page := &fastlyManagedDomainsPage{}
+	if err := json.NewDecoder(resp.Body).Decode(page); err != nil {
+		return nil, fmt.Errorf("failed to decode Fastly domain %s: %w", name, err)
+	}
+	return page, nil
+}
+
+// countUnverifiedTLSActivations counts the missing TLS activations of domains that aren't verified
+func (l *Logic) countUnverifiedTLSActivations() int {
+	count := 0
+	for _, data := range l.ObservedState.MissingTLSActivationData {
+		if data.Domain == nil {
+			continue
+		}
+		if slices.ContainsFunc(l.ObservedState.UnverifiedDomains, func(name string) bool {
+			return strings.EqualFold(name, data.Domain.ID)
+		}) {
+			count++
+		}
+	}
+	return count
+}
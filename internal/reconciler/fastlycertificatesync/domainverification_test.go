@@ -0,0 +1,239 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// managedDomains mocks the Domain Management API of an account with the given domains, and whether they are verified.
+// Matches are returned one per page, and lookups are counted.
+func managedDomains(domains map[string]bool, lookups *int) *MockFastlyClient {
+	return &MockFastlyClient{
+		GetFunc: func(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+			if p != "/domains/v1" {
+				return nil, fmt.Errorf("unexpected request to %s", p)
+			}
+			if lookups != nil {
+				*lookups++
+			}
+			var matches []string
+			for fqdn := range domains {
+				// the fqdn filter matches partially
+				if strings.Contains(fqdn, ro.Params["fqdn"]) {
+					matches = append(matches, fqdn)
+				}
+			}
+			slices.Sort(matches)
+
+			page, _ := strconv.Atoi(ro.Params["cursor"])
+			var data, nextCursor string
+			if page < len(matches) {
+				data = fmt.Sprintf(`{"fqdn":%q,"verified":%t}`, matches[page], domains[matches[page]])
+			}
+			if page+1 < len(matches) {
+				nextCursor = strconv.Itoa(page + 1)
+			}
+			body := fmt.Sprintf(`{"data":[%s],"meta":{"next_cursor":%q}}`, data, nextCursor)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+}
+
+// missingTLSActivations is the observed state of a subject whose certificate still needs to be activated
+func missingTLSActivations() ObservedState {
+	return ObservedState{MissingTLSActivationData: []TLSActivationData{{Domain: &fastly.TLSDomain{ID: "example.com"}}}}
+}
+
+func TestLogic_observeDomainVerification(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		logic := &Logic{FastlyClient: &MockFastlyClient{}}
+
+		logic.observeDomainVerification(ctx)
+		assert.False(t, logic.ObservedState.DomainVerificationChecked)
+	})
+
+	t.Run("reports_unverified_domains", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "www.example.com", "example.com", "api.example.com")
+		ctx.Config.DomainVerificationCheck = true
+		logic := &Logic{
+			FastlyClient: managedDomains(map[string]bool{
+				"www.example.com": true,
+				"example.com":     false,
+			}, nil),
+			ObservedState: missingTLSActivations(),
+		}
+
+		logic.observeDomainVerification(ctx)
+		assert.True(t, logic.ObservedState.DomainVerificationChecked)
+		assert.Equal(t, []string{"api.example.com", "example.com"}, logic.ObservedState.UnverifiedDomains,
+			"domains that are only partial matches, or not domains of the account, aren't verified")
+	})
+
+	t.Run("searches_every_page", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		ctx.Config.DomainVerificationCheck = true
+		// The partial matches come first, so the exact one is on the last page
+		logic := &Logic{
+			FastlyClient: managedDomains(map[string]bool{
+				"api.example.com": false,
+				"cdn.example.com": false,
+				"example.com":     true,
+			}, nil),
+			ObservedState: missingTLSActivations(),
+		}
+
+		logic.observeDomainVerification(ctx)
+		assert.True(t, logic.ObservedState.DomainVerificationChecked)
+		assert.Empty(t, logic.ObservedState.UnverifiedDomains)
+	})
+
+	t.Run("caches_lookups_per_account", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		ctx.Config.DomainVerificationCheck = true
+		lookups := 0
+		logic := &Logic{FastlyClient: managedDomains(map[string]bool{"example.com": true}, &lookups)}
+
+		for range 2 {
+			logic.ObservedState = missingTLSActivations()
+			logic.observeDomainVerification(ctx)
+			assert.True(t, logic.ObservedState.DomainVerificationChecked)
+		}
+		assert.Equal(t, 1, lookups)
+	})
+
+	t.Run("skipped_without_missing_tls_activations", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		ctx.Config.DomainVerificationCheck = true
+		lookups := 0
+		logic := &Logic{FastlyClient: managedDomains(map[string]bool{"example.com": false}, &lookups)}
+
+		logic.observeDomainVerification(ctx)
+		assert.True(t, logic.ObservedState.DomainVerificationSkipped)
+		assert.False(t, logic.ObservedState.DomainVerificationChecked)
+		assert.Zero(t, lookups)
+	})
+
+	t.Run("lookup_failure_is_not_fatal", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		ctx.Config.DomainVerificationCheck = true
+		logic := &Logic{
+			FastlyClient: &MockFastlyClient{
+				GetFunc: func(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+					return nil, errors.New("forbidden")
+				},
+			},
+			ObservedState: missingTLSActivations(),
+		}
+
+		logic.observeDomainVerification(ctx)
+		assert.False(t, logic.ObservedState.DomainVerificationChecked)
+		assert.Equal(t, "failed to look up Fastly domain example.com: forbidden", logic.ObservedState.DomainVerificationError)
+	})
+}
+
+func TestLogic_observeDomainVerifiedCondition(t *testing.T) {
+	tests := []struct {
+		name           string
+		disabled       bool
+		observedState  ObservedState
+		previous       *metav1.Condition
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:     "omitted_when_disabled",
+			disabled: true,
+		},
+		{
+			name:           "verified",
+			observedState:  ObservedState{DomainVerificationChecked: true},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "DomainsVerified",
+		},
+		{
+			name:           "unverified",
+			observedState:  ObservedState{DomainVerificationChecked: true, UnverifiedDomains: []string{"example.com"}},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "DomainsUnverified",
+		},
+		{
+			name:           "skipped_without_missing_tls_activations",
+			observedState:  ObservedState{DomainVerificationSkipped: true},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "TLSActivationsComplete",
+		},
+		{
+			name:           "lookup_failed",
+			observedState:  ObservedState{DomainVerificationError: "forbidden"},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "DomainLookupFailed",
+		},
+		{
+			name:           "carried_over_when_not_observed",
+			previous:       &metav1.Condition{Type: "DomainVerified", Status: metav1.ConditionFalse, Reason: "DomainsUnverified"},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "DomainsUnverified",
+		},
+		{
+			name:           "not_observed_yet",
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "DomainsNotObserved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Config.DomainVerificationCheck = !tt.disabled
+			if tt.previous != nil {
+				ctx.Subject.Status.Conditions = []metav1.Condition{*tt.previous}
+			}
+			logic := &Logic{ObservedState: tt.observedState}
+
+			condition, err := logic.observeDomainVerifiedCondition(ctx)
+			require.NoError(t, err)
+			if tt.disabled {
+				assert.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			assert.Equal(t, tt.expectedStatus, condition.Status)
+			assert.Equal(t, tt.expectedReason, condition.Reason)
+		})
+	}
+}
+
+func TestLogic_observeTLSActivationReadyCondition_DomainsUnverified(t *testing.T) {
+	ctx := createTestContext()
+	logic := &Logic{ObservedState: ObservedState{
+		MissingTLSActivationData: []TLSActivationData{
+			{Domain: &fastly.TLSDomain{ID: "example.com"}},
+			{Domain: &fastly.TLSDomain{ID: "www.example.com"}},
+		},
+		UnverifiedDomains: []string{"Example.com"},
+	}}
+
+	condition, err := logic.observeTLSActivationReadyCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "DomainsUnverified", condition.Reason)
+	assert.Equal(t, "Missing 2 TLS activations that need to be created, 1 of which are for domains that aren't verified in Fastly, see the DomainVerified condition", condition.Message)
+
+	logic.ObservedState.UnverifiedDomains = nil
+	condition, err = logic.observeTLSActivationReadyCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "TLSActivationsMissing", condition.Reason)
+}
@@ -0,0 +1,160 @@
+package fastlycertificatesync
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// driftCheckFullObservationMaxAge bounds how long quick drift checks may stand in for a full observation of Fastly
+const driftCheckFullObservationMaxAge = time.Hour
+
+// syncedSubjectCache remembers the subjects last fully observed to be in sync with Fastly.
+//
+// Quick drift checks only look the synced certificate up by ID, which is far cheaper than listing every private key,
+// certificate and activation in the account. This catches certificates deleted from Fastly out of band, while a full
+// observation still happens whenever anything changed, or the previous one grew old.
+type syncedSubjectCache struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName]syncedSubject
+	now     func() time.Time
+}
+
+// syncedSubject captures what a subject was fully observed to be in sync with
+type syncedSubject struct {
+	account               string
+	generation            int64
+	secretResourceVersion string
+	certificateID         string
+	serialNumber          string
+	observedAt            time.Time
+}
+
+func (c *syncedSubjectCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Add records that the subject was fully observed to be in sync
+func (c *syncedSubjectCache) Add(subject types.NamespacedName, synced syncedSubject) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[types.NamespacedName]syncedSubject{}
+	}
+	synced.observedAt = c.clock()
+	c.entries[subject] = synced
+}
+
+// Get returns what the subject was last fully observed to be in sync with, within the max age
+func (c *syncedSubjectCache) Get(subject types.NamespacedName, maxAge time.Duration) (syncedSubject, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	synced, ok := c.entries[subject]
+	if !ok || c.clock().Sub(synced.observedAt) >= maxAge {
+		return syncedSubject{}, false
+	}
+	return synced, true
+}
+
+// Forget discards what the subject was last observed to be in sync with
+func (c *syncedSubjectCache) Forget(subject types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, subject)
+}
+
+// quickDriftCheck stands in for a full observation of Fastly when nothing changed since the subject was last fully
+// observed to be in sync, and its certificate still exists in Fastly with the same serial number. It reports whether
+// the observed state was filled in; when it wasn't, a full observation is required.
+func (l *Logic) quickDriftCheck(ctx *Context) bool {
+	if !ctx.Config.QuickDriftCheck {
+		return false
+	}
+	// Deleting a previous certificate whose grace period is over takes a full observation
+	if isPreviousFastlyCertificateDue(ctx) {
+		return false
+	}
+
+	synced, ok := l.syncedSubjects.Get(ctx.NamespacedName, driftCheckFullObservationMaxAge)
+	if !ok {
+		return false
+	}
+
+	_, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return false
+	}
+	if synced.account != l.fastlyAccount() ||
+		synced.generation != ctx.Subject.Generation ||
+		synced.secretResourceVersion != secret.ResourceVersion {
+		return false
+	}
+
+	fastlyCertificate, err := l.fastlyClient().GetCustomTLSCertificate(ctx, &fastly.GetCustomTLSCertificateInput{ID: synced.certificateID})
+	if err != nil {
+		var httpErr *fastly.HTTPError
+		if errors.As(err, &httpErr) && httpErr.IsNotFound() {
+			ctx.Log.Info("Fastly certificate disappeared since the last sync", "certificate_id", synced.certificateID)
+			l.syncedSubjects.Forget(ctx.NamespacedName)
+		} else {
+			ctx.Log.Error(err, "quick drift check failed, falling back to a full observation", "certificate_id", synced.certificateID)
+		}
+		return false
+	}
+	if fastlyCertificate.SerialNumber != synced.serialNumber {
+		ctx.Log.Info("Fastly certificate changed since the last sync", "certificate_id", synced.certificateID)
+		l.syncedSubjects.Forget(ctx.NamespacedName)
+		return false
+	}
+
+	ctx.Log.Info("Fastly certificate is unchanged since the last sync, skipping full observation", "certificate_id", synced.certificateID)
+	l.ObservedState.PrivateKeyUploaded = true
+	l.ObservedState.CertificateStatus = CertificateStatusSynced
+	l.ObservedState.FastlyCertificate = fastlyCertificate
+	l.ObservedState.QuickDriftChecked = true
+
+	return true
+}
+
+// scheduleDriftCheck remembers that the subject was observed to be fully in sync, and requeues it to spot check
+// Fastly for changes made out of band, rather than waiting for the next resync
+func (l *Logic) scheduleDriftCheck(ctx *Context) error {
+	// Only a full observation may extend how long quick drift checks stand in for one. Subjects syncing to several
+	// accounts are always fully observed.
+	if !l.ObservedState.QuickDriftChecked && l.ObservedState.FastlyCertificate != nil && len(ctx.Subject.Spec.Accounts) == 0 {
+		_, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+		if err != nil {
+			return err
+		}
+		l.syncedSubjects.Add(ctx.NamespacedName, syncedSubject{
+			account:               l.fastlyAccount(),
+			generation:            ctx.Subject.Generation,
+			secretResourceVersion: secret.ResourceVersion,
+			certificateID:         l.ObservedState.FastlyCertificate.ID,
+			serialNumber:          l.ObservedState.FastlyCertificate.SerialNumber,
+		})
+	}
+
+	if interval := driftCheckInterval(ctx); interval > 0 {
+		ctx.SetRequeue(interval)
+	}
+	return nil
+}
+
+// driftCheckInterval is how soon a subject in sync is checked for drift again, spec.resyncInterval taking precedence
+// over the operator's interval
+func driftCheckInterval(ctx *Context) time.Duration {
+	if interval := ctx.Subject.Spec.ResyncInterval; interval != nil {
+		return interval.Duration
+	}
+	return ctx.Config.DriftCheckInterval
+}
@@ -0,0 +1,204 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncedSubjectCache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := &syncedSubjectCache{now: func() time.Time { return now }}
+	subject := types.NamespacedName{Namespace: "ns", Name: "a"}
+
+	_, ok := cache.Get(subject, time.Hour)
+	assert.False(t, ok)
+
+	cache.Add(subject, syncedSubject{certificateID: "cert1"})
+	synced, ok := cache.Get(subject, time.Hour)
+	require.True(t, ok)
+	assert.Equal(t, "cert1", synced.certificateID)
+
+	now = now.Add(time.Hour)
+	_, ok = cache.Get(subject, time.Hour)
+	assert.False(t, ok, "entries expire after the max age")
+
+	cache.Add(subject, syncedSubject{certificateID: "cert1"})
+	cache.Forget(subject)
+	_, ok = cache.Get(subject, time.Hour)
+	assert.False(t, ok)
+}
+
+func TestLogic_quickDriftCheck(t *testing.T) {
+	tests := []struct {
+		name               string
+		disabled           bool
+		synced             *syncedSubject
+		fastlyCertificate  *fastly.CustomTLSCertificate
+		fastlyError        error
+		expectedChecked    bool
+		expectedForgotten  bool
+		expectedFastlyCall bool
+	}{
+		{
+			name:     "disabled",
+			disabled: true,
+			synced:   &syncedSubject{account: "default", generation: 1, secretResourceVersion: "999", certificateID: "cert1", serialNumber: "123"},
+		},
+		{
+			name: "never_synced",
+		},
+		{
+			name:   "generation_changed",
+			synced: &syncedSubject{account: "default", generation: 0, secretResourceVersion: "999", certificateID: "cert1", serialNumber: "123"},
+		},
+		{
+			name:   "secret_changed",
+			synced: &syncedSubject{account: "default", generation: 1, secretResourceVersion: "1", certificateID: "cert1", serialNumber: "123"},
+		},
+		{
+			name:               "certificate_unchanged",
+			synced:             &syncedSubject{account: "default", generation: 1, secretResourceVersion: "999", certificateID: "cert1", serialNumber: "123"},
+			fastlyCertificate:  &fastly.CustomTLSCertificate{ID: "cert1", SerialNumber: "123"},
+			expectedChecked:    true,
+			expectedFastlyCall: true,
+		},
+		{
+			name:               "certificate_replaced",
+			synced:             &syncedSubject{account: "default", generation: 1, secretResourceVersion: "999", certificateID: "cert1", serialNumber: "123"},
+			fastlyCertificate:  &fastly.CustomTLSCertificate{ID: "cert1", SerialNumber: "456"},
+			expectedForgotten:  true,
+			expectedFastlyCall: true,
+		},
+		{
+			name:               "certificate_deleted",
+			synced:             &syncedSubject{account: "default", generation: 1, secretResourceVersion: "999", certificateID: "cert1", serialNumber: "123"},
+			fastlyError:        &fastly.HTTPError{StatusCode: http.StatusNotFound},
+			expectedForgotten:  true,
+			expectedFastlyCall: true,
+		},
+		{
+			name:               "fastly_unavailable",
+			synced:             &syncedSubject{account: "default", generation: 1, secretResourceVersion: "999", certificateID: "cert1", serialNumber: "123"},
+			fastlyError:        &fastly.HTTPError{StatusCode: http.StatusServiceUnavailable},
+			expectedFastlyCall: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = cmv1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&cmv1.Certificate{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+					Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace", ResourceVersion: "999"},
+				},
+			).Build()
+
+			ctx := createTestContext()
+			ctx.Config.QuickDriftCheck = !tt.disabled
+			ctx.Subject.Generation = 1
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			fastlyCalled := false
+			logic := &Logic{
+				FastlyClient: &MockFastlyClient{
+					GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+						fastlyCalled = true
+						assert.Equal(t, "cert1", input.ID)
+						return tt.fastlyCertificate, tt.fastlyError
+					},
+				},
+			}
+			if tt.synced != nil {
+				logic.syncedSubjects.Add(ctx.NamespacedName, *tt.synced)
+			}
+
+			assert.Equal(t, tt.expectedChecked, logic.quickDriftCheck(ctx))
+			assert.Equal(t, tt.expectedFastlyCall, fastlyCalled)
+			assert.Equal(t, tt.expectedChecked, logic.ObservedState.QuickDriftChecked)
+
+			_, stillSynced := logic.syncedSubjects.Get(ctx.NamespacedName, time.Hour)
+			assert.Equal(t, tt.synced != nil && !tt.expectedForgotten, stillSynced)
+
+			if tt.expectedChecked {
+				assert.True(t, logic.ObservedState.PrivateKeyUploaded)
+				assert.Equal(t, CertificateStatusSynced, logic.ObservedState.CertificateStatus)
+				assert.Equal(t, tt.fastlyCertificate, logic.ObservedState.FastlyCertificate)
+			}
+		})
+	}
+}
+
+func TestLogic_scheduleDriftCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+			Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace", ResourceVersion: "999"},
+		},
+	).Build()
+
+	ctx := createTestContext()
+	ctx.Config.DriftCheckInterval = 15 * time.Minute
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	logic := &Logic{ObservedState: ObservedState{
+		FastlyCertificate: &fastly.CustomTLSCertificate{ID: "cert1", SerialNumber: "123"},
+	}}
+	require.NoError(t, logic.scheduleDriftCheck(ctx))
+
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Equal(t, 15*time.Minute, *ctx.RequeueAfter)
+
+	// The subject's own interval takes precedence over the operator's
+	ctx.RequeueAfter = nil
+	ctx.Subject.Spec.ResyncInterval = &metav1.Duration{Duration: 4 * time.Hour}
+	require.NoError(t, logic.scheduleDriftCheck(ctx))
+	require.NotNil(t, ctx.RequeueAfter)
+	assert.Equal(t, 4*time.Hour, *ctx.RequeueAfter)
+	ctx.Subject.Spec.ResyncInterval = nil
+
+	synced, ok := logic.syncedSubjects.Get(ctx.NamespacedName, time.Hour)
+	require.True(t, ok)
+	assert.Equal(t, "cert1", synced.certificateID)
+	assert.Equal(t, "123", synced.serialNumber)
+	assert.Equal(t, "999", synced.secretResourceVersion)
+
+	// A quick drift check doesn't extend how long quick drift checks stand in for a full observation
+	logic.syncedSubjects.Forget(ctx.NamespacedName)
+	logic.ObservedState.QuickDriftChecked = true
+	require.NoError(t, logic.scheduleDriftCheck(ctx))
+	_, ok = logic.syncedSubjects.Get(ctx.NamespacedName, time.Hour)
+	assert.False(t, ok)
+}
@@ -0,0 +1,602 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errOperatorKilled is returned by fakeFastlyAccount to the operator that was killed partway through a sync
+var errOperatorKilled = errors.New("operator was killed")
+
+// fakeFastlyAccount keeps the private keys, certificates and TLS activations of a Fastly account in memory, so that
+// the state left behind by an interrupted sync outlives the operator that was syncing
+type fakeFastlyAccount struct {
+	mu           sync.Mutex
+	nextID       int
+	privateKeys  []*fastly.PrivateKey
+	certificates []*fastly.CustomTLSCertificate
+	activations  []*fastly.TLSActivation
+
+	// kill names the write after which the operator is killed, once the write took effect in Fastly but before its
+	// response reached the operator. Every call made by the killed operator fails until restart.
+	kill   string
+	killed bool
+
+	// requests counts the calls made to the account
+	requests int
+}
+
+// call checks that the operator is still alive, applies the write, and kills the operator when it is the one to
+func (f *fakeFastlyAccount) call(operation string, write func()) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+	if f.killed {
+		return errOperatorKilled
+	}
+	if write != nil {
+		write()
+	}
+	if operation == f.kill {
+		f.kill = ""
+		f.killed = true
+		return errOperatorKilled
+	}
+	return nil
+}
+
+// restart lets a fresh operator talk to the account again
+func (f *fakeFastlyAccount) restart() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.killed = false
+}
+
+func (f *fakeFastlyAccount) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s%d", prefix, f.nextID)
+}
+
+// certificateIndex returns the index of the certificate of the given ID, or -1
+func (f *fakeFastlyAccount) certificateIndex(id string) int {
+	return slices.IndexFunc(f.certificates, func(cert *fastly.CustomTLSCertificate) bool { return cert.ID == id })
+}
+
+// seed fills the account with the private key, certificate and TLS activation of n certificates synced by others
+func (f *fakeFastlyAccount) seed(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range n {
+		key := &fastly.PrivateKey{ID: f.id("key"), Name: fmt.Sprintf("other-%d", i), PublicKeySHA1: fmt.Sprintf("%040x", i)}
+		cert := &fastly.CustomTLSCertificate{
+			ID:           f.id("cert"),
+			Name:         fmt.Sprintf("other-%d", i),
+			SerialNumber: fmt.Sprintf("%d", 1000+i),
+			Domains:      []*fastly.TLSDomain{{ID: fmt.Sprintf("other-%d.example.com", i)}},
+		}
+		f.privateKeys = append(f.privateKeys, key)
+		f.certificates = append(f.certificates, cert)
+		f.activations = append(f.activations, &fastly.TLSActivation{
+			ID:            f.id("act"),
+			Certificate:   &fastly.CustomTLSCertificate{ID: cert.ID},
+			Configuration: &fastly.TLSConfiguration{ID: "config1"},
+			Domain:        cert.Domains[0],
+		})
+	}
+}
+
+// fastlyPage returns the items on the given page, as Fastly paginates its listings
+func fastlyPage[T any](page, size int, items []*T) []*T {
+	if size <= 0 {
+		size = defaultFastlyPageSize
+	}
+	start := min((page-1)*size, len(items))
+	return append([]*T{}, items[start:min(start+size, len(items))]...)
+}
+
+func (f *fakeFastlyAccount) client(t testing.TB) *MockFastlyClient {
+	return &MockFastlyClient{
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			var res []*fastly.PrivateKey
+			err := f.call("ListPrivateKeys", func() { res = fastlyPage(input.PageNumber, input.PageSize, f.privateKeys) })
+			return res, err
+		},
+		CreatePrivateKeyFunc: func(ctx context.Context, input *fastly.CreatePrivateKeyInput) (*fastly.PrivateKey, error) {
+			publicKeySHA1, err := getPublicKeySHA1FromPEM([]byte(input.Key))
+			require.NoError(t, err)
+
+			var key *fastly.PrivateKey
+			err = f.call("CreatePrivateKey", func() {
+				key = &fastly.PrivateKey{ID: f.id("key"), Name: input.Name, PublicKeySHA1: publicKeySHA1}
+				f.privateKeys = append(f.privateKeys, key)
+			})
+			return key, err
+		},
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			var res []*fastly.CustomTLSCertificate
+			err := f.call("ListCustomTLSCertificates", func() { res = fastlyPage(input.PageNumber, input.PageSize, f.certificates) })
+			return res, err
+		},
+		CreateCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			block, _ := pem.Decode([]byte(input.CertBlob))
+			require.NotNil(t, block)
+			parsed, err := x509.ParseCertificate(block.Bytes)
+			require.NoError(t, err)
+
+			var cert *fastly.CustomTLSCertificate
+			err = f.call("CreateCustomTLSCertificate", func() {
+				cert = &fastly.CustomTLSCertificate{ID: f.id("cert"), Name: input.Name, SerialNumber: parsed.SerialNumber.String()}
+				for _, name := range parsed.DNSNames {
+					cert.Domains = append(cert.Domains, &fastly.TLSDomain{ID: name})
+				}
+				f.certificates = append(f.certificates, cert)
+			})
+			return cert, err
+		},
+		GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			var cert *fastly.CustomTLSCertificate
+			err := f.call("GetCustomTLSCertificate", func() {
+				if i := f.certificateIndex(input.ID); i >= 0 {
+					cert = f.certificates[i]
+				}
+			})
+			if err == nil && cert == nil {
+				return nil, &fastly.HTTPError{StatusCode: http.StatusNotFound}
+			}
+			return cert, err
+		},
+		// Certificates are only ever patched to be renamed, go-fastly can't update the name without the blob
+		PatchFunc: func(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+			var req jsonAPIRequest
+			require.NoError(t, json.NewDecoder(ro.Body).Decode(&req))
+			id, ok := strings.CutPrefix(p, "/tls/certificates/")
+			require.True(t, ok, "unexpected PATCH %s", p)
+
+			found := false
+			err := f.call("RenameCustomTLSCertificate", func() {
+				if i := f.certificateIndex(id); i >= 0 {
+					renamed := *f.certificates[i]
+					renamed.Name = req.Data.Attributes["name"]
+					f.certificates[i] = &renamed
+					found = true
+				}
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return nil, &fastly.HTTPError{StatusCode: http.StatusNotFound}
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		},
+		DeleteCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.DeleteCustomTLSCertificateInput) error {
+			found := false
+			err := f.call("DeleteCustomTLSCertificate", func() {
+				f.certificates = slices.DeleteFunc(f.certificates, func(cert *fastly.CustomTLSCertificate) bool {
+					found = found || cert.ID == input.ID
+					return cert.ID == input.ID
+				})
+			})
+			if err == nil && !found {
+				return &fastly.HTTPError{StatusCode: http.StatusNotFound}
+			}
+			return err
+		},
+		ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+			var res []*fastly.TLSActivation
+			err := f.call("ListTLSActivations", func() {
+				var filtered []*fastly.TLSActivation
+				for _, activation := range f.activations {
+					if input.FilterTLSCertificateID == "" || activation.Certificate.ID == input.FilterTLSCertificateID {
+						filtered = append(filtered, activation)
+					}
+				}
+				res = fastlyPage(input.PageNumber, input.PageSize, filtered)
+			})
+			return res, err
+		},
+		CreateTLSActivationFunc: func(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error) {
+			var activation *fastly.TLSActivation
+			err := f.call("CreateTLSActivation", func() {
+				activation = &fastly.TLSActivation{ID: f.id("act"), Certificate: input.Certificate, Configuration: input.Configuration, Domain: input.Domain}
+				f.activations = append(f.activations, activation)
+			})
+			return activation, err
+		},
+		UpdateTLSActivationFunc: func(ctx context.Context, input *fastly.UpdateTLSActivationInput) (*fastly.TLSActivation, error) {
+			var activation *fastly.TLSActivation
+			err := f.call("UpdateTLSActivation", func() {
+				i := slices.IndexFunc(f.activations, func(activation *fastly.TLSActivation) bool { return activation.ID == input.ID })
+				if i < 0 {
+					return
+				}
+				updated := *f.activations[i]
+				updated.Certificate = input.Certificate
+				f.activations[i] = &updated
+				activation = &updated
+			})
+			if err == nil && activation == nil {
+				return nil, &fastly.HTTPError{StatusCode: http.StatusNotFound}
+			}
+			return activation, err
+		},
+	}
+}
+
+// jsonAPIResource is a resource as Fastly's TLS endpoints encode it in JSON:API
+type jsonAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id,omitempty"`
+	Attributes    map[string]any                 `json:"attributes,omitempty"`
+	Relationships map[string]jsonAPIRelationship `json:"relationships,omitempty"`
+}
+
+// jsonAPIRelationship holds a single resource identifier, or a list of them for to-many relationships
+type jsonAPIRelationship struct {
+	Data any `json:"data"`
+}
+
+type jsonAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// jsonAPIRequest is the body of a request creating a resource
+type jsonAPIRequest struct {
+	Data struct {
+		Attributes    map[string]string `json:"attributes"`
+		Relationships map[string]struct {
+			Data jsonAPIIdentifier `json:"data"`
+		} `json:"relationships"`
+	} `json:"data"`
+}
+
+func privateKeyResource(key *fastly.PrivateKey) jsonAPIResource {
+	return jsonAPIResource{
+		Type:       "tls_private_key",
+		ID:         key.ID,
+		Attributes: map[string]any{"name": key.Name, "public_key_sha1": key.PublicKeySHA1},
+	}
+}
+
+func certificateResource(cert *fastly.CustomTLSCertificate) jsonAPIResource {
+	domains := []jsonAPIIdentifier{}
+	for _, domain := range cert.Domains {
+		domains = append(domains, jsonAPIIdentifier{Type: "tls_domain", ID: domain.ID})
+	}
+	return jsonAPIResource{
+		Type:          "tls_certificate",
+		ID:            cert.ID,
+		Attributes:    map[string]any{"name": cert.Name, "serial_number": cert.SerialNumber},
+		Relationships: map[string]jsonAPIRelationship{"tls_domains": {Data: domains}},
+	}
+}
+
+func activationResource(activation *fastly.TLSActivation) jsonAPIResource {
+	return jsonAPIResource{
+		Type: "tls_activation",
+		ID:   activation.ID,
+		Relationships: map[string]jsonAPIRelationship{
+			"tls_certificate":   {Data: jsonAPIIdentifier{Type: "tls_certificate", ID: activation.Certificate.ID}},
+			"tls_configuration": {Data: jsonAPIIdentifier{Type: "tls_configuration", ID: activation.Configuration.ID}},
+			"tls_domain":        {Data: jsonAPIIdentifier{Type: "tls_domain", ID: activation.Domain.ID}},
+		},
+	}
+}
+
+func resources[T any](items []*T, resource func(*T) jsonAPIResource) []jsonAPIResource {
+	res := []jsonAPIResource{}
+	for _, item := range items {
+		res = append(res, resource(item))
+	}
+	return res
+}
+
+// server serves the account over HTTP the way Fastly's API does, for the real go-fastly client to talk to. It is
+// backed by the in-memory client, so that both give the same answers.
+func (f *fakeFastlyAccount) server(t testing.TB) *httptest.Server {
+	fastlyClient := f.client(t)
+	mux := http.NewServeMux()
+
+	// Handlers run outside of the test's goroutine, so they assert rather than require
+	respond := func(w http.ResponseWriter, status int, data any, err error) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		if err != nil {
+			var httpErr *fastly.HTTPError
+			if errors.As(err, &httpErr) {
+				status = httpErr.StatusCode
+			} else {
+				status = http.StatusInternalServerError
+			}
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"title": err.Error()}}})
+			return
+		}
+		w.WriteHeader(status)
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]any{"data": data}))
+	}
+	decode := func(r *http.Request) jsonAPIRequest {
+		var req jsonAPIRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		return req
+	}
+	page := func(r *http.Request) (int, int) {
+		number, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page[size]"))
+		return max(number, 1), size
+	}
+
+	mux.HandleFunc("GET /tls/private_keys", func(w http.ResponseWriter, r *http.Request) {
+		number, size := page(r)
+		keys, err := fastlyClient.ListPrivateKeys(r.Context(), &fastly.ListPrivateKeysInput{PageNumber: number, PageSize: size})
+		respond(w, http.StatusOK, resources(keys, privateKeyResource), err)
+	})
+	mux.HandleFunc("POST /tls/private_keys", func(w http.ResponseWriter, r *http.Request) {
+		req := decode(r)
+		key, err := fastlyClient.CreatePrivateKey(r.Context(), &fastly.CreatePrivateKeyInput{
+			Key:  req.Data.Attributes["key"],
+			Name: req.Data.Attributes["name"],
+		})
+		if err != nil {
+			respond(w, http.StatusCreated, nil, err)
+			return
+		}
+		respond(w, http.StatusCreated, privateKeyResource(key), nil)
+	})
+	mux.HandleFunc("GET /tls/certificates", func(w http.ResponseWriter, r *http.Request) {
+		number, size := page(r)
+		certs, err := fastlyClient.ListCustomTLSCertificates(r.Context(), &fastly.ListCustomTLSCertificatesInput{PageNumber: number, PageSize: size})
+		respond(w, http.StatusOK, resources(certs, certificateResource), err)
+	})
+	mux.HandleFunc("POST /tls/certificates", func(w http.ResponseWriter, r *http.Request) {
+		req := decode(r)
+		cert, err := fastlyClient.CreateCustomTLSCertificate(r.Context(), &fastly.CreateCustomTLSCertificateInput{
+			CertBlob: req.Data.Attributes["cert_blob"],
+			Name:     req.Data.Attributes["name"],
+		})
+		if err != nil {
+			respond(w, http.StatusCreated, nil, err)
+			return
+		}
+		respond(w, http.StatusCreated, certificateResource(cert), nil)
+	})
+	mux.HandleFunc("GET /tls/certificates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		cert, err := fastlyClient.GetCustomTLSCertificate(r.Context(), &fastly.GetCustomTLSCertificateInput{ID: r.PathValue("id")})
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		respond(w, http.StatusOK, certificateResource(cert), nil)
+	})
+	mux.HandleFunc("PATCH /tls/certificates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		resp, err := fastlyClient.Patch(r.Context(), r.URL.Path, fastly.RequestOptions{Body: r.Body})
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		assert.NoError(t, resp.Body.Close())
+		f.mu.Lock()
+		renamed := f.certificates[f.certificateIndex(r.PathValue("id"))]
+		f.mu.Unlock()
+		respond(w, http.StatusOK, certificateResource(renamed), nil)
+	})
+	mux.HandleFunc("DELETE /tls/certificates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := fastlyClient.DeleteCustomTLSCertificate(r.Context(), &fastly.DeleteCustomTLSCertificateInput{ID: r.PathValue("id")}); err != nil {
+			respond(w, http.StatusNoContent, nil, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /tls/activations", func(w http.ResponseWriter, r *http.Request) {
+		number, size := page(r)
+		activations, err := fastlyClient.ListTLSActivations(r.Context(), &fastly.ListTLSActivationsInput{
+			FilterTLSCertificateID: r.URL.Query().Get("filter[tls_certificate.id]"),
+			PageNumber:             number,
+			PageSize:               size,
+		})
+		respond(w, http.StatusOK, resources(activations, activationResource), err)
+	})
+	mux.HandleFunc("POST /tls/activations", func(w http.ResponseWriter, r *http.Request) {
+		relationships := decode(r).Data.Relationships
+		activation, err := fastlyClient.CreateTLSActivation(r.Context(), &fastly.CreateTLSActivationInput{
+			Certificate:   &fastly.CustomTLSCertificate{ID: relationships["tls_certificate"].Data.ID},
+			Configuration: &fastly.TLSConfiguration{ID: relationships["tls_configuration"].Data.ID},
+			Domain:        &fastly.TLSDomain{ID: relationships["tls_domain"].Data.ID},
+		})
+		if err != nil {
+			respond(w, http.StatusCreated, nil, err)
+			return
+		}
+		respond(w, http.StatusCreated, activationResource(activation), nil)
+	})
+	mux.HandleFunc("PATCH /tls/activations/{id}", func(w http.ResponseWriter, r *http.Request) {
+		relationships := decode(r).Data.Relationships
+		activation, err := fastlyClient.UpdateTLSActivation(r.Context(), &fastly.UpdateTLSActivationInput{
+			ID:          r.PathValue("id"),
+			Certificate: &fastly.CustomTLSCertificate{ID: relationships["tls_certificate"].Data.ID},
+		})
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		respond(w, http.StatusOK, activationResource(activation), nil)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestFakeFastlyAccount_Contract checks that the real go-fastly client, talking to the account over HTTP, gets the
+// same answers as the in-memory client the tests use, for every endpoint the fake implements. A fake that drifts
+// from what go-fastly sends or decodes fails here rather than passing tests that production would fail.
+func TestFakeFastlyAccount_Contract(t *testing.T) {
+	keyPEM, _ := createTestKeyPEM(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"www.example.com", "api.example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error)
+	}{
+		{
+			name: "list_private_keys",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: 1, PageSize: defaultFastlyPageSize})
+			},
+		},
+		{
+			name: "list_private_keys_last_page",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: 2, PageSize: defaultFastlyPageSize})
+			},
+		},
+		{
+			name: "list_private_keys_past_the_end",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: 3, PageSize: defaultFastlyPageSize})
+			},
+		},
+		{
+			name: "create_private_key",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.CreatePrivateKey(ctx, &fastly.CreatePrivateKeyInput{Key: string(keyPEM), Name: "test-certificate"})
+			},
+		},
+		{
+			name: "list_custom_tls_certificates",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{PageNumber: 2, PageSize: defaultFastlyPageSize})
+			},
+		},
+		{
+			name: "create_custom_tls_certificate",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.CreateCustomTLSCertificate(ctx, &fastly.CreateCustomTLSCertificateInput{CertBlob: string(certPEM), Name: "test-certificate"})
+			},
+		},
+		{
+			name: "get_custom_tls_certificate",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.GetCustomTLSCertificate(ctx, &fastly.GetCustomTLSCertificateInput{ID: "cert5"})
+			},
+		},
+		{
+			name: "rename_custom_tls_certificate",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				body := `{"data":{"id":"cert5","type":"tls_certificate","attributes":{"name":"other-1-previous"}}}`
+				ro := fastly.CreateRequestOptions()
+				ro.Headers["Content-Type"] = "application/vnd.api+json"
+				ro.Body = strings.NewReader(body)
+				ro.BodyLength = int64(len(body))
+				resp, err := fastlyClient.Patch(ctx, "/tls/certificates/cert5", ro)
+				if err != nil {
+					return nil, err
+				}
+				return resp.StatusCode, resp.Body.Close()
+			},
+		},
+		{
+			name: "delete_custom_tls_certificate",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return nil, fastlyClient.DeleteCustomTLSCertificate(ctx, &fastly.DeleteCustomTLSCertificateInput{ID: "cert5"})
+			},
+		},
+		{
+			name: "delete_custom_tls_certificate_not_found",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				err := fastlyClient.DeleteCustomTLSCertificate(ctx, &fastly.DeleteCustomTLSCertificateInput{ID: "cert0"})
+				var httpErr *fastly.HTTPError
+				if !errors.As(err, &httpErr) {
+					return nil, err
+				}
+				return httpErr.IsNotFound(), nil
+			},
+		},
+		{
+			name: "list_tls_activations_of_certificate",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{FilterTLSCertificateID: "cert5", PageNumber: 1, PageSize: defaultFastlyPageSize})
+			},
+		},
+		{
+			name: "list_tls_activations_of_account",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{PageNumber: 2, PageSize: defaultFastlyPageSize})
+			},
+		},
+		{
+			name: "update_tls_activation",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.UpdateTLSActivation(ctx, &fastly.UpdateTLSActivationInput{
+					ID:          "act3",
+					Certificate: &fastly.CustomTLSCertificate{ID: "cert5"},
+				})
+			},
+		},
+		{
+			name: "create_tls_activation",
+			call: func(ctx context.Context, fastlyClient FastlyClientInterface) (any, error) {
+				return fastlyClient.CreateTLSActivation(ctx, &fastly.CreateTLSActivationInput{
+					Certificate:   &fastly.CustomTLSCertificate{ID: "cert5"},
+					Configuration: &fastly.TLSConfiguration{ID: "config2"},
+					Domain:        &fastly.TLSDomain{ID: "other-1.example.com"},
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Both accounts are seeded alike, so that they hand out the same IDs
+			inMemory := &fakeFastlyAccount{}
+			inMemory.seed(30)
+			overHTTP := &fakeFastlyAccount{}
+			overHTTP.seed(30)
+			realClient, err := fastly.NewClientForEndpoint("test-token", overHTTP.server(t).URL)
+			require.NoError(t, err)
+
+			expected, err := tt.call(context.Background(), inMemory.client(t))
+			require.NoError(t, err)
+			actual, err := tt.call(context.Background(), realClient)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, actual)
+			assert.Equal(t, inMemory.privateKeys, overHTTP.privateKeys)
+			assert.Equal(t, inMemory.certificates, overHTTP.certificates)
+			assert.Equal(t, inMemory.activations, overHTTP.activations)
+		})
+	}
+}
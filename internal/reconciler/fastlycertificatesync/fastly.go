@@ -2,20 +2,28 @@ package fastlycertificatesync
 
 import (
 	"context"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
 	"github.com/fastly/go-fastly/v11/fastly"
-	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
 	defaultFastlyPageSize = 20
 )
 
+// errFastlyCertificateChanged is returned when a certificate was written to by someone else between being observed
+// and being updated
+var errFastlyCertificateChanged = errors.New("certificate changed concurrently")
+
+//go:generate go run go.uber.org/mock/mockgen@v0.6.0 -source=fastly.go -destination=../../fastlymock/fastly_client.go -package=fastlymock -mock_names=FastlyClientInterface=MockFastlyClient
+
 // FastlyClientInterface defines the Fastly API methods needed by the Logic struct
 type FastlyClientInterface interface {
 	ListPrivateKeys(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error)
@@ -24,9 +32,20 @@ type FastlyClientInterface interface {
 	ListCustomTLSCertificates(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error)
 	CreateCustomTLSCertificate(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
 	UpdateCustomTLSCertificate(ctx context.Context, input *fastly.UpdateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
+	GetCustomTLSCertificate(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
+	DeleteCustomTLSCertificate(ctx context.Context, input *fastly.DeleteCustomTLSCertificateInput) error
 	ListTLSActivations(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error)
 	CreateTLSActivation(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error)
+	GetTLSActivation(ctx context.Context, input *fastly.GetTLSActivationInput) (*fastly.TLSActivation, error)
+	UpdateTLSActivation(ctx context.Context, input *fastly.UpdateTLSActivationInput) (*fastly.TLSActivation, error)
 	DeleteTLSActivation(ctx context.Context, input *fastly.DeleteTLSActivationInput) error
+	ListCustomTLSConfigurations(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error)
+	GetService(ctx context.Context, input *fastly.GetServiceInput) (*fastly.Service, error)
+	ListDomains(ctx context.Context, input *fastly.ListDomainsInput) ([]*fastly.Domain, error)
+	// Get requests endpoints that go-fastly doesn't fully model, see getFastlyDomainVerified
+	Get(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error)
+	// Patch requests endpoints that go-fastly doesn't fully model, see renameFastlyCertificate
+	Patch(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error)
 }
 
 // joinErrors combines multiple errors into a single error
@@ -40,74 +59,89 @@ func (l *Logic) getFastlyPrivateKeyExists(ctx *Context) (bool, error) {
 		return false, fmt.Errorf("failed to get TLS secret from context: %w", err)
 	}
 
-	// get private key from secret
-	keyPEM, ok := secret.Data["tls.key"]
-	if !ok {
-		return false, fmt.Errorf("secret %s/%s does not contain tls.key", secret.Namespace, secret.Name)
-	}
-
-	var allPrivateKeys []*fastly.PrivateKey
-	pageNumber := 1
-
-	for {
-		privateKeys, err := l.FastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{
-			PageNumber: pageNumber,
-			PageSize:   defaultFastlyPageSize,
-		})
-		if err != nil {
-			return false, fmt.Errorf("failed to list Fastly private keys: %w", err)
-		}
-
-		allPrivateKeys = append(allPrivateKeys, privateKeys...)
-
-		// If we received fewer keys than the page size, we've reached the end
-		if len(privateKeys) < defaultFastlyPageSize {
-			break
-		}
-		pageNumber++
+	allPrivateKeys, err := l.getFastlyPrivateKeys(ctx)
+	if err != nil {
+		return false, err
 	}
 
 	// Fastly doesn't advertise the private key values from its API (this is good)
 	// They will instead give us the sha1 of the public key component, which we can calculate on our end in order to match against the private key.
-	publicKeySHA1, err := getPublicKeySHA1FromPEM(keyPEM)
+	// The sha256 isn't matched on, it is only reported alongside for compliance.
+	fingerprints, err := getExpectedPublicKeyFingerprints(ctx, secret)
 	if err != nil {
-		return false, fmt.Errorf("failed to get public key SHA1: %w", err)
+		return false, fmt.Errorf("failed to get public key fingerprints: %w", err)
 	}
+	publicKeySHA1 := fingerprints.SHA1
 
-	ctx.Log.Info("calculated public key SHA1", "sha1", publicKeySHA1)
+	ctx.Log.Info("calculated public key fingerprints", "sha1", publicKeySHA1, "sha256", fingerprints.SHA256)
+	l.ObservedState.PrivateKeyPublicKeySHA1 = publicKeySHA1
+	l.ObservedState.PrivateKeyPublicKeySHA256 = fingerprints.SHA256
 
 	// does a private key exist in Fastly with a matching public key sha1?
 	keyExistsInFastly := false
 	for _, key := range allPrivateKeys {
 		ctx.Log.V(5).Info("found private key in Fastly with public_key_sha1", "public_key_sha1", key.PublicKeySHA1)
 		if key.PublicKeySHA1 == publicKeySHA1 {
-			ctx.Log.Info("found matching private key in Fastly, we do not need to upload our key", "key_id", key.ID, "fastly_public_key_sha1", key.PublicKeySHA1, "local_public_key_sha1", publicKeySHA1)
+			ctx.Log.Info("found matching private key in Fastly, we do not need to upload our key", "fastly_public_key_sha1", key.PublicKeySHA1, "local_public_key_sha1", publicKeySHA1, "local_public_key_sha256", fingerprints.SHA256)
+			l.ObservedState.PrivateKeyID = key.ID
 			keyExistsInFastly = true
 		}
 	}
 
+	// the key may have been uploaded moments ago, possibly for another subject sharing the same secret
+	if !keyExistsInFastly && ctx.Config.PrivateKeyUploadCacheTTL > 0 &&
+		l.uploadedPrivateKeys.Contains(l.fastlyAccount(), publicKeySHA1, ctx.Config.PrivateKeyUploadCacheTTL) {
+		ctx.Log.Info("private key was recently uploaded to Fastly but is not listed yet, skipping upload", "local_public_key_sha1", publicKeySHA1)
+		keyExistsInFastly = true
+	}
+
 	return keyExistsInFastly, nil
 }
 
 func (l *Logic) createFastlyPrivateKey(ctx *Context) error {
+	if err := l.checkAccountQuota(ctx, quotaPrivateKeys); err != nil {
+		return err
+	}
+
 	_, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get TLS secret from context: %w", err)
 	}
 
-	keyPEM, ok := secret.Data["tls.key"]
-	if !ok {
-		return fmt.Errorf("secret %s/%s does not contain tls.key", secret.Namespace, secret.Name)
+	keyPEM, err := openSecretKeyPEM(ctx, secret)
+	if err != nil {
+		return err
 	}
+	defer keyPEM.wipe()
 
-	createResp, err := l.FastlyClient.CreatePrivateKey(ctx, &fastly.CreatePrivateKeyInput{
+	// Reserve the upload before making it, another reconcile sharing the key may be about to upload it too
+	publicKeySHA1, _ := getPublicKeySHA1FromPEM(keyPEM)
+	if ctx.Config.PrivateKeyUploadCacheTTL > 0 && publicKeySHA1 != "" {
+		if !l.uploadedPrivateKeys.Reserve(l.fastlyAccount(), publicKeySHA1, ctx.Config.PrivateKeyUploadCacheTTL) {
+			ctx.Log.Info("private key is already being uploaded to Fastly, skipping upload", "local_public_key_sha1", publicKeySHA1)
+			return nil
+		}
+	}
+
+	createResp, err := l.fastlyClient().CreatePrivateKey(ctx, &fastly.CreatePrivateKeyInput{
 		Key:  string(keyPEM),
-		Name: secret.Name,
+		Name: fastlyObjectName(ctx, secret.Namespace, secret.Name),
 	})
 	if err != nil {
+		l.forgetFastlyInventory()
+		if publicKeySHA1 != "" {
+			l.uploadedPrivateKeys.Release(l.fastlyAccount(), publicKeySHA1)
+		}
 		return fmt.Errorf("failed to create Fastly private key: %w", err)
 	}
-	ctx.Log.Info("created new private key in Fastly", "key_id", createResp.ID)
+	l.recordFastlyPrivateKey(createResp)
+	l.accountQuotas.Add(l.fastlyAccount(), 0, 1)
+	ctx.Log.Info("created new private key in Fastly", "key_id", createResp.ID, "fastly_public_key_sha1", createResp.PublicKeySHA1, "local_public_key_sha256", l.ObservedState.PrivateKeyPublicKeySHA256)
+
+	// Fastly's SHA1 is what the listing is matched against, should it ever differ from ours
+	if createResp.PublicKeySHA1 != "" && createResp.PublicKeySHA1 != publicKeySHA1 {
+		l.uploadedPrivateKeys.Add(l.fastlyAccount(), createResp.PublicKeySHA1)
+	}
 
 	return nil
 }
@@ -137,69 +171,204 @@ func (l *Logic) getFastlyCertificateStatus(ctx *Context) (CertificateStatus, err
 	return CertificateStatusSynced, nil
 }
 
-// Get the Fastly certificate whose details match the certificate referenced by the subject
+// fastlyCertificateSnapshot indexes the Fastly certificates listed while observing the subject by name, so that the
+// steps of a reconcile share a single listing of the account
+type fastlyCertificateSnapshot struct {
+	certs  []*fastly.CustomTLSCertificate
+	byName map[string]*fastly.CustomTLSCertificate
+}
+
+// add indexes certs, keeping the first certificate listed under each name
+func (s *fastlyCertificateSnapshot) add(certs []*fastly.CustomTLSCertificate) {
+	if s.byName == nil {
+		s.byName = map[string]*fastly.CustomTLSCertificate{}
+	}
+	s.certs = append(s.certs, certs...)
+	for _, cert := range certs {
+		if _, ok := s.byName[cert.Name]; !ok {
+			s.byName[cert.Name] = cert
+		}
+	}
+}
+
+// find returns the first certificate listed that matches, or nil
+func (s *fastlyCertificateSnapshot) find(matches func(cert *fastly.CustomTLSCertificate) bool) *fastly.CustomTLSCertificate {
+	for _, cert := range s.certs {
+		if matches(cert) {
+			return cert
+		}
+	}
+	return nil
+}
+
+// findID returns the certificate of the given ID, or nil
+func (s *fastlyCertificateSnapshot) findID(id string) *fastly.CustomTLSCertificate {
+	if id == "" {
+		return nil
+	}
+	return s.find(func(cert *fastly.CustomTLSCertificate) bool { return cert.ID == id })
+}
+
+// match returns the certificate going by the first of names that any certificate does, or nil
+func (s *fastlyCertificateSnapshot) match(names []string) *fastly.CustomTLSCertificate {
+	for _, name := range names {
+		if cert, ok := s.byName[name]; ok {
+			return cert
+		}
+	}
+	return nil
+}
+
+// Get the Fastly certificate whose details match the certificate referenced by the subject. The certificates are
+// listed once per observation, later calls are answered from the snapshot in the observed state.
 func (l *Logic) getFastlyCertificateMatchingSubject(ctx *Context) (*fastly.CustomTLSCertificate, error) {
+	ref := certificateReference(ctx.Subject)
 	subjectCertificate := &cmv1.Certificate{}
-	if err := ctx.Client.Client.Get(ctx, types.NamespacedName{Name: ctx.Subject.Spec.CertificateName, Namespace: ctx.Subject.Namespace}, subjectCertificate); err != nil {
-		return nil, fmt.Errorf("failed to get certificate of name %s and namespace %s: %w", ctx.Subject.Spec.CertificateName, ctx.Subject.Namespace, err)
+	if err := ctx.Client.Client.Get(ctx, ref, subjectCertificate); err != nil {
+		return nil, fmt.Errorf("failed to get certificate of name %s and namespace %s: %w", ref.Name, ref.Namespace, err)
 	}
 
-	// List existing certificates in Fastly
-	var allCerts []*fastly.CustomTLSCertificate
-	pageNumber := 1
+	// match certificate based on name, preferring the owned name over others that may need adoption
+	names := fastlyObjectNameCandidates(ctx, subjectCertificate.Namespace, subjectCertificate.Name)
+	desiredName := names[0]
+	match, err := l.fastlyCertificateMatcher(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot := l.ObservedState.fastlyCertificates; snapshot != nil {
+		return match(snapshot), nil
+	}
+
+	snapshot := &fastlyCertificateSnapshot{}
+
+	// A cached listing is complete, so it's indexed as a whole
+	if ctx.Config.FastlyInventoryCacheTTL > 0 {
+		allCerts, err := l.getFastlyCertificates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Log.Info(fmt.Sprintf("found %d certificates", len(allCerts)))
 
-	for {
-		certs, err := l.FastlyClient.ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{
+		snapshot.add(allCerts)
+		l.ObservedState.fastlyCertificates = snapshot
+		return match(snapshot), nil
+	}
+
+	// Otherwise list existing certificates in Fastly until the owned one is found, or all of them when matching by
+	// anything other than the name
+	byName := isMatchedByName(ctx)
+	for pageNumber := 1; ; pageNumber++ {
+		certs, err := l.fastlyClient().ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{
 			PageNumber: pageNumber,
 			PageSize:   defaultFastlyPageSize,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to list Fastly certificates: %w", err)
 		}
+		snapshot.add(certs)
 
-		allCerts = append(allCerts, certs...)
+		if _, ok := snapshot.byName[desiredName]; ok && byName {
+			ctx.Log.Info("found matching certificate", "page", pageNumber)
+			break
+		}
 
 		// If we received fewer certificates than the page size, we've reached the end
 		if len(certs) < defaultFastlyPageSize {
+			ctx.Log.Info(fmt.Sprintf("found no owned certificate in %d pages", pageNumber))
 			break
 		}
-		pageNumber++
 	}
 
-	ctx.Log.Info(fmt.Sprintf("found %d certificates", len(allCerts)))
+	l.ObservedState.fastlyCertificates = snapshot
+	// nil when no match was found
+	return match(snapshot), nil
+}
 
-	// match certificate based on name
-	for _, cert := range allCerts {
-		if cert.Name == subjectCertificate.Name {
-			return cert, nil
-		}
+// isMatchedByName reports whether the subject's Fastly certificate is matched by its name, with spec.matchStrategy
+func isMatchedByName(ctx *Context) bool {
+	strategy := ctx.Subject.Spec.MatchStrategy
+	return strategy == "" || strategy == v1alpha1.CertificateMatchStrategyName
+}
+
+// fastlyCertificateMatcher returns how the subject's certificate is picked out of the listed Fastly certificates,
+// according to spec.matchStrategy. Strategies other than Name ignore the names of certificates entirely, as resources
+// reusing Certificate names may share them.
+func (l *Logic) fastlyCertificateMatcher(ctx *Context, names []string) (func(*fastlyCertificateSnapshot) *fastly.CustomTLSCertificate, error) {
+	byName := func(s *fastlyCertificateSnapshot) *fastly.CustomTLSCertificate {
+		return s.match(names)
 	}
 
-	// no match found
-	return nil, nil
+	switch ctx.Subject.Spec.MatchStrategy {
+	case v1alpha1.CertificateMatchStrategySerialNumber:
+		_, tlsSecret, err := getCertificateAndTLSSecretFromSubject(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get TLS secret from context: %w", err)
+		}
+		certPEM, err := getCertPEMForSecret(ctx, tlsSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cert PEM for secret: %w", err)
+		}
+		serialNumber, err := getSerialNumberFromCertificatePEM(certPEM)
+		if err != nil {
+			return nil, err
+		}
+		trackedID, err := l.trackedFastlyCertificateID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Once the local certificate is renewed, the certificate last synced still holds the previous serial number
+		return func(s *fastlyCertificateSnapshot) *fastly.CustomTLSCertificate {
+			if cert := s.find(func(cert *fastly.CustomTLSCertificate) bool { return cert.SerialNumber == serialNumber }); cert != nil {
+				return cert
+			}
+			return s.findID(trackedID)
+		}, nil
+	case v1alpha1.CertificateMatchStrategyFastlyID:
+		trackedID, err := l.trackedFastlyCertificateID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// The certificate is found by name until one was synced
+		if trackedID == "" {
+			return byName, nil
+		}
+		return func(s *fastlyCertificateSnapshot) *fastly.CustomTLSCertificate {
+			return s.findID(trackedID)
+		}, nil
+	default:
+		return byName, nil
+	}
 }
 
-func (l *Logic) createFastlyCertificate(ctx *Context) error {
+func (l *Logic) createFastlyCertificate(ctx *Context) (*fastly.CustomTLSCertificate, error) {
+	if err := l.checkAccountQuota(ctx, quotaCustomCertificates); err != nil {
+		return nil, err
+	}
+
 	subjectCertificate, tlsSecret, err := getCertificateAndTLSSecretFromSubject(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get TLS secret from context: %w", err)
+		return nil, fmt.Errorf("failed to get TLS secret from context: %w", err)
 	}
 
 	certPEM, err := getCertPEMForSecret(ctx, tlsSecret)
 	if err != nil {
-		return fmt.Errorf("failed to get CertPEM for Fastly certificate: %w", err)
+		return nil, fmt.Errorf("failed to get CertPEM for Fastly certificate: %w", err)
 	}
 
-	_, err = l.FastlyClient.CreateCustomTLSCertificate(ctx, &fastly.CreateCustomTLSCertificateInput{
+	created, err := l.fastlyClient().CreateCustomTLSCertificate(ctx, &fastly.CreateCustomTLSCertificateInput{
 		CertBlob:           string(certPEM),
-		Name:               subjectCertificate.Name,
+		Name:               fastlyObjectName(ctx, subjectCertificate.Namespace, subjectCertificate.Name),
 		AllowUntrustedRoot: ctx.Config.HackFastlyCertificateSyncLocalReconciliation,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create Fastly certificate: %w", err)
+		l.forgetFastlyCertificates()
+		return nil, fmt.Errorf("failed to create Fastly certificate: %w", err)
 	}
+	l.recordFastlyCertificate(created)
+	l.accountQuotas.Add(l.fastlyAccount(), 1, 0)
 
-	return nil
+	return created, nil
 }
 
 func (l *Logic) updateFastlyCertificate(ctx *Context) error {
@@ -222,19 +391,78 @@ func (l *Logic) updateFastlyCertificate(ctx *Context) error {
 		return fmt.Errorf("fastly certificate not found")
 	}
 
-	_, err = l.FastlyClient.UpdateCustomTLSCertificate(ctx, &fastly.UpdateCustomTLSCertificateInput{
+	trackedID, err := l.trackedFastlyCertificateID(ctx)
+	if err != nil {
+		return err
+	}
+	if isFastlyCertificateAdoptionRequired(ctx, fastlyCertificate, CertificateStatusStale, trackedID) {
+		return fmt.Errorf("refusing to update Fastly certificate %s that was not created by the operator without spec.adoptExisting or the %s annotation", fastlyCertificate.Name, AdoptFastlyCertificateAnnotation)
+	}
+
+	// Fastly's certificate API has no conditional updates, so the certificate is read again right before the update
+	// to narrow the window in which a concurrent write would be silently overwritten
+	current, err := l.getUnchangedFastlyCertificate(ctx, fastlyCertificate, certPEM)
+	if err != nil || current == nil {
+		return err
+	}
+
+	// Renewals may keep the certificate they replace around for a while, rather than replacing it in place
+	if retainsPreviousFastlyCertificate(ctx) && l.ObservedState.CertificateStatus == CertificateStatusStale {
+		return l.rotateFastlyCertificate(ctx, current, fastlyObjectName(ctx, subjectCertificate.Namespace, subjectCertificate.Name))
+	}
+
+	// Updating an adopted certificate also renames it into the owned prefix
+	updated, err := l.fastlyClient().UpdateCustomTLSCertificate(ctx, &fastly.UpdateCustomTLSCertificateInput{
 		CertBlob:           string(certPEM),
-		Name:               subjectCertificate.Name,
+		Name:               fastlyObjectName(ctx, subjectCertificate.Namespace, subjectCertificate.Name),
 		ID:                 fastlyCertificate.ID,
 		AllowUntrustedRoot: ctx.Config.HackFastlyCertificateSyncLocalReconciliation,
 	})
 	if err != nil {
+		l.forgetFastlyCertificates()
 		return fmt.Errorf("failed to update Fastly certificate: %w", err)
 	}
+	l.recordFastlyCertificate(updated)
 
 	return nil
 }
 
+// getUnchangedFastlyCertificate reads the certificate about to be updated from Fastly, and returns it as long as it
+// still holds the serial number it was observed with. A certificate that already holds the local certificate, such as
+// one updated by another replica in the meantime, is recorded without being updated again and nil is returned.
+// Any other change fails the update, so that the certificate is observed again before anything is overwritten.
+func (l *Logic) getUnchangedFastlyCertificate(ctx *Context, observed *fastly.CustomTLSCertificate, certPEM []byte) (*fastly.CustomTLSCertificate, error) {
+	current, err := l.fastlyClient().GetCustomTLSCertificate(ctx, &fastly.GetCustomTLSCertificateInput{ID: observed.ID})
+	if err != nil {
+		l.forgetFastlyCertificates()
+		return nil, fmt.Errorf("failed to get Fastly certificate %s before updating it: %w", observed.ID, err)
+	}
+	if current.SerialNumber == observed.SerialNumber {
+		return current, nil
+	}
+
+	localSerialNumber, err := getSerialNumberFromCertificatePEM(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if current.SerialNumber == localSerialNumber {
+		ctx.Log.Info("Fastly certificate was updated to the local certificate in the meantime, skipping update", "certificate_id", current.ID, "serial_number", current.SerialNumber)
+		l.recordFastlyCertificate(current)
+		return nil, nil
+	}
+
+	l.forgetFastlyCertificates()
+	return nil, fmt.Errorf("certificate %s in Fastly changed from serial number %s to %s since it was observed: %w",
+		current.ID, observed.SerialNumber, current.SerialNumber, errFastlyCertificateChanged)
+}
+
+// forgetFastlyCertificates drops the listed certificates, both the snapshot of this reconcile and the cached
+// inventory of the account, after a certificate was created or changed
+func (l *Logic) forgetFastlyCertificates() {
+	l.ObservedState.fastlyCertificates = nil
+	l.forgetFastlyInventory()
+}
+
 func (l *Logic) isFastlyCertificateStale(ctx *Context, fastlyCertificate *fastly.CustomTLSCertificate) (bool, error) {
 	subjectCertificate, tlsSecret, err := getCertificateAndTLSSecretFromSubject(ctx)
 	if err != nil {
@@ -246,17 +474,12 @@ func (l *Logic) isFastlyCertificateStale(ctx *Context, fastlyCertificate *fastly
 		return false, fmt.Errorf("failed to get cert PEM for secret: %w", err)
 	}
 
-	block, _ := pem.Decode(certPEM)
-	if block == nil {
-		return false, fmt.Errorf("failed to decode PEM block")
-	}
-
 	// serialNumber comparison is used to determine if the local certificate was refreshed
-	cert, err := x509.ParseCertificate(block.Bytes)
+	serialNumber, err := getSerialNumberFromCertificatePEM(certPEM)
 	if err != nil {
-		return false, fmt.Errorf("failed to parse certificate: %w", err)
+		return false, err
 	}
-	serialNumber := cert.SerialNumber.String()
+	l.ObservedState.LocalSerialNumber = serialNumber
 
 	ctx.Log.Info("checking serial number of existing fastly certificate against local value", "domains", subjectCertificate.Spec.DNSNames, "fastly_cert_serial_number", fastlyCertificate.SerialNumber, "local_cert_serial_number", serialNumber)
 
@@ -266,9 +489,6 @@ func (l *Logic) isFastlyCertificateStale(ctx *Context, fastlyCertificate *fastly
 }
 
 func (l *Logic) getFastlyTLSActivationState(ctx *Context) ([]TLSActivationData, []string, error) {
-	missingTLSActivationData := []TLSActivationData{}
-	extraTLSActivationIDs := []string{}
-
 	fastlyCertificate, err := l.getFastlyCertificateMatchingSubject(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get Fastly certificate matching subject: %w", err)
@@ -277,108 +497,158 @@ func (l *Logic) getFastlyTLSActivationState(ctx *Context) ([]TLSActivationData,
 	// If no certificate exists in Fastly yet, there can be no TLS activations
 	if fastlyCertificate == nil {
 		ctx.Log.Info("No certificate found in Fastly, skipping TLS activation checks")
-		return missingTLSActivationData, extraTLSActivationIDs, nil
+		return []TLSActivationData{}, []string{}, nil
 	}
 
-	domainAndConfigurationToActivation, err := l.getFastlyDomainAndConfigurationToActivationMap(ctx, fastlyCertificate)
+	activations, err := l.getFastlyTLSActivations(ctx, fastlyCertificate)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get Fastly domain and configuration to activation map: %w", err)
 	}
 
-	// For each certificate domain and expected configuration id, report activations that do not exist
-	for _, domain := range fastlyCertificate.Domains {
-		for _, configID := range ctx.Subject.Spec.TLSConfigurationIds {
-			if _, exists := domainAndConfigurationToActivation[domain.ID][configID]; !exists {
-				missingTLSActivationData = append(missingTLSActivationData, TLSActivationData{
-					Certificate:   fastlyCertificate,
-					Configuration: &fastly.TLSConfiguration{ID: configID},
-					Domain:        domain,
-				})
-			} else {
-				ctx.Log.Info("TLS activation already exists", "config_id", configID)
-				// Remove from map since we want to keep this activation
-				delete(domainAndConfigurationToActivation[domain.ID], configID)
+	// Resume a partially created batch, rather than creating activations Fastly doesn't list yet again
+	diff := diffTLSActivations(fastlyCertificate, activatedTLSDomains(ctx, fastlyCertificate), l.tlsConfigurationIDs(ctx), activations,
+		func(domainID, configID string) bool {
+			if !l.isTLSActivationCreated(ctx, fastlyCertificate, domainID, configID) {
+				return false
 			}
-		}
-	}
+			ctx.Log.Info("TLS activation was recently created but is not listed yet, skipping", "domain", domainID, "config_id", configID)
+			return true
+		})
 
-	// Any remaining activations in the map should be deleted
-	for _, configToActivation := range domainAndConfigurationToActivation {
-		for _, activation := range configToActivation {
-			extraTLSActivationIDs = append(extraTLSActivationIDs, activation.ID)
-		}
+	if !diff.pendingListing {
+		l.tlsActivationProgress.Forget(ctx.NamespacedName, l.fastlyAccount())
 	}
 
-	return missingTLSActivationData, extraTLSActivationIDs, nil
+	return diff.missing, diff.extraIDs, nil
 }
 
-// Build the mapping of domain -> configuration -> activation for a given certificate
-func (l *Logic) getFastlyDomainAndConfigurationToActivationMap(ctx *Context, cert *fastly.CustomTLSCertificate) (map[string]map[string]*fastly.TLSActivation, error) {
-	var allActivations []*fastly.TLSActivation
-	pageNumber := 1
-
-	for {
-		activations, err := l.FastlyClient.ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{
-			FilterTLSCertificateID: cert.ID,
-			PageNumber:             pageNumber,
-			PageSize:               defaultFastlyPageSize,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to list Fastly TLS activations: %w", err)
-		}
-
-		allActivations = append(allActivations, activations...)
+// activatedTLSDomains returns the TLS domains of the certificate to activate, those serving spec.domains when set.
+// The TLS domain of a wildcard certificate is the wildcard itself, so requested subdomains map onto it, unless the
+// certificate also names them explicitly.
+func activatedTLSDomains(ctx *Context, cert *fastly.CustomTLSCertificate) []*fastly.TLSDomain {
+	if len(ctx.Subject.Spec.Domains) == 0 {
+		return cert.Domains
+	}
 
-		// If we received fewer activations than the page size, we've reached the end
-		if len(activations) < defaultFastlyPageSize {
-			break
+	activated := map[string]bool{}
+	for _, name := range ctx.Subject.Spec.Domains {
+		if domain := tlsDomainServing(cert, strings.ToLower(name)); domain != nil {
+			activated[domain.ID] = true
 		}
-		pageNumber++
 	}
 
-	ctx.Log.Info(fmt.Sprintf("Found %d TLS activations", len(allActivations)), "domains", cert.Domains)
+	var res []*fastly.TLSDomain
+	for _, domain := range cert.Domains {
+		if activated[domain.ID] {
+			res = append(res, domain)
+		}
+	}
+	return res
+}
 
-	// map domain id -> configuration id -> activation
-	domainAndConfigurationToActivation := make(map[string]map[string]*fastly.TLSActivation)
-	for _, activation := range allActivations {
-		if domainAndConfigurationToActivation[activation.Domain.ID] == nil {
-			domainAndConfigurationToActivation[activation.Domain.ID] = make(map[string]*fastly.TLSActivation)
+// tlsDomainServing returns the TLS domain of the certificate that serves the name, preferring the name itself over a
+// wildcard covering it
+func tlsDomainServing(cert *fastly.CustomTLSCertificate, name string) *fastly.TLSDomain {
+	var wildcard *fastly.TLSDomain
+	for _, domain := range cert.Domains {
+		id := strings.ToLower(domain.ID)
+		if id == name {
+			return domain
+		}
+		if wildcard == nil && isDomainServed(name, []string{id}) {
+			wildcard = domain
 		}
-		domainAndConfigurationToActivation[activation.Domain.ID][activation.Configuration.ID] = activation
 	}
-	return domainAndConfigurationToActivation, nil
+	return wildcard
+}
+
+// Build the mapping of domain -> configuration -> activation for a given certificate
+func (l *Logic) getFastlyDomainAndConfigurationToActivationMap(ctx *Context, cert *fastly.CustomTLSCertificate) (map[string]map[string]*fastly.TLSActivation, error) {
+	activations, err := l.getFastlyTLSActivations(ctx, cert)
+	if err != nil {
+		return nil, err
+	}
+	return indexTLSActivations(activations), nil
 }
 
 func (l *Logic) createMissingFastlyTLSActivations(ctx *Context) error {
+	if ctx.Subject.ActivatesInOrder() {
+		return l.createMissingFastlyTLSActivationsInOrder(ctx)
+	}
+	_, err := l.createFastlyTLSActivations(ctx, l.ObservedState.MissingTLSActivationData)
+	return err
+}
+
+// createFastlyTLSActivations creates the given TLS activations in parallel. It reports whether none were deferred by
+// the mutation budget.
+func (l *Logic) createFastlyTLSActivations(ctx *Context, missing []TLSActivationData) (bool, error) {
 	var errors []error
 
-	for _, activationData := range l.ObservedState.MissingTLSActivationData {
+	activations := make([]*fastly.TLSActivation, len(missing))
+	errs := make([]error, len(missing))
+	deferrals := make([]time.Duration, len(missing))
+	deferred := make([]bool, len(missing))
+
+	runParallel(l.Config.TLSActivationParallelism, len(missing), func(i int) {
+		if allowed, retryAfter := l.reserveFastlyMutation(ctx); !allowed {
+			deferred[i], deferrals[i] = true, retryAfter
+			return
+		}
+
 		// Create new activation
-		_, err := l.FastlyClient.CreateTLSActivation(ctx, &fastly.CreateTLSActivationInput{
-			Certificate:   activationData.Certificate,
-			Configuration: activationData.Configuration,
-			Domain:        activationData.Domain,
+		activations[i], errs[i] = l.fastlyClient().CreateTLSActivation(ctx, &fastly.CreateTLSActivationInput{
+			Certificate:   missing[i].Certificate,
+			Configuration: missing[i].Configuration,
+			Domain:        missing[i].Domain,
 		})
-		if err != nil {
-			errors = append(errors, fmt.Errorf("failed to create TLS activation for config %s: %w", activationData.Configuration.ID, err))
+	})
+
+	for i, activationData := range missing {
+		if deferred[i] {
+			continue
+		}
+		l.recordTLSActivationResult(activationData, activations[i], errs[i])
+		if errs[i] != nil {
+			errors = append(errors, fmt.Errorf("failed to create TLS activation for domain %s and config %s: %w", activationData.Domain.ID, activationData.Configuration.ID, errs[i]))
+			continue
+		}
+		// Only activations Fastly confirmed are masked until they are listed, anything else is retried
+		if activations[i] == nil || activations[i].ID == "" {
+			continue
 		}
+		l.tlsActivationProgress.Add(ctx.NamespacedName, l.fastlyAccount(), ctx.Subject.Generation, activationData.Certificate.ID, activationData.Domain.ID, activationData.Configuration.ID)
 	}
+	l.deferTLSActivations(ctx, deferred, deferrals)
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to create TLS activations: %w", joinErrors(errors))
+		return false, fmt.Errorf("failed to create TLS activations: %w", joinErrors(errors))
 	}
-	return nil
+	return !slices.Contains(deferred, true), nil
 }
 
 func (l *Logic) deleteExtraFastlyTLSActivations(ctx *Context) error {
 	var errors []error
 
-	for _, activationID := range l.ObservedState.ExtraTLSActivationIDs {
-		err := l.FastlyClient.DeleteTLSActivation(ctx, &fastly.DeleteTLSActivationInput{ID: activationID})
-		if err != nil {
-			errors = append(errors, fmt.Errorf("failed to delete TLS activation %s: %w", activationID, err))
+	extra := l.ObservedState.ExtraTLSActivationIDs
+	errs := make([]error, len(extra))
+	deferrals := make([]time.Duration, len(extra))
+	deferred := make([]bool, len(extra))
+
+	runParallel(l.Config.TLSActivationParallelism, len(extra), func(i int) {
+		if allowed, retryAfter := l.reserveFastlyMutation(ctx); !allowed {
+			deferred[i], deferrals[i] = true, retryAfter
+			return
+		}
+
+		errs[i] = l.fastlyClient().DeleteTLSActivation(ctx, &fastly.DeleteTLSActivationInput{ID: extra[i]})
+	})
+
+	for i, activationID := range extra {
+		if errs[i] != nil {
+			errors = append(errors, fmt.Errorf("failed to delete TLS activation %s: %w", activationID, errs[i]))
 		}
 	}
+	l.deferTLSActivations(ctx, deferred, deferrals)
 
 	if len(errors) > 0 {
 		return fmt.Errorf("failed to delete TLS activations: %w", joinErrors(errors))
@@ -386,27 +656,22 @@ func (l *Logic) deleteExtraFastlyTLSActivations(ctx *Context) error {
 	return nil
 }
 
-func (l *Logic) getFastlyUnusedPrivateKeyIDs(ctx *Context) ([]string, error) {
-	privateKeys, err := l.FastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{FilterInUse: "false"})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list Fastly private keys: %w", err)
+// deferTLSActivations requeues the subject once the mutation budget allows for the activation changes that were
+// skipped after it ran out mid-batch
+func (l *Logic) deferTLSActivations(ctx *Context, deferred []bool, deferrals []time.Duration) {
+	count := 0
+	var retryAfter time.Duration
+	for i := range deferred {
+		if deferred[i] {
+			count++
+			retryAfter = max(retryAfter, deferrals[i])
+		}
 	}
-
-	unusedPrivateKeyIDs := []string{}
-	for _, key := range privateKeys {
-		unusedPrivateKeyIDs = append(unusedPrivateKeyIDs, key.ID)
+	if count == 0 {
+		return
 	}
-	return unusedPrivateKeyIDs, nil
-}
 
-func (l *Logic) clearFastlyUnusedPrivateKeys(ctx *Context) {
-	for _, privateKeyID := range l.ObservedState.UnusedPrivateKeyIDs {
-		ctx.Log.Info(fmt.Sprintf("attempting to delete unused private key %s", privateKeyID))
-		if err := l.FastlyClient.DeletePrivateKey(ctx, &fastly.DeletePrivateKeyInput{ID: privateKeyID}); err != nil {
-			// Deleting a private key has some inconsistencies on Fastly's end.
-			// It is never critical to delete a private key, we only need deletion to be eventually consistent.
-			// We effectively swallow the error, but notify via an info log that wont trigger a monitor.
-			ctx.Log.Info(fmt.Sprintf("Failed to delete Fastly private key %s: %v. This is not critical, there are often race conditions when querying for unused private keys", privateKeyID, err))
-		}
-	}
+	ctx.Log.Info("Fastly mutation budget exceeded, deferring TLS activation changes", "deferred", count, "retry_after", retryAfter)
+	ctx.SetRequeue(retryAfter)
+	l.ObservedState.MutationsDeferred = true
 }
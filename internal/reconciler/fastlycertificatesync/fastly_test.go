@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
 	"github.com/fastly/go-fastly/v11/fastly"
 	"github.com/go-logr/logr"
 	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
@@ -19,22 +23,34 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
-// MockFastlyClient implements FastlyClientInterface for testing
+// MockFastlyClient implements FastlyClientInterface for testing. It has to be extended by hand along with the
+// interface, new tests use the mock generated into fastlymock instead.
 type MockFastlyClient struct {
-	ListPrivateKeysFunc            func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error)
-	CreatePrivateKeyFunc           func(ctx context.Context, input *fastly.CreatePrivateKeyInput) (*fastly.PrivateKey, error)
-	DeletePrivateKeyFunc           func(ctx context.Context, input *fastly.DeletePrivateKeyInput) error
-	ListCustomTLSCertificatesFunc  func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error)
-	CreateCustomTLSCertificateFunc func(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
-	UpdateCustomTLSCertificateFunc func(ctx context.Context, input *fastly.UpdateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
-	ListTLSActivationsFunc         func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error)
-	CreateTLSActivationFunc        func(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error)
-	DeleteTLSActivationFunc        func(ctx context.Context, input *fastly.DeleteTLSActivationInput) error
-
-	// Track method calls
-	DeletePrivateKeyCalls    []string
-	DeleteTLSActivationCalls []string
-	CreateTLSActivationCalls []*fastly.CreateTLSActivationInput
+	ListPrivateKeysFunc             func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error)
+	CreatePrivateKeyFunc            func(ctx context.Context, input *fastly.CreatePrivateKeyInput) (*fastly.PrivateKey, error)
+	DeletePrivateKeyFunc            func(ctx context.Context, input *fastly.DeletePrivateKeyInput) error
+	ListCustomTLSCertificatesFunc   func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error)
+	CreateCustomTLSCertificateFunc  func(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
+	UpdateCustomTLSCertificateFunc  func(ctx context.Context, input *fastly.UpdateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
+	GetCustomTLSCertificateFunc     func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error)
+	DeleteCustomTLSCertificateFunc  func(ctx context.Context, input *fastly.DeleteCustomTLSCertificateInput) error
+	ListTLSActivationsFunc          func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error)
+	CreateTLSActivationFunc         func(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error)
+	DeleteTLSActivationFunc         func(ctx context.Context, input *fastly.DeleteTLSActivationInput) error
+	GetTLSActivationFunc            func(ctx context.Context, input *fastly.GetTLSActivationInput) (*fastly.TLSActivation, error)
+	UpdateTLSActivationFunc         func(ctx context.Context, input *fastly.UpdateTLSActivationInput) (*fastly.TLSActivation, error)
+	ListCustomTLSConfigurationsFunc func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error)
+	GetServiceFunc                  func(ctx context.Context, input *fastly.GetServiceInput) (*fastly.Service, error)
+	ListDomainsFunc                 func(ctx context.Context, input *fastly.ListDomainsInput) ([]*fastly.Domain, error)
+	GetFunc                         func(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error)
+	PatchFunc                       func(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error)
+
+	// Track method calls, guarded by mu as TLS activations are changed concurrently
+	mu                              sync.Mutex
+	DeletePrivateKeyCalls           []string
+	DeleteCustomTLSCertificateCalls []string
+	DeleteTLSActivationCalls        []string
+	CreateTLSActivationCalls        []*fastly.CreateTLSActivationInput
 }
 
 // MockKubernetesClient implements a simple mock for the Kubernetes client Get method
@@ -70,7 +86,9 @@ func (m *MockFastlyClient) CreatePrivateKey(ctx context.Context, input *fastly.C
 
 func (m *MockFastlyClient) DeletePrivateKey(ctx context.Context, input *fastly.DeletePrivateKeyInput) error {
 	// Track the call
+	m.mu.Lock()
 	m.DeletePrivateKeyCalls = append(m.DeletePrivateKeyCalls, input.ID)
+	m.mu.Unlock()
 
 	if m.DeletePrivateKeyFunc != nil {
 		return m.DeletePrivateKeyFunc(ctx, input)
@@ -99,6 +117,60 @@ func (m *MockFastlyClient) UpdateCustomTLSCertificate(ctx context.Context, input
 	return nil, nil
 }
 
+func (m *MockFastlyClient) GetCustomTLSCertificate(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+	if m.GetCustomTLSCertificateFunc != nil {
+		return m.GetCustomTLSCertificateFunc(ctx, input)
+	}
+	return nil, nil
+}
+
+func (m *MockFastlyClient) DeleteCustomTLSCertificate(ctx context.Context, input *fastly.DeleteCustomTLSCertificateInput) error {
+	// Track the call
+	m.mu.Lock()
+	m.DeleteCustomTLSCertificateCalls = append(m.DeleteCustomTLSCertificateCalls, input.ID)
+	m.mu.Unlock()
+
+	if m.DeleteCustomTLSCertificateFunc != nil {
+		return m.DeleteCustomTLSCertificateFunc(ctx, input)
+	}
+	return nil
+}
+
+func (m *MockFastlyClient) ListCustomTLSConfigurations(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+	if m.ListCustomTLSConfigurationsFunc != nil {
+		return m.ListCustomTLSConfigurationsFunc(ctx, input)
+	}
+	return nil, nil
+}
+
+func (m *MockFastlyClient) GetService(ctx context.Context, input *fastly.GetServiceInput) (*fastly.Service, error) {
+	if m.GetServiceFunc != nil {
+		return m.GetServiceFunc(ctx, input)
+	}
+	return nil, nil
+}
+
+func (m *MockFastlyClient) ListDomains(ctx context.Context, input *fastly.ListDomainsInput) ([]*fastly.Domain, error) {
+	if m.ListDomainsFunc != nil {
+		return m.ListDomainsFunc(ctx, input)
+	}
+	return nil, nil
+}
+
+func (m *MockFastlyClient) Get(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, p, ro)
+	}
+	return nil, fmt.Errorf("unexpected request to %s", p)
+}
+
+func (m *MockFastlyClient) Patch(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+	if m.PatchFunc != nil {
+		return m.PatchFunc(ctx, p, ro)
+	}
+	return nil, fmt.Errorf("unexpected request to %s", p)
+}
+
 func (m *MockFastlyClient) ListTLSActivations(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
 	if m.ListTLSActivationsFunc != nil {
 		return m.ListTLSActivationsFunc(ctx, input)
@@ -108,7 +180,9 @@ func (m *MockFastlyClient) ListTLSActivations(ctx context.Context, input *fastly
 
 func (m *MockFastlyClient) CreateTLSActivation(ctx context.Context, input *fastly.CreateTLSActivationInput) (*fastly.TLSActivation, error) {
 	// Track the call
+	m.mu.Lock()
 	m.CreateTLSActivationCalls = append(m.CreateTLSActivationCalls, input)
+	m.mu.Unlock()
 
 	if m.CreateTLSActivationFunc != nil {
 		return m.CreateTLSActivationFunc(ctx, input)
@@ -116,9 +190,25 @@ func (m *MockFastlyClient) CreateTLSActivation(ctx context.Context, input *fastl
 	return nil, nil
 }
 
+func (m *MockFastlyClient) GetTLSActivation(ctx context.Context, input *fastly.GetTLSActivationInput) (*fastly.TLSActivation, error) {
+	if m.GetTLSActivationFunc != nil {
+		return m.GetTLSActivationFunc(ctx, input)
+	}
+	return nil, nil
+}
+
+func (m *MockFastlyClient) UpdateTLSActivation(ctx context.Context, input *fastly.UpdateTLSActivationInput) (*fastly.TLSActivation, error) {
+	if m.UpdateTLSActivationFunc != nil {
+		return m.UpdateTLSActivationFunc(ctx, input)
+	}
+	return nil, nil
+}
+
 func (m *MockFastlyClient) DeleteTLSActivation(ctx context.Context, input *fastly.DeleteTLSActivationInput) error {
 	// Track the call
+	m.mu.Lock()
 	m.DeleteTLSActivationCalls = append(m.DeleteTLSActivationCalls, input.ID)
+	m.mu.Unlock()
 
 	if m.DeleteTLSActivationFunc != nil {
 		return m.DeleteTLSActivationFunc(ctx, input)
@@ -178,203 +268,6 @@ func TestJoinErrors(t *testing.T) {
 	}
 }
 
-func TestLogic_getFastlyUnusedPrivateKeyIDs(t *testing.T) {
-	tests := []struct {
-		name          string
-		mockResponse  []*fastly.PrivateKey
-		mockError     error
-		expectedIDs   []string
-		expectedError string
-	}{
-		{
-			name: "successful call with multiple keys",
-			mockResponse: []*fastly.PrivateKey{
-				{ID: "key1"},
-				{ID: "key2"},
-				{ID: "key3"},
-			},
-			expectedIDs:   []string{"key1", "key2", "key3"},
-			expectedError: "",
-		},
-		{
-			name:          "successful call with no keys",
-			mockResponse:  []*fastly.PrivateKey{},
-			expectedIDs:   []string{},
-			expectedError: "",
-		},
-		{
-			name:          "successful call with single key",
-			mockResponse:  []*fastly.PrivateKey{{ID: "single-key"}},
-			expectedIDs:   []string{"single-key"},
-			expectedError: "",
-		},
-		{
-			name:          "api call fails",
-			mockResponse:  nil,
-			mockError:     errors.New("api error"),
-			expectedIDs:   nil,
-			expectedError: "failed to list Fastly private keys: api error",
-		},
-		{
-			name:          "api call returns nil response",
-			mockResponse:  nil,
-			mockError:     nil,
-			expectedIDs:   []string{},
-			expectedError: "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create mock client
-			mockClient := &MockFastlyClient{
-				ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
-					// Verify the correct filter is set
-					if input.FilterInUse != "false" {
-						t.Errorf("Expected FilterInUse = 'false', got %q", input.FilterInUse)
-					}
-					return tt.mockResponse, tt.mockError
-				},
-			}
-
-			// Create Logic instance with mock client
-			logic := &Logic{
-				FastlyClient: mockClient,
-			}
-
-			// Call the actual function from fastly.go
-			result, err := logic.getFastlyUnusedPrivateKeyIDs(nil)
-
-			// Check error
-			if tt.expectedError == "" {
-				if err != nil {
-					t.Errorf("getFastlyUnusedPrivateKeyIDs() error = %v, want nil", err)
-				}
-			} else {
-				if err == nil {
-					t.Errorf("getFastlyUnusedPrivateKeyIDs() error = nil, want %q", tt.expectedError)
-				} else if err.Error() != tt.expectedError {
-					t.Errorf("getFastlyUnusedPrivateKeyIDs() error = %q, want %q", err.Error(), tt.expectedError)
-				}
-			}
-
-			// Check result
-			if err == nil {
-				if len(result) != len(tt.expectedIDs) {
-					t.Errorf("getFastlyUnusedPrivateKeyIDs() returned %d IDs, want %d", len(result), len(tt.expectedIDs))
-				}
-				for i, id := range result {
-					if i >= len(tt.expectedIDs) || id != tt.expectedIDs[i] {
-						t.Errorf("getFastlyUnusedPrivateKeyIDs() result[%d] = %q, want %q", i, id, tt.expectedIDs[i])
-					}
-				}
-			}
-		})
-	}
-}
-
-func TestLogic_clearFastlyUnusedPrivateKeys(t *testing.T) {
-	tests := []struct {
-		name                string
-		unusedPrivateKeyIDs []string
-		deleteErrors        map[string]error // Map of keyID -> error to return
-		expectedDeletedKeys []string
-	}{
-		{
-			name:                "successful deletion of multiple keys",
-			unusedPrivateKeyIDs: []string{"key1", "key2", "key3"},
-			deleteErrors:        map[string]error{},
-			expectedDeletedKeys: []string{"key1", "key2", "key3"},
-		},
-		{
-			name:                "no keys to delete",
-			unusedPrivateKeyIDs: []string{},
-			deleteErrors:        map[string]error{},
-			expectedDeletedKeys: []string{},
-		},
-		{
-			name:                "successful deletion of single key",
-			unusedPrivateKeyIDs: []string{"single-key"},
-			deleteErrors:        map[string]error{},
-			expectedDeletedKeys: []string{"single-key"},
-		},
-		{
-			name:                "some deletions fail - should continue",
-			unusedPrivateKeyIDs: []string{"key1", "key2", "key3"},
-			deleteErrors: map[string]error{
-				"key1": errors.New("delete failed"),
-				"key3": errors.New("another delete failed"),
-			},
-			expectedDeletedKeys: []string{"key1", "key2", "key3"},
-		},
-		{
-			name:                "all deletions fail - should continue",
-			unusedPrivateKeyIDs: []string{"key1", "key2"},
-			deleteErrors: map[string]error{
-				"key1": errors.New("delete failed"),
-				"key2": errors.New("another delete failed"),
-			},
-			expectedDeletedKeys: []string{"key1", "key2"},
-		},
-		{
-			name:                "mixed success and failure",
-			unusedPrivateKeyIDs: []string{"success-key", "fail-key", "another-success"},
-			deleteErrors: map[string]error{
-				"fail-key": errors.New("this one fails"),
-			},
-			expectedDeletedKeys: []string{"success-key", "fail-key", "another-success"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create mock client
-			mockClient := &MockFastlyClient{
-				DeletePrivateKeyCalls: []string{}, // Reset calls
-				DeletePrivateKeyFunc: func(ctx context.Context, input *fastly.DeletePrivateKeyInput) error {
-					// Return error if specified for this key
-					if err, exists := tt.deleteErrors[input.ID]; exists {
-						return err
-					}
-					return nil
-				},
-			}
-
-			// Create Logic instance with mock client and observed state
-			logic := &Logic{
-				FastlyClient: mockClient,
-				ObservedState: ObservedState{
-					UnusedPrivateKeyIDs: tt.unusedPrivateKeyIDs,
-				},
-			}
-
-			// Create a mock context with logger
-			ctx := &Context{
-				Log: logr.Discard(),
-			}
-
-			// Call the actual function from fastly.go
-			logic.clearFastlyUnusedPrivateKeys(ctx)
-
-			// Verify the correct delete calls were made
-			if len(mockClient.DeletePrivateKeyCalls) != len(tt.expectedDeletedKeys) {
-				t.Errorf("clearFastlyUnusedPrivateKeys() made %d delete calls, want %d",
-					len(mockClient.DeletePrivateKeyCalls), len(tt.expectedDeletedKeys))
-			}
-
-			// Verify each expected call was made
-			for i, expectedID := range tt.expectedDeletedKeys {
-				if i >= len(mockClient.DeletePrivateKeyCalls) {
-					t.Errorf("clearFastlyUnusedPrivateKeys() missing delete call %d for key %s", i, expectedID)
-				} else if mockClient.DeletePrivateKeyCalls[i] != expectedID {
-					t.Errorf("clearFastlyUnusedPrivateKeys() delete call %d = %s, want %s",
-						i, mockClient.DeletePrivateKeyCalls[i], expectedID)
-				}
-			}
-		})
-	}
-}
-
 func TestLogic_deleteExtraFastlyTLSActivations(t *testing.T) {
 	tests := []struct {
 		name                  string
@@ -553,10 +446,8 @@ func TestLogic_createMissingFastlyTLSActivations(t *testing.T) {
 				},
 			}
 
-			// Create a mock context (function ignores it anyway)
-			ctx := &Context{
-				Log: logr.Discard(),
-			}
+			// Created activations are remembered for the subject
+			ctx := createTestContext()
 
 			// Call the actual function from fastly.go
 			err := logic.createMissingFastlyTLSActivations(ctx)
@@ -2121,7 +2012,7 @@ rI/pIULoTkGajE0uXlIlG0k=
 			ctx.Config.HackFastlyCertificateSyncLocalReconciliation = tt.hackLocalReconciliation
 
 			// Call the function
-			err := logic.createFastlyCertificate(ctx)
+			_, err := logic.createFastlyCertificate(ctx)
 
 			// Check error expectation
 			if tt.expectedError != "" {
@@ -2240,10 +2131,42 @@ rI/pIULoTkGajE0uXlIlG0k=
 		fastlyAPIShouldNotBeCalled    bool                         // If true, fail test if UpdateCustomTLSCertificate is called
 		fastlyAPIError                string                       // If set, return this error from UpdateCustomTLSCertificate
 		hackLocalReconciliation       bool                         // Value for AllowUntrustedRoot
+		untracked                     bool                         // If true, the existing certificate wasn't created by the operator
 		expectedError                 string
 		expectFastlyUpdateCall        bool
 		expectedFastlyUpdateInput     *fastly.UpdateCustomTLSCertificateInput
 	}{
+		{
+			name: "refuses to update a certificate not created by the operator",
+			setupObjects: []client.Object{
+				&cmv1.Certificate{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-certificate",
+						Namespace: "test-namespace",
+					},
+					Spec: cmv1.CertificateSpec{
+						SecretName: "test-secret",
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-secret",
+						Namespace: "test-namespace",
+					},
+					Data: map[string][]byte{
+						"tls.key": []byte(testPrivateKeyPEM),
+						"tls.crt": []byte(testCertPEM),
+					},
+				},
+			},
+			mockExistingFastlyCertificate: &fastly.CustomTLSCertificate{
+				ID:   "existing-cert-123",
+				Name: "test-certificate",
+			},
+			untracked:                  true,
+			fastlyAPIShouldNotBeCalled: true,
+			expectedError:              "refusing to update Fastly certificate test-certificate that was not created by the operator",
+		},
 		{
 			name: "successful certificate update - production mode",
 			setupObjects: []client.Object{
@@ -2505,6 +2428,10 @@ rI/pIULoTkGajE0uXlIlG0k=
 				}
 				return []*fastly.CustomTLSCertificate{}, nil
 			},
+			// The certificate is read again right before it is updated
+			GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+				return mockCert, nil
+			},
 			UpdateCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.UpdateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
 				if shouldNotCallUpdate {
 					t.Error("UpdateCustomTLSCertificate should not be called in this test case")
@@ -2555,6 +2482,10 @@ rI/pIULoTkGajE0uXlIlG0k=
 			}
 			// Set the hack flag for testing AllowUntrustedRoot
 			ctx.Config.HackFastlyCertificateSyncLocalReconciliation = tt.hackLocalReconciliation
+			// The operator tracks the certificates it created
+			if tt.mockExistingFastlyCertificate != nil && !tt.untracked {
+				ctx.Subject.Status.CertificateID = tt.mockExistingFastlyCertificate.ID
+			}
 
 			// Call the function
 			err := logic.updateFastlyCertificate(ctx)
@@ -2738,7 +2669,7 @@ func TestLogic_getFastlyCertificateMatchingSubject(t *testing.T) {
 				},
 			},
 			expectedCertificate:  &fastly.CustomTLSCertificate{ID: "matching-cert", Name: "test-certificate"},
-			expectedPageRequests: 2, // Will request page 2 since page 1 was full, even though match is on page 1
+			expectedPageRequests: 1,
 		},
 		{
 			name: "multiple matching certificates - returns first found",
@@ -2903,6 +2834,196 @@ func TestLogic_getFastlyCertificateMatchingSubject(t *testing.T) {
 	}
 }
 
+func TestLogic_getFastlyCertificateMatchingSubject_OwnedOnLaterPage(t *testing.T) {
+	pages := [][]*fastly.CustomTLSCertificate{
+		generateCertPageWithMatch(1, "bare", "test-certificate"),
+		{{ID: "owned", Name: "k8s-test-certificate"}},
+	}
+	pageRequests := 0
+	logic := &Logic{
+		FastlyClient: &MockFastlyClient{
+			ListCustomTLSCertificatesFunc: func(_ context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+				pageRequests++
+				return pages[input.PageNumber-1], nil
+			},
+		},
+	}
+
+	ctx := newCertificateMatchingTestContext()
+	ctx.Config.FastlyObjectNamePrefix = "k8s-"
+
+	// A bare match doesn't stop the listing, the owned certificate is preferred
+	cert, err := logic.getFastlyCertificateMatchingSubject(ctx)
+	if err != nil {
+		t.Fatalf("getFastlyCertificateMatchingSubject() unexpected error = %v", err)
+	}
+	if cert == nil || cert.ID != "owned" {
+		t.Errorf("getFastlyCertificateMatchingSubject() = %v, want certificate with ID owned", cert)
+	}
+	if pageRequests != 2 {
+		t.Errorf("getFastlyCertificateMatchingSubject() made %d page requests, want 2", pageRequests)
+	}
+}
+
+func TestLogic_getFastlyCertificateMatchingSubject_Snapshot(t *testing.T) {
+	pageRequests := 0
+	logic := &Logic{
+		FastlyClient: &MockFastlyClient{
+			ListCustomTLSCertificatesFunc: func(_ context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+				pageRequests++
+				return []*fastly.CustomTLSCertificate{{ID: "cert-id", Name: "test-certificate"}}, nil
+			},
+		},
+	}
+	ctx := newCertificateMatchingTestContext()
+
+	// The status check, adoption check and activation state of a reconcile share one listing
+	for range 3 {
+		cert, err := logic.getFastlyCertificateMatchingSubject(ctx)
+		if err != nil {
+			t.Fatalf("getFastlyCertificateMatchingSubject() unexpected error = %v", err)
+		}
+		if cert == nil || cert.ID != "cert-id" {
+			t.Errorf("getFastlyCertificateMatchingSubject() = %v, want certificate with ID cert-id", cert)
+		}
+	}
+	if pageRequests != 1 {
+		t.Errorf("getFastlyCertificateMatchingSubject() made %d page requests, want 1", pageRequests)
+	}
+
+	// Changing a certificate drops the snapshot
+	logic.forgetFastlyCertificates()
+	if _, err := logic.getFastlyCertificateMatchingSubject(ctx); err != nil {
+		t.Fatalf("getFastlyCertificateMatchingSubject() unexpected error = %v", err)
+	}
+	if pageRequests != 2 {
+		t.Errorf("getFastlyCertificateMatchingSubject() made %d page requests, want 2", pageRequests)
+	}
+}
+
+func TestLogic_getFastlyCertificateMatchingSubject_MatchStrategy(t *testing.T) {
+	sameName := &fastly.CustomTLSCertificate{ID: "same-name", Name: "test-certificate", SerialNumber: "999"}
+	bySerial := &fastly.CustomTLSCertificate{ID: "by-serial", Name: "other-certificate", SerialNumber: "1"}
+	tracked := &fastly.CustomTLSCertificate{ID: "tracked", Name: "renamed-certificate", SerialNumber: "5"}
+
+	tests := []struct {
+		name       string
+		strategy   v1alpha1.CertificateMatchStrategy
+		certs      []*fastly.CustomTLSCertificate
+		trackedID  string
+		expectedID string
+	}{
+		{name: "name_by_default", certs: []*fastly.CustomTLSCertificate{sameName, bySerial, tracked}, trackedID: "tracked", expectedID: "same-name"},
+		{name: "serial_number", strategy: v1alpha1.CertificateMatchStrategySerialNumber, certs: []*fastly.CustomTLSCertificate{sameName, bySerial, tracked}, trackedID: "tracked", expectedID: "by-serial"},
+		{name: "serial_number_renewed", strategy: v1alpha1.CertificateMatchStrategySerialNumber, certs: []*fastly.CustomTLSCertificate{sameName, tracked}, trackedID: "tracked", expectedID: "tracked"},
+		{name: "serial_number_missing", strategy: v1alpha1.CertificateMatchStrategySerialNumber, certs: []*fastly.CustomTLSCertificate{sameName, tracked}},
+		{name: "fastly_id", strategy: v1alpha1.CertificateMatchStrategyFastlyID, certs: []*fastly.CustomTLSCertificate{sameName, bySerial, tracked}, trackedID: "tracked", expectedID: "tracked"},
+		{name: "fastly_id_until_synced", strategy: v1alpha1.CertificateMatchStrategyFastlyID, certs: []*fastly.CustomTLSCertificate{sameName, bySerial, tracked}, expectedID: "same-name"},
+		{name: "fastly_id_deleted", strategy: v1alpha1.CertificateMatchStrategyFastlyID, certs: []*fastly.CustomTLSCertificate{sameName, bySerial}, trackedID: "tracked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := &Logic{
+				FastlyClient: &MockFastlyClient{
+					ListCustomTLSCertificatesFunc: func(_ context.Context, _ *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+						return tt.certs, nil
+					},
+				},
+			}
+
+			scheme := runtime.NewScheme()
+			_ = cmv1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&cmv1.Certificate{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+					Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+					Data:       map[string][]byte{"tls.crt": createTestCertPEM(t, time.Now().Add(24*time.Hour))},
+				},
+			).Build()
+
+			ctx := createTestContext()
+			ctx.Subject.Spec.MatchStrategy = tt.strategy
+			ctx.Subject.Status.CertificateID = tt.trackedID
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			cert, err := logic.getFastlyCertificateMatchingSubject(ctx)
+			if err != nil {
+				t.Fatalf("getFastlyCertificateMatchingSubject() unexpected error = %v", err)
+			}
+			switch {
+			case tt.expectedID == "" && cert != nil:
+				t.Errorf("getFastlyCertificateMatchingSubject() = %s, want no certificate", cert.ID)
+			case tt.expectedID != "" && (cert == nil || cert.ID != tt.expectedID):
+				t.Errorf("getFastlyCertificateMatchingSubject() = %v, want certificate with ID %s", cert, tt.expectedID)
+			}
+		})
+	}
+}
+
+// newCertificateMatchingTestContext returns a context whose subject references an existing Certificate
+func newCertificateMatchingTestContext() *Context {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&cmv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+	}).Build()
+
+	ctx := createTestContext()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	return ctx
+}
+
+// BenchmarkLogic_getFastlyCertificateMatchingSubject looks up a certificate in an account of about 1000 certificates,
+// reporting the pages listed per lookup
+func BenchmarkLogic_getFastlyCertificateMatchingSubject(b *testing.B) {
+	const accountPages = 50
+
+	for _, matchPage := range []int{1, accountPages / 2, accountPages} {
+		b.Run(fmt.Sprintf("match_on_page_%d", matchPage), func(b *testing.B) {
+			pages := make([][]*fastly.CustomTLSCertificate, accountPages)
+			for i := range pages {
+				pages[i] = generateCertPage(i+1, defaultFastlyPageSize)
+			}
+			pages[matchPage-1] = generateCertPageWithMatch(matchPage, "matching-cert", "test-certificate")
+			pages[accountPages-1] = pages[accountPages-1][1:]
+
+			pageRequests := 0
+			logic := &Logic{
+				FastlyClient: &MockFastlyClient{
+					ListCustomTLSCertificatesFunc: func(_ context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+						pageRequests++
+						return pages[input.PageNumber-1], nil
+					},
+				},
+			}
+			ctx := newCertificateMatchingTestContext()
+
+			b.ResetTimer()
+			for range b.N {
+				logic.ObservedState = ObservedState{}
+				cert, err := logic.getFastlyCertificateMatchingSubject(ctx)
+				if err != nil || cert == nil {
+					b.Fatalf("expected a matching certificate, got %v, %v", cert, err)
+				}
+			}
+			b.ReportMetric(float64(pageRequests)/float64(b.N), "pages/op")
+		})
+	}
+}
+
 func TestLogic_getFastlyTLSActivationState(t *testing.T) {
 	tests := []struct {
 		name                        string
@@ -0,0 +1,137 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	fleetStateTotal     = "total"
+	fleetStateReady     = "ready"
+	fleetStateError     = "error"
+	fleetStateStale     = "stale"
+	fleetStateSuspended = "suspended"
+)
+
+var fleetSyncs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "fastly_certificate_syncs",
+	Help: "FastlyCertificateSyncs in the cluster, in total and by state",
+}, []string{"state"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(fleetSyncs)
+}
+
+// FleetMetrics keeps gauges summarizing every FastlyCertificateSync in the cluster up to date, recounting them from
+// the shared informer whenever one changes. Unlike the per-subject metrics, their cardinality doesn't grow with the
+// fleet.
+type FleetMetrics struct {
+	Cache cache.Cache
+	// Interval is the minimum time between recounts, so that a burst of changes is counted once
+	Interval time.Duration
+}
+
+// fleetTotals are the number of FastlyCertificateSyncs in each state
+type fleetTotals map[string]int
+
+// NeedLeaderElection only counts from the leader, so the gauges aren't reported by each replica
+func (f *FleetMetrics) NeedLeaderElection() bool {
+	return true
+}
+
+// Start recounts the FastlyCertificateSyncs after they change, at most once per interval, until the context is
+// cancelled
+func (f *FleetMetrics) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("fastly-fleet-metrics")
+
+	informer, err := f.Cache.GetInformer(ctx, &v1alpha1.FastlyCertificateSync{})
+	if err != nil {
+		return fmt.Errorf("failed to get FastlyCertificateSync informer: %w", err)
+	}
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	registration, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { notify() },
+		UpdateFunc: func(any, any) { notify() },
+		DeleteFunc: func(any) { notify() },
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch FastlyCertificateSyncs: %w", err)
+	}
+	defer func() { _ = informer.RemoveEventHandler(registration) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+
+		f.recount(ctx, log)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(f.Interval):
+		}
+	}
+}
+
+func (f *FleetMetrics) recount(ctx context.Context, log logr.Logger) {
+	all := v1alpha1.FastlyCertificateSyncList{}
+	if err := f.Cache.List(ctx, &all); err != nil {
+		log.Error(err, "failed to list FastlyCertificateSyncs")
+		return
+	}
+	setFleetMetrics(countFleetTotals(all.Items))
+}
+
+// countFleetTotals counts the subjects in each state. A subject may be in several states, e.g. both stale and
+// failing to sync.
+func countFleetTotals(subjects []v1alpha1.FastlyCertificateSync) fleetTotals {
+	totals := fleetTotals{
+		fleetStateTotal:     len(subjects),
+		fleetStateReady:     0,
+		fleetStateError:     0,
+		fleetStateStale:     0,
+		fleetStateSuspended: 0,
+	}
+
+	for _, subject := range subjects {
+		if subject.Spec.Suspend {
+			totals[fleetStateSuspended]++
+		}
+		if subject.Status.Ready {
+			totals[fleetStateReady]++
+		}
+		if subject.Status.ConsecutiveFailures > 0 || subject.Status.LastError != nil {
+			totals[fleetStateError]++
+		}
+		if condition := subject.Status.GetCondition("CertificateReady"); condition != nil &&
+			condition.Reason == "CertificateStale" {
+			totals[fleetStateStale]++
+		}
+	}
+	return totals
+}
+
+func setFleetMetrics(totals fleetTotals) {
+	for state, n := range totals {
+		fleetSyncs.WithLabelValues(state).Set(float64(n))
+	}
+}
@@ -0,0 +1,77 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCountFleetTotals(t *testing.T) {
+	subjects := []v1alpha1.FastlyCertificateSync{
+		{Status: v1alpha1.FastlyCertificateSyncStatus{Ready: true}},
+		{Status: v1alpha1.FastlyCertificateSyncStatus{Ready: true}},
+		{Spec: v1alpha1.FastlyCertificateSyncSpec{Suspend: true}},
+		{Status: v1alpha1.FastlyCertificateSyncStatus{
+			ConsecutiveFailures: 2,
+			Conditions: []metav1.Condition{
+				{Type: "CertificateReady", Status: metav1.ConditionFalse, Reason: "CertificateStale"},
+			},
+		}},
+		{Status: v1alpha1.FastlyCertificateSyncStatus{LastError: &v1alpha1.FastlyError{Message: "failed"}}},
+	}
+
+	assert.Equal(t, fleetTotals{
+		fleetStateTotal:     5,
+		fleetStateReady:     2,
+		fleetStateError:     2,
+		fleetStateStale:     1,
+		fleetStateSuspended: 1,
+	}, countFleetTotals(subjects))
+
+	assert.Equal(t, fleetTotals{
+		fleetStateTotal:     0,
+		fleetStateReady:     0,
+		fleetStateError:     0,
+		fleetStateStale:     0,
+		fleetStateSuspended: 0,
+	}, countFleetTotals(nil), "every state is reported, even when empty")
+}
+
+// fleetCache serves the informers of a fake cache and lists from a fake client
+type fleetCache struct {
+	*informertest.FakeInformers
+	reader client.Reader
+}
+
+func (c *fleetCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.reader.List(ctx, list, opts...)
+}
+
+func TestFleetMetrics_recount(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	metrics := &FleetMetrics{
+		Cache: &fleetCache{
+			FakeInformers: &informertest.FakeInformers{Scheme: scheme},
+			reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&v1alpha1.FastlyCertificateSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace"},
+				Status:     v1alpha1.FastlyCertificateSyncStatus{Ready: true},
+			}).Build(),
+		},
+	}
+	t.Cleanup(func() { fleetSyncs.Reset() })
+
+	metrics.recount(context.Background(), logr.Discard())
+
+	assert.Equal(t, 1.0, gaugeValue(t, fleetSyncs, fleetStateTotal))
+	assert.Equal(t, 1.0, gaugeValue(t, fleetSyncs, fleetStateReady))
+	assert.Equal(t, 0.0, gaugeValue(t, fleetSyncs, fleetStateError))
+}
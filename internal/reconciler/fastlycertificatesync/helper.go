@@ -3,10 +3,12 @@ package fastlycertificatesync
 import (
 	"crypto"
 	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"time"
 
 	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
@@ -17,29 +19,88 @@ import (
 // Determine if the subject is ready for reconciliation
 // Certificate and Secret must exist
 // Certificate must be in the ready state
-func isSubjectReadyForReconciliation(ctx *Context) bool {
+// When the subject is not ready, the reason and message explain why, passing through cert-manager's own Ready condition
+func isSubjectReadyForReconciliation(ctx *Context) (ready bool, reason, message string) {
+	// Certificates in other namespaces may only be synced when explicitly permitted
+	ref := certificateReference(ctx.Subject)
+	permitted, err := isCertificateReferencePermitted(ctx, ref)
+	if err != nil {
+		return false, "CertificateUnavailable", err.Error()
+	}
+	if !permitted {
+		ctx.Log.Info("cross-namespace Certificate reference is not permitted, we will not reconcile this FastlyCertificateSync", "certificate_name", ref.Name, "certificate_namespace", ref.Namespace)
+		return false, "ReferenceNotPermitted", fmt.Sprintf("Certificate %s/%s may not be referenced from namespace %s, a ReferenceGrant or the operator's allowlist must permit it", ref.Namespace, ref.Name, ctx.Subject.Namespace)
+	}
+
 	var certificate *cmv1.Certificate
-	var err error
-	if certificate, _, err = getCertificateAndTLSSecretFromSubject(ctx); err != nil {
+	var secret *corev1.Secret
+	if certificate, secret, err = getCertificateAndTLSSecretFromSubject(ctx); err != nil {
 		ctx.Log.Info("Certificate and Secret not available, we will not reconcile this FastlyCertificateSync", "name", ctx.Subject.Name, "namespace", ctx.Subject.Namespace)
-		return false
+		return false, "CertificateUnavailable", err.Error()
 	}
 
 	for _, condition := range certificate.Status.Conditions {
-		if condition.Type == cmv1.CertificateConditionReady && condition.Status == cmmetav1.ConditionTrue {
-			return true
+		if condition.Type != cmv1.CertificateConditionReady {
+			continue
+		}
+		if condition.Status == cmmetav1.ConditionTrue {
+			// During renewal the Certificate can report Ready before the Secret holds the new certificate (or vice versa)
+			if err := checkSecretMatchesCertificateStatus(ctx, certificate, secret); err != nil {
+				ctx.Log.Info("Secret does not match Certificate status yet, waiting for cert-manager to catch up", "error", err.Error())
+				return false, "SecretOutOfDate", err.Error()
+			}
+			return true, "CertificateReady", fmt.Sprintf("Certificate %s is ready", certificate.Name)
 		}
+
+		reason, message = condition.Reason, condition.Message
+		if reason == "" {
+			reason = "CertificateNotReady"
+		}
+		return false, reason, message
+	}
+
+	return false, "CertificateNotReady", fmt.Sprintf("Certificate %s does not have a Ready condition yet", certificate.Name)
+}
+
+// checkSecretMatchesCertificateStatus verifies that the certificate held in the secret is the one cert-manager reports
+// in the Certificate status, by comparing the leaf certificate expiry against status.notAfter.
+// Certificates without a reported notAfter are not checked.
+func checkSecretMatchesCertificateStatus(ctx *Context, certificate *cmv1.Certificate, secret *corev1.Secret) error {
+	if certificate.Status.NotAfter == nil {
+		return nil
+	}
+
+	certPEM, err := getSecretCertPEM(ctx, secret)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return fmt.Errorf("failed to decode PEM block of secret %s/%s", secret.Namespace, secret.Name)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate of secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	if !cert.NotAfter.Equal(certificate.Status.NotAfter.Time) {
+		return fmt.Errorf("secret %s/%s holds a certificate expiring at %s, but Certificate %s reports %s",
+			secret.Namespace, secret.Name, cert.NotAfter.UTC().Format(time.RFC3339), certificate.Name, certificate.Status.NotAfter.UTC().Format(time.RFC3339))
 	}
-	return false
+
+	return nil
 }
 
 // Helper function to retrieve the TLS secret from the context.
 // Gets the certificate from the subject reference, and then gets the secret from the certificate reference.
 func getCertificateAndTLSSecretFromSubject(ctx *Context) (*cmv1.Certificate, *corev1.Secret, error) {
 	// get certificate from subject
+	ref := certificateReference(ctx.Subject)
 	certificate := &cmv1.Certificate{}
-	if err := ctx.Client.Client.Get(ctx, types.NamespacedName{Name: ctx.Subject.Spec.CertificateName, Namespace: ctx.Subject.Namespace}, certificate); err != nil {
-		return nil, nil, fmt.Errorf("failed to get certificate of name %s and namespace %s: %w", ctx.Subject.Spec.CertificateName, ctx.Subject.Namespace, err)
+	if err := ctx.Client.Client.Get(ctx, ref, certificate); err != nil {
+		return nil, nil, fmt.Errorf("failed to get certificate of name %s and namespace %s: %w", ref.Name, ref.Namespace, err)
 	}
 
 	// get secret from certificate
@@ -51,43 +112,98 @@ func getCertificateAndTLSSecretFromSubject(ctx *Context) (*cmv1.Certificate, *co
 	return certificate, secret, nil
 }
 
-// getPublicKeySHA1FromPEM calculates the SHA1 hash of the public key derived from a PEM-encoded private key.
-// Supports RSA (PKCS#1), ECDSA (EC PRIVATE KEY or PKCS#8), and PKCS#8 ("PRIVATE KEY") including Ed25519.
+// getPublicKeySHA1FromPEM calculates the SHA1 hash of the public key derived from a PEM-encoded private key
 func getPublicKeySHA1FromPEM(keyPEM []byte) (string, error) {
+	pubKey, err := getPublicKeyFromPEM(keyPEM)
+	if err != nil {
+		return "", err
+	}
+	return getPublicKeySHA1(pubKey)
+}
+
+// getPublicKeyFromPEM derives the public key from a PEM-encoded private key.
+// Supports RSA (PKCS#1), ECDSA (EC PRIVATE KEY or PKCS#8), and PKCS#8 ("PRIVATE KEY") including Ed25519.
+func getPublicKeyFromPEM(keyPEM []byte) (crypto.PublicKey, error) {
 	// Decode the PEM block
 	block, _ := pem.Decode(keyPEM)
 	if block == nil {
-		return "", fmt.Errorf("failed to parse PEM block")
+		return nil, fmt.Errorf("failed to parse PEM block")
 	}
 
-	var pubKey interface{}
+	var pubKey crypto.PublicKey
 	switch block.Type {
 	case "RSA PRIVATE KEY":
 		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse RSA private key: %w", err)
+			return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
 		}
 		pubKey = &priv.PublicKey
 	case "EC PRIVATE KEY":
 		priv, err := x509.ParseECPrivateKey(block.Bytes)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse EC private key: %w", err)
+			return nil, fmt.Errorf("failed to parse EC private key: %w", err)
 		}
 		pubKey = &priv.PublicKey
 	case "PRIVATE KEY":
 		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse PKCS#8 private key: %w", err)
+			return nil, fmt.Errorf("failed to parse PKCS#8 private key: %w", err)
 		}
 		signer, ok := priv.(crypto.Signer)
 		if !ok {
-			return "", fmt.Errorf("unsupported private key type in PKCS#8: %T", priv)
+			return nil, fmt.Errorf("unsupported private key type in PKCS#8: %T", priv)
 		}
 		pubKey = signer.Public()
 	default:
-		return "", fmt.Errorf("unsupported PEM block type %q (expected RSA PRIVATE KEY, EC PRIVATE KEY, or PRIVATE KEY)", block.Type)
+		return nil, fmt.Errorf("unsupported PEM block type %q (expected RSA PRIVATE KEY, EC PRIVATE KEY, or PRIVATE KEY)", block.Type)
+	}
+
+	return pubKey, nil
+}
+
+// getPublicKeySHA1FromCertificatePEM calculates the SHA1 hash of the public key of the leaf certificate in a
+// PEM-encoded chain, which matches that of the certificate's private key
+func getPublicKeySHA1FromCertificatePEM(certPEM []byte) (string, error) {
+	pubKey, err := getPublicKeyFromCertificatePEM(certPEM)
+	if err != nil {
+		return "", err
+	}
+	return getPublicKeySHA1(pubKey)
+}
+
+// getPublicKeyFromCertificatePEM returns the public key of the leaf certificate in a PEM-encoded chain
+func getPublicKeyFromCertificatePEM(certPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse PEM block")
 	}
 
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return cert.PublicKey, nil
+}
+
+// getSerialNumberFromCertificatePEM returns the serial number of the leaf certificate in a PEM-encoded chain, in the
+// decimal form Fastly reports it in
+func getSerialNumberFromCertificatePEM(certPEM []byte) (string, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return "", fmt.Errorf("failed to decode PEM block")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return cert.SerialNumber.String(), nil
+}
+
+// getPublicKeySHA1 calculates the SHA1 hash of a PEM-encoded public key, the way Fastly reports it for private keys
+func getPublicKeySHA1(pubKey crypto.PublicKey) (string, error) {
 	// Marshal the public key to DER format
 	pubKeyBytes, err := x509.MarshalPKIXPublicKey(pubKey)
 	if err != nil {
@@ -109,13 +225,44 @@ func getPublicKeySHA1FromPEM(keyPEM []byte) (string, error) {
 	return sha1String, nil
 }
 
+// getPublicKeySHA256 calculates the SHA256 hash of the DER encoded public key, the fingerprint that tools such as
+// openssl report for it. Fastly doesn't report it, it is only kept alongside the SHA1 for reporting.
+func getPublicKeySHA256(pubKey crypto.PublicKey) (string, error) {
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %v", err)
+	}
+
+	hash := sha256.Sum256(pubKeyBytes)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// publicKeyFingerprints identifies the public key of a private key by the SHA1 that Fastly reports, which private keys
+// in Fastly are matched by, and by its SHA256, which double checks it in status and logs for compliance reporting
+type publicKeyFingerprints struct {
+	SHA1   string
+	SHA256 string
+}
+
+func getPublicKeyFingerprints(pubKey crypto.PublicKey) (publicKeyFingerprints, error) {
+	sha1Hash, err := getPublicKeySHA1(pubKey)
+	if err != nil {
+		return publicKeyFingerprints{}, err
+	}
+	sha256Hash, err := getPublicKeySHA256(pubKey)
+	if err != nil {
+		return publicKeyFingerprints{}, err
+	}
+	return publicKeyFingerprints{SHA1: sha1Hash, SHA256: sha256Hash}, nil
+}
+
 // get the certPEM byte slice for the given secret.
 // abstract away the details around local reconciliation vs. trusted issuers.
 func getCertPEMForSecret(ctx *Context, secret *corev1.Secret) ([]byte, error) {
 	// Get certificate details from secret
-	certPEM, ok := secret.Data["tls.crt"]
-	if !ok {
-		return nil, fmt.Errorf("secret %s/%s does not contain tls.crt", secret.Namespace, secret.Name)
+	certPEM, err := getSecretCertPEM(ctx, secret)
+	if err != nil {
+		return nil, err
 	}
 
 	// in a local environment, we need to provide the entire chain of trust and append caCertPEM details to the certPEM
@@ -123,12 +270,54 @@ func getCertPEMForSecret(ctx *Context, secret *corev1.Secret) ([]byte, error) {
 	if ctx.Config.HackFastlyCertificateSyncLocalReconciliation {
 		ctx.Log.Info("local environment detected, appending root CA details")
 		// Attempt to get the root CA certificate details from the secret, if required.
-		// We cannot proceed if this is not present when in our local reconciliation mode.
-		caCertPEM, ok := secret.Data["ca.crt"]
-		if !ok {
-			return nil, fmt.Errorf("secret %s/%s does not contain ca.crt", secret.Namespace, secret.Name)
+		// We cannot proceed if this is not present when in our local reconciliation mode, unless told to upload the
+		// certificate on its own instead.
+		if ctx.Config.LocalReconciliationAllowMissingCA && !hasSecretCA(ctx, secret) {
+			ctx.Log.Info("secret holds no CA certificate, uploading the certificate without it", "secret", secret.Name)
+			return certPEM, nil
+		}
+		caCertPEM, err := getSecretCAPEM(ctx, secret)
+		if err != nil {
+			return nil, err
 		}
 		certPEM = append(certPEM, caCertPEM...)
 	}
 	return certPEM, nil
 }
+
+// getExpectedPublicKeyFingerprints returns the fingerprints of the public key that the private key in Fastly must
+// match. Externally managed private keys are never read, their fingerprints are taken from the certificate instead,
+// with the SHA1 from the spec when it is set.
+func getExpectedPublicKeyFingerprints(ctx *Context, secret *corev1.Secret) (publicKeyFingerprints, error) {
+	if !ctx.Subject.IsPrivateKeyExternal() {
+		keyPEM, err := openSecretKeyPEM(ctx, secret)
+		if err != nil {
+			return publicKeyFingerprints{}, err
+		}
+		defer keyPEM.wipe()
+
+		pubKey, err := getPublicKeyFromPEM(keyPEM)
+		if err != nil {
+			return publicKeyFingerprints{}, err
+		}
+		return getPublicKeyFingerprints(pubKey)
+	}
+
+	certPEM, err := getSecretCertPEM(ctx, secret)
+	if err != nil {
+		return publicKeyFingerprints{}, err
+	}
+	pubKey, err := getPublicKeyFromCertificatePEM(certPEM)
+	if err != nil {
+		return publicKeyFingerprints{}, err
+	}
+	fingerprints, err := getPublicKeyFingerprints(pubKey)
+	if err != nil {
+		return publicKeyFingerprints{}, err
+	}
+
+	if sha1Hash := ctx.Subject.Spec.PrivateKeyPublicKeySHA1; sha1Hash != "" {
+		fingerprints.SHA1 = sha1Hash
+	}
+	return fingerprints, nil
+}
@@ -3,9 +3,17 @@ package fastlycertificatesync
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
+	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
@@ -348,7 +356,7 @@ func TestIsSubjectReadyForReconciliation(t *testing.T) {
 			}
 
 			// Call the actual function under test
-			result := isSubjectReadyForReconciliation(ctx)
+			result, _, _ := isSubjectReadyForReconciliation(ctx)
 
 			// Check the result
 			if result != tt.expectedResult {
@@ -358,6 +366,130 @@ func TestIsSubjectReadyForReconciliation(t *testing.T) {
 	}
 }
 
+func TestIsSubjectReadyForReconciliation_Reason(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+				Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+				Status: cmv1.CertificateStatus{
+					Conditions: []cmv1.CertificateCondition{
+						{
+							Type:    cmv1.CertificateConditionReady,
+							Status:  cmmetav1.ConditionFalse,
+							Reason:  "Issuing",
+							Message: "Issuing certificate as Secret does not exist",
+						},
+					},
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data:       map[string][]byte{"tls.crt": []byte("test-cert-data"), "tls.key": []byte("test-key-data")},
+			},
+		).
+		Build()
+
+	ctx := createTestContext()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	ready, reason, message := isSubjectReadyForReconciliation(ctx)
+	if ready {
+		t.Errorf("isSubjectReadyForReconciliation() = true, want false")
+	}
+	if reason != "Issuing" {
+		t.Errorf("isSubjectReadyForReconciliation() reason = %q, want %q", reason, "Issuing")
+	}
+	if message != "Issuing certificate as Secret does not exist" {
+		t.Errorf("isSubjectReadyForReconciliation() message = %q, want cert-manager's message", message)
+	}
+}
+
+// Helper to create a self-signed PEM certificate expiring at notAfter
+func createTestCertPEM(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCheckSecretMatchesCertificateStatus(t *testing.T) {
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		notAfter    *metav1.Time
+		certData    []byte
+		expectError bool
+	}{
+		{
+			name:        "no_not_after_in_status",
+			notAfter:    nil,
+			certData:    []byte("test-cert-data"),
+			expectError: false,
+		},
+		{
+			name:        "secret_matches_status",
+			notAfter:    &metav1.Time{Time: notAfter},
+			certData:    createTestCertPEM(t, notAfter),
+			expectError: false,
+		},
+		{
+			name:        "secret_holds_previous_certificate",
+			notAfter:    &metav1.Time{Time: notAfter.Add(90 * 24 * time.Hour)},
+			certData:    createTestCertPEM(t, notAfter),
+			expectError: true,
+		},
+		{
+			name:        "secret_holds_invalid_certificate",
+			notAfter:    &metav1.Time{Time: notAfter},
+			certData:    []byte("test-cert-data"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certificate := &cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+				Status:     cmv1.CertificateStatus{NotAfter: tt.notAfter},
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data:       map[string][]byte{"tls.crt": tt.certData},
+			}
+
+			err := checkSecretMatchesCertificateStatus(createTestContext(), certificate, secret)
+			if (err != nil) != tt.expectError {
+				t.Errorf("checkSecretMatchesCertificateStatus() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
 func TestGetCertificateAndTLSSecretFromSubject(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -628,6 +760,7 @@ MIICACertificateDataHere
 		name                    string
 		secret                  *corev1.Secret
 		hackLocalReconciliation bool   // Value for HackFastlyCertificateSyncLocalReconciliation
+		allowMissingCA          bool   // Value for LocalReconciliationAllowMissingCA
 		expectedPEM             []byte // Expected returned PEM data
 		expectedError           string // Expected error message substring (empty if no error expected)
 		description             string // Test case description
@@ -731,6 +864,23 @@ MIICACertificateDataHere
 			expectedError:           "secret test-namespace/test-secret does not contain ca.crt",
 			description:             "Should return error when ca.crt is missing in local mode",
 		},
+		{
+			name: "local_mode_missing_ca_crt_allowed",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "test-namespace",
+				},
+				Data: map[string][]byte{
+					"tls.crt": dummyCertPEM,
+					"tls.key": []byte("dummy-key-data"),
+				},
+			},
+			hackLocalReconciliation: true,
+			allowMissingCA:          true,
+			expectedPEM:             dummyCertPEM,
+			description:             "Should upload the certificate on its own when ca.crt is missing and allowed to be",
+		},
 		{
 			name: "empty_secret_data_production_mode",
 			secret: &corev1.Secret{
@@ -810,6 +960,7 @@ MIICACertificateDataHere
 			// Create test context
 			ctx := createTestContext()
 			ctx.Config.HackFastlyCertificateSyncLocalReconciliation = tt.hackLocalReconciliation
+			ctx.Config.LocalReconciliationAllowMissingCA = tt.allowMissingCA
 
 			// Call the function under test
 			result, err := getCertPEMForSecret(ctx, tt.secret)
@@ -1234,3 +1385,95 @@ YWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXo=
 		}
 	})
 }
+
+func TestGetExpectedPublicKeyFingerprints(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	keySHA1, err := getPublicKeySHA1FromPEM(keyPEM)
+	if err != nil {
+		t.Fatalf("failed to get public key SHA1 of private key: %v", err)
+	}
+	spkiDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	spkiSHA256 := sha256.Sum256(spkiDER)
+	keySHA256 := hex.EncodeToString(spkiSHA256[:])
+
+	tests := []struct {
+		name          string
+		management    v1alpha1.PrivateKeyManagement
+		specSHA1      string
+		secretData    map[string][]byte
+		expectedSHA1  string
+		errorContains string
+	}{
+		{
+			name:         "operator_reads_private_key",
+			secretData:   map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM},
+			expectedSHA1: keySHA1,
+		},
+		{
+			name:          "operator_requires_private_key",
+			secretData:    map[string][]byte{"tls.crt": certPEM},
+			errorContains: "does not contain tls.key",
+		},
+		{
+			name:         "external_derives_sha1_from_certificate",
+			management:   v1alpha1.PrivateKeyManagementExternal,
+			secretData:   map[string][]byte{"tls.crt": certPEM},
+			expectedSHA1: keySHA1,
+		},
+		{
+			name:         "external_prefers_sha1_from_spec",
+			management:   v1alpha1.PrivateKeyManagementExternal,
+			specSHA1:     "1ccf8849ae82aaab5749d5c791a221354f182a73",
+			secretData:   map[string][]byte{"tls.crt": certPEM, "tls.key": []byte("never read")},
+			expectedSHA1: "1ccf8849ae82aaab5749d5c791a221354f182a73",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Subject.Spec.PrivateKeyManagement = tt.management
+			ctx.Subject.Spec.PrivateKeyPublicKeySHA1 = tt.specSHA1
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data:       tt.secretData,
+			}
+
+			fingerprints, err := getExpectedPublicKeyFingerprints(ctx, secret)
+			if tt.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("getExpectedPublicKeyFingerprints() error = %v, want error containing %q", err, tt.errorContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getExpectedPublicKeyFingerprints() unexpected error = %v", err)
+			}
+			if fingerprints.SHA1 != tt.expectedSHA1 {
+				t.Errorf("getExpectedPublicKeyFingerprints() SHA1 = %q, want %q", fingerprints.SHA1, tt.expectedSHA1)
+			}
+			// The SHA256 is always that of the key itself, a SHA1 from the spec only overrides matching
+			if fingerprints.SHA256 != keySHA256 {
+				t.Errorf("getExpectedPublicKeyFingerprints() SHA256 = %q, want %q", fingerprints.SHA256, keySHA256)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package fastlycertificatesync
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+// Limits on the blobs sent to Fastly. Fastly rejects oversized or malformed blobs with an opaque 400, so they are
+// checked up front to attribute the failure to the source Secret instead.
+const (
+	maxCertificateBlobSize      = 64 * 1024
+	maxCertificateBlobPEMBlocks = 10
+	maxPrivateKeyBlobSize       = 16 * 1024
+)
+
+// privateKeyPEMTypes are the PEM block types accepted as a private key
+var privateKeyPEMTypes = []string{"PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY"}
+
+// validateFastlyInput checks that the certificate chain and private key held by the source Secret, and the names they
+// are given, would be accepted by Fastly. It returns why Fastly would reject them, or an error when the Secret couldn't
+// be read, which is retried.
+func validateFastlyInput(ctx *Context) (string, error) {
+	if invalid := validateNameTemplate(ctx); invalid != "" {
+		return invalid, nil
+	}
+
+	_, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	certPEM, err := getCertPEMForSecret(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+	if err := validateCertificateBlob(certPEM); err != nil {
+		return fmt.Sprintf("certificate of secret %s/%s is invalid: %v", secret.Namespace, secret.Name, err), nil
+	}
+
+	// Externally managed private keys are never read
+	if ctx.Subject.IsPrivateKeyExternal() {
+		return "", nil
+	}
+
+	keyPEM, err := openSecretKeyPEM(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+	defer keyPEM.wipe()
+	if err := validatePrivateKeyBlob(keyPEM); err != nil {
+		return fmt.Sprintf("private key of secret %s/%s is invalid: %v", secret.Namespace, secret.Name, err), nil
+	}
+
+	return "", nil
+}
+
+// validateCertificateBlob checks a PEM encoded certificate chain against Fastly's limits
+func validateCertificateBlob(blob []byte) error {
+	blocks, err := decodePEMBlob(blob, maxCertificateBlobSize)
+	if err != nil {
+		return err
+	}
+
+	if len(blocks) > maxCertificateBlobPEMBlocks {
+		return fmt.Errorf("contains %d PEM blocks, at most %d are allowed", len(blocks), maxCertificateBlobPEMBlocks)
+	}
+	for i, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("PEM block %d is a %s, expected a CERTIFICATE", i, block.Type)
+		}
+	}
+
+	return nil
+}
+
+// validatePrivateKeyBlob checks a PEM encoded private key against Fastly's limits
+func validatePrivateKeyBlob(blob []byte) error {
+	blocks, err := decodePEMBlob(blob, maxPrivateKeyBlobSize)
+	if err != nil {
+		return err
+	}
+
+	if len(blocks) != 1 {
+		return fmt.Errorf("contains %d PEM blocks, expected exactly one", len(blocks))
+	}
+	if !slices.Contains(privateKeyPEMTypes, blocks[0].Type) {
+		return fmt.Errorf("PEM block is a %s, expected a private key", blocks[0].Type)
+	}
+
+	return nil
+}
+
+// decodePEMBlob decodes every PEM block of the blob, rejecting it when it is oversized, isn't valid UTF-8, or holds
+// anything besides PEM blocks
+func decodePEMBlob(blob []byte, maxSize int) ([]*pem.Block, error) {
+	if len(blob) > maxSize {
+		return nil, fmt.Errorf("is %d bytes, at most %d are allowed", len(blob), maxSize)
+	}
+	if !utf8.Valid(blob) {
+		return nil, fmt.Errorf("contains non UTF-8 content")
+	}
+
+	var blocks []*pem.Block
+	rest := bytes.TrimSpace(blob)
+	for len(rest) > 0 {
+		// pem.Decode silently skips anything before a block, which Fastly would reject
+		if !bytes.HasPrefix(rest, []byte("-----BEGIN ")) {
+			return nil, fmt.Errorf("contains data outside of PEM blocks")
+		}
+
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("contains malformed PEM data")
+		}
+		blocks = append(blocks, block)
+		rest = bytes.TrimSpace(rest)
+	}
+
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("contains no PEM blocks")
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,108 @@
+package fastlycertificatesync
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateCertificateBlob(t *testing.T) {
+	certPEM := createTestCertPEM(t, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name          string
+		blob          []byte
+		expectedError string
+	}{
+		{name: "single_certificate", blob: certPEM},
+		{name: "chain_with_surrounding_whitespace", blob: append(append([]byte("\n"), bytes.Repeat(certPEM, 3)...), '\n')},
+		{name: "empty", blob: nil, expectedError: "contains no PEM blocks"},
+		{name: "too_large", blob: make([]byte, maxCertificateBlobSize+1), expectedError: "at most 65536 are allowed"},
+		{name: "non_utf8", blob: append([]byte{0xff, 0xfe}, certPEM...), expectedError: "contains non UTF-8 content"},
+		{name: "leading_garbage", blob: append([]byte("subject=CN=example.com\n"), certPEM...), expectedError: "contains data outside of PEM blocks"},
+		{name: "truncated", blob: certPEM[:len(certPEM)-30], expectedError: "contains malformed PEM data"},
+		{name: "too_many_blocks", blob: bytes.Repeat(certPEM, maxCertificateBlobPEMBlocks+1), expectedError: "contains 11 PEM blocks"},
+		{name: "private_key_in_chain", blob: append(certPEM, []byte(testPKCS12PrivateKeyPEM)...), expectedError: "PEM block 1 is a EC PRIVATE KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCertificateBlob(tt.blob)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidatePrivateKeyBlob(t *testing.T) {
+	tests := []struct {
+		name          string
+		blob          []byte
+		expectedError string
+	}{
+		{name: "ec_private_key", blob: []byte(testPKCS12PrivateKeyPEM)},
+		{name: "two_private_keys", blob: []byte(testPKCS12PrivateKeyPEM + "\n" + testPKCS12PrivateKeyPEM), expectedError: "contains 2 PEM blocks"},
+		{name: "certificate", blob: createTestCertPEM(t, time.Now().Add(time.Hour)), expectedError: "PEM block is a CERTIFICATE"},
+		{name: "too_large", blob: make([]byte, maxPrivateKeyBlobSize+1), expectedError: "at most 16384 are allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePrivateKeyBlob(tt.blob)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateFastlyInput(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+			Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+			Data: map[string][]byte{
+				"tls.crt": createTestCertPEM(t, time.Now().Add(time.Hour)),
+				"tls.key": []byte("not a key"),
+			},
+		},
+	).Build()
+
+	ctx := createTestContext()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	invalidInput, err := validateFastlyInput(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "private key of secret test-namespace/test-secret is invalid: contains data outside of PEM blocks", invalidInput)
+
+	// Failing to read the Secret is not invalid input, it is returned to be retried
+	require.NoError(t, fakeClient.Delete(context.Background(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"}}))
+	invalidInput, err = validateFastlyInput(ctx)
+	assert.Error(t, err)
+	assert.Empty(t, invalidInput)
+}
@@ -0,0 +1,211 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newInterruptedSyncTestContext creates a context syncing a certificate for two domains onto two TLS configurations
+func newInterruptedSyncTestContext(t testing.TB) *Context {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		DNSNames:     []string{"www.example.com", "api.example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.TLSConfigurationIds = []string{"config1", "config2"}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			ctx.Subject,
+			&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+				Spec:       cmv1.CertificateSpec{SecretName: "test-secret", DNSNames: template.DNSNames},
+				Status: cmv1.CertificateStatus{
+					Conditions: []cmv1.CertificateCondition{{Type: cmv1.CertificateConditionReady, Status: cmmetav1.ConditionTrue}},
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data: map[string][]byte{
+					"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+					"tls.key": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+				},
+			},
+		).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	return ctx
+}
+
+// syncFastly observes and applies changes to Fastly, as consecutive reconciles of a single operator do, until Fastly
+// is in sync
+func syncFastly(ctx *Context, logic *Logic) error {
+	for range 10 {
+		logic.ObservedState = ObservedState{}
+		if err := logic.observeFastly(ctx); err != nil {
+			return err
+		}
+		// The sync is completed by applying once nothing is left to change
+		synced := logic.ObservedState.isSynced()
+		if err := logic.applyFastlyChanges(ctx); err != nil {
+			return err
+		}
+		if synced {
+			return nil
+		}
+	}
+	return fmt.Errorf("not in sync with Fastly after 10 reconciles, pending %v", logic.ObservedState.pendingMutations())
+}
+
+// Kills the operator between the steps of a sync, once a write took effect in Fastly but before the operator learned
+// about it, and checks that a fresh operator picks up where it left off without duplicating anything
+func TestLogic_InterruptedSyncConverges(t *testing.T) {
+	tests := []struct {
+		name             string
+		kill             string
+		keys, certs, act int
+	}{
+		{name: "private_key_uploaded_certificate_missing", kill: "CreatePrivateKey", keys: 1},
+		{name: "certificate_created_without_activations", kill: "CreateCustomTLSCertificate", keys: 1, certs: 1},
+		{name: "activations_partially_created", kill: "CreateTLSActivation", keys: 1, certs: 1, act: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := &fakeFastlyAccount{kill: tt.kill}
+			ctx := newInterruptedSyncTestContext(t)
+
+			err := syncFastly(ctx, &Logic{FastlyClient: account.client(t)})
+			require.ErrorIs(t, err, errOperatorKilled)
+			assert.Len(t, account.privateKeys, tt.keys)
+			assert.Len(t, account.certificates, tt.certs)
+			assert.Len(t, account.activations, tt.act)
+
+			// Nothing the killed operator held in memory survives, only what it wrote to Kubernetes
+			account.restart()
+			subject := &v1alpha1.FastlyCertificateSync{}
+			require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+			ctx.Subject = subject
+			require.NoError(t, syncFastly(ctx, &Logic{FastlyClient: account.client(t)}))
+
+			require.Len(t, account.privateKeys, 1, "the private key is not uploaded again")
+			require.Len(t, account.certificates, 1, "the certificate is not created again")
+			cert := account.certificates[0]
+			assert.Equal(t, "42", cert.SerialNumber)
+
+			activated := map[string]bool{}
+			for _, activation := range account.activations {
+				assert.Equal(t, cert.ID, activation.Certificate.ID)
+				key := activation.Domain.ID + "/" + activation.Configuration.ID
+				assert.False(t, activated[key], "%s is activated more than once", key)
+				activated[key] = true
+			}
+			assert.Equal(t, map[string]bool{
+				"www.example.com/config1": true,
+				"www.example.com/config2": true,
+				"api.example.com/config1": true,
+				"api.example.com/config2": true,
+			}, activated)
+		})
+	}
+}
+
+// renewInterruptedSyncCertificate renews the certificate in the Secret, keeping its private key
+func renewInterruptedSyncCertificate(t testing.TB, ctx *Context) {
+	secret := &corev1.Secret{}
+	require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKey{Name: "test-secret", Namespace: "test-namespace"}, secret))
+	block, _ := pem.Decode(secret.Data["tls.key"])
+	require.NotNil(t, block)
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(43),
+		NotAfter:     time.Now().Add(48 * time.Hour),
+		DNSNames:     []string{"www.example.com", "api.example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	secret.Data["tls.crt"] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	require.NoError(t, ctx.Client.Client.Update(ctx, secret))
+}
+
+// Kills the operator once it renamed the certificate it rotates out, and checks that the renamed certificate was
+// already tracked as the previous one, so that a fresh operator moves its TLS activations over rather than losing it
+func TestLogic_InterruptedRotationConverges(t *testing.T) {
+	account := &fakeFastlyAccount{}
+	ctx := newInterruptedSyncTestContext(t)
+	subject := &v1alpha1.FastlyCertificateSync{}
+	require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+	subject.Spec.PreviousCertificateGracePeriod = &metav1.Duration{Duration: time.Hour}
+	require.NoError(t, ctx.Client.Client.Update(ctx, subject))
+	ctx.Subject = subject
+	require.NoError(t, syncFastly(ctx, &Logic{FastlyClient: account.client(t)}))
+	require.Len(t, account.certificates, 1)
+	rotated := account.certificates[0]
+
+	renewInterruptedSyncCertificate(t, ctx)
+	account.kill = "RenameCustomTLSCertificate"
+	err := syncFastly(ctx, &Logic{FastlyClient: account.client(t)})
+	require.ErrorIs(t, err, errOperatorKilled)
+	require.Len(t, account.certificates, 1, "killed before the renewed certificate was created")
+	assert.Equal(t, rotated.Name+previousFastlyCertificateSuffix, account.certificates[0].Name)
+
+	account.restart()
+	subject = &v1alpha1.FastlyCertificateSync{}
+	require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+	require.NotNil(t, subject.Status.PreviousCertificate, "the renamed certificate is tracked before it is renamed")
+	assert.Equal(t, rotated.ID, subject.Status.PreviousCertificate.CertificateID)
+	ctx.Subject = subject
+	require.NoError(t, syncFastly(ctx, &Logic{FastlyClient: account.client(t)}))
+
+	require.Len(t, account.certificates, 2, "the previous certificate is kept alongside the renewed one")
+	renewed := account.certificates[1]
+	assert.Equal(t, "43", renewed.SerialNumber)
+	assert.Equal(t, rotated.Name, renewed.Name)
+	assert.Equal(t, rotated.ID, ctx.Subject.Status.PreviousCertificate.CertificateID)
+	assert.Equal(t, renewed.ID, ctx.Subject.Status.CertificateID)
+	require.Len(t, account.activations, 4, "the TLS activations are moved, not created again")
+	for _, activation := range account.activations {
+		assert.Equal(t, renewed.ID, activation.Certificate.ID, "%s/%s is moved to the renewed certificate",
+			activation.Domain.ID, activation.Configuration.ID)
+	}
+}
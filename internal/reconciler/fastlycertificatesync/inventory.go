@@ -0,0 +1,272 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fastlyInventory is the listing of the certificates and private keys of a single Fastly account
+type fastlyInventory struct {
+	privateKeys  []*fastly.PrivateKey
+	certificates []*fastly.CustomTLSCertificate
+}
+
+// fastlyInventoryCache shares the inventory of each Fastly account across reconciles.
+//
+// Observing a subject lists every certificate and private key in its account. Without this cache, a full resync of
+// N subjects, e.g. right after the operator starts, pages through the whole account N times.
+type fastlyInventoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*fastlyInventoryEntry
+	now     func() time.Time
+}
+
+// fastlyInventoryEntry holds the inventory of one account. Its lock is held while the inventory is fetched, so that
+// concurrent reconciles of the same account wait for a single listing.
+type fastlyInventoryEntry struct {
+	mu        sync.Mutex
+	inventory *fastlyInventory
+	fetchedAt time.Time
+}
+
+func (c *fastlyInventoryCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Load returns the inventory of the given account if it was fetched within the ttl, and fetches it otherwise
+func (c *fastlyInventoryCache) Load(account string, ttl time.Duration, fetch func() (*fastlyInventory, error)) (*fastlyInventory, error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]*fastlyInventoryEntry{}
+	}
+	entry, ok := c.entries[account]
+	if !ok {
+		entry = &fastlyInventoryEntry{}
+		c.entries[account] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.inventory != nil && c.clock().Sub(entry.fetchedAt) < ttl {
+		return entry.inventory, nil
+	}
+
+	inventory, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	entry.inventory = inventory
+	entry.fetchedAt = c.clock()
+	return inventory, nil
+}
+
+// Forget drops the inventory of the given account, after the operator changed it. A fetch that is in progress is
+// not stored either, it may predate the change.
+func (c *fastlyInventoryCache) Forget(account string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, account)
+}
+
+// Update applies a change the operator made to the account onto its cached inventory, in place of forgetting it. The
+// next reconcile then sees the change without listing the account again, which Fastly may not reflect yet anyway.
+// Accounts without a cached inventory are left alone.
+func (c *fastlyInventoryCache) Update(account string, update func(inventory *fastlyInventory)) {
+	c.mu.Lock()
+	entry := c.entries[account]
+	c.mu.Unlock()
+	if entry == nil {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.inventory == nil {
+		return
+	}
+	// Inventories already handed out are shared with other reconciles, so the change is made to a copy
+	inventory := &fastlyInventory{
+		privateKeys:  slices.Clone(entry.inventory.privateKeys),
+		certificates: slices.Clone(entry.inventory.certificates),
+	}
+	update(inventory)
+	entry.inventory = inventory
+}
+
+// putPrivateKey adds the private key to the inventory, or replaces the one with the same ID
+func (i *fastlyInventory) putPrivateKey(key *fastly.PrivateKey) {
+	if idx := slices.IndexFunc(i.privateKeys, func(k *fastly.PrivateKey) bool { return k.ID == key.ID }); idx >= 0 {
+		i.privateKeys[idx] = key
+		return
+	}
+	i.privateKeys = append(i.privateKeys, key)
+}
+
+// putCertificate adds the certificate to the inventory, or replaces the one with the same ID
+func (i *fastlyInventory) putCertificate(cert *fastly.CustomTLSCertificate) {
+	if idx := slices.IndexFunc(i.certificates, func(c *fastly.CustomTLSCertificate) bool { return c.ID == cert.ID }); idx >= 0 {
+		i.certificates[idx] = cert
+		return
+	}
+	i.certificates = append(i.certificates, cert)
+}
+
+// listFastlyInventory lists every certificate and private key in the account
+func listFastlyInventory(ctx context.Context, fastlyClient FastlyClientInterface) (*fastlyInventory, error) {
+	privateKeys, err := listFastlyPrivateKeys(ctx, fastlyClient)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := listFastlyCertificates(ctx, fastlyClient)
+	if err != nil {
+		return nil, err
+	}
+	return &fastlyInventory{privateKeys: privateKeys, certificates: certificates}, nil
+}
+
+func listFastlyPrivateKeys(ctx context.Context, fastlyClient FastlyClientInterface) ([]*fastly.PrivateKey, error) {
+	privateKeys, err := listFastlyPages(func(page int) ([]*fastly.PrivateKey, error) {
+		return fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly private keys: %w", err)
+	}
+	return privateKeys, nil
+}
+
+func listFastlyCertificates(ctx context.Context, fastlyClient FastlyClientInterface) ([]*fastly.CustomTLSCertificate, error) {
+	certificates, err := listFastlyPages(func(page int) ([]*fastly.CustomTLSCertificate, error) {
+		return fastlyClient.ListCustomTLSCertificates(ctx, &fastly.ListCustomTLSCertificatesInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly certificates: %w", err)
+	}
+	return certificates, nil
+}
+
+// getFastlyPrivateKeys returns every private key in the current account, from the cache when it is enabled and fresh
+func (l *Logic) getFastlyPrivateKeys(ctx *Context) ([]*fastly.PrivateKey, error) {
+	if ctx.Config.FastlyInventoryCacheTTL <= 0 {
+		return listFastlyPrivateKeys(ctx, l.fastlyClient())
+	}
+	inventory, err := l.getFastlyInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return inventory.privateKeys, nil
+}
+
+// getFastlyCertificates returns every certificate in the current account, from the cache when it is enabled and fresh
+func (l *Logic) getFastlyCertificates(ctx *Context) ([]*fastly.CustomTLSCertificate, error) {
+	if ctx.Config.FastlyInventoryCacheTTL <= 0 {
+		return listFastlyCertificates(ctx, l.fastlyClient())
+	}
+	inventory, err := l.getFastlyInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return inventory.certificates, nil
+}
+
+func (l *Logic) getFastlyInventory(ctx *Context) (*fastlyInventory, error) {
+	return l.inventoryCache().Load(l.fastlyAccount(), ctx.Config.FastlyInventoryCacheTTL, func() (*fastlyInventory, error) {
+		return listFastlyInventory(ctx, l.fastlyClient())
+	})
+}
+
+// forgetFastlyInventory drops the cached inventory of the current account, after a certificate or private key in it
+// was created, changed or deleted
+func (l *Logic) forgetFastlyInventory() {
+	l.inventoryCache().Forget(l.fastlyAccount())
+}
+
+// recordFastlyPrivateKey keeps the private key Fastly returned for an upload in the cached inventory of the current
+// account, so that the follow-up reconcile finds it without listing the account. Incomplete responses drop the
+// inventory instead.
+func (l *Logic) recordFastlyPrivateKey(key *fastly.PrivateKey) {
+	if key == nil || key.ID == "" || key.PublicKeySHA1 == "" {
+		l.forgetFastlyInventory()
+		return
+	}
+	l.inventoryCache().Update(l.fastlyAccount(), func(inventory *fastlyInventory) {
+		inventory.putPrivateKey(key)
+	})
+}
+
+// recordFastlyCertificate keeps the certificate Fastly returned for a create or update in the cached inventory of the
+// current account, so that the follow-up reconcile verifies it without listing the account. Responses lacking what
+// the certificate is matched and activated by drop the inventory instead.
+func (l *Logic) recordFastlyCertificate(cert *fastly.CustomTLSCertificate) {
+	l.ObservedState.fastlyCertificates = nil
+	if cert == nil || cert.ID == "" || cert.SerialNumber == "" || len(cert.Domains) == 0 {
+		l.forgetFastlyInventory()
+		return
+	}
+	l.inventoryCache().Update(l.fastlyAccount(), func(inventory *fastlyInventory) {
+		inventory.putCertificate(cert)
+	})
+}
+
+// inventoryCache returns the cache of account inventories, which standby observations share with the Logic they
+// observe for
+func (l *Logic) inventoryCache() *fastlyInventoryCache {
+	if l.sharedInventory != nil {
+		return l.sharedInventory
+	}
+	return &l.fastlyInventory
+}
+
+// InventoryWarmup fetches the inventory of every Fastly account once the operator becomes the leader, so that the
+// initial flood of reconciles is served from the cache rather than each listing the whole account
+type InventoryWarmup struct {
+	Logic  *Logic
+	Client client.Reader
+}
+
+// NeedLeaderElection warms up the leader, which is the replica that reconciles
+func (w *InventoryWarmup) NeedLeaderElection() bool {
+	return true
+}
+
+// Start fetches the inventory of each account and returns
+func (w *InventoryWarmup) Start(ctx context.Context) error {
+	w.Logic.warmFastlyInventory(ctx, w.Client, ctrl.Log.WithName("fastly-inventory-warmup"))
+	return nil
+}
+
+// warmFastlyInventory fetches the inventory of each account into the cache, unless it is still fresh
+func (l *Logic) warmFastlyInventory(ctx context.Context, reader client.Reader, log logr.Logger) {
+	clients, err := l.accountClients(ctx, reader)
+	if err != nil {
+		log.Error(err, "failed to resolve Fastly accounts")
+	}
+
+	for _, account := range slices.Sorted(maps.Keys(clients)) {
+		inventory, err := l.inventoryCache().Load(account, l.Config.FastlyInventoryCacheTTL, func() (*fastlyInventory, error) {
+			return listFastlyInventory(ctx, clients[account])
+		})
+		if err != nil {
+			// Reconciles of the account list it themselves
+			log.Error(err, "failed to warm up Fastly inventory", "account", account)
+			continue
+		}
+		log.Info("warmed up Fastly inventory", "account", account,
+			"certificates", len(inventory.certificates), "private_keys", len(inventory.privateKeys))
+	}
+}
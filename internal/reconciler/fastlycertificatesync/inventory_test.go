@@ -0,0 +1,224 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastlyInventoryCache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := &fastlyInventoryCache{now: func() time.Time { return now }}
+
+	fetches := 0
+	fetch := func() (*fastlyInventory, error) {
+		fetches++
+		return &fastlyInventory{certificates: []*fastly.CustomTLSCertificate{{ID: "cert-id"}}}, nil
+	}
+
+	inventory, err := cache.Load("default", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "cert-id", inventory.certificates[0].ID)
+
+	now = now.Add(30 * time.Second)
+	_, err = cache.Load("default", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches, "a fresh inventory is reused")
+
+	_, err = cache.Load("secret/team-a", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches, "inventories are scoped per account")
+
+	now = now.Add(30 * time.Second)
+	_, err = cache.Load("default", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetches, "inventories expire after the ttl")
+
+	cache.Forget("default")
+	_, err = cache.Load("default", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 4, fetches, "forgotten inventories are fetched again")
+
+	cache.Forget("secret/team-a")
+	_, err = cache.Load("secret/team-a", time.Minute, func() (*fastlyInventory, error) {
+		return nil, errors.New("unavailable")
+	})
+	assert.EqualError(t, err, "unavailable")
+	_, err = cache.Load("secret/team-a", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 5, fetches, "failed fetches are not cached")
+}
+
+func TestFastlyInventoryCache_ForgetDuringFetch(t *testing.T) {
+	cache := &fastlyInventoryCache{}
+
+	fetches := 0
+	_, err := cache.Load("default", time.Minute, func() (*fastlyInventory, error) {
+		fetches++
+		// the operator changes the account while it is being listed
+		cache.Forget("default")
+		return &fastlyInventory{}, nil
+	})
+	require.NoError(t, err)
+
+	_, err = cache.Load("default", time.Minute, func() (*fastlyInventory, error) {
+		fetches++
+		return &fastlyInventory{}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches, "an inventory fetched before the change is not reused")
+}
+
+func TestLogic_getFastlyCertificates(t *testing.T) {
+	listed := 0
+	fastlyClient := &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			listed++
+			return []*fastly.CustomTLSCertificate{{ID: "cert-id"}}, nil
+		},
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			return nil, nil
+		},
+	}
+
+	t.Run("cache_disabled", func(t *testing.T) {
+		listed = 0
+		logic := &Logic{FastlyClient: fastlyClient}
+		ctx := createTestContext()
+
+		for range 2 {
+			certs, err := logic.getFastlyCertificates(ctx)
+			require.NoError(t, err)
+			assert.Len(t, certs, 1)
+		}
+		assert.Equal(t, 2, listed)
+	})
+
+	t.Run("cache_enabled", func(t *testing.T) {
+		listed = 0
+		logic := &Logic{FastlyClient: fastlyClient}
+		ctx := createTestContext()
+		ctx.Config.FastlyInventoryCacheTTL = time.Minute
+
+		for range 2 {
+			certs, err := logic.getFastlyCertificates(ctx)
+			require.NoError(t, err)
+			assert.Len(t, certs, 1)
+		}
+		assert.Equal(t, 1, listed)
+
+		logic.forgetFastlyInventory()
+		_, err := logic.getFastlyCertificates(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, listed, "the account is listed again after the operator changed it")
+	})
+}
+
+func TestInventoryWarmup_Start(t *testing.T) {
+	certsListed, keysListed := 0, 0
+	logic := &Logic{
+		Config: RuntimeConfig{FastlyInventoryCacheTTL: time.Minute},
+		FastlyClient: &MockFastlyClient{
+			ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+				certsListed++
+				return []*fastly.CustomTLSCertificate{{ID: "cert-id"}}, nil
+			},
+			ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+				keysListed++
+				return []*fastly.PrivateKey{{ID: "key-id"}}, nil
+			},
+		},
+	}
+
+	require.NoError(t, (&InventoryWarmup{Logic: logic}).Start(context.Background()))
+	assert.Equal(t, 1, certsListed)
+	assert.Equal(t, 1, keysListed)
+
+	// The reconciles that follow are served from the cache
+	ctx := createTestContext()
+	ctx.Config.RuntimeConfig = logic.Config
+	for range 3 {
+		certs, err := logic.getFastlyCertificates(ctx)
+		require.NoError(t, err)
+		assert.Len(t, certs, 1)
+
+		keys, err := logic.getFastlyPrivateKeys(ctx)
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+	}
+	assert.Equal(t, 1, certsListed)
+	assert.Equal(t, 1, keysListed)
+}
+
+func TestFastlyInventoryCache_Update(t *testing.T) {
+	cache := &fastlyInventoryCache{}
+
+	// Nothing is cached for accounts that were never listed
+	cache.Update("default", func(inventory *fastlyInventory) {
+		inventory.putCertificate(&fastly.CustomTLSCertificate{ID: "cert-id"})
+	})
+	assert.Empty(t, cache.entries)
+
+	listed, err := cache.Load("default", time.Minute, func() (*fastlyInventory, error) {
+		return &fastlyInventory{certificates: []*fastly.CustomTLSCertificate{{ID: "cert-id", SerialNumber: "1"}}}, nil
+	})
+	require.NoError(t, err)
+
+	cache.Update("default", func(inventory *fastlyInventory) {
+		inventory.putCertificate(&fastly.CustomTLSCertificate{ID: "cert-id", SerialNumber: "2"})
+		inventory.putCertificate(&fastly.CustomTLSCertificate{ID: "other-id"})
+		inventory.putPrivateKey(&fastly.PrivateKey{ID: "key-id"})
+	})
+
+	inventory, err := cache.Load("default", time.Minute, func() (*fastlyInventory, error) {
+		t.Fatal("an updated inventory is not listed again")
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*fastly.CustomTLSCertificate{{ID: "cert-id", SerialNumber: "2"}, {ID: "other-id"}}, inventory.certificates)
+	assert.Equal(t, []*fastly.PrivateKey{{ID: "key-id"}}, inventory.privateKeys)
+	assert.Equal(t, "1", listed.certificates[0].SerialNumber, "inventories already handed out are left untouched")
+}
+
+func TestLogic_recordFastlyCertificate(t *testing.T) {
+	listed := 0
+	logic := &Logic{FastlyClient: &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			listed++
+			return nil, nil
+		},
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			return nil, nil
+		},
+	}}
+	ctx := createTestContext()
+	ctx.Config.FastlyInventoryCacheTTL = time.Minute
+
+	_, err := logic.getFastlyCertificates(ctx)
+	require.NoError(t, err)
+
+	// The certificate Fastly returned is verified by the follow-up reconcile without listing the account again
+	created := &fastly.CustomTLSCertificate{ID: "cert-id", SerialNumber: "1", Domains: []*fastly.TLSDomain{{ID: "example.com"}}}
+	logic.recordFastlyCertificate(created)
+	certs, err := logic.getFastlyCertificates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*fastly.CustomTLSCertificate{created}, certs)
+	assert.Equal(t, 1, listed)
+
+	logic.recordFastlyPrivateKey(&fastly.PrivateKey{ID: "key-id", PublicKeySHA1: "sha1"})
+	keys, err := logic.getFastlyPrivateKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*fastly.PrivateKey{{ID: "key-id", PublicKeySHA1: "sha1"}}, keys)
+	assert.Equal(t, 1, listed)
+
+	// Responses missing what the certificate is matched by have the account listed again
+	logic.recordFastlyCertificate(&fastly.CustomTLSCertificate{ID: "cert-id"})
+	_, err = logic.getFastlyCertificates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, listed)
+}
@@ -0,0 +1,109 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	rm "github.com/seatgeek/k8s-reconciler-generic/pkg/resourcemanager"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// certificateIssues reports problems with a Certificate that prevent it from being synced to Fastly
+func certificateIssues(config RuntimeConfig, certificate *cmv1.Certificate, now time.Time) []string {
+	var facts []string
+
+	if !isCertificateSyncEnabled(config, certificate) {
+		facts = append(facts, "certificate not annotated for fastly sync")
+	}
+
+	ready := false
+	for _, condition := range certificate.Status.Conditions {
+		if condition.Type == cmv1.CertificateConditionReady && condition.Status == cmmetav1.ConditionTrue {
+			ready = true
+		}
+	}
+	if !ready {
+		facts = append(facts, "certificate not ready")
+	}
+
+	if notAfter := certificate.Status.NotAfter; notAfter != nil && !now.Before(notAfter.Time) {
+		facts = append(facts, "certificate expired")
+	}
+
+	return facts
+}
+
+// secretIssues reports the TLS material missing from a Certificate's secret.
+// The private key is not required when it is managed outside the operator.
+func secretIssues(secret *corev1.Secret, keys v1alpha1.SecretKeys, privateKeyExternal bool) []string {
+	required := []string{keys.Certificate, keys.PrivateKey}
+	if privateKeyExternal {
+		required = []string{keys.Certificate}
+	} else if keys.PKCS12 != "" {
+		required = []string{keys.PKCS12}
+	}
+
+	var facts []string
+	for _, key := range required {
+		if _, ok := secret.Data[key]; !ok {
+			facts = append(facts, fmt.Sprintf("secret missing %s", key))
+		}
+	}
+
+	return facts
+}
+
+// observeSourceIssues reports problems with the subject's source Certificate and Secret. These aren't resources
+// managed by the reconciler, so the framework doesn't report their issues for us.
+func observeSourceIssues(ctx *Context) []string {
+	var issues []string
+
+	// Certificates the subject may not reference are neither read nor reported on
+	ref := certificateReference(ctx.Subject)
+	if permitted, err := isCertificateReferencePermitted(ctx, ref); err != nil || !permitted {
+		return issues
+	}
+
+	certificate := &cmv1.Certificate{}
+	if err := ctx.Client.Client.Get(ctx, ref, certificate); err != nil {
+		return issues
+	}
+	warnCertificateNotAnnotated(ctx, certificate)
+	if facts := certificateIssues(ctx.Config.RuntimeConfig, certificate, time.Now()); len(facts) > 0 {
+		issues = append(issues, renderIssue(cmv1.SchemeGroupVersion.WithKind(cmv1.CertificateKind).GroupKind(), certificate.Name, facts))
+	}
+
+	secret := &corev1.Secret{}
+	if err := ctx.Client.Client.Get(ctx, types.NamespacedName{Name: certificate.Spec.SecretName, Namespace: certificate.Namespace}, secret); err != nil {
+		return append(issues, renderIssue(corev1.SchemeGroupVersion.WithKind("Secret").GroupKind(), certificate.Spec.SecretName, []string{"missing"}))
+	}
+	if facts := secretIssues(secret, getSecretKeys(ctx), ctx.Subject.IsPrivateKeyExternal()); len(facts) > 0 {
+		issues = append(issues, renderIssue(corev1.SchemeGroupVersion.WithKind("Secret").GroupKind(), secret.Name, facts))
+	}
+
+	return issues
+}
+
+// warnCertificateNotAnnotated records a Warning event on the subject when its Certificate isn't annotated for syncing.
+// Renewals of such a Certificate don't trigger a sync, which otherwise only waits for the next resync.
+func warnCertificateNotAnnotated(ctx *Context, certificate *cmv1.Certificate) {
+	if ctx.EventRecorder == nil || isCertificateSyncEnabled(ctx.Config.RuntimeConfig, certificate) {
+		return
+	}
+
+	key, value := ctx.Config.certificateSyncAnnotation()
+	ctx.Eventf(ctx.Subject, corev1.EventTypeWarning, "CertificateNotAnnotated",
+		"Certificate %s/%s is not annotated with %s: %q, changes to it are only synced on the next resync",
+		certificate.Namespace, certificate.Name, key, value)
+}
+
+// renderIssue formats facts about an object the same way the framework reports issues of managed resources
+func renderIssue(gk schema.GroupKind, name string, facts []string) string {
+	return fmt.Sprintf("%s(%s)", rm.RenderResourceKey(gk, name), strings.Join(facts, ","))
+}
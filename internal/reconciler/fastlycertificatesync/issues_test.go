@@ -0,0 +1,166 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLogic_ResourceIssues(t *testing.T) {
+	readyCondition := cmv1.CertificateCondition{Type: cmv1.CertificateConditionReady, Status: cmmetav1.ConditionTrue}
+	annotations := map[string]string{EnableFastlySyncAnnotation: "true"}
+
+	tests := []struct {
+		name          string
+		obj           client.Object
+		expectedFacts []string
+	}{
+		{
+			name: "healthy_certificate",
+			obj: &cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+				Status: cmv1.CertificateStatus{
+					Conditions: []cmv1.CertificateCondition{readyCondition},
+					NotAfter:   &metav1.Time{Time: time.Now().Add(time.Hour)},
+				},
+			},
+			expectedFacts: nil,
+		},
+		{
+			name: "unannotated_expired_certificate",
+			obj: &cmv1.Certificate{
+				Status: cmv1.CertificateStatus{
+					NotAfter: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+				},
+			},
+			expectedFacts: []string{"certificate not annotated for fastly sync", "certificate not ready", "certificate expired"},
+		},
+		{
+			name: "secret_missing_key",
+			obj: &corev1.Secret{
+				Data: map[string][]byte{"tls.crt": []byte("test-cert-data")},
+			},
+			expectedFacts: []string{"secret missing tls.key"},
+		},
+		{
+			name:          "unrelated_object",
+			obj:           &corev1.ConfigMap{},
+			expectedFacts: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedFacts, (&Logic{}).ResourceIssues(tt.obj))
+		})
+	}
+}
+
+func TestObserveSourceIssues(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+			Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"tls.crt": []byte("test-cert-data")},
+		},
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "other-namespace"},
+			Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+	).Build()
+
+	tests := []struct {
+		name           string
+		setup          func(ctx *Context)
+		expectedIssues []string
+	}{
+		{
+			name: "missing_private_key",
+			expectedIssues: []string{
+				"Certificate.cert-manager.io/test-certificate(certificate not annotated for fastly sync,certificate not ready)",
+				"Secret/test-secret(secret missing tls.key)",
+			},
+		},
+		{
+			name: "external_private_key",
+			setup: func(ctx *Context) {
+				ctx.Subject.Spec.PrivateKeyManagement = v1alpha1.PrivateKeyManagementExternal
+			},
+			expectedIssues: []string{
+				"Certificate.cert-manager.io/test-certificate(certificate not annotated for fastly sync,certificate not ready)",
+			},
+		},
+		{
+			name: "annotation_ignored",
+			setup: func(ctx *Context) {
+				ctx.Config.IgnoreCertificateSyncAnnotation = true
+			},
+			expectedIssues: []string{
+				"Certificate.cert-manager.io/test-certificate(certificate not ready)",
+				"Secret/test-secret(secret missing tls.key)",
+			},
+		},
+		{
+			name: "reference_not_permitted",
+			setup: func(ctx *Context) {
+				ctx.Subject.Spec.CertificateName = ""
+				ctx.Subject.Spec.CertificateRef = &v1alpha1.CertificateRef{Name: "test-certificate", Namespace: "other-namespace"}
+			},
+			expectedIssues: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+			if tt.setup != nil {
+				tt.setup(ctx)
+			}
+
+			assert.Equal(t, tt.expectedIssues, observeSourceIssues(ctx))
+		})
+	}
+}
+
+func TestWarnCertificateNotAnnotated(t *testing.T) {
+	certificate := &cmv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"}}
+
+	recorder := record.NewFakeRecorder(1)
+	ctx := createTestContext()
+	ctx.EventRecorder = recorder
+
+	warnCertificateNotAnnotated(ctx, certificate)
+	assert.Equal(t, `Warning CertificateNotAnnotated Certificate test-namespace/test-certificate is not annotated with `+
+		`platform.seatgeek.io/enable-fastly-sync: "true", changes to it are only synced on the next resync`, <-recorder.Events)
+
+	// Nothing is recorded for annotated Certificates, or when the annotation is ignored
+	certificate.Annotations = map[string]string{EnableFastlySyncAnnotation: "true"}
+	warnCertificateNotAnnotated(ctx, certificate)
+	certificate.Annotations = nil
+	ctx.Config.IgnoreCertificateSyncAnnotation = true
+	warnCertificateNotAnnotated(ctx, certificate)
+	assert.Empty(t, recorder.Events)
+}
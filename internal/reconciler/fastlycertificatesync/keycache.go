@@ -0,0 +1,89 @@
+package fastlycertificatesync
+
+import (
+	"sync"
+	"time"
+)
+
+// uploadedPrivateKeyCache remembers the public key SHA1s of private keys recently uploaded to each Fastly account.
+//
+// Fastly's private key listing is eventually consistent, and many FastlyCertificateSyncs may share the same secret
+// (e.g. a wildcard certificate). Without this cache, every one of them may fail to find the freshly uploaded key and
+// upload a duplicate during a renewal storm.
+type uploadedPrivateKeyCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+func (c *uploadedPrivateKeyCache) key(account, publicKeySHA1 string) string {
+	return account + "/" + publicKeySHA1
+}
+
+func (c *uploadedPrivateKeyCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Add records that a private key was uploaded to the given account
+func (c *uploadedPrivateKeyCache) Add(account, publicKeySHA1 string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]time.Time{}
+	}
+	c.entries[c.key(account, publicKeySHA1)] = c.clock()
+}
+
+// Reserve records that a private key is about to be uploaded to the given account, unless one was uploaded or
+// reserved within the ttl. It reports whether the caller may go ahead with the upload, so that concurrent reconciles
+// sharing a key don't both upload it.
+func (c *uploadedPrivateKeyCache) Reserve(account, publicKeySHA1 string, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock()
+	c.prune(now, ttl)
+
+	k := c.key(account, publicKeySHA1)
+	if _, ok := c.entries[k]; ok {
+		return false
+	}
+	if c.entries == nil {
+		c.entries = map[string]time.Time{}
+	}
+	c.entries[k] = now
+	return true
+}
+
+// Release drops a reservation whose upload failed, so that the next reconcile retries it
+func (c *uploadedPrivateKeyCache) Release(account, publicKeySHA1 string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, c.key(account, publicKeySHA1))
+}
+
+// Contains reports whether a private key was uploaded to the given account within the ttl.
+// Expired entries are pruned as a side effect.
+func (c *uploadedPrivateKeyCache) Contains(account, publicKeySHA1 string, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune(c.clock(), ttl)
+
+	_, ok := c.entries[c.key(account, publicKeySHA1)]
+	return ok
+}
+
+// prune drops the entries older than the ttl, the caller must hold the lock
+func (c *uploadedPrivateKeyCache) prune(now time.Time, ttl time.Duration) {
+	for k, uploadedAt := range c.entries {
+		if now.Sub(uploadedAt) >= ttl {
+			delete(c.entries, k)
+		}
+	}
+}
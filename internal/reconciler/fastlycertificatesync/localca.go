@@ -0,0 +1,55 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hasSecretCA reports whether the secret holds a CA certificate to append to the certificate in local reconciliation
+// mode. Issuers that don't publish their CA leave the key out, or empty.
+func hasSecretCA(ctx *Context, secret *corev1.Secret) bool {
+	return len(secret.Data[getSecretKeys(ctx).CA]) > 0
+}
+
+// observeCACertificate notes whether the certificate is uploaded without its CA, which only happens in local
+// reconciliation mode when missing CAs are allowed
+func (l *Logic) observeCACertificate(ctx *Context) {
+	if !ctx.Config.HackFastlyCertificateSyncLocalReconciliation || !ctx.Config.LocalReconciliationAllowMissingCA {
+		return
+	}
+
+	_, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		ctx.Log.Error(err, "failed to check CA certificate")
+		return
+	}
+	l.ObservedState.CACertificateMissing = !hasSecretCA(ctx, secret)
+}
+
+// observeCACertificateAvailableCondition generates the condition warning that the certificate is uploaded without its
+// CA. It is omitted unless missing CAs are allowed in local reconciliation mode, as the sync fails without a CA
+// otherwise.
+func (l *Logic) observeCACertificateAvailableCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if !ctx.Config.HackFastlyCertificateSyncLocalReconciliation || !ctx.Config.LocalReconciliationAllowMissingCA ||
+		!l.SubjectReadyForReconciliation {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type: "CACertificateAvailable",
+	}
+
+	if l.ObservedState.CACertificateMissing {
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "CACertificateMissing"
+		condition.Message = fmt.Sprintf("The secret of the certificate holds no %s, the certificate is uploaded without its CA", getSecretKeys(ctx).CA)
+	} else {
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "CACertificatePresent"
+		condition.Message = "The CA certificate is uploaded along with the certificate"
+	}
+
+	return condition, nil
+}
@@ -0,0 +1,93 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLogic_observeCACertificate(t *testing.T) {
+	tests := []struct {
+		name          string
+		localMode     bool
+		allowMissing  bool
+		ca            []byte
+		expectMissing bool
+	}{
+		{name: "missing", localMode: true, allowMissing: true, expectMissing: true},
+		{name: "empty", localMode: true, allowMissing: true, ca: []byte{}, expectMissing: true},
+		{name: "present", localMode: true, allowMissing: true, ca: []byte("ca")},
+		{name: "not allowed", localMode: true},
+		{name: "production mode", allowMissing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string][]byte{"tls.crt": []byte("cert")}
+			if tt.ca != nil {
+				data["ca.crt"] = tt.ca
+			}
+
+			scheme := runtime.NewScheme()
+			_ = cmv1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&cmv1.Certificate{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+					Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+					Data:       data,
+				},
+			).Build()
+
+			ctx := createTestContext()
+			ctx.Config.HackFastlyCertificateSyncLocalReconciliation = tt.localMode
+			ctx.Config.LocalReconciliationAllowMissingCA = tt.allowMissing
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			logic := &Logic{}
+			logic.observeCACertificate(ctx)
+			assert.Equal(t, tt.expectMissing, logic.ObservedState.CACertificateMissing)
+		})
+	}
+}
+
+func TestLogic_observeCACertificateAvailableCondition(t *testing.T) {
+	ctx := createTestContext()
+	logic := &Logic{SubjectReadyForReconciliation: true, ObservedState: ObservedState{CACertificateMissing: true}}
+
+	// Omitted unless missing CAs are allowed in local reconciliation mode
+	condition, err := logic.observeCACertificateAvailableCondition(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, condition)
+
+	ctx.Config.HackFastlyCertificateSyncLocalReconciliation = true
+	ctx.Config.LocalReconciliationAllowMissingCA = true
+	condition, err = logic.observeCACertificateAvailableCondition(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "CACertificateMissing", condition.Reason)
+	assert.Contains(t, condition.Message, "ca.crt")
+
+	logic.ObservedState.CACertificateMissing = false
+	condition, err = logic.observeCACertificateAvailableCondition(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "CACertificatePresent", condition.Reason)
+}
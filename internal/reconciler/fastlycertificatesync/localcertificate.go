@@ -0,0 +1,90 @@
+package fastlycertificatesync
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validateLocalCertificate checks that the certificate chain and private key held by the source Secret can be parsed,
+// and that they belong together. A rotation that left the Secret corrupted would otherwise fail every reconcile with
+// an error that doesn't point at the Secret. It returns what is wrong with them, or an error when the Secret couldn't
+// be read, which is retried.
+//
+// It is only run on input that passed validateFastlyInput, so the chain is known to be made of PEM blocks.
+func validateLocalCertificate(ctx *Context) (string, error) {
+	_, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	certPEM, err := getCertPEMForSecret(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+	blocks, err := decodePEMBlob(certPEM, maxCertificateBlobSize)
+	if err != nil {
+		return fmt.Sprintf("certificate of secret %s/%s fails to decode: %v", secret.Namespace, secret.Name, err), nil
+	}
+
+	var leaf *x509.Certificate
+	for i, block := range blocks {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Sprintf("certificate %d of secret %s/%s fails to parse: %v", i, secret.Namespace, secret.Name, err), nil
+		}
+		if leaf == nil {
+			leaf = cert
+		}
+	}
+
+	// Externally managed private keys are never read
+	if ctx.Subject.IsPrivateKeyExternal() {
+		return "", nil
+	}
+
+	keyPEM, err := openSecretKeyPEM(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+	defer keyPEM.wipe()
+
+	keyPublic, err := getPublicKeyFromPEM(keyPEM)
+	if err != nil {
+		return fmt.Sprintf("private key of secret %s/%s fails to parse: %v", secret.Namespace, secret.Name, err), nil
+	}
+	if !publicKeysEqual(leaf.PublicKey, keyPublic) {
+		return fmt.Sprintf("private key of secret %s/%s does not match its certificate with serial number %s", secret.Namespace, secret.Name, leaf.SerialNumber), nil
+	}
+
+	return "", nil
+}
+
+// observeLocalCertificateInvalidCondition generates the condition for a source Secret whose certificate or private key
+// can't be parsed. Fastly is left as it was last synced until the Secret recovers.
+func (l *Logic) observeLocalCertificateInvalidCondition(ctx *Context) (*kmetav1.Condition, error) {
+	condition := &kmetav1.Condition{
+		Type: "LocalCertificateInvalid",
+	}
+
+	switch {
+	case l.ObservedState.LocalCertificateInvalid != "":
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "ParseFailed"
+		condition.Message = l.ObservedState.LocalCertificateInvalid
+		if id := ctx.Subject.Status.CertificateID; id != "" {
+			condition.Message += fmt.Sprintf(", Fastly certificate %s is left untouched until it recovers", id)
+		}
+	case !l.SubjectReadyForReconciliation:
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "LocalCertificateNotObserved"
+		condition.Message = "Certificate and private key of the Secret have not been parsed yet"
+	default:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "LocalCertificateValid"
+		condition.Message = "Certificate and private key of the Secret parse and match"
+	}
+
+	return condition, nil
+}
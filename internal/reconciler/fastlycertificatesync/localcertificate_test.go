@@ -0,0 +1,114 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"encoding/pem"
+	"testing"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateLocalCertificate(t *testing.T) {
+	chain := createTestCertificateChain(t)
+	corruptedCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("corrupted")})
+	corruptedKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("corrupted")})
+
+	tests := []struct {
+		name       string
+		management v1alpha1.PrivateKeyManagement
+		data       map[string][]byte
+		expected   string
+	}{
+		{
+			name: "valid",
+			data: map[string][]byte{"tls.crt": concatPEM(chain.leafPEM, chain.caPEM), "tls.key": chain.leafKeyPEM},
+		},
+		{
+			name:     "corrupted_intermediate",
+			data:     map[string][]byte{"tls.crt": concatPEM(chain.leafPEM, corruptedCertPEM), "tls.key": chain.leafKeyPEM},
+			expected: "certificate 1 of secret test-namespace/test-secret fails to parse: x509: malformed certificate",
+		},
+		{
+			name:     "corrupted_private_key",
+			data:     map[string][]byte{"tls.crt": chain.leafPEM, "tls.key": corruptedKeyPEM},
+			expected: "private key of secret test-namespace/test-secret fails to parse: failed to parse EC private key",
+		},
+		{
+			name:     "private_key_of_another_certificate",
+			data:     map[string][]byte{"tls.crt": chain.otherLeafPEM, "tls.key": chain.leafKeyPEM},
+			expected: "private key of secret test-namespace/test-secret does not match its certificate with serial number 2",
+		},
+		{
+			name:       "external_private_key_is_not_read",
+			management: v1alpha1.PrivateKeyManagementExternal,
+			data:       map[string][]byte{"tls.crt": chain.otherLeafPEM, "tls.key": corruptedKeyPEM},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = cmv1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&cmv1.Certificate{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+					Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+					Data:       tt.data,
+				},
+			).Build()
+
+			ctx := createTestContext()
+			ctx.Subject.Spec.PrivateKeyManagement = tt.management
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			invalid, err := validateLocalCertificate(ctx)
+			require.NoError(t, err)
+			if tt.expected == "" {
+				assert.Empty(t, invalid)
+			} else {
+				assert.Contains(t, invalid, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLogic_observeLocalCertificateInvalidCondition(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Status.CertificateID = "cert1"
+	logic := &Logic{}
+	logic.ObservedState.LocalCertificateInvalid = "certificate 0 of secret test-namespace/test-secret fails to parse: x509: malformed certificate"
+
+	condition, err := logic.observeLocalCertificateInvalidCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "ParseFailed", condition.Reason)
+	assert.Equal(t, "certificate 0 of secret test-namespace/test-secret fails to parse: x509: malformed certificate, Fastly certificate cert1 is left untouched until it recovers", condition.Message)
+
+	ready, err := logic.observeReadyCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, "LocalCertificateInvalid", ready.Reason)
+
+	logic.ObservedState.LocalCertificateInvalid = ""
+	logic.SubjectReadyForReconciliation = true
+	condition, err = logic.observeLocalCertificateInvalidCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "LocalCertificateValid", condition.Reason)
+}
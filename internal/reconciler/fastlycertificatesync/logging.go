@@ -0,0 +1,37 @@
+package fastlycertificatesync
+
+// Keys of the values that every line logged while reconciling a subject carries
+const (
+	logKeySubject      = "subject"
+	logKeyNamespace    = "namespace"
+	logKeyGeneration   = "generation"
+	logKeyAccount      = "account"
+	logKeyFastlyCertID = "fastlyCertID"
+	logKeyFastlyKeyID  = "fastlyKeyID"
+)
+
+// withSubjectLogValues adds the subject to every line logged during the rest of the reconcile, so that call sites
+// don't need to repeat it
+func withSubjectLogValues(ctx *Context) {
+	ctx.Log = ctx.Log.WithValues(
+		logKeySubject, ctx.Subject.Name,
+		logKeyNamespace, ctx.Subject.Namespace,
+		logKeyGeneration, ctx.Subject.Generation,
+	)
+}
+
+// withFastlyKeyLogValues adds the ID of the private key observed in Fastly to every line logged afterwards, for
+// subjects syncing to several accounts only until the next account is observed
+func (l *Logic) withFastlyKeyLogValues(ctx *Context) {
+	if id := l.ObservedState.PrivateKeyID; id != "" {
+		ctx.Log = ctx.Log.WithValues(logKeyFastlyKeyID, id)
+	}
+}
+
+// withFastlyCertificateLogValues adds the ID of the certificate matched in Fastly to every line logged afterwards, for
+// subjects syncing to several accounts only until the next account is observed
+func (l *Logic) withFastlyCertificateLogValues(ctx *Context) {
+	if cert := l.ObservedState.FastlyCertificate; cert != nil {
+		ctx.Log = ctx.Log.WithValues(logKeyFastlyCertID, cert.ID)
+	}
+}
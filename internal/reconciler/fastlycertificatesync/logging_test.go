@@ -0,0 +1,35 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogValues(t *testing.T) {
+	var lines []string
+	ctx := createTestContext()
+	ctx.Subject.Generation = 3
+	ctx.Log = funcr.New(func(prefix, args string) { lines = append(lines, args) }, funcr.Options{})
+
+	logic := &Logic{}
+	withSubjectLogValues(ctx)
+
+	// IDs that weren't observed are left out rather than logged empty
+	logic.withFastlyKeyLogValues(ctx)
+	logic.withFastlyCertificateLogValues(ctx)
+	ctx.Log.Info("observed")
+
+	logic.ObservedState.PrivateKeyID = "key1"
+	logic.ObservedState.FastlyCertificate = &fastly.CustomTLSCertificate{ID: "cert1"}
+	logic.withFastlyKeyLogValues(ctx)
+	logic.withFastlyCertificateLogValues(ctx)
+	ctx.Log.Info("applied")
+
+	assert.Equal(t, []string{
+		`"level"=0 "msg"="observed" "subject"="test-cert-sync" "namespace"="test-namespace" "generation"=3`,
+		`"level"=0 "msg"="applied" "subject"="test-cert-sync" "namespace"="test-namespace" "generation"=3 "fastlyKeyID"="key1" "fastlyCertID"="cert1"`,
+	}, lines)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
@@ -11,7 +13,7 @@ import (
 	"github.com/fastly/go-fastly/v11/fastly"
 	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
 	rm "github.com/seatgeek/k8s-reconciler-generic/pkg/resourcemanager"
-	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -21,11 +23,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// +kubebuilder:rbac:groups=platform.seatgeek.io,resources=fastlycertificatesyncs,verbs=get;list;watch;update;patch;create;delete
+// The permissions below only read, writes are granted next to the code making them, on the objects the operator owns
+// or annotates.
+// +kubebuilder:rbac:groups=platform.seatgeek.io,resources=fastlycertificatesyncs,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=platform.seatgeek.io,resources=fastlycertificatesyncs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=platform.seatgeek.io,resources=fastlycertificatesyncs/finalizers,verbs=update
-// +kubebuilder:rbac:groups="cert-manager.io",resources=certificaterequests;certificates,verbs=*
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=*
+// +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 type Context = genrec.Context[*v1alpha1.FastlyCertificateSync, *Config]
 
@@ -53,24 +57,162 @@ type TLSActivationData struct {
 }
 
 type ObservedState struct {
-	PrivateKeyUploaded       bool
-	CertificateStatus        CertificateStatus
-	UnusedPrivateKeyIDs      []string
-	MissingTLSActivationData []TLSActivationData
-	ExtraTLSActivationIDs    []string
+	CertificateSourceReady      bool
+	CertificateSourceReason     string
+	CertificateSourceMessage    string
+	SourceIssues                []string
+	InvalidInput                string
+	LocalCertificateInvalid     string
+	PrivateKeyUploaded          bool
+	PrivateKeyPublicKeySHA1     string
+	PrivateKeyPublicKeySHA256   string
+	PrivateKeyID                string
+	CertificateStatus           CertificateStatus
+	CertificateAdoptionRequired bool
+	CertificateRenameRequired   bool
+	LocalSerialNumber           string
+	ConflictingSerialNumber     string
+	WrittenSerialNumber         string
+	FastlyCertificate           *fastly.CustomTLSCertificate
+	MissingTLSActivationData    []TLSActivationData
+	ExtraTLSActivationIDs       []string
+	PreviousTLSActivations      []previousTLSActivation
+	PreviousCertificateExpired  bool
+	Rollback                    rollbackPhase
+	MutationBudgetExceeded      bool
+	MutationBudgetRetryAfter    time.Duration
+	MutationsDeferred           bool
+	TLSActivationResults        []v1alpha1.TLSActivationResult
+	QuickDriftChecked           bool
+	ServiceDomainsChecked       bool
+	MissingServiceDomains       []string
+	ServiceDomainsError         string
+	DomainVerificationChecked   bool
+	DomainVerificationSkipped   bool
+	UnverifiedDomains           []string
+	DomainVerificationError     string
+	DomainCoverageChecked       bool
+	UncoveredDomains            []string
+	DomainCoverageError         string
+	CACertificateMissing        bool
+	AccountQuotaObserved        bool
+	AccountQuotas               []accountQuota
+	TLSConfigurationsSelected   bool
+	SelectedTLSConfigurationIDs []string
+	DefaultTLSConfigurationID   string
+	PartitionHandedOver         bool
+	MutationsPaused             bool
+	MutationPauseUnreadable     string
+
+	// fastlyCertificates is the listing of Fastly certificates shared by the steps of the observation
+	fastlyCertificates *fastlyCertificateSnapshot
+	// fastlyTLSActivations is the listing of the TLS activations of the matching certificate shared by the steps of
+	// the observation
+	fastlyTLSActivations *fastlyTLSActivationSnapshot
+}
+
+// isSynced reports whether the private key, certificate and TLS activations of the subject are in sync
+func (o *ObservedState) isSynced() bool {
+	return o.PrivateKeyUploaded &&
+		o.CertificateStatus == CertificateStatusSynced &&
+		len(o.MissingTLSActivationData) == 0 &&
+		len(o.ExtraTLSActivationIDs) == 0 &&
+		len(o.PreviousTLSActivations) == 0
+}
+
+// hasPendingMutations reports whether the observed state requires any write operations against Fastly
+func (o *ObservedState) hasPendingMutations() bool {
+	return len(o.pendingMutations()) > 0
+}
+
+// pendingMutations describes the write operations against Fastly that the observed state requires
+func (o *ObservedState) pendingMutations() []string {
+	var res []string
+	if !o.PrivateKeyUploaded {
+		res = append(res, "upload private key")
+	}
+	switch o.CertificateStatus {
+	case CertificateStatusSynced:
+		if o.CertificateRenameRequired {
+			res = append(res, "rename certificate")
+		}
+	case CertificateStatusStale:
+		res = append(res, "update certificate")
+	default:
+		res = append(res, "create certificate")
+	}
+	if n := len(o.MissingTLSActivationData); n > 0 {
+		res = append(res, fmt.Sprintf("create %d TLS activation(s)", n))
+	}
+	if n := len(o.ExtraTLSActivationIDs); n > 0 {
+		res = append(res, fmt.Sprintf("delete %d TLS activation(s)", n))
+	}
+	if n := len(o.PreviousTLSActivations); n > 0 {
+		res = append(res, fmt.Sprintf("move %d TLS activation(s) off the previous certificate", n))
+	}
+	if o.PreviousCertificateExpired {
+		res = append(res, "delete previous certificate")
+	}
+	if o.Rollback == rollbackPending {
+		res = append(res, "roll back to previous certificate")
+	}
+	return res
 }
 
 type Logic struct {
-	genrec.WithoutFinalizationMixin[*v1alpha1.FastlyCertificateSync, *Config]
 	rm.ResourceManager[*Context]
 	Config       RuntimeConfig
 	FastlyClient FastlyClientInterface
+	// NewFastlyClient creates clients for namespaces that are mapped to their own Fastly token
+	NewFastlyClient FastlyClientFactory
+	// Notifier, when set, is told about subjects that need someone's attention
+	Notifier Notifier
+	// QueueHealth, when set, tracks the reconcile queue for the liveness probe
+	QueueHealth *QueueHealth
 	// For the following state, we make sure that:
 	// * Always reset state at the beginning of `ObserveResources`
 	// * Only set state during `ObserveResources`
 	// * Only read state during `ApplyUnmanaged`
 	ObservedState                 ObservedState
 	SubjectReadyForReconciliation bool
+
+	// namespaceFastlyClient is resolved at the beginning of `ObserveResources` when the subject's namespace is
+	// mapped to its own Fastly token
+	namespaceFastlyClient  FastlyClientInterface
+	namespaceFastlyAccount string
+	fastlyClientsMu        sync.Mutex
+	fastlyClientsBySecret  map[types.NamespacedName]cachedFastlyClient
+
+	// accountObservations holds what was observed in each of the accounts in spec.accounts, and currentAccount the
+	// one being observed or changed
+	accountObservations []fastlyAccountObservation
+	currentAccount      *v1alpha1.FastlyAccount
+
+	// uploadedPrivateKeys suppresses duplicate private key uploads while Fastly catches up
+	uploadedPrivateKeys uploadedPrivateKeyCache
+	// fastlyInventory shares the listing of each account's certificates and private keys across reconciles
+	fastlyInventory fastlyInventoryCache
+	// sharedInventory, when set, is used instead of fastlyInventory, see inventoryCache
+	sharedInventory *fastlyInventoryCache
+	// domainVerifications shares the verification status of each account's domains across reconciles
+	domainVerifications domainVerificationCache
+	// accountQuotas tracks the totals of each account against its configured limits, as of its last audit
+	accountQuotas accountQuotaTracker
+	// mutationBudget counts Fastly write operations to protect the account from runaway reconcile loops
+	mutationBudget mutationBudget
+	// mutationsPaused is whether Fastly write operations are paused, as last read from the mutation pause ConfigMap, or
+	// because it failed to be read
+	mutationsPaused atomic.Bool
+	// fastlyCalls counts the Fastly API calls made through fastlyClient within the current reconcile, when set
+	fastlyCalls *fastlyCallCounter
+	// syncedSubjects lets quick drift checks stand in for a full observation of subjects that are in sync
+	syncedSubjects syncedSubjectCache
+	// tlsActivationProgress lets retries resume a partially created batch of TLS activations while Fastly catches up
+	tlsActivationProgress tlsActivationProgress
+	// notifications rate limits the notifications sent for each subject
+	notifications notificationLimiter
+	// notificationsInFlight tracks the notifications being sent in the background
+	notificationsInFlight sync.WaitGroup
 }
 
 func (l *Logic) NewSubject() *v1alpha1.FastlyCertificateSync {
@@ -82,6 +224,12 @@ func (l *Logic) GetConfig(nn types.NamespacedName) *Config {
 }
 
 func (l *Logic) FillDefaults(c *Context) error {
+	defer recoverPanic(c, reconcilePhaseFillDefaults)
+
+	// A templated Certificate is named after the subject
+	if hasCertificateTemplate(c) && c.Subject.Spec.CertificateName == "" {
+		c.Subject.Spec.CertificateName = c.ObjectName("", "")
+	}
 	return nil
 }
 
@@ -93,8 +241,13 @@ func (l *Logic) IsSubjectNil(subj *v1alpha1.FastlyCertificateSync) bool {
 	return subj == nil
 }
 
-func (l *Logic) ResourceIssues(_ client.Object) (facts []string) {
-	// TODO report any semantic or state issues for a watched object
+func (l *Logic) ResourceIssues(obj client.Object) (facts []string) {
+	switch o := obj.(type) {
+	case *cmv1.Certificate:
+		return certificateIssues(l.Config, o, time.Now())
+	case *corev1.Secret:
+		return secretIssues(o, v1alpha1.SecretKeys{Certificate: defaultSecretCertificateKey, PrivateKey: defaultSecretPrivateKeyKey}, false)
+	}
 	return
 }
 
@@ -115,45 +268,41 @@ func (l *Logic) ConfigureController(cb *builder.Builder, cluster cluster.Cluster
 
 	cb.Owns(&v1alpha1.FastlyCertificateSync{})
 
-	watchOpts := builder.WithPredicates() // NOTE: we care about `.status` field updates on Certificates, so don't drop those events
+	cb.WithOptions(l.controllerOptions())
+
+	// NOTE: we care about `.status` field updates on Certificates, so generation based predicates would drop too much.
+	// Instead, only the changes that are relevant to syncing the certificate pass.
+	watchOpts := builder.WithPredicates(certificateChangedPredicate(l.Config))
 
 	// watch all Certificates - re-reconcile the FastlyCertificateSync resources that reference them
 	cb.Watches(&cmv1.Certificate{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, object client.Object) []reconcile.Request {
-		res := []reconcile.Request{}
-
-		// discard certificate if it is not annotated for fastly-certificate-sync
-		if sync, ok := object.GetAnnotations()["platform.seatgeek.io/enable-fastly-sync"]; !ok || sync != "true" {
-			ctrl.Log.V(5).Info("certificate is not annotated for fastly-certificate-sync, skipping reconciliation", "certificate_name", object.GetName(), "certificate_namespace", object.GetNamespace())
-			return res
-		}
-
-		all := v1alpha1.FastlyCertificateSyncList{}
-
-		if err := cluster.GetClient().List(ctx, &all, &client.ListOptions{Namespace: kmetav1.NamespaceAll}); err != nil {
-			ctrl.Log.Error(err, "could not list FastlyCertificateSync resources to reconcile while watching Certificates")
-		}
-
-		// attempt to match a fastlyCertificateSync
-		for _, fastlyCertificateSync := range all.Items {
-			// reconcile fastlyCertificateSync resources that are referenced by the watched certificate
-			if (object.GetName() == fastlyCertificateSync.Spec.CertificateName) && (object.GetNamespace() == fastlyCertificateSync.GetNamespace()) {
-				res = append(res, reconcile.Request{
-					NamespacedName: types.NamespacedName{
-						Name:      fastlyCertificateSync.GetName(),
-						Namespace: fastlyCertificateSync.GetNamespace(),
-					},
-				})
-			}
-		}
-
-		return res
+		return l.mapCertificateToSubjects(ctx, cluster.GetClient(), object)
 	}), watchOpts)
 
+	// watch the mutation pause ConfigMap - reconcile every FastlyCertificateSync when the pause is set or lifted
+	if l.Config.MutationPauseConfigMap.Name != "" {
+		cb.Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, _ client.Object) []reconcile.Request {
+			return l.mapMutationPauseToSubjects(ctx, cluster.GetClient())
+		}), builder.WithPredicates(mutationPauseChangedPredicate(l.Config.MutationPauseConfigMap)))
+	}
+
 	ctrl.Log.Info("Configured controller", "controller", "fastlycertificatesync")
 
 	return nil
 }
 
+// isEnqueueable reports whether a change to a watched object should reconcile the subject, which it shouldn't when
+// the subject is suspended or belongs to another partition. Changes to the subject itself still reconcile it.
+func (l *Logic) isEnqueueable(subject *v1alpha1.FastlyCertificateSync) bool {
+	if subject.IsSuspended() {
+		return false
+	}
+	if l.Config.PartitionAnnotation != "" && subject.GetAnnotations()[l.Config.PartitionAnnotation] != l.Config.Partition {
+		return false
+	}
+	return true
+}
+
 func (l *Logic) Reconcile(ctx *Context) (ctrl.Result, error) {
 	// The actual reconciliation takes place in `ObserveResources` and `ApplyUnmanaged`
 	ctx.Log.Info("reconciling FastlyCertificateSync", "name", ctx.Subject.Name, "namespace", ctx.Subject.Namespace)
@@ -162,12 +311,28 @@ func (l *Logic) Reconcile(ctx *Context) (ctrl.Result, error) {
 }
 
 func (l *Logic) Validate(svc *v1alpha1.FastlyCertificateSync) error {
-	// TODO: Implement validation logic
-	return nil
+	// Report every problem at once, rather than making users fix them one at a time
+	return joinErrors([]error{
+		validateCertificateSource(svc),
+		validateCertificateReference(svc),
+		validateCertificateTemplate(svc),
+		validateTLSConfigurationIDs(svc),
+		validateTLSConfigurationSelector(svc),
+		validateAccounts(svc),
+		validateServiceID(svc),
+		validateDomains(svc),
+		validatePrivateKeyManagement(svc),
+		validateResyncInterval(svc),
+		validateRollbackToSerial(svc),
+	})
 }
 
-func (l *Logic) ObserveResources(ctx *Context) (genrec.Resources, error) {
-	ctx.Log.Info("observing resources for FastlyCertificateSync", "name", ctx.Subject.Name, "namespace", ctx.Subject.Namespace)
+func (l *Logic) ObserveResources(ctx *Context) (resources genrec.Resources, err error) {
+	withSubjectLogValues(ctx)
+	ctx.Log.Info("observing resources for FastlyCertificateSync")
+
+	defer func(start time.Time) { observeReconcilePhase(ctx, reconcilePhaseObserve, start, err) }(time.Now())
+	defer recoverPanic(ctx, reconcilePhaseObserve)
 
 	// Allow `ApplyUnmanaged` to differentiate between:
 	// * A subject that isn't ready for reconciliation (certificate and secret not available)
@@ -176,131 +341,400 @@ func (l *Logic) ObserveResources(ctx *Context) (genrec.Resources, error) {
 
 	// Always start with fresh observation state, avoid sharing data between reconciliations
 	l.ObservedState = ObservedState{}
+	l.accountObservations = nil
+	l.startCountingFastlyCalls()
+
+	// Changes are held back, but still observed, while Fastly write operations are paused
+	l.observeMutationPause(ctx)
+
+	// Observe the resources we own, such as a Certificate created from spec.certificateTemplate
+	resources, err = l.ResourceManager.ObserveResources(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if !isSubjectReadyForReconciliation(ctx) {
+	// A subject moved to another partition is left to the operator of that partition
+	if l.observePartitionHandOver(ctx) {
+		return resources, nil
+	}
+
+	// Reflect why the Certificate isn't ready, so that users can see why nothing is happening
+	ready, reason, message := isSubjectReadyForReconciliation(ctx)
+	l.ObservedState.CertificateSourceReady = ready
+	l.ObservedState.CertificateSourceReason = reason
+	l.ObservedState.CertificateSourceMessage = message
+	l.ObservedState.SourceIssues = observeSourceIssues(ctx)
+
+	if !ready {
 		// Requeue after 30s to allow the certificate to be created and ready for reconciliation
 		ctx.Log.Info("Requeueing in 30s")
 		ctx.SetRequeue(30 * time.Second)
 
-		return genrec.Resources{}, nil
+		return resources, nil
+	}
+
+	// Catch input that Fastly would reject before talking to it, so the failure is attributed to the Secret
+	invalidInput, err := validateFastlyInput(ctx)
+	if err != nil {
+		return resources, err
+	}
+	if invalidInput != "" {
+		ctx.Log.Info("Certificate input is invalid, skipping sync", "reason", invalidInput)
+		l.ObservedState.InvalidInput = invalidInput
+
+		return resources, nil
+	}
+
+	// A Secret left unparsable, such as by a corrupted rotation, leaves Fastly as it was last synced until it recovers
+	localCertificateInvalid, err := validateLocalCertificate(ctx)
+	if err != nil {
+		return resources, err
+	}
+	if localCertificateInvalid != "" {
+		ctx.Log.Info("Local certificate is invalid, leaving Fastly untouched until it recovers", "reason", localCertificateInvalid)
+		l.ObservedState.LocalCertificateInvalid = localCertificateInvalid
+
+		return resources, nil
 	}
 
 	l.SubjectReadyForReconciliation = true
 
-	// Begin observation
+	// Domains the certificate can't serve are reported before any activation is attempted for them
+	l.observeDomainCoverage(ctx)
+	l.observeCACertificate(ctx)
+
+	// Subjects syncing to several accounts observe each of them in turn
+	if len(ctx.Subject.Spec.Accounts) > 0 {
+		if err := l.observeFastlyAccounts(ctx); err != nil {
+			return resources, l.recordObserveFailure(ctx, err)
+		}
+	} else {
+		// Talk to the Fastly account that the subject's namespace is routed to
+		if err := l.resolveFastlyClient(ctx); err != nil {
+			return resources, err
+		}
+		l.observeAccountQuota(ctx)
+
+		// A rollback stands in for syncing the source Certificate until it is renewed
+		rolledBack, err := l.observeRollback(ctx)
+		if err != nil {
+			return resources, l.recordObserveFailure(ctx, err)
+		}
+		if rolledBack {
+			return resources, nil
+		}
+
+		// Spot checks of subjects that are in sync don't need to observe all of Fastly
+		if l.quickDriftCheck(ctx) {
+			return resources, nil
+		}
+
+		if err := l.observeFastly(ctx); err != nil {
+			return resources, l.recordObserveFailure(ctx, err)
+		}
+
+		l.observeServiceDomains(ctx)
+		l.observeDomainVerification(ctx)
+	}
+
+	// Defer any pending changes once the subject, or the operator as a whole, has exhausted its write budget
+	if l.ObservedState.hasPendingMutations() {
+		allowed, retryAfter := l.checkMutationBudget(ctx)
+		l.ObservedState.MutationBudgetExceeded = !allowed
+		l.ObservedState.MutationBudgetRetryAfter = retryAfter
+	}
+
+	return resources, nil
+}
+
+// observeFastly observes the private key, certificate and TLS activations of the subject in the current account
+func (l *Logic) observeFastly(ctx *Context) error {
 	// First, the private key must exist in Fastly
+	start := time.Now()
 	fastlyPrivateKeyExists, err := l.getFastlyPrivateKeyExists(ctx)
+	observeReconcileStep(ctx, reconcileStepKeyCheck, start, err)
 	if err != nil {
-		return genrec.Resources{}, err
+		return err
 	}
 	l.ObservedState.PrivateKeyUploaded = fastlyPrivateKeyExists
+	l.withFastlyKeyLogValues(ctx)
 
 	// Second, the certificate must be present and up to date (synced) in Fastly
+	start = time.Now()
 	fastlyCertificateStatus, err := l.getFastlyCertificateStatus(ctx)
+	observeReconcileStep(ctx, reconcileStepCertificateMatch, start, err)
 	if err != nil {
-		return genrec.Resources{}, err
+		return err
 	}
 	l.ObservedState.CertificateStatus = fastlyCertificateStatus
 
+	// Certificates the operator didn't create must be adopted before we touch them, the certificate matched above
+	// is looked up again in the snapshot of the listing
+	fastlyCertificate, err := l.getFastlyCertificateMatchingSubject(ctx)
+	if err != nil {
+		return err
+	}
+	trackedID, err := l.trackedFastlyCertificateID(ctx)
+	if err != nil {
+		return err
+	}
+	l.ObservedState.CertificateAdoptionRequired = isFastlyCertificateAdoptionRequired(ctx, fastlyCertificate, fastlyCertificateStatus, trackedID)
+	l.ObservedState.CertificateRenameRequired = isFastlyCertificateRenameRequired(ctx, fastlyCertificate, l.ObservedState.CertificateAdoptionRequired)
+	l.ObservedState.FastlyCertificate = fastlyCertificate
+	l.withFastlyCertificateLogValues(ctx)
+	l.observeWriterConflict(ctx)
+
+	// Configurations may be selected by their attributes rather than listed, discover which ones match
+	if ctx.Subject.Spec.TLSConfigurationSelector != nil {
+		selected, err := l.selectFastlyTLSConfigurations(ctx)
+		if err != nil {
+			return err
+		}
+		l.ObservedState.TLSConfigurationsSelected = true
+		l.ObservedState.SelectedTLSConfigurationIDs = selected
+	}
+
+	// Without any configurations to activate the certificate on, fall back to the account's default one when enabled
+	if err := l.observeDefaultTLSConfiguration(ctx); err != nil {
+		return err
+	}
+
 	// Third, TLS activations must be present for all desired configurations
+	start = time.Now()
 	missingTLSActivationData, extraTLSActivationIDs, err := l.getFastlyTLSActivationState(ctx)
+	observeReconcileStep(ctx, reconcileStepActivationDiff, start, err)
 	if err != nil {
-		return genrec.Resources{}, err
+		return err
 	}
 	l.ObservedState.MissingTLSActivationData = missingTLSActivationData
 	l.ObservedState.ExtraTLSActivationIDs = extraTLSActivationIDs
 
-	// Lastly, unused private keys must be removed from Fastly
-	unusedPrivateKeyIDs, err := l.getFastlyUnusedPrivateKeyIDs(ctx)
-	if err != nil {
-		return genrec.Resources{}, err
-	}
-	l.ObservedState.UnusedPrivateKeyIDs = unusedPrivateKeyIDs
-
-	return genrec.Resources{}, nil
+	// TLS activations left on the certificate replaced by a rotation are moved over rather than created again
+	return l.observePreviousFastlyCertificate(ctx)
 }
 
 func (l *Logic) ApplyUnmanaged(ctx *Context) error {
+	defer recoverPanic(ctx, reconcilePhaseApply)
+
+	if l.ObservedState.PartitionHandedOver {
+		return l.handOverPartition(ctx)
+	}
+
 	if !l.SubjectReadyForReconciliation {
 		ctx.Log.Info("Subject is not ready for reconciliation, skipping")
 		return nil
 	}
 
-	ctx.Log.Info("applying unmanaged FastlyCertificateSync", "name", ctx.Subject.Name, "namespace", ctx.Subject.Namespace)
+	ctx.Log.Info("applying unmanaged FastlyCertificateSync")
+
+	l.notifyCertificateExpiring(ctx)
+
+	if ctx.Subject.IsObserveOnly() {
+		ctx.Log.Info("Subject is suspended in observe only mode, skipping changes", "pending_changes", l.ObservedState.pendingMutations())
+		return nil
+	}
+
+	if err := l.labelSources(ctx); err != nil {
+		return fmt.Errorf("failed to label sources: %w", err)
+	}
+
+	if l.ObservedState.MutationsPaused {
+		ctx.Log.Info("Fastly write operations are paused, skipping changes", "pending_changes", l.ObservedState.pendingMutations())
+		return nil
+	}
+
+	if l.ObservedState.MutationBudgetExceeded {
+		ctx.Log.Info("Fastly mutation budget exceeded, deferring changes", "retry_after", l.ObservedState.MutationBudgetRetryAfter)
+		ctx.SetRequeue(l.ObservedState.MutationBudgetRetryAfter)
+		return nil
+	}
+
+	if len(l.accountObservations) > 0 {
+		return l.applyFastlyAccounts(ctx)
+	}
+
+	if l.ObservedState.Rollback.standsIn() {
+		return l.applyRollback(ctx)
+	}
+
+	// Refusing to touch a certificate we don't own is not a failed sync, so it is not backed off
+	if err := l.checkCertificateAdoption(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := l.applyFastlyChanges(ctx)
+	observeReconcilePhase(ctx, reconcilePhaseApply, start, err)
+	if err != nil {
+		return l.recordSyncFailure(ctx, err)
+	}
+
+	if err := l.recordCertificateWrite(ctx); err != nil {
+		return err
+	}
+
+	return l.resetSyncFailures(ctx)
+}
+
+// checkCertificateAdoption refuses changes to a certificate the operator didn't create that hasn't been adopted
+func (l *Logic) checkCertificateAdoption(ctx *Context) error {
+	if l.ObservedState.CertificateAdoptionRequired &&
+		(l.ObservedState.CertificateStatus == CertificateStatusStale ||
+			len(l.ObservedState.MissingTLSActivationData) > 0 ||
+			len(l.ObservedState.ExtraTLSActivationIDs) > 0) {
+		err := fmt.Errorf("refusing to modify Fastly certificate that was not created by the operator, set spec.adoptExisting or annotate the FastlyCertificateSync with %s=true to adopt it", AdoptFastlyCertificateAnnotation)
+		l.notify(ctx, NotificationCertificateConflict, err.Error())
+		return err
+	}
+	return nil
+}
+
+// applyFastlyChanges makes the next change needed to bring Fastly in line with the observed state
+func (l *Logic) applyFastlyChanges(ctx *Context) error {
+	// Externally managed private keys are never read, someone else is responsible for uploading them
+	if !l.ObservedState.PrivateKeyUploaded && ctx.Subject.IsPrivateKeyExternal() {
+		return fmt.Errorf("private key is managed externally, but no private key with public key SHA1 %s exists in Fastly", l.ObservedState.PrivateKeyPublicKeySHA1)
+	}
 
 	if !l.ObservedState.PrivateKeyUploaded {
 		ctx.Log.Info("Private key is not uploaded, doing that now...")
 
+		// Every write is reserved against the budget, the check before applying only covers the first one
+		if !l.reserveFastlyWrite(ctx) {
+			return nil
+		}
 		if err := l.createFastlyPrivateKey(ctx); err != nil {
-			return fmt.Errorf("failed to create Fastly private key: %w", err)
+			return withFastlyOperation("CreatePrivateKey", fmt.Errorf("failed to create Fastly private key: %w", err))
 		}
 
 		// Requeue immediately after altering state
-		ctx.Log.Info("Requeueing...")
-		ctx.SetRequeue(0)
+		l.requeueAfterFastlyChanges(ctx)
 
 		return nil
 	}
 
 	if l.ObservedState.CertificateStatus == CertificateStatusMissing {
 		ctx.Log.Info("Certificate is missing, creating new certificate in Fastly")
-		if err := l.createFastlyCertificate(ctx); err != nil {
-			return fmt.Errorf("failed to create Fastly certificate: %w", err)
+		if !l.reserveFastlyWrite(ctx) {
+			return nil
 		}
+		if _, err := l.createFastlyCertificate(ctx); err != nil {
+			return withFastlyOperation("CreateCustomTLSCertificate", fmt.Errorf("failed to create Fastly certificate: %w", err))
+		}
+		l.ObservedState.WrittenSerialNumber = l.ObservedState.LocalSerialNumber
 
-		ctx.Log.Info("Requeueing...")
-		ctx.SetRequeue(0)
+		l.requeueAfterFastlyChanges(ctx)
 
 		return nil
 	}
 
 	if l.ObservedState.CertificateStatus == CertificateStatusStale {
+		// Another writer overwrote our last update, back off rather than overwriting it right back
+		if l.holdOffConflictingWriter(ctx) {
+			return nil
+		}
+
 		ctx.Log.Info("Certificate is stale, updating certificate in Fastly")
+		if !l.reserveFastlyWrite(ctx) {
+			return nil
+		}
+		if err := l.updateFastlyCertificate(ctx); err != nil {
+			return withFastlyOperation("UpdateCustomTLSCertificate", fmt.Errorf("failed to update Fastly certificate: %w", err))
+		}
+		l.ObservedState.WrittenSerialNumber = l.ObservedState.LocalSerialNumber
+
+		l.requeueAfterFastlyChanges(ctx)
+		return nil
+	}
+
+	if l.ObservedState.CertificateRenameRequired {
+		ctx.Log.Info("Certificate goes by a previous name, renaming certificate in Fastly", "name", l.ObservedState.FastlyCertificate.Name)
+		if !l.reserveFastlyWrite(ctx) {
+			return nil
+		}
 		if err := l.updateFastlyCertificate(ctx); err != nil {
-			return fmt.Errorf("failed to update Fastly certificate: %w", err)
+			return withFastlyOperation("UpdateCustomTLSCertificate", fmt.Errorf("failed to rename Fastly certificate: %w", err))
+		}
+		l.ObservedState.WrittenSerialNumber = l.ObservedState.LocalSerialNumber
+
+		l.requeueAfterFastlyChanges(ctx)
+		return nil
+	}
+
+	if len(l.ObservedState.PreviousTLSActivations) > 0 {
+		ctx.Log.Info("TLS activations found on the previous certificate, moving them to the rotated certificate")
+		if err := l.movePreviousFastlyTLSActivations(ctx); err != nil {
+			return withFastlyOperation("UpdateTLSActivation", fmt.Errorf("failed to move Fastly TLS activations: %w", err))
 		}
 
-		ctx.Log.Info("Requeueing...")
-		ctx.SetRequeue(0)
+		l.requeueAfterFastlyChanges(ctx)
 		return nil
 	}
 
 	if len(l.ObservedState.MissingTLSActivationData) > 0 {
 		ctx.Log.Info("Missing TLS activations found, creating them in Fastly")
 		if err := l.createMissingFastlyTLSActivations(ctx); err != nil {
-			return fmt.Errorf("failed to create Fastly TLS activations: %w", err)
+			return withFastlyOperation("CreateTLSActivation", fmt.Errorf("failed to create Fastly TLS activations: %w", err))
 		}
 
-		ctx.Log.Info("Requeueing...")
-		ctx.SetRequeue(0)
+		l.requeueAfterFastlyChanges(ctx)
 		return nil
 	}
 
 	if len(l.ObservedState.ExtraTLSActivationIDs) > 0 {
 		ctx.Log.Info("Extra TLS activations found, deleting them from Fastly")
 		if err := l.deleteExtraFastlyTLSActivations(ctx); err != nil {
-			return fmt.Errorf("failed to delete Fastly TLS activations: %w", err)
+			return withFastlyOperation("DeleteTLSActivation", fmt.Errorf("failed to delete Fastly TLS activations: %w", err))
 		}
 
-		ctx.Log.Info("Requeueing...")
-		ctx.SetRequeue(0)
+		l.requeueAfterFastlyChanges(ctx)
 		return nil
 	}
 
-	if len(l.ObservedState.UnusedPrivateKeyIDs) > 0 {
-		ctx.Log.Info("Unused private keys found, deleting them from Fastly")
-		l.clearFastlyUnusedPrivateKeys(ctx)
+	if l.ObservedState.PreviousCertificateExpired {
+		if err := l.deletePreviousFastlyCertificate(ctx); err != nil {
+			return withFastlyOperation("DeleteCustomTLSCertificate", fmt.Errorf("failed to delete previous Fastly certificate: %w", err))
+		}
+	}
 
-		ctx.Log.Info("Requeueing...")
-		ctx.SetRequeue(0)
+	// Accounts synced together are only complete once every one of them is in sync
+	if l.currentAccount != nil {
 		return nil
 	}
 
+	return l.completeFastlySync(ctx)
+}
+
+// completeFastlySync follows up on Fastly being fully in sync
+func (l *Logic) completeFastlySync(ctx *Context) error {
+	// Let other automation watching the Certificate and Secret know
+	if err := l.annotateSyncResults(ctx); err != nil {
+		return fmt.Errorf("failed to annotate sync results: %w", err)
+	}
+
+	if err := l.scheduleDriftCheck(ctx); err != nil {
+		return fmt.Errorf("failed to schedule drift check: %w", err)
+	}
+	l.scheduleRenewalCheck(ctx)
+
 	return nil
 }
 
+// FinalizerKey attaches the Finalizer to every subject, so that deleted subjects are finalized
+func (l *Logic) FinalizerKey() string {
+	return Finalizer
+}
+
+// Finalize deletes the certificate from Fastly for subjects with spec.deletionPolicy Delete. Subjects retaining their
+// certificate are finalized right away, while failed deletions keep the subject around until they are retried.
+// Subjects protected from deletion are kept around, and reconciled as usual, until the protection is lifted.
 func (l *Logic) Finalize(ctx *Context) (genrec.FinalizationAction, error) {
-	// TODO: Implement finalization logic
-	// Return Continue to indicate finalization should continue
+	l.startCountingFastlyCalls()
+	if isDeletionProtected(ctx.Subject) {
+		return l.holdProtectedDeletion(ctx), nil
+	}
+	if err := l.deleteFastlyCertificates(ctx); err != nil {
+		return "", err
+	}
 	return genrec.FinalizationCompleted, nil
 }
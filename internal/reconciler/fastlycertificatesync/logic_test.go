@@ -0,0 +1,164 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogic_ApplyUnmanaged_ObserveOnly(t *testing.T) {
+	mockClient := &MockFastlyClient{}
+	logic := &Logic{
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded:    true,
+			CertificateStatus:     CertificateStatusSynced,
+			ExtraTLSActivationIDs: []string{"activation1"},
+		},
+		SubjectReadyForReconciliation: true,
+	}
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.Suspend = true
+	ctx.Subject.Spec.SuspendMode = v1alpha1.SuspendModeObserveOnly
+
+	require.NoError(t, logic.ApplyUnmanaged(ctx))
+	assert.Empty(t, mockClient.DeleteTLSActivationCalls, "no changes should be made while suspended")
+	assert.Nil(t, ctx.RequeueAfter)
+}
+
+func TestLogic_applyFastlyChanges_ExternalPrivateKey(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		CreatePrivateKeyFunc: func(ctx context.Context, input *fastly.CreatePrivateKeyInput) (*fastly.PrivateKey, error) {
+			t.Error("externally managed private keys are never uploaded")
+			return nil, nil
+		},
+	}
+	logic := &Logic{
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded:      false,
+			PrivateKeyPublicKeySHA1: "1ccf8849ae82aaab5749d5c791a221354f182a73",
+		},
+	}
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.PrivateKeyManagement = v1alpha1.PrivateKeyManagementExternal
+
+	err := logic.applyFastlyChanges(ctx)
+	assert.EqualError(t, err, "private key is managed externally, but no private key with public key SHA1 1ccf8849ae82aaab5749d5c791a221354f182a73 exists in Fastly")
+}
+
+func TestFastlyCertificateSync_IsSuspended(t *testing.T) {
+	tests := []struct {
+		name                string
+		suspend             bool
+		suspendMode         v1alpha1.SuspendMode
+		expectedSuspended   bool
+		expectedObserveOnly bool
+	}{
+		{name: "not_suspended", suspend: false, suspendMode: v1alpha1.SuspendModeObserveOnly, expectedSuspended: false, expectedObserveOnly: false},
+		{name: "suspended_default_mode", suspend: true, suspendMode: "", expectedSuspended: true, expectedObserveOnly: false},
+		{name: "suspended_full", suspend: true, suspendMode: v1alpha1.SuspendModeFull, expectedSuspended: true, expectedObserveOnly: false},
+		{name: "suspended_observe_only", suspend: true, suspendMode: v1alpha1.SuspendModeObserveOnly, expectedSuspended: false, expectedObserveOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := &v1alpha1.FastlyCertificateSync{
+				Spec: v1alpha1.FastlyCertificateSyncSpec{Suspend: tt.suspend, SuspendMode: tt.suspendMode},
+			}
+			assert.Equal(t, tt.expectedSuspended, subject.IsSuspended())
+			assert.Equal(t, tt.expectedObserveOnly, subject.IsObserveOnly())
+		})
+	}
+}
+
+func TestLogic_isEnqueueable(t *testing.T) {
+	const partitionAnnotation = "platform.seatgeek.io/partition"
+
+	tests := []struct {
+		name        string
+		spec        v1alpha1.FastlyCertificateSyncSpec
+		annotations map[string]string
+		partition   string
+		expected    bool
+	}{
+		{name: "active", expected: true},
+		{name: "suspended", spec: v1alpha1.FastlyCertificateSyncSpec{Suspend: true}},
+		{
+			name:     "observe_only",
+			spec:     v1alpha1.FastlyCertificateSyncSpec{Suspend: true, SuspendMode: v1alpha1.SuspendModeObserveOnly},
+			expected: true,
+		},
+		{name: "other_partition", annotations: map[string]string{partitionAnnotation: "canary"}},
+		{
+			name:        "same_partition",
+			annotations: map[string]string{partitionAnnotation: "canary"},
+			partition:   "canary",
+			expected:    true,
+		},
+		{name: "unpartitioned_in_partition", partition: "canary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := &Logic{Config: RuntimeConfig{PartitionAnnotation: partitionAnnotation, Partition: tt.partition}}
+			subject := &v1alpha1.FastlyCertificateSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace", Annotations: tt.annotations},
+				Spec:       tt.spec,
+			}
+			assert.Equal(t, tt.expected, logic.isEnqueueable(subject))
+		})
+	}
+}
+
+// BenchmarkLogic_ObserveResources observes a subject that is in sync with an account holding 5000 certificates, private
+// keys and TLS activations synced by others, reporting the requests made to Fastly per observation
+func BenchmarkLogic_ObserveResources(b *testing.B) {
+	const inventorySize = 5000
+
+	tests := []struct {
+		name              string
+		matchStrategy     v1alpha1.CertificateMatchStrategy
+		inventoryCacheTTL time.Duration
+	}{
+		{name: "listing_until_matched_by_name"},
+		{name: "listing_all_to_match_by_serial_number", matchStrategy: v1alpha1.CertificateMatchStrategySerialNumber},
+		{name: "cached_inventory", inventoryCacheTTL: time.Minute},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			account := &fakeFastlyAccount{}
+			account.seed(inventorySize)
+			ctx := newInterruptedSyncTestContext(b)
+			ctx.Subject.Spec.MatchStrategy = tt.matchStrategy
+			ctx.Config.FastlyInventoryCacheTTL = tt.inventoryCacheTTL
+			logic := &Logic{ResourceManager: ResourceManager, FastlyClient: account.client(b)}
+
+			// The subject's own certificate is created last, so that it is found on the last page of the listing
+			if err := syncFastly(ctx, logic); err != nil {
+				b.Fatalf("failed to sync the subject: %v", err)
+			}
+			account.requests = 0
+
+			b.ResetTimer()
+			for range b.N {
+				if _, err := logic.ObserveResources(ctx); err != nil {
+					b.Fatalf("failed to observe resources: %v", err)
+				}
+				if !logic.ObservedState.isSynced() {
+					b.Fatalf("expected the subject to be in sync, pending %v", logic.ObservedState.pendingMutations())
+				}
+			}
+			b.ReportMetric(float64(account.requests)/float64(b.N), "requests/op")
+		})
+	}
+}
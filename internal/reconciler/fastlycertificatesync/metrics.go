@@ -1,27 +1,201 @@
 package fastlycertificatesync
 
 import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+const (
+	reconcilePhaseFillDefaults = "fill_defaults"
+	reconcilePhaseObserve      = "observe"
+	reconcilePhaseFillStatus   = "fill_status"
+	reconcilePhaseApply        = "apply"
+	reconcilePhaseStandby      = "standby"
+
+	reconcileStepKeyCheck         = "key_check"
+	reconcileStepCertificateMatch = "certificate_match"
+	reconcileStepActivationDiff   = "activation_diff"
+
+	reconcileOutcomeSuccess = "success"
+	reconcileOutcomeError   = "error"
+)
+
+// reconcileDurationBuckets spans quick drift checks through subjects that page through large Fastly accounts
+var reconcileDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}
+
+var (
+	reconcilePhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fastly_certificate_sync_reconcile_phase_duration_seconds",
+		Help:    "Duration of the observe and apply phases of reconciling a FastlyCertificateSync",
+		Buckets: reconcileDurationBuckets,
+	}, []string{"namespace", "name", "phase", "outcome"})
+
+	reconcileStepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fastly_certificate_sync_reconcile_step_duration_seconds",
+		Help:    "Duration of the individual Fastly lookups made while observing a FastlyCertificateSync",
+		Buckets: reconcileDurationBuckets,
+	}, []string{"namespace", "name", "step", "outcome"})
+
+	// The following are labeled by namespace only, to keep their cardinality bounded by the number of tenants
+
+	reconcilesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fastly_certificate_sync_reconciles_total",
+		Help: "Reconciliations of FastlyCertificateSyncs, by the state they completed in",
+	}, []string{"namespace", "status"})
+
+	reconcileRequeuesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fastly_certificate_sync_reconcile_requeues_total",
+		Help: "Reconciliations of FastlyCertificateSyncs that scheduled another reconciliation",
+	}, []string{"namespace"})
+
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fastly_certificate_sync_reconcile_errors_total",
+		Help: "Reconciliations of FastlyCertificateSyncs that failed, by the state they failed in",
+	}, []string{"namespace", "status"})
+
+	privateKeyCleanupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fastly_certificate_sync_private_key_cleanups_total",
+		Help: "Unused private keys deleted from Fastly, by whether the deletion succeeded",
+	}, []string{"namespace", "outcome"})
+
+	certificateWatchMappingsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fastly_certificate_sync_certificate_watch_mappings_total",
+		Help: "Changes to Certificates seen by the FastlyCertificateSync controller, by the namespace of the Certificate and whether they were mapped to FastlyCertificateSyncs to reconcile",
+	}, []string{"namespace", "result"})
+
+	reconcilePanicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fastly_certificate_sync_reconcile_panics_total",
+		Help: "Panics recovered while reconciling FastlyCertificateSyncs, by the phase that panicked",
+	}, []string{"namespace", "phase"})
+
+	fastlyAPICallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fastly_certificate_sync_fastly_api_calls_total",
+		Help: "Fastly API calls made while reconciling a FastlyCertificateSync, by operation",
+	}, []string{"namespace", "name", "operation"})
+
+	reconcileFastlyAPICalls = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fastly_certificate_sync_reconcile_fastly_api_calls",
+		Help:    "Fastly API calls made by a single reconciliation of a FastlyCertificateSync",
+		Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500},
+	}, []string{"namespace"})
+
+	staleTooLong = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fastly_certificate_sync_stale_too_long",
+		Help: "Whether the certificate of a FastlyCertificateSync has been stale or missing in Fastly for longer than the stale threshold",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		reconcilePhaseDuration,
+		reconcileStepDuration,
+		reconcilesTotal,
+		reconcileRequeuesTotal,
+		reconcileErrorsTotal,
+		privateKeyCleanupsTotal,
+		certificateWatchMappingsTotal,
+		reconcilePanicsTotal,
+		fastlyAPICallsTotal,
+		reconcileFastlyAPICalls,
+		staleTooLong,
+	)
+}
+
+func reconcileOutcome(err error) string {
+	if err != nil {
+		return reconcileOutcomeError
+	}
+	return reconcileOutcomeSuccess
+}
+
+// observeReconcilePhase records how long a phase of the subject's reconciliation took since start
+func observeReconcilePhase(c *Context, phase string, start time.Time, err error) {
+	reconcilePhaseDuration.
+		WithLabelValues(c.Namespace, c.Name, phase, reconcileOutcome(err)).
+		Observe(time.Since(start).Seconds())
+}
+
+// observeReconcileStep records how long a step of observing the subject took since start
+func observeReconcileStep(c *Context, step string, start time.Time, err error) {
+	reconcileStepDuration.
+		WithLabelValues(c.Namespace, c.Name, step, reconcileOutcome(err)).
+		Observe(time.Since(start).Seconds())
+}
+
+// deleteSubjectMetrics drops every series labeled with the subject, so deleted subjects don't linger in scrapes
+func deleteSubjectMetrics(c *Context) {
+	labels := prometheus.Labels{"namespace": c.Namespace, "name": c.Name}
+	reconcilePhaseDuration.DeletePartialMatch(labels)
+	reconcileStepDuration.DeletePartialMatch(labels)
+	fastlyAPICallsTotal.DeletePartialMatch(labels)
+	staleTooLong.DeletePartialMatch(labels)
+}
+
+// countReconcile attributes the reconciliation, and whether it requeued or failed, to the subject's namespace.
+// Aborted and retried reconciliations are transient and not counted as errors.
+func countReconcile(c *Context, rs genrec.ReconciliationStatus, err error) {
+	reconcilesTotal.WithLabelValues(c.Namespace, string(rs)).Inc()
+
+	if c.RequeueAfter != nil {
+		reconcileRequeuesTotal.WithLabelValues(c.Namespace).Inc()
+	}
+
+	if err != nil && !errors.Is(err, genrec.ErrAbort) && !errors.Is(err, genrec.ErrRetry) {
+		reconcileErrorsTotal.WithLabelValues(c.Namespace, string(rs)).Inc()
+	}
+}
+
+// countSyncFailure attributes a failed change to Fastly to the subject's namespace. Those are backed off rather than
+// returned to the framework, so countReconcile never sees them.
+func countSyncFailure(c *Context) {
+	reconcileErrorsTotal.WithLabelValues(c.Namespace, string(genrec.ApplyError)).Inc()
+}
+
+// countPrivateKeyCleanup attributes the deletion of an unused private key to the namespace it was uploaded for, empty
+// when its name doesn't tell
+func countPrivateKeyCleanup(namespace string, err error) {
+	privateKeyCleanupsTotal.WithLabelValues(namespace, reconcileOutcome(err)).Inc()
+}
+
+// countCertificateWatchMapping records what the Certificate watch decided for a change to the Certificate
+func countCertificateWatchMapping(certificate client.Object, result string) {
+	certificateWatchMappingsTotal.WithLabelValues(certificate.GetNamespace(), result).Inc()
+}
+
+// setStaleTooLongMetric reflects the subject's StaleTooLong condition, so that stuck subjects can be alerted on
+func setStaleTooLongMetric(c *Context) {
+	value := 0.0
+	if apimeta.IsStatusConditionTrue(c.Subject.Status.Conditions, staleTooLongConditionType) {
+		value = 1
+	}
+	staleTooLong.WithLabelValues(c.Namespace, c.Name).Set(value)
+}
+
 func (l *Logic) ReconcileComplete(c *Context, rs genrec.ReconciliationStatus, err error) {
-	if c.Subject == nil {
+	l.reportFastlyCalls(c)
+
+	if rs != genrec.PartitionMismatch { // ignore subjects in other partitions
+		countReconcile(c, rs, err)
+	}
+
+	// The subject is hidden once it is deleted or moved to another partition, so its series are keyed by the request
+	if rs == genrec.SubjectNotFound || rs == genrec.PartitionMismatch {
+		deleteSubjectMetrics(c)
 		return
 	}
 
-	if rs == genrec.PartitionMismatch { // ignore subjects in other partitions
+	if c.Subject == nil {
 		return
 	}
 
 	switch rs { //nolint:exhaustive
-	case genrec.SubjectNotFound, genrec.PartitionMismatch:
-		// TODO: delete all relevant gauges for this subject
-
 	case genrec.Okay:
-		// TODO: zero out all gauges
-
-		// TODO: set any relevant gauges if observed
+		setStaleTooLongMetric(c)
 	}
-
-	// TODO: report reconciliation errors but ignore transient errors
 }
@@ -0,0 +1,97 @@
+package fastlycertificatesync
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReconcileMetrics(t *testing.T) {
+	ctx := createTestContext()
+	ctx.NamespacedName = types.NamespacedName{Namespace: "metrics-namespace", Name: "metrics-cert-sync"}
+	other := createTestContext()
+	other.NamespacedName = types.NamespacedName{Namespace: "metrics-namespace", Name: "other-cert-sync"}
+	t.Cleanup(func() {
+		deleteSubjectMetrics(ctx)
+		deleteSubjectMetrics(other)
+	})
+
+	phases := countSeries(reconcilePhaseDuration)
+	steps := countSeries(reconcileStepDuration)
+
+	start := time.Now()
+	observeReconcilePhase(ctx, reconcilePhaseObserve, start, nil)
+	observeReconcilePhase(ctx, reconcilePhaseApply, start, errors.New("failed"))
+	observeReconcilePhase(ctx, reconcilePhaseApply, start, nil)
+	observeReconcileStep(ctx, reconcileStepKeyCheck, start, nil)
+	observeReconcileStep(other, reconcileStepActivationDiff, start, nil)
+
+	assert.Equal(t, phases+3, countSeries(reconcilePhaseDuration), "series are labeled by phase and outcome")
+	assert.Equal(t, steps+2, countSeries(reconcileStepDuration), "series are labeled by subject")
+
+	// Deleted subjects drop their series, leaving other subjects untouched
+	(&Logic{}).ReconcileComplete(ctx, genrec.SubjectNotFound, nil)
+	assert.Equal(t, phases, countSeries(reconcilePhaseDuration))
+	assert.Equal(t, steps+1, countSeries(reconcileStepDuration))
+	assert.Equal(t, 1, reconcileStepDuration.DeletePartialMatch(prometheus.Labels{"name": "other-cert-sync"}))
+}
+
+func TestReconcileComplete_Counters(t *testing.T) {
+	ctx := createTestContext()
+	ctx.NamespacedName = types.NamespacedName{Namespace: "counters-namespace", Name: "a"}
+	t.Cleanup(func() {
+		labels := prometheus.Labels{"namespace": "counters-namespace"}
+		reconcilesTotal.DeletePartialMatch(labels)
+		reconcileRequeuesTotal.DeletePartialMatch(labels)
+		reconcileErrorsTotal.DeletePartialMatch(labels)
+		staleTooLong.DeletePartialMatch(labels)
+	})
+
+	logic := &Logic{}
+	logic.ReconcileComplete(ctx, genrec.Okay, nil)
+	ctx.SetRequeue(time.Minute)
+	logic.ReconcileComplete(ctx, genrec.Okay, nil)
+	logic.ReconcileComplete(ctx, genrec.ApplyError, errors.New("failed"))
+	logic.ReconcileComplete(ctx, genrec.ObserveResourcesError, fmt.Errorf("conflict: %w", genrec.ErrRetry))
+	logic.ReconcileComplete(ctx, genrec.PartitionMismatch, nil)
+	countSyncFailure(ctx)
+
+	assert.Equal(t, 2.0, counterValue(t, reconcilesTotal.WithLabelValues("counters-namespace", string(genrec.Okay))))
+	assert.Equal(t, 1.0, counterValue(t, reconcilesTotal.WithLabelValues("counters-namespace", string(genrec.ApplyError))))
+	assert.Equal(t, 3.0, counterValue(t, reconcileRequeuesTotal.WithLabelValues("counters-namespace")))
+	assert.Equal(t, 2.0, counterValue(t, reconcileErrorsTotal.WithLabelValues("counters-namespace", string(genrec.ApplyError))),
+		"failed Fastly changes that are backed off are counted too")
+	assert.Equal(t, 0.0, counterValue(t, reconcileErrorsTotal.WithLabelValues("counters-namespace", string(genrec.ObserveResourcesError))),
+		"transient errors are not counted")
+	assert.Equal(t, 0.0, counterValue(t, reconcilesTotal.WithLabelValues("counters-namespace", string(genrec.PartitionMismatch))),
+		"subjects in other partitions are not counted")
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	require.NoError(t, counter.Write(metric))
+	return metric.GetCounter().GetValue()
+}
+
+func countSeries(collector prometheus.Collector) int {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collector.Collect(ch)
+		close(ch)
+	}()
+
+	n := 0
+	for range ch {
+		n++
+	}
+	return n
+}
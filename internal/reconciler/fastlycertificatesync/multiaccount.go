@@ -0,0 +1,220 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+)
+
+// fastlyAccountObservation is what was observed of Fastly in one of the accounts in spec.accounts
+type fastlyAccountObservation struct {
+	account v1alpha1.FastlyAccount
+	client  FastlyClientInterface
+	// label identifies the token the client was created from, see fastlyAccount
+	label string
+	state ObservedState
+}
+
+// useFastlyAccount points the client, observed state and TLS configuration IDs at one of the accounts in
+// spec.accounts, so that everything written for a single account applies to it as is. A nil observation resets
+// them to the subject's namespace.
+func (l *Logic) useFastlyAccount(obs *fastlyAccountObservation) {
+	if obs == nil {
+		l.currentAccount = nil
+		l.namespaceFastlyClient = nil
+		l.namespaceFastlyAccount = ""
+		return
+	}
+
+	l.currentAccount = &obs.account
+	l.namespaceFastlyClient = obs.client
+	l.namespaceFastlyAccount = obs.label
+	l.ObservedState = obs.state
+}
+
+// tlsConfigurationIDs returns the TLS configuration IDs to activate the certificate on, in the current account,
+// including those discovered with spec.tlsConfigurationSelector or the account's default TLS configuration
+func (l *Logic) tlsConfigurationIDs(ctx *Context) []string {
+	if l.ObservedState.DefaultTLSConfigurationID != "" {
+		return []string{l.ObservedState.DefaultTLSConfigurationID}
+	}
+	if l.currentAccount != nil {
+		return l.currentAccount.TLSConfigurationIds
+	}
+	if len(l.ObservedState.SelectedTLSConfigurationIDs) == 0 {
+		return ctx.Subject.Spec.TLSConfigurationIds
+	}
+
+	res := slices.Clone(ctx.Subject.Spec.TLSConfigurationIds)
+	for _, id := range l.ObservedState.SelectedTLSConfigurationIDs {
+		if !slices.Contains(res, id) {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// accountName returns the name of the current account in spec.accounts, empty when syncing to a single account
+func (l *Logic) accountName() string {
+	if l.currentAccount != nil {
+		return l.currentAccount.Name
+	}
+	return ""
+}
+
+// observeFastlyAccounts observes each of the accounts in spec.accounts in turn, and merges what was observed so that
+// the subject as a whole is only in sync once every account is
+func (l *Logic) observeFastlyAccounts(ctx *Context) error {
+	source := l.ObservedState
+	defer l.useFastlyAccount(nil)
+
+	// The IDs of the Fastly objects observed in an account only go on the lines logged for that account
+	log := ctx.Log
+	defer func() { ctx.Log = log }()
+
+	for _, account := range ctx.Subject.Spec.Accounts {
+		ctx.Log = log.WithValues(logKeyAccount, account.Name)
+		if err := l.resolveFastlyAccountClient(ctx, account); err != nil {
+			return err
+		}
+		l.currentAccount = &account
+		l.ObservedState = source
+
+		if err := l.observeFastly(ctx); err != nil {
+			return fmt.Errorf("failed to observe Fastly account %s: %w", account.Name, err)
+		}
+
+		l.accountObservations = append(l.accountObservations, fastlyAccountObservation{
+			account: account,
+			client:  l.namespaceFastlyClient,
+			label:   l.namespaceFastlyAccount,
+			state:   l.ObservedState,
+		})
+	}
+
+	l.ObservedState = mergeAccountObservations(source, l.accountObservations)
+	return nil
+}
+
+// mergeAccountObservations combines the state observed in each account into the state of the subject as a whole
+func mergeAccountObservations(source ObservedState, observations []fastlyAccountObservation) ObservedState {
+	res := source
+	res.PrivateKeyUploaded = true
+	res.CertificateStatus = CertificateStatusSynced
+
+	for _, obs := range observations {
+		state := obs.state
+		res.PrivateKeyUploaded = res.PrivateKeyUploaded && state.PrivateKeyUploaded
+		if res.PrivateKeyPublicKeySHA1 == "" {
+			res.PrivateKeyPublicKeySHA1 = state.PrivateKeyPublicKeySHA1
+		}
+		if res.PrivateKeyPublicKeySHA256 == "" {
+			res.PrivateKeyPublicKeySHA256 = state.PrivateKeyPublicKeySHA256
+		}
+
+		// A missing certificate takes precedence over a stale one
+		switch {
+		case state.CertificateStatus == CertificateStatusMissing:
+			res.CertificateStatus = CertificateStatusMissing
+		case state.CertificateStatus == CertificateStatusStale && res.CertificateStatus != CertificateStatusMissing:
+			res.CertificateStatus = CertificateStatusStale
+		}
+
+		res.CertificateAdoptionRequired = res.CertificateAdoptionRequired || state.CertificateAdoptionRequired
+		res.CertificateRenameRequired = res.CertificateRenameRequired || state.CertificateRenameRequired
+		if res.FastlyCertificate == nil {
+			res.FastlyCertificate = state.FastlyCertificate
+		}
+		res.MissingTLSActivationData = append(res.MissingTLSActivationData, state.MissingTLSActivationData...)
+		res.ExtraTLSActivationIDs = append(res.ExtraTLSActivationIDs, state.ExtraTLSActivationIDs...)
+	}
+
+	return res
+}
+
+// applyFastlyAccounts makes the next change needed in each of the accounts in spec.accounts. An account that fails
+// to sync, or holds a certificate we don't own, doesn't hold back the others.
+func (l *Logic) applyFastlyAccounts(ctx *Context) error {
+	merged := l.ObservedState
+	var failures, refusals []error
+
+	for i := range l.accountObservations {
+		obs := &l.accountObservations[i]
+		l.useFastlyAccount(obs)
+
+		if err := l.checkCertificateAdoption(ctx); err != nil {
+			refusals = append(refusals, fmt.Errorf("account %s: %w", obs.account.Name, err))
+			continue
+		}
+
+		start := time.Now()
+		err := l.applyFastlyChanges(ctx)
+		observeReconcilePhase(ctx, reconcilePhaseApply, start, err)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("account %s: %w", obs.account.Name, err))
+		}
+		merged.TLSActivationResults = append(merged.TLSActivationResults, l.ObservedState.TLSActivationResults...)
+	}
+
+	l.useFastlyAccount(nil)
+	l.ObservedState = merged
+
+	// Fastly is fully in sync once it is in every account
+	if len(failures) == 0 && len(refusals) == 0 && !l.ObservedState.hasPendingMutations() {
+		if err := l.completeFastlySync(ctx); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	var err error
+	if len(failures) > 0 {
+		err = l.recordSyncFailure(ctx, joinErrors(failures))
+	} else {
+		err = l.resetSyncFailures(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Refusing to touch a certificate we don't own is not a failed sync, so it is not backed off
+	return joinErrors(refusals)
+}
+
+// accountStatuses reports the sync state of each of the accounts in spec.accounts, using the same conditions as the
+// subject as a whole
+func (l *Logic) accountStatuses(ctx *Context) []v1alpha1.FastlyAccountStatus {
+	if len(l.accountObservations) == 0 {
+		return nil
+	}
+
+	merged := l.ObservedState
+	defer func() {
+		l.useFastlyAccount(nil)
+		l.ObservedState = merged
+	}()
+
+	res := make([]v1alpha1.FastlyAccountStatus, 0, len(l.accountObservations))
+	for i := range l.accountObservations {
+		obs := &l.accountObservations[i]
+		l.useFastlyAccount(obs)
+
+		status := v1alpha1.FastlyAccountStatus{
+			Name:  obs.account.Name,
+			Ready: obs.state.isSynced(),
+			Conditions: l.generateStatusConditions(ctx,
+				l.observePrivateKeyReadyCondition,
+				l.observeCertificateReadyCondition,
+				l.observeTLSActivationReadyCondition,
+			),
+		}
+		// Like status.certificateId, only certificates created or adopted by the operator are tracked
+		if obs.state.FastlyCertificate != nil && !obs.state.CertificateAdoptionRequired {
+			status.CertificateID = obs.state.FastlyCertificate.ID
+		}
+		res = append(res, status)
+	}
+
+	return res
+}
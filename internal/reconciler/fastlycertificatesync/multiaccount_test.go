@@ -0,0 +1,170 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMergeAccountObservations(t *testing.T) {
+	source := ObservedState{CertificateSourceReady: true}
+	production := &fastly.CustomTLSCertificate{ID: "cert-production"}
+
+	merged := mergeAccountObservations(source, []fastlyAccountObservation{
+		{state: ObservedState{
+			PrivateKeyUploaded:    true,
+			CertificateStatus:     CertificateStatusStale,
+			FastlyCertificate:     production,
+			ExtraTLSActivationIDs: []string{"activation1"},
+		}},
+		{state: ObservedState{
+			PrivateKeyUploaded: false,
+			CertificateStatus:  CertificateStatusMissing,
+		}},
+		{state: ObservedState{
+			PrivateKeyUploaded:          true,
+			CertificateStatus:           CertificateStatusStale,
+			CertificateAdoptionRequired: true,
+		}},
+	})
+
+	assert.True(t, merged.CertificateSourceReady, "the certificate source is shared by every account")
+	assert.False(t, merged.PrivateKeyUploaded, "the private key must be uploaded to every account")
+	assert.Equal(t, CertificateStatusMissing, merged.CertificateStatus, "a missing certificate takes precedence over a stale one")
+	assert.True(t, merged.CertificateAdoptionRequired)
+	assert.Same(t, production, merged.FastlyCertificate)
+	assert.Equal(t, []string{"activation1"}, merged.ExtraTLSActivationIDs)
+	assert.False(t, merged.isSynced())
+}
+
+func TestLogic_ApplyUnmanaged_MultipleAccounts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.TLSConfigurationIds = nil
+	ctx.Subject.Spec.Accounts = []v1alpha1.FastlyAccount{{Name: "production"}, {Name: "staging"}}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(ctx.Subject).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	production := &MockFastlyClient{
+		DeleteTLSActivationFunc: func(ctx context.Context, input *fastly.DeleteTLSActivationInput) error {
+			return errors.New("fastly unavailable")
+		},
+	}
+	staging := &MockFastlyClient{}
+
+	observations := []fastlyAccountObservation{
+		{
+			account: ctx.Subject.Spec.Accounts[0],
+			client:  production,
+			label:   "test-namespace/fastly-production",
+			state: ObservedState{
+				PrivateKeyUploaded:    true,
+				CertificateStatus:     CertificateStatusSynced,
+				ExtraTLSActivationIDs: []string{"activation1"},
+			},
+		},
+		{
+			account: ctx.Subject.Spec.Accounts[1],
+			client:  staging,
+			label:   "test-namespace/fastly-staging",
+			state: ObservedState{
+				PrivateKeyUploaded:    true,
+				CertificateStatus:     CertificateStatusSynced,
+				ExtraTLSActivationIDs: []string{"activation2"},
+			},
+		},
+	}
+	logic := &Logic{
+		ObservedState:                 mergeAccountObservations(ObservedState{}, observations),
+		SubjectReadyForReconciliation: true,
+		accountObservations:           observations,
+	}
+
+	require.NoError(t, logic.ApplyUnmanaged(ctx))
+
+	// Failing to sync one account doesn't hold back the other
+	assert.Equal(t, []string{"activation1"}, production.DeleteTLSActivationCalls)
+	assert.Equal(t, []string{"activation2"}, staging.DeleteTLSActivationCalls)
+
+	stored := &v1alpha1.FastlyCertificateSync{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(ctx.Subject), stored))
+	assert.Equal(t, 1, stored.Status.ConsecutiveFailures)
+
+	// Every account is handled as its own, nothing is left pointing at the last one
+	assert.Nil(t, logic.currentAccount)
+	assert.Nil(t, logic.namespaceFastlyClient)
+}
+
+func TestLogic_FillStatus_MultipleAccounts(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Spec.Accounts = []v1alpha1.FastlyAccount{{Name: "production"}, {Name: "staging"}}
+
+	observations := []fastlyAccountObservation{
+		{
+			account: ctx.Subject.Spec.Accounts[0],
+			state: ObservedState{
+				PrivateKeyUploaded: true,
+				CertificateStatus:  CertificateStatusSynced,
+				FastlyCertificate:  &fastly.CustomTLSCertificate{ID: "cert-production"},
+			},
+		},
+		{
+			account: ctx.Subject.Spec.Accounts[1],
+			state: ObservedState{
+				PrivateKeyUploaded: true,
+				CertificateStatus:  CertificateStatusMissing,
+			},
+		},
+	}
+	logic := &Logic{
+		ObservedState:                 mergeAccountObservations(ObservedState{CertificateSourceReady: true}, observations),
+		SubjectReadyForReconciliation: true,
+		accountObservations:           observations,
+	}
+
+	require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+
+	status := ctx.Subject.Status
+	assert.False(t, status.Ready, "the subject is only ready once every account is")
+	require.Len(t, status.Accounts, 2)
+
+	assert.Equal(t, "production", status.Accounts[0].Name)
+	assert.True(t, status.Accounts[0].Ready)
+	assert.Equal(t, "cert-production", status.Accounts[0].CertificateID)
+
+	assert.Equal(t, "staging", status.Accounts[1].Name)
+	assert.False(t, status.Accounts[1].Ready)
+	assert.Empty(t, status.Accounts[1].CertificateID)
+
+	reasons := map[string]string{}
+	for _, condition := range status.Accounts[1].Conditions {
+		reasons[condition.Type] = condition.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"PrivateKeyReady":    "PrivateKeyUploaded",
+		"CertificateReady":   "CertificateMissing",
+		"TLSActivationReady": "TLSActivationsSynced",
+	}, reasons)
+
+	// The merged state is left in place for applying changes
+	assert.Equal(t, CertificateStatusMissing, logic.ObservedState.CertificateStatus)
+}
@@ -0,0 +1,145 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// MutationPausedKey is the key of the mutation pause ConfigMap that halts every Fastly write operation when "true"
+const MutationPausedKey = "paused"
+
+// mutationPauseRetryInterval is how soon writers that aren't reconciled once the pause is lifted, such as the sweep of
+// unused private keys and config store syncs, retry the writes refused while paused
+const mutationPauseRetryInterval = time.Minute
+
+// refreshMutationPause reads the mutation pause ConfigMap, a missing one lifting the pause, and reports whether Fastly
+// write operations are paused. Failing to read the ConfigMap pauses them until it is read again, as it may well be
+// pausing them, even when the operator was restarted since it was last read.
+func (l *Logic) refreshMutationPause(ctx context.Context, reader client.Reader) (bool, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := reader.Get(ctx, l.Config.MutationPauseConfigMap, configMap); err != nil && !apierrors.IsNotFound(err) {
+		if !l.mutationsPaused.Swap(true) {
+			ctrl.Log.Info("Fastly write operations are paused until the mutation pause ConfigMap can be read", "configMap", l.Config.MutationPauseConfigMap)
+		}
+		return true, fmt.Errorf("failed to get mutation pause ConfigMap %s: %w", l.Config.MutationPauseConfigMap, err)
+	}
+
+	paused := isMutationPaused(configMap)
+	if l.mutationsPaused.Swap(paused) != paused {
+		if paused {
+			ctrl.Log.Info("Fastly write operations are paused, only observing until the pause is lifted", "configMap", l.Config.MutationPauseConfigMap)
+		} else {
+			ctrl.Log.Info("Fastly write operations are no longer paused", "configMap", l.Config.MutationPauseConfigMap)
+		}
+	}
+	return paused, nil
+}
+
+// isMutationPaused reports whether the mutation pause ConfigMap pauses Fastly write operations
+func isMutationPaused(configMap *corev1.ConfigMap) bool {
+	return strings.EqualFold(strings.TrimSpace(configMap.Data[MutationPausedKey]), "true")
+}
+
+// areFastlyMutationsPaused reports whether Fastly write operations are paused, as last read
+func (l *Logic) areFastlyMutationsPaused() bool {
+	return l.mutationsPaused.Load()
+}
+
+// observeMutationPause records whether Fastly write operations are paused, so that the subject reports it
+func (l *Logic) observeMutationPause(ctx *Context) {
+	if l.Config.MutationPauseConfigMap.Name == "" {
+		return
+	}
+
+	paused, err := l.refreshMutationPause(ctx, ctx.Client.Client)
+	if err != nil {
+		ctx.Log.Error(err, "failed to observe the mutation pause, pausing Fastly write operations")
+		l.ObservedState.MutationPauseUnreadable = err.Error()
+	}
+	l.ObservedState.MutationsPaused = paused
+}
+
+// mutationPauseChangedPredicate passes the creation, deletion, and changes to the pause, of the mutation pause
+// ConfigMap
+func mutationPauseChangedPredicate(configMap types.NamespacedName) predicate.Funcs {
+	isPauseConfigMap := func(object client.Object) bool {
+		return object.GetNamespace() == configMap.Namespace && object.GetName() == configMap.Name
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return isPauseConfigMap(e.Object) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return isPauseConfigMap(e.Object) },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldConfigMap, ok := e.ObjectOld.(*corev1.ConfigMap)
+			if !ok || !isPauseConfigMap(oldConfigMap) {
+				return false
+			}
+			newConfigMap, ok := e.ObjectNew.(*corev1.ConfigMap)
+			return ok && isMutationPaused(oldConfigMap) != isMutationPaused(newConfigMap)
+		},
+	}
+}
+
+// mapMutationPauseToSubjects reconciles every FastlyCertificateSync once the mutation pause is set or lifted, so that
+// each reports the pause right away, and catches up on the changes deferred while it was set
+func (l *Logic) mapMutationPauseToSubjects(ctx context.Context, reader client.Reader) []reconcile.Request {
+	if _, err := l.refreshMutationPause(ctx, reader); err != nil {
+		ctrl.Log.Error(err, "failed to observe the mutation pause")
+	}
+
+	all := v1alpha1.FastlyCertificateSyncList{}
+	if err := reader.List(ctx, &all, &client.ListOptions{Namespace: kmetav1.NamespaceAll}); err != nil {
+		ctrl.Log.Error(err, "could not list FastlyCertificateSync resources to reconcile after the mutation pause changed")
+		return nil
+	}
+
+	res := []reconcile.Request{}
+	for _, subject := range all.Items {
+		if l.isEnqueueable(&subject) {
+			res = append(res, reconcile.Request{NamespacedName: types.NamespacedName{Name: subject.Name, Namespace: subject.Namespace}})
+		}
+	}
+	return res
+}
+
+// observeMutationsPausedCondition generates the condition for subjects whose changes are held back by the mutation
+// pause, listing them. It is omitted while Fastly write operations aren't paused.
+func (l *Logic) observeMutationsPausedCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if !l.ObservedState.MutationsPaused {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type:   "MutationsPaused",
+		Status: kmetav1.ConditionTrue,
+		Reason: "FastlyMutationsPaused",
+	}
+
+	prefix := fmt.Sprintf("Fastly write operations are paused by ConfigMap %s", l.Config.MutationPauseConfigMap)
+	if unreadable := l.ObservedState.MutationPauseUnreadable; unreadable != "" {
+		condition.Reason = "MutationPauseUnreadable"
+		prefix = fmt.Sprintf("Fastly write operations are paused until ConfigMap %s can be read (%s)", l.Config.MutationPauseConfigMap, unreadable)
+	}
+	if pending := l.ObservedState.pendingMutations(); l.SubjectReadyForReconciliation && len(pending) > 0 {
+		condition.Message = fmt.Sprintf("%s, pending changes: %s", prefix, strings.Join(pending, ", "))
+	} else {
+		condition.Message = prefix
+	}
+
+	return condition, nil
+}
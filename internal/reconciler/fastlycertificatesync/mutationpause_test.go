@@ -0,0 +1,184 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var testMutationPauseConfigMap = types.NamespacedName{Namespace: "operator", Name: "fastly-tls-operator-mutation-pause"}
+
+func newMutationPauseConfigMap(paused string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: testMutationPauseConfigMap.Name, Namespace: testMutationPauseConfigMap.Namespace},
+		Data:       map[string]string{MutationPausedKey: paused},
+	}
+}
+
+func TestLogic_observeMutationPause(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name           string
+		objects        []client.Object
+		expectedPaused bool
+	}{
+		{name: "missing"},
+		{name: "paused", objects: []client.Object{newMutationPauseConfigMap("true")}, expectedPaused: true},
+		{name: "not_paused", objects: []client.Object{newMutationPauseConfigMap("false")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objects...).Build()},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+			logic := &Logic{Config: RuntimeConfig{MutationPauseConfigMap: testMutationPauseConfigMap}}
+
+			logic.observeMutationPause(ctx)
+			assert.Equal(t, tt.expectedPaused, logic.ObservedState.MutationsPaused)
+			assert.Equal(t, tt.expectedPaused, logic.areFastlyMutationsPaused())
+
+			allowed, _ := logic.ReserveFastlyMutation(ctx.NamespacedName)
+			assert.Equal(t, !tt.expectedPaused, allowed, "nothing is reserved while paused")
+		})
+	}
+}
+
+func TestLogic_observeMutationPause_Unreadable(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	unreadable := true
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(newMutationPauseConfigMap("false")).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if unreadable {
+					return apierrors.NewForbidden(corev1.Resource("configmaps"), key.Name, errors.New("denied"))
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+	ctx := createTestContext()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	// A freshly started operator never read the pause
+	logic := &Logic{Config: RuntimeConfig{MutationPauseConfigMap: testMutationPauseConfigMap}}
+
+	logic.observeMutationPause(ctx)
+	assert.True(t, logic.ObservedState.MutationsPaused, "writes are paused while the pause can't be read")
+	assert.True(t, logic.areFastlyMutationsPaused())
+	assert.Contains(t, logic.ObservedState.MutationPauseUnreadable, "denied")
+
+	cnd, err := logic.observeMutationsPausedCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "MutationPauseUnreadable", cnd.Reason)
+	assert.Contains(t, cnd.Message, "Fastly write operations are paused until ConfigMap operator/fastly-tls-operator-mutation-pause can be read")
+
+	unreadable = false
+	logic.ObservedState = ObservedState{}
+	logic.observeMutationPause(ctx)
+	assert.False(t, logic.ObservedState.MutationsPaused, "lifted once the pause is read again")
+	assert.False(t, logic.areFastlyMutationsPaused())
+	assert.Empty(t, logic.ObservedState.MutationPauseUnreadable)
+}
+
+func TestLogic_ApplyUnmanaged_MutationsPaused(t *testing.T) {
+	mockClient := &MockFastlyClient{}
+	logic := &Logic{
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded:    true,
+			CertificateStatus:     CertificateStatusSynced,
+			ExtraTLSActivationIDs: []string{"activation1"},
+			MutationsPaused:       true,
+		},
+		SubjectReadyForReconciliation: true,
+	}
+
+	require.NoError(t, logic.ApplyUnmanaged(createTestContext()))
+	assert.Empty(t, mockClient.DeleteTLSActivationCalls, "no mutations should be made while paused")
+}
+
+func TestMutationPauseChangedPredicate(t *testing.T) {
+	predicate := mutationPauseChangedPredicate(testMutationPauseConfigMap)
+	other := newMutationPauseConfigMap("true")
+	other.Name = "other"
+
+	assert.True(t, predicate.Create(event.CreateEvent{Object: newMutationPauseConfigMap("false")}))
+	assert.False(t, predicate.Create(event.CreateEvent{Object: other}))
+	assert.True(t, predicate.Delete(event.DeleteEvent{Object: newMutationPauseConfigMap("true")}))
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: newMutationPauseConfigMap("false"), ObjectNew: newMutationPauseConfigMap("true")}))
+	assert.False(t, predicate.Update(event.UpdateEvent{ObjectOld: newMutationPauseConfigMap("true"), ObjectNew: newMutationPauseConfigMap("TRUE")}),
+		"changes that don't set or lift the pause are dropped")
+	assert.False(t, predicate.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: newMutationPauseConfigMap("false")}))
+}
+
+func TestLogic_mapMutationPauseToSubjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newMutationPauseConfigMap("true"),
+		&v1alpha1.FastlyCertificateSync{ObjectMeta: metav1.ObjectMeta{Name: "active", Namespace: "team-a"}},
+		&v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "suspended", Namespace: "team-a"},
+			Spec:       v1alpha1.FastlyCertificateSyncSpec{Suspend: true},
+		},
+	).Build()
+	logic := &Logic{Config: RuntimeConfig{MutationPauseConfigMap: testMutationPauseConfigMap}}
+
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "active", Namespace: "team-a"}}},
+		logic.mapMutationPauseToSubjects(context.Background(), reader))
+	assert.True(t, logic.areFastlyMutationsPaused())
+}
+
+func TestLogic_observeMutationsPausedCondition(t *testing.T) {
+	ctx := createTestContext()
+
+	logic := &Logic{}
+	cnd, err := logic.observeMutationsPausedCondition(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cnd, "omitted while not paused")
+
+	logic = &Logic{
+		Config: RuntimeConfig{MutationPauseConfigMap: testMutationPauseConfigMap},
+		ObservedState: ObservedState{
+			PrivateKeyUploaded: true,
+			CertificateStatus:  CertificateStatusStale,
+			MutationsPaused:    true,
+		},
+		SubjectReadyForReconciliation: true,
+	}
+	cnd, err = logic.observeMutationsPausedCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionTrue, cnd.Status)
+	assert.Equal(t, "FastlyMutationsPaused", cnd.Reason)
+	assert.Equal(t, "Fastly write operations are paused by ConfigMap operator/fastly-tls-operator-mutation-pause, pending changes: update certificate",
+		cnd.Message)
+}
@@ -0,0 +1,94 @@
+package fastlycertificatesync
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+)
+
+var (
+	// nameTemplatePlaceholder matches the placeholders of spec.nameTemplate, such as {labels.team}
+	nameTemplatePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+	// nameTemplateLiteral matches the characters allowed around placeholders, which label values are limited to as well
+	nameTemplateLiteral = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+)
+
+// renderFastlyNameTemplate renders spec.nameTemplate for the named source object, the Certificate or its secret.
+// Labels are those of the subject, so that the names of its certificate and private key agree.
+func renderFastlyNameTemplate(subject *v1alpha1.FastlyCertificateSync, namespace, name string) (string, error) {
+	template := subject.Spec.NameTemplate
+
+	var res strings.Builder
+	last := 0
+	for _, loc := range nameTemplatePlaceholder.FindAllStringIndex(template, -1) {
+		if err := writeNameTemplateLiteral(&res, template[last:loc[0]]); err != nil {
+			return "", err
+		}
+		last = loc[1]
+
+		placeholder := template[loc[0]+1 : loc[1]-1]
+		switch {
+		case placeholder == "namespace":
+			res.WriteString(namespace)
+		case placeholder == "name":
+			res.WriteString(name)
+		case strings.HasPrefix(placeholder, "labels."):
+			key := strings.TrimPrefix(placeholder, "labels.")
+			value := subject.GetLabels()[key]
+			if value == "" {
+				return "", fmt.Errorf("label %s is not set", key)
+			}
+			res.WriteString(value)
+		default:
+			return "", fmt.Errorf("unknown placeholder {%s}", placeholder)
+		}
+	}
+	if err := writeNameTemplateLiteral(&res, template[last:]); err != nil {
+		return "", err
+	}
+
+	if res.Len() == 0 {
+		return "", errors.New("renders to an empty name")
+	}
+	return res.String(), nil
+}
+
+func writeNameTemplateLiteral(res *strings.Builder, literal string) error {
+	if !nameTemplateLiteral.MatchString(literal) {
+		return fmt.Errorf("%q may only contain letters, digits, '.', '_' and '-' outside of placeholders", literal)
+	}
+	res.WriteString(literal)
+	return nil
+}
+
+// templatedFastlyName returns the name that takes the place of the source object's name in the names of Fastly
+// objects, which is the name itself unless spec.nameTemplate is set
+func templatedFastlyName(ctx *Context, namespace, name string) string {
+	if ctx.Subject.Spec.NameTemplate == "" {
+		return name
+	}
+
+	// Invalid templates are reported by validateFastlyInput before anything is created, keep the plain name
+	rendered, err := renderFastlyNameTemplate(ctx.Subject, namespace, name)
+	if err != nil {
+		return name
+	}
+	return rendered
+}
+
+// validateNameTemplate reports why spec.nameTemplate can't be rendered for the source Certificate, such as a label
+// it refers to missing from the subject
+func validateNameTemplate(ctx *Context) string {
+	if ctx.Subject.Spec.NameTemplate == "" {
+		return ""
+	}
+
+	ref := certificateReference(ctx.Subject)
+	if _, err := renderFastlyNameTemplate(ctx.Subject, ref.Namespace, ref.Name); err != nil {
+		return fmt.Sprintf("spec.nameTemplate %q is invalid: %v", ctx.Subject.Spec.NameTemplate, err)
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderFastlyNameTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		expected    string
+		expectedErr string
+	}{
+		{name: "name", template: "{name}", expected: "web-tls"},
+		{name: "labels and literals", template: "{labels.team}_{namespace}.{name}-v2", expected: "payments_team-a.web-tls-v2"},
+		{name: "qualified label key", template: "{labels.example.com/owner}-{name}", expected: "alice-web-tls"},
+		{name: "literal only", template: "shared-tls", expected: "shared-tls"},
+		{name: "missing label", template: "{labels.cost-center}-{name}", expectedErr: "label cost-center is not set"},
+		{name: "empty label", template: "{labels.empty}", expectedErr: "label empty is not set"},
+		{name: "unknown placeholder", template: "{cluster}-{name}", expectedErr: "unknown placeholder {cluster}"},
+		{name: "invalid literal", template: "{name}/tls", expectedErr: `"/tls" may only contain letters, digits, '.', '_' and '-' outside of placeholders`},
+		{name: "unbalanced brace", template: "{name", expectedErr: `"{name" may only contain letters, digits, '.', '_' and '-' outside of placeholders`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := &v1alpha1.FastlyCertificateSync{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "payments", "example.com/owner": "alice", "empty": ""}},
+				Spec:       v1alpha1.FastlyCertificateSyncSpec{NameTemplate: tt.template},
+			}
+
+			rendered, err := renderFastlyNameTemplate(subject, "team-a", "web-tls")
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rendered)
+		})
+	}
+}
+
+func TestFastlyObjectName_NameTemplate(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Labels = map[string]string{"team": "payments"}
+	ctx.Subject.Spec.NameTemplate = "{labels.team}-{name}"
+	ctx.Config.FastlyObjectNamePrefix = "k8s-"
+
+	assert.Equal(t, "k8s-payments-web-tls", fastlyObjectName(ctx, "team-a", "web-tls"))
+	assert.Equal(t, []string{"k8s-payments-web-tls", "k8s-web-tls", "web-tls"}, fastlyObjectNameCandidates(ctx, "team-a", "web-tls"),
+		"certificates named before the template was set are renamed")
+
+	// The cluster name and namespace are still embedded, so that the operator can tell its objects apart
+	ctx.Config.FastlyClusterName = "east"
+	name := fastlyObjectName(ctx, "team-a", "web-tls")
+	assert.Equal(t, "k8s-east--team-a--payments-web-tls", name)
+	assert.True(t, isFastlyObjectOwned(ctx, name))
+	assert.Equal(t, []string{"k8s-east--team-a--payments-web-tls", "k8s-east--team-a--web-tls", "k8s-web-tls", "web-tls"},
+		fastlyObjectNameCandidates(ctx, "team-a", "web-tls"))
+
+	// Templates that can't be rendered keep the plain name, the subject is reported as invalid before anything is named
+	ctx.Subject.Labels = nil
+	assert.Equal(t, "k8s-east--team-a--web-tls", fastlyObjectName(ctx, "team-a", "web-tls"))
+}
+
+func TestValidateNameTemplate(t *testing.T) {
+	ctx := createTestContext()
+	assert.Empty(t, validateNameTemplate(ctx))
+
+	ctx.Subject.Spec.NameTemplate = "{labels.team}-{name}"
+	assert.Equal(t, `spec.nameTemplate "{labels.team}-{name}" is invalid: label team is not set`, validateNameTemplate(ctx))
+
+	ctx.Subject.Labels = map[string]string{"team": "payments"}
+	assert.Empty(t, validateNameTemplate(ctx))
+}
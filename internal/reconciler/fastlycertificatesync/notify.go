@@ -0,0 +1,213 @@
+package fastlycertificatesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NotificationKind identifies the situation a notification is sent for
+type NotificationKind string
+
+const (
+	// NotificationSyncFailed is sent when a change to Fastly fails
+	NotificationSyncFailed NotificationKind = "SyncFailed"
+	// NotificationCertificateExpiring is sent when the certificate served by Fastly is about to expire
+	NotificationCertificateExpiring NotificationKind = "CertificateExpiring"
+	// NotificationCertificateConflict is sent when the certificate in Fastly is not owned by the operator
+	NotificationCertificateConflict NotificationKind = "CertificateConflict"
+)
+
+// notificationTimeout bounds how long sending a notification may take. Notifications are sent in the background, so
+// a slow webhook never holds up reconciliation.
+const notificationTimeout = 10 * time.Second
+
+// DefaultNotificationTemplate renders the text of a notification when no template is configured
+const DefaultNotificationTemplate = `FastlyCertificateSync {{ .Namespace }}/{{ .Name }}: {{ .Kind }}: {{ .Message }}`
+
+// Notification describes a situation with a subject that needs someone's attention
+type Notification struct {
+	Kind      NotificationKind `json:"kind"`
+	Namespace string           `json:"namespace"`
+	Name      string           `json:"name"`
+	Message   string           `json:"message"`
+}
+
+// Notifier delivers notifications to people, e.g. through a chat webhook
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// WebhookNotifier posts notifications as JSON to a webhook. The rendered template is sent as `text`, which is what
+// Slack incoming webhooks display, alongside the notification's fields for other consumers.
+type WebhookNotifier struct {
+	URL      string
+	Template *template.Template
+	Client   *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url, rendering messages with the given text/template.
+// An empty template uses DefaultNotificationTemplate.
+func NewWebhookNotifier(url, tmpl string) (*WebhookNotifier, error) {
+	if tmpl == "" {
+		tmpl = DefaultNotificationTemplate
+	}
+
+	parsed, err := template.New("notification").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+
+	return &WebhookNotifier{
+		URL:      url,
+		Template: parsed,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	var text bytes.Buffer
+	if err := w.Template.Execute(&text, n); err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+		Notification
+	}{Text: text.String(), Notification: n})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// notificationLimiter remembers when each kind of notification was last sent for each subject, so that a subject
+// stuck in the same situation doesn't flood the channel on every reconciliation
+type notificationLimiter struct {
+	mu     sync.Mutex
+	sentAt map[notificationLimiterKey]time.Time
+	now    func() time.Time
+}
+
+type notificationLimiterKey struct {
+	subject types.NamespacedName
+	kind    NotificationKind
+}
+
+func (n *notificationLimiter) clock() time.Time {
+	if n.now != nil {
+		return n.now()
+	}
+	return time.Now()
+}
+
+// Allow reports whether a notification of the kind may be sent for the subject, and if so records it as sent, so that
+// concurrent reconciles don't send it twice. Expired entries are pruned as a side effect.
+func (n *notificationLimiter) Allow(subject types.NamespacedName, kind NotificationKind, interval time.Duration) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.clock()
+	for k, sentAt := range n.sentAt {
+		if now.Sub(sentAt) >= interval {
+			delete(n.sentAt, k)
+		}
+	}
+
+	key := notificationLimiterKey{subject: subject, kind: kind}
+	if _, ok := n.sentAt[key]; ok {
+		return false
+	}
+
+	if n.sentAt == nil {
+		n.sentAt = map[notificationLimiterKey]time.Time{}
+	}
+	n.sentAt[key] = now
+	return true
+}
+
+// Release forgets a notification that failed to send, so that the next reconciliation tries again
+func (n *notificationLimiter) Release(subject types.NamespacedName, kind NotificationKind) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.sentAt, notificationLimiterKey{subject: subject, kind: kind})
+}
+
+// notify sends a notification about the subject, unless notifications are disabled or one of the same kind was sent
+// within the notification interval. The notification is sent in the background, failing to send it never fails the
+// reconciliation, and it is only counted against the interval once the webhook accepted it.
+func (l *Logic) notify(ctx *Context, kind NotificationKind, message string) {
+	if l.Notifier == nil {
+		return
+	}
+
+	subject := types.NamespacedName{Namespace: ctx.Subject.Namespace, Name: ctx.Subject.Name}
+	if !l.notifications.Allow(subject, kind, l.Config.NotificationInterval) {
+		ctx.Log.V(5).Info("notification was sent recently, skipping", "kind", kind)
+		return
+	}
+
+	notification := Notification{
+		Kind:      kind,
+		Namespace: subject.Namespace,
+		Name:      subject.Name,
+		Message:   message,
+	}
+	log := ctx.Log
+	l.notificationsInFlight.Add(1)
+	go func() {
+		defer l.notificationsInFlight.Done()
+
+		sendCtx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+		defer cancel()
+		if err := l.Notifier.Notify(sendCtx, notification); err != nil {
+			log.Info("failed to send notification", "kind", kind, "error", err.Error())
+			l.notifications.Release(subject, kind)
+		}
+	}()
+}
+
+// notifyCertificateExpiring warns when the certificate served by Fastly expires within the configured threshold, and
+// isn't about to be replaced by a renewed certificate
+func (l *Logic) notifyCertificateExpiring(ctx *Context) {
+	certificate := l.ObservedState.FastlyCertificate
+	threshold := l.Config.NotificationExpiryThreshold
+	if threshold <= 0 || certificate == nil || certificate.NotAfter == nil {
+		return
+	}
+	if l.ObservedState.CertificateStatus == CertificateStatusStale {
+		return
+	}
+
+	remaining := time.Until(*certificate.NotAfter)
+	if remaining > threshold {
+		return
+	}
+
+	l.notify(ctx, NotificationCertificateExpiring, fmt.Sprintf("Fastly certificate %s expires at %s and has not been refreshed",
+		certificate.ID, certificate.NotAfter.UTC().Format(time.RFC3339)))
+}
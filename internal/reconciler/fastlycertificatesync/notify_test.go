@@ -0,0 +1,165 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []Notification
+	err           error
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifications = append(r.notifications, n)
+	return r.err
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, "")
+	require.NoError(t, err)
+	require.NoError(t, notifier.Notify(context.Background(), Notification{
+		Kind:      NotificationSyncFailed,
+		Namespace: "ns",
+		Name:      "a",
+		Message:   "boom",
+	}))
+
+	assert.Equal(t, map[string]string{
+		"text":      "FastlyCertificateSync ns/a: SyncFailed: boom",
+		"kind":      "SyncFailed",
+		"namespace": "ns",
+		"name":      "a",
+		"message":   "boom",
+	}, received)
+
+	notifier, err = NewWebhookNotifier(server.URL, ":rotating_light: {{ .Name }}")
+	require.NoError(t, err)
+	require.NoError(t, notifier.Notify(context.Background(), Notification{Name: "a"}))
+	assert.Equal(t, ":rotating_light: a", received["text"])
+
+	_, err = NewWebhookNotifier(server.URL, "{{ .Name")
+	assert.ErrorContains(t, err, "invalid notification template")
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, "")
+	require.NoError(t, err)
+	assert.EqualError(t, notifier.Notify(context.Background(), Notification{}), "notification webhook responded with 403 Forbidden")
+}
+
+func TestNotificationLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := &notificationLimiter{now: func() time.Time { return now }}
+	subject := types.NamespacedName{Namespace: "ns", Name: "a"}
+
+	assert.True(t, limiter.Allow(subject, NotificationSyncFailed, time.Hour))
+	assert.False(t, limiter.Allow(subject, NotificationSyncFailed, time.Hour), "repeated notifications are suppressed")
+	assert.True(t, limiter.Allow(subject, NotificationCertificateConflict, time.Hour), "limits are per kind")
+	assert.True(t, limiter.Allow(types.NamespacedName{Namespace: "ns", Name: "b"}, NotificationSyncFailed, time.Hour), "limits are per subject")
+
+	now = now.Add(time.Hour)
+	assert.True(t, limiter.Allow(subject, NotificationSyncFailed, time.Hour), "notifications are sent again after the interval")
+
+	limiter.Release(subject, NotificationSyncFailed)
+	assert.True(t, limiter.Allow(subject, NotificationSyncFailed, time.Hour), "notifications that failed to send are retried")
+}
+
+func TestLogic_notify(t *testing.T) {
+	ctx := createTestContext()
+	notifier := &recordingNotifier{}
+	logic := &Logic{Notifier: notifier, Config: RuntimeConfig{NotificationInterval: time.Hour}}
+
+	logic.notify(ctx, NotificationSyncFailed, "boom")
+	logic.notificationsInFlight.Wait()
+	logic.notify(ctx, NotificationSyncFailed, "boom again")
+	logic.notificationsInFlight.Wait()
+
+	assert.Equal(t, []Notification{{
+		Kind:      NotificationSyncFailed,
+		Namespace: "test-namespace",
+		Name:      "test-cert-sync",
+		Message:   "boom",
+	}}, notifier.notifications)
+
+	// Notifications that failed to send don't count against the interval
+	notifier = &recordingNotifier{err: errors.New("webhook unavailable")}
+	logic = &Logic{Notifier: notifier, Config: RuntimeConfig{NotificationInterval: time.Hour}}
+	logic.notify(ctx, NotificationSyncFailed, "boom")
+	logic.notificationsInFlight.Wait()
+	logic.notify(ctx, NotificationSyncFailed, "boom again")
+	logic.notificationsInFlight.Wait()
+	assert.Len(t, notifier.notifications, 2)
+
+	// Notifications are disabled without a notifier
+	(&Logic{}).notify(ctx, NotificationSyncFailed, "boom")
+}
+
+func TestLogic_notifyCertificateExpiring(t *testing.T) {
+	soon := time.Now().Add(24 * time.Hour)
+	later := time.Now().Add(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		notAfter *time.Time
+		status   CertificateStatus
+		expected bool
+	}{
+		{name: "expiring", notAfter: &soon, status: CertificateStatusSynced, expected: true},
+		{name: "not_expiring", notAfter: &later, status: CertificateStatusSynced},
+		{name: "being_refreshed", notAfter: &soon, status: CertificateStatusStale},
+		{name: "unknown_expiry", status: CertificateStatusSynced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &recordingNotifier{}
+			logic := &Logic{
+				Notifier: notifier,
+				Config:   RuntimeConfig{NotificationExpiryThreshold: 7 * 24 * time.Hour},
+				ObservedState: ObservedState{
+					CertificateStatus: tt.status,
+					FastlyCertificate: &fastly.CustomTLSCertificate{ID: "cert1", NotAfter: tt.notAfter},
+				},
+			}
+
+			logic.notifyCertificateExpiring(createTestContext())
+			logic.notificationsInFlight.Wait()
+
+			if tt.expected {
+				require.Len(t, notifier.notifications, 1)
+				assert.Equal(t, NotificationCertificateExpiring, notifier.notifications[0].Kind)
+				assert.Contains(t, notifier.notifications[0].Message, "Fastly certificate cert1 expires at")
+			} else {
+				assert.Empty(t, notifier.notifications)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+)
+
+// AdoptFastlyCertificateAnnotation allows a FastlyCertificateSync to take over a Fastly certificate whose name
+// does not carry the operator's owned prefix, e.g. one previously created by hand or by Terraform.
+const AdoptFastlyCertificateAnnotation = "platform.seatgeek.io/adopt-fastly-certificate"
+
+// fastlyObjectNameSeparator separates the cluster, namespace and name embedded into the names of Fastly objects
+const fastlyObjectNameSeparator = "--"
+
+// fastlyObjectOwner is what the name of a Fastly object created by the operator tells about its source
+type fastlyObjectOwner struct {
+	Cluster   string
+	Namespace string
+	Name      string
+}
+
+// fastlyObjectName returns the name given to Fastly objects created by the operator for the named source object.
+// With a cluster name configured, the name is structured as <prefix><cluster>--<namespace>--<name>, so that objects
+// created by different clusters or namespaces sharing an account never collide and can be told apart. With namespaced
+// names, it is <prefix><namespace>--<name>, and otherwise <prefix><name>. The name is rendered from spec.nameTemplate
+// when it is set.
+func fastlyObjectName(ctx *Context, namespace, name string) string {
+	return formatFastlyObjectName(ctx.Config.RuntimeConfig, namespace, templatedFastlyName(ctx, namespace, name))
+}
+
+// formatFastlyObjectName structures the name of a Fastly object as described by fastlyObjectName
+func formatFastlyObjectName(config RuntimeConfig, namespace, name string) string {
+	if config.FastlyClusterName == "" && !config.FastlyNamespacedObjectNames {
+		return config.FastlyObjectNamePrefix + name
+	}
+	return ownedFastlyNamePrefix(config) + namespace + fastlyObjectNameSeparator + name
+}
+
+// ownedFastlyNamePrefix returns the prefix shared by the names of every Fastly object the operator creates
+func ownedFastlyNamePrefix(config RuntimeConfig) string {
+	if config.FastlyClusterName == "" {
+		return config.FastlyObjectNamePrefix
+	}
+	return config.FastlyObjectNamePrefix + config.FastlyClusterName + fastlyObjectNameSeparator
+}
+
+// parseFastlyObjectName parses a name given by fastlyObjectName with a cluster name configured, of this or any other
+// cluster. Namespaces containing the separator are ambiguous, the namespace is taken to end at its first occurrence.
+func parseFastlyObjectName(config RuntimeConfig, objectName string) (fastlyObjectOwner, bool) {
+	rest, ok := strings.CutPrefix(objectName, config.FastlyObjectNamePrefix)
+	if !ok {
+		return fastlyObjectOwner{}, false
+	}
+	parts := strings.SplitN(rest, fastlyObjectNameSeparator, 3)
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return fastlyObjectOwner{}, false
+	}
+	return fastlyObjectOwner{Cluster: parts[0], Namespace: parts[1], Name: parts[2]}, true
+}
+
+// fastlyObjectNameCandidates returns the names a Fastly object created for the named source object may go by, the
+// name the operator gives it first. Names from before spec.nameTemplate was set or a cluster name or namespaced names
+// were configured, and the bare name of objects created elsewhere, are matched as well, such objects must be adopted
+// before they are modified.
+func fastlyObjectNameCandidates(ctx *Context, namespace, name string) []string {
+	res := []string{fastlyObjectName(ctx, namespace, name)}
+	if untemplated := formatFastlyObjectName(ctx.Config.RuntimeConfig, namespace, name); !slices.Contains(res, untemplated) {
+		res = append(res, untemplated)
+	}
+	if ctx.Config.FastlyClusterName != "" || ctx.Config.FastlyNamespacedObjectNames {
+		if prefixed := ctx.Config.FastlyObjectNamePrefix + name; !slices.Contains(res, prefixed) {
+			res = append(res, prefixed)
+		}
+	}
+	if !slices.Contains(res, name) {
+		res = append(res, name)
+	}
+	return res
+}
+
+// hasOwnedFastlyNames reports whether the names of Fastly objects tell which ones the operator created
+func hasOwnedFastlyNames(ctx *Context) bool {
+	return ownedFastlyNamePrefix(ctx.Config.RuntimeConfig) != ""
+}
+
+// isFastlyObjectOwned reports whether a Fastly object name falls within the operator's owned prefix, and was created
+// by this cluster when a cluster name is configured. Without either, the operator considers every object its own.
+func isFastlyObjectOwned(ctx *Context, name string) bool {
+	return isFastlyObjectNameOwned(ctx.Config.RuntimeConfig, name)
+}
+
+// isFastlyObjectNameOwned is isFastlyObjectOwned for callers working across subjects, such as the private key sweeper
+func isFastlyObjectNameOwned(config RuntimeConfig, name string) bool {
+	if config.FastlyClusterName == "" {
+		return strings.HasPrefix(name, config.FastlyObjectNamePrefix)
+	}
+	owner, ok := parseFastlyObjectName(config, name)
+	return ok && owner.Cluster == config.FastlyClusterName
+}
+
+// isFastlyCertificateAdopted reports whether the subject explicitly takes over Fastly certificates that the operator
+// did not create, with spec.adoptExisting or the adoption annotation
+func isFastlyCertificateAdopted(ctx *Context) bool {
+	return ctx.Subject.Spec.AdoptExisting || ctx.Subject.GetAnnotations()[AdoptFastlyCertificateAnnotation] == "true"
+}
+
+// isFastlyCertificateAdoptionRequired reports whether the given certificate must be explicitly adopted by the subject
+// before the operator is allowed to modify it. Certificates created by the operator carry the owned prefix, or the
+// cluster name, when one is configured. Without a prefix, they are known by trackedID, the ID the operator last synced; a certificate that
+// already holds the local certificate is safe to track as well, since there is nothing to overwrite.
+func isFastlyCertificateAdoptionRequired(ctx *Context, cert *fastly.CustomTLSCertificate, status CertificateStatus, trackedID string) bool {
+	if cert == nil || isFastlyCertificateAdopted(ctx) {
+		return false
+	}
+
+	// Names from before namespaced names were enabled may have been shared by Certificates of the same name in
+	// several namespaces, only the subject tracking the certificate takes it over
+	if ctx.Config.FastlyNamespacedObjectNames && ctx.Config.FastlyClusterName == "" {
+		ref := certificateReference(ctx.Subject)
+		if cert.Name != fastlyObjectName(ctx, ref.Namespace, ref.Name) && cert.ID != trackedID {
+			return true
+		}
+	}
+
+	if hasOwnedFastlyNames(ctx) {
+		return !isFastlyObjectOwned(ctx, cert.Name)
+	}
+
+	return cert.ID != trackedID && status != CertificateStatusSynced
+}
+
+// isFastlyCertificateRenameRequired reports whether a certificate the operator may modify still goes by one of the
+// names it was given before the current naming scheme, such as before namespaced names were enabled. Such
+// certificates are renamed right away, rather than only once the local certificate is renewed.
+func isFastlyCertificateRenameRequired(ctx *Context, cert *fastly.CustomTLSCertificate, adoptionRequired bool) bool {
+	if cert == nil || adoptionRequired {
+		return false
+	}
+
+	ref := certificateReference(ctx.Subject)
+	names := fastlyObjectNameCandidates(ctx, ref.Namespace, ref.Name)
+	return slices.Contains(names[1:], cert.Name)
+}
+
+// trackedFastlyCertificateID returns the ID of the Fastly certificate that the operator created or adopted for the
+// subject in the current account. It is read from status, falling back to the sync result annotations of the source
+// Certificate for subjects last synced before status tracked it.
+func (l *Logic) trackedFastlyCertificateID(ctx *Context) (string, error) {
+	if name := l.accountName(); name != "" {
+		for _, account := range ctx.Subject.Status.Accounts {
+			if account.Name == name {
+				return account.CertificateID, nil
+			}
+		}
+		return "", nil
+	}
+
+	if id := ctx.Subject.Status.CertificateID; id != "" {
+		return id, nil
+	}
+
+	certificate, _, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get certificate from context: %w", err)
+	}
+	return certificate.GetAnnotations()[FastlyCertificateIDAnnotation], nil
+}
+
+// externalPrivateKeySHA1s returns the public key SHA1s of the private keys that FastlyCertificateSyncs with
+// externally managed private keys rely on. Those keys were uploaded by someone else, and may sit unused in Fastly until
+// their certificate is created, so they must never be cleaned up as unused.
+func externalPrivateKeySHA1s(subjects []v1alpha1.FastlyCertificateSync) map[string]bool {
+	res := map[string]bool{}
+	for _, subject := range subjects {
+		if !subject.IsPrivateKeyExternal() {
+			continue
+		}
+		for _, sha1 := range []string{subject.Spec.PrivateKeyPublicKeySHA1, subject.Status.PrivateKeyPublicKeySHA1} {
+			if sha1 != "" {
+				res[sha1] = true
+			}
+		}
+	}
+	return res
+}
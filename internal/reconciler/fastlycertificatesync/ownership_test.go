@@ -0,0 +1,424 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsFastlyCertificateAdoptionRequired(t *testing.T) {
+	tests := []struct {
+		name          string
+		prefix        string
+		annotations   map[string]string
+		adoptExisting bool
+		cert          *fastly.CustomTLSCertificate
+		status        CertificateStatus
+		trackedID     string
+		expected      bool
+	}{
+		{
+			name:     "no certificate",
+			prefix:   "k8s-",
+			cert:     nil,
+			expected: false,
+		},
+		{
+			name:      "no prefix configured, certificate created by the operator",
+			prefix:    "",
+			cert:      &fastly.CustomTLSCertificate{ID: "cert1", Name: "test-certificate"},
+			status:    CertificateStatusStale,
+			trackedID: "cert1",
+			expected:  false,
+		},
+		{
+			name:     "no prefix configured, unknown certificate",
+			prefix:   "",
+			cert:     &fastly.CustomTLSCertificate{ID: "cert1", Name: "test-certificate"},
+			status:   CertificateStatusStale,
+			expected: true,
+		},
+		{
+			name:      "no prefix configured, certificate created by someone else",
+			prefix:    "",
+			cert:      &fastly.CustomTLSCertificate{ID: "cert1", Name: "test-certificate"},
+			status:    CertificateStatusStale,
+			trackedID: "cert2",
+			expected:  true,
+		},
+		{
+			name:     "no prefix configured, unknown certificate that is already synced",
+			prefix:   "",
+			cert:     &fastly.CustomTLSCertificate{ID: "cert1", Name: "test-certificate"},
+			status:   CertificateStatusSynced,
+			expected: false,
+		},
+		{
+			name:          "no prefix configured, unknown certificate adopted with spec.adoptExisting",
+			prefix:        "",
+			adoptExisting: true,
+			cert:          &fastly.CustomTLSCertificate{ID: "cert1", Name: "test-certificate"},
+			status:        CertificateStatusStale,
+			expected:      false,
+		},
+		{
+			name:     "certificate within owned prefix",
+			prefix:   "k8s-",
+			cert:     &fastly.CustomTLSCertificate{Name: "k8s-test-certificate"},
+			status:   CertificateStatusStale,
+			expected: false,
+		},
+		{
+			name:     "certificate outside owned prefix",
+			prefix:   "k8s-",
+			cert:     &fastly.CustomTLSCertificate{Name: "test-certificate"},
+			status:   CertificateStatusStale,
+			expected: true,
+		},
+		{
+			name:        "certificate outside owned prefix but adopted",
+			prefix:      "k8s-",
+			annotations: map[string]string{AdoptFastlyCertificateAnnotation: "true"},
+			cert:        &fastly.CustomTLSCertificate{Name: "test-certificate"},
+			status:      CertificateStatusStale,
+			expected:    false,
+		},
+		{
+			name:          "certificate outside owned prefix adopted with spec.adoptExisting",
+			prefix:        "k8s-",
+			adoptExisting: true,
+			cert:          &fastly.CustomTLSCertificate{Name: "test-certificate"},
+			status:        CertificateStatusStale,
+			expected:      false,
+		},
+		{
+			name:        "adoption annotation not true",
+			prefix:      "k8s-",
+			annotations: map[string]string{AdoptFastlyCertificateAnnotation: "yes"},
+			cert:        &fastly.CustomTLSCertificate{Name: "test-certificate"},
+			status:      CertificateStatusStale,
+			expected:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Config.FastlyObjectNamePrefix = tt.prefix
+			ctx.Subject.Annotations = tt.annotations
+			ctx.Subject.Spec.AdoptExisting = tt.adoptExisting
+
+			assert.Equal(t, tt.expected, isFastlyCertificateAdoptionRequired(ctx, tt.cert, tt.status, tt.trackedID))
+		})
+	}
+}
+
+func TestFastlyObjectName(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Config.FastlyObjectNamePrefix = "k8s-"
+	assert.Equal(t, "k8s-web-tls", fastlyObjectName(ctx, "team-a", "web-tls"))
+	assert.True(t, isFastlyObjectOwned(ctx, "k8s-web-tls"))
+
+	ctx.Config.FastlyClusterName = "east"
+	name := fastlyObjectName(ctx, "team-a", "web-tls")
+	assert.Equal(t, "k8s-east--team-a--web-tls", name)
+
+	owner, ok := parseFastlyObjectName(ctx.Config.RuntimeConfig, name)
+	require.True(t, ok)
+	assert.Equal(t, fastlyObjectOwner{Cluster: "east", Namespace: "team-a", Name: "web-tls"}, owner)
+
+	// Objects of other clusters, or from before the cluster name was configured, are not owned
+	assert.True(t, isFastlyObjectOwned(ctx, name))
+	assert.False(t, isFastlyObjectOwned(ctx, "k8s-west--team-a--web-tls"))
+	assert.False(t, isFastlyObjectOwned(ctx, "k8s-web-tls"))
+
+	for _, invalid := range []string{"web-tls", "k8s-web-tls", "k8s-east--web-tls", "k8s-east----web-tls"} {
+		_, ok := parseFastlyObjectName(ctx.Config.RuntimeConfig, invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestFastlyObjectName_Namespaced(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Config.FastlyObjectNamePrefix = "k8s-"
+	ctx.Config.FastlyNamespacedObjectNames = true
+
+	assert.Equal(t, "k8s-team-a--web-tls", fastlyObjectName(ctx, "team-a", "web-tls"))
+	assert.Equal(t, []string{"k8s-team-a--web-tls", "k8s-web-tls", "web-tls"},
+		fastlyObjectNameCandidates(ctx, "team-a", "web-tls"))
+	assert.Equal(t, "team-a", fastlyObjectNamespace(ctx.Config.RuntimeConfig, "k8s-team-a--web-tls"))
+	assert.Empty(t, fastlyObjectNamespace(ctx.Config.RuntimeConfig, "k8s-web-tls"))
+
+	// The cluster name takes precedence, it embeds the namespace as well
+	ctx.Config.FastlyClusterName = "east"
+	assert.Equal(t, "k8s-east--team-a--web-tls", fastlyObjectName(ctx, "team-a", "web-tls"))
+}
+
+func TestFastlyCertificate_NamespacedNamesMigration(t *testing.T) {
+	tests := []struct {
+		name             string
+		cert             *fastly.CustomTLSCertificate
+		trackedID        string
+		expectAdoption   bool
+		expectRenameTask bool
+	}{
+		{
+			name: "namespaced name",
+			cert: &fastly.CustomTLSCertificate{ID: "cert1", Name: "k8s-test-namespace--test-certificate"},
+		},
+		{
+			name:             "previous name tracked by the subject",
+			cert:             &fastly.CustomTLSCertificate{ID: "cert1", Name: "k8s-test-certificate"},
+			trackedID:        "cert1",
+			expectRenameTask: true,
+		},
+		{
+			name:           "previous name tracked by another subject",
+			cert:           &fastly.CustomTLSCertificate{ID: "cert1", Name: "k8s-test-certificate"},
+			trackedID:      "cert2",
+			expectAdoption: true,
+		},
+		{
+			name:           "previous name untracked",
+			cert:           &fastly.CustomTLSCertificate{ID: "cert1", Name: "k8s-test-certificate"},
+			expectAdoption: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Config.FastlyObjectNamePrefix = "k8s-"
+			ctx.Config.FastlyNamespacedObjectNames = true
+
+			adoptionRequired := isFastlyCertificateAdoptionRequired(ctx, tt.cert, CertificateStatusSynced, tt.trackedID)
+			assert.Equal(t, tt.expectAdoption, adoptionRequired)
+			assert.Equal(t, tt.expectRenameTask, isFastlyCertificateRenameRequired(ctx, tt.cert, adoptionRequired))
+		})
+	}
+}
+
+func TestLogic_ApplyFastlyChanges_RenamesCertificate(t *testing.T) {
+	var renamed *fastly.UpdateCustomTLSCertificateInput
+	mockClient := &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(_ context.Context, _ *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			return []*fastly.CustomTLSCertificate{{ID: "cert1", Name: "k8s-test-certificate"}}, nil
+		},
+		GetCustomTLSCertificateFunc: func(_ context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return &fastly.CustomTLSCertificate{ID: input.ID, Name: "k8s-test-certificate"}, nil
+		},
+		UpdateCustomTLSCertificateFunc: func(_ context.Context, input *fastly.UpdateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			renamed = input
+			return &fastly.CustomTLSCertificate{ID: input.ID, Name: input.Name}, nil
+		},
+	}
+
+	ctx := createTestContext()
+	ctx.Config.FastlyObjectNamePrefix = "k8s-"
+	ctx.Config.FastlyNamespacedObjectNames = true
+	ctx.Subject.Status.CertificateID = "cert1"
+
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+			Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"tls.crt": createTestCertPEM(t, time.Now().Add(24*time.Hour))},
+		},
+	).Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	logic := &Logic{
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			PrivateKeyUploaded:        true,
+			CertificateStatus:         CertificateStatusSynced,
+			CertificateRenameRequired: true,
+			FastlyCertificate:         &fastly.CustomTLSCertificate{ID: "cert1", Name: "k8s-test-certificate"},
+		},
+	}
+	require.NoError(t, logic.applyFastlyChanges(ctx))
+
+	require.NotNil(t, renamed)
+	assert.Equal(t, "cert1", renamed.ID)
+	assert.Equal(t, "k8s-test-namespace--test-certificate", renamed.Name)
+	require.NotNil(t, ctx.RequeueAfter)
+}
+
+func TestLogic_getFastlyCertificateMatchingSubject_OwnedPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterName string
+		certs       []*fastly.CustomTLSCertificate
+		expectedID  string
+	}{
+		{
+			name:        "prefers structured name with a cluster name",
+			clusterName: "east",
+			certs: []*fastly.CustomTLSCertificate{
+				{ID: "legacy", Name: "k8s-test-certificate"},
+				{ID: "west", Name: "k8s-west--test-namespace--test-certificate"},
+				{ID: "owned", Name: "k8s-east--test-namespace--test-certificate"},
+			},
+			expectedID: "owned",
+		},
+		{
+			name:        "falls back to name from before the cluster name",
+			clusterName: "east",
+			certs: []*fastly.CustomTLSCertificate{
+				{ID: "bare", Name: "test-certificate"},
+				{ID: "legacy", Name: "k8s-test-certificate"},
+			},
+			expectedID: "legacy",
+		},
+		{
+			name: "prefers prefixed name over bare name",
+			certs: []*fastly.CustomTLSCertificate{
+				{ID: "bare", Name: "test-certificate"},
+				{ID: "owned", Name: "k8s-test-certificate"},
+			},
+			expectedID: "owned",
+		},
+		{
+			name: "falls back to bare name",
+			certs: []*fastly.CustomTLSCertificate{
+				{ID: "bare", Name: "test-certificate"},
+				{ID: "other", Name: "k8s-other-certificate"},
+			},
+			expectedID: "bare",
+		},
+		{
+			name: "no match",
+			certs: []*fastly.CustomTLSCertificate{
+				{ID: "other", Name: "k8s-other-certificate"},
+			},
+			expectedID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = cmv1.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+			}).Build()
+
+			logic := &Logic{
+				FastlyClient: &MockFastlyClient{
+					ListCustomTLSCertificatesFunc: func(_ context.Context, _ *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+						return tt.certs, nil
+					},
+				},
+			}
+
+			ctx := createTestContext()
+			ctx.Config.FastlyObjectNamePrefix = "k8s-"
+			ctx.Config.FastlyClusterName = tt.clusterName
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			cert, err := logic.getFastlyCertificateMatchingSubject(ctx)
+			require.NoError(t, err)
+			if tt.expectedID == "" {
+				assert.Nil(t, cert)
+			} else {
+				require.NotNil(t, cert)
+				assert.Equal(t, tt.expectedID, cert.ID)
+			}
+		})
+	}
+}
+
+func TestLogic_ApplyUnmanaged_RefusesUnownedCertificate(t *testing.T) {
+	tests := []struct {
+		name          string
+		observedState ObservedState
+		expectError   bool
+	}{
+		{
+			name: "stale certificate",
+			observedState: ObservedState{
+				PrivateKeyUploaded:          true,
+				CertificateStatus:           CertificateStatusStale,
+				CertificateAdoptionRequired: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "missing activations",
+			observedState: ObservedState{
+				PrivateKeyUploaded:          true,
+				CertificateStatus:           CertificateStatusSynced,
+				CertificateAdoptionRequired: true,
+				MissingTLSActivationData:    []TLSActivationData{{}},
+			},
+			expectError: true,
+		},
+		{
+			name: "extra activations",
+			observedState: ObservedState{
+				PrivateKeyUploaded:          true,
+				CertificateStatus:           CertificateStatusSynced,
+				CertificateAdoptionRequired: true,
+				ExtraTLSActivationIDs:       []string{"activation1"},
+			},
+			expectError: true,
+		},
+		{
+			name: "nothing to modify",
+			observedState: ObservedState{
+				PrivateKeyUploaded:          true,
+				CertificateStatus:           CertificateStatusSynced,
+				CertificateAdoptionRequired: true,
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockFastlyClient{}
+			logic := &Logic{
+				FastlyClient:                  mockClient,
+				ObservedState:                 tt.observedState,
+				SubjectReadyForReconciliation: true,
+			}
+
+			ctx := createTestContext()
+			ctx.Config.FastlyObjectNamePrefix = "k8s-"
+
+			err := logic.ApplyUnmanaged(ctx)
+			if tt.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), AdoptFastlyCertificateAnnotation)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Empty(t, mockClient.CreateTLSActivationCalls)
+			assert.Empty(t, mockClient.DeleteTLSActivationCalls)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package fastlycertificatesync
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// recoverPanic makes a panic in a phase of the reconcile visible, by counting it and recording a Warning event on the
+// subject, then panics again for the controller to recover from as before. It must be deferred directly.
+func recoverPanic(ctx *Context, phase string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	reconcilePanicsTotal.WithLabelValues(ctx.Namespace, phase).Inc()
+	if ctx.EventRecorder != nil && ctx.Subject != nil {
+		ctx.Eventf(ctx.Subject, corev1.EventTypeWarning, "ReconcilePanic",
+			"Reconciling panicked in the %s phase, the operator log has the stack trace: %v", phase, r)
+	}
+
+	panic(r)
+}
@@ -0,0 +1,37 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(10)
+		ctx := createTestContext()
+		ctx.NamespacedName = types.NamespacedName{Namespace: "test-namespace", Name: "test-cert-sync"}
+		ctx.EventRecorder = recorder
+		before := counterValue(t, reconcilePanicsTotal.WithLabelValues("test-namespace", reconcilePhaseObserve))
+
+		assert.PanicsWithValue(t, "boom", func() {
+			defer recoverPanic(ctx, reconcilePhaseObserve)
+			panic("boom")
+		}, "the panic is passed on to the controller")
+
+		assert.Equal(t, before+1, counterValue(t, reconcilePanicsTotal.WithLabelValues("test-namespace", reconcilePhaseObserve)))
+		assert.Equal(t, "Warning ReconcilePanic Reconciling panicked in the observe phase, the operator log has the stack trace: boom", <-recorder.Events)
+	})
+
+	t.Run("no_panic", func(t *testing.T) {
+		ctx := createTestContext()
+		before := counterValue(t, reconcilePanicsTotal.WithLabelValues("test-namespace", reconcilePhaseApply))
+
+		assert.NotPanics(t, func() {
+			defer recoverPanic(ctx, reconcilePhaseApply)
+		})
+		assert.Equal(t, before, counterValue(t, reconcilePanicsTotal.WithLabelValues("test-namespace", reconcilePhaseApply)))
+	})
+}
@@ -0,0 +1,24 @@
+package fastlycertificatesync
+
+import "sync"
+
+// runParallel calls fn for every index below n, with at most parallelism calls in flight. A parallelism below one
+// runs the calls sequentially.
+func runParallel(parallelism, n int, fn func(i int)) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, parallelism)
+	for i := range n {
+		workers <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
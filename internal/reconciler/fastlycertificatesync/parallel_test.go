@@ -0,0 +1,51 @@
+package fastlycertificatesync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunParallel(t *testing.T) {
+	tests := []struct {
+		name                   string
+		parallelism            int
+		expectedMaxConcurrency int32
+	}{
+		{name: "sequential_when_unset", parallelism: 0, expectedMaxConcurrency: 1},
+		{name: "bounded", parallelism: 3, expectedMaxConcurrency: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			seen := map[int]bool{}
+			var inFlight, maxInFlight atomic.Int32
+
+			runParallel(tt.parallelism, 12, func(i int) {
+				current := inFlight.Add(1)
+				for {
+					peak := maxInFlight.Load()
+					if current <= peak || maxInFlight.CompareAndSwap(peak, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				inFlight.Add(-1)
+
+				mu.Lock()
+				seen[i] = true
+				mu.Unlock()
+			})
+
+			assert.Len(t, seen, 12, "every index should be visited")
+			assert.LessOrEqual(t, maxInFlight.Load(), tt.expectedMaxConcurrency)
+			if tt.expectedMaxConcurrency == 1 {
+				assert.Equal(t, int32(1), maxInFlight.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// observePartitionHandOver reports whether the subject is to be handed over to the partition in spec.partition. Only
+// the operator of the partition the subject is in reconciles it, so a subject being handed over has neither Fastly nor
+// its status touched by this one. Observing changes nothing, the subject is handed over by ApplyUnmanaged.
+func (l *Logic) observePartitionHandOver(ctx *Context) bool {
+	partition := ctx.Subject.Spec.Partition
+	l.ObservedState.PartitionHandedOver = l.Config.PartitionAnnotation != "" && partition != "" && partition != l.Config.Partition
+	return l.ObservedState.PartitionHandedOver
+}
+
+// handOverPartition moves the subject to the partition in spec.partition, by setting the annotation that assigns
+// subjects to partitions
+func (l *Logic) handOverPartition(ctx *Context) error {
+	partition := ctx.Subject.Spec.Partition
+	patch := client.MergeFrom(ctx.Subject.DeepCopy())
+	annotations := ctx.Subject.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[l.Config.PartitionAnnotation] = partition
+	ctx.Subject.SetAnnotations(annotations)
+	if err := ctx.Client.Client.Patch(ctx, ctx.Subject, patch); err != nil {
+		return fmt.Errorf("failed to hand over to partition %s: %w", partition, err)
+	}
+
+	ctx.Log.Info("handed over to the operator of another partition", "from", l.Config.Partition, "to", partition)
+	if ctx.EventRecorder != nil {
+		ctx.Eventf(ctx.Subject, corev1.EventTypeNormal, "PartitionHandedOver", "Handed over from partition %q to partition %q",
+			l.Config.Partition, partition)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLogic_handOverPartition(t *testing.T) {
+	const partitionAnnotation = "platform.seatgeek.io/partition"
+
+	tests := []struct {
+		name                string
+		partitionAnnotation string
+		partition           string
+		specPartition       string
+		expectedHandedOver  bool
+	}{
+		{name: "unset_spec_partition", partitionAnnotation: partitionAnnotation, partition: "canary"},
+		{name: "partitions_disabled", specPartition: "canary"},
+		{name: "own_partition", partitionAnnotation: partitionAnnotation, partition: "canary", specPartition: "canary"},
+		{name: "from_unpartitioned", partitionAnnotation: partitionAnnotation, specPartition: "canary", expectedHandedOver: true},
+		{name: "between_partitions", partitionAnnotation: partitionAnnotation, partition: "canary", specPartition: "team-a", expectedHandedOver: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Subject.Spec.Partition = tt.specPartition
+			if tt.partition != "" {
+				ctx.Subject.Annotations = map[string]string{partitionAnnotation: tt.partition}
+			}
+			scheme := runtime.NewScheme()
+			_ = v1alpha1.AddToScheme(scheme)
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(ctx.Subject).Build()},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+			recorder := record.NewFakeRecorder(1)
+			ctx.EventRecorder = recorder
+			logic := &Logic{Config: RuntimeConfig{PartitionAnnotation: tt.partitionAnnotation, Partition: tt.partition}}
+
+			assert.Equal(t, tt.expectedHandedOver, logic.observePartitionHandOver(ctx))
+			assert.Equal(t, tt.expectedHandedOver, logic.ObservedState.PartitionHandedOver)
+
+			subject := &v1alpha1.FastlyCertificateSync{}
+			require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+			assert.Equal(t, tt.partition, subject.Annotations[partitionAnnotation], "observing changes nothing")
+
+			require.NoError(t, logic.ApplyUnmanaged(ctx))
+			require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+			require.NoError(t, ctx.Client.Client.Get(ctx, client.ObjectKeyFromObject(ctx.Subject), subject))
+			if !tt.expectedHandedOver {
+				assert.Equal(t, tt.partition, subject.Annotations[partitionAnnotation])
+				assert.Empty(t, recorder.Events)
+				return
+			}
+			assert.Equal(t, tt.specPartition, subject.Annotations[partitionAnnotation])
+			assert.Equal(t, `Normal PartitionHandedOver Handed over from partition "`+tt.partition+`" to partition "`+tt.specPartition+`"`,
+				<-recorder.Events)
+		})
+	}
+}
+
+func TestLogic_handOverPartition_WithoutEventRecorder(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Spec.Partition = "canary"
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(ctx.Subject).Build()},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	logic := &Logic{Config: RuntimeConfig{PartitionAnnotation: "platform.seatgeek.io/partition"}}
+
+	require.NoError(t, logic.handOverPartition(ctx))
+	assert.Equal(t, "canary", ctx.Subject.Annotations["platform.seatgeek.io/partition"])
+}
+
+func TestLogic_FillStatus_PartitionHandedOver(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Status.Ready = true
+	logic := &Logic{ObservedState: ObservedState{PartitionHandedOver: true}}
+
+	require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+	assert.True(t, ctx.Subject.Status.Ready, "the status is left to the operator of the new partition")
+	assert.Empty(t, ctx.Subject.Status.Conditions)
+}
@@ -0,0 +1,63 @@
+package fastlycertificatesync
+
+import (
+	"reflect"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// certificateChangedPredicate drops updates to Certificates that can't affect the FastlyCertificateSyncs referencing
+// them, e.g. changes to labels, managed fields or conditions other than Ready. Creations and deletions always pass.
+func certificateChangedPredicate(config RuntimeConfig) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCertificate, ok := e.ObjectOld.(*cmv1.Certificate)
+			if !ok {
+				return true
+			}
+			newCertificate, ok := e.ObjectNew.(*cmv1.Certificate)
+			if !ok {
+				return true
+			}
+			return isCertificateChangeRelevant(config, oldCertificate, newCertificate)
+		},
+	}
+}
+
+// isCertificateChangeRelevant reports whether the update changed what a FastlyCertificateSync observes of the
+// Certificate: whether it is enabled for syncing, the secret holding it, the issued revision and its validity, and its
+// readiness
+func isCertificateChangeRelevant(config RuntimeConfig, oldCertificate, newCertificate *cmv1.Certificate) bool {
+	if isCertificateSyncEnabled(config, oldCertificate) != isCertificateSyncEnabled(config, newCertificate) {
+		return true
+	}
+	if oldCertificate.Spec.SecretName != newCertificate.Spec.SecretName {
+		return true
+	}
+
+	oldStatus, newStatus := oldCertificate.Status, newCertificate.Status
+	if !reflect.DeepEqual(oldStatus.Revision, newStatus.Revision) ||
+		!reflect.DeepEqual(oldStatus.NotAfter, newStatus.NotAfter) ||
+		!reflect.DeepEqual(oldStatus.RenewalTime, newStatus.RenewalTime) {
+		return true
+	}
+
+	oldReady, newReady := certificateReadyCondition(oldCertificate), certificateReadyCondition(newCertificate)
+	if (oldReady == nil) != (newReady == nil) {
+		return true
+	}
+	return oldReady != nil &&
+		(oldReady.Status != newReady.Status || oldReady.Reason != newReady.Reason || oldReady.Message != newReady.Message)
+}
+
+// certificateReadyCondition returns the Ready condition of the Certificate, or nil
+func certificateReadyCondition(certificate *cmv1.Certificate) *cmv1.CertificateCondition {
+	for i := range certificate.Status.Conditions {
+		if certificate.Status.Conditions[i].Type == cmv1.CertificateConditionReady {
+			return &certificate.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestCertificateChangedPredicate(t *testing.T) {
+	revision := 1
+	base := func() *cmv1.Certificate {
+		return &cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-certificate",
+				Namespace:   "test-namespace",
+				Annotations: map[string]string{EnableFastlySyncAnnotation: "true"},
+			},
+			Spec: cmv1.CertificateSpec{SecretName: "test-secret"},
+			Status: cmv1.CertificateStatus{
+				Revision: &revision,
+				NotAfter: &metav1.Time{Time: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+				Conditions: []cmv1.CertificateCondition{
+					{Type: cmv1.CertificateConditionReady, Status: cmmetav1.ConditionTrue, Reason: "Ready", Message: "Certificate is up to date"},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(c *cmv1.Certificate)
+		expected bool
+	}{
+		{name: "no_change", change: func(c *cmv1.Certificate) {}},
+		{name: "labels", change: func(c *cmv1.Certificate) { c.Labels = map[string]string{"team": "a"} }},
+		{name: "resource_version", change: func(c *cmv1.Certificate) { c.ResourceVersion = "2" }},
+		{
+			name: "other_condition",
+			change: func(c *cmv1.Certificate) {
+				c.Status.Conditions = append(c.Status.Conditions, cmv1.CertificateCondition{Type: cmv1.CertificateConditionIssuing, Status: cmmetav1.ConditionTrue})
+			},
+		},
+		{
+			name:     "sync_annotation",
+			change:   func(c *cmv1.Certificate) { delete(c.Annotations, EnableFastlySyncAnnotation) },
+			expected: true,
+		},
+		{name: "secret_name", change: func(c *cmv1.Certificate) { c.Spec.SecretName = "other-secret" }, expected: true},
+		{
+			name: "revision",
+			change: func(c *cmv1.Certificate) {
+				next := revision + 1
+				c.Status.Revision = &next
+			},
+			expected: true,
+		},
+		{
+			name:     "renewal_time",
+			change:   func(c *cmv1.Certificate) { c.Status.RenewalTime = &metav1.Time{Time: time.Now()} },
+			expected: true,
+		},
+		{
+			name:     "ready_status",
+			change:   func(c *cmv1.Certificate) { c.Status.Conditions[0].Status = cmmetav1.ConditionFalse },
+			expected: true,
+		},
+		{
+			name: "ready_message",
+			change: func(c *cmv1.Certificate) {
+				c.Status.Conditions[0].Message = "Issuing certificate as Secret does not exist"
+			},
+			expected: true,
+		},
+		{name: "ready_removed", change: func(c *cmv1.Certificate) { c.Status.Conditions = nil }, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base()
+			tt.change(updated)
+			assert.Equal(t, tt.expected, certificateChangedPredicate(RuntimeConfig{}).Update(event.UpdateEvent{ObjectOld: base(), ObjectNew: updated}))
+		})
+	}
+
+	assert.True(t, certificateChangedPredicate(RuntimeConfig{}).Create(event.CreateEvent{Object: base()}))
+	assert.True(t, certificateChangedPredicate(RuntimeConfig{}).Delete(event.DeleteEvent{Object: base()}))
+
+	// Only the configured annotation enables syncing
+	config := RuntimeConfig{CertificateSyncAnnotation: "example.com/fastly", CertificateSyncAnnotationValue: "enabled"}
+	updated := base()
+	updated.Annotations["example.com/fastly"] = "enabled"
+	assert.True(t, certificateChangedPredicate(config).Update(event.UpdateEvent{ObjectOld: base(), ObjectNew: updated}))
+	delete(updated.Annotations, EnableFastlySyncAnnotation)
+	assert.False(t, certificateChangedPredicate(config).Update(event.UpdateEvent{ObjectOld: updated.DeepCopy(), ObjectNew: updated}))
+}
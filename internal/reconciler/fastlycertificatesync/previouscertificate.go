@@ -0,0 +1,186 @@
+package fastlycertificatesync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// previousFastlyCertificateSuffix is appended to the name of a certificate kept in Fastly after a rotation
+const previousFastlyCertificateSuffix = "-previous"
+
+// previousTLSActivation is a TLS activation of the previous certificate on a domain and configuration that the
+// current certificate is missing an activation for, which is moved over to it rather than created again
+type previousTLSActivation struct {
+	ID   string
+	Data TLSActivationData
+}
+
+// retainsPreviousFastlyCertificate reports whether renewed certificates are uploaded alongside the previous one,
+// rather than replacing it in place
+func retainsPreviousFastlyCertificate(ctx *Context) bool {
+	period := ctx.Subject.Spec.PreviousCertificateGracePeriod
+	return period != nil && period.Duration > 0 && len(ctx.Subject.Spec.Accounts) == 0
+}
+
+// isPreviousFastlyCertificateDue reports whether the grace period of the previous certificate is over
+func isPreviousFastlyCertificateDue(ctx *Context) bool {
+	previous := ctx.Subject.Status.PreviousCertificate
+	return previous != nil && !time.Now().Before(previous.RetainedUntil.Time)
+}
+
+// rotateFastlyCertificate keeps the certificate about to be replaced in Fastly, renamed with the previous suffix, and
+// uploads the local certificate as a new one. The TLS activations are moved over to the new certificate by the
+// following reconciles, and the previous certificate is deleted once the grace period is over.
+func (l *Logic) rotateFastlyCertificate(ctx *Context, current *fastly.CustomTLSCertificate, name string) error {
+	// Only a single previous certificate is kept, the one from the rotation before goes first
+	if previous := ctx.Subject.Status.PreviousCertificate; previous != nil && previous.CertificateID != current.ID {
+		if err := l.deleteFastlyCertificate(ctx, previous.CertificateID); err != nil {
+			return fmt.Errorf("failed to delete previous Fastly certificate: %w", err)
+		}
+	}
+
+	// The previous certificate is tracked before anything else, even its rename. Renamed, it is no longer found by
+	// name, and its TLS activations must be moved over even if the new certificate fails to be created.
+	retainedUntil := kmetav1.NewTime(time.Now().Add(ctx.Subject.Spec.PreviousCertificateGracePeriod.Duration))
+	if err := l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.PreviousCertificate = &v1alpha1.PreviousCertificateStatus{
+			CertificateID: current.ID,
+			SerialNumber:  current.SerialNumber,
+			RetainedUntil: retainedUntil,
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to record previous Fastly certificate: %w", err)
+	}
+
+	previousName := name + previousFastlyCertificateSuffix
+	if err := l.renameFastlyCertificate(ctx, current.ID, previousName); err != nil {
+		return err
+	}
+	ctx.Log.Info("renamed Fastly certificate ahead of its rotation", "certificate_id", current.ID, "name", previousName)
+
+	if !l.reserveFastlyWrite(ctx) {
+		return nil
+	}
+	created, err := l.createFastlyCertificate(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Certificates matched by ID must not go back to the previous one
+	return l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.CertificateID = created.ID
+	})
+}
+
+// renameFastlyCertificate changes the name of a certificate without replacing it. go-fastly only updates the name
+// along with the certificate blob, so the request is made directly.
+func (l *Logic) renameFastlyCertificate(ctx *Context, id, name string) error {
+	body, err := json.Marshal(map[string]any{
+		"data": map[string]any{
+			"id":         id,
+			"type":       "tls_certificate",
+			"attributes": map[string]string{"name": name},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ro := fastly.CreateRequestOptions()
+	ro.Headers["Content-Type"] = "application/vnd.api+json"
+	ro.Body = bytes.NewReader(body)
+	ro.BodyLength = int64(len(body))
+
+	resp, err := l.fastlyClient().Patch(ctx, "/tls/certificates/"+id, ro)
+	l.forgetFastlyCertificates()
+	if err != nil {
+		return fmt.Errorf("failed to rename Fastly certificate %s: %w", id, err)
+	}
+	return resp.Body.Close()
+}
+
+// observePreviousFastlyCertificate finds the TLS activations still on the previous certificate that the current
+// certificate is missing, and whether the previous certificate is due to be deleted
+func (l *Logic) observePreviousFastlyCertificate(ctx *Context) error {
+	previous := ctx.Subject.Status.PreviousCertificate
+	if previous == nil || len(ctx.Subject.Spec.Accounts) > 0 {
+		return nil
+	}
+
+	activations, err := listFastlyPages(func(page int) ([]*fastly.TLSActivation, error) {
+		return l.fastlyClient().ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{
+			FilterTLSCertificateID: previous.CertificateID,
+			PageNumber:             page,
+			PageSize:               defaultFastlyPageSize,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list TLS activations of previous Fastly certificate: %w", err)
+	}
+
+	listed := indexTLSActivations(activations)
+	var missing []TLSActivationData
+	for _, data := range l.ObservedState.MissingTLSActivationData {
+		if activation, ok := listed[data.Domain.ID][data.Configuration.ID]; ok {
+			l.ObservedState.PreviousTLSActivations = append(l.ObservedState.PreviousTLSActivations, previousTLSActivation{ID: activation.ID, Data: data})
+			continue
+		}
+		missing = append(missing, data)
+	}
+	if len(l.ObservedState.PreviousTLSActivations) > 0 {
+		l.ObservedState.MissingTLSActivationData = missing
+	}
+
+	// The previous certificate goes once its grace period is over, and nothing is left to move off of it. A rotation
+	// interrupted before the new certificate was created leaves the current certificate tracked as the previous one.
+	l.ObservedState.PreviousCertificateExpired = len(l.ObservedState.PreviousTLSActivations) == 0 && isPreviousFastlyCertificateDue(ctx) &&
+		previous.CertificateID != ctx.Subject.Status.CertificateID
+
+	return nil
+}
+
+// movePreviousFastlyTLSActivations points the TLS activations of the previous certificate at the current one
+func (l *Logic) movePreviousFastlyTLSActivations(ctx *Context) error {
+	cert := l.ObservedState.FastlyCertificate
+	if cert == nil {
+		return errors.New("no Fastly certificate to move TLS activations to")
+	}
+
+	for _, activation := range l.orderPreviousTLSActivations(ctx) {
+		if !l.reserveFastlyWrite(ctx) {
+			return nil
+		}
+
+		_, err := l.fastlyClient().UpdateTLSActivation(ctx, &fastly.UpdateTLSActivationInput{
+			ID:          activation.ID,
+			Certificate: &fastly.CustomTLSCertificate{ID: cert.ID},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move TLS activation %s to certificate %s: %w", activation.ID, cert.ID, err)
+		}
+		ctx.Log.Info("moved TLS activation to the rotated Fastly certificate", "activation_id", activation.ID,
+			"domain", activation.Data.Domain.ID, "configuration_id", activation.Data.Configuration.ID)
+	}
+	return nil
+}
+
+// deletePreviousFastlyCertificate deletes the previous certificate once its grace period is over, along with any TLS
+// activations left on it
+func (l *Logic) deletePreviousFastlyCertificate(ctx *Context) error {
+	previous := ctx.Subject.Status.PreviousCertificate
+	ctx.Log.Info("grace period of previous Fastly certificate is over, deleting it", "certificate_id", previous.CertificateID)
+
+	if err := l.deleteFastlyCertificate(ctx, previous.CertificateID); err != nil {
+		return err
+	}
+	return l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.PreviousCertificate = nil
+	})
+}
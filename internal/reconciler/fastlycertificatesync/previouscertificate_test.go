@@ -0,0 +1,212 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPreviousCertificateTestContext() *Context {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.PreviousCertificateGracePeriod = &metav1.Duration{Duration: time.Hour}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			ctx.Subject,
+			&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+				Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data:       map[string][]byte{"tls.crt": []byte("renewed-certificate")},
+			},
+		).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	return ctx
+}
+
+func TestRetainsPreviousFastlyCertificate(t *testing.T) {
+	ctx := createTestContext()
+	assert.False(t, retainsPreviousFastlyCertificate(ctx))
+
+	ctx.Subject.Spec.PreviousCertificateGracePeriod = &metav1.Duration{}
+	assert.False(t, retainsPreviousFastlyCertificate(ctx), "a zero grace period replaces certificates in place")
+
+	ctx.Subject.Spec.PreviousCertificateGracePeriod = &metav1.Duration{Duration: time.Hour}
+	assert.True(t, retainsPreviousFastlyCertificate(ctx))
+
+	ctx.Subject.Spec.Accounts = []v1alpha1.FastlyAccount{{Name: "secondary"}}
+	assert.False(t, retainsPreviousFastlyCertificate(ctx), "not supported when syncing to several accounts")
+}
+
+func TestLogic_rotateFastlyCertificate(t *testing.T) {
+	ctx := newPreviousCertificateTestContext()
+	var patchedPath string
+	var patchedBody map[string]any
+	mockClient := &MockFastlyClient{
+		PatchFunc: func(_ context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+			assert.NotNil(t, ctx.Subject.Status.PreviousCertificate, "the previous certificate is recorded before it is renamed")
+			patchedPath = p
+			require.NoError(t, json.NewDecoder(ro.Body).Decode(&patchedBody))
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		},
+		CreateCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			assert.Equal(t, "test-certificate", input.Name)
+			assert.Equal(t, "renewed-certificate", input.CertBlob)
+			return &fastly.CustomTLSCertificate{ID: "cert2"}, nil
+		},
+	}
+	logic := &Logic{FastlyClient: mockClient}
+
+	current := &fastly.CustomTLSCertificate{ID: "cert1", Name: "test-certificate", SerialNumber: "01"}
+	require.NoError(t, logic.rotateFastlyCertificate(ctx, current, "test-certificate"))
+
+	assert.Equal(t, "/tls/certificates/cert1", patchedPath)
+	assert.Equal(t, map[string]any{
+		"data": map[string]any{
+			"id":         "cert1",
+			"type":       "tls_certificate",
+			"attributes": map[string]any{"name": "test-certificate-previous"},
+		},
+	}, patchedBody)
+
+	previous := ctx.Subject.Status.PreviousCertificate
+	require.NotNil(t, previous)
+	assert.Equal(t, "cert1", previous.CertificateID)
+	assert.Equal(t, "01", previous.SerialNumber)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), previous.RetainedUntil.Time, time.Minute)
+	assert.Equal(t, "cert2", ctx.Subject.Status.CertificateID)
+	assert.Empty(t, mockClient.DeleteCustomTLSCertificateCalls)
+}
+
+func TestLogic_rotateFastlyCertificate_ReplacesOlderPreviousCertificate(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return &fastly.CustomTLSCertificate{ID: input.ID, Name: "test-certificate-previous"}, nil
+		},
+		PatchFunc: func(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		},
+		CreateCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return &fastly.CustomTLSCertificate{ID: "cert2"}, nil
+		},
+	}
+	ctx := newPreviousCertificateTestContext()
+	ctx.Subject.Status.PreviousCertificate = &v1alpha1.PreviousCertificateStatus{CertificateID: "cert0"}
+	logic := &Logic{FastlyClient: mockClient}
+
+	require.NoError(t, logic.rotateFastlyCertificate(ctx, &fastly.CustomTLSCertificate{ID: "cert1"}, "test-certificate"))
+
+	assert.Equal(t, []string{"cert0"}, mockClient.DeleteCustomTLSCertificateCalls)
+	assert.Equal(t, "cert1", ctx.Subject.Status.PreviousCertificate.CertificateID)
+}
+
+func TestLogic_observePreviousFastlyCertificate(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+			assert.Equal(t, "cert1", input.FilterTLSCertificateID)
+			return []*fastly.TLSActivation{
+				{ID: "act1", Domain: &fastly.TLSDomain{ID: "example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}},
+			}, nil
+		},
+	}
+	certificate := &fastly.CustomTLSCertificate{ID: "cert2"}
+	moved := TLSActivationData{Certificate: certificate, Configuration: &fastly.TLSConfiguration{ID: "config1"}, Domain: &fastly.TLSDomain{ID: "example.com"}}
+	created := TLSActivationData{Certificate: certificate, Configuration: &fastly.TLSConfiguration{ID: "config1"}, Domain: &fastly.TLSDomain{ID: "www.example.com"}}
+
+	ctx := createTestContext()
+	ctx.Subject.Status.PreviousCertificate = &v1alpha1.PreviousCertificateStatus{
+		CertificateID: "cert1",
+		RetainedUntil: metav1.NewTime(time.Now().Add(-time.Minute)),
+	}
+	logic := &Logic{
+		FastlyClient:  mockClient,
+		ObservedState: ObservedState{MissingTLSActivationData: []TLSActivationData{moved, created}},
+	}
+
+	require.NoError(t, logic.observePreviousFastlyCertificate(ctx))
+	assert.Equal(t, []previousTLSActivation{{ID: "act1", Data: moved}}, logic.ObservedState.PreviousTLSActivations)
+	assert.Equal(t, []TLSActivationData{created}, logic.ObservedState.MissingTLSActivationData)
+	assert.False(t, logic.ObservedState.PreviousCertificateExpired, "not deleted while TLS activations are left to move")
+
+	// Once everything is moved over, the previous certificate goes
+	logic.ObservedState = ObservedState{MissingTLSActivationData: []TLSActivationData{created}}
+	require.NoError(t, logic.observePreviousFastlyCertificate(ctx))
+	assert.Empty(t, logic.ObservedState.PreviousTLSActivations)
+	assert.True(t, logic.ObservedState.PreviousCertificateExpired)
+
+	// But not before the grace period is over
+	ctx.Subject.Status.PreviousCertificate.RetainedUntil = metav1.NewTime(time.Now().Add(time.Hour))
+	logic.ObservedState = ObservedState{}
+	require.NoError(t, logic.observePreviousFastlyCertificate(ctx))
+	assert.False(t, logic.ObservedState.PreviousCertificateExpired)
+
+	// Nor while it is still the current one, after a rotation was interrupted
+	ctx.Subject.Status.PreviousCertificate.RetainedUntil = metav1.NewTime(time.Now().Add(-time.Minute))
+	ctx.Subject.Status.CertificateID = "cert1"
+	logic.ObservedState = ObservedState{}
+	require.NoError(t, logic.observePreviousFastlyCertificate(ctx))
+	assert.False(t, logic.ObservedState.PreviousCertificateExpired)
+}
+
+func TestLogic_movePreviousFastlyTLSActivations(t *testing.T) {
+	var moved []string
+	mockClient := &MockFastlyClient{
+		UpdateTLSActivationFunc: func(ctx context.Context, input *fastly.UpdateTLSActivationInput) (*fastly.TLSActivation, error) {
+			assert.Equal(t, "cert2", input.Certificate.ID)
+			moved = append(moved, input.ID)
+			return &fastly.TLSActivation{ID: input.ID}, nil
+		},
+	}
+	data := TLSActivationData{Configuration: &fastly.TLSConfiguration{ID: "config1"}, Domain: &fastly.TLSDomain{ID: "example.com"}}
+	logic := &Logic{
+		FastlyClient: mockClient,
+		ObservedState: ObservedState{
+			FastlyCertificate:      &fastly.CustomTLSCertificate{ID: "cert2"},
+			PreviousTLSActivations: []previousTLSActivation{{ID: "act1", Data: data}, {ID: "act2", Data: data}},
+		},
+	}
+
+	require.NoError(t, logic.movePreviousFastlyTLSActivations(createTestContext()))
+	assert.Equal(t, []string{"act1", "act2"}, moved)
+}
+
+func TestLogic_deleteFastlyCertificates_PreviousCertificate(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return &fastly.CustomTLSCertificate{ID: input.ID}, nil
+		},
+	}
+	ctx := createDeletionTestContext()
+	ctx.Subject.Status.PreviousCertificate = &v1alpha1.PreviousCertificateStatus{CertificateID: "cert0"}
+
+	require.NoError(t, (&Logic{FastlyClient: mockClient}).deleteFastlyCertificates(ctx))
+	assert.Equal(t, []string{"cert0", "cert1"}, mockClient.DeleteCustomTLSCertificateCalls)
+}
@@ -0,0 +1,199 @@
+package fastlycertificatesync
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// privateKeySweeperSubject is what the sweeper's deletions are counted against in the mutation budget
+var privateKeySweeperSubject = types.NamespacedName{Name: "private-key-sweeper"}
+
+// PrivateKeySweeper periodically deletes the private keys that no certificate uses from every Fastly account the
+// operator talks to. Unused keys belong to the account rather than to any FastlyCertificateSync, so they are found
+// once per account here instead of by the reconciliation of every subject.
+type PrivateKeySweeper struct {
+	Logic    *Logic
+	Client   client.Reader
+	Interval time.Duration
+}
+
+// NeedLeaderElection only sweeps from the leader, replicas would otherwise race to delete the same keys
+func (s *PrivateKeySweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// Start sweeps the accounts every interval until the context is cancelled
+func (s *PrivateKeySweeper) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("fastly-private-key-sweeper")
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweepAccounts(ctx, log)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PrivateKeySweeper) sweepAccounts(ctx context.Context, log logr.Logger) {
+	// Keys of subjects managing their private key externally are never swept, so a sweep can't go ahead without them
+	subjects := &v1alpha1.FastlyCertificateSyncList{}
+	if err := s.Client.List(ctx, subjects); err != nil {
+		log.Error(err, "failed to list FastlyCertificateSyncs")
+		return
+	}
+	externalKeys := externalPrivateKeySHA1s(subjects.Items)
+
+	clients, err := s.Logic.accountClients(ctx, s.Client)
+	if err != nil {
+		log.Error(err, "failed to resolve Fastly accounts")
+	}
+	if err := s.Logic.addSubjectAccountClients(ctx, s.Client, subjects.Items, clients); err != nil {
+		log.Error(err, "failed to resolve Fastly accounts of FastlyCertificateSyncs")
+	}
+
+	for _, account := range slices.Sorted(maps.Keys(clients)) {
+		accountLog := log.WithValues("account", account)
+
+		keys, err := s.Logic.listUnusedPrivateKeys(ctx, clients[account], externalKeys)
+		if err != nil {
+			accountLog.Error(err, "failed to list unused Fastly private keys")
+			continue
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		s.deletePrivateKeys(ctx, accountLog, clients[account], keys)
+		s.Logic.inventoryCache().Forget(account)
+	}
+}
+
+// addSubjectAccountClients adds a client for every account in spec.accounts with a token secret of its own, labeled
+// the same way as fastlyAccount. Accounts whose token can't be resolved are skipped and reported in the error.
+func (l *Logic) addSubjectAccountClients(ctx context.Context, reader client.Reader, subjects []v1alpha1.FastlyCertificateSync, clients map[string]FastlyClientInterface) error {
+	var errs []error
+	for _, subject := range subjects {
+		for _, account := range subject.Spec.Accounts {
+			ref := account.TokenSecretRef
+			if ref == nil {
+				continue
+			}
+
+			secret := types.NamespacedName{Name: ref.Name, Namespace: subject.Namespace}
+			label := "secret/" + secret.String()
+			if _, ok := clients[label]; ok {
+				continue
+			}
+
+			fastlyClient, err := l.fastlyClientForSecret(ctx, reader, secret, cmp.Or(ref.Key, l.Config.FastlyTokenSecretKey))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to create Fastly client for account %s: %w", account.Name, err))
+				continue
+			}
+			clients[label] = fastlyClient
+		}
+	}
+	return joinErrors(errs)
+}
+
+// listUnusedPrivateKeys returns the private keys in the account that no certificate uses and that the operator may
+// delete. Keys outside of the owned prefix may be managed by something else in the account, and keys uploaded for
+// subjects managing their private key externally are left alone even within it.
+func (l *Logic) listUnusedPrivateKeys(ctx context.Context, fastlyClient FastlyClientInterface, externalKeys map[string]bool) ([]*fastly.PrivateKey, error) {
+	var res []*fastly.PrivateKey
+	for page := 1; ; page++ {
+		keys, err := fastlyClient.ListPrivateKeys(ctx, &fastly.ListPrivateKeysInput{FilterInUse: "false", PageNumber: page, PageSize: defaultFastlyPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Fastly private keys: %w", err)
+		}
+
+		for _, key := range keys {
+			if isFastlyObjectNameOwned(l.Config, key.Name) && !externalKeys[key.PublicKeySHA1] {
+				res = append(res, key)
+			}
+		}
+
+		if len(keys) < defaultFastlyPageSize {
+			return res, nil
+		}
+	}
+}
+
+// deletePrivateKeys deletes the unused keys of a single account, up to PrivateKeyDeletionParallelism at a time. Keys
+// left over once the mutation budget ran out, or that failed to be deleted, are retried on the next sweep.
+func (s *PrivateKeySweeper) deletePrivateKeys(ctx context.Context, log logr.Logger, fastlyClient FastlyClientInterface, keys []*fastly.PrivateKey) {
+	errs := make([]error, len(keys))
+	deferred := make([]bool, len(keys))
+	runParallel(s.Logic.Config.PrivateKeyDeletionParallelism, len(keys), func(i int) {
+		if allowed, _ := s.Logic.ReserveFastlyMutation(privateKeySweeperSubject); !allowed {
+			deferred[i] = true
+			return
+		}
+
+		log.Info(fmt.Sprintf("attempting to delete unused private key %s", keys[i].ID))
+		errs[i] = fastlyClient.DeletePrivateKey(ctx, &fastly.DeletePrivateKeyInput{ID: keys[i].ID})
+	})
+
+	attempted := 0
+	var failed []string
+	for i, key := range keys {
+		if deferred[i] {
+			continue
+		}
+		attempted++
+		countPrivateKeyCleanup(fastlyObjectNamespace(s.Logic.Config, key.Name), errs[i])
+		if errs[i] != nil {
+			// Deleting a private key has some inconsistencies on Fastly's end.
+			// It is never critical to delete a private key, we only need deletion to be eventually consistent.
+			// We effectively swallow the error, but notify via an info log that wont trigger a monitor.
+			log.Info(fmt.Sprintf("Failed to delete Fastly private key %s: %v. This is not critical, there are often race conditions when querying for unused private keys", key.ID, errs[i]))
+			failed = append(failed, key.ID)
+		}
+	}
+
+	if deferredCount := len(keys) - attempted; deferredCount > 0 {
+		log.Info("Fastly mutation budget exceeded, deferring private key deletions to the next sweep", "deferred", deferredCount)
+	}
+	if attempted > 0 {
+		log.Info("Deleted unused private keys from Fastly", "deleted", attempted-len(failed), "failed", failed)
+	}
+}
+
+// fastlyObjectNamespace returns the namespace embedded in the name of a Fastly object the operator created with a
+// cluster name or namespaced names configured, empty for any other name
+func fastlyObjectNamespace(config RuntimeConfig, name string) string {
+	if config.FastlyClusterName == "" {
+		if !config.FastlyNamespacedObjectNames {
+			return ""
+		}
+		rest, _ := strings.CutPrefix(name, config.FastlyObjectNamePrefix)
+		namespace, _, ok := strings.Cut(rest, fastlyObjectNameSeparator)
+		if !ok {
+			return ""
+		}
+		return namespace
+	}
+	owner, ok := parseFastlyObjectName(config, name)
+	if !ok {
+		return ""
+	}
+	return owner.Namespace
+}
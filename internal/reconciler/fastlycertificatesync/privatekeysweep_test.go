@@ -0,0 +1,180 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPrivateKeySweeper_sweepAccounts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "hsm", Namespace: "sweep-a"},
+			Spec: v1alpha1.FastlyCertificateSyncSpec{
+				PrivateKeyManagement:    v1alpha1.PrivateKeyManagementExternal,
+				PrivateKeyPublicKeySHA1: "external-sha1",
+			},
+		},
+		&v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "sweep-b"},
+			Spec: v1alpha1.FastlyCertificateSyncSpec{
+				Accounts: []v1alpha1.FastlyAccount{
+					{Name: "staging", TokenSecretRef: &v1alpha1.FastlyTokenSecretRef{Name: "staging-token"}},
+					{Name: "production"},
+				},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "staging-token", Namespace: "sweep-b"},
+			Data:       map[string][]byte{"api-key": []byte("staging")},
+		},
+	).Build()
+
+	defaultClient := &MockFastlyClient{
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			assert.Equal(t, "false", input.FilterInUse)
+			return []*fastly.PrivateKey{
+				{ID: "owned", Name: "k8s-west--sweep-a--web-tls"},
+				{ID: "other-cluster", Name: "k8s-east--sweep-a--web-tls"},
+				{ID: "terraform", Name: "terraform-managed"},
+				{ID: "external", Name: "k8s-west--sweep-a--hsm-tls", PublicKeySHA1: "external-sha1"},
+			}, nil
+		},
+	}
+	stagingClient := &MockFastlyClient{
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			return []*fastly.PrivateKey{{ID: "staging-key", Name: "k8s-west--sweep-b--api-tls"}}, nil
+		},
+	}
+
+	sweeper := &PrivateKeySweeper{
+		Logic: &Logic{
+			FastlyClient: defaultClient,
+			NewFastlyClient: func(token string) (FastlyClientInterface, error) {
+				assert.Equal(t, "staging", token)
+				return stagingClient, nil
+			},
+			Config: RuntimeConfig{
+				FastlyObjectNamePrefix: "k8s-",
+				FastlyClusterName:      "west",
+				FastlyTokenSecretKey:   "api-key",
+			},
+		},
+		Client: fakeClient,
+	}
+	t.Cleanup(func() {
+		privateKeyCleanupsTotal.DeletePartialMatch(prometheus.Labels{"namespace": "sweep-a"})
+		privateKeyCleanupsTotal.DeletePartialMatch(prometheus.Labels{"namespace": "sweep-b"})
+	})
+
+	sweeper.sweepAccounts(context.Background(), logr.Discard())
+
+	assert.Equal(t, []string{"owned"}, defaultClient.DeletePrivateKeyCalls,
+		"keys of other owners and of externally managed subjects are never swept")
+	assert.Equal(t, []string{"staging-key"}, stagingClient.DeletePrivateKeyCalls,
+		"accounts in spec.accounts with a token secret of their own are swept as well")
+	assert.Equal(t, 1.0, counterValue(t, privateKeyCleanupsTotal.WithLabelValues("sweep-a", reconcileOutcomeSuccess)))
+	assert.Equal(t, 1.0, counterValue(t, privateKeyCleanupsTotal.WithLabelValues("sweep-b", reconcileOutcomeSuccess)))
+}
+
+func TestLogic_listUnusedPrivateKeys_Pages(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			n := defaultFastlyPageSize
+			if input.PageNumber > 1 {
+				n = 1
+			}
+			keys := make([]*fastly.PrivateKey, n)
+			for i := range keys {
+				keys[i] = &fastly.PrivateKey{ID: fmt.Sprintf("key-%d-%d", input.PageNumber, i)}
+			}
+			return keys, nil
+		},
+	}
+
+	keys, err := (&Logic{}).listUnusedPrivateKeys(context.Background(), mockClient, nil)
+	require.NoError(t, err)
+	assert.Len(t, keys, defaultFastlyPageSize+1)
+
+	mockClient.ListPrivateKeysFunc = func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+		return nil, errors.New("api error")
+	}
+	_, err = (&Logic{}).listUnusedPrivateKeys(context.Background(), mockClient, nil)
+	assert.EqualError(t, err, "failed to list Fastly private keys: api error")
+}
+
+func TestPrivateKeySweeper_deletePrivateKeys_Parallel(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	mockClient := &MockFastlyClient{
+		DeletePrivateKeyFunc: func(ctx context.Context, input *fastly.DeletePrivateKeyInput) error {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				seen := maxInFlight.Load()
+				if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			if input.ID == "key2" {
+				return errors.New("delete failed")
+			}
+			return nil
+		},
+	}
+	t.Cleanup(func() {
+		privateKeyCleanupsTotal.DeletePartialMatch(prometheus.Labels{"namespace": "cleanup-namespace"})
+	})
+
+	sweeper := &PrivateKeySweeper{Logic: &Logic{
+		Config: RuntimeConfig{PrivateKeyDeletionParallelism: 2, FastlyClusterName: "west"},
+	}}
+	var keys []*fastly.PrivateKey
+	for _, id := range []string{"key1", "key2", "key3", "key4"} {
+		keys = append(keys, &fastly.PrivateKey{ID: id, Name: "west--cleanup-namespace--" + id})
+	}
+	sweeper.deletePrivateKeys(context.Background(), logr.Discard(), mockClient, keys)
+
+	assert.ElementsMatch(t, []string{"key1", "key2", "key3", "key4"}, mockClient.DeletePrivateKeyCalls)
+	assert.Equal(t, int32(2), maxInFlight.Load(), "deletions run concurrently, up to the parallelism")
+
+	assert.Equal(t, 3.0, counterValue(t, privateKeyCleanupsTotal.WithLabelValues("cleanup-namespace", reconcileOutcomeSuccess)))
+	assert.Equal(t, 1.0, counterValue(t, privateKeyCleanupsTotal.WithLabelValues("cleanup-namespace", reconcileOutcomeError)))
+}
+
+func TestPrivateKeySweeper_deletePrivateKeys_MutationBudget(t *testing.T) {
+	mockClient := &MockFastlyClient{}
+	sweeper := &PrivateKeySweeper{Logic: &Logic{
+		Config: RuntimeConfig{
+			MutationBudgetWindow:  time.Hour,
+			SubjectMutationBudget: 2,
+		},
+	}}
+	t.Cleanup(func() {
+		privateKeyCleanupsTotal.DeletePartialMatch(prometheus.Labels{"namespace": ""})
+	})
+
+	keys := []*fastly.PrivateKey{{ID: "key1"}, {ID: "key2"}, {ID: "key3"}}
+	sweeper.deletePrivateKeys(context.Background(), logr.Discard(), mockClient, keys)
+
+	// Deletions beyond the budget are deferred to a later sweep
+	assert.Len(t, mockClient.DeletePrivateKeyCalls, 2)
+}
@@ -0,0 +1,129 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// QueueHealth watches the reconcile queue of the controller, to fail the liveness probe of an operator that is
+// wedged, e.g. on a reconcile that never returns, so that Kubernetes restarts it.
+type QueueHealth struct {
+	// MaxItemAge is how long a request may wait in the queue to be reconciled, 0 disables the check. Requests
+	// scheduled for later, e.g. requeues and retries, aren't tracked, they are covered by MaxIdle.
+	MaxItemAge time.Duration
+	// MaxIdle is how long the queue may hold requests without a reconcile completing, 0 disables the check
+	MaxIdle time.Duration
+
+	now func() time.Time
+
+	mu sync.Mutex
+	// queue is nil until the controller starts, i.e. on replicas that aren't the leader
+	queue workqueue.TypedRateLimitingInterface[reconcile.Request]
+	// waiting holds when each request waiting in the queue was first added
+	waiting map[reconcile.Request]time.Time
+	// lastProgress is when a reconcile last completed, or the queue was created
+	lastProgress time.Time
+}
+
+func (h *QueueHealth) clock() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+// NewQueue creates the default queue of the controller, wrapped to track the requests waiting in it. It is meant for
+// controller.Options.NewQueue.
+func (h *QueueHealth) NewQueue(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return h.wrap(workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{
+		Name: controllerName,
+	}))
+}
+
+func (h *QueueHealth) wrap(queue workqueue.TypedRateLimitingInterface[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.queue = queue
+	h.waiting = map[reconcile.Request]time.Time{}
+	h.lastProgress = h.clock()
+	return &trackedQueue{TypedRateLimitingInterface: queue, health: h}
+}
+
+// Check is a healthz.Checker that fails once the oldest request waited longer than MaxItemAge, or no reconcile
+// completed within MaxIdle while requests are waiting
+func (h *QueueHealth) Check(_ *http.Request) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.queue == nil || h.queue.Len() == 0 {
+		return nil
+	}
+
+	now := h.clock()
+	if h.MaxIdle > 0 && now.Sub(h.lastProgress) > h.MaxIdle {
+		return fmt.Errorf("no reconcile completed in %s with %d requests queued", now.Sub(h.lastProgress).Round(time.Second), h.queue.Len())
+	}
+
+	if h.MaxItemAge > 0 {
+		for request, added := range h.waiting {
+			if age := now.Sub(added); age > h.MaxItemAge {
+				return fmt.Errorf("request %s waited %s in the reconcile queue", request, age.Round(time.Second))
+			}
+		}
+	}
+	return nil
+}
+
+func (h *QueueHealth) added(request reconcile.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.waiting[request]; !ok {
+		h.waiting[request] = h.clock()
+	}
+}
+
+func (h *QueueHealth) started(request reconcile.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.waiting, request)
+}
+
+func (h *QueueHealth) completed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastProgress = h.clock()
+}
+
+// trackedQueue reports the requests added to, taken from and completed by the queue to its QueueHealth. Requests
+// added after a delay are added by the underlying queue itself once they are due, bypassing Add.
+type trackedQueue struct {
+	workqueue.TypedRateLimitingInterface[reconcile.Request]
+	health *QueueHealth
+}
+
+func (q *trackedQueue) Add(request reconcile.Request) {
+	q.health.added(request)
+	q.TypedRateLimitingInterface.Add(request)
+}
+
+func (q *trackedQueue) Get() (reconcile.Request, bool) {
+	request, shutdown := q.TypedRateLimitingInterface.Get()
+	if !shutdown {
+		q.health.started(request)
+	}
+	return request, shutdown
+}
+
+func (q *trackedQueue) Done(request reconcile.Request) {
+	q.TypedRateLimitingInterface.Done(request)
+	q.health.completed()
+}
@@ -0,0 +1,69 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestQueueHealth_Check(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newHealth := func(maxItemAge, maxIdle time.Duration) (*QueueHealth, workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		health := &QueueHealth{MaxItemAge: maxItemAge, MaxIdle: maxIdle, now: func() time.Time { return now }}
+		queue := health.NewQueue("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		t.Cleanup(queue.ShutDown)
+		return health, queue
+	}
+	first := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "first"}}
+	second := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "second"}}
+
+	t.Run("not_started", func(t *testing.T) {
+		health := &QueueHealth{MaxItemAge: time.Minute, MaxIdle: time.Minute}
+		assert.NoError(t, health.Check(nil), "standby replicas have no queue")
+	})
+
+	t.Run("oldest_item", func(t *testing.T) {
+		start := now
+		defer func() { now = start }()
+		health, queue := newHealth(10*time.Minute, 0)
+
+		queue.Add(first)
+		now = now.Add(5 * time.Minute)
+		queue.Add(second)
+		queue.Add(first)
+		assert.NoError(t, health.Check(nil))
+
+		now = now.Add(6 * time.Minute)
+		assert.EqualError(t, health.Check(nil), "request test-namespace/first waited 11m0s in the reconcile queue",
+			"re-adding a waiting request doesn't reset its age")
+
+		request, _ := queue.Get()
+		require.Equal(t, first, request)
+		queue.Done(request)
+		assert.NoError(t, health.Check(nil))
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		start := now
+		defer func() { now = start }()
+		health, queue := newHealth(0, 15*time.Minute)
+
+		now = now.Add(time.Hour)
+		assert.NoError(t, health.Check(nil), "an empty queue is idle by design")
+
+		queue.Add(first)
+		assert.EqualError(t, health.Check(nil), "no reconcile completed in 1h0m0s with 1 requests queued")
+
+		request, _ := queue.Get()
+		queue.Add(second)
+		assert.Error(t, health.Check(nil), "a reconcile that doesn't return makes no progress")
+
+		queue.Done(request)
+		assert.NoError(t, health.Check(nil))
+	})
+}
@@ -0,0 +1,209 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	accountCustomCertificatesRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fastly_account_custom_certificates_remaining",
+		Help: "Custom TLS certificates that can still be created before the Fastly account reaches -fastly-custom-certificate-limit",
+	}, []string{"account"})
+
+	accountPrivateKeysRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fastly_account_private_keys_remaining",
+		Help: "TLS private keys that can still be uploaded before the Fastly account reaches -fastly-private-key-limit",
+	}, []string{"account"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(accountCustomCertificatesRemaining, accountPrivateKeysRemaining)
+}
+
+// accountQuotaTracker holds the totals of each Fastly account as of its last audit, counting the objects created by
+// the operator since then, so that creations can be refused before the account's limits are hit
+type accountQuotaTracker struct {
+	mu     sync.Mutex
+	totals map[string]accountTotals
+}
+
+// Set records the audited totals of the given account
+func (t *accountQuotaTracker) Set(account string, totals accountTotals) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.totals == nil {
+		t.totals = map[string]accountTotals{}
+	}
+	t.totals[account] = totals
+}
+
+// Forget drops the totals of the given account, whose audit failed
+func (t *accountQuotaTracker) Forget(account string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.totals, account)
+}
+
+// Get returns the totals of the given account, if it was audited
+func (t *accountQuotaTracker) Get(account string) (accountTotals, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals, ok := t.totals[account]
+	return totals, ok
+}
+
+// Add counts objects created in the given account since its last audit
+func (t *accountQuotaTracker) Add(account string, customCertificates, privateKeys int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals, ok := t.totals[account]
+	if !ok {
+		return
+	}
+	totals.customCertificates += customCertificates
+	totals.privateKeys += privateKeys
+	t.totals[account] = totals
+}
+
+const (
+	quotaCustomCertificates = "custom certificates"
+	quotaPrivateKeys        = "private keys"
+)
+
+// accountQuota is the usage of a single account limit
+type accountQuota struct {
+	kind  string
+	used  int
+	limit int
+}
+
+func (q accountQuota) exhausted() bool {
+	return q.limit > 0 && q.used >= q.limit
+}
+
+// nearLimit reports whether the usage is within the warning threshold of the limit
+func (q accountQuota) nearLimit(warningPercent int) bool {
+	return q.limit > 0 && q.used*100 >= q.limit*warningPercent
+}
+
+func (q accountQuota) String() string {
+	return fmt.Sprintf("%d of %d %s", q.used, q.limit, q.kind)
+}
+
+// accountQuotas returns the usage of each configured limit, given the totals of an account
+func accountQuotas(config RuntimeConfig, totals accountTotals) []accountQuota {
+	var res []accountQuota
+	if config.FastlyCustomCertificateLimit > 0 {
+		res = append(res, accountQuota{kind: quotaCustomCertificates, used: totals.customCertificates, limit: config.FastlyCustomCertificateLimit})
+	}
+	if config.FastlyPrivateKeyLimit > 0 {
+		res = append(res, accountQuota{kind: quotaPrivateKeys, used: totals.privateKeys, limit: config.FastlyPrivateKeyLimit})
+	}
+	return res
+}
+
+func hasAccountQuotas(config RuntimeConfig) bool {
+	return config.FastlyCustomCertificateLimit > 0 || config.FastlyPrivateKeyLimit > 0
+}
+
+// setAccountQuotaMetrics exports the remaining capacity of the account, for the limits that are configured
+func setAccountQuotaMetrics(config RuntimeConfig, account string, totals accountTotals) {
+	if config.FastlyCustomCertificateLimit > 0 {
+		accountCustomCertificatesRemaining.WithLabelValues(account).Set(float64(max(config.FastlyCustomCertificateLimit-totals.customCertificates, 0)))
+	}
+	if config.FastlyPrivateKeyLimit > 0 {
+		accountPrivateKeysRemaining.WithLabelValues(account).Set(float64(max(config.FastlyPrivateKeyLimit-totals.privateKeys, 0)))
+	}
+}
+
+func deleteAccountQuotaMetrics(account string) {
+	accountCustomCertificatesRemaining.DeleteLabelValues(account)
+	accountPrivateKeysRemaining.DeleteLabelValues(account)
+}
+
+// checkAccountQuota refuses to create another object of the given kind in the current account once the account has
+// reached its limit, rather than have Fastly reject the creation with an opaque error. Accounts that weren't audited
+// yet are not checked.
+func (l *Logic) checkAccountQuota(ctx *Context, kind string) error {
+	totals, ok := l.accountQuotas.Get(l.fastlyAccount())
+	if !ok {
+		return nil
+	}
+
+	for _, quota := range accountQuotas(ctx.Config.RuntimeConfig, totals) {
+		if quota.kind == kind && quota.exhausted() {
+			return fmt.Errorf("account %s holds %s, refusing to create another", l.fastlyAccount(), quota)
+		}
+	}
+	return nil
+}
+
+// observeAccountQuota looks up the usage of the current account's limits, as of its last audit
+func (l *Logic) observeAccountQuota(ctx *Context) {
+	if !hasAccountQuotas(ctx.Config.RuntimeConfig) {
+		return
+	}
+
+	totals, ok := l.accountQuotas.Get(l.fastlyAccount())
+	l.ObservedState.AccountQuotaObserved = ok
+	if ok {
+		l.ObservedState.AccountQuotas = accountQuotas(ctx.Config.RuntimeConfig, totals)
+	}
+}
+
+// observeQuotaNearLimitCondition generates the condition warning that the Fastly account is running out of room for
+// new certificates or private keys. It is omitted unless account limits are configured, and for subjects syncing to
+// several accounts.
+func (l *Logic) observeQuotaNearLimitCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if !hasAccountQuotas(ctx.Config.RuntimeConfig) || !l.SubjectReadyForReconciliation || len(ctx.Subject.Spec.Accounts) > 0 {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type: "QuotaNearLimit",
+	}
+
+	if !l.ObservedState.AccountQuotaObserved {
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "QuotaNotObserved"
+		condition.Message = fmt.Sprintf("Fastly account %s has not been audited yet", l.fastlyAccount())
+		return condition, nil
+	}
+
+	var exhausted, near []string
+	for _, quota := range l.ObservedState.AccountQuotas {
+		switch {
+		case quota.exhausted():
+			exhausted = append(exhausted, quota.String())
+		case quota.nearLimit(ctx.Config.FastlyQuotaWarningPercent):
+			near = append(near, quota.String())
+		}
+	}
+
+	switch {
+	case len(exhausted) > 0:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "QuotaExhausted"
+		condition.Message = fmt.Sprintf("Fastly account %s holds %s, new ones are not created", l.fastlyAccount(), strings.Join(append(exhausted, near...), ", "))
+	case len(near) > 0:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "QuotaNearLimit"
+		condition.Message = fmt.Sprintf("Fastly account %s holds %s", l.fastlyAccount(), strings.Join(near, ", "))
+	default:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "QuotaAvailable"
+		condition.Message = fmt.Sprintf("Fastly account %s is below %d%% of its limits", l.fastlyAccount(), ctx.Config.FastlyQuotaWarningPercent)
+	}
+
+	return condition, nil
+}
@@ -0,0 +1,185 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAccountQuotaTracker(t *testing.T) {
+	var tracker accountQuotaTracker
+
+	// Creations in accounts that weren't audited yet aren't counted
+	tracker.Add("default", 1, 1)
+	_, ok := tracker.Get("default")
+	assert.False(t, ok)
+
+	tracker.Set("default", accountTotals{customCertificates: 10, privateKeys: 5})
+	tracker.Add("default", 1, 0)
+	tracker.Add("default", 0, 2)
+	totals, ok := tracker.Get("default")
+	require.True(t, ok)
+	assert.Equal(t, 11, totals.customCertificates)
+	assert.Equal(t, 7, totals.privateKeys)
+
+	tracker.Forget("default")
+	_, ok = tracker.Get("default")
+	assert.False(t, ok)
+}
+
+func TestAccountQuota(t *testing.T) {
+	tests := []struct {
+		name            string
+		quota           accountQuota
+		expectNearLimit bool
+		expectExhausted bool
+	}{
+		{name: "below threshold", quota: accountQuota{used: 89, limit: 100}},
+		{name: "at threshold", quota: accountQuota{used: 90, limit: 100}, expectNearLimit: true},
+		{name: "at limit", quota: accountQuota{used: 100, limit: 100}, expectNearLimit: true, expectExhausted: true},
+		{name: "past limit", quota: accountQuota{used: 120, limit: 100}, expectNearLimit: true, expectExhausted: true},
+		{name: "unlimited", quota: accountQuota{used: 1000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectNearLimit, tt.quota.nearLimit(90))
+			assert.Equal(t, tt.expectExhausted, tt.quota.exhausted())
+		})
+	}
+}
+
+func TestAccountAudit_auditAccounts_Quotas(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			return make([]*fastly.CustomTLSCertificate, 8), nil
+		},
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			return make([]*fastly.PrivateKey, 12), nil
+		},
+	}
+	audit := &AccountAudit{
+		Logic: &Logic{
+			FastlyClient: mockClient,
+			Config:       RuntimeConfig{FastlyCustomCertificateLimit: 10, FastlyPrivateKeyLimit: 10},
+		},
+	}
+	t.Cleanup(func() {
+		accountCustomCertificatesRemaining.Reset()
+		accountPrivateKeysRemaining.Reset()
+	})
+
+	audit.auditAccounts(context.Background(), logr.Discard())
+
+	assert.Equal(t, float64(2), gaugeValue(t, accountCustomCertificatesRemaining, "default"))
+	assert.Equal(t, float64(0), gaugeValue(t, accountPrivateKeysRemaining, "default"), "remaining capacity never goes negative")
+	totals, ok := audit.Logic.accountQuotas.Get("default")
+	require.True(t, ok)
+	assert.Equal(t, 8, totals.customCertificates)
+}
+
+func TestLogic_checkAccountQuota(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Config.FastlyCustomCertificateLimit = 10
+	logic := &Logic{}
+
+	// Accounts that weren't audited yet are not checked
+	assert.NoError(t, logic.checkAccountQuota(ctx, quotaCustomCertificates))
+
+	logic.accountQuotas.Set("default", accountTotals{customCertificates: 9, privateKeys: 100})
+	assert.NoError(t, logic.checkAccountQuota(ctx, quotaCustomCertificates))
+	assert.NoError(t, logic.checkAccountQuota(ctx, quotaPrivateKeys), "private keys are unlimited")
+
+	logic.accountQuotas.Add("default", 1, 0)
+	assert.EqualError(t, logic.checkAccountQuota(ctx, quotaCustomCertificates),
+		"account default holds 10 of 10 custom certificates, refusing to create another")
+}
+
+func TestLogic_createFastlyCertificate_QuotaExhausted(t *testing.T) {
+	mockClient := &MockFastlyClient{
+		CreateCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.CreateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			t.Fatal("no certificate should be created")
+			return nil, nil
+		},
+	}
+	ctx := createTestContext()
+	ctx.Config.FastlyCustomCertificateLimit = 1
+	logic := &Logic{FastlyClient: mockClient}
+	logic.accountQuotas.Set("default", accountTotals{customCertificates: 1})
+
+	// The limit is checked before the certificate is read, so the context needs no Secret
+	_, err := logic.createFastlyCertificate(ctx)
+	assert.ErrorContains(t, err, "refusing to create another")
+}
+
+func TestLogic_observeQuotaNearLimitCondition(t *testing.T) {
+	ctx := createTestContext()
+	logic := &Logic{SubjectReadyForReconciliation: true}
+
+	// Omitted unless limits are configured
+	logic.observeAccountQuota(ctx)
+	condition, err := logic.observeQuotaNearLimitCondition(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, condition)
+
+	ctx.Config.FastlyCustomCertificateLimit = 100
+	ctx.Config.FastlyPrivateKeyLimit = 50
+	ctx.Config.FastlyQuotaWarningPercent = 90
+
+	logic.observeAccountQuota(ctx)
+	condition, err = logic.observeQuotaNearLimitCondition(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.Equal(t, "QuotaNotObserved", condition.Reason)
+
+	tests := []struct {
+		name          string
+		totals        accountTotals
+		expectStatus  metav1.ConditionStatus
+		expectReason  string
+		expectMessage string
+	}{
+		{
+			name:          "available",
+			totals:        accountTotals{customCertificates: 10, privateKeys: 10},
+			expectStatus:  metav1.ConditionFalse,
+			expectReason:  "QuotaAvailable",
+			expectMessage: "Fastly account default is below 90% of its limits",
+		},
+		{
+			name:          "near limit",
+			totals:        accountTotals{customCertificates: 95, privateKeys: 10},
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  "QuotaNearLimit",
+			expectMessage: "Fastly account default holds 95 of 100 custom certificates",
+		},
+		{
+			name:          "exhausted",
+			totals:        accountTotals{customCertificates: 95, privateKeys: 50},
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  "QuotaExhausted",
+			expectMessage: "Fastly account default holds 50 of 50 private keys, 95 of 100 custom certificates, new ones are not created",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic.accountQuotas.Set("default", tt.totals)
+			logic.ObservedState = ObservedState{}
+			logic.observeAccountQuota(ctx)
+
+			condition, err := logic.observeQuotaNearLimitCondition(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, condition)
+			assert.Equal(t, tt.expectStatus, condition.Status)
+			assert.Equal(t, tt.expectReason, condition.Reason)
+			assert.Equal(t, tt.expectMessage, condition.Message)
+		})
+	}
+}
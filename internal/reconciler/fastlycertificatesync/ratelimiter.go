@@ -0,0 +1,44 @@
+package fastlycertificatesync
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Defaults of the per-subject backoff of controller-runtime, used for whichever bound isn't configured
+const (
+	defaultRetryBaseDelay = 5 * time.Millisecond
+	defaultRetryMaxDelay  = 1000 * time.Second
+)
+
+// controllerOptions configures the queue of the controller, leaving the rest to the manager's defaults
+func (l *Logic) controllerOptions() controller.Options {
+	options := controller.Options{}
+	if l.Config.RetryBaseDelay > 0 || l.Config.RetryMaxDelay > 0 {
+		options.RateLimiter = newRetryRateLimiter(l.Config.RetryBaseDelay, l.Config.RetryMaxDelay)
+	}
+	if l.QueueHealth != nil {
+		options.NewQueue = l.QueueHealth.NewQueue
+	}
+	return options
+}
+
+// newRetryRateLimiter mirrors controller-runtime's default rate limiter with the given bounds: each subject that keeps
+// failing backs off exponentially from baseDelay up to maxDelay, so it can't crowd out the others, while retries
+// across all subjects are capped at 10 per second with bursts of 100
+func newRetryRateLimiter(baseDelay, maxDelay time.Duration) workqueue.TypedRateLimiter[reconcile.Request] {
+	if baseDelay <= 0 {
+		baseDelay = defaultRetryBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](baseDelay, max(baseDelay, maxDelay)),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
@@ -0,0 +1,54 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNewRetryRateLimiter(t *testing.T) {
+	failing := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "failing"}}
+	other := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "other"}}
+
+	limiter := newRetryRateLimiter(time.Second, 5*time.Minute)
+	var delays []time.Duration
+	for range 10 {
+		delays = append(delays, limiter.When(failing))
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+		32 * time.Second, 64 * time.Second, 128 * time.Second, 256 * time.Second, 5 * time.Minute,
+	}, delays)
+	assert.Equal(t, time.Second, limiter.When(other), "each subject backs off on its own")
+
+	limiter.Forget(failing)
+	assert.Equal(t, time.Second, limiter.When(failing), "a successful reconcile resets the backoff")
+
+	t.Run("defaults", func(t *testing.T) {
+		limiter := newRetryRateLimiter(0, time.Minute)
+		assert.Equal(t, defaultRetryBaseDelay, limiter.When(failing))
+
+		limiter = newRetryRateLimiter(10*time.Minute, 0)
+		assert.Equal(t, 10*time.Minute, limiter.When(failing))
+		assert.Equal(t, defaultRetryMaxDelay, limiter.When(failing))
+
+		limiter = newRetryRateLimiter(time.Hour, time.Minute)
+		assert.Equal(t, time.Hour, limiter.When(failing), "the max delay is never below the base delay")
+	})
+}
+
+func TestLogic_controllerOptions(t *testing.T) {
+	logic := &Logic{}
+	options := logic.controllerOptions()
+	assert.Nil(t, options.RateLimiter, "the controller-runtime default is kept")
+	assert.Nil(t, options.NewQueue)
+
+	logic.Config.RetryBaseDelay = time.Second
+	logic.QueueHealth = &QueueHealth{}
+	options = logic.controllerOptions()
+	assert.NotNil(t, options.RateLimiter)
+	assert.NotNil(t, options.NewQueue)
+}
@@ -0,0 +1,37 @@
+package fastlycertificatesync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// redactedPrivateKey replaces private key material wherever it would be formatted or logged
+const redactedPrivateKey = "[REDACTED PRIVATE KEY]"
+
+// privateKeyPEM holds PEM encoded private key material as read from a Secret. It masks its contents whenever it is
+// formatted, logged or marshalled, so that the key can't end up in a log line or error message by accident, at any
+// verbosity. Only converting it to a string or byte slice, as when uploading it to Fastly, reveals the key.
+type privateKeyPEM []byte
+
+func (k privateKeyPEM) String() string {
+	return redactedPrivateKey
+}
+
+func (k privateKeyPEM) GoString() string {
+	return redactedPrivateKey
+}
+
+// Format masks the key for every verb, %x and %q included, which would otherwise bypass String
+func (k privateKeyPEM) Format(f fmt.State, _ rune) {
+	_, _ = io.WriteString(f, redactedPrivateKey)
+}
+
+// MarshalLog masks the key for logr sinks, which log the value in its place
+func (k privateKeyPEM) MarshalLog() any {
+	return redactedPrivateKey
+}
+
+func (k privateKeyPEM) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redactedPrivateKey)
+}
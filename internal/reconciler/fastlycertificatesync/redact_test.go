@@ -0,0 +1,126 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr/funcr"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Helper to create a PEM encoded private key, along with a line of its body that must never be logged
+func createTestKeyPEM(t *testing.T) ([]byte, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	return keyPEM, strings.Split(string(keyPEM), "\n")[1]
+}
+
+func TestPrivateKeyPEM_Redacted(t *testing.T) {
+	keyPEM, body := createTestKeyPEM(t)
+	key := privateKeyPEM(keyPEM)
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x", "%X", "%d"} {
+		formatted := fmt.Sprintf(format, key)
+		assert.Equal(t, redactedPrivateKey, formatted, format)
+	}
+	assert.EqualError(t, fmt.Errorf("failed to upload %v", key), "failed to upload "+redactedPrivateKey)
+
+	marshalled, err := json.Marshal(map[string]any{"key": key})
+	require.NoError(t, err)
+	assert.NotContains(t, string(marshalled), body)
+
+	var lines []string
+	log := funcr.New(func(prefix, args string) { lines = append(lines, args) }, funcr.Options{Verbosity: 10})
+	log.V(5).Info("private key", "key", key)
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], redactedPrivateKey)
+	assert.NotContains(t, lines[0], body)
+
+	// The key itself is still available to be uploaded
+	assert.Equal(t, string(keyPEM), string(key))
+}
+
+// Observes a subject whose private key isn't in Fastly yet and uploads it, logging at every verbosity, to catch any
+// code path that logs the raw key held by the Secret
+func TestLogic_NeverLogsPrivateKey(t *testing.T) {
+	keyPEM, body := createTestKeyPEM(t)
+
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+			Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+			Data: map[string][]byte{
+				"tls.crt": createTestCertPEM(t, time.Now().Add(24*time.Hour)),
+				"tls.key": keyPEM,
+			},
+		},
+	).Build()
+
+	var lines []string
+	ctx := createTestContext()
+	ctx.Log = funcr.New(func(prefix, args string) { lines = append(lines, args) }, funcr.Options{Verbosity: 10})
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	uploaded := ""
+	logic := &Logic{
+		FastlyClient: &MockFastlyClient{
+			ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+				return nil, nil
+			},
+			ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+				return nil, nil
+			},
+			CreatePrivateKeyFunc: func(ctx context.Context, input *fastly.CreatePrivateKeyInput) (*fastly.PrivateKey, error) {
+				uploaded = input.Key
+				return &fastly.PrivateKey{ID: "key1"}, nil
+			},
+		},
+	}
+
+	invalidInput, err := validateFastlyInput(ctx)
+	require.NoError(t, err)
+	require.Empty(t, invalidInput)
+	require.NoError(t, logic.observeFastly(ctx))
+	require.False(t, logic.ObservedState.PrivateKeyUploaded)
+	require.NoError(t, logic.applyFastlyChanges(ctx))
+
+	assert.Equal(t, string(keyPEM), uploaded, "the raw key is only handed to Fastly")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.NotContains(t, line, body)
+		assert.NotContains(t, line, "PRIVATE KEY-----")
+	}
+}
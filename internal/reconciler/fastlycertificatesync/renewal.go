@@ -0,0 +1,62 @@
+package fastlycertificatesync
+
+import (
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+)
+
+// renewalCheckDelay is how long after cert-manager is expected to renew the certificate the subject is reconciled
+// again, leaving the issuer time to sign the renewed certificate
+const renewalCheckDelay = 5 * time.Minute
+
+// expectedRenewalTime returns when cert-manager is expected to renew the certificate. This is status.renewalTime when
+// cert-manager reported it, otherwise it is derived from the validity of the issued certificate the same way
+// cert-manager does, defaulting to renewing once two thirds of the lifetime have passed.
+func expectedRenewalTime(certificate *cmv1.Certificate) (time.Time, bool) {
+	status := certificate.Status
+	if status.RenewalTime != nil {
+		return status.RenewalTime.Time, true
+	}
+	if status.NotAfter == nil || status.NotBefore == nil {
+		return time.Time{}, false
+	}
+
+	lifetime := status.NotAfter.Sub(status.NotBefore.Time)
+	renewBefore := lifetime / 3
+	switch {
+	case certificate.Spec.RenewBefore != nil && certificate.Spec.RenewBefore.Duration < lifetime:
+		renewBefore = certificate.Spec.RenewBefore.Duration
+	case certificate.Spec.RenewBeforePercentage != nil:
+		renewBefore = lifetime * time.Duration(*certificate.Spec.RenewBeforePercentage) / 100
+	}
+	return status.NotAfter.Add(-renewBefore), true
+}
+
+// scheduleRenewalCheck requeues the subject shortly after its certificate is expected to be renewed, so that Fastly
+// is refreshed within minutes of the renewal even if the change to the Secret is missed
+func (l *Logic) scheduleRenewalCheck(ctx *Context) {
+	if l.ObservedState.FastlyCertificate == nil {
+		return
+	}
+
+	certificate, _, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		ctx.Log.Error(err, "failed to get certificate, not scheduling a renewal check")
+		return
+	}
+
+	renewalTime, ok := expectedRenewalTime(certificate)
+	if !ok {
+		return
+	}
+
+	// A renewal that is overdue is left to the watches and the resync, rather than checked every few minutes
+	until := time.Until(renewalTime)
+	if until < 0 {
+		return
+	}
+
+	ctx.Log.Info("scheduling renewal check", "renewal_time", renewalTime)
+	ctx.SetRequeue(until + renewalCheckDelay)
+}
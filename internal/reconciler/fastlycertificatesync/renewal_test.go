@@ -0,0 +1,136 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExpectedRenewalTime(t *testing.T) {
+	notBefore := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := notBefore.Add(90 * 24 * time.Hour)
+	percentage := int32(50)
+
+	tests := []struct {
+		name     string
+		spec     cmv1.CertificateSpec
+		status   cmv1.CertificateStatus
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:   "not_issued",
+			status: cmv1.CertificateStatus{},
+		},
+		{
+			name: "renewal_time",
+			status: cmv1.CertificateStatus{
+				NotBefore:   &metav1.Time{Time: notBefore},
+				NotAfter:    &metav1.Time{Time: notAfter},
+				RenewalTime: &metav1.Time{Time: notBefore.Add(time.Hour)},
+			},
+			expected: notBefore.Add(time.Hour),
+			ok:       true,
+		},
+		{
+			name:     "default_renew_before",
+			status:   cmv1.CertificateStatus{NotBefore: &metav1.Time{Time: notBefore}, NotAfter: &metav1.Time{Time: notAfter}},
+			expected: notAfter.Add(-30 * 24 * time.Hour),
+			ok:       true,
+		},
+		{
+			name:     "renew_before",
+			spec:     cmv1.CertificateSpec{RenewBefore: &metav1.Duration{Duration: 7 * 24 * time.Hour}},
+			status:   cmv1.CertificateStatus{NotBefore: &metav1.Time{Time: notBefore}, NotAfter: &metav1.Time{Time: notAfter}},
+			expected: notAfter.Add(-7 * 24 * time.Hour),
+			ok:       true,
+		},
+		{
+			name:     "renew_before_longer_than_lifetime",
+			spec:     cmv1.CertificateSpec{RenewBefore: &metav1.Duration{Duration: 100 * 24 * time.Hour}},
+			status:   cmv1.CertificateStatus{NotBefore: &metav1.Time{Time: notBefore}, NotAfter: &metav1.Time{Time: notAfter}},
+			expected: notAfter.Add(-30 * 24 * time.Hour),
+			ok:       true,
+		},
+		{
+			name:     "renew_before_percentage",
+			spec:     cmv1.CertificateSpec{RenewBeforePercentage: &percentage},
+			status:   cmv1.CertificateStatus{NotBefore: &metav1.Time{Time: notBefore}, NotAfter: &metav1.Time{Time: notAfter}},
+			expected: notAfter.Add(-45 * 24 * time.Hour),
+			ok:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renewalTime, ok := expectedRenewalTime(&cmv1.Certificate{Spec: tt.spec, Status: tt.status})
+			assert.Equal(t, tt.ok, ok)
+			assert.True(t, tt.expected.Equal(renewalTime), "expected %s, got %s", tt.expected, renewalTime)
+		})
+	}
+}
+
+func TestLogic_scheduleRenewalCheck(t *testing.T) {
+	newContext := func(renewalTime time.Time) *Context {
+		scheme := runtime.NewScheme()
+		_ = cmv1.AddToScheme(scheme)
+		_ = corev1.AddToScheme(scheme)
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+				Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+				Status:     cmv1.CertificateStatus{RenewalTime: &metav1.Time{Time: renewalTime}},
+			},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"}},
+		).Build()
+
+		ctx := createTestContext()
+		ctx.Client = &k8sutil.ContextClient{
+			SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+			Context:       context.Background(),
+			Namespace:     "test-namespace",
+		}
+		return ctx
+	}
+	synced := &Logic{ObservedState: ObservedState{FastlyCertificate: &fastly.CustomTLSCertificate{ID: "cert-id"}}}
+
+	t.Run("upcoming_renewal", func(t *testing.T) {
+		ctx := newContext(time.Now().Add(time.Hour))
+		synced.scheduleRenewalCheck(ctx)
+
+		require.NotNil(t, ctx.RequeueAfter)
+		assert.InDelta(t, (time.Hour + renewalCheckDelay).Seconds(), ctx.RequeueAfter.Seconds(), 5)
+	})
+
+	t.Run("earlier_requeue_is_kept", func(t *testing.T) {
+		ctx := newContext(time.Now().Add(time.Hour))
+		ctx.SetRequeue(15 * time.Minute)
+		synced.scheduleRenewalCheck(ctx)
+
+		assert.Equal(t, 15*time.Minute, *ctx.RequeueAfter)
+	})
+
+	t.Run("overdue_renewal", func(t *testing.T) {
+		ctx := newContext(time.Now().Add(-time.Hour))
+		synced.scheduleRenewalCheck(ctx)
+
+		assert.Nil(t, ctx.RequeueAfter)
+	})
+
+	t.Run("not_synced", func(t *testing.T) {
+		ctx := newContext(time.Now().Add(time.Hour))
+		(&Logic{}).scheduleRenewalCheck(ctx)
+
+		assert.Nil(t, ctx.RequeueAfter)
+	})
+}
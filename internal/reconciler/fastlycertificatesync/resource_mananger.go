@@ -1,7 +1,14 @@
 package fastlycertificatesync
 
 import (
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	rm "github.com/seatgeek/k8s-reconciler-generic/pkg/resourcemanager"
 )
 
-var ResourceManager = rm.ResourceManager[*Context]{}
+// Certificates created from spec.certificateTemplate are owned by their FastlyCertificateSync
+// +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=create;update;patch;delete
+
+var ResourceManager = rm.ResourceManager[*Context]{
+	// Certificates created from spec.certificateTemplate, named after the FastlyCertificateSync
+	rm.NewHandler[cmv1.Certificate]("", "", generateCertificate, rm.Requires(hasCertificateTemplate)),
+}
@@ -0,0 +1,224 @@
+package fastlycertificatesync
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rollbackPhase is where the subject stands with the rollback requested by spec.rollbackToSerial
+type rollbackPhase string
+
+const (
+	// rollbackPending is a rollback that is yet to be made
+	rollbackPending rollbackPhase = "Pending"
+	// rollbackHeld is a rollback that was made, the source Certificate is not synced until it is renewed
+	rollbackHeld rollbackPhase = "Held"
+	// rollbackReleased is a rollback that was made, after which the source Certificate was renewed and synced again
+	rollbackReleased rollbackPhase = "Released"
+	// rollbackUnavailable is a rollback to a certificate that is not kept in Fastly
+	rollbackUnavailable rollbackPhase = "Unavailable"
+)
+
+// standsIn reports whether the rollback stands in for syncing the source Certificate, Fastly is not observed meanwhile
+func (p rollbackPhase) standsIn() bool {
+	return p == rollbackPending || p == rollbackHeld
+}
+
+// observeRollback works out where the subject stands with spec.rollbackToSerial, and reports whether the rollback
+// stands in for syncing the source Certificate, in which case Fastly needs no further observation
+func (l *Logic) observeRollback(ctx *Context) (bool, error) {
+	serial := ctx.Subject.Spec.RollbackToSerial
+	if serial == "" || len(ctx.Subject.Spec.Accounts) > 0 {
+		return false, nil
+	}
+
+	localSerial, err := localCertificateSerialNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// Rollbacks are made once, and hold for as long as the source Certificate holds the certificate rolled back from
+	if rollback := ctx.Subject.Status.Rollback; rollback != nil && rollback.SerialNumber == serial {
+		if localSerial == rollback.FromSerialNumber {
+			l.ObservedState.Rollback = rollbackHeld
+			return true, nil
+		}
+		l.ObservedState.Rollback = rollbackReleased
+		return false, nil
+	}
+
+	if previous := ctx.Subject.Status.PreviousCertificate; previous == nil || previous.SerialNumber != serial {
+		l.ObservedState.Rollback = rollbackUnavailable
+		return false, nil
+	}
+
+	l.ObservedState.Rollback = rollbackPending
+	l.ObservedState.LocalSerialNumber = localSerial
+	return true, nil
+}
+
+// localCertificateSerialNumber returns the serial number of the certificate in the source Certificate's secret
+func localCertificateSerialNumber(ctx *Context) (string, error) {
+	_, tlsSecret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get TLS secret from context: %w", err)
+	}
+
+	certPEM, err := getCertPEMForSecret(ctx, tlsSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get cert PEM for secret: %w", err)
+	}
+	return getSerialNumberFromCertificatePEM(certPEM)
+}
+
+// applyRollback makes the rollback requested by spec.rollbackToSerial, or holds off syncing the source Certificate
+// once it was made
+func (l *Logic) applyRollback(ctx *Context) error {
+	if l.ObservedState.Rollback == rollbackHeld {
+		ctx.Log.Info("Rolled back to a previous certificate, skipping changes until the Certificate is renewed",
+			"serial_number", ctx.Subject.Status.Rollback.SerialNumber)
+		return nil
+	}
+
+	ctx.Log.Info("Rolling back to the previous certificate in Fastly", "serial_number", ctx.Subject.Spec.RollbackToSerial)
+	start := time.Now()
+	done, err := l.rollBackFastlyCertificate(ctx)
+	observeReconcilePhase(ctx, reconcilePhaseApply, start, err)
+	if err != nil {
+		return l.recordSyncFailure(ctx, fmt.Errorf("failed to roll back Fastly certificate: %w", err))
+	}
+
+	// Rollbacks deferred by the mutation budget are already requeued for when it allows them
+	if done {
+		ctx.Log.Info("Requeueing...")
+		ctx.SetRequeue(0)
+	}
+	return l.resetSyncFailures(ctx)
+}
+
+// rollBackFastlyCertificate moves the TLS activations of the current certificate back to the previous one, which then
+// takes its place. The current certificate is deleted, the source Certificate still holds it, so that the next
+// renewal rotates the certificate rolled back to as usual. Every step can be repeated, should the rollback be
+// interrupted. It reports whether the rollback was made, rather than deferred by the mutation budget.
+func (l *Logic) rollBackFastlyCertificate(ctx *Context) (bool, error) {
+	previous := ctx.Subject.Status.PreviousCertificate
+	current := ctx.Subject.Status.CertificateID
+	if current == "" {
+		return false, errors.New("no Fastly certificate is tracked to roll back from")
+	}
+
+	activations, err := listFastlyPages(func(page int) ([]*fastly.TLSActivation, error) {
+		return l.fastlyClient().ListTLSActivations(ctx, &fastly.ListTLSActivationsInput{
+			FilterTLSCertificateID: current,
+			PageNumber:             page,
+			PageSize:               defaultFastlyPageSize,
+		})
+	})
+	if err != nil {
+		return false, withFastlyOperation("ListTLSActivations", fmt.Errorf("failed to list TLS activations of Fastly certificate %s: %w", current, err))
+	}
+
+	for _, activation := range activations {
+		if !l.reserveFastlyWrite(ctx) {
+			return false, nil
+		}
+
+		_, err := l.fastlyClient().UpdateTLSActivation(ctx, &fastly.UpdateTLSActivationInput{
+			ID:          activation.ID,
+			Certificate: &fastly.CustomTLSCertificate{ID: previous.CertificateID},
+		})
+		if err != nil {
+			return false, withFastlyOperation("UpdateTLSActivation", fmt.Errorf("failed to move TLS activation %s to certificate %s: %w", activation.ID, previous.CertificateID, err))
+		}
+		ctx.Log.Info("moved TLS activation back to the previous Fastly certificate", "activation_id", activation.ID,
+			"domain", activation.Domain.ID, "configuration_id", activation.Configuration.ID)
+	}
+
+	if err := l.deleteFastlyCertificate(ctx, current); err != nil {
+		return false, withFastlyOperation("DeleteCustomTLSCertificate", fmt.Errorf("failed to delete Fastly certificate rolled back from: %w", err))
+	}
+
+	if !l.reserveFastlyWrite(ctx) {
+		return false, nil
+	}
+	ref := certificateReference(ctx.Subject)
+	if err := l.renameFastlyCertificate(ctx, previous.CertificateID, fastlyObjectName(ctx, ref.Namespace, ref.Name)); err != nil {
+		return false, withFastlyOperation("UpdateCustomTLSCertificate", err)
+	}
+
+	now := kmetav1.Now()
+	err = l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.CertificateID = previous.CertificateID
+		status.PreviousCertificate = nil
+		status.Rollback = &v1alpha1.RollbackStatus{
+			SerialNumber:     previous.SerialNumber,
+			FromSerialNumber: l.ObservedState.LocalSerialNumber,
+			CertificateID:    previous.CertificateID,
+			Time:             now,
+		}
+
+		// The certificate rolled back to is the operator's last write, not that of a conflicting writer
+		status.LastWrittenSerial = previous.SerialNumber
+		status.LastWriteTime = &now
+		status.AppendSyncHistory(v1alpha1.SyncHistoryEntry{SerialNumber: previous.SerialNumber, Time: now})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record rollback: %w", err)
+	}
+	return true, nil
+}
+
+// keptFromLastObservation carries over the condition generated by fn from the last observation of Fastly while Fastly
+// isn't observed, because a rollback stands in for syncing the source Certificate or the local certificate is invalid
+func (l *Logic) keptFromLastObservation(fn func(ctx *Context) (*kmetav1.Condition, error)) func(ctx *Context) (*kmetav1.Condition, error) {
+	return func(ctx *Context) (*kmetav1.Condition, error) {
+		condition, err := fn(ctx)
+		if condition == nil || (!l.ObservedState.Rollback.standsIn() && l.ObservedState.LocalCertificateInvalid == "") {
+			return condition, err
+		}
+		if previous := ctx.Subject.Status.GetCondition(condition.Type); previous != nil {
+			return previous, nil
+		}
+		return condition, err
+	}
+}
+
+// observeRolledBackCondition generates the condition for the rollback requested by spec.rollbackToSerial. It is
+// omitted when no rollback is requested.
+func (l *Logic) observeRolledBackCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || l.ObservedState.Rollback == "" {
+		return nil, nil
+	}
+
+	serial := ctx.Subject.Spec.RollbackToSerial
+	condition := &kmetav1.Condition{
+		Type: "RolledBack",
+	}
+
+	switch l.ObservedState.Rollback {
+	case rollbackPending:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "RollbackPending"
+		condition.Message = fmt.Sprintf("Rolling back to the certificate with serial number %s", serial)
+	case rollbackHeld:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "RolledBack"
+		condition.Message = fmt.Sprintf("Serving the certificate with serial number %s in place of %s until the Certificate is renewed",
+			serial, ctx.Subject.Status.Rollback.FromSerialNumber)
+	case rollbackReleased:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "CertificateRenewed"
+		condition.Message = fmt.Sprintf("The Certificate was renewed since rolling back to serial number %s and is synced again, spec.rollbackToSerial can be unset", serial)
+	case rollbackUnavailable:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "PreviousCertificateUnavailable"
+		condition.Message = fmt.Sprintf("No previous certificate with serial number %s is kept in Fastly to roll back to", serial)
+	}
+
+	return condition, nil
+}
@@ -0,0 +1,218 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newRollbackTestContext creates a context whose source Certificate holds a certificate with serial number 1
+func newRollbackTestContext(t *testing.T) *Context {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.RollbackToSerial = "7"
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			ctx.Subject,
+			&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+				Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data:       map[string][]byte{"tls.crt": createTestCertPEM(t, time.Now().Add(24*time.Hour))},
+			},
+		).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	return ctx
+}
+
+func TestLogic_observeRollback(t *testing.T) {
+	tests := []struct {
+		name             string
+		rollbackToSerial string
+		previous         *v1alpha1.PreviousCertificateStatus
+		rollback         *v1alpha1.RollbackStatus
+		expectPhase      rollbackPhase
+		expectStandsIn   bool
+	}{
+		{
+			name:     "not requested",
+			previous: &v1alpha1.PreviousCertificateStatus{CertificateID: "cert1", SerialNumber: "7"},
+		},
+		{
+			name:             "no previous certificate",
+			rollbackToSerial: "7",
+			expectPhase:      rollbackUnavailable,
+		},
+		{
+			name:             "previous certificate with another serial number",
+			rollbackToSerial: "7",
+			previous:         &v1alpha1.PreviousCertificateStatus{CertificateID: "cert1", SerialNumber: "6"},
+			expectPhase:      rollbackUnavailable,
+		},
+		{
+			name:             "pending",
+			rollbackToSerial: "7",
+			previous:         &v1alpha1.PreviousCertificateStatus{CertificateID: "cert1", SerialNumber: "7"},
+			expectPhase:      rollbackPending,
+			expectStandsIn:   true,
+		},
+		{
+			name:             "held until the Certificate is renewed",
+			rollbackToSerial: "7",
+			rollback:         &v1alpha1.RollbackStatus{SerialNumber: "7", FromSerialNumber: "1", CertificateID: "cert1"},
+			expectPhase:      rollbackHeld,
+			expectStandsIn:   true,
+		},
+		{
+			name:             "released once the Certificate is renewed",
+			rollbackToSerial: "7",
+			previous:         &v1alpha1.PreviousCertificateStatus{CertificateID: "cert1", SerialNumber: "7"},
+			rollback:         &v1alpha1.RollbackStatus{SerialNumber: "7", FromSerialNumber: "0", CertificateID: "cert1"},
+			expectPhase:      rollbackReleased,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newRollbackTestContext(t)
+			ctx.Subject.Spec.RollbackToSerial = tt.rollbackToSerial
+			ctx.Subject.Status.PreviousCertificate = tt.previous
+			ctx.Subject.Status.Rollback = tt.rollback
+			logic := &Logic{}
+
+			standsIn, err := logic.observeRollback(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectStandsIn, standsIn)
+			assert.Equal(t, tt.expectPhase, logic.ObservedState.Rollback)
+		})
+	}
+}
+
+func TestLogic_rollBackFastlyCertificate(t *testing.T) {
+	activations := map[string]string{"act1": "cert2", "act2": "cert2"}
+	var patchedPath string
+	mockClient := &MockFastlyClient{
+		ListTLSActivationsFunc: func(ctx context.Context, input *fastly.ListTLSActivationsInput) ([]*fastly.TLSActivation, error) {
+			var res []*fastly.TLSActivation
+			for _, id := range []string{"act1", "act2"} {
+				if activations[id] == input.FilterTLSCertificateID {
+					res = append(res, &fastly.TLSActivation{ID: id, Domain: &fastly.TLSDomain{ID: id + ".example.com"}, Configuration: &fastly.TLSConfiguration{ID: "config1"}})
+				}
+			}
+			return res, nil
+		},
+		UpdateTLSActivationFunc: func(ctx context.Context, input *fastly.UpdateTLSActivationInput) (*fastly.TLSActivation, error) {
+			activations[input.ID] = input.Certificate.ID
+			return &fastly.TLSActivation{ID: input.ID}, nil
+		},
+		GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+			return &fastly.CustomTLSCertificate{ID: input.ID, Name: "test-certificate"}, nil
+		},
+		PatchFunc: func(ctx context.Context, p string, ro fastly.RequestOptions) (*http.Response, error) {
+			patchedPath = p
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		},
+	}
+	ctx := newRollbackTestContext(t)
+	ctx.Subject.Status.CertificateID = "cert2"
+	ctx.Subject.Status.PreviousCertificate = &v1alpha1.PreviousCertificateStatus{CertificateID: "cert1", SerialNumber: "7"}
+	logic := &Logic{FastlyClient: mockClient}
+
+	standsIn, err := logic.observeRollback(ctx)
+	require.NoError(t, err)
+	require.True(t, standsIn)
+
+	done, err := logic.rollBackFastlyCertificate(ctx)
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	assert.Equal(t, map[string]string{"act1": "cert1", "act2": "cert1"}, activations)
+	assert.Empty(t, mockClient.DeleteTLSActivationCalls, "activations are moved rather than deleted")
+	assert.Equal(t, []string{"cert2"}, mockClient.DeleteCustomTLSCertificateCalls)
+	assert.Equal(t, "/tls/certificates/cert1", patchedPath, "the certificate rolled back to takes the current name")
+
+	status := ctx.Subject.Status
+	assert.Equal(t, "cert1", status.CertificateID)
+	assert.Nil(t, status.PreviousCertificate)
+	require.NotNil(t, status.Rollback)
+	assert.Equal(t, "7", status.Rollback.SerialNumber)
+	assert.Equal(t, "1", status.Rollback.FromSerialNumber)
+	assert.Equal(t, "cert1", status.Rollback.CertificateID)
+	assert.Equal(t, "7", status.LastWrittenSerial)
+}
+
+func TestLogic_observeRolledBackCondition(t *testing.T) {
+	ctx := createTestContext()
+	logic := &Logic{}
+
+	condition, err := logic.observeRolledBackCondition(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, condition, "omitted when no rollback is requested")
+
+	ctx.Subject.Spec.RollbackToSerial = "7"
+	ctx.Subject.Status.Rollback = &v1alpha1.RollbackStatus{SerialNumber: "7", FromSerialNumber: "1"}
+	logic.ObservedState.Rollback = rollbackHeld
+	condition, err = logic.observeRolledBackCondition(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "RolledBack", condition.Reason)
+	assert.Equal(t, "Serving the certificate with serial number 7 in place of 1 until the Certificate is renewed", condition.Message)
+
+	logic.ObservedState.Rollback = rollbackUnavailable
+	condition, err = logic.observeRolledBackCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "PreviousCertificateUnavailable", condition.Reason)
+}
+
+func TestLogic_keptFromLastObservation(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Status.Conditions = []metav1.Condition{{Type: "CertificateReady", Status: metav1.ConditionTrue, Reason: "CertificateSynced"}}
+	logic := &Logic{}
+	observe := logic.keptFromLastObservation(logic.observeCertificateReadyCondition)
+
+	condition, err := observe(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "CertificateStatusUnknown", condition.Reason)
+
+	// Fastly isn't observed while a rollback stands in for syncing the Certificate
+	logic.ObservedState.Rollback = rollbackHeld
+	condition, err = observe(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "CertificateSynced", condition.Reason)
+
+	// Nor while the local certificate is invalid
+	logic.ObservedState.Rollback = ""
+	logic.ObservedState.LocalCertificateInvalid = "private key of secret test-namespace/test-secret fails to parse"
+	condition, err = observe(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "CertificateSynced", condition.Reason)
+}
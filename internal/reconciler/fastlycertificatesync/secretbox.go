@@ -0,0 +1,96 @@
+package fastlycertificatesync
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// secretBox holds private key material sealed with a random key of its own, rather than as PEM that can be picked
+// out of a heap dump, from when it is read from a Secret until it is uploaded to Fastly. Opening the box returns a
+// plain copy of the key material, which the caller wipes as soon as it is done with it, and the box itself is wiped
+// once it is no longer needed.
+//
+// Only the operator's own copies are protected. The Secret, as cached by the manager, and the request sent to
+// Fastly, which takes the key as a string, still hold the key in plain until they are garbage collected.
+type secretBox struct {
+	key    []byte
+	nonce  []byte
+	sealed []byte
+}
+
+// sealSecretBox seals a copy of plaintext, which is left to the caller
+func sealSecretBox(plaintext []byte) (*secretBox, error) {
+	box := &secretBox{key: make([]byte, 32)}
+	if _, err := rand.Read(box.key); err != nil {
+		return nil, fmt.Errorf("failed to generate secret box key: %w", err)
+	}
+
+	aead, err := box.aead()
+	if err != nil {
+		return nil, err
+	}
+	box.nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(box.nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate secret box nonce: %w", err)
+	}
+	box.sealed = aead.Seal(nil, box.nonce, plaintext, nil)
+
+	return box, nil
+}
+
+func (b *secretBox) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(b.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret box cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// open returns a plain copy of the private key held by the box, which the caller must wipe once done with it
+func (b *secretBox) open() (privateKeyPEM, error) {
+	if b.sealed == nil {
+		return nil, errors.New("secret box was already wiped")
+	}
+
+	aead, err := b.aead()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, b.nonce, b.sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secret box: %w", err)
+	}
+	return privateKeyPEM(plaintext), nil
+}
+
+// wipe zeroes the box, which can't be opened anymore
+func (b *secretBox) wipe() {
+	if b == nil {
+		return
+	}
+	clear(b.key)
+	clear(b.sealed)
+	b.sealed = nil
+}
+
+// wipe zeroes the key material, once it was uploaded or otherwise used
+func (k privateKeyPEM) wipe() {
+	clear(k)
+}
+
+// openSecretKeyPEM returns a plain copy of the private key held by the secret, for immediate use. The caller must wipe
+// it once done with it.
+func openSecretKeyPEM(ctx *Context, secret *corev1.Secret) (privateKeyPEM, error) {
+	box, err := getSecretKeyPEM(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	defer box.wipe()
+
+	return box.open()
+}
@@ -0,0 +1,50 @@
+package fastlycertificatesync
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSecretBox(t *testing.T) {
+	keyPEM, body := createTestKeyPEM(t)
+
+	box, err := sealSecretBox(keyPEM)
+	require.NoError(t, err)
+	assert.NotContains(t, string(box.sealed), body, "the key is not held in plain")
+	assert.NotContains(t, string(box.sealed), "PRIVATE KEY")
+
+	opened, err := box.open()
+	require.NoError(t, err)
+	assert.Equal(t, string(keyPEM), string(opened))
+
+	// Wiping the opened copy leaves the box, and the sealed plaintext, alone
+	opened.wipe()
+	assert.Equal(t, make([]byte, len(keyPEM)), []byte(opened))
+	reopened, err := box.open()
+	require.NoError(t, err)
+	assert.Equal(t, string(keyPEM), string(reopened))
+
+	box.wipe()
+	_, err = box.open()
+	assert.EqualError(t, err, "secret box was already wiped")
+	assert.Equal(t, make([]byte, 32), box.key)
+}
+
+func TestOpenSecretKeyPEM_LeavesSecretIntact(t *testing.T) {
+	keyPEM, _ := createTestKeyPEM(t)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Data:       map[string][]byte{"tls.key": bytes.Clone(keyPEM)},
+	}
+
+	opened, err := openSecretKeyPEM(createTestContext(), secret)
+	require.NoError(t, err)
+	opened.wipe()
+
+	assert.Equal(t, keyPEM, secret.Data["tls.key"], "the Secret read from the cache is left alone")
+}
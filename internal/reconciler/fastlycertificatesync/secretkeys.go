@@ -0,0 +1,191 @@
+package fastlycertificatesync
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Default keys of a kubernetes.io/tls secret, as populated by cert-manager
+const (
+	defaultSecretCertificateKey = "tls.crt"
+	defaultSecretPrivateKeyKey  = "tls.key"
+	defaultSecretCAKey          = "ca.crt"
+)
+
+// getSecretKeys returns the secret keys configured on the subject, with defaults filled in
+func getSecretKeys(ctx *Context) v1alpha1.SecretKeys {
+	keys := v1alpha1.SecretKeys{}
+	if ctx.Subject != nil && ctx.Subject.Spec.SecretKeys != nil {
+		keys = *ctx.Subject.Spec.SecretKeys
+	}
+
+	if keys.Certificate == "" {
+		keys.Certificate = defaultSecretCertificateKey
+	}
+	if keys.PrivateKey == "" {
+		keys.PrivateKey = defaultSecretPrivateKeyKey
+	}
+	if keys.CA == "" {
+		keys.CA = defaultSecretCAKey
+	}
+
+	return keys
+}
+
+// getSecretData returns the value of the given key in the secret
+func getSecretData(secret *corev1.Secret, key string) ([]byte, error) {
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s does not contain %s", secret.Namespace, secret.Name, key)
+	}
+	return data, nil
+}
+
+// getSecretCertPEM returns the PEM encoded certificate chain held by the secret, leaf certificate first
+func getSecretCertPEM(ctx *Context, secret *corev1.Secret) ([]byte, error) {
+	keys := getSecretKeys(ctx)
+	var certPEM []byte
+	var err error
+	if keys.PKCS12 == "" {
+		certPEM, err = getSecretData(secret, keys.Certificate)
+	} else {
+		certPEM, _, err = getPKCS12PEMFromSecret(secret, keys)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	certPEM, err = orderCertificateChain(certPEM, func() (func(crypto.PublicKey) bool, error) {
+		return secretKeyMatcher(ctx, secret)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the leaf certificate of secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	return certPEM, nil
+}
+
+// getSecretKeyPEM returns the PEM encoded private key held by the secret, sealed until it is used and masked from
+// logs and error messages once opened
+func getSecretKeyPEM(ctx *Context, secret *corev1.Secret) (*secretBox, error) {
+	keys := getSecretKeys(ctx)
+	if keys.PKCS12 == "" {
+		data, err := getSecretData(secret, keys.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		return sealSecretBox(data)
+	}
+
+	_, keyPEM, err := getPKCS12PEMFromSecret(secret, keys)
+	if err != nil {
+		return nil, err
+	}
+	// The key extracted from the keystore is a copy of the operator's own
+	defer clear(keyPEM)
+	return sealSecretBox(keyPEM)
+}
+
+// getSecretCAPEM returns the PEM encoded CA certificate held by the secret
+func getSecretCAPEM(ctx *Context, secret *corev1.Secret) ([]byte, error) {
+	return getSecretData(secret, getSecretKeys(ctx).CA)
+}
+
+// getPKCS12PEMFromSecret extracts the certificate chain and private key from the secret's PKCS#12 keystore
+func getPKCS12PEMFromSecret(secret *corev1.Secret, keys v1alpha1.SecretKeys) ([]byte, []byte, error) {
+	keystore, err := getSecretData(secret, keys.PKCS12)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	password := ""
+	if keys.PKCS12Password != "" {
+		passwordData, err := getSecretData(secret, keys.PKCS12Password)
+		if err != nil {
+			return nil, nil, err
+		}
+		password = string(bytes.TrimSpace(passwordData))
+	}
+
+	certPEM, keyPEM, err := convertPKCS12ToPEM(keystore, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract %s of secret %s/%s: %w", keys.PKCS12, secret.Namespace, secret.Name, err)
+	}
+
+	return certPEM, keyPEM, nil
+}
+
+// convertPKCS12ToPEM converts a PKCS#12 keystore into a PEM encoded certificate chain and private key.
+// The leaf certificate, the one matching the private key, is placed first in the chain.
+func convertPKCS12ToPEM(keystore []byte, password string) ([]byte, []byte, error) {
+	priv, cert, caCerts, err := pkcs12.DecodeChain(keystore, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode PKCS#12 keystore: %w", err)
+	}
+
+	key, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported private key type in keystore: %T", priv)
+	}
+	keyPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// DecodeChain returns the first certificate of the keystore as the leaf, which need not be the one matching the key
+	var leaf, chain []byte
+	for _, c := range append([]*x509.Certificate{cert}, caCerts...) {
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+		if leaf == nil && publicKeysEqual(c.PublicKey, key.Public()) {
+			leaf = certPEM
+		} else {
+			chain = append(chain, certPEM...)
+		}
+	}
+	if leaf == nil {
+		return nil, nil, fmt.Errorf("keystore does not contain a certificate matching its private key")
+	}
+
+	return append(leaf, chain...), keyPEM, nil
+}
+
+// encodePrivateKeyPEM encodes a private key extracted from a keystore with the PEM type matching its algorithm
+func encodePrivateKeyPEM(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode private key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode private key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+}
+
+// publicKeysEqual compares two public keys by their PKIX encoding
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	aDER, err := x509.MarshalPKIXPublicKey(a)
+	if err != nil {
+		return false
+	}
+	bDER, err := x509.MarshalPKIXPublicKey(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aDER, bDER)
+}
@@ -0,0 +1,234 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// selfCheckRetryInterval is how long SelfCheck waits before checking again after a check failed
+const selfCheckRetryInterval = 30 * time.Second
+
+// selfCheckKinds are the kinds the operator watches, whose CRDs must be installed
+var selfCheckKinds = []schema.GroupVersionKind{
+	v1alpha1.GroupVersion.WithKind("FastlyCertificateSync"),
+	v1alpha1.GroupVersion.WithKind("FastlyConfigStoreSync"),
+	cmv1.SchemeGroupVersion.WithKind("Certificate"),
+}
+
+// selfCheckAccess is the access across all namespaces the operator can't sync anything without
+var selfCheckAccess = []authorizationv1.ResourceAttributes{
+	{Resource: "secrets", Verb: "get"},
+	{Resource: "secrets", Verb: "list"},
+	{Resource: "secrets", Verb: "watch"},
+	{Group: "cert-manager.io", Resource: "certificates", Verb: "get"},
+	{Group: "cert-manager.io", Resource: "certificates", Verb: "list"},
+	{Group: "cert-manager.io", Resource: "certificates", Verb: "watch"},
+}
+
+// +kubebuilder:rbac:groups="authorization.k8s.io",resources=selfsubjectaccessreviews,verbs=create
+
+// SelfCheck checks on startup that the operator is able to sync: its CRDs are installed, its RBAC lets it read
+// Secrets and Certificates, its Fastly tokens are accepted, and the TLS configurations referenced by
+// FastlyCertificateSyncs exist. The results are logged, and Check fails the readiness probe until every check
+// passes, so that a broken deploy is obvious within seconds rather than once the first renewal fails to sync.
+// Failed checks are retried until they pass.
+type SelfCheck struct {
+	Logic *Logic
+	// Reader reads from the API server directly, the cache isn't started on replicas that aren't the leader
+	Reader client.Reader
+	// Client creates the SelfSubjectAccessReviews
+	Client     client.Client
+	RESTMapper meta.RESTMapper
+
+	mu sync.Mutex
+	// results are nil until the checks first completed
+	results []selfCheckResult
+}
+
+// selfCheckResult is the outcome of one of the checks of SelfCheck, err being nil when it passed
+type selfCheckResult struct {
+	name string
+	err  error
+}
+
+// NeedLeaderElection checks from every replica, as each has its own readiness
+func (s *SelfCheck) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the checks, and again every selfCheckRetryInterval until they all pass or the context is cancelled
+func (s *SelfCheck) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("self-check")
+
+	ticker := time.NewTicker(selfCheckRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.run(ctx, log) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// run runs every check, logs and records their results, and returns whether they all passed
+func (s *SelfCheck) run(ctx context.Context, log logr.Logger) bool {
+	results := []selfCheckResult{
+		{name: "crds", err: s.checkCRDs()},
+		{name: "rbac", err: s.checkRBAC(ctx)},
+	}
+	tokenErr, configurationsErr := s.checkFastly(ctx)
+	results = append(results,
+		selfCheckResult{name: "fastly-token", err: tokenErr},
+		selfCheckResult{name: "tls-configurations", err: configurationsErr},
+	)
+
+	passed := true
+	for _, result := range results {
+		if result.err != nil {
+			passed = false
+			log.Error(result.err, "self-check failed", "check", result.name)
+			continue
+		}
+		log.Info("self-check passed", "check", result.name)
+	}
+	if !passed {
+		log.Info("self-check failed, retrying", "retryInterval", selfCheckRetryInterval)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = results
+	return passed
+}
+
+// Check is a healthz.Checker failing until every check passed. Its error, served at /readyz/<name of the check>,
+// details the checks that failed.
+func (s *SelfCheck) Check(_ *http.Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.results == nil {
+		return errors.New("the startup self-check has not completed")
+	}
+
+	var failed []string
+	for _, result := range s.results {
+		if result.err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", result.name, result.err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("the startup self-check failed\n%s", strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// checkCRDs checks that the API server serves the kinds the operator watches
+func (s *SelfCheck) checkCRDs() error {
+	var errs []error
+	for _, gvk := range selfCheckKinds {
+		if _, err := s.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s is not served, is its CRD installed? %w", gvk.GroupKind(), gvk.Version, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkRBAC checks that the operator is allowed selfCheckAccess, with SelfSubjectAccessReviews
+func (s *SelfCheck) checkRBAC(ctx context.Context) error {
+	var errs []error
+	for _, attributes := range selfCheckAccess {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes},
+		}
+		resource := schema.GroupResource{Group: attributes.Group, Resource: attributes.Resource}
+		if err := s.Client.Create(ctx, review); err != nil {
+			errs = append(errs, fmt.Errorf("failed to review access to %s %s: %w", attributes.Verb, resource, err))
+			continue
+		}
+		if !review.Status.Allowed {
+			errs = append(errs, fmt.Errorf("not allowed to %s %s in all namespaces", attributes.Verb, resource))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkFastly lists the TLS configurations of every Fastly account, returning whether any token is rejected, and
+// whether any TLS configuration referenced by the spec.tlsConfigurationIds of a FastlyCertificateSync is missing
+// from its account. Resources with spec.accounts use tokens of their own, and aren't checked.
+func (s *SelfCheck) checkFastly(ctx context.Context) (tokenErr, configurationsErr error) {
+	clients, err := s.Logic.accountClients(ctx, s.Reader)
+	var tokenErrs []error
+	if err != nil {
+		tokenErrs = append(tokenErrs, err)
+	}
+
+	configurations := map[string]map[string]bool{}
+	for _, account := range slices.Sorted(maps.Keys(clients)) {
+		listed, err := listFastlyPages(func(page int) ([]*fastly.CustomTLSConfiguration, error) {
+			return clients[account].ListCustomTLSConfigurations(ctx, &fastly.ListCustomTLSConfigurationsInput{PageNumber: page, PageSize: defaultFastlyPageSize})
+		})
+		if err != nil {
+			tokenErrs = append(tokenErrs, fmt.Errorf("failed to list the TLS configurations of Fastly account %s: %w", account, err))
+			continue
+		}
+		configurations[account] = map[string]bool{}
+		for _, configuration := range listed {
+			configurations[account][configuration.ID] = true
+		}
+	}
+
+	all := v1alpha1.FastlyCertificateSyncList{}
+	if err := s.Reader.List(ctx, &all); err != nil {
+		return errors.Join(tokenErrs...), fmt.Errorf("failed to list FastlyCertificateSyncs: %w", err)
+	}
+
+	var configurationsErrs []error
+	for _, subject := range all.Items {
+		if len(subject.Spec.Accounts) > 0 {
+			continue
+		}
+
+		account := "default"
+		if secretName, ok := s.Logic.Config.FastlyTokenSecretsByNamespace[subject.Namespace]; ok {
+			account = "secret/" + secretName
+		}
+		// Accounts that couldn't be listed are reported as such
+		existing, ok := configurations[account]
+		if !ok {
+			continue
+		}
+
+		for _, id := range subject.Spec.TLSConfigurationIds {
+			if !existing[id] {
+				configurationsErrs = append(configurationsErrs, fmt.Errorf("TLS configuration %s of %s/%s doesn't exist in Fastly account %s",
+					id, subject.Namespace, subject.Name, account))
+			}
+		}
+	}
+
+	return errors.Join(tokenErrs...), errors.Join(configurationsErrs...)
+}
@@ -0,0 +1,147 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly-tls-operator/internal/fastlymock"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// newSelfCheckTestClient returns a client holding objects, which allows access to everything but denied
+func newSelfCheckTestClient(t *testing.T, denied []authorizationv1.ResourceAttributes, objects ...client.Object) client.WithWatch {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+				require.True(t, ok, "unexpected creation of %T", obj)
+				for _, attributes := range denied {
+					if *review.Spec.ResourceAttributes == attributes {
+						return nil
+					}
+				}
+				review.Status.Allowed = true
+				return nil
+			},
+		}).
+		Build()
+}
+
+// newSelfCheckTestRESTMapper returns a RESTMapper serving kinds
+func newSelfCheckTestRESTMapper(kinds ...schema.GroupVersionKind) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range kinds {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	return mapper
+}
+
+func newSelfCheckTestSubject(namespace, name string, tlsConfigurationIds ...string) *v1alpha1.FastlyCertificateSync {
+	return &v1alpha1.FastlyCertificateSync{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.FastlyCertificateSyncSpec{
+			CertificateName:     name,
+			TLSConfigurationIds: tlsConfigurationIds,
+		},
+	}
+}
+
+func TestSelfCheck_Passes(t *testing.T) {
+	defaultClient := fastlymock.NewMockFastlyClient(gomock.NewController(t))
+	defaultClient.EXPECT().ListCustomTLSConfigurations(gomock.Any(), gomock.Any()).
+		Return([]*fastly.CustomTLSConfiguration{{ID: "config1"}, {ID: "config2"}}, nil)
+
+	fakeClient := newSelfCheckTestClient(t, nil, newSelfCheckTestSubject("team-a", "www", "config1", "config2"))
+	selfCheck := &SelfCheck{
+		Logic:      &Logic{FastlyClient: defaultClient},
+		Reader:     fakeClient,
+		Client:     fakeClient,
+		RESTMapper: newSelfCheckTestRESTMapper(selfCheckKinds...),
+	}
+
+	assert.EqualError(t, selfCheck.Check(nil), "the startup self-check has not completed")
+	assert.True(t, selfCheck.run(context.Background(), logr.Discard()))
+	assert.NoError(t, selfCheck.Check(nil))
+}
+
+func TestSelfCheck_ReportsFailedChecks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defaultClient := fastlymock.NewMockFastlyClient(mockCtrl)
+	defaultClient.EXPECT().ListCustomTLSConfigurations(gomock.Any(), gomock.Any()).
+		Return([]*fastly.CustomTLSConfiguration{{ID: "config1"}}, nil)
+	teamClient := fastlymock.NewMockFastlyClient(mockCtrl)
+	teamClient.EXPECT().ListCustomTLSConfigurations(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("401 - Unauthorized"))
+
+	fakeClient := newSelfCheckTestClient(t,
+		[]authorizationv1.ResourceAttributes{{Resource: "secrets", Verb: "watch"}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-b-token", Namespace: "operator"},
+			Data:       map[string][]byte{"api-key": []byte("team-b")},
+		},
+		newSelfCheckTestSubject("team-a", "www", "config1", "config9"),
+		newSelfCheckTestSubject("team-b", "api", "config9"),
+		&v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "multi", Namespace: "team-a"},
+			Spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "multi",
+				Accounts:        []v1alpha1.FastlyAccount{{Name: "other", TLSConfigurationIds: []string{"config9"}}},
+			},
+		},
+	)
+	selfCheck := &SelfCheck{
+		Logic: &Logic{
+			FastlyClient: defaultClient,
+			NewFastlyClient: func(token string) (FastlyClientInterface, error) {
+				assert.Equal(t, "team-b", token)
+				return teamClient, nil
+			},
+			Config: RuntimeConfig{
+				FastlyTokenSecretsByNamespace: map[string]string{"team-b": "team-b-token"},
+				FastlyTokenSecretNamespace:    "operator",
+				FastlyTokenSecretKey:          "api-key",
+			},
+		},
+		Reader: fakeClient,
+		Client: fakeClient,
+		RESTMapper: newSelfCheckTestRESTMapper(
+			v1alpha1.GroupVersion.WithKind("FastlyCertificateSync"),
+			cmv1.SchemeGroupVersion.WithKind("Certificate"),
+		),
+	}
+
+	assert.False(t, selfCheck.run(context.Background(), logr.Discard()))
+
+	err := selfCheck.Check(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "crds: FastlyConfigStoreSync.platform.seatgeek.io v1alpha1 is not served")
+	assert.Contains(t, err.Error(), "rbac: not allowed to watch secrets in all namespaces")
+	assert.NotContains(t, err.Error(), "certificates.cert-manager.io")
+	assert.Contains(t, err.Error(), "fastly-token: failed to list the TLS configurations of Fastly account secret/team-b-token: 401 - Unauthorized")
+	assert.Contains(t, err.Error(), "tls-configurations: TLS configuration config9 of team-a/www doesn't exist in Fastly account default")
+	assert.NotContains(t, err.Error(), "team-b/api", "resources of accounts that couldn't be listed aren't checked")
+	assert.NotContains(t, err.Error(), "team-a/multi", "resources with spec.accounts aren't checked")
+}
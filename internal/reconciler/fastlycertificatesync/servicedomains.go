@@ -0,0 +1,115 @@
+package fastlycertificatesync
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+)
+
+// observeServiceDomains checks that the DNS names of the certificate are domains of the service in spec.serviceId.
+// Having TLS activations doesn't mean traffic for a domain reaches a service, so this catches a common
+// misconfiguration. Failing to check is reported in status rather than failing the sync, as it doesn't affect it.
+func (l *Logic) observeServiceDomains(ctx *Context) {
+	if ctx.Subject.Spec.ServiceID == "" {
+		return
+	}
+
+	missing, err := l.getMissingServiceDomains(ctx)
+	if err != nil {
+		ctx.Log.Error(err, "failed to check service domains", "service_id", ctx.Subject.Spec.ServiceID)
+		l.ObservedState.ServiceDomainsError = err.Error()
+		return
+	}
+
+	l.ObservedState.ServiceDomainsChecked = true
+	l.ObservedState.MissingServiceDomains = missing
+}
+
+// getMissingServiceDomains returns the DNS names of the certificate that are not domains of the active version of
+// the service in spec.serviceId
+func (l *Logic) getMissingServiceDomains(ctx *Context) ([]string, error) {
+	dnsNames, err := getSubjectCertificateDNSNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID := ctx.Subject.Spec.ServiceID
+	service, err := l.fastlyClient().GetService(ctx, &fastly.GetServiceInput{ServiceID: serviceID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Fastly service %s: %w", serviceID, err)
+	}
+	if service.ActiveVersion == nil || *service.ActiveVersion == 0 {
+		return nil, fmt.Errorf("fastly service %s has no active version", serviceID)
+	}
+
+	domains, err := l.fastlyClient().ListDomains(ctx, &fastly.ListDomainsInput{
+		ServiceID:      serviceID,
+		ServiceVersion: *service.ActiveVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains of Fastly service %s: %w", serviceID, err)
+	}
+
+	var serviceDomains []string
+	for _, domain := range domains {
+		if domain.Name != nil {
+			serviceDomains = append(serviceDomains, strings.ToLower(*domain.Name))
+		}
+	}
+
+	var missing []string
+	for _, name := range dnsNames {
+		if !isDomainServed(strings.ToLower(name), serviceDomains) {
+			missing = append(missing, name)
+		}
+	}
+	slices.Sort(missing)
+
+	return missing, nil
+}
+
+// isDomainServed reports whether a DNS name is one of the service's domains, or is covered by one of its wildcard
+// domains
+func isDomainServed(name string, serviceDomains []string) bool {
+	for _, domain := range serviceDomains {
+		if domain == name {
+			return true
+		}
+		// A wildcard domain covers exactly one label, as it does in certificates
+		if suffix, ok := strings.CutPrefix(domain, "*."); ok {
+			if label, rest, found := strings.Cut(name, "."); found && label != "*" && rest == suffix {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getSubjectCertificateDNSNames returns the DNS names of the leaf certificate held by the subject's secret
+func getSubjectCertificateDNSNames(ctx *Context) ([]string, error) {
+	_, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TLS secret from context: %w", err)
+	}
+
+	certPEM, err := getSecretCertPEM(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block of secret %s/%s", secret.Namespace, secret.Name)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate of secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	return cert.DNSNames, nil
+}
@@ -0,0 +1,242 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/internal/fastlymock"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// createServiceDomainsTestContext creates a test context whose certificate secret holds a certificate for dnsNames
+func createServiceDomainsTestContext(t *testing.T, dnsNames ...string) *Context {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+				Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data: map[string][]byte{
+					"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+				},
+			},
+		).
+		Build()
+
+	ctx := createTestContext()
+	ctx.Subject.Spec.ServiceID = "service1"
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	return ctx
+}
+
+// serviceWithDomains mocks service1, whose active version 3 has the given domains
+func serviceWithDomains(t *testing.T, domains ...string) *fastlymock.MockFastlyClient {
+	fastlyClient := fastlymock.NewMockFastlyClient(gomock.NewController(t))
+	fastlyClient.EXPECT().
+		GetService(gomock.Any(), &fastly.GetServiceInput{ServiceID: "service1"}).
+		Return(&fastly.Service{ServiceID: fastly.ToPointer("service1"), ActiveVersion: fastly.ToPointer(3)}, nil)
+
+	var res []*fastly.Domain
+	for _, domain := range domains {
+		res = append(res, &fastly.Domain{Name: fastly.ToPointer(domain)})
+	}
+	fastlyClient.EXPECT().
+		ListDomains(gomock.Any(), &fastly.ListDomainsInput{ServiceID: "service1", ServiceVersion: 3}).
+		Return(res, nil)
+	return fastlyClient
+}
+
+func TestIsDomainServed(t *testing.T) {
+	serviceDomains := []string{"example.com", "*.api.example.com"}
+
+	assert.True(t, isDomainServed("example.com", serviceDomains))
+	assert.True(t, isDomainServed("v1.api.example.com", serviceDomains))
+	assert.True(t, isDomainServed("*.api.example.com", serviceDomains))
+	assert.False(t, isDomainServed("www.example.com", serviceDomains))
+	assert.False(t, isDomainServed("a.v1.api.example.com", serviceDomains), "wildcards cover exactly one label")
+	assert.False(t, isDomainServed("api.example.com", serviceDomains))
+}
+
+func TestLogic_getMissingServiceDomains(t *testing.T) {
+	t.Run("reports_domains_missing_from_service", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "www.example.com", "example.com", "v1.api.example.com")
+		logic := &Logic{FastlyClient: serviceWithDomains(t, "Example.com", "*.api.example.com")}
+
+		missing, err := logic.getMissingServiceDomains(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"www.example.com"}, missing)
+	})
+
+	t.Run("service_without_active_version", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		fastlyClient := fastlymock.NewMockFastlyClient(gomock.NewController(t))
+		fastlyClient.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(&fastly.Service{}, nil)
+		logic := &Logic{FastlyClient: fastlyClient}
+
+		_, err := logic.getMissingServiceDomains(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no active version")
+	})
+}
+
+func TestLogic_observeServiceDomains(t *testing.T) {
+	t.Run("lookup_failure_is_not_fatal", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		fastlyClient := fastlymock.NewMockFastlyClient(gomock.NewController(t))
+		fastlyClient.EXPECT().GetService(gomock.Any(), gomock.Any()).Return(nil, errors.New("service not found"))
+		logic := &Logic{FastlyClient: fastlyClient}
+
+		logic.observeServiceDomains(ctx)
+		assert.False(t, logic.ObservedState.ServiceDomainsChecked)
+		assert.Contains(t, logic.ObservedState.ServiceDomainsError, "service not found")
+	})
+
+	t.Run("skipped_without_service", func(t *testing.T) {
+		ctx := createServiceDomainsTestContext(t, "example.com")
+		ctx.Subject.Spec.ServiceID = ""
+		// Fastly isn't called at all
+		logic := &Logic{FastlyClient: fastlymock.NewMockFastlyClient(gomock.NewController(t))}
+
+		logic.observeServiceDomains(ctx)
+		assert.False(t, logic.ObservedState.ServiceDomainsChecked)
+		assert.Empty(t, logic.ObservedState.ServiceDomainsError)
+	})
+}
+
+func TestLogic_observeServiceDomainMissingCondition(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceID      string
+		state          ObservedState
+		previous       []metav1.Condition
+		expectedNil    bool
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:        "omitted_without_service",
+			expectedNil: true,
+		},
+		{
+			name:           "domains_missing",
+			serviceID:      "service1",
+			state:          ObservedState{ServiceDomainsChecked: true, MissingServiceDomains: []string{"www.example.com"}},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "DomainsNotAttached",
+		},
+		{
+			name:           "domains_attached",
+			serviceID:      "service1",
+			state:          ObservedState{ServiceDomainsChecked: true},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "DomainsAttached",
+		},
+		{
+			name:           "lookup_failed",
+			serviceID:      "service1",
+			state:          ObservedState{ServiceDomainsError: "service not found"},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ServiceLookupFailed",
+		},
+		{
+			name:      "carried_over_when_not_checked",
+			serviceID: "service1",
+			previous: []metav1.Condition{{
+				Type:   "ServiceDomainMissing",
+				Status: metav1.ConditionTrue,
+				Reason: "DomainsNotAttached",
+			}},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "DomainsNotAttached",
+		},
+		{
+			name:           "not_checked_yet",
+			serviceID:      "service1",
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ServiceDomainsNotObserved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Subject.Spec.ServiceID = tt.serviceID
+			ctx.Subject.Status.Conditions = tt.previous
+			logic := &Logic{ObservedState: tt.state}
+
+			condition, err := logic.observeServiceDomainMissingCondition(ctx)
+			require.NoError(t, err)
+			if tt.expectedNil {
+				assert.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			assert.Equal(t, tt.expectedStatus, condition.Status)
+			assert.Equal(t, tt.expectedReason, condition.Reason)
+		})
+	}
+}
+
+func TestLogic_FillStatus_ServiceDomainMissing(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Spec.ServiceID = "service1"
+	logic := &Logic{
+		SubjectReadyForReconciliation: true,
+		ObservedState: ObservedState{
+			CertificateSourceReady: true,
+			PrivateKeyUploaded:     true,
+			CertificateStatus:      CertificateStatusSynced,
+			ServiceDomainsChecked:  true,
+			MissingServiceDomains:  []string{"www.example.com"},
+		},
+	}
+
+	require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+
+	condition := apimeta.FindStatusCondition(ctx.Subject.Status.Conditions, "ServiceDomainMissing")
+	require.NotNil(t, condition)
+	assert.Equal(t, "DomainsNotAttached", condition.Reason)
+	assert.Contains(t, condition.Message, "www.example.com")
+	assert.True(t, ctx.Subject.Status.Ready, "a domain missing from the service doesn't hold back the sync")
+}
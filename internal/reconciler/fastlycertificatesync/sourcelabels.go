@@ -0,0 +1,61 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FastlyCertificateSyncLabel is set on the source Certificate and Secret to the name of the FastlyCertificateSync in
+// their namespace syncing them, with RuntimeConfig.LabelSources, so that they can be looked up by label selector
+const FastlyCertificateSyncLabel = "platform.seatgeek.io/fastly-certificate-sync"
+
+// labelSources labels the source Certificate and Secret with the name of the subject syncing them. As with the sync
+// results, sources in another namespace are never labeled, and a source shared by several subjects keeps the label of
+// the first one for as long as that subject exists.
+func (l *Logic) labelSources(ctx *Context) error {
+	if !ctx.Config.LabelSources {
+		return nil
+	}
+
+	certificate, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get TLS secret from context: %w", err)
+	}
+	if certificate.Namespace != ctx.Subject.Namespace {
+		return nil
+	}
+
+	name := ctx.Subject.Name
+	if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+		ctx.Log.V(1).Info("name is not a valid label value, sources are not labeled", "errors", errs)
+		return nil
+	}
+
+	for _, obj := range []client.Object{certificate, secret} {
+		labels := obj.GetLabels()
+		owner := labels[FastlyCertificateSyncLabel]
+		if owner == name {
+			continue
+		}
+		if owner != "" && l.syncResultsOwnerExists(ctx, ctx.Subject.Namespace+"/"+owner) {
+			ctx.Log.V(1).Info("source is labeled by another FastlyCertificateSync, skipping", "kind", fmt.Sprintf("%T", obj), "name", obj.GetName(), "labeled_by", owner)
+			continue
+		}
+
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[FastlyCertificateSyncLabel] = name
+		obj.SetLabels(labels)
+
+		ctx.Log.Info("labeling source", "kind", fmt.Sprintf("%T", obj), "name", obj.GetName())
+		if err := ctx.Client.Client.Patch(ctx, obj, patch); err != nil {
+			return fmt.Errorf("failed to label %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLogic_labelSources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-certificate",
+				Namespace: "test-namespace",
+				Labels:    map[string]string{"team": "web"},
+			},
+			Spec: cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-secret",
+				Namespace: "test-namespace",
+				Labels:    map[string]string{FastlyCertificateSyncLabel: "other-sync"},
+			},
+			Data: map[string][]byte{"tls.crt": []byte("test-cert-data"), "tls.key": []byte("test-key-data")},
+		},
+		&v1alpha1.FastlyCertificateSync{ObjectMeta: metav1.ObjectMeta{Name: "other-sync", Namespace: "test-namespace"}},
+	).Build()
+
+	ctx := createTestContext()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+	get := func(obj client.Object, name string) {
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "test-namespace"}, obj))
+	}
+
+	// Nothing is labeled unless enabled
+	require.NoError(t, (&Logic{}).labelSources(ctx))
+	certificate := &cmv1.Certificate{}
+	get(certificate, "test-certificate")
+	assert.NotContains(t, certificate.Labels, FastlyCertificateSyncLabel)
+
+	ctx.Config.LabelSources = true
+	require.NoError(t, (&Logic{}).labelSources(ctx))
+
+	get(certificate, "test-certificate")
+	assert.Equal(t, map[string]string{"team": "web", FastlyCertificateSyncLabel: "test-cert-sync"}, certificate.Labels)
+
+	secret := &corev1.Secret{}
+	get(secret, "test-secret")
+	assert.Equal(t, "other-sync", secret.Labels[FastlyCertificateSyncLabel], "sources keep the label of an existing subject")
+
+	// The label is taken over once the subject labeling the source is gone
+	require.NoError(t, fakeClient.Delete(context.Background(), &v1alpha1.FastlyCertificateSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-sync", Namespace: "test-namespace"},
+	}))
+	require.NoError(t, (&Logic{}).labelSources(ctx))
+	get(secret, "test-secret")
+	assert.Equal(t, "test-cert-sync", secret.Labels[FastlyCertificateSyncLabel])
+
+	// Labeled sources are left untouched
+	resourceVersion := secret.ResourceVersion
+	require.NoError(t, (&Logic{}).labelSources(ctx))
+	get(secret, "test-secret")
+	assert.Equal(t, resourceVersion, secret.ResourceVersion)
+}
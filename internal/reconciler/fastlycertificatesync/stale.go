@@ -0,0 +1,53 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const staleTooLongConditionType = "StaleTooLong"
+
+// trackStaleSince records when the certificate in Fastly was first observed to be out of date, and forgets it once
+// the certificate is in sync again
+func trackStaleSince(status *v1alpha1.FastlyCertificateSyncStatus, certificateStatus CertificateStatus) {
+	switch certificateStatus {
+	case CertificateStatusStale, CertificateStatusMissing:
+		if status.StaleSince == nil {
+			now := kmetav1.Now()
+			status.StaleSince = &now
+		}
+	case CertificateStatusSynced:
+		status.StaleSince = nil
+	}
+}
+
+// observeStaleTooLongCondition generates the condition for a certificate that has remained stale or missing in Fastly
+// for longer than the stale threshold, separating subjects that are stuck from those that are only catching up. It is
+// omitted when the threshold is disabled.
+func (l *Logic) observeStaleTooLongCondition(ctx *Context) (*kmetav1.Condition, error) {
+	threshold := ctx.Config.StaleThreshold
+	if ctx.Subject == nil || threshold <= 0 {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type: staleTooLongConditionType,
+	}
+
+	staleSince := ctx.Subject.Status.StaleSince
+	if staleSince != nil && time.Since(staleSince.Time) >= threshold {
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "StaleThresholdExceeded"
+		condition.Message = fmt.Sprintf("Certificate has been stale or missing in Fastly since %s, for longer than %s",
+			staleSince.UTC().Format(time.RFC3339), threshold)
+	} else {
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "WithinStaleThreshold"
+		condition.Message = fmt.Sprintf("Certificate has not been stale or missing in Fastly for longer than %s", threshold)
+	}
+
+	return condition, nil
+}
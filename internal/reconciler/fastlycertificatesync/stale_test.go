@@ -0,0 +1,106 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestTrackStaleSince(t *testing.T) {
+	status := &v1alpha1.FastlyCertificateSyncStatus{}
+
+	trackStaleSince(status, CertificateStatusMissing)
+	require.NotNil(t, status.StaleSince)
+	assert.WithinDuration(t, time.Now(), status.StaleSince.Time, 5*time.Second)
+
+	// The time the certificate was first seen out of date is kept while it stays that way
+	first := metav1.NewTime(time.Now().Add(-time.Hour))
+	status.StaleSince = &first
+	trackStaleSince(status, CertificateStatusStale)
+	assert.Equal(t, &first, status.StaleSince)
+
+	trackStaleSince(status, CertificateStatusSynced)
+	assert.Nil(t, status.StaleSince)
+}
+
+func TestLogic_observeStaleTooLongCondition(t *testing.T) {
+	ctx := createTestContext()
+	logic := &Logic{}
+
+	// Disabled without a threshold
+	condition, err := logic.observeStaleTooLongCondition(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, condition)
+
+	ctx.Config.StaleThreshold = 2 * time.Hour
+	tests := []struct {
+		name           string
+		staleSince     *metav1.Time
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "in sync",
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "WithinStaleThreshold",
+		},
+		{
+			name:           "catching up",
+			staleSince:     &metav1.Time{Time: time.Now().Add(-time.Hour)},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "WithinStaleThreshold",
+		},
+		{
+			name:           "stuck",
+			staleSince:     &metav1.Time{Time: time.Now().Add(-3 * time.Hour)},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "StaleThresholdExceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx.Subject.Status.StaleSince = tt.staleSince
+
+			condition, err := logic.observeStaleTooLongCondition(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, condition)
+			assert.Equal(t, staleTooLongConditionType, condition.Type)
+			assert.Equal(t, tt.expectedStatus, condition.Status)
+			assert.Equal(t, tt.expectedReason, condition.Reason)
+		})
+	}
+}
+
+func TestReconcileComplete_StaleTooLongMetric(t *testing.T) {
+	ctx := createTestContext()
+	ctx.NamespacedName = types.NamespacedName{Namespace: "stale-namespace", Name: "stale-cert-sync"}
+	t.Cleanup(func() { deleteSubjectMetrics(ctx) })
+
+	value := func() float64 {
+		t.Helper()
+		metric := &dto.Metric{}
+		require.NoError(t, staleTooLong.WithLabelValues("stale-namespace", "stale-cert-sync").Write(metric))
+		return metric.GetGauge().GetValue()
+	}
+
+	ctx.Subject.Status.Conditions = []metav1.Condition{{Type: staleTooLongConditionType, Status: metav1.ConditionTrue}}
+	(&Logic{}).ReconcileComplete(ctx, genrec.Okay, nil)
+	assert.Equal(t, 1.0, value())
+
+	ctx.Subject.Status.Conditions = []metav1.Condition{{Type: staleTooLongConditionType, Status: metav1.ConditionFalse}}
+	(&Logic{}).ReconcileComplete(ctx, genrec.Okay, nil)
+	assert.Equal(t, 0.0, value())
+
+	// Deleted subjects drop the series
+	(&Logic{}).ReconcileComplete(ctx, genrec.SubjectNotFound, nil)
+	assert.Equal(t, 0, staleTooLong.DeletePartialMatch(prometheus.Labels{"name": "stale-cert-sync"}))
+}
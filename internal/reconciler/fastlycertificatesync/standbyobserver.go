@@ -0,0 +1,178 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	standbyResultSynced   = "synced"
+	standbyResultPending  = "pending"
+	standbyResultNotReady = "not_ready"
+	standbyResultInvalid  = "invalid"
+	standbyResultError    = "error"
+)
+
+var standbyObservations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "fastly_certificate_sync_standby_observations",
+	Help: "FastlyCertificateSyncs observed by a standby replica in its last pass, by the result of the observation",
+}, []string{"result"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(standbyObservations)
+}
+
+// StandbyObserver runs read-only observations of every FastlyCertificateSync on replicas that don't hold the leader
+// lease, so that the Fastly inventory cache is warm when one of them takes over, and so that standbys report what
+// the leader would find. It stops once the replica is elected, leaving the reconciles to the controller.
+type StandbyObserver struct {
+	Logic      *Logic
+	Reconciler *genrec.Reconciler[*v1alpha1.FastlyCertificateSync, *Config]
+	Interval   time.Duration
+	// Elected is closed once the replica becomes the leader
+	Elected <-chan struct{}
+}
+
+// NeedLeaderElection runs the observer on every replica, it stops by itself on the leader
+func (o *StandbyObserver) NeedLeaderElection() bool {
+	return false
+}
+
+// Start observes every FastlyCertificateSync each interval until the replica is elected or the context is cancelled
+func (o *StandbyObserver) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("fastly-standby-observer")
+	// The leader doesn't report standby observations
+	defer standbyObservations.Reset()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-o.Elected:
+			log.Info("elected leader, stopping standby observations")
+			return nil
+		default:
+		}
+
+		o.observe(ctx, log)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-o.Elected:
+			log.Info("elected leader, stopping standby observations")
+			return nil
+		case <-time.After(o.Interval):
+		}
+	}
+}
+
+// observe warms up the Fastly inventory of each account and observes every subject the leader would reconcile
+func (o *StandbyObserver) observe(ctx context.Context, log logr.Logger) {
+	if o.Logic.Config.FastlyInventoryCacheTTL > 0 {
+		o.Logic.warmFastlyInventory(ctx, o.Reconciler.Client, log.V(1))
+	}
+
+	all := v1alpha1.FastlyCertificateSyncList{}
+	if err := o.Reconciler.Client.List(ctx, &all); err != nil {
+		log.Error(err, "failed to list FastlyCertificateSyncs")
+		return
+	}
+
+	// A separate Logic holds the observed state, the leader's Logic may start reconciling at any time
+	observer := o.observerLogic()
+	results := map[string]int{
+		standbyResultSynced:   0,
+		standbyResultPending:  0,
+		standbyResultNotReady: 0,
+		standbyResultInvalid:  0,
+		standbyResultError:    0,
+	}
+	for i := range all.Items {
+		subject := &all.Items[i]
+		if !o.Logic.isEnqueueable(subject) {
+			continue
+		}
+		results[observer.observeStandby(o.newContext(ctx, subject, log))]++
+	}
+
+	for result, n := range results {
+		standbyObservations.WithLabelValues(result).Set(float64(n))
+	}
+}
+
+// observerLogic returns a Logic that observes with the same configuration and Fastly accounts as the leader's, and
+// fills the inventory cache that the leader's reconciles are served from
+func (o *StandbyObserver) observerLogic() *Logic {
+	return &Logic{
+		ResourceManager: o.Logic.ResourceManager,
+		Config:          o.Logic.Config,
+		FastlyClient:    o.Logic.FastlyClient,
+		NewFastlyClient: o.Logic.NewFastlyClient,
+		sharedInventory: o.Logic.inventoryCache(),
+	}
+}
+
+// newContext builds the Context the reconciler would observe the subject with, without an event recorder
+func (o *StandbyObserver) newContext(ctx context.Context, subject *v1alpha1.FastlyCertificateSync, log logr.Logger) *Context {
+	nn := types.NamespacedName{Name: subject.Name, Namespace: subject.Namespace}
+	return &Context{
+		NamespacedName: nn,
+		Context:        ctx,
+		Subject:        subject,
+		Owner:          o.Reconciler,
+		Config:         o.Logic.GetConfig(nn),
+		Log:            log.WithValues("namespace", subject.Namespace, "name", subject.Name).V(1),
+		Started:        time.Now(),
+		Client: &k8sutil.ContextClient{
+			SchemedClient: o.Reconciler.Client,
+			Context:       ctx,
+			Namespace:     nn.Namespace,
+		},
+	}
+}
+
+// observeStandby validates and observes the subject without changing anything, and returns the result
+func (l *Logic) observeStandby(ctx *Context) (result string) {
+	// Unlike reconciles, standby observations have no controller to recover from panics, nor to log them
+	defer func() {
+		if r := recover(); r != nil {
+			reconcilePanicsTotal.WithLabelValues(ctx.Namespace, reconcilePhaseStandby).Inc()
+			ctx.Log.Error(fmt.Errorf("%v", r), "standby observation panicked", "stack", string(debug.Stack()))
+			result = standbyResultError
+		}
+	}()
+
+	if err := l.FillDefaults(ctx); err != nil {
+		return standbyResultError
+	}
+	if err := l.Validate(ctx.Subject); err != nil {
+		return standbyResultInvalid
+	}
+	if _, err := l.ObserveResources(ctx); err != nil {
+		ctx.Log.Info("standby observation failed", "error", err.Error())
+		return standbyResultError
+	}
+
+	switch {
+	case l.ObservedState.InvalidInput != "", l.ObservedState.LocalCertificateInvalid != "":
+		return standbyResultInvalid
+	case !l.SubjectReadyForReconciliation:
+		return standbyResultNotReady
+	case l.ObservedState.isSynced():
+		return standbyResultSynced
+	default:
+		return standbyResultPending
+	}
+}
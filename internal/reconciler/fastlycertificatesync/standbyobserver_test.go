@@ -0,0 +1,133 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/go-logr/logr"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newStandbyObserver(t *testing.T, fastlyClient *MockFastlyClient) *StandbyObserver {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, cmv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing-certificate", Namespace: "test-namespace"},
+			Spec:       v1alpha1.FastlyCertificateSyncSpec{CertificateName: "does-not-exist"},
+		},
+		&v1alpha1.FastlyCertificateSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "suspended", Namespace: "test-namespace"},
+			Spec:       v1alpha1.FastlyCertificateSyncSpec{CertificateName: "does-not-exist", Suspend: true},
+		},
+	).Build()
+
+	logic := &Logic{
+		ResourceManager: ResourceManager,
+		Config:          RuntimeConfig{FastlyInventoryCacheTTL: time.Minute},
+		FastlyClient:    fastlyClient,
+	}
+	return &StandbyObserver{
+		Logic: logic,
+		Reconciler: &genrec.Reconciler[*v1alpha1.FastlyCertificateSync, *Config]{
+			Logic:  logic,
+			Client: k8sutil.SchemedClient{Client: fakeClient, Scheme: scheme},
+		},
+		Interval: time.Minute,
+	}
+}
+
+func TestStandbyObserver_observe(t *testing.T) {
+	certsListed := 0
+	observer := newStandbyObserver(t, &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			certsListed++
+			return []*fastly.CustomTLSCertificate{{ID: "cert-id"}}, nil
+		},
+		ListPrivateKeysFunc: func(ctx context.Context, input *fastly.ListPrivateKeysInput) ([]*fastly.PrivateKey, error) {
+			return nil, nil
+		},
+	})
+	defer standbyObservations.Reset()
+
+	observer.observe(context.Background(), logr.Discard())
+
+	assert.Equal(t, float64(1), gaugeValue(t, standbyObservations, standbyResultNotReady))
+	assert.Equal(t, float64(0), gaugeValue(t, standbyObservations, standbyResultSynced), "suspended subjects are skipped")
+	assert.Equal(t, 1, certsListed)
+
+	// The leader's reconciles are served from the inventory the standby warmed up
+	ctx := createTestContext()
+	ctx.Config.RuntimeConfig = observer.Logic.Config
+	certs, err := observer.Logic.getFastlyCertificates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, certs, 1)
+	assert.Equal(t, 1, certsListed)
+
+	// Observations don't leave state behind in the leader's Logic
+	assert.False(t, observer.Logic.SubjectReadyForReconciliation)
+	assert.Equal(t, ObservedState{}, observer.Logic.ObservedState)
+}
+
+func TestStandbyObserver_observe_PartitionHandOver(t *testing.T) {
+	observer := newStandbyObserver(t, &MockFastlyClient{})
+	observer.Logic.Config.PartitionAnnotation = "platform.seatgeek.io/partition"
+	defer standbyObservations.Reset()
+
+	fakeClient := observer.Reconciler.Client.Client
+	key := types.NamespacedName{Name: "missing-certificate", Namespace: "test-namespace"}
+	subject := &v1alpha1.FastlyCertificateSync{}
+	require.NoError(t, fakeClient.Get(context.Background(), key, subject))
+	subject.Spec.Partition = "canary"
+	require.NoError(t, fakeClient.Update(context.Background(), subject))
+
+	assert.NotPanics(t, func() { observer.observe(context.Background(), logr.Discard()) })
+
+	require.NoError(t, fakeClient.Get(context.Background(), key, subject))
+	assert.Empty(t, subject.Annotations, "standbys leave the hand over to the leader")
+}
+
+func TestStandbyObserver_Start_Elected(t *testing.T) {
+	observer := newStandbyObserver(t, &MockFastlyClient{
+		ListCustomTLSCertificatesFunc: func(ctx context.Context, input *fastly.ListCustomTLSCertificatesInput) ([]*fastly.CustomTLSCertificate, error) {
+			t.Error("the leader doesn't observe as a standby")
+			return nil, nil
+		},
+	})
+	elected := make(chan struct{})
+	close(elected)
+	observer.Elected = elected
+
+	require.NoError(t, observer.Start(context.Background()))
+	assert.Equal(t, 0, countSeries(standbyObservations))
+}
+
+func TestLogic_observeStandby_Panic(t *testing.T) {
+	logic := &Logic{}
+	// Looking the Certificate up without a client panics
+	ctx := createTestContext()
+	ctx.Client = nil
+	ctx.NamespacedName = types.NamespacedName{Namespace: "test-namespace", Name: "test-cert-sync"}
+
+	before := counterValue(t, reconcilePanicsTotal.WithLabelValues("test-namespace", reconcilePhaseStandby))
+	assert.NotPanics(t, func() {
+		assert.Equal(t, standbyResultError, logic.observeStandby(ctx))
+	})
+	assert.Equal(t, before+1, counterValue(t, reconcilePanicsTotal.WithLabelValues("test-namespace", reconcilePhaseStandby)))
+}
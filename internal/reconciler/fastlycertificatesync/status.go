@@ -2,7 +2,11 @@ package fastlycertificatesync
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
 	"github.com/seatgeek/k8s-reconciler-generic/apiobjects"
 	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
@@ -10,29 +14,125 @@ import (
 )
 
 func (l *Logic) FillStatus(ctx *Context, obs genrec.Resources, ss apiobjects.SubjectStatus) error {
+	defer recoverPanic(ctx, reconcilePhaseFillStatus)
+
+	// The status is left to the operator of the partition the subject was handed over to
+	if l.ObservedState.PartitionHandedOver {
+		return nil
+	}
+
 	res := &(ctx.Subject.Status)
 	res.SubjectStatus = ss
+	// The source Certificate and Secret aren't managed resources, so report their issues alongside
+	res.Issues = append(res.Issues, l.ObservedState.SourceIssues...)
 
 	ctx.Log.Info("filling status")
 
+	// Activation results are only of interest until every activation exists
+	if l.SubjectReadyForReconciliation && len(l.ObservedState.MissingTLSActivationData) == 0 {
+		res.TLSActivationResults = nil
+	}
+
+	// Selected configurations are carried over from the last time they were discovered, such as during quick drift checks
+	if l.ObservedState.TLSConfigurationsSelected {
+		res.SelectedTLSConfigurationIds = l.ObservedState.SelectedTLSConfigurationIDs
+	} else if ctx.Subject.Spec.TLSConfigurationSelector == nil {
+		res.SelectedTLSConfigurationIds = nil
+	}
+
+	if l.ObservedState.PrivateKeyPublicKeySHA1 != "" {
+		res.PrivateKeyPublicKeySHA1 = l.ObservedState.PrivateKeyPublicKeySHA1
+	}
+	if l.ObservedState.PrivateKeyPublicKeySHA256 != "" {
+		res.PrivateKeyPublicKeySHA256 = l.ObservedState.PrivateKeyPublicKeySHA256
+	}
+
+	// Track the certificate the operator created or adopted, so that it is recognized once it goes stale
+	if cert := l.ObservedState.FastlyCertificate; cert != nil && !l.ObservedState.CertificateAdoptionRequired && len(ctx.Subject.Spec.Accounts) == 0 {
+		res.CertificateID = cert.ID
+	}
+
+	// The certificate in Fastly is carried over while Fastly isn't observed
+	if len(ctx.Subject.Spec.Accounts) > 0 {
+		res.FastlyCertificate = nil
+	} else if l.SubjectReadyForReconciliation {
+		res.FastlyCertificate = describeFastlyCertificate(l.ObservedState.FastlyCertificate)
+	}
+
+	if l.SubjectReadyForReconciliation {
+		trackStaleSince(res, l.ObservedState.CertificateStatus)
+	}
+
+	// Rollbacks are only tracked for as long as they are requested
+	if ctx.Subject.Spec.RollbackToSerial == "" {
+		res.Rollback = nil
+	}
+
 	// Consider the FastlyCertificateSync ready when all observed state results in no actions.
-	res.Ready = l.ObservedState.PrivateKeyUploaded &&
-		l.ObservedState.CertificateStatus == CertificateStatusSynced &&
-		len(l.ObservedState.MissingTLSActivationData) == 0 &&
-		len(l.ObservedState.ExtraTLSActivationIDs) == 0 &&
-		len(l.ObservedState.UnusedPrivateKeyIDs) == 0
+	res.Ready = l.ObservedState.isSynced()
+
+	if l.SubjectReadyForReconciliation {
+		res.Accounts = l.accountStatuses(ctx)
+	}
+
+	// Activations by configuration are carried over when Fastly wasn't fully observed, such as during quick drift checks
+	if l.SubjectReadyForReconciliation && !l.ObservedState.QuickDriftChecked {
+		res.TLSConfigurations = l.tlsConfigurationStatuses(ctx)
+	}
+
+	l.fillDebugStatus(ctx)
 
 	return l.FillStatusConditions(ctx,
-		l.observePrivateKeyReadyCondition,
-		l.observeCertificateReadyCondition,
-		l.observeTLSActivationReadyCondition,
-		l.observeCleanupRequiredCondition,
+		l.observeCertificateSourceReadyCondition,
+		l.observeInvalidInputCondition,
+		l.observeLocalCertificateInvalidCondition,
+		l.keptFromLastObservation(l.observePrivateKeyReadyCondition),
+		l.keptFromLastObservation(l.observeCertificateReadyCondition),
+		l.keptFromLastObservation(l.observeTLSActivationReadyCondition),
+		l.observeMutationBudgetExceededCondition,
+		l.observeMutationsPausedCondition,
+		l.observeSuspendedCondition,
+		l.observeServiceDomainMissingCondition,
+		l.observeDomainsCoveredCondition,
+		l.observeDomainVerifiedCondition,
+		l.observeCACertificateAvailableCondition,
+		l.observeQuotaNearLimitCondition,
+		l.observeConflictingWriterCondition,
+		l.observeRolledBackCondition,
+		l.observeCertificateInUseCondition,
+		l.observeDeletionProtectedCondition,
+		l.observeStaleTooLongCondition,
 		l.observeReadyCondition,
 	)
 }
 
+// describeFastlyCertificate reports the certificate Fastly serves, so that it can be compared to the local one
+func describeFastlyCertificate(cert *fastly.CustomTLSCertificate) *v1alpha1.FastlyCertificateStatus {
+	if cert == nil {
+		return nil
+	}
+
+	res := &v1alpha1.FastlyCertificateStatus{
+		SerialNumber: cert.SerialNumber,
+		Issuer:       cert.Issuer,
+		SANCount:     len(cert.Domains),
+	}
+	if cert.NotAfter != nil {
+		notAfter := kmetav1.NewTime(*cert.NotAfter)
+		res.NotAfter = &notAfter
+	}
+	return res
+}
+
 func (l *Logic) FillStatusConditions(ctx *Context, conditionGeneratorFuncs ...func(ctx *Context) (*kmetav1.Condition, error)) error {
-	ctx.Subject.Status.Conditions = []kmetav1.Condition{}
+	ctx.Subject.Status.Conditions = l.generateStatusConditions(ctx, conditionGeneratorFuncs...)
+
+	return nil
+}
+
+// generateStatusConditions runs the condition generators, skipping conditions that don't apply
+func (l *Logic) generateStatusConditions(ctx *Context, conditionGeneratorFuncs ...func(ctx *Context) (*kmetav1.Condition, error)) []kmetav1.Condition {
+	conditions := []kmetav1.Condition{}
 
 	for _, fn := range conditionGeneratorFuncs {
 		cnd, err := fn(ctx)
@@ -42,10 +142,55 @@ func (l *Logic) FillStatusConditions(ctx *Context, conditionGeneratorFuncs ...fu
 		if cnd == nil {
 			continue
 		}
-		_ = apimeta.SetStatusCondition(&ctx.Subject.Status.Conditions, *cnd)
+		_ = apimeta.SetStatusCondition(&conditions, *cnd)
 	}
 
-	return nil
+	return conditions
+}
+
+// observeCertificateSourceReadyCondition generates the condition for the readiness of the referenced cert-manager Certificate
+func (l *Logic) observeCertificateSourceReadyCondition(ctx *Context) (*kmetav1.Condition, error) {
+	condition := &kmetav1.Condition{
+		Type:    "CertificateSourceReady",
+		Reason:  l.ObservedState.CertificateSourceReason,
+		Message: l.ObservedState.CertificateSourceMessage,
+	}
+
+	if l.ObservedState.CertificateSourceReady {
+		condition.Status = kmetav1.ConditionTrue
+	} else {
+		condition.Status = kmetav1.ConditionFalse
+	}
+
+	if condition.Reason == "" {
+		condition.Reason = "CertificateNotObserved"
+		condition.Message = "Certificate has not been observed yet"
+	}
+
+	return condition, nil
+}
+
+// observeInvalidInputCondition generates the condition for certificate input that Fastly would reject
+func (l *Logic) observeInvalidInputCondition(ctx *Context) (*kmetav1.Condition, error) {
+	condition := &kmetav1.Condition{
+		Type: "InvalidInput",
+	}
+
+	if l.ObservedState.InvalidInput != "" {
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "InputRejected"
+		condition.Message = l.ObservedState.InvalidInput
+	} else if !l.SubjectReadyForReconciliation {
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "InputNotObserved"
+		condition.Message = "Certificate input has not been validated yet"
+	} else {
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "InputValid"
+		condition.Message = "Certificate and private key are within Fastly's limits"
+	}
+
+	return condition, nil
 }
 
 // observePrivateKeyReadyCondition generates the condition for private key upload status
@@ -54,11 +199,16 @@ func (l *Logic) observePrivateKeyReadyCondition(ctx *Context) (*kmetav1.Conditio
 		Type: "PrivateKeyReady",
 	}
 
-	if l.ObservedState.PrivateKeyUploaded {
+	switch {
+	case l.ObservedState.PrivateKeyUploaded:
 		condition.Status = kmetav1.ConditionTrue
 		condition.Reason = "PrivateKeyUploaded"
 		condition.Message = "Private key has been successfully uploaded to Fastly"
-	} else {
+	case ctx.Subject.IsPrivateKeyExternal():
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "ExternalPrivateKeyMissing"
+		condition.Message = fmt.Sprintf("Private key is managed externally and must be uploaded to Fastly, no private key with public key SHA1 %s exists", l.ObservedState.PrivateKeyPublicKeySHA1)
+	default:
 		condition.Status = kmetav1.ConditionFalse
 		condition.Reason = "PrivateKeyMissing"
 		condition.Message = "Private key needs to be uploaded to Fastly"
@@ -82,6 +232,10 @@ func (l *Logic) observeCertificateReadyCondition(ctx *Context) (*kmetav1.Conditi
 		condition.Status = kmetav1.ConditionFalse
 		condition.Reason = "CertificateStale"
 		condition.Message = "Certificate exists in Fastly but is stale and needs to be updated"
+		if l.ObservedState.CertificateAdoptionRequired {
+			condition.Reason = "NeedsAdoption"
+			condition.Message = fmt.Sprintf("Certificate exists in Fastly but was not created by the operator, set spec.adoptExisting or the %s annotation to update it", AdoptFastlyCertificateAnnotation)
+		}
 	case CertificateStatusMissing:
 		condition.Status = kmetav1.ConditionFalse
 		condition.Reason = "CertificateMissing"
@@ -105,6 +259,13 @@ func (l *Logic) observeTLSActivationReadyCondition(ctx *Context) (*kmetav1.Condi
 		condition.Status = kmetav1.ConditionFalse
 		condition.Reason = "TLSActivationsMissing"
 		condition.Message = fmt.Sprintf("Missing %d TLS activations that need to be created", len(l.ObservedState.MissingTLSActivationData))
+		if unverified := l.countUnverifiedTLSActivations(); unverified > 0 {
+			condition.Reason = "DomainsUnverified"
+			condition.Message += fmt.Sprintf(", %d of which are for domains that aren't verified in Fastly, see the DomainVerified condition", unverified)
+		} else if failed := countFailedTLSActivations(ctx.Subject.Status.TLSActivationResults, l.accountName()); failed > 0 {
+			condition.Reason = "TLSActivationsFailed"
+			condition.Message += fmt.Sprintf(", %d failed on the last attempt, see status.tlsActivationResults", failed)
+		}
 	} else if len(l.ObservedState.ExtraTLSActivationIDs) > 0 {
 		condition.Status = kmetav1.ConditionFalse
 		condition.Reason = "TLSActivationsExtra"
@@ -118,20 +279,20 @@ func (l *Logic) observeTLSActivationReadyCondition(ctx *Context) (*kmetav1.Condi
 	return condition, nil
 }
 
-// observeCleanupRequiredCondition generates the condition for cleanup requirements
-func (l *Logic) observeCleanupRequiredCondition(ctx *Context) (*kmetav1.Condition, error) {
+// observeMutationBudgetExceededCondition generates the condition for deferred changes due to the mutation budget
+func (l *Logic) observeMutationBudgetExceededCondition(ctx *Context) (*kmetav1.Condition, error) {
 	condition := &kmetav1.Condition{
-		Type: "CleanupRequired",
+		Type: "BudgetExceeded",
 	}
 
-	if len(l.ObservedState.UnusedPrivateKeyIDs) > 0 {
+	if l.ObservedState.MutationBudgetExceeded {
 		condition.Status = kmetav1.ConditionTrue
-		condition.Reason = "UnusedPrivateKeysFound"
-		condition.Message = fmt.Sprintf("Found %d unused private keys that should be cleaned up", len(l.ObservedState.UnusedPrivateKeyIDs))
+		condition.Reason = "MutationBudgetExceeded"
+		condition.Message = fmt.Sprintf("Fastly write budget is exhausted, deferring changes for %s", l.ObservedState.MutationBudgetRetryAfter.Round(time.Second))
 	} else {
 		condition.Status = kmetav1.ConditionFalse
-		condition.Reason = "NoCleanupNeeded"
-		condition.Message = "No unused private keys found"
+		condition.Reason = "WithinMutationBudget"
+		condition.Message = "Fastly write operations are within budget"
 	}
 
 	return condition, nil
@@ -143,15 +304,15 @@ func (l *Logic) observeReadyCondition(ctx *Context) (*kmetav1.Condition, error)
 		Type: "Ready",
 	}
 
-	// Ready when: private key uploaded, certificate synced, TLS activations synced, and no cleanup required
-	if l.ObservedState.PrivateKeyUploaded &&
-		l.ObservedState.CertificateStatus == CertificateStatusSynced &&
-		len(l.ObservedState.MissingTLSActivationData) == 0 &&
-		len(l.ObservedState.ExtraTLSActivationIDs) == 0 &&
-		len(l.ObservedState.UnusedPrivateKeyIDs) == 0 {
+	// Ready when: private key uploaded, certificate synced and TLS activations synced, regardless of cleanup
+	if l.ObservedState.isSynced() {
 		condition.Status = kmetav1.ConditionTrue
 		condition.Reason = "FastlySyncComplete"
 		condition.Message = "FastlyCertificateSync is ready and all components are synchronized"
+	} else if l.ObservedState.LocalCertificateInvalid != "" {
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "LocalCertificateInvalid"
+		condition.Message = "FastlyCertificateSync is not ready - Fastly is left as last synced until the local certificate recovers"
 	} else {
 		condition.Status = kmetav1.ConditionFalse
 		condition.Reason = "FastlySyncIncomplete"
@@ -160,3 +321,110 @@ func (l *Logic) observeReadyCondition(ctx *Context) (*kmetav1.Condition, error)
 
 	return condition, nil
 }
+
+// observeServiceDomainMissingCondition generates the condition for DNS names of the certificate that are not domains
+// of the service in spec.serviceId. It is omitted for subjects without a service, and carried over from the last
+// check when the service wasn't looked up, such as during quick drift checks.
+func (l *Logic) observeServiceDomainMissingCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || ctx.Subject.Spec.ServiceID == "" {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type: "ServiceDomainMissing",
+	}
+
+	switch {
+	case l.ObservedState.ServiceDomainsError != "":
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "ServiceLookupFailed"
+		condition.Message = l.ObservedState.ServiceDomainsError
+	case !l.ObservedState.ServiceDomainsChecked:
+		if previous := ctx.Subject.Status.GetCondition(condition.Type); previous != nil {
+			return previous, nil
+		}
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "ServiceDomainsNotObserved"
+		condition.Message = "Service domains have not been checked yet"
+	case len(l.ObservedState.MissingServiceDomains) > 0:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "DomainsNotAttached"
+		condition.Message = fmt.Sprintf("Certificate domains %s are not domains of Fastly service %s",
+			strings.Join(l.ObservedState.MissingServiceDomains, ", "), ctx.Subject.Spec.ServiceID)
+	default:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "DomainsAttached"
+		condition.Message = fmt.Sprintf("All certificate domains are domains of Fastly service %s", ctx.Subject.Spec.ServiceID)
+	}
+
+	return condition, nil
+}
+
+// observeDomainVerifiedCondition generates the condition for DNS names of the certificate that aren't verified domains
+// in Fastly. It is omitted unless domain verification is checked, or for subjects syncing to several accounts, and
+// carried over from the last check when Fastly wasn't fully observed, such as during quick drift checks. Once no TLS
+// activations are missing, unverified domains no longer hold the certificate back.
+func (l *Logic) observeDomainVerifiedCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || !ctx.Config.DomainVerificationCheck || len(ctx.Subject.Spec.Accounts) > 0 {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type: "DomainVerified",
+	}
+
+	switch {
+	case l.ObservedState.DomainVerificationError != "":
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "DomainLookupFailed"
+		condition.Message = l.ObservedState.DomainVerificationError
+	case l.ObservedState.DomainVerificationSkipped:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "TLSActivationsComplete"
+		condition.Message = "No TLS activations are missing, domain verification is only checked while some are"
+	case !l.ObservedState.DomainVerificationChecked:
+		if previous := ctx.Subject.Status.GetCondition(condition.Type); previous != nil {
+			return previous, nil
+		}
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "DomainsNotObserved"
+		condition.Message = "Domain verification has not been checked yet"
+	case len(l.ObservedState.UnverifiedDomains) > 0:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "DomainsUnverified"
+		condition.Message = fmt.Sprintf("Certificate domains %s are not verified in Fastly",
+			strings.Join(l.ObservedState.UnverifiedDomains, ", "))
+	default:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "DomainsVerified"
+		condition.Message = "All certificate domains are verified in Fastly"
+	}
+
+	return condition, nil
+}
+
+// observeSuspendedCondition generates the condition for subjects suspended in observe only mode, reporting the
+// changes that would otherwise be made. It is omitted for subjects that aren't suspended.
+func (l *Logic) observeSuspendedCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || !ctx.Subject.IsObserveOnly() {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type:   "Suspended",
+		Status: kmetav1.ConditionTrue,
+	}
+
+	if !l.SubjectReadyForReconciliation {
+		condition.Reason = "SuspendedNotObserved"
+		condition.Message = "Reconciliation is suspended, and the Certificate is not ready to be observed"
+	} else if pending := l.ObservedState.pendingMutations(); len(pending) > 0 {
+		condition.Reason = "SuspendedWithDrift"
+		condition.Message = fmt.Sprintf("Reconciliation is suspended, pending changes: %s", strings.Join(pending, ", "))
+	} else {
+		condition.Reason = "SuspendedInSync"
+		condition.Message = "Reconciliation is suspended, Fastly is in sync"
+	}
+
+	return condition, nil
+}
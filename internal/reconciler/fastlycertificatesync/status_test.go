@@ -2,6 +2,7 @@ package fastlycertificatesync
 
 import (
 	"testing"
+	"time"
 
 	"github.com/fastly-tls-operator/api/v1alpha1"
 	"github.com/fastly/go-fastly/v11/fastly"
@@ -29,7 +30,6 @@ func TestLogic_FillStatus(t *testing.T) {
 			observedState: ObservedState{
 				PrivateKeyUploaded:       false,
 				CertificateStatus:        CertificateStatusMissing,
-				UnusedPrivateKeyIDs:      []string{},
 				MissingTLSActivationData: []TLSActivationData{},
 				ExtraTLSActivationIDs:    []string{},
 			},
@@ -54,11 +54,6 @@ func TestLogic_FillStatus(t *testing.T) {
 					reason:  "TLSActivationsSynced",
 					message: "All TLS activations are properly configured",
 				},
-				"CleanupRequired": {
-					status:  metav1.ConditionFalse,
-					reason:  "NoCleanupNeeded",
-					message: "No unused private keys found",
-				},
 				"Ready": {
 					status:  metav1.ConditionFalse,
 					reason:  "FastlySyncIncomplete",
@@ -71,7 +66,6 @@ func TestLogic_FillStatus(t *testing.T) {
 			observedState: ObservedState{
 				PrivateKeyUploaded:       true,
 				CertificateStatus:        CertificateStatusMissing,
-				UnusedPrivateKeyIDs:      []string{},
 				MissingTLSActivationData: []TLSActivationData{},
 				ExtraTLSActivationIDs:    []string{},
 			},
@@ -103,7 +97,6 @@ func TestLogic_FillStatus(t *testing.T) {
 			observedState: ObservedState{
 				PrivateKeyUploaded:       true,
 				CertificateStatus:        CertificateStatusStale,
-				UnusedPrivateKeyIDs:      []string{},
 				MissingTLSActivationData: []TLSActivationData{},
 				ExtraTLSActivationIDs:    []string{},
 			},
@@ -133,9 +126,8 @@ func TestLogic_FillStatus(t *testing.T) {
 		{
 			name: "private_key_and_certificate_synced_missing_tls_activations",
 			observedState: ObservedState{
-				PrivateKeyUploaded:  true,
-				CertificateStatus:   CertificateStatusSynced,
-				UnusedPrivateKeyIDs: []string{},
+				PrivateKeyUploaded: true,
+				CertificateStatus:  CertificateStatusSynced,
 				MissingTLSActivationData: []TLSActivationData{
 					{
 						Certificate:   &fastly.CustomTLSCertificate{ID: "cert1"},
@@ -183,7 +175,6 @@ func TestLogic_FillStatus(t *testing.T) {
 			observedState: ObservedState{
 				PrivateKeyUploaded:       true,
 				CertificateStatus:        CertificateStatusSynced,
-				UnusedPrivateKeyIDs:      []string{},
 				MissingTLSActivationData: []TLSActivationData{},
 				ExtraTLSActivationIDs:    []string{"activation1", "activation2", "activation3"},
 			},
@@ -215,54 +206,11 @@ func TestLogic_FillStatus(t *testing.T) {
 				},
 			},
 		},
-		{
-			name: "everything_synced_but_unused_private_keys_need_cleanup",
-			observedState: ObservedState{
-				PrivateKeyUploaded:       true,
-				CertificateStatus:        CertificateStatusSynced,
-				UnusedPrivateKeyIDs:      []string{"key1", "key2"},
-				MissingTLSActivationData: []TLSActivationData{},
-				ExtraTLSActivationIDs:    []string{},
-			},
-			expectedReady: false,
-			expectedConditions: map[string]struct {
-				status  metav1.ConditionStatus
-				reason  string
-				message string
-			}{
-				"PrivateKeyReady": {
-					status:  metav1.ConditionTrue,
-					reason:  "PrivateKeyUploaded",
-					message: "Private key has been successfully uploaded to Fastly",
-				},
-				"CertificateReady": {
-					status:  metav1.ConditionTrue,
-					reason:  "CertificateSynced",
-					message: "Certificate is up-to-date and synced with Fastly",
-				},
-				"TLSActivationReady": {
-					status:  metav1.ConditionTrue,
-					reason:  "TLSActivationsSynced",
-					message: "All TLS activations are properly configured",
-				},
-				"CleanupRequired": {
-					status:  metav1.ConditionTrue,
-					reason:  "UnusedPrivateKeysFound",
-					message: "Found 2 unused private keys that should be cleaned up",
-				},
-				"Ready": {
-					status:  metav1.ConditionFalse,
-					reason:  "FastlySyncIncomplete",
-					message: "FastlyCertificateSync is not ready - synchronization in progress",
-				},
-			},
-		},
 		{
 			name: "fully_ready_everything_synced",
 			observedState: ObservedState{
 				PrivateKeyUploaded:       true,
 				CertificateStatus:        CertificateStatusSynced,
-				UnusedPrivateKeyIDs:      []string{},
 				MissingTLSActivationData: []TLSActivationData{},
 				ExtraTLSActivationIDs:    []string{},
 			},
@@ -287,11 +235,6 @@ func TestLogic_FillStatus(t *testing.T) {
 					reason:  "TLSActivationsSynced",
 					message: "All TLS activations are properly configured",
 				},
-				"CleanupRequired": {
-					status:  metav1.ConditionFalse,
-					reason:  "NoCleanupNeeded",
-					message: "No unused private keys found",
-				},
 				"Ready": {
 					status:  metav1.ConditionTrue,
 					reason:  "FastlySyncComplete",
@@ -302,9 +245,8 @@ func TestLogic_FillStatus(t *testing.T) {
 		{
 			name: "mixed_scenario_missing_and_extra_tls_activations",
 			observedState: ObservedState{
-				PrivateKeyUploaded:  true,
-				CertificateStatus:   CertificateStatusSynced,
-				UnusedPrivateKeyIDs: []string{},
+				PrivateKeyUploaded: true,
+				CertificateStatus:  CertificateStatusSynced,
 				MissingTLSActivationData: []TLSActivationData{
 					{
 						Certificate:   &fastly.CustomTLSCertificate{ID: "cert1"},
@@ -335,9 +277,8 @@ func TestLogic_FillStatus(t *testing.T) {
 		{
 			name: "complex_scenario_multiple_issues",
 			observedState: ObservedState{
-				PrivateKeyUploaded:  true,
-				CertificateStatus:   CertificateStatusStale,
-				UnusedPrivateKeyIDs: []string{"key1", "key2", "key3"},
+				PrivateKeyUploaded: true,
+				CertificateStatus:  CertificateStatusStale,
 				MissingTLSActivationData: []TLSActivationData{
 					{
 						Certificate:   &fastly.CustomTLSCertificate{ID: "cert1"},
@@ -368,11 +309,6 @@ func TestLogic_FillStatus(t *testing.T) {
 					reason:  "TLSActivationsMissing",
 					message: "Missing 1 TLS activations that need to be created",
 				},
-				"CleanupRequired": {
-					status:  metav1.ConditionTrue,
-					reason:  "UnusedPrivateKeysFound",
-					message: "Found 3 unused private keys that should be cleaned up",
-				},
 				"Ready": {
 					status:  metav1.ConditionFalse,
 					reason:  "FastlySyncIncomplete",
@@ -558,6 +494,93 @@ func TestLogic_ObserveConditionFunctions_Individual(t *testing.T) {
 		}
 	})
 
+	t.Run("observeCertificateSourceReadyCondition", func(t *testing.T) {
+		ctx := &Context{Log: logr.Discard()}
+
+		logic := &Logic{ObservedState: ObservedState{
+			CertificateSourceReason:  "Issuing",
+			CertificateSourceMessage: "Issuing certificate as Secret does not exist",
+		}}
+		condition, err := logic.observeCertificateSourceReadyCondition(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "CertificateSourceReady", condition.Type)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "Issuing", condition.Reason)
+		assert.Equal(t, "Issuing certificate as Secret does not exist", condition.Message)
+
+		logic = &Logic{ObservedState: ObservedState{CertificateSourceReady: true, CertificateSourceReason: "CertificateReady"}}
+		condition, err = logic.observeCertificateSourceReadyCondition(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, "CertificateReady", condition.Reason)
+
+		logic = &Logic{}
+		condition, err = logic.observeCertificateSourceReadyCondition(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "CertificateNotObserved", condition.Reason)
+	})
+
+	t.Run("observeInvalidInputCondition", func(t *testing.T) {
+		ctx := &Context{Log: logr.Discard()}
+
+		logic := &Logic{ObservedState: ObservedState{InvalidInput: "certificate of secret test/test is invalid: contains no PEM blocks"}}
+		condition, err := logic.observeInvalidInputCondition(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "InvalidInput", condition.Type)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, "InputRejected", condition.Reason)
+		assert.Equal(t, "certificate of secret test/test is invalid: contains no PEM blocks", condition.Message)
+
+		logic = &Logic{}
+		condition, err = logic.observeInvalidInputCondition(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+		assert.Equal(t, "InputNotObserved", condition.Reason)
+
+		logic = &Logic{SubjectReadyForReconciliation: true}
+		condition, err = logic.observeInvalidInputCondition(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "InputValid", condition.Reason)
+	})
+
+	t.Run("observeSuspendedCondition", func(t *testing.T) {
+		ctx := &Context{
+			Subject: &v1alpha1.FastlyCertificateSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Spec:       v1alpha1.FastlyCertificateSyncSpec{Suspend: true, SuspendMode: v1alpha1.SuspendModeObserveOnly},
+			},
+			Log: logr.Discard(),
+		}
+
+		logic := &Logic{
+			SubjectReadyForReconciliation: true,
+			ObservedState: ObservedState{
+				PrivateKeyUploaded:    true,
+				CertificateStatus:     CertificateStatusStale,
+				ExtraTLSActivationIDs: []string{"activation1"},
+			},
+		}
+		condition, err := logic.observeSuspendedCondition(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, condition)
+		assert.Equal(t, "Suspended", condition.Type)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, "SuspendedWithDrift", condition.Reason)
+		assert.Equal(t, "Reconciliation is suspended, pending changes: update certificate, delete 1 TLS activation(s)", condition.Message)
+
+		logic.ObservedState = ObservedState{PrivateKeyUploaded: true, CertificateStatus: CertificateStatusSynced}
+		condition, err = logic.observeSuspendedCondition(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "SuspendedInSync", condition.Reason)
+
+		ctx.Subject.Spec.Suspend = false
+		condition, err = logic.observeSuspendedCondition(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, condition, "condition is omitted when not suspended")
+	})
+
 	t.Run("observeCertificateReadyCondition", func(t *testing.T) {
 		ctx := &Context{
 			Subject: &v1alpha1.FastlyCertificateSync{
@@ -569,6 +592,7 @@ func TestLogic_ObserveConditionFunctions_Individual(t *testing.T) {
 		tests := []struct {
 			name                  string
 			certificateStatus     CertificateStatus
+			adoptionRequired      bool
 			expectedStatus        metav1.ConditionStatus
 			expectedReason        string
 			expectedMessageSubstr string
@@ -587,6 +611,14 @@ func TestLogic_ObserveConditionFunctions_Individual(t *testing.T) {
 				expectedReason:        "CertificateStale",
 				expectedMessageSubstr: "stale and needs to be updated",
 			},
+			{
+				name:                  "certificate_needs_adoption",
+				certificateStatus:     CertificateStatusStale,
+				adoptionRequired:      true,
+				expectedStatus:        metav1.ConditionFalse,
+				expectedReason:        "NeedsAdoption",
+				expectedMessageSubstr: "not created by the operator",
+			},
 			{
 				name:                  "certificate_missing",
 				certificateStatus:     CertificateStatusMissing,
@@ -607,7 +639,8 @@ func TestLogic_ObserveConditionFunctions_Individual(t *testing.T) {
 			t.Run(tt.name, func(t *testing.T) {
 				logic := &Logic{
 					ObservedState: ObservedState{
-						CertificateStatus: tt.certificateStatus,
+						CertificateStatus:           tt.certificateStatus,
+						CertificateAdoptionRequired: tt.adoptionRequired,
 					},
 				}
 
@@ -623,3 +656,28 @@ func TestLogic_ObserveConditionFunctions_Individual(t *testing.T) {
 		}
 	})
 }
+
+func TestLogic_FillStatus_FastlyCertificate(t *testing.T) {
+	notAfter := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cert := &fastly.CustomTLSCertificate{
+		ID:           "cert1",
+		SerialNumber: "01",
+		Issuer:       "R11",
+		NotAfter:     &notAfter,
+		Domains:      []*fastly.TLSDomain{{ID: "example.com"}, {ID: "www.example.com"}},
+	}
+
+	ctx := createTestContext()
+	logic := &Logic{ObservedState: ObservedState{FastlyCertificate: cert}, SubjectReadyForReconciliation: true}
+	require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+	expected := &v1alpha1.FastlyCertificateStatus{SerialNumber: "01", Issuer: "R11", NotAfter: &metav1.Time{Time: notAfter}, SANCount: 2}
+	assert.Equal(t, expected, ctx.Subject.Status.FastlyCertificate)
+
+	logic = &Logic{}
+	require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+	assert.Equal(t, expected, ctx.Subject.Status.FastlyCertificate, "carried over while Fastly isn't observed")
+
+	logic = &Logic{ObservedState: ObservedState{CertificateStatus: CertificateStatusMissing}, SubjectReadyForReconciliation: true}
+	require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+	assert.Nil(t, ctx.Subject.Status.FastlyCertificate, "dropped once the certificate is gone from Fastly")
+}
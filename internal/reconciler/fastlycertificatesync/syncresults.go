@@ -0,0 +1,96 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Annotations written onto the source Certificate and Secret once Fastly is fully in sync, so that other automation
+// keying off those objects can tell which certificate Fastly is serving.
+const (
+	FastlyCertificateIDAnnotation = "platform.seatgeek.io/fastly-certificate-id"
+	FastlySyncedSerialAnnotation  = "platform.seatgeek.io/fastly-synced-serial"
+	FastlySyncTimestampAnnotation = "platform.seatgeek.io/fastly-synced-at"
+	// FastlySyncedByAnnotation names the FastlyCertificateSync that wrote the sync results, as namespace/name
+	FastlySyncedByAnnotation = "platform.seatgeek.io/fastly-synced-by"
+)
+
+// The sync results and source labels are patched onto the source Certificate and Secret, in the namespace of the
+// FastlyCertificateSync
+// +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=patch
+
+// annotateSyncResults records the Fastly certificate ID and synced serial number on the source Certificate and Secret.
+// Objects that already carry the current results are left untouched, so the timestamp reflects when the serial was
+// first seen in sync rather than the latest reconciliation.
+//
+// Sources in another namespace are only granted for reading, so they are never annotated. A source shared by several
+// subjects is annotated by the first one to sync it for as long as that subject exists, rather than by each of them in
+// turn.
+func (l *Logic) annotateSyncResults(ctx *Context) error {
+	fastlyCertificate := l.ObservedState.FastlyCertificate
+	if fastlyCertificate == nil {
+		return nil
+	}
+
+	certificate, secret, err := getCertificateAndTLSSecretFromSubject(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get TLS secret from context: %w", err)
+	}
+	if certificate.Namespace != ctx.Subject.Namespace {
+		return nil
+	}
+
+	syncedBy := ctx.Subject.Namespace + "/" + ctx.Subject.Name
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, obj := range []client.Object{certificate, secret} {
+		annotations := obj.GetAnnotations()
+		if owner := annotations[FastlySyncedByAnnotation]; owner != "" && owner != syncedBy && l.syncResultsOwnerExists(ctx, owner) {
+			ctx.Log.V(1).Info("sync results are annotated by another FastlyCertificateSync, skipping", "kind", fmt.Sprintf("%T", obj), "name", obj.GetName(), "synced_by", owner)
+			continue
+		}
+		if annotations[FastlyCertificateIDAnnotation] == fastlyCertificate.ID &&
+			annotations[FastlySyncedSerialAnnotation] == fastlyCertificate.SerialNumber &&
+			annotations[FastlySyncedByAnnotation] == syncedBy {
+			continue
+		}
+
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		if annotations[FastlyCertificateIDAnnotation] != fastlyCertificate.ID ||
+			annotations[FastlySyncedSerialAnnotation] != fastlyCertificate.SerialNumber {
+			annotations[FastlySyncTimestampAnnotation] = now
+		}
+		annotations[FastlyCertificateIDAnnotation] = fastlyCertificate.ID
+		annotations[FastlySyncedSerialAnnotation] = fastlyCertificate.SerialNumber
+		annotations[FastlySyncedByAnnotation] = syncedBy
+		obj.SetAnnotations(annotations)
+
+		ctx.Log.Info("annotating sync results", "kind", fmt.Sprintf("%T", obj), "name", obj.GetName(), "fastly_certificate_id", fastlyCertificate.ID, "serial_number", fastlyCertificate.SerialNumber)
+		if err := ctx.Client.Client.Patch(ctx, obj, patch); err != nil {
+			return fmt.Errorf("failed to annotate %s/%s with sync results: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// syncResultsOwnerExists reports whether the FastlyCertificateSync that annotated sync results, as namespace/name, still
+// exists. When it can't be told, it is assumed to exist so that the annotations aren't fought over.
+func (l *Logic) syncResultsOwnerExists(ctx *Context, owner string) bool {
+	namespace, name, ok := strings.Cut(owner, "/")
+	if !ok {
+		return false
+	}
+
+	err := ctx.Client.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &v1alpha1.FastlyCertificateSync{})
+	return !apierrors.IsNotFound(err)
+}
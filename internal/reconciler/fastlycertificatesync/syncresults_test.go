@@ -0,0 +1,161 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"testing"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLogic_annotateSyncResults(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = cmv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&cmv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: "test-namespace"},
+			Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-secret",
+				Namespace:   "test-namespace",
+				Annotations: map[string]string{"cert-manager.io/certificate-name": "test-certificate"},
+			},
+			Data: map[string][]byte{"tls.crt": []byte("test-cert-data"), "tls.key": []byte("test-key-data")},
+		},
+	).Build()
+
+	ctx := createTestContext()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	t.Run("no_fastly_certificate", func(t *testing.T) {
+		logic := &Logic{}
+		require.NoError(t, logic.annotateSyncResults(ctx))
+
+		secret := &corev1.Secret{}
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-secret", Namespace: "test-namespace"}, secret))
+		assert.NotContains(t, secret.Annotations, FastlyCertificateIDAnnotation)
+	})
+
+	t.Run("annotates_certificate_and_secret", func(t *testing.T) {
+		logic := &Logic{ObservedState: ObservedState{
+			FastlyCertificate: &fastly.CustomTLSCertificate{ID: "cert-id", SerialNumber: "1234"},
+		}}
+		require.NoError(t, logic.annotateSyncResults(ctx))
+
+		certificate := &cmv1.Certificate{}
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-certificate", Namespace: "test-namespace"}, certificate))
+		assert.Equal(t, "cert-id", certificate.Annotations[FastlyCertificateIDAnnotation])
+		assert.Equal(t, "1234", certificate.Annotations[FastlySyncedSerialAnnotation])
+		assert.NotEmpty(t, certificate.Annotations[FastlySyncTimestampAnnotation])
+
+		secret := &corev1.Secret{}
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-secret", Namespace: "test-namespace"}, secret))
+		assert.Equal(t, "cert-id", secret.Annotations[FastlyCertificateIDAnnotation])
+		assert.Equal(t, "1234", secret.Annotations[FastlySyncedSerialAnnotation])
+		assert.Equal(t, "test-certificate", secret.Annotations["cert-manager.io/certificate-name"], "existing annotations are preserved")
+
+		// Already in sync, nothing is patched
+		resourceVersion := secret.ResourceVersion
+		require.NoError(t, logic.annotateSyncResults(ctx))
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-secret", Namespace: "test-namespace"}, secret))
+		assert.Equal(t, resourceVersion, secret.ResourceVersion)
+	})
+}
+
+func TestLogic_annotateSyncResults_SharedSources(t *testing.T) {
+	newClient := func(certificateNamespace, syncedBy string, objects ...client.Object) client.Client {
+		scheme := runtime.NewScheme()
+		_ = cmv1.AddToScheme(scheme)
+		_ = corev1.AddToScheme(scheme)
+		_ = v1alpha1.AddToScheme(scheme)
+		var annotations map[string]string
+		if syncedBy != "" {
+			annotations = map[string]string{FastlySyncedByAnnotation: syncedBy}
+		}
+		objects = append(objects,
+			&cmv1.Certificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-certificate", Namespace: certificateNamespace, Annotations: annotations},
+				Spec:       cmv1.CertificateSpec{SecretName: "test-secret"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: certificateNamespace, Annotations: annotations},
+			},
+		)
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	}
+
+	tests := []struct {
+		name                 string
+		certificateNamespace string
+		syncedBy             string
+		objects              []client.Object
+		expectAnnotated      bool
+	}{
+		{
+			name:                 "source in another namespace",
+			certificateNamespace: "shared",
+			expectAnnotated:      false,
+		},
+		{
+			name:                 "annotated by another existing subject",
+			certificateNamespace: "test-namespace",
+			syncedBy:             "test-namespace/other-sync",
+			objects: []client.Object{&v1alpha1.FastlyCertificateSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "other-sync", Namespace: "test-namespace"},
+			}},
+			expectAnnotated: false,
+		},
+		{
+			name:                 "annotated by a deleted subject",
+			certificateNamespace: "test-namespace",
+			syncedBy:             "test-namespace/other-sync",
+			expectAnnotated:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := newClient(tt.certificateNamespace, tt.syncedBy, tt.objects...)
+
+			ctx := createTestContext()
+			ctx.Subject.Spec.CertificateName = ""
+			ctx.Subject.Spec.CertificateRef = &v1alpha1.CertificateRef{Name: "test-certificate", Namespace: tt.certificateNamespace}
+			ctx.Client = &k8sutil.ContextClient{
+				SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+				Context:       context.Background(),
+				Namespace:     "test-namespace",
+			}
+
+			logic := &Logic{ObservedState: ObservedState{
+				FastlyCertificate: &fastly.CustomTLSCertificate{ID: "cert-id", SerialNumber: "1234"},
+			}}
+			require.NoError(t, logic.annotateSyncResults(ctx))
+
+			certificate := &cmv1.Certificate{}
+			require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-certificate", Namespace: tt.certificateNamespace}, certificate))
+			if tt.expectAnnotated {
+				assert.Equal(t, "cert-id", certificate.Annotations[FastlyCertificateIDAnnotation])
+				assert.Equal(t, "test-namespace/test-cert-sync", certificate.Annotations[FastlySyncedByAnnotation])
+			} else {
+				assert.NotContains(t, certificate.Annotations, FastlyCertificateIDAnnotation)
+			}
+		})
+	}
+}
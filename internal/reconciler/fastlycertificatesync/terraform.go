@@ -0,0 +1,76 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TerraformImport is a Fastly object that can be brought under Terraform's management with an import
+type TerraformImport struct {
+	// Address is the Terraform resource address, e.g. fastly_tls_certificate.k8s_example_com
+	Address string
+	// ID is the ID of the object in Fastly
+	ID string
+}
+
+// Block renders the import as a Terraform import block, supported since Terraform 1.5
+func (i TerraformImport) Block() string {
+	return fmt.Sprintf("import {\n  to = %s\n  id = %s\n}\n", i.Address, strconv.Quote(i.ID))
+}
+
+// Command renders the import as a terraform import command
+func (i TerraformImport) Command() string {
+	return fmt.Sprintf("terraform import %s %s\n", i.Address, i.ID)
+}
+
+// TerraformImports returns imports for the private keys, certificates and TLS activations of a backup. Resource names
+// are derived from the Fastly object names and made unique, so that the generated configuration can be used as is.
+func TerraformImports(backup *Backup) []TerraformImport {
+	var imports []TerraformImport
+	used := map[string]bool{}
+	add := func(resourceType, name, id string) {
+		address := resourceType + "." + terraformResourceName(name)
+		for n := 2; used[address]; n++ {
+			address = resourceType + "." + terraformResourceName(name) + "_" + strconv.Itoa(n)
+		}
+		used[address] = true
+		imports = append(imports, TerraformImport{Address: address, ID: id})
+	}
+
+	for _, key := range backup.PrivateKeys {
+		add("fastly_tls_private_key", key.Name, key.ID)
+	}
+	for _, cert := range backup.Certificates {
+		add("fastly_tls_certificate", cert.Name, cert.ID)
+	}
+	for _, cert := range backup.Certificates {
+		for _, activation := range cert.Activations {
+			if activation.ID == "" {
+				continue
+			}
+			add("fastly_tls_activation", cert.Name+"_"+activation.Domain+"_"+backupActivationConfigurationLabel(activation), activation.ID)
+		}
+	}
+
+	return imports
+}
+
+// terraformResourceName turns a Fastly object name into a valid Terraform identifier, replacing anything but letters,
+// digits, underscores and dashes with underscores
+func terraformResourceName(name string) string {
+	identifier := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	// identifiers must start with a letter or underscore
+	if identifier == "" || (identifier[0] >= '0' && identifier[0] <= '9') || identifier[0] == '-' {
+		identifier = "_" + identifier
+	}
+	return identifier
+}
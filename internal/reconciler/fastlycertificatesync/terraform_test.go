@@ -0,0 +1,47 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerraformImports(t *testing.T) {
+	backup := &Backup{
+		PrivateKeys: []BackupPrivateKey{{ID: "key1", Name: "k8s-example.com"}},
+		Certificates: []BackupCertificate{
+			{
+				ID:   "cert1",
+				Name: "k8s-example.com",
+				Activations: []BackupActivation{
+					{ID: "act1", Domain: "example.com", ConfigurationID: "config1", ConfigurationName: "production"},
+					{ID: "act2", Domain: "*.example.com", ConfigurationID: "config1", ConfigurationName: "production"},
+					{Domain: "www.example.com", ConfigurationID: "config1"},
+				},
+			},
+			{ID: "cert2", Name: "k8s-example+com"},
+		},
+	}
+
+	assert.Equal(t, []TerraformImport{
+		{Address: "fastly_tls_private_key.k8s-example_com", ID: "key1"},
+		{Address: "fastly_tls_certificate.k8s-example_com", ID: "cert1"},
+		{Address: "fastly_tls_certificate.k8s-example_com_2", ID: "cert2"},
+		{Address: "fastly_tls_activation.k8s-example_com_example_com_production", ID: "act1"},
+		{Address: "fastly_tls_activation.k8s-example_com___example_com_production", ID: "act2"},
+	}, TerraformImports(backup))
+}
+
+func TestTerraformImport_Render(t *testing.T) {
+	i := TerraformImport{Address: "fastly_tls_certificate.example", ID: "cert1"}
+
+	assert.Equal(t, "import {\n  to = fastly_tls_certificate.example\n  id = \"cert1\"\n}\n", i.Block())
+	assert.Equal(t, "terraform import fastly_tls_certificate.example cert1\n", i.Command())
+}
+
+func TestTerraformResourceName(t *testing.T) {
+	assert.Equal(t, "k8s-example_com", terraformResourceName("k8s-example.com"))
+	assert.Equal(t, "_1password", terraformResourceName("1password"))
+	assert.Equal(t, "_-prefixed", terraformResourceName("-prefixed"))
+	assert.Equal(t, "_", terraformResourceName(""))
+}
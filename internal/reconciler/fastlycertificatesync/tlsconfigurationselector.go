@@ -0,0 +1,107 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+)
+
+// selectFastlyTLSConfigurations discovers the TLS configurations matching spec.tlsConfigurationSelector, so that
+// configurations added to Fastly later are activated without having to list their IDs
+func (l *Logic) selectFastlyTLSConfigurations(ctx *Context) ([]string, error) {
+	selector := ctx.Subject.Spec.TLSConfigurationSelector
+	namePattern, err := regexp.Compile(selector.NamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("spec.tlsConfigurationSelector.namePattern is invalid: %w", err)
+	}
+
+	// Narrow the listing down when only bulk configurations are wanted, the API can't filter on the opposite
+	allConfigurations, err := l.listFastlyTLSConfigurations(ctx, selector.Bulk != nil && *selector.Bulk)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, configuration := range allConfigurations {
+		if tlsConfigurationMatchesSelector(configuration, selector, namePattern) {
+			res = append(res, configuration.ID)
+		}
+	}
+	slices.Sort(res)
+
+	ctx.Log.Info(fmt.Sprintf("Selected %d of %d TLS configurations", len(res), len(allConfigurations)), "config_ids", res)
+
+	return res, nil
+}
+
+// observeDefaultTLSConfiguration resolves the account's default TLS configuration for subjects that don't list or
+// select any, when the fallback is enabled. A subject selecting configurations is left alone even when none match.
+func (l *Logic) observeDefaultTLSConfiguration(ctx *Context) error {
+	if !ctx.Config.DefaultTLSConfigurationFallback ||
+		ctx.Subject.Spec.TLSConfigurationSelector != nil ||
+		len(l.tlsConfigurationIDs(ctx)) > 0 {
+		return nil
+	}
+
+	defaultID, err := l.getFastlyDefaultTLSConfigurationID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx.Log.Info("No TLS configuration IDs set, using the default TLS configuration", "config_id", defaultID)
+	l.ObservedState.DefaultTLSConfigurationID = defaultID
+	return nil
+}
+
+// getFastlyDefaultTLSConfigurationID returns the ID of the TLS configuration that Fastly marks as the account's default
+func (l *Logic) getFastlyDefaultTLSConfigurationID(ctx *Context) (string, error) {
+	configurations, err := l.listFastlyTLSConfigurations(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	for _, configuration := range configurations {
+		if configuration.Default {
+			return configuration.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no default TLS configuration found in Fastly")
+}
+
+// listFastlyTLSConfigurations lists every TLS configuration in the account, only bulk ones when filterBulk is set
+func (l *Logic) listFastlyTLSConfigurations(ctx *Context, filterBulk bool) ([]*fastly.CustomTLSConfiguration, error) {
+	var allConfigurations []*fastly.CustomTLSConfiguration
+	pageNumber := 1
+
+	for {
+		configurations, err := l.fastlyClient().ListCustomTLSConfigurations(ctx, &fastly.ListCustomTLSConfigurationsInput{
+			FilterBulk: filterBulk,
+			PageNumber: pageNumber,
+			PageSize:   defaultFastlyPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Fastly TLS configurations: %w", err)
+		}
+
+		allConfigurations = append(allConfigurations, configurations...)
+
+		// If we received fewer configurations than the page size, we've reached the end
+		if len(configurations) < defaultFastlyPageSize {
+			break
+		}
+		pageNumber++
+	}
+
+	return allConfigurations, nil
+}
+
+// tlsConfigurationMatchesSelector reports whether a TLS configuration matches every field set in the selector
+func tlsConfigurationMatchesSelector(configuration *fastly.CustomTLSConfiguration, selector *v1alpha1.TLSConfigurationSelector, namePattern *regexp.Regexp) bool {
+	if selector.Bulk != nil && configuration.Bulk != *selector.Bulk {
+		return false
+	}
+	return namePattern.MatchString(configuration.Name)
+}
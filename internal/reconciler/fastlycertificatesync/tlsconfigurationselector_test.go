@@ -0,0 +1,232 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSConfigurationMatchesSelector(t *testing.T) {
+	production := &fastly.CustomTLSConfiguration{ID: "config1", Name: "production-http3"}
+	bulk := &fastly.CustomTLSConfiguration{ID: "config2", Name: "production-bulk", Bulk: true}
+
+	tests := []struct {
+		name     string
+		selector *v1alpha1.TLSConfigurationSelector
+		expected []bool
+	}{
+		{
+			name:     "name_pattern",
+			selector: &v1alpha1.TLSConfigurationSelector{NamePattern: "^production-"},
+			expected: []bool{true, true},
+		},
+		{
+			name:     "bulk_only",
+			selector: &v1alpha1.TLSConfigurationSelector{Bulk: fastly.ToPointer(true)},
+			expected: []bool{false, true},
+		},
+		{
+			name:     "name_pattern_and_not_bulk",
+			selector: &v1alpha1.TLSConfigurationSelector{NamePattern: "^production-", Bulk: fastly.ToPointer(false)},
+			expected: []bool{true, false},
+		},
+		{
+			name:     "no_match",
+			selector: &v1alpha1.TLSConfigurationSelector{NamePattern: "^staging-"},
+			expected: []bool{false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namePattern := regexp.MustCompile(tt.selector.NamePattern)
+			assert.Equal(t, tt.expected[0], tlsConfigurationMatchesSelector(production, tt.selector, namePattern))
+			assert.Equal(t, tt.expected[1], tlsConfigurationMatchesSelector(bulk, tt.selector, namePattern))
+		})
+	}
+}
+
+func TestLogic_selectFastlyTLSConfigurations(t *testing.T) {
+	t.Run("pages_through_configurations", func(t *testing.T) {
+		ctx := createTestContext()
+		ctx.Subject.Spec.TLSConfigurationSelector = &v1alpha1.TLSConfigurationSelector{NamePattern: "-edge$"}
+
+		var inputs []*fastly.ListCustomTLSConfigurationsInput
+		logic := &Logic{FastlyClient: &MockFastlyClient{
+			ListCustomTLSConfigurationsFunc: func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+				inputs = append(inputs, input)
+				if input.PageNumber > 1 {
+					return []*fastly.CustomTLSConfiguration{{ID: "configZ", Name: "last-edge"}}, nil
+				}
+				// A full first page, with a single match
+				var res []*fastly.CustomTLSConfiguration
+				for i := range defaultFastlyPageSize {
+					res = append(res, &fastly.CustomTLSConfiguration{ID: fmt.Sprintf("config%d", i), Name: fmt.Sprintf("config-%d", i)})
+				}
+				res[3].Name = "first-edge"
+				return res, nil
+			},
+		}}
+
+		selected, err := logic.selectFastlyTLSConfigurations(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config3", "configZ"}, selected)
+		require.Len(t, inputs, 2)
+		assert.False(t, inputs[0].FilterBulk)
+	})
+
+	t.Run("filters_bulk_in_fastly", func(t *testing.T) {
+		ctx := createTestContext()
+		ctx.Subject.Spec.TLSConfigurationSelector = &v1alpha1.TLSConfigurationSelector{Bulk: fastly.ToPointer(true)}
+
+		logic := &Logic{FastlyClient: &MockFastlyClient{
+			ListCustomTLSConfigurationsFunc: func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+				assert.True(t, input.FilterBulk)
+				return []*fastly.CustomTLSConfiguration{{ID: "config1", Bulk: true}}, nil
+			},
+		}}
+
+		selected, err := logic.selectFastlyTLSConfigurations(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config1"}, selected)
+	})
+
+	t.Run("fastly_error", func(t *testing.T) {
+		ctx := createTestContext()
+		ctx.Subject.Spec.TLSConfigurationSelector = &v1alpha1.TLSConfigurationSelector{NamePattern: ".*"}
+
+		logic := &Logic{FastlyClient: &MockFastlyClient{
+			ListCustomTLSConfigurationsFunc: func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+				return nil, errors.New("fastly unavailable")
+			},
+		}}
+
+		_, err := logic.selectFastlyTLSConfigurations(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to list Fastly TLS configurations")
+	})
+}
+
+func TestLogic_tlsConfigurationIDs_Selected(t *testing.T) {
+	ctx := createTestContext()
+	ctx.Subject.Spec.TLSConfigurationIds = []string{"config1", "config2"}
+	logic := &Logic{ObservedState: ObservedState{SelectedTLSConfigurationIDs: []string{"config2", "config3"}}}
+
+	assert.Equal(t, []string{"config1", "config2", "config3"}, logic.tlsConfigurationIDs(ctx))
+	assert.Equal(t, []string{"config1", "config2"}, ctx.Subject.Spec.TLSConfigurationIds, "the spec is left untouched")
+}
+
+func TestLogic_FillStatus_SelectedTLSConfigurations(t *testing.T) {
+	t.Run("reports_discovered_configurations", func(t *testing.T) {
+		ctx := createTestContext()
+		ctx.Subject.Spec.TLSConfigurationSelector = &v1alpha1.TLSConfigurationSelector{NamePattern: ".*"}
+		logic := &Logic{ObservedState: ObservedState{
+			TLSConfigurationsSelected:   true,
+			SelectedTLSConfigurationIDs: []string{"config1"},
+		}}
+
+		require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+		assert.Equal(t, []string{"config1"}, ctx.Subject.Status.SelectedTLSConfigurationIds)
+	})
+
+	t.Run("carried_over_when_not_discovered", func(t *testing.T) {
+		ctx := createTestContext()
+		ctx.Subject.Spec.TLSConfigurationSelector = &v1alpha1.TLSConfigurationSelector{NamePattern: ".*"}
+		ctx.Subject.Status.SelectedTLSConfigurationIds = []string{"config1"}
+		logic := &Logic{ObservedState: ObservedState{QuickDriftChecked: true}}
+
+		require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+		assert.Equal(t, []string{"config1"}, ctx.Subject.Status.SelectedTLSConfigurationIds)
+	})
+
+	t.Run("cleared_without_selector", func(t *testing.T) {
+		ctx := createTestContext()
+		ctx.Subject.Status.SelectedTLSConfigurationIds = []string{"config1"}
+		logic := &Logic{}
+
+		require.NoError(t, logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+		assert.Empty(t, ctx.Subject.Status.SelectedTLSConfigurationIds)
+	})
+}
+
+func TestLogic_observeDefaultTLSConfiguration(t *testing.T) {
+	configurations := &MockFastlyClient{
+		ListCustomTLSConfigurationsFunc: func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+			return []*fastly.CustomTLSConfiguration{
+				{ID: "config1", Name: "HTTP/3 & TLS v1.3"},
+				{ID: "config2", Name: "Standard", Default: true},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		fallback   bool
+		spec       v1alpha1.FastlyCertificateSyncSpec
+		client     FastlyClientInterface
+		expectedID string
+		expectErr  string
+	}{
+		{
+			name:       "falls_back_without_configuration_ids",
+			fallback:   true,
+			client:     configurations,
+			expectedID: "config2",
+		},
+		{
+			name:   "disabled",
+			client: configurations,
+		},
+		{
+			name:     "configuration_ids_listed",
+			fallback: true,
+			spec:     v1alpha1.FastlyCertificateSyncSpec{TLSConfigurationIds: []string{"config1"}},
+			client:   configurations,
+		},
+		{
+			name:     "configurations_selected",
+			fallback: true,
+			spec:     v1alpha1.FastlyCertificateSyncSpec{TLSConfigurationSelector: &v1alpha1.TLSConfigurationSelector{NamePattern: "^none$"}},
+			client:   configurations,
+		},
+		{
+			name:     "no_default_configuration",
+			fallback: true,
+			client: &MockFastlyClient{
+				ListCustomTLSConfigurationsFunc: func(ctx context.Context, input *fastly.ListCustomTLSConfigurationsInput) ([]*fastly.CustomTLSConfiguration, error) {
+					return []*fastly.CustomTLSConfiguration{{ID: "config1"}}, nil
+				},
+			},
+			expectErr: "no default TLS configuration found in Fastly",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+			ctx.Config.DefaultTLSConfigurationFallback = tt.fallback
+			tt.spec.CertificateName = ctx.Subject.Spec.CertificateName
+			ctx.Subject.Spec = tt.spec
+			logic := &Logic{FastlyClient: tt.client}
+
+			err := logic.observeDefaultTLSConfiguration(ctx)
+			if tt.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, logic.ObservedState.DefaultTLSConfigurationID)
+			if tt.expectedID != "" {
+				assert.Equal(t, []string{tt.expectedID}, logic.tlsConfigurationIDs(ctx))
+			}
+		})
+	}
+}
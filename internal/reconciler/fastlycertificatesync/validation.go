@@ -0,0 +1,190 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// minResyncInterval is the shortest spec.resyncInterval accepted
+const minResyncInterval = time.Minute
+
+// fastlyIDPattern matches the format of Fastly object IDs, such as TLS configuration IDs
+var fastlyIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// validateCertificateSource ensures that the subject references, or templates, a valid Certificate
+func validateCertificateSource(svc *v1alpha1.FastlyCertificateSync) error {
+	var name string
+	switch {
+	case svc.Spec.CertificateRef != nil:
+		name = svc.Spec.CertificateRef.Name
+		if ns := svc.Spec.CertificateRef.Namespace; ns != "" {
+			if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+				return fmt.Errorf("spec.certificateRef.namespace %q is invalid: %s", ns, strings.Join(errs, ", "))
+			}
+		}
+	case svc.Spec.CertificateName != "":
+		name = svc.Spec.CertificateName
+	case svc.Spec.CertificateTemplate != nil:
+		// the templated Certificate is named after the subject
+		return nil
+	default:
+		return fmt.Errorf("one of spec.certificateName, spec.certificateRef or spec.certificateTemplate must be set")
+	}
+
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("certificate name %q is invalid: %s", name, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// validateTLSConfigurationIDs ensures that the TLS configuration IDs are well-formed and unique
+func validateTLSConfigurationIDs(svc *v1alpha1.FastlyCertificateSync) error {
+	return validateFastlyIDs("spec.tlsConfigurationIds", svc.Spec.TLSConfigurationIds)
+}
+
+// validateFastlyIDs ensures that the TLS configuration IDs listed at the field path are well-formed and unique
+func validateFastlyIDs(path string, ids []string) error {
+	seen := map[string]bool{}
+	for i, id := range ids {
+		if !fastlyIDPattern.MatchString(id) {
+			return fmt.Errorf("%s[%d] %q is not a valid Fastly TLS configuration ID", path, i, id)
+		}
+		if seen[id] {
+			return fmt.Errorf("%s[%d] %q is listed more than once", path, i, id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// validateTLSConfigurationSelector ensures that TLS configurations are selected by at least one attribute, in a
+// single account
+func validateTLSConfigurationSelector(svc *v1alpha1.FastlyCertificateSync) error {
+	selector := svc.Spec.TLSConfigurationSelector
+	if selector == nil {
+		return nil
+	}
+	if selector.NamePattern == "" && selector.Bulk == nil {
+		return fmt.Errorf("spec.tlsConfigurationSelector must set namePattern or bulk")
+	}
+	if _, err := regexp.Compile(selector.NamePattern); err != nil {
+		return fmt.Errorf("spec.tlsConfigurationSelector.namePattern %q is invalid: %w", selector.NamePattern, err)
+	}
+	if len(svc.Spec.Accounts) > 0 {
+		return fmt.Errorf("spec.tlsConfigurationSelector may not be set with spec.accounts")
+	}
+
+	return nil
+}
+
+// validateAccounts ensures that the accounts the certificate is synced to are uniquely named, and each list their
+// own TLS configuration IDs
+func validateAccounts(svc *v1alpha1.FastlyCertificateSync) error {
+	if len(svc.Spec.Accounts) == 0 {
+		return nil
+	}
+	if len(svc.Spec.TLSConfigurationIds) > 0 {
+		return fmt.Errorf("spec.tlsConfigurationIds may not be set with spec.accounts, list the TLS configuration IDs of each account instead")
+	}
+
+	seen := map[string]bool{}
+	for i, account := range svc.Spec.Accounts {
+		if errs := validation.IsDNS1123Label(account.Name); len(errs) > 0 {
+			return fmt.Errorf("spec.accounts[%d].name %q is invalid: %s", i, account.Name, strings.Join(errs, ", "))
+		}
+		if seen[account.Name] {
+			return fmt.Errorf("spec.accounts[%d].name %q is listed more than once", i, account.Name)
+		}
+		seen[account.Name] = true
+
+		if ref := account.TokenSecretRef; ref != nil {
+			if errs := validation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+				return fmt.Errorf("spec.accounts[%d].tokenSecretRef.name %q is invalid: %s", i, ref.Name, strings.Join(errs, ", "))
+			}
+		}
+
+		if err := validateFastlyIDs(fmt.Sprintf("spec.accounts[%d].tlsConfigurationIds", i), account.TLSConfigurationIds); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateServiceID ensures that the service the certificate's domains are checked against is well-formed, and can
+// be looked up in a single account
+func validateServiceID(svc *v1alpha1.FastlyCertificateSync) error {
+	if svc.Spec.ServiceID == "" {
+		return nil
+	}
+	if !fastlyIDPattern.MatchString(svc.Spec.ServiceID) {
+		return fmt.Errorf("spec.serviceId %q is not a valid Fastly service ID", svc.Spec.ServiceID)
+	}
+	if len(svc.Spec.Accounts) > 0 {
+		return fmt.Errorf("spec.serviceId may not be set with spec.accounts")
+	}
+
+	return nil
+}
+
+// validateDomains ensures that the domains to activate the certificate on are DNS names, or wildcards, listed once
+func validateDomains(svc *v1alpha1.FastlyCertificateSync) error {
+	seen := map[string]bool{}
+	for i, domain := range svc.Spec.Domains {
+		name := strings.ToLower(domain)
+		if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(name, "*.")); len(errs) > 0 {
+			return fmt.Errorf("spec.domains[%d] %q is invalid: %s", i, domain, strings.Join(errs, ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("spec.domains[%d] %q is listed more than once", i, domain)
+		}
+		seen[name] = true
+	}
+
+	return nil
+}
+
+// validatePrivateKeyManagement ensures that externally managed private keys are never read from the secret
+func validatePrivateKeyManagement(svc *v1alpha1.FastlyCertificateSync) error {
+	if !svc.IsPrivateKeyExternal() {
+		if svc.Spec.PrivateKeyPublicKeySHA1 != "" {
+			return fmt.Errorf("spec.privateKeyPublicKeySHA1 may only be set with spec.privateKeyManagement %s", v1alpha1.PrivateKeyManagementExternal)
+		}
+		return nil
+	}
+
+	// The certificate can't be extracted from a keystore without also decrypting its private key
+	if svc.Spec.SecretKeys != nil && svc.Spec.SecretKeys.PKCS12 != "" {
+		return fmt.Errorf("spec.secretKeys.pkcs12 may not be used with spec.privateKeyManagement %s", v1alpha1.PrivateKeyManagementExternal)
+	}
+
+	return nil
+}
+
+// validateResyncInterval ensures that the drift check interval neither disables drift checks nor hammers Fastly
+func validateResyncInterval(svc *v1alpha1.FastlyCertificateSync) error {
+	if svc.Spec.ResyncInterval == nil {
+		return nil
+	}
+	if interval := svc.Spec.ResyncInterval.Duration; interval < minResyncInterval {
+		return fmt.Errorf("spec.resyncInterval %s must be at least %s", interval, minResyncInterval)
+	}
+
+	return nil
+}
+
+// validateRollbackToSerial ensures that rollbacks are only requested where previous certificates are kept
+func validateRollbackToSerial(svc *v1alpha1.FastlyCertificateSync) error {
+	if svc.Spec.RollbackToSerial != "" && len(svc.Spec.Accounts) > 0 {
+		return fmt.Errorf("spec.rollbackToSerial may not be set with spec.accounts")
+	}
+
+	return nil
+}
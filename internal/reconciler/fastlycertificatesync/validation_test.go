@@ -0,0 +1,251 @@
+package fastlycertificatesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogic_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          v1alpha1.FastlyCertificateSyncSpec
+		expectedError string
+	}{
+		{
+			name: "valid_spec",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:     "test-certificate",
+				TLSConfigurationIds: []string{"abc123", "def456"},
+			},
+		},
+		{
+			name: "valid_spec_with_certificate_template",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateTemplate: &v1alpha1.CertificateTemplate{DNSNames: []string{"example.com"}},
+			},
+		},
+		{
+			name:          "missing_certificate",
+			spec:          v1alpha1.FastlyCertificateSyncSpec{TLSConfigurationIds: []string{"abc123"}},
+			expectedError: "one of spec.certificateName, spec.certificateRef or spec.certificateTemplate must be set",
+		},
+		{
+			name:          "invalid_certificate_name",
+			spec:          v1alpha1.FastlyCertificateSyncSpec{CertificateName: "Not_Valid"},
+			expectedError: `certificate name "Not_Valid" is invalid`,
+		},
+		{
+			name: "invalid_certificate_ref_namespace",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateRef: &v1alpha1.CertificateRef{Name: "test-certificate", Namespace: "tls.central"},
+			},
+			expectedError: `spec.certificateRef.namespace "tls.central" is invalid`,
+		},
+		{
+			name: "malformed_tls_configuration_id",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:     "test-certificate",
+				TLSConfigurationIds: []string{"abc123", " def-456"},
+			},
+			expectedError: `spec.tlsConfigurationIds[1] " def-456" is not a valid Fastly TLS configuration ID`,
+		},
+		{
+			name: "duplicate_tls_configuration_id",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:     "test-certificate",
+				TLSConfigurationIds: []string{"abc123", "abc123"},
+			},
+			expectedError: `spec.tlsConfigurationIds[1] "abc123" is listed more than once`,
+		},
+		{
+			name: "conflicting_options",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				CertificateRef:  &v1alpha1.CertificateRef{Name: "test-certificate"},
+			},
+			expectedError: "spec.certificateName and spec.certificateRef are mutually exclusive",
+		},
+		{
+			name: "external_private_key",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:         "test-certificate",
+				PrivateKeyManagement:    v1alpha1.PrivateKeyManagementExternal,
+				PrivateKeyPublicKeySHA1: "1ccf8849ae82aaab5749d5c791a221354f182a73",
+			},
+		},
+		{
+			name: "public_key_sha1_without_external_private_key",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:         "test-certificate",
+				PrivateKeyPublicKeySHA1: "1ccf8849ae82aaab5749d5c791a221354f182a73",
+			},
+			expectedError: "spec.privateKeyPublicKeySHA1 may only be set with spec.privateKeyManagement External",
+		},
+		{
+			name: "external_private_key_in_keystore",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:      "test-certificate",
+				PrivateKeyManagement: v1alpha1.PrivateKeyManagementExternal,
+				SecretKeys:           &v1alpha1.SecretKeys{PKCS12: "keystore.p12"},
+			},
+			expectedError: "spec.secretKeys.pkcs12 may not be used with spec.privateKeyManagement External",
+		},
+		{
+			name: "multiple_accounts",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				Accounts: []v1alpha1.FastlyAccount{
+					{Name: "production", TLSConfigurationIds: []string{"abc123"}},
+					{Name: "staging", TokenSecretRef: &v1alpha1.FastlyTokenSecretRef{Name: "fastly-staging"}, TLSConfigurationIds: []string{"abc123"}},
+				},
+			},
+		},
+		{
+			name: "accounts_with_tls_configuration_ids",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:     "test-certificate",
+				TLSConfigurationIds: []string{"abc123"},
+				Accounts:            []v1alpha1.FastlyAccount{{Name: "production"}},
+			},
+			expectedError: "spec.tlsConfigurationIds may not be set with spec.accounts",
+		},
+		{
+			name: "duplicate_account",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				Accounts:        []v1alpha1.FastlyAccount{{Name: "production"}, {Name: "production"}},
+			},
+			expectedError: `spec.accounts[1].name "production" is listed more than once`,
+		},
+		{
+			name: "malformed_account_tls_configuration_id",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				Accounts:        []v1alpha1.FastlyAccount{{Name: "production", TLSConfigurationIds: []string{"abc-123"}}},
+			},
+			expectedError: `spec.accounts[0].tlsConfigurationIds[0] "abc-123" is not a valid Fastly TLS configuration ID`,
+		},
+		{
+			name: "valid_tls_configuration_selector",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:          "test-certificate",
+				TLSConfigurationSelector: &v1alpha1.TLSConfigurationSelector{NamePattern: "^production-"},
+			},
+		},
+		{
+			name: "empty_tls_configuration_selector",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:          "test-certificate",
+				TLSConfigurationSelector: &v1alpha1.TLSConfigurationSelector{},
+			},
+			expectedError: "spec.tlsConfigurationSelector must set namePattern or bulk",
+		},
+		{
+			name: "invalid_tls_configuration_selector_name_pattern",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:          "test-certificate",
+				TLSConfigurationSelector: &v1alpha1.TLSConfigurationSelector{NamePattern: "production-("},
+			},
+			expectedError: `spec.tlsConfigurationSelector.namePattern "production-(" is invalid`,
+		},
+		{
+			name: "tls_configuration_selector_with_accounts",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:          "test-certificate",
+				TLSConfigurationSelector: &v1alpha1.TLSConfigurationSelector{NamePattern: "^production-"},
+				Accounts:                 []v1alpha1.FastlyAccount{{Name: "production"}},
+			},
+			expectedError: "spec.tlsConfigurationSelector may not be set with spec.accounts",
+		},
+		{
+			name: "valid_service_id",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				ServiceID:       "SU1Z0isxPaozGVKXdv0eY",
+			},
+		},
+		{
+			name: "malformed_service_id",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				ServiceID:       "service-1",
+			},
+			expectedError: `spec.serviceId "service-1" is not a valid Fastly service ID`,
+		},
+		{
+			name: "service_id_with_accounts",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				ServiceID:       "service1",
+				Accounts:        []v1alpha1.FastlyAccount{{Name: "production"}},
+			},
+			expectedError: "spec.serviceId may not be set with spec.accounts",
+		},
+		{
+			name: "valid_domains",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				Domains:         []string{"www.example.com", "*.example.org"},
+			},
+		},
+		{
+			name: "invalid_domain",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				Domains:         []string{"www.example.com", "not a domain"},
+			},
+			expectedError: `spec.domains[1] "not a domain" is invalid`,
+		},
+		{
+			name: "duplicate_domain",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				Domains:         []string{"www.example.com", "WWW.example.com"},
+			},
+			expectedError: `spec.domains[1] "WWW.example.com" is listed more than once`,
+		},
+		{
+			name: "valid_resync_interval",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				ResyncInterval:  &metav1.Duration{Duration: 15 * time.Minute},
+			},
+		},
+		{
+			name: "resync_interval_too_short",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName: "test-certificate",
+				ResyncInterval:  &metav1.Duration{Duration: 10 * time.Second},
+			},
+			expectedError: "spec.resyncInterval 10s must be at least 1m0s",
+		},
+		{
+			name: "rollback_to_serial_with_accounts",
+			spec: v1alpha1.FastlyCertificateSyncSpec{
+				CertificateName:  "test-certificate",
+				RollbackToSerial: "1234",
+				Accounts:         []v1alpha1.FastlyAccount{{Name: "production"}},
+			},
+			expectedError: "spec.rollbackToSerial may not be set with spec.accounts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Logic{}).Validate(&v1alpha1.FastlyCertificateSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cert-sync", Namespace: "test-namespace"},
+				Spec:       tt.spec,
+			})
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package fastlycertificatesync
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// observeWriterConflict notices that the certificate in Fastly was overwritten by another writer since this operator
+// last wrote it, such as an operator in another cluster syncing a certificate of the same name into the account.
+// Subjects syncing to several accounts don't track their last write, so conflicts aren't detected for them.
+func (l *Logic) observeWriterConflict(ctx *Context) {
+	if len(ctx.Subject.Spec.Accounts) > 0 || l.currentAccount != nil {
+		return
+	}
+
+	lastWrittenSerial := ctx.Subject.Status.LastWrittenSerial
+	fastlyCertificate := l.ObservedState.FastlyCertificate
+	if l.ObservedState.CertificateStatus != CertificateStatusStale || fastlyCertificate == nil || lastWrittenSerial == "" {
+		return
+	}
+
+	// A stale certificate still carrying our last write only means the local certificate was renewed
+	if fastlyCertificate.SerialNumber != lastWrittenSerial {
+		l.ObservedState.ConflictingSerialNumber = fastlyCertificate.SerialNumber
+	}
+}
+
+// holdOffConflictingWriter reports whether updating the certificate is held back, because another writer overwrote
+// our last write less than WriterConflictHoldOff ago. The subject is requeued for when the hold off ends, after which
+// the certificate is updated once more and held off again should the other writer still be active.
+func (l *Logic) holdOffConflictingWriter(ctx *Context) bool {
+	holdOff := ctx.Config.WriterConflictHoldOff
+	lastWriteTime := ctx.Subject.Status.LastWriteTime
+	if l.ObservedState.ConflictingSerialNumber == "" || holdOff <= 0 || lastWriteTime == nil {
+		return false
+	}
+
+	remaining := time.Until(lastWriteTime.Add(holdOff))
+	if remaining <= 0 {
+		return false
+	}
+
+	ctx.Log.Info("Certificate was overwritten in Fastly by another writer, holding off updates",
+		"fastly_cert_serial_number", l.ObservedState.ConflictingSerialNumber,
+		"last_written_serial_number", ctx.Subject.Status.LastWrittenSerial, "retry_after", remaining)
+	ctx.SetRequeue(remaining)
+	return true
+}
+
+// recordCertificateWrite keeps the serial number of the certificate just written to Fastly in the subject's status,
+// marking this operator as its last writer
+func (l *Logic) recordCertificateWrite(ctx *Context) error {
+	serial := l.ObservedState.WrittenSerialNumber
+	if serial == "" {
+		return nil
+	}
+
+	now := kmetav1.Now()
+	return l.patchApplyStatus(ctx, func(status *v1alpha1.FastlyCertificateSyncStatus) {
+		status.LastWrittenSerial = serial
+		status.LastWriteTime = &now
+		status.AppendSyncHistory(v1alpha1.SyncHistoryEntry{SerialNumber: serial, Time: now})
+	})
+}
+
+// observeConflictingWriterCondition generates the condition for another writer overwriting the certificate in Fastly.
+// It is omitted for subjects syncing to several accounts, and carried over from the last check when Fastly wasn't
+// fully observed, such as during quick drift checks.
+func (l *Logic) observeConflictingWriterCondition(ctx *Context) (*kmetav1.Condition, error) {
+	if ctx.Subject == nil || len(ctx.Subject.Spec.Accounts) > 0 {
+		return nil, nil
+	}
+
+	condition := &kmetav1.Condition{
+		Type: "ConflictingWriter",
+	}
+
+	switch {
+	case l.ObservedState.QuickDriftChecked:
+		if previous := ctx.Subject.Status.GetCondition(condition.Type); previous != nil {
+			return previous, nil
+		}
+		return nil, nil
+	case l.ObservedState.ConflictingSerialNumber != "":
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "CertificateOverwritten"
+		condition.Message = fmt.Sprintf("Certificate with serial number %s written to Fastly was overwritten with serial number %s by another writer",
+			ctx.Subject.Status.LastWrittenSerial, l.ObservedState.ConflictingSerialNumber)
+	default:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "NoConflictingWriter"
+		condition.Message = "No other writer has overwritten the certificate in Fastly"
+	}
+
+	return condition, nil
+}
@@ -0,0 +1,286 @@
+package fastlycertificatesync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLogic_ObserveWriterConflict(t *testing.T) {
+	tests := []struct {
+		name              string
+		status            CertificateStatus
+		fastlySerial      string
+		lastWrittenSerial string
+		accounts          []v1alpha1.FastlyAccount
+		expected          string
+	}{
+		{
+			name:              "overwritten by another writer",
+			status:            CertificateStatusStale,
+			fastlySerial:      "222",
+			lastWrittenSerial: "111",
+			expected:          "222",
+		},
+		{
+			name:              "local certificate renewed",
+			status:            CertificateStatusStale,
+			fastlySerial:      "111",
+			lastWrittenSerial: "111",
+		},
+		{
+			name:         "never written",
+			status:       CertificateStatusStale,
+			fastlySerial: "222",
+		},
+		{
+			name:              "synced",
+			status:            CertificateStatusSynced,
+			fastlySerial:      "333",
+			lastWrittenSerial: "111",
+		},
+		{
+			name:              "several accounts",
+			status:            CertificateStatusStale,
+			fastlySerial:      "222",
+			lastWrittenSerial: "111",
+			accounts:          []v1alpha1.FastlyAccount{{Name: "production"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := &Logic{
+				ObservedState: ObservedState{
+					CertificateStatus: tt.status,
+					FastlyCertificate: &fastly.CustomTLSCertificate{ID: "cert1", SerialNumber: tt.fastlySerial},
+				},
+			}
+
+			ctx := createTestContext()
+			ctx.Subject.Spec.Accounts = tt.accounts
+			ctx.Subject.Status.LastWrittenSerial = tt.lastWrittenSerial
+
+			logic.observeWriterConflict(ctx)
+			assert.Equal(t, tt.expected, logic.ObservedState.ConflictingSerialNumber)
+		})
+	}
+}
+
+func TestLogic_ApplyFastlyChanges_HoldsOffConflictingWriter(t *testing.T) {
+	tests := []struct {
+		name           string
+		lastWriteTime  time.Time
+		holdOff        time.Duration
+		expectHoldOff  bool
+		expectRequeued time.Duration
+	}{
+		{
+			name:           "within hold off",
+			lastWriteTime:  time.Now().Add(-10 * time.Minute),
+			holdOff:        time.Hour,
+			expectHoldOff:  true,
+			expectRequeued: 50 * time.Minute,
+		},
+		{
+			name:          "hold off passed",
+			lastWriteTime: time.Now().Add(-2 * time.Hour),
+			holdOff:       time.Hour,
+		},
+		{
+			name:          "hold off disabled",
+			lastWriteTime: time.Now().Add(-10 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := false
+			mockClient := &MockFastlyClient{
+				UpdateCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.UpdateCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+					updated = true
+					return nil, assert.AnError
+				},
+			}
+			logic := &Logic{
+				FastlyClient: mockClient,
+				ObservedState: ObservedState{
+					PrivateKeyUploaded:      true,
+					CertificateStatus:       CertificateStatusStale,
+					ConflictingSerialNumber: "222",
+				},
+			}
+
+			ctx := createTestContext()
+			ctx.Config.WriterConflictHoldOff = tt.holdOff
+			lastWriteTime := kmetav1.NewTime(tt.lastWriteTime)
+			ctx.Subject.Status.LastWrittenSerial = "111"
+			ctx.Subject.Status.LastWriteTime = &lastWriteTime
+
+			held := logic.holdOffConflictingWriter(ctx)
+			assert.Equal(t, tt.expectHoldOff, held)
+			if !tt.expectHoldOff {
+				assert.Nil(t, ctx.RequeueAfter)
+				return
+			}
+
+			require.NoError(t, logic.applyFastlyChanges(ctx))
+			assert.False(t, updated, "the certificate should not be updated while held off")
+			require.NotNil(t, ctx.RequeueAfter)
+			assert.InDelta(t, tt.expectRequeued.Seconds(), ctx.RequeueAfter.Seconds(), 5)
+		})
+	}
+}
+
+func TestLogic_RecordCertificateWrite(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx := createTestContext()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(ctx.Subject).
+		WithStatusSubresource(&v1alpha1.FastlyCertificateSync{}).
+		Build()
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{Client: fakeClient},
+		Context:       context.Background(),
+		Namespace:     "test-namespace",
+	}
+
+	// Nothing is recorded without a write
+	logic := &Logic{}
+	require.NoError(t, logic.recordCertificateWrite(ctx))
+
+	stored := &v1alpha1.FastlyCertificateSync{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(ctx.Subject), stored))
+	assert.Empty(t, stored.Status.LastWrittenSerial)
+	assert.Nil(t, stored.Status.LastWriteTime)
+
+	logic.ObservedState.WrittenSerialNumber = "111"
+	require.NoError(t, logic.recordCertificateWrite(ctx))
+
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(ctx.Subject), stored))
+	assert.Equal(t, "111", stored.Status.LastWrittenSerial)
+	require.NotNil(t, stored.Status.LastWriteTime)
+	assert.WithinDuration(t, time.Now(), stored.Status.LastWriteTime.Time, 5*time.Second)
+	require.Len(t, stored.Status.SyncHistory, 1)
+	assert.Equal(t, "111", stored.Status.SyncHistory[0].SerialNumber)
+
+	// Every write is kept in the history, up to its cap
+	for i := range v1alpha1.MaxSyncHistory {
+		logic.ObservedState.WrittenSerialNumber = fmt.Sprintf("2%02d", i)
+		require.NoError(t, logic.recordCertificateWrite(ctx))
+	}
+
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(ctx.Subject), stored))
+	require.Len(t, stored.Status.SyncHistory, v1alpha1.MaxSyncHistory)
+	assert.Equal(t, "200", stored.Status.SyncHistory[0].SerialNumber, "the oldest write is dropped")
+	assert.Equal(t, fmt.Sprintf("2%02d", v1alpha1.MaxSyncHistory-1), stored.Status.SyncHistory[v1alpha1.MaxSyncHistory-1].SerialNumber)
+}
+
+func TestLogic_ObserveConflictingWriterCondition(t *testing.T) {
+	logic := &Logic{ObservedState: ObservedState{ConflictingSerialNumber: "222"}}
+	ctx := createTestContext()
+	ctx.Subject.Status.LastWrittenSerial = "111"
+
+	condition, err := logic.observeConflictingWriterCondition(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, condition)
+	assert.Equal(t, kmetav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "CertificateOverwritten", condition.Reason)
+	assert.Contains(t, condition.Message, "111")
+	assert.Contains(t, condition.Message, "222")
+
+	// Quick drift checks carry the condition over
+	ctx.Subject.Status.Conditions = []kmetav1.Condition{*condition}
+	logic = &Logic{ObservedState: ObservedState{QuickDriftChecked: true}}
+	carried, err := logic.observeConflictingWriterCondition(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, condition, carried)
+
+	logic = &Logic{}
+	condition, err = logic.observeConflictingWriterCondition(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, condition)
+	assert.Equal(t, kmetav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "NoConflictingWriter", condition.Reason)
+
+	// Resources syncing to several accounts don't track their writers
+	ctx.Subject.Spec.Accounts = []v1alpha1.FastlyAccount{{Name: "production"}}
+	condition, err = logic.observeConflictingWriterCondition(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, condition)
+}
+
+func TestLogic_getUnchangedFastlyCertificate(t *testing.T) {
+	certPEM := createTestCertPEM(t, time.Now().Add(24*time.Hour))
+	observed := &fastly.CustomTLSCertificate{ID: "cert1", SerialNumber: "111"}
+
+	tests := []struct {
+		name          string
+		currentSerial string
+		getError      error
+		expectCurrent bool
+		expectError   error
+	}{
+		{
+			name:          "unchanged",
+			currentSerial: "111",
+			expectCurrent: true,
+		},
+		{
+			name:          "already updated to the local certificate",
+			currentSerial: "1",
+		},
+		{
+			name:          "overwritten by another writer",
+			currentSerial: "222",
+			expectError:   errFastlyCertificateChanged,
+		},
+		{
+			name:        "read fails",
+			getError:    assert.AnError,
+			expectError: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockFastlyClient{
+				GetCustomTLSCertificateFunc: func(ctx context.Context, input *fastly.GetCustomTLSCertificateInput) (*fastly.CustomTLSCertificate, error) {
+					assert.Equal(t, "cert1", input.ID)
+					if tt.getError != nil {
+						return nil, tt.getError
+					}
+					return &fastly.CustomTLSCertificate{ID: input.ID, SerialNumber: tt.currentSerial}, nil
+				},
+			}
+
+			current, err := (&Logic{FastlyClient: mockClient}).getUnchangedFastlyCertificate(createTestContext(), observed, certPEM)
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				assert.Nil(t, current)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectCurrent {
+				require.NotNil(t, current)
+				assert.Equal(t, "111", current.SerialNumber)
+			} else {
+				assert.Nil(t, current, "nothing is left to update")
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package fastlyconfigstoresync
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// FastlyClientFactory creates a Fastly client authenticated with the given API token
+type FastlyClientFactory func(token string) (FastlyClientInterface, error)
+
+// MutationBudget limits the write operations made against Fastly, shared with the other controllers writing to it
+type MutationBudget interface {
+	// ReserveFastlyMutation counts a write operation made on behalf of the subject, if the budget allows for it. When
+	// it does not, the returned duration indicates how long until it may.
+	ReserveFastlyMutation(subject types.NamespacedName) (bool, time.Duration)
+}
+
+// resolveFastlyClient selects the Fastly client used for the rest of this reconciliation.
+// Subjects in a namespace mapped to a dedicated token secret talk to Fastly with that token, everything else
+// uses the operator's default client.
+func (l *Logic) resolveFastlyClient(ctx *Context) error {
+	l.namespaceFastlyClient = nil
+
+	secretName, ok := ctx.Config.FastlyTokenSecretsByNamespace[ctx.Subject.Namespace]
+	if !ok {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := ctx.Client.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: ctx.Config.FastlyTokenSecretNamespace}, secret); err != nil {
+		return fmt.Errorf("failed to get Fastly token secret of name %s and namespace %s: %w", secretName, ctx.Config.FastlyTokenSecretNamespace, err)
+	}
+
+	token, ok := secret.Data[ctx.Config.FastlyTokenSecretKey]
+	if !ok || len(token) == 0 {
+		return fmt.Errorf("secret %s/%s does not contain %s", secret.Namespace, secret.Name, ctx.Config.FastlyTokenSecretKey)
+	}
+
+	client, err := l.fastlyClientForToken(string(token))
+	if err != nil {
+		return fmt.Errorf("failed to create Fastly client for namespace %s: %w", ctx.Subject.Namespace, err)
+	}
+
+	ctx.Log.V(5).Info("using namespace specific Fastly token", "token_secret", secretName)
+	l.namespaceFastlyClient = client
+
+	return nil
+}
+
+// fastlyClientForToken returns a cached client for the token, creating one if this token hasn't been seen before.
+// Rotating a token in its secret simply results in a new client being created.
+func (l *Logic) fastlyClientForToken(token string) (FastlyClientInterface, error) {
+	l.fastlyClientsMu.Lock()
+	defer l.fastlyClientsMu.Unlock()
+
+	if client, ok := l.fastlyClientsByToken[token]; ok {
+		return client, nil
+	}
+
+	if l.NewFastlyClient == nil {
+		return nil, fmt.Errorf("no Fastly client factory configured")
+	}
+
+	client, err := l.NewFastlyClient(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.fastlyClientsByToken == nil {
+		l.fastlyClientsByToken = map[string]FastlyClientInterface{}
+	}
+	l.fastlyClientsByToken[token] = client
+
+	return client, nil
+}
+
+// fastlyClient returns the Fastly client resolved for the current subject
+func (l *Logic) fastlyClient() FastlyClientInterface {
+	if l.namespaceFastlyClient != nil {
+		return l.namespaceFastlyClient
+	}
+	return l.FastlyClient
+}
+
+// reserveFastlyWrite reserves the mutation budget for a single write operation. When the budget doesn't allow for it,
+// the subject is requeued once it does, and false is returned.
+func (l *Logic) reserveFastlyWrite(ctx *Context) bool {
+	if l.MutationBudget == nil {
+		return true
+	}
+
+	allowed, retryAfter := l.MutationBudget.ReserveFastlyMutation(ctx.NamespacedName)
+	if !allowed {
+		ctx.Log.Info("Fastly mutation budget exceeded, deferring changes", "retry_after", retryAfter)
+		ctx.SetRequeue(retryAfter)
+	}
+	return allowed
+}
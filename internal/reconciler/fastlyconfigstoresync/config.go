@@ -0,0 +1,23 @@
+package fastlyconfigstoresync
+
+import "time"
+
+// RuntimeConfig contains the runtime configuration for the FastlyConfigStoreSync controller
+type RuntimeConfig struct {
+	// DriftCheckInterval is how soon a subject that is in sync is reconciled again, to notice changes made to the
+	// config store out of band. Zero leaves this to the resync period.
+	DriftCheckInterval time.Duration
+
+	// FastlyTokenSecretsByNamespace maps a subject namespace to the name of a secret holding the Fastly API token
+	// for that namespace. Namespaces without an entry use the operator's default Fastly client.
+	FastlyTokenSecretsByNamespace map[string]string
+	// FastlyTokenSecretNamespace is the namespace the per-namespace token secrets live in
+	FastlyTokenSecretNamespace string
+	// FastlyTokenSecretKey is the key within each per-namespace token secret holding the API token
+	FastlyTokenSecretKey string
+}
+
+// Config wraps the runtime configuration
+type Config struct {
+	RuntimeConfig
+}
@@ -0,0 +1,150 @@
+package fastlyconfigstoresync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+)
+
+// Limits Fastly imposes on config store items
+const (
+	maxItemKeyLength   = 256
+	maxItemValueLength = 8000
+)
+
+// FastlyClientInterface defines the Fastly API methods needed by the Logic struct
+type FastlyClientInterface interface {
+	ListConfigStores(ctx context.Context, input *fastly.ListConfigStoresInput) ([]*fastly.ConfigStore, error)
+	CreateConfigStore(ctx context.Context, input *fastly.CreateConfigStoreInput) (*fastly.ConfigStore, error)
+	ListConfigStoreItems(ctx context.Context, input *fastly.ListConfigStoreItemsInput) ([]*fastly.ConfigStoreItem, error)
+	CreateConfigStoreItem(ctx context.Context, input *fastly.CreateConfigStoreItemInput) (*fastly.ConfigStoreItem, error)
+	UpdateConfigStoreItem(ctx context.Context, input *fastly.UpdateConfigStoreItemInput) (*fastly.ConfigStoreItem, error)
+	DeleteConfigStoreItem(ctx context.Context, input *fastly.DeleteConfigStoreItemInput) error
+}
+
+// validateItems ensures that every ConfigMap entry fits within Fastly's limits for config store items
+func validateItems(data map[string]string) error {
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		if len(key) > maxItemKeyLength {
+			errs = append(errs, fmt.Errorf("key %q exceeds Fastly's limit of %d characters", key, maxItemKeyLength))
+		}
+		if len(data[key]) > maxItemValueLength {
+			errs = append(errs, fmt.Errorf("value of key %q exceeds Fastly's limit of %d characters", key, maxItemValueLength))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// getFastlyConfigStore returns the config store named in the spec, nil when it doesn't exist yet
+func (l *Logic) getFastlyConfigStore(ctx *Context) (*fastly.ConfigStore, error) {
+	stores, err := l.fastlyClient().ListConfigStores(ctx, &fastly.ListConfigStoresInput{Name: ctx.Subject.Spec.StoreName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Fastly config stores: %w", err)
+	}
+
+	for _, store := range stores {
+		if store.Name == ctx.Subject.Spec.StoreName {
+			return store, nil
+		}
+	}
+	return nil, nil
+}
+
+// getFastlyConfigStoreItemDiff compares the items of the config store against the desired ConfigMap data, returning
+// the keys of items that are missing, stale and extra
+func (l *Logic) getFastlyConfigStoreItemDiff(ctx *Context, store *fastly.ConfigStore, desired map[string]string) (missing, stale, extra []string, err error) {
+	// Fastly lists every item of a config store at once, the endpoint takes no cursor
+	items, err := l.fastlyClient().ListConfigStoreItems(ctx, &fastly.ListConfigStoreItemsInput{StoreID: store.StoreID})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list items of Fastly config store %s: %w", store.StoreID, err)
+	}
+	l.ObservedState.ItemCount = len(items)
+
+	existing := map[string]string{}
+	for _, item := range items {
+		existing[item.Key] = item.Value
+	}
+	l.ObservedState.StoreKeys = slices.Sorted(maps.Keys(existing))
+
+	for _, key := range slices.Sorted(maps.Keys(desired)) {
+		value, ok := existing[key]
+		switch {
+		case !ok:
+			missing = append(missing, key)
+		case value != desired[key]:
+			stale = append(stale, key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(existing)) {
+		if _, ok := desired[key]; !ok {
+			extra = append(extra, key)
+		}
+	}
+
+	return missing, stale, extra, nil
+}
+
+func (l *Logic) createFastlyConfigStore(ctx *Context) error {
+	store, err := l.fastlyClient().CreateConfigStore(ctx, &fastly.CreateConfigStoreInput{Name: ctx.Subject.Spec.StoreName})
+	if err != nil {
+		return fmt.Errorf("failed to create Fastly config store %s: %w", ctx.Subject.Spec.StoreName, err)
+	}
+	ctx.Log.Info("created Fastly config store", "store_id", store.StoreID, "store_name", store.Name)
+	return nil
+}
+
+// syncFastlyConfigStoreItems creates, updates and deletes items until the config store mirrors the ConfigMap. Every
+// change is attempted, so that a single rejected item doesn't hold back the others. Changes beyond the mutation budget
+// are deferred, which is reported alongside any errors.
+func (l *Logic) syncFastlyConfigStoreItems(ctx *Context) (bool, error) {
+	storeID := l.ObservedState.Store.StoreID
+	var errs []error
+
+	for _, key := range l.ObservedState.MissingItems {
+		if !l.reserveFastlyWrite(ctx) {
+			return true, errors.Join(errs...)
+		}
+		ctx.Log.Info("creating config store item", "store_id", storeID, "key", key)
+		if _, err := l.fastlyClient().CreateConfigStoreItem(ctx, &fastly.CreateConfigStoreItemInput{
+			StoreID: storeID,
+			Key:     key,
+			Value:   l.desiredItems[key],
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create config store item %s: %w", key, err))
+		}
+	}
+
+	for _, key := range l.ObservedState.StaleItems {
+		if !l.reserveFastlyWrite(ctx) {
+			return true, errors.Join(errs...)
+		}
+		ctx.Log.Info("updating config store item", "store_id", storeID, "key", key)
+		if _, err := l.fastlyClient().UpdateConfigStoreItem(ctx, &fastly.UpdateConfigStoreItemInput{
+			StoreID: storeID,
+			Key:     key,
+			Value:   l.desiredItems[key],
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update config store item %s: %w", key, err))
+		}
+	}
+
+	for _, key := range l.ObservedState.ExtraItems {
+		if !l.reserveFastlyWrite(ctx) {
+			return true, errors.Join(errs...)
+		}
+		ctx.Log.Info("deleting config store item", "store_id", storeID, "key", key)
+		if err := l.fastlyClient().DeleteConfigStoreItem(ctx, &fastly.DeleteConfigStoreItemInput{
+			StoreID: storeID,
+			Key:     key,
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete config store item %s: %w", key, err))
+		}
+	}
+
+	return false, errors.Join(errs...)
+}
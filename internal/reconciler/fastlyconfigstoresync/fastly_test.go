@@ -0,0 +1,275 @@
+package fastlyconfigstoresync
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MockFastlyClient implements FastlyClientInterface for testing, backed by an in-memory set of config stores
+type MockFastlyClient struct {
+	Stores map[string]*fastly.ConfigStore
+	Items  map[string]map[string]string
+
+	ListConfigStoresErr      error
+	ListConfigStoreItemsErr  error
+	CreateConfigStoreItemErr error
+	UpdateConfigStoreItemErr error
+	DeleteConfigStoreItemErr error
+
+	// Track method calls
+	CreateConfigStoreCalls     []string
+	CreateConfigStoreItemCalls []string
+	UpdateConfigStoreItemCalls []string
+	DeleteConfigStoreItemCalls []string
+}
+
+func NewMockFastlyClient() *MockFastlyClient {
+	return &MockFastlyClient{
+		Stores: map[string]*fastly.ConfigStore{},
+		Items:  map[string]map[string]string{},
+	}
+}
+
+// withStore adds a config store holding the given items
+func (m *MockFastlyClient) withStore(id, name string, items map[string]string) *MockFastlyClient {
+	m.Stores[id] = &fastly.ConfigStore{StoreID: id, Name: name}
+	m.Items[id] = items
+	return m
+}
+
+func (m *MockFastlyClient) ListConfigStores(ctx context.Context, input *fastly.ListConfigStoresInput) ([]*fastly.ConfigStore, error) {
+	if m.ListConfigStoresErr != nil {
+		return nil, m.ListConfigStoresErr
+	}
+	var res []*fastly.ConfigStore
+	for _, store := range m.Stores {
+		// Fastly matches the name filter loosely, the caller must match it exactly
+		if input.Name == "" || strings.Contains(store.Name, input.Name) {
+			res = append(res, store)
+		}
+	}
+	return res, nil
+}
+
+func (m *MockFastlyClient) CreateConfigStore(ctx context.Context, input *fastly.CreateConfigStoreInput) (*fastly.ConfigStore, error) {
+	m.CreateConfigStoreCalls = append(m.CreateConfigStoreCalls, input.Name)
+	id := "store-" + input.Name
+	m.withStore(id, input.Name, map[string]string{})
+	return m.Stores[id], nil
+}
+
+func (m *MockFastlyClient) ListConfigStoreItems(ctx context.Context, input *fastly.ListConfigStoreItemsInput) ([]*fastly.ConfigStoreItem, error) {
+	if m.ListConfigStoreItemsErr != nil {
+		return nil, m.ListConfigStoreItemsErr
+	}
+	var res []*fastly.ConfigStoreItem
+	for key, value := range m.Items[input.StoreID] {
+		res = append(res, &fastly.ConfigStoreItem{StoreID: input.StoreID, Key: key, Value: value})
+	}
+	slices.SortFunc(res, func(a, b *fastly.ConfigStoreItem) int { return strings.Compare(a.Key, b.Key) })
+	return res, nil
+}
+
+func (m *MockFastlyClient) CreateConfigStoreItem(ctx context.Context, input *fastly.CreateConfigStoreItemInput) (*fastly.ConfigStoreItem, error) {
+	m.CreateConfigStoreItemCalls = append(m.CreateConfigStoreItemCalls, input.Key)
+	if m.CreateConfigStoreItemErr != nil {
+		return nil, m.CreateConfigStoreItemErr
+	}
+	m.Items[input.StoreID][input.Key] = input.Value
+	return &fastly.ConfigStoreItem{StoreID: input.StoreID, Key: input.Key, Value: input.Value}, nil
+}
+
+func (m *MockFastlyClient) UpdateConfigStoreItem(ctx context.Context, input *fastly.UpdateConfigStoreItemInput) (*fastly.ConfigStoreItem, error) {
+	m.UpdateConfigStoreItemCalls = append(m.UpdateConfigStoreItemCalls, input.Key)
+	if m.UpdateConfigStoreItemErr != nil {
+		return nil, m.UpdateConfigStoreItemErr
+	}
+	m.Items[input.StoreID][input.Key] = input.Value
+	return &fastly.ConfigStoreItem{StoreID: input.StoreID, Key: input.Key, Value: input.Value}, nil
+}
+
+func (m *MockFastlyClient) DeleteConfigStoreItem(ctx context.Context, input *fastly.DeleteConfigStoreItemInput) error {
+	m.DeleteConfigStoreItemCalls = append(m.DeleteConfigStoreItemCalls, input.Key)
+	if m.DeleteConfigStoreItemErr != nil {
+		return m.DeleteConfigStoreItemErr
+	}
+	delete(m.Items[input.StoreID], input.Key)
+	return nil
+}
+
+func TestValidateItems(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string]string
+		expectedErr string
+	}{
+		{
+			name: "within_limits",
+			data: map[string]string{"key": "value", strings.Repeat("k", maxItemKeyLength): strings.Repeat("v", maxItemValueLength)},
+		},
+		{
+			name:        "key_too_long",
+			data:        map[string]string{strings.Repeat("k", maxItemKeyLength+1): "value"},
+			expectedErr: "exceeds Fastly's limit of 256 characters",
+		},
+		{
+			name:        "value_too_long",
+			data:        map[string]string{"key": strings.Repeat("v", maxItemValueLength+1)},
+			expectedErr: `value of key "key" exceeds Fastly's limit of 8000 characters`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateItems(tt.data)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestLogic_getFastlyConfigStore(t *testing.T) {
+	ctx := createTestContext()
+
+	t.Run("matches_exact_name", func(t *testing.T) {
+		client := NewMockFastlyClient().
+			withStore("store1", "test-store-staging", nil).
+			withStore("store2", "test-store", nil)
+		logic := &Logic{FastlyClient: client}
+
+		store, err := logic.getFastlyConfigStore(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, store)
+		assert.Equal(t, "store2", store.StoreID)
+	})
+
+	t.Run("missing_store", func(t *testing.T) {
+		logic := &Logic{FastlyClient: NewMockFastlyClient().withStore("store1", "test-store-staging", nil)}
+
+		store, err := logic.getFastlyConfigStore(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("fastly_error", func(t *testing.T) {
+		client := NewMockFastlyClient()
+		client.ListConfigStoresErr = errors.New("fastly unavailable")
+		logic := &Logic{FastlyClient: client}
+
+		_, err := logic.getFastlyConfigStore(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to list Fastly config stores")
+	})
+}
+
+func TestLogic_getFastlyConfigStoreItemDiff(t *testing.T) {
+	ctx := createTestContext()
+	client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{
+		"same":    "value",
+		"changed": "old",
+		"removed": "value",
+	})
+	logic := &Logic{FastlyClient: client}
+
+	missing, stale, extra, err := logic.getFastlyConfigStoreItemDiff(ctx, client.Stores["store1"], map[string]string{
+		"same":    "value",
+		"changed": "new",
+		"added":   "value",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"added"}, missing)
+	assert.Equal(t, []string{"changed"}, stale)
+	assert.Equal(t, []string{"removed"}, extra)
+	assert.Equal(t, 3, logic.ObservedState.ItemCount)
+}
+
+func TestLogic_syncFastlyConfigStoreItems(t *testing.T) {
+	ctx := createTestContext()
+
+	t.Run("creates_updates_and_deletes_items", func(t *testing.T) {
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"changed": "old", "removed": "value"})
+		logic := &Logic{
+			FastlyClient: client,
+			ObservedState: ObservedState{
+				Store:        client.Stores["store1"],
+				MissingItems: []string{"added"},
+				StaleItems:   []string{"changed"},
+				ExtraItems:   []string{"removed"},
+			},
+			desiredItems: map[string]string{"added": "value", "changed": "new"},
+		}
+
+		deferred, err := logic.syncFastlyConfigStoreItems(ctx)
+		require.NoError(t, err)
+		assert.False(t, deferred)
+		assert.Equal(t, map[string]string{"added": "value", "changed": "new"}, client.Items["store1"])
+	})
+
+	t.Run("failed_item_does_not_hold_back_others", func(t *testing.T) {
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"removed": "value"})
+		client.CreateConfigStoreItemErr = errors.New("fastly unavailable")
+		logic := &Logic{
+			FastlyClient: client,
+			ObservedState: ObservedState{
+				Store:        client.Stores["store1"],
+				MissingItems: []string{"added"},
+				ExtraItems:   []string{"removed"},
+			},
+			desiredItems: map[string]string{"added": "value"},
+		}
+
+		_, err := logic.syncFastlyConfigStoreItems(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create config store item added")
+		assert.Equal(t, []string{"removed"}, client.DeleteConfigStoreItemCalls)
+		assert.Empty(t, client.Items["store1"])
+	})
+
+	t.Run("defers_changes_beyond_mutation_budget", func(t *testing.T) {
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"removed": "value"})
+		budget := &fakeMutationBudget{remaining: 1, retryAfter: time.Minute}
+		logic := &Logic{
+			FastlyClient:   client,
+			MutationBudget: budget,
+			ObservedState: ObservedState{
+				Store:        client.Stores["store1"],
+				MissingItems: []string{"added"},
+				ExtraItems:   []string{"removed"},
+			},
+			desiredItems: map[string]string{"added": "value"},
+		}
+
+		deferred, err := logic.syncFastlyConfigStoreItems(ctx)
+		require.NoError(t, err)
+		assert.True(t, deferred)
+		assert.Equal(t, []string{"added"}, client.CreateConfigStoreItemCalls)
+		assert.Empty(t, client.DeleteConfigStoreItemCalls)
+	})
+}
+
+// fakeMutationBudget allows for a fixed number of writes
+type fakeMutationBudget struct {
+	remaining  int
+	retryAfter time.Duration
+}
+
+func (b *fakeMutationBudget) ReserveFastlyMutation(subject types.NamespacedName) (bool, time.Duration) {
+	if b.remaining == 0 {
+		return false, b.retryAfter
+	}
+	b.remaining--
+	return true, 0
+}
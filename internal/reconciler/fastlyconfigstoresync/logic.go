@@ -0,0 +1,297 @@
+package fastlyconfigstoresync
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	rm "github.com/seatgeek/k8s-reconciler-generic/pkg/resourcemanager"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// +kubebuilder:rbac:groups=platform.seatgeek.io,resources=fastlyconfigstoresyncs,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=platform.seatgeek.io,resources=fastlyconfigstoresyncs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=platform.seatgeek.io,resources=fastlyconfigstoresyncs/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+type Context = genrec.Context[*v1alpha1.FastlyConfigStoreSync, *Config]
+
+type ObservedState struct {
+	ConfigMapFound bool
+	InvalidItems   string
+	Store          *fastly.ConfigStore
+	ItemCount      int
+	StoreKeys      []string
+	MissingItems   []string
+	StaleItems     []string
+	ExtraItems     []string
+	UnmanagedItems []string
+}
+
+// isSynced reports whether the config store exists and mirrors the ConfigMap
+func (o *ObservedState) isSynced() bool {
+	return o.Store != nil &&
+		len(o.MissingItems) == 0 &&
+		len(o.StaleItems) == 0 &&
+		len(o.ExtraItems) == 0
+}
+
+type Logic struct {
+	genrec.WithoutFinalizationMixin[*v1alpha1.FastlyConfigStoreSync, *Config]
+	rm.ResourceManager[*Context]
+	Config       RuntimeConfig
+	FastlyClient FastlyClientInterface
+	// NewFastlyClient creates clients for namespaces that are mapped to their own Fastly token
+	NewFastlyClient FastlyClientFactory
+	// MutationBudget limits the writes made against Fastly, nil leaves them unlimited
+	MutationBudget MutationBudget
+	// For the following state, we make sure that:
+	// * Always reset state at the beginning of `ObserveResources`
+	// * Only set state during `ObserveResources`
+	// * Only read state during `ApplyUnmanaged`
+	ObservedState                 ObservedState
+	SubjectReadyForReconciliation bool
+
+	// desiredItems holds the ConfigMap data observed at the beginning of `ObserveResources`
+	desiredItems map[string]string
+
+	// namespaceFastlyClient is resolved at the beginning of `ObserveResources` when the subject's namespace is
+	// mapped to its own Fastly token
+	namespaceFastlyClient FastlyClientInterface
+	fastlyClientsMu       sync.Mutex
+	fastlyClientsByToken  map[string]FastlyClientInterface
+}
+
+func (l *Logic) NewSubject() *v1alpha1.FastlyConfigStoreSync {
+	return &v1alpha1.FastlyConfigStoreSync{}
+}
+
+func (l *Logic) GetConfig(nn types.NamespacedName) *Config {
+	return &Config{RuntimeConfig: l.Config}
+}
+
+func (l *Logic) FillDefaults(c *Context) error {
+	return nil
+}
+
+func (l *Logic) IsStatusEqual(a, b *v1alpha1.FastlyConfigStoreSync) bool {
+	return reflect.DeepEqual(a.Status, b.Status)
+}
+
+func (l *Logic) IsSubjectNil(subj *v1alpha1.FastlyConfigStoreSync) bool {
+	return subj == nil
+}
+
+func (l *Logic) ResourceIssues(obj client.Object) (facts []string) {
+	return
+}
+
+func (l *Logic) ExtraLabelsForObject(context *Context, tier, suffix string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/managed-by": "fastly-tls-operator",
+	}
+}
+
+func (l *Logic) ExtraAnnotationsForObject(_ *Context, _, _ string) map[string]string {
+	return nil
+}
+
+func (l *Logic) ConfigureController(cb *builder.Builder, cluster cluster.Cluster) error {
+	if err := l.RegisterOwnedTypes(cb); err != nil {
+		return err
+	}
+
+	// watch ConfigMaps - re-reconcile the FastlyConfigStoreSync resources in the same namespace that mirror them
+	cb.Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, object client.Object) []reconcile.Request {
+		res := []reconcile.Request{}
+
+		all := v1alpha1.FastlyConfigStoreSyncList{}
+		if err := cluster.GetClient().List(ctx, &all, client.InNamespace(object.GetNamespace())); err != nil {
+			ctrl.Log.Error(err, "could not list FastlyConfigStoreSync resources to reconcile while watching ConfigMaps")
+		}
+
+		for _, configStoreSync := range all.Items {
+			if configStoreSync.Spec.ConfigMapName == object.GetName() {
+				res = append(res, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      configStoreSync.GetName(),
+						Namespace: configStoreSync.GetNamespace(),
+					},
+				})
+			}
+		}
+
+		return res
+	}))
+
+	ctrl.Log.Info("Configured controller", "controller", "fastlyconfigstoresync")
+
+	return nil
+}
+
+func (l *Logic) Validate(svc *v1alpha1.FastlyConfigStoreSync) error {
+	if svc.Spec.ConfigMapName == "" {
+		return fmt.Errorf("spec.configMapName must be set")
+	}
+	if errs := validation.IsDNS1123Subdomain(svc.Spec.ConfigMapName); len(errs) > 0 {
+		return fmt.Errorf("spec.configMapName %q is invalid: %v", svc.Spec.ConfigMapName, errs)
+	}
+	if svc.Spec.StoreName == "" {
+		return fmt.Errorf("spec.storeName must be set")
+	}
+	return nil
+}
+
+func (l *Logic) ObserveResources(ctx *Context) (genrec.Resources, error) {
+	ctx.Log.Info("observing resources for FastlyConfigStoreSync", "name", ctx.Subject.Name, "namespace", ctx.Subject.Namespace)
+
+	// Always start with fresh observation state, avoid sharing data between reconciliations
+	l.SubjectReadyForReconciliation = false
+	l.ObservedState = ObservedState{}
+	l.desiredItems = nil
+
+	resources, err := l.ResourceManager.ObserveResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := ctx.Client.Client.Get(ctx, types.NamespacedName{Name: ctx.Subject.Spec.ConfigMapName, Namespace: ctx.Subject.Namespace}, configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return resources, fmt.Errorf("failed to get ConfigMap %s: %w", ctx.Subject.Spec.ConfigMapName, err)
+		}
+
+		// Requeue after 30s to allow the ConfigMap to be created
+		ctx.Log.Info("ConfigMap not found, requeueing in 30s", "config_map", ctx.Subject.Spec.ConfigMapName)
+		ctx.SetRequeue(30 * time.Second)
+		return resources, nil
+	}
+	l.ObservedState.ConfigMapFound = true
+
+	// Catch items that Fastly would reject before talking to it, so the failure is attributed to the ConfigMap
+	if err := validateItems(configMap.Data); err != nil {
+		ctx.Log.Info("ConfigMap items are invalid, skipping sync", "reason", err.Error())
+		l.ObservedState.InvalidItems = err.Error()
+		return resources, nil
+	}
+
+	l.desiredItems = configMap.Data
+	if l.desiredItems == nil {
+		l.desiredItems = map[string]string{}
+	}
+	l.SubjectReadyForReconciliation = true
+
+	// Talk to the Fastly account that the subject's namespace is routed to
+	if err := l.resolveFastlyClient(ctx); err != nil {
+		return resources, err
+	}
+
+	store, err := l.getFastlyConfigStore(ctx)
+	if err != nil {
+		return resources, err
+	}
+	l.ObservedState.Store = store
+
+	// Every item is missing from a store that doesn't exist yet
+	if store == nil {
+		l.ObservedState.MissingItems = slices.Sorted(maps.Keys(l.desiredItems))
+		return resources, nil
+	}
+
+	missing, stale, extra, err := l.getFastlyConfigStoreItemDiff(ctx, store, l.desiredItems)
+	if err != nil {
+		return resources, err
+	}
+	l.ObservedState.MissingItems = missing
+	l.ObservedState.StaleItems = stale
+
+	// Only items synced from the ConfigMap are deleted, unless the subject adopts everything in the store
+	for _, key := range extra {
+		if ctx.Subject.Spec.AdoptExistingItems || slices.Contains(ctx.Subject.Status.ManagedKeys, key) {
+			l.ObservedState.ExtraItems = append(l.ObservedState.ExtraItems, key)
+		} else {
+			l.ObservedState.UnmanagedItems = append(l.ObservedState.UnmanagedItems, key)
+		}
+	}
+	if len(l.ObservedState.UnmanagedItems) > 0 {
+		ctx.Log.Info("leaving items that were not synced from the ConfigMap in place", "keys", l.ObservedState.UnmanagedItems)
+	}
+
+	return resources, nil
+}
+
+func (l *Logic) ApplyUnmanaged(ctx *Context) error {
+	if !l.SubjectReadyForReconciliation {
+		ctx.Log.Info("Subject is not ready for reconciliation, skipping")
+		return nil
+	}
+
+	ctx.Log.Info("applying unmanaged FastlyConfigStoreSync", "name", ctx.Subject.Name, "namespace", ctx.Subject.Namespace)
+
+	if l.ObservedState.Store == nil {
+		ctx.Log.Info("Config store is missing, creating it in Fastly")
+		if !l.reserveFastlyWrite(ctx) {
+			return nil
+		}
+		if err := l.createFastlyConfigStore(ctx); err != nil {
+			return err
+		}
+
+		// Requeue immediately after altering state
+		ctx.Log.Info("Requeueing...")
+		ctx.SetRequeue(0)
+		return nil
+	}
+
+	if !l.ObservedState.isSynced() {
+		ctx.Log.Info("Config store items are out of sync, syncing them to Fastly",
+			"missing", len(l.ObservedState.MissingItems),
+			"stale", len(l.ObservedState.StaleItems),
+			"extra", len(l.ObservedState.ExtraItems))
+		deferred, err := l.syncFastlyConfigStoreItems(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to sync Fastly config store items: %w", err)
+		}
+		// The subject was already requeued for when the budget allows for the remaining changes
+		if deferred {
+			return nil
+		}
+
+		ctx.Log.Info("Requeueing...")
+		ctx.SetRequeue(0)
+		return nil
+	}
+
+	// The config store is in sync, check it for changes made out of band rather than waiting for the next resync
+	if ctx.Config.DriftCheckInterval > 0 {
+		ctx.SetRequeue(ctx.Config.DriftCheckInterval)
+	}
+
+	return nil
+}
+
+func (l *Logic) ReconcileComplete(c *Context, rs genrec.ReconciliationStatus, err error) {
+	// No metrics are exported for config store syncs
+}
+
+func (l *Logic) Finalize(ctx *Context) (genrec.FinalizationAction, error) {
+	// The config store is left in place, it may still be in use by Fastly services
+	return genrec.FinalizationCompleted, nil
+}
@@ -0,0 +1,259 @@
+package fastlyconfigstoresync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fastly-tls-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Helper to create a test context with necessary fields
+func createTestContext() *Context {
+	return &Context{
+		Subject: &v1alpha1.FastlyConfigStoreSync{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-config-store-sync",
+				Namespace: "test-namespace",
+			},
+			Spec: v1alpha1.FastlyConfigStoreSyncSpec{
+				ConfigMapName: "test-config",
+				StoreName:     "test-store",
+			},
+		},
+		Config: &Config{},
+		Log:    logr.Discard(), // Use a no-op logger for tests
+	}
+}
+
+// withObjects points the context at a fake client holding the given objects
+func withObjects(ctx *Context, objects ...client.Object) *Context {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	ctx.Client = &k8sutil.ContextClient{
+		SchemedClient: k8sutil.SchemedClient{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		},
+		Context:   context.Background(),
+		Namespace: "test-namespace",
+	}
+	return ctx
+}
+
+func testConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-config",
+			Namespace: "test-namespace",
+		},
+		Data: data,
+	}
+}
+
+func TestLogic_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        v1alpha1.FastlyConfigStoreSyncSpec
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			spec: v1alpha1.FastlyConfigStoreSyncSpec{ConfigMapName: "test-config", StoreName: "test-store"},
+		},
+		{
+			name:        "missing_config_map_name",
+			spec:        v1alpha1.FastlyConfigStoreSyncSpec{StoreName: "test-store"},
+			expectedErr: "spec.configMapName must be set",
+		},
+		{
+			name:        "invalid_config_map_name",
+			spec:        v1alpha1.FastlyConfigStoreSyncSpec{ConfigMapName: "Test_Config", StoreName: "test-store"},
+			expectedErr: `spec.configMapName "Test_Config" is invalid`,
+		},
+		{
+			name:        "missing_store_name",
+			spec:        v1alpha1.FastlyConfigStoreSyncSpec{ConfigMapName: "test-config"},
+			expectedErr: "spec.storeName must be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := &Logic{}
+			err := logic.Validate(&v1alpha1.FastlyConfigStoreSync{Spec: tt.spec})
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestLogic_ObserveResources(t *testing.T) {
+	t.Run("config_map_not_found", func(t *testing.T) {
+		ctx := withObjects(createTestContext())
+		logic := &Logic{FastlyClient: NewMockFastlyClient()}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+		assert.False(t, logic.SubjectReadyForReconciliation)
+		assert.False(t, logic.ObservedState.ConfigMapFound)
+	})
+
+	t.Run("invalid_items", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"key": string(make([]byte, maxItemValueLength+1))}))
+		logic := &Logic{FastlyClient: NewMockFastlyClient()}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+		assert.False(t, logic.SubjectReadyForReconciliation)
+		assert.True(t, logic.ObservedState.ConfigMapFound)
+		assert.Contains(t, logic.ObservedState.InvalidItems, `value of key "key" exceeds Fastly's limit`)
+	})
+
+	t.Run("store_missing", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"b": "2", "a": "1"}))
+		logic := &Logic{FastlyClient: NewMockFastlyClient()}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+		assert.True(t, logic.SubjectReadyForReconciliation)
+		assert.Nil(t, logic.ObservedState.Store)
+		assert.Equal(t, []string{"a", "b"}, logic.ObservedState.MissingItems)
+		assert.False(t, logic.ObservedState.isSynced())
+	})
+
+	t.Run("store_drifted", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"a": "1", "b": "2"}))
+		ctx.Subject.Status.ManagedKeys = []string{"b", "c"}
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"b": "3", "c": "4"})
+		logic := &Logic{FastlyClient: client}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, logic.ObservedState.MissingItems)
+		assert.Equal(t, []string{"b"}, logic.ObservedState.StaleItems)
+		assert.Equal(t, []string{"c"}, logic.ObservedState.ExtraItems)
+		assert.False(t, logic.ObservedState.isSynced())
+	})
+
+	t.Run("empty_config_map_empties_store", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(nil))
+		ctx.Subject.Status.ManagedKeys = []string{"a"}
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"a": "1"})
+		logic := &Logic{FastlyClient: client}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+		assert.True(t, logic.SubjectReadyForReconciliation)
+		assert.Equal(t, []string{"a"}, logic.ObservedState.ExtraItems)
+	})
+
+	t.Run("leaves_unmanaged_items", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"a": "1"}))
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"a": "1", "foreign": "2"})
+		logic := &Logic{FastlyClient: client}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, logic.ObservedState.ExtraItems)
+		assert.Equal(t, []string{"foreign"}, logic.ObservedState.UnmanagedItems)
+		assert.True(t, logic.ObservedState.isSynced())
+	})
+
+	t.Run("adopts_existing_items", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"a": "1"}))
+		ctx.Subject.Spec.AdoptExistingItems = true
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"a": "1", "foreign": "2"})
+		logic := &Logic{FastlyClient: client}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"foreign"}, logic.ObservedState.ExtraItems)
+		assert.Empty(t, logic.ObservedState.UnmanagedItems)
+	})
+
+	t.Run("uses_namespace_fastly_token", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"a": "1"}), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-token", Namespace: "operator"},
+			Data:       map[string][]byte{"token": []byte("team")},
+		})
+		ctx.Config.FastlyTokenSecretsByNamespace = map[string]string{"test-namespace": "team-token"}
+		ctx.Config.FastlyTokenSecretNamespace = "operator"
+		ctx.Config.FastlyTokenSecretKey = "token"
+		teamClient := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"a": "1"})
+		var tokens []string
+		logic := &Logic{
+			FastlyClient: NewMockFastlyClient(),
+			NewFastlyClient: func(token string) (FastlyClientInterface, error) {
+				tokens = append(tokens, token)
+				return teamClient, nil
+			},
+		}
+
+		// The client is created once and reused for the following reconciliations
+		for range 2 {
+			_, err := logic.ObserveResources(ctx)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"team"}, tokens)
+		assert.Equal(t, "store1", logic.ObservedState.Store.StoreID)
+	})
+}
+
+func TestLogic_ApplyUnmanaged(t *testing.T) {
+	t.Run("converges_to_config_map", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"a": "1", "b": "2"}))
+		ctx.Config.DriftCheckInterval = 15 * time.Minute
+		client := NewMockFastlyClient()
+		logic := &Logic{FastlyClient: client}
+
+		// The first pass creates the store, the second syncs its items and the third finds it in sync
+		for range 3 {
+			_, err := logic.ObserveResources(ctx)
+			require.NoError(t, err)
+			require.NoError(t, logic.ApplyUnmanaged(ctx))
+		}
+
+		assert.Equal(t, []string{"test-store"}, client.CreateConfigStoreCalls)
+		assert.Equal(t, []string{"a", "b"}, client.CreateConfigStoreItemCalls)
+		assert.Equal(t, map[string]string{"a": "1", "b": "2"}, client.Items["store-test-store"])
+		assert.True(t, logic.ObservedState.isSynced())
+	})
+
+	t.Run("skips_when_not_ready", func(t *testing.T) {
+		ctx := createTestContext()
+		client := NewMockFastlyClient()
+		logic := &Logic{FastlyClient: client}
+
+		require.NoError(t, logic.ApplyUnmanaged(ctx))
+		assert.Empty(t, client.CreateConfigStoreCalls)
+	})
+
+	t.Run("reports_failed_items", func(t *testing.T) {
+		ctx := withObjects(createTestContext(), testConfigMap(map[string]string{"a": "1"}))
+		client := NewMockFastlyClient().withStore("store1", "test-store", map[string]string{"a": "2"})
+		client.UpdateConfigStoreItemErr = assert.AnError
+		logic := &Logic{FastlyClient: client}
+
+		_, err := logic.ObserveResources(ctx)
+		require.NoError(t, err)
+
+		err = logic.ApplyUnmanaged(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to sync Fastly config store items")
+	})
+}
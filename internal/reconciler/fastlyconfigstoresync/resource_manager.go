@@ -0,0 +1,8 @@
+package fastlyconfigstoresync
+
+import (
+	rm "github.com/seatgeek/k8s-reconciler-generic/pkg/resourcemanager"
+)
+
+// ResourceManager is empty, the config store is not a Kubernetes resource and is reconciled in ApplyUnmanaged
+var ResourceManager = rm.ResourceManager[*Context]{}
@@ -0,0 +1,158 @@
+package fastlyconfigstoresync
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/seatgeek/k8s-reconciler-generic/apiobjects"
+	"github.com/seatgeek/k8s-reconciler-generic/pkg/genrec"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (l *Logic) FillStatus(ctx *Context, obs genrec.Resources, ss apiobjects.SubjectStatus) error {
+	res := &(ctx.Subject.Status)
+	res.SubjectStatus = ss
+
+	ctx.Log.Info("filling status")
+
+	if l.ObservedState.Store != nil {
+		res.StoreID = l.ObservedState.Store.StoreID
+		res.ItemCount = l.ObservedState.ItemCount
+	}
+	if l.SubjectReadyForReconciliation {
+		res.ManagedKeys = managedKeys(res.ManagedKeys, l.ObservedState.StoreKeys, l.desiredItems)
+	}
+
+	// Consider the FastlyConfigStoreSync ready when all observed state results in no actions.
+	res.Ready = l.SubjectReadyForReconciliation && l.ObservedState.isSynced()
+
+	return l.FillStatusConditions(ctx,
+		l.observeConfigMapReadyCondition,
+		l.observeStoreReadyCondition,
+		l.observeItemsSyncedCondition,
+		l.observeReadyCondition,
+	)
+}
+
+// managedKeys returns the keys of the items synced from the ConfigMap: every key of the ConfigMap, which is about to be
+// synced if it isn't already, along with previously synced keys that are still in the store and remain to be deleted
+func managedKeys(previous, storeKeys []string, desired map[string]string) []string {
+	res := slices.Collect(maps.Keys(desired))
+	for _, key := range previous {
+		if _, ok := desired[key]; !ok && slices.Contains(storeKeys, key) {
+			res = append(res, key)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+func (l *Logic) FillStatusConditions(ctx *Context, conditionGeneratorFuncs ...func(ctx *Context) (*kmetav1.Condition, error)) error {
+	ctx.Subject.Status.Conditions = []kmetav1.Condition{}
+
+	for _, fn := range conditionGeneratorFuncs {
+		cnd, err := fn(ctx)
+		if err != nil {
+			ctx.Log.Error(err, "error generating condition", "namespace", ctx.Subject.Namespace, "name", ctx.Subject.Name)
+		}
+		if cnd == nil {
+			continue
+		}
+		_ = ctx.Subject.Status.SetCondition(*cnd)
+	}
+
+	return nil
+}
+
+// observeConfigMapReadyCondition generates the condition for the ConfigMap being mirrored
+func (l *Logic) observeConfigMapReadyCondition(ctx *Context) (*kmetav1.Condition, error) {
+	condition := &kmetav1.Condition{
+		Type: "ConfigMapReady",
+	}
+
+	switch {
+	case !l.ObservedState.ConfigMapFound:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "ConfigMapNotFound"
+		condition.Message = fmt.Sprintf("ConfigMap %s does not exist", ctx.Subject.Spec.ConfigMapName)
+	case l.ObservedState.InvalidItems != "":
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "InvalidItems"
+		condition.Message = l.ObservedState.InvalidItems
+	default:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "ConfigMapFound"
+		condition.Message = "ConfigMap exists and its items are within Fastly's limits"
+	}
+
+	return condition, nil
+}
+
+// observeStoreReadyCondition generates the condition for the existence of the Fastly config store
+func (l *Logic) observeStoreReadyCondition(ctx *Context) (*kmetav1.Condition, error) {
+	condition := &kmetav1.Condition{
+		Type: "StoreReady",
+	}
+
+	switch {
+	case !l.SubjectReadyForReconciliation:
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "StoreNotObserved"
+		condition.Message = "Config store has not been observed yet"
+	case l.ObservedState.Store == nil:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "StoreMissing"
+		condition.Message = fmt.Sprintf("Config store %s is missing from Fastly and needs to be created", ctx.Subject.Spec.StoreName)
+	default:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "StoreExists"
+		condition.Message = fmt.Sprintf("Config store %s exists in Fastly", ctx.Subject.Spec.StoreName)
+	}
+
+	return condition, nil
+}
+
+// observeItemsSyncedCondition generates the condition for the items of the config store mirroring the ConfigMap
+func (l *Logic) observeItemsSyncedCondition(ctx *Context) (*kmetav1.Condition, error) {
+	condition := &kmetav1.Condition{
+		Type: "ItemsSynced",
+	}
+
+	missing, stale, extra := len(l.ObservedState.MissingItems), len(l.ObservedState.StaleItems), len(l.ObservedState.ExtraItems)
+	switch {
+	case !l.SubjectReadyForReconciliation:
+		condition.Status = kmetav1.ConditionUnknown
+		condition.Reason = "ItemsNotObserved"
+		condition.Message = "Config store items have not been observed yet"
+	case missing+stale+extra > 0:
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "ItemsDrifted"
+		condition.Message = fmt.Sprintf("%d missing, %d stale and %d extra items need to be synced", missing, stale, extra)
+	default:
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "ItemsSynced"
+		condition.Message = "Config store items mirror the ConfigMap"
+	}
+
+	return condition, nil
+}
+
+// observeReadyCondition generates the overall ready condition
+func (l *Logic) observeReadyCondition(ctx *Context) (*kmetav1.Condition, error) {
+	condition := &kmetav1.Condition{
+		Type: "Ready",
+	}
+
+	if l.SubjectReadyForReconciliation && l.ObservedState.isSynced() {
+		condition.Status = kmetav1.ConditionTrue
+		condition.Reason = "FastlySyncComplete"
+		condition.Message = "FastlyConfigStoreSync is ready and the config store mirrors the ConfigMap"
+	} else {
+		condition.Status = kmetav1.ConditionFalse
+		condition.Reason = "FastlySyncIncomplete"
+		condition.Message = "FastlyConfigStoreSync is not ready - synchronization in progress"
+	}
+
+	return condition, nil
+}
@@ -0,0 +1,98 @@
+package fastlyconfigstoresync
+
+import (
+	"testing"
+
+	"github.com/fastly/go-fastly/v11/fastly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func conditionReasons(conditions []kmetav1.Condition) map[string]string {
+	res := map[string]string{}
+	for _, condition := range conditions {
+		res[condition.Type] = condition.Reason
+	}
+	return res
+}
+
+func TestLogic_FillStatus(t *testing.T) {
+	store := &fastly.ConfigStore{StoreID: "store1", Name: "test-store"}
+
+	tests := []struct {
+		name            string
+		logic           *Logic
+		expectedReady   bool
+		expectedStoreID string
+		expectedReasons map[string]string
+	}{
+		{
+			name:  "config_map_not_found",
+			logic: &Logic{},
+			expectedReasons: map[string]string{
+				"ConfigMapReady": "ConfigMapNotFound",
+				"StoreReady":     "StoreNotObserved",
+				"ItemsSynced":    "ItemsNotObserved",
+				"Ready":          "FastlySyncIncomplete",
+			},
+		},
+		{
+			name:  "invalid_items",
+			logic: &Logic{ObservedState: ObservedState{ConfigMapFound: true, InvalidItems: "key too long"}},
+			expectedReasons: map[string]string{
+				"ConfigMapReady": "InvalidItems",
+				"StoreReady":     "StoreNotObserved",
+				"ItemsSynced":    "ItemsNotObserved",
+				"Ready":          "FastlySyncIncomplete",
+			},
+		},
+		{
+			name: "store_missing",
+			logic: &Logic{
+				SubjectReadyForReconciliation: true,
+				ObservedState:                 ObservedState{ConfigMapFound: true, MissingItems: []string{"a"}},
+			},
+			expectedReasons: map[string]string{
+				"ConfigMapReady": "ConfigMapFound",
+				"StoreReady":     "StoreMissing",
+				"ItemsSynced":    "ItemsDrifted",
+				"Ready":          "FastlySyncIncomplete",
+			},
+		},
+		{
+			name: "synced",
+			logic: &Logic{
+				SubjectReadyForReconciliation: true,
+				ObservedState:                 ObservedState{ConfigMapFound: true, Store: store, ItemCount: 2},
+			},
+			expectedReady:   true,
+			expectedStoreID: "store1",
+			expectedReasons: map[string]string{
+				"ConfigMapReady": "ConfigMapFound",
+				"StoreReady":     "StoreExists",
+				"ItemsSynced":    "ItemsSynced",
+				"Ready":          "FastlySyncComplete",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := createTestContext()
+
+			require.NoError(t, tt.logic.FillStatus(ctx, nil, ctx.Subject.Status.SubjectStatus))
+
+			status := ctx.Subject.Status
+			assert.Equal(t, tt.expectedReady, status.Ready)
+			assert.Equal(t, tt.expectedStoreID, status.StoreID)
+			assert.Equal(t, tt.expectedReasons, conditionReasons(status.Conditions))
+		})
+	}
+}
+
+func TestManagedKeys(t *testing.T) {
+	// Keys synced before are kept until they're gone from the store, keys that never were are not picked up
+	res := managedKeys([]string{"a", "removed", "deleted"}, []string{"a", "foreign", "removed"}, map[string]string{"a": "1", "b": "2"})
+	assert.Equal(t, []string{"a", "b", "removed"}, res)
+}
@@ -0,0 +1,88 @@
+// Package version describes the build of the operator binary.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// goFastlyModule is the module path of the Fastly API client the operator is built against
+const goFastlyModule = "github.com/fastly/go-fastly/v11"
+
+// Version and Commit are set at build time, e.g.
+// -ldflags "-X github.com/fastly-tls-operator/internal/version.Version=v1.2.3". When Commit isn't set, the VCS
+// revision recorded by the Go toolchain is used instead.
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+var buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "fastly_tls_operator_build_info",
+	Help: "Always 1, labeled by the version and commit of the operator and the go-fastly version it is built against",
+}, []string{"version", "commit", "go_fastly_version"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(buildInfo)
+	info := Get()
+	buildInfo.WithLabelValues(info.Version, info.Commit, info.GoFastlyVersion).Set(1)
+}
+
+// Info is the build of the running binary
+type Info struct {
+	Version         string `json:"version"`
+	Commit          string `json:"commit"`
+	GoFastlyVersion string `json:"goFastlyVersion"`
+	GoVersion       string `json:"goVersion"`
+}
+
+// Get returns the build of the running binary. Anything that isn't known is reported as "unknown".
+func Get() Info {
+	build, _ := debug.ReadBuildInfo()
+	return fromBuildInfo(build)
+}
+
+func fromBuildInfo(build *debug.BuildInfo) Info {
+	info := Info{
+		Version:         Version,
+		Commit:          Commit,
+		GoFastlyVersion: "unknown",
+		GoVersion:       runtime.Version(),
+	}
+	if build == nil {
+		if info.Commit == "" {
+			info.Commit = "unknown"
+		}
+		return info
+	}
+
+	for _, dep := range build.Deps {
+		if dep.Path != goFastlyModule {
+			continue
+		}
+		info.GoFastlyVersion = dep.Version
+		if dep.Replace != nil {
+			info.GoFastlyVersion = dep.Replace.Version
+		}
+	}
+	if info.Commit == "" {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
+
+// String formats the build for humans, e.g. "v1.2.3 (commit abc123, go-fastly v11.0.0, go1.24.0)"
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, go-fastly %s, %s)", i.Version, i.Commit, i.GoFastlyVersion, i.GoVersion)
+}
@@ -0,0 +1,48 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	t.Run("no_build_info", func(t *testing.T) {
+		info := fromBuildInfo(nil)
+		assert.Equal(t, "dev", info.Version)
+		assert.Equal(t, "unknown", info.Commit)
+		assert.Equal(t, "unknown", info.GoFastlyVersion)
+	})
+
+	t.Run("dependency_and_vcs_revision", func(t *testing.T) {
+		info := fromBuildInfo(&debug.BuildInfo{
+			Deps: []*debug.Module{
+				{Path: "github.com/prometheus/client_golang", Version: "v1.20.0"},
+				{Path: goFastlyModule, Version: "v11.0.0"},
+			},
+			Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}},
+		})
+		assert.Equal(t, "abc123", info.Commit)
+		assert.Equal(t, "v11.0.0", info.GoFastlyVersion)
+	})
+
+	t.Run("replaced_dependency", func(t *testing.T) {
+		info := fromBuildInfo(&debug.BuildInfo{
+			Deps: []*debug.Module{
+				{Path: goFastlyModule, Version: "v11.0.0", Replace: &debug.Module{Version: "v11.0.1-fork"}},
+			},
+		})
+		assert.Equal(t, "v11.0.1-fork", info.GoFastlyVersion)
+	})
+
+	t.Run("commit_set_at_build_time", func(t *testing.T) {
+		Commit = "def456"
+		defer func() { Commit = "" }()
+
+		info := fromBuildInfo(&debug.BuildInfo{
+			Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}},
+		})
+		assert.Equal(t, "def456", info.Commit, "the commit set with -ldflags wins over the VCS revision")
+	})
+}